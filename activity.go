@@ -0,0 +1,206 @@
+package empire
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/headerutil"
+	"github.com/remind101/empire/pkg/timex"
+)
+
+// The types of activity in the activity feed of an app. Releases have the
+// type of their source (deploy, rollback or config), or ActivityRelease if it
+// isn't known.
+const (
+	ActivityRelease = "release"
+	ActivityScale   = "scale"
+	ActivityRestart = "restart"
+	ActivityCrash   = "crash"
+)
+
+// DefaultActivityFeedMax is the number of activities that are returned from
+// the activity feed when the query doesn't have a Max.
+const DefaultActivityFeedMax = 50
+
+// Activity is something that happened to an app: a release, a scale, a
+// restart, or a crash. Scales and restarts are stored as activities. Releases
+// and crashes are stored as releases and task transitions, and are turned into
+// activities by the activity feed.
+type Activity struct {
+	// A unique uuid that identifies the activity. Empty for releases and
+	// crashes.
+	ID string
+
+	// The id of the app that the activity happened to.
+	AppID string
+
+	// What happened (e.g. scale).
+	Type string
+
+	// The name of the user that did it, if it was done by a user.
+	CreatedBy string
+
+	// A human readable description of what happened.
+	Description string
+
+	// The version of the release, or of the release that the crashed
+	// process was running.
+	Release int
+
+	// The time that it happened.
+	CreatedAt *time.Time
+}
+
+// BeforeCreate sets created_at before inserting.
+func (a *Activity) BeforeCreate() error {
+	if a.CreatedAt == nil {
+		t := timex.Now()
+		a.CreatedAt = &t
+	}
+	return nil
+}
+
+func activitiesCreate(db *gorm.DB, a *Activity) (*Activity, error) {
+	return a, db.Create(a).Error
+}
+
+// ActivityFeedQuery scopes the activity feed of an app.
+type ActivityFeedQuery struct {
+	// The app to return the activity feed of.
+	App *App
+
+	// If provided, only returns activities after the given time.
+	Since *time.Time
+
+	// If provided, only returns activities before the given time, which
+	// can be used to page through the feed, with the time of the last
+	// activity that was returned.
+	Until *time.Time
+
+	// If provided, the Max of the range is the most activities that are
+	// returned. The activities are always sorted by time, most recent
+	// first.
+	Range headerutil.Range
+}
+
+// DefaultRange returns the default headerutil.Range used if values aren't
+// provided.
+func (q ActivityFeedQuery) DefaultRange() headerutil.Range {
+	max := DefaultActivityFeedMax
+	return headerutil.Range{Max: &max}
+}
+
+// activityFeed returns the releases, scales, restarts and crashes of the app,
+// most recent first.
+func activityFeed(db *gorm.DB, q ActivityFeedQuery) ([]*Activity, error) {
+	max := *q.Range.WithDefaults(q.DefaultRange()).Max
+	desc := "desc"
+	rangeBy := func(field string) headerutil.Range {
+		return headerutil.Range{Max: &max, Sort: &field, Order: &desc}
+	}
+	between := createdBetween(q.Since, q.Until)
+
+	var releases []*Release
+	if err := find(db, composedScope{
+		ReleasesQuery{App: q.App, Range: rangeBy("created_at")},
+		between("created_at"),
+	}, &releases); err != nil {
+		return nil, err
+	}
+
+	var activities []*Activity
+	if err := find(db, composedScope{
+		forApp(q.App),
+		between("created_at"),
+		inRange(rangeBy("created_at")),
+	}, &activities); err != nil {
+		return nil, err
+	}
+
+	crashed := TransitionCrashed
+	crashes, err := taskTransitions(db, TaskTransitionsQuery{
+		App:   q.App,
+		State: &crashed,
+		Since: q.Since,
+		Until: q.Until,
+		Range: rangeBy("occurred_at"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range releases {
+		activities = append(activities, releaseActivity(r))
+	}
+	for _, t := range crashes {
+		activities = append(activities, crashActivity(t))
+	}
+
+	sortActivities(activities)
+	if len(activities) > max {
+		activities = activities[:max]
+	}
+	return activities, nil
+}
+
+// createdBetween returns a function that returns a scope that finds records
+// where field is after since, and before until, when they're provided.
+func createdBetween(since, until *time.Time) func(field string) scope {
+	return func(field string) scope {
+		var scope composedScope
+		if since != nil {
+			t := *since
+			scope = append(scope, scopeFunc(func(db *gorm.DB) *gorm.DB {
+				return db.Where(fmt.Sprintf("%s > ?", field), t)
+			}))
+		}
+		if until != nil {
+			t := *until
+			scope = append(scope, scopeFunc(func(db *gorm.DB) *gorm.DB {
+				return db.Where(fmt.Sprintf("%s < ?", field), t)
+			}))
+		}
+		return scope
+	}
+}
+
+func releaseActivity(r *Release) *Activity {
+	t := r.Source
+	if t == "" {
+		t = ActivityRelease
+	}
+	return &Activity{
+		AppID:       r.AppID,
+		Type:        t,
+		CreatedBy:   r.CreatedBy,
+		Description: r.Description,
+		Release:     r.Version,
+		CreatedAt:   r.CreatedAt,
+	}
+}
+
+func crashActivity(t *TaskTransition) *Activity {
+	return &Activity{
+		AppID:       t.AppID,
+		Type:        ActivityCrash,
+		Description: fmt.Sprintf("%s crashed", t.PID),
+		Release:     t.Release,
+		CreatedAt:   t.OccurredAt,
+	}
+}
+
+// sortActivities sorts the activities by time, most recent first.
+func sortActivities(activities []*Activity) {
+	sort.SliceStable(activities, func(i, j int) bool {
+		return activityTime(activities[i]).After(activityTime(activities[j]))
+	})
+}
+
+func activityTime(a *Activity) time.Time {
+	if a.CreatedAt == nil {
+		return time.Time{}
+	}
+	return *a.CreatedAt
+}
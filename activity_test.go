@@ -0,0 +1,37 @@
+package empire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortActivities(t *testing.T) {
+	at := func(minutes int) *time.Time {
+		t := time.Date(2016, 6, 13, 3, minutes, 0, 0, time.UTC)
+		return &t
+	}
+
+	deploy := releaseActivity(&Release{
+		Version:     2,
+		Description: "Deploy remind101/acme-inc:latest",
+		CreatedBy:   "ejholmes",
+		Source:      ReleaseSourceDeploy,
+		CreatedAt:   at(1),
+	})
+	release := releaseActivity(&Release{Version: 1, CreatedAt: at(0)})
+	crash := crashActivity(&TaskTransition{PID: "v2.web.1", Release: 2, State: TransitionCrashed, OccurredAt: at(3)})
+	scale := &Activity{Type: ActivityScale, CreatedBy: "ejholmes", Release: 2, CreatedAt: at(2)}
+
+	activities := []*Activity{release, scale, deploy, crash}
+	sortActivities(activities)
+	assert.Equal(t, []*Activity{crash, scale, deploy, release}, activities)
+
+	assert.Equal(t, ReleaseSourceDeploy, deploy.Type)
+	assert.Equal(t, "ejholmes", deploy.CreatedBy)
+	assert.Equal(t, 2, deploy.Release)
+	assert.Equal(t, ActivityRelease, release.Type)
+	assert.Equal(t, ActivityCrash, crash.Type)
+	assert.Equal(t, "v2.web.1 crashed", crash.Description)
+}
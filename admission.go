@@ -0,0 +1,105 @@
+package empire
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// Operations that are evaluated by the AdmissionController.
+const (
+	AdmissionDeploy   = "deploy"
+	AdmissionRollback = "rollback"
+	AdmissionConfig   = "config"
+	AdmissionScale    = "scale"
+)
+
+// AdmissionRequest describes a change that is about to be submitted to the
+// scheduler.
+type AdmissionRequest struct {
+	// The operation that is creating, or updating, the release.
+	Operation string
+
+	// The user performing the operation.
+	User *User
+
+	// The app that the release belongs to.
+	App *App
+
+	// The release that will be submitted to the scheduler. For scale
+	// operations, the Formation will contain the new quantities and
+	// constraints.
+	Release *Release
+
+	// The environment that this Empire instance manages.
+	Environment string
+
+	// If provided, the reason the user gave for releasing during a freeze
+	// window.
+	FreezeOverride string
+
+	// True when the release is being simulated, and won't be submitted to
+	// the scheduler.
+	Simulate bool
+}
+
+// AdmissionController is evaluated before a release is submitted to the
+// scheduler, and can reject it by returning an error. This allows operators to
+// enforce rules like "no :latest tags" or "max 100 instances per app".
+type AdmissionController interface {
+	Admit(context.Context, *AdmissionRequest) error
+}
+
+// AdmissionControllerFunc is a function that implements the AdmissionController
+// interface.
+type AdmissionControllerFunc func(context.Context, *AdmissionRequest) error
+
+func (fn AdmissionControllerFunc) Admit(ctx context.Context, req *AdmissionRequest) error {
+	return fn(ctx, req)
+}
+
+// AdmissionDeniedError is returned when an AdmissionController rejects a
+// release.
+type AdmissionDeniedError struct {
+	// The reasons why the release was rejected.
+	Reasons []string
+}
+
+func (e *AdmissionDeniedError) Error() string {
+	return fmt.Sprintf("denied by policy: %s", strings.Join(e.Reasons, ", "))
+}
+
+// admit checks the request against any active freeze windows, quotas, the
+// security policy and the service mesh, then evaluates it against the
+// configured AdmissionController.
+func (e *Empire) admit(ctx context.Context, req *AdmissionRequest) error {
+	req.App = req.Release.App
+	req.Environment = e.Environment
+
+	if err := req.Release.Formation.IsValid(); err != nil {
+		return &ValidationError{Err: err}
+	}
+
+	if err := e.checkFreeze(ctx, req); err != nil {
+		return err
+	}
+
+	if err := e.checkQuota(ctx, req); err != nil {
+		return err
+	}
+
+	if err := e.checkSecurity(ctx, req); err != nil {
+		return err
+	}
+
+	if err := e.checkMesh(ctx, req); err != nil {
+		return err
+	}
+
+	if e.AdmissionController == nil {
+		return nil
+	}
+
+	return e.AdmissionController.Admit(ctx, req)
+}
@@ -0,0 +1,195 @@
+// Package opa provides an empire.AdmissionController implementation that
+// evaluates admission requests against policies loaded into an Open Policy
+// Agent server.
+//
+// The policy document is queried using OPA's Data API, with the admission
+// request provided as the input document. Policies should define a `deny` set
+// of messages; if the set is non-empty, the release is rejected. For example:
+//
+//	package empire.admission
+//
+//	deny[msg] {
+//		input.release.image.tag == "latest"
+//		msg := "images must not use the :latest tag"
+//	}
+package opa
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/remind101/empire"
+	"golang.org/x/net/context"
+)
+
+// DefaultPath is the default path to the policy document.
+const DefaultPath = "empire/admission"
+
+// ErrUndefined is returned when the policy document does not exist in OPA. We
+// fail closed in this case, since it usually indicates that the policy was not
+// loaded.
+var ErrUndefined = errors.New("opa: policy document is undefined")
+
+// AdmissionController is an empire.AdmissionController backed by OPA.
+type AdmissionController struct {
+	// The base url of the OPA server (e.g. http://localhost:8181).
+	URL string
+
+	// The path to the policy document. The zero value is DefaultPath.
+	Path string
+
+	client *http.Client
+}
+
+// NewAdmissionController returns a new AdmissionController that will query the
+// OPA server at the given url.
+func NewAdmissionController(url string) *AdmissionController {
+	return &AdmissionController{
+		URL:    url,
+		client: http.DefaultClient,
+	}
+}
+
+// Admit implements the empire.AdmissionController interface.
+func (c *AdmissionController) Admit(ctx context.Context, req *empire.AdmissionRequest) error {
+	raw, err := json.Marshal(map[string]interface{}{
+		"input": newInput(req),
+	})
+	if err != nil {
+		return err
+	}
+
+	path := c.Path
+	if path == "" {
+		path = DefaultPath
+	}
+	url := fmt.Sprintf("%s/v1/data/%s", strings.TrimSuffix(c.URL, "/"), strings.Trim(path, "/"))
+
+	httpReq, err := http.NewRequest("POST", url, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("opa: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("opa: unexpected response status %d", resp.StatusCode)
+	}
+
+	var res response
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("opa: error decoding response: %v", err)
+	}
+
+	if res.Result == nil {
+		return ErrUndefined
+	}
+
+	if len(res.Result.Deny) > 0 {
+		return &empire.AdmissionDeniedError{Reasons: res.Result.Deny}
+	}
+
+	return nil
+}
+
+// response represents the response from the OPA Data API.
+type response struct {
+	Result *struct {
+		Deny []string `json:"deny"`
+	} `json:"result"`
+}
+
+// input is the input document that policies are evaluated against.
+type input struct {
+	Operation   string   `json:"operation"`
+	Environment string   `json:"environment"`
+	User        *user    `json:"user"`
+	App         *app     `json:"app"`
+	Release     *release `json:"release"`
+}
+
+type user struct {
+	Name string `json:"name"`
+}
+
+type app struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Team string `json:"team"`
+}
+
+type release struct {
+	Version   int                 `json:"version"`
+	Image     *imageInput         `json:"image"`
+	Processes map[string]*process `json:"processes"`
+	Instances int                 `json:"instances"`
+}
+
+type imageInput struct {
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	Digest     string `json:"digest"`
+}
+
+type process struct {
+	Command  []string `json:"command"`
+	Quantity int      `json:"quantity"`
+	Memory   uint     `json:"memory"`
+	CPUShare uint     `json:"cpu_share"`
+}
+
+// newInput builds the input document for the admission request.
+func newInput(req *empire.AdmissionRequest) *input {
+	i := &input{
+		Operation:   req.Operation,
+		Environment: req.Environment,
+	}
+
+	if u := req.User; u != nil {
+		i.User = &user{Name: u.Name}
+	}
+
+	if a := req.App; a != nil {
+		i.App = &app{ID: a.ID, Name: a.Name, Team: a.Team}
+	}
+
+	if r := req.Release; r != nil {
+		i.Release = &release{
+			Version:   r.Version,
+			Processes: make(map[string]*process),
+		}
+
+		if r.Slug != nil {
+			i.Release.Image = &imageInput{
+				Registry:   r.Slug.Image.Registry,
+				Repository: r.Slug.Image.Repository,
+				Tag:        r.Slug.Image.Tag,
+				Digest:     r.Slug.Image.Digest,
+			}
+		}
+
+		for name, p := range r.Formation {
+			i.Release.Processes[name] = &process{
+				Command:  p.Command,
+				Quantity: p.Quantity,
+				Memory:   uint(p.Memory),
+				CPUShare: uint(p.CPUShare),
+			}
+			if p.Quantity > 0 {
+				i.Release.Instances += p.Quantity
+			}
+		}
+	}
+
+	return i
+}
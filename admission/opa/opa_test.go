@@ -0,0 +1,53 @@
+package opa
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/image"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestAdmissionController(t *testing.T) {
+	tests := []struct {
+		response string
+		err      error
+	}{
+		{`{"result": {"deny": []}}`, nil},
+		{`{"result": {}}`, nil},
+		{`{}`, ErrUndefined},
+		{`{"result": {"deny": ["images must not use the :latest tag"]}}`, &empire.AdmissionDeniedError{Reasons: []string{"images must not use the :latest tag"}}},
+	}
+
+	for _, tt := range tests {
+		var body map[string]map[string]interface{}
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/data/empire/admission", r.URL.Path)
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			w.Write([]byte(tt.response))
+		}))
+
+		c := NewAdmissionController(s.URL)
+		err := c.Admit(context.Background(), &empire.AdmissionRequest{
+			Operation: empire.AdmissionDeploy,
+			User:      &empire.User{Name: "ejholmes"},
+			App:       &empire.App{Name: "acme-inc"},
+			Release: &empire.Release{
+				Version: 1,
+				Slug:    &empire.Slug{Image: image.Image{Repository: "remind101/acme-inc", Tag: "latest"}},
+				Formation: empire.Formation{
+					"web": empire.Process{Quantity: 2},
+				},
+			},
+		})
+		s.Close()
+
+		assert.Equal(t, tt.err, err)
+		assert.Equal(t, "deploy", body["input"]["operation"])
+		assert.Equal(t, float64(2), body["input"]["release"].(map[string]interface{})["instances"])
+	}
+}
@@ -0,0 +1,28 @@
+package empire
+
+import "golang.org/x/net/context"
+
+// SetAlertRoutingKeyOpts are options provided when changing the key that
+// incidents about an app are routed with.
+type SetAlertRoutingKeyOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// The new key. Empty uses the default key of the alerting backend.
+	RoutingKey string
+}
+
+// SetAlertRoutingKey changes the key that incidents about the app, like failed
+// deploys and crash loops, are routed with, so that they reach whoever is on
+// call for the app.
+func (e *Empire) SetAlertRoutingKey(ctx context.Context, opts SetAlertRoutingKeyOpts) error {
+	if err := e.authorize(opts.User, opts.App, ActionAdmin); err != nil {
+		return err
+	}
+
+	opts.App.AlertRoutingKey = opts.RoutingKey
+	return appsUpdate(e.db, opts.App)
+}
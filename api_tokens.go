@@ -0,0 +1,309 @@
+package empire
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/timex"
+)
+
+// Actions that an APIToken can be scoped to. Each action requires the
+// corresponding role when RBAC is enabled.
+const (
+	ActionCreate   = "create"
+	ActionDeploy   = "deploy"
+	ActionRollback = "rollback"
+	ActionConfig   = "config"
+	ActionScale    = "scale"
+	ActionRestart  = "restart"
+	ActionRun      = "run"
+	ActionAdmin    = "admin"
+)
+
+// actionRoles maps an action to the role that is required to perform it.
+var actionRoles = map[string]string{
+	ActionCreate:   RoleDeployer,
+	ActionDeploy:   RoleDeployer,
+	ActionRollback: RoleDeployer,
+	ActionConfig:   RoleDeployer,
+	ActionScale:    RoleDeployer,
+	ActionRestart:  RoleDeployer,
+	ActionRun:      RoleDeployer,
+	ActionAdmin:    RoleAdmin,
+}
+
+// APITokenPrefix is prepended to all API tokens, which makes them easy to
+// distinguish from other credentials (and to detect if they're leaked).
+const APITokenPrefix = "emp_"
+
+// APITokenUserPrefix is prepended to the name of an APIToken to get the name
+// of the user that it authenticates as, so that a token can't take on the
+// roles, and team memberships, of a real user with the same name.
+const APITokenUserPrefix = "token:"
+
+var (
+	// ErrAPITokenName is returned when an APIToken is created without a
+	// name.
+	ErrAPITokenName = &ValidationError{
+		errors.New("API tokens require a name."),
+	}
+
+	// ErrAPITokenAction is returned when an APIToken is scoped to an
+	// unknown action.
+	ErrAPITokenAction = &ValidationError{
+		errors.New("Actions must be one of create, deploy, rollback, config, scale, restart, run or admin."),
+	}
+)
+
+// Scope restricts what an authenticated user can do, in addition to the roles
+// that they've been granted. Users authenticated with an APIToken are always
+// scoped. Users can always read the apps that are in scope.
+type Scope struct {
+	// If provided, the ids of the apps that can be accessed.
+	Apps []string `json:"apps,omitempty"`
+
+	// If provided, the actions that can be performed.
+	Actions []string `json:"actions,omitempty"`
+}
+
+// AllowsApp returns true if the app is within the scope. A nil app represents
+// actions that aren't scoped to an app, which are only allowed if the scope
+// isn't restricted to specific apps.
+func (s *Scope) AllowsApp(app *App) bool {
+	if len(s.Apps) == 0 {
+		return true
+	}
+
+	if app == nil {
+		return false
+	}
+
+	for _, id := range s.Apps {
+		if id == app.ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowsAction returns true if the action is within the scope.
+func (s *Scope) AllowsAction(action string) bool {
+	if len(s.Actions) == 0 {
+		return true
+	}
+
+	for _, a := range s.Actions {
+		if a == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Scan implements the sql.Scanner interface.
+func (s *Scope) Scan(src interface{}) error {
+	bytes, ok := src.([]byte)
+	if !ok {
+		return error(errors.New("Scan source was not []bytes"))
+	}
+
+	var scope Scope
+	if err := json.Unmarshal(bytes, &scope); err != nil {
+		return err
+	}
+	*s = scope
+
+	return nil
+}
+
+// Value implements the driver.Value interface.
+func (s Scope) Value() (driver.Value, error) {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return driver.Value(raw), nil
+}
+
+// APIToken is a long lived credential for a service account, like a CI system.
+// The name of the token is used as the name of the service account, so roles
+// can be granted to it like any other user.
+type APIToken struct {
+	// A unique uuid that identifies the token.
+	ID string
+
+	// The name of the service account that this token authenticates as.
+	Name string
+
+	// The plaintext token. This is only available when the token is
+	// created, and is never stored.
+	Token string `sql:"-"`
+
+	// A sha256 hash of the token.
+	TokenHash string
+
+	// The apps and actions that the token is restricted to.
+	Scope Scope
+
+	// The user that created the token.
+	CreatedBy string
+
+	// The time that the token was created.
+	CreatedAt *time.Time
+
+	// The last time that the token was used to authenticate.
+	LastUsedAt *time.Time
+}
+
+// IsValid returns an error if the token isn't valid.
+func (t *APIToken) IsValid() error {
+	if t.Name == "" {
+		return ErrAPITokenName
+	}
+
+	for _, a := range t.Scope.Actions {
+		if _, ok := actionRoles[a]; !ok {
+			return ErrAPITokenAction
+		}
+	}
+
+	return nil
+}
+
+// BeforeCreate sets created_at before inserting.
+func (t *APIToken) BeforeCreate() error {
+	now := timex.Now()
+	t.CreatedAt = &now
+	return t.IsValid()
+}
+
+// User returns the User that this token authenticates as, which is named
+// "token:<name>".
+func (t *APIToken) User() *User {
+	scope := t.Scope
+	return &User{
+		Name:  APITokenUserPrefix + t.Name,
+		Scope: &scope,
+	}
+}
+
+// IsAPIToken returns true if the given credential looks like an APIToken.
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// generateAPIToken returns a new random token.
+func generateAPIToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return APITokenPrefix + hex.EncodeToString(b), nil
+}
+
+// hashAPIToken returns the hash of the token that is stored in the database.
+func hashAPIToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// APITokensQuery is a scope implementation for common things to filter API
+// tokens by.
+type APITokensQuery struct {
+	// If provided, finds the token with the given id.
+	ID *string
+
+	// If provided, finds the token with the given hash.
+	TokenHash *string
+}
+
+// scope implements the scope interface.
+func (q APITokensQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.ID != nil {
+		scope = append(scope, idEquals(*q.ID))
+	}
+
+	if q.TokenHash != nil {
+		scope = append(scope, fieldEquals("token_hash", *q.TokenHash))
+	}
+
+	scope = append(scope, order("created_at desc"))
+
+	return scope.scope(db)
+}
+
+// apiTokensFind returns the first matching API token.
+func apiTokensFind(db *gorm.DB, scope scope) (*APIToken, error) {
+	var token APIToken
+	return &token, first(db, scope, &token)
+}
+
+// apiTokens returns all API tokens matching the scope.
+func apiTokens(db *gorm.DB, scope scope) ([]*APIToken, error) {
+	var tokens []*APIToken
+	return tokens, find(db, scope, &tokens)
+}
+
+func apiTokensCreate(db *gorm.DB, token *APIToken) (*APIToken, error) {
+	return token, db.Create(token).Error
+}
+
+func apiTokensDestroy(db *gorm.DB, token *APIToken) error {
+	return db.Delete(token).Error
+}
+
+// apiTokensTouch records that the token was just used.
+func apiTokensTouch(db *gorm.DB, token *APIToken) error {
+	now := timex.Now()
+	token.LastUsedAt = &now
+	return db.Model(token).UpdateColumn("last_used_at", now).Error
+}
+
+// APITokensCreateOpts are options provided when creating an API token.
+type APITokensCreateOpts struct {
+	// User performing the action.
+	User *User
+
+	// The name of the service account.
+	Name string
+
+	// The apps and actions to restrict the token to.
+	Scope Scope
+}
+
+// APITokensDestroyOpts are options provided when revoking an API token.
+type APITokensDestroyOpts struct {
+	// User performing the action.
+	User *User
+
+	// The token to revoke.
+	Token *APIToken
+}
+
+// authorizeScope checks that the user can manage API tokens with the given
+// scope. The user must be an admin of every app in the scope, or of all apps if
+// the scope isn't restricted to specific apps.
+func (e *Empire) authorizeScope(user *User, scope Scope) error {
+	if len(scope.Apps) == 0 {
+		return e.authorize(user, nil, ActionAdmin)
+	}
+
+	for _, id := range scope.Apps {
+		if err := e.authorizeAppID(user, id, ActionAdmin); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPITokensQuery(t *testing.T) {
+	var (
+		id   = "1234"
+		hash = "abcd"
+	)
+
+	tests := scopeTests{
+		{APITokensQuery{}, "ORDER BY created_at desc", []interface{}{}},
+		{APITokensQuery{ID: &id}, "WHERE (id = $1) ORDER BY created_at desc", []interface{}{"1234"}},
+		{APITokensQuery{TokenHash: &hash}, "WHERE (token_hash = $1) ORDER BY created_at desc", []interface{}{"abcd"}},
+	}
+
+	tests.Run(t)
+}
+
+func TestAPIToken_IsValid(t *testing.T) {
+	tests := []struct {
+		token APIToken
+		err   error
+	}{
+		{APIToken{Name: "ci"}, nil},
+		{APIToken{Name: "ci", Scope: Scope{Actions: []string{ActionDeploy, ActionScale}}}, nil},
+		{APIToken{}, ErrAPITokenName},
+		{APIToken{Name: "ci", Scope: Scope{Actions: []string{"destroy"}}}, ErrAPITokenAction},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.err, tt.token.IsValid())
+	}
+}
+
+func TestGenerateAPIToken(t *testing.T) {
+	a, err := generateAPIToken()
+	assert.NoError(t, err)
+	b, err := generateAPIToken()
+	assert.NoError(t, err)
+
+	assert.True(t, IsAPIToken(a))
+	assert.NotEqual(t, a, b)
+	assert.NotEqual(t, hashAPIToken(a), hashAPIToken(b))
+	assert.Equal(t, hashAPIToken(a), hashAPIToken(a))
+	assert.False(t, IsAPIToken("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"))
+}
+
+func TestScope(t *testing.T) {
+	app, otherApp := &App{ID: "4321"}, &App{ID: "1234"}
+
+	s := &Scope{}
+	assert.True(t, s.AllowsApp(app))
+	assert.True(t, s.AllowsApp(nil))
+	assert.True(t, s.AllowsAction(ActionAdmin))
+
+	s = &Scope{Apps: []string{"4321"}, Actions: []string{ActionDeploy}}
+	assert.True(t, s.AllowsApp(app))
+	assert.False(t, s.AllowsApp(otherApp))
+	assert.False(t, s.AllowsApp(nil))
+	assert.True(t, s.AllowsAction(ActionDeploy))
+	assert.False(t, s.AllowsAction(ActionConfig))
+}
+
+func TestEmpire_authorize_Scope(t *testing.T) {
+	e := &Empire{}
+	app, otherApp := &App{ID: "4321", Name: "acme-inc"}, &App{ID: "1234", Name: "other"}
+
+	token := &APIToken{Name: "ci", Scope: Scope{Apps: []string{"4321"}, Actions: []string{ActionDeploy}}}
+	user := token.User()
+
+	assert.NoError(t, e.authorize(user, app, ActionDeploy))
+	assert.NoError(t, e.Authorize(user, app, RoleViewer))
+	assert.EqualError(t, e.authorize(user, app, ActionConfig), "token:ci is not allowed to config on acme-inc")
+	assert.EqualError(t, e.authorize(user, otherApp, ActionDeploy), "token:ci does not have the deployer role on other")
+	assert.EqualError(t, e.authorize(user, nil, ActionCreate), "token:ci does not have the deployer role on all apps")
+}
+
+func TestAPIToken_User(t *testing.T) {
+	token := &APIToken{Name: "ci", Scope: Scope{Actions: []string{ActionDeploy}}}
+	user := token.User()
+
+	assert.Equal(t, "token:ci", user.Name)
+	assert.Equal(t, &token.Scope, user.Scope)
+}
@@ -0,0 +1,271 @@
+package empire
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/timex"
+	"golang.org/x/net/context"
+)
+
+// AppExportVersion is the version of the AppExport format that this version of
+// Empire exports, and can import.
+const AppExportVersion = 1
+
+// ErrAppExportVersion is returned when importing an AppExport with a version
+// that isn't supported.
+var ErrAppExportVersion = &ValidationError{
+	errors.New("The app export was created by an unsupported version of Empire."),
+}
+
+// AppExport is a portable document that completely describes an app, so that it
+// can be moved to another Empire installation, or rebuilt after a disaster.
+// The AppSpec describes the config, domains, formation and image of the
+// current release, and Settings describes the app itself.
+//
+// Empire doesn't have add-ons, since the resources that apps are attached to
+// are configured with config vars, so they're included in Config.
+//
+// Exports include the values of config vars, which are often secrets, so they
+// need to be stored as carefully as the database.
+type AppExport struct {
+	// The version of the format of the document.
+	Version int `json:"version"`
+
+	// The time that the app was exported.
+	ExportedAt time.Time `json:"exported_at"`
+
+	AppSpec
+
+	// The settings of the app.
+	Settings AppSettings `json:"settings"`
+
+	// If the app has been deployed, describes its current release.
+	Release *ReleaseExport `json:"release,omitempty"`
+}
+
+// AppSettings are the settings of an app that are included in an AppExport.
+// The cluster and namespace of the app are specific to the installation, so
+// they're not included.
+type AppSettings struct {
+	Repo            *string        `json:"repo,omitempty"`
+	Exposure        string         `json:"exposure,omitempty"`
+	Certs           Certs          `json:"certs,omitempty"`
+	Maintenance     bool           `json:"maintenance,omitempty"`
+	Protected       bool           `json:"protected,omitempty"`
+	Team            string         `json:"team,omitempty"`
+	Labels          Labels         `json:"labels,omitempty"`
+	RouterSettings  RouterSettings `json:"router_settings"`
+	DeployTimeout   time.Duration  `json:"deploy_timeout,omitempty"`
+	CronTimezone    string         `json:"cron_timezone,omitempty"`
+	AlertRoutingKey string         `json:"alert_routing_key,omitempty"`
+	ChaosFraction   float64        `json:"chaos_fraction,omitempty"`
+	SensitiveVars   SensitiveVars  `json:"sensitive_vars,omitempty"`
+}
+
+// ReleaseExport describes the current release of an app in an AppExport.
+type ReleaseExport struct {
+	// The version of the release, in the installation that it was exported
+	// from.
+	Version int `json:"version"`
+
+	// The description of the release.
+	Description string `json:"description"`
+
+	// Where the image of the release was built from, if it's known.
+	Provenance Provenance `json:"provenance,omitempty"`
+
+	// The time that the release was created.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IsValid returns an error if the export can't be imported.
+func (x *AppExport) IsValid() error {
+	if x.Version != AppExportVersion {
+		return ErrAppExportVersion
+	}
+	if err := x.Settings.Labels.IsValid(); err != nil {
+		return err
+	}
+	return x.AppSpec.IsValid()
+}
+
+// ExportAppOpts are options provided when exporting an app.
+type ExportAppOpts struct {
+	// User performing the action.
+	User *User
+
+	// The app to export.
+	App *App
+}
+
+// ExportApp returns an AppExport of the app. Exports include the values of
+// config vars that aren't sensitive, so it requires the admin role on the app.
+func (e *Empire) ExportApp(ctx context.Context, opts ExportAppOpts) (*AppExport, error) {
+	if err := e.authorize(opts.User, opts.App, ActionAdmin); err != nil {
+		return nil, err
+	}
+
+	app := opts.App
+	x := &AppExport{
+		Version:    AppExportVersion,
+		ExportedAt: timex.Now(),
+		AppSpec:    AppSpec{Name: app.Name, ConfigRules: app.ConfigRules},
+		Settings: AppSettings{
+			Repo:            app.Repo,
+			Exposure:        app.Exposure,
+			Certs:           app.Certs,
+			Maintenance:     app.Maintenance,
+			Protected:       app.Protected,
+			Team:            app.Team,
+			Labels:          app.Labels,
+			RouterSettings:  app.RouterSettings,
+			DeployTimeout:   app.DeployTimeout,
+			CronTimezone:    app.CronTimezone,
+			AlertRoutingKey: app.AlertRoutingKey,
+			ChaosFraction:   app.ChaosFraction,
+			SensitiveVars:   app.SensitiveVars,
+		},
+	}
+
+	state, err := e.appState(app)
+	if err != nil {
+		return nil, err
+	}
+
+	// The values of sensitive vars aren't exported, and are kept when the
+	// export is imported into the same installation.
+	x.Config = make(map[string]string)
+	for k, v := range app.SensitiveVars.Redact(state.Config) {
+		if v != nil {
+			x.Config[string(k)] = *v
+		}
+	}
+
+	x.Domains = state.Domains
+	if x.Domains == nil {
+		x.Domains = []string{}
+	}
+
+	release, err := releasesFind(e.db, ReleasesQuery{App: app})
+	if err != nil && err != gorm.RecordNotFound {
+		return nil, err
+	}
+	if err == nil {
+		x.Image = release.Slug.Image.String()
+		x.Formation = make(map[string]ProcessSpec)
+		for name, p := range release.Formation {
+			x.Formation[name] = ProcessSpec{
+				Quantity: p.Quantity,
+				Size:     p.Constraints().String(),
+			}
+		}
+		x.Release = &ReleaseExport{
+			Version:     release.Version,
+			Description: release.Description,
+			Provenance:  release.Slug.Provenance,
+			CreatedAt:   *release.CreatedAt,
+		}
+	}
+
+	return x, nil
+}
+
+// ImportAppOpts are options provided when importing an app.
+type ImportAppOpts struct {
+	// User performing the action.
+	User *User
+
+	// The exported app.
+	Export *AppExport
+
+	// If provided, the cluster that the app is scheduled to, when it's
+	// created.
+	Cluster string
+
+	// Commit message
+	Message string
+
+	// Required before destructive changes are made to a protected app. See
+	// ApplyOpts.
+	TwoFactor *TwoFactorCode
+}
+
+// ImportApp recreates an app from an AppExport, and returns a description of
+// each change that was made. When the app doesn't exist, it's created with
+// the settings in the export. Then, the AppSpec in the export is applied, which
+// sets the config vars, deploys the image of the exported release, adds the
+// domains and scales the processes. Like Apply, importing again after a
+// failure continues from where the failure happened, but the settings of an
+// app that already exists aren't changed.
+func (e *Empire) ImportApp(ctx context.Context, opts ImportAppOpts) ([]string, error) {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return nil, err
+	}
+
+	x := opts.Export
+	if err := x.IsValid(); err != nil {
+		return nil, err
+	}
+
+	var changes []string
+
+	_, err := appsFind(e.db, AppsQuery{Name: &x.Name})
+	if err == gorm.RecordNotFound {
+		app, err := e.Create(ctx, CreateOpts{
+			User:    opts.User,
+			Name:    x.Name,
+			Team:    x.Settings.Team,
+			Cluster: opts.Cluster,
+			Message: opts.Message,
+		})
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, fmt.Sprintf("create app %s", x.Name))
+
+		x.Settings.apply(app)
+		if err := appsUpdate(e.db, app); err != nil {
+			return changes, err
+		}
+		changes = append(changes, "update settings")
+	} else if err != nil {
+		return nil, err
+	}
+
+	var provenance Provenance
+	if x.Release != nil {
+		provenance = x.Release.Provenance
+	}
+
+	applied, err := e.Apply(ctx, ApplyOpts{
+		User:       opts.User,
+		Spec:       &x.AppSpec,
+		Provenance: provenance,
+		Message:    opts.Message,
+
+		TwoFactor: opts.TwoFactor,
+	})
+	return append(changes, applied...), err
+}
+
+// apply copies the settings to the app.
+func (s AppSettings) apply(app *App) {
+	app.Repo = s.Repo
+	if s.Exposure != "" {
+		app.Exposure = s.Exposure
+	}
+	app.Certs = s.Certs
+	app.Maintenance = s.Maintenance
+	app.Protected = s.Protected
+	app.Team = s.Team
+	app.Labels = s.Labels
+	app.RouterSettings = s.RouterSettings
+	app.DeployTimeout = s.DeployTimeout
+	app.CronTimezone = s.CronTimezone
+	app.AlertRoutingKey = s.AlertRoutingKey
+	app.ChaosFraction = s.ChaosFraction
+	app.SensitiveVars = s.SensitiveVars
+}
@@ -0,0 +1,47 @@
+package empire
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppExport_IsValid(t *testing.T) {
+	tests := []struct {
+		export AppExport
+		err    error
+	}{
+		{AppExport{Version: AppExportVersion, AppSpec: AppSpec{Name: "acme-inc"}}, nil},
+		{AppExport{Version: 2, AppSpec: AppSpec{Name: "acme-inc"}}, ErrAppExportVersion},
+		{AppExport{AppSpec: AppSpec{Name: "acme-inc"}}, ErrAppExportVersion},
+		{AppExport{Version: AppExportVersion}, ErrAppSpecName},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.err, tt.export.IsValid())
+	}
+}
+
+func TestAppExport_JSON(t *testing.T) {
+	var export AppExport
+	err := json.Unmarshal([]byte(`{
+  "version": 1,
+  "name": "acme-inc",
+  "image": "remind101/acme-inc:v42",
+  "config": {"RAILS_ENV": "production"},
+  "settings": {"exposure": "public", "labels": {"tier": "web"}},
+  "release": {"version": 12, "provenance": {"Commit": "c6f77d2"}}
+}`), &export)
+	assert.NoError(t, err)
+	assert.NoError(t, export.IsValid())
+
+	assert.Equal(t, AppSpec{
+		Name:   "acme-inc",
+		Image:  "remind101/acme-inc:v42",
+		Config: map[string]string{"RAILS_ENV": "production"},
+	}, export.AppSpec)
+	assert.Equal(t, "public", export.Settings.Exposure)
+	assert.Equal(t, Labels{"tier": "web"}, export.Settings.Labels)
+	assert.Equal(t, Provenance{Commit: "c6f77d2"}, export.Release.Provenance)
+}
@@ -0,0 +1,118 @@
+package empire
+
+import (
+	"sort"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/headerutil"
+	"golang.org/x/net/context"
+)
+
+// RecentReleasesLimit is the number of releases that are included in the
+// AppInfo of an app.
+const RecentReleasesLimit = 10
+
+// AppInfo is an overview of an app, which brings together everything that
+// clients usually look up about an app, so that they can get it in a single
+// call.
+type AppInfo struct {
+	// The app.
+	App *App
+
+	// The current release of the app, or nil if the app hasn't been
+	// deployed.
+	Release *Release
+
+	// The current process formation.
+	Formation Formation
+
+	// The id of the current config. This changes whenever config vars are
+	// set or unset.
+	ConfigID string
+
+	// The number of tasks in each state, for each process.
+	Processes []*ProcessStates
+
+	// The domains that are routed to the app.
+	Domains []*Domain
+
+	// The most recent releases of the app, newest first, and what changed
+	// in each of them.
+	Releases       []*Release
+	ReleaseChanges []*ReleaseChanges
+}
+
+// ProcessStates is the number of tasks of a process that are in each state.
+type ProcessStates struct {
+	// The process type.
+	Type string
+
+	// The number of tasks in each state (e.g. RUNNING).
+	States map[string]int
+}
+
+// AppInfo returns an overview of the app, with its current release, formation,
+// config, task states, domains and recent releases.
+func (e *Empire) AppInfo(ctx context.Context, app *App) (*AppInfo, error) {
+	info := &AppInfo{App: app}
+
+	release, err := releasesFind(e.db, ReleasesQuery{App: app})
+	if err != nil && err != gorm.RecordNotFound {
+		return nil, err
+	}
+	if err == nil {
+		info.Release = release
+		info.Formation = release.Formation
+	}
+
+	config, err := e.configs.Config(e.db, app)
+	if err != nil {
+		return nil, err
+	}
+	info.ConfigID = config.ID
+
+	tasks, err := e.tasks.Tasks(ctx, TasksQuery{App: app})
+	if err != nil {
+		return nil, err
+	}
+	info.Processes = processStates(tasks)
+
+	if info.Domains, err = domains(e.db, DomainsQuery{App: app}); err != nil {
+		return nil, err
+	}
+
+	max := RecentReleasesLimit
+	if info.Releases, err = releases(e.db, ReleasesQuery{App: app, Range: headerutil.Range{Max: &max}}); err != nil {
+		return nil, err
+	}
+	if info.ReleaseChanges, err = releasesChanges(e.db, info.Releases); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// processStates counts the tasks of each process by their state. Processes are
+// sorted by type.
+func processStates(tasks []*Task) []*ProcessStates {
+	byType := make(map[string]*ProcessStates)
+	var processes []*ProcessStates
+	for _, t := range tasks {
+		p, ok := byType[t.Type]
+		if !ok {
+			p = &ProcessStates{Type: t.Type, States: make(map[string]int)}
+			byType[t.Type] = p
+			processes = append(processes, p)
+		}
+		p.States[t.State]++
+	}
+
+	sort.Sort(processStatesByType(processes))
+	return processes
+}
+
+type processStatesByType []*ProcessStates
+
+func (s processStatesByType) Len() int           { return len(s) }
+func (s processStatesByType) Less(i, j int) bool { return s[i].Type < s[j].Type }
+func (s processStatesByType) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
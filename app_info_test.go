@@ -0,0 +1,21 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessStates(t *testing.T) {
+	processes := processStates([]*Task{
+		{Type: "worker", State: "RUNNING"},
+		{Type: "web", State: "RUNNING"},
+		{Type: "web", State: "PENDING"},
+		{Type: "web", State: "RUNNING"},
+	})
+
+	assert.Equal(t, []*ProcessStates{
+		{Type: "web", States: map[string]int{"RUNNING": 2, "PENDING": 1}},
+		{Type: "worker", States: map[string]int{"RUNNING": 1}},
+	}, processes)
+}
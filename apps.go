@@ -91,6 +91,62 @@ type App struct {
 
 	// Maintenance defines whether the app is in maintenance mode or not.
 	Maintenance bool
+
+	// Protected apps require a two factor code to be destroyed, scaled to
+	// zero, or to have config vars removed.
+	Protected bool
+
+	// If provided, the team that owns this application.
+	Team string
+
+	// If provided, the name of the namespace that the application is in,
+	// which is set when the application is created, or when the namespace
+	// that its name is in is claimed.
+	Namespace string
+
+	// Arbitrary key/value pairs that describe the application, like its
+	// tier or cost center, which apps can be selected by.
+	Labels Labels
+
+	// If provided, the name of the cluster that this application is
+	// scheduled to. The default cluster is used when empty.
+	Cluster string
+
+	// The percentage of traffic to the load balancers of the app that's
+	// sent to the processes of the previous release, instead of the current
+	// release. When 0, the previous release isn't running.
+	PreviousReleaseWeight int
+
+	// Controls how the load balancers of the app handle connections.
+	RouterSettings RouterSettings
+
+	// If not zero, how long a deploy waits for the new processes to become
+	// healthy. Deploys that take longer are aborted, and the previous
+	// release is restored.
+	DeployTimeout time.Duration
+
+	// If provided, the time zone that the schedules of scheduled processes
+	// are evaluated in (e.g. "America/New_York"), unless they set their
+	// own. Schedules are evaluated in UTC otherwise.
+	CronTimezone string
+
+	// If provided, the key that incidents about the app are routed with,
+	// like the integration key of a PagerDuty service. The default key of
+	// the alerting backend is used when empty.
+	AlertRoutingKey string
+
+	// The fraction of the instances of each long running process that the
+	// ChaosMonkey kills each time it runs. The zero value opts the app out
+	// of chaos testing.
+	ChaosFraction float64
+
+	// Rules that the config vars of each release of the app need to
+	// satisfy, by the name of the config var.
+	ConfigRules ConfigRules
+
+	// The names of the config vars whose values are redacted when they're
+	// read.
+	SensitiveVars SensitiveVars
 }
 
 // IsValid returns an error if the app isn't valid.
@@ -124,6 +180,15 @@ type AppsQuery struct {
 
 	// If provided, finds apps with the given repo attached.
 	Repo *string
+
+	// If provided, finds apps owned by the given team.
+	Team *string
+
+	// If provided, finds apps in the given namespace.
+	Namespace *string
+
+	// If provided, finds apps with labels matching the selector.
+	Selector LabelSelector
 }
 
 // scope implements the scope interface.
@@ -142,6 +207,18 @@ func (q AppsQuery) scope(db *gorm.DB) *gorm.DB {
 		scope = append(scope, fieldEquals("repo", *q.Repo))
 	}
 
+	if q.Team != nil {
+		scope = append(scope, fieldEquals("team", *q.Team))
+	}
+
+	if q.Namespace != nil {
+		scope = append(scope, fieldEquals("namespace", *q.Namespace))
+	}
+
+	if len(q.Selector) > 0 {
+		scope = append(scope, q.Selector)
+	}
+
 	return scope.scope(db)
 }
 
@@ -155,14 +232,42 @@ func (s *appsService) Destroy(ctx context.Context, db *gorm.DB, app *App) error
 		return err
 	}
 
-	return s.Scheduler.Remove(ctx, app.ID)
+	if err := recordUsage(db, app, usage{}); err != nil {
+		return err
+	}
+
+	scheduler, err := s.scheduler(app)
+	if err != nil {
+		return err
+	}
+
+	return scheduler.Remove(ctx, app.ID)
 }
 
 func (s *appsService) Restart(ctx context.Context, db *gorm.DB, opts RestartOpts) error {
+	if _, err := activitiesCreate(db, &Activity{
+		AppID:       opts.App.ID,
+		Type:        ActivityRestart,
+		CreatedBy:   opts.User.Name,
+		Description: opts.Event().String(),
+	}); err != nil {
+		return err
+	}
+
 	if opts.PID != "" {
-		return s.Scheduler.Stop(ctx, opts.PID)
+		scheduler, err := s.scheduler(opts.App)
+		if err != nil {
+			return err
+		}
+		if err := unscheduleOpenTasks(db, opts.App, TaskTransitionsQuery{TaskIDs: []string{opts.PID}}, "restarted"); err != nil {
+			return err
+		}
+		return scheduler.Stop(ctx, opts.PID)
 	}
 
+	if err := unscheduleOpenTasks(db, opts.App, TaskTransitionsQuery{}, "restarted"); err != nil {
+		return err
+	}
 	return s.releases.Restart(ctx, db, opts.App)
 }
 
@@ -177,13 +282,34 @@ func (s *appsService) Scale(ctx context.Context, db *gorm.DB, opts ScaleOpts) ([
 		return nil, &ValidationError{Err: fmt.Errorf("no releases for %s", app.Name)}
 	}
 
+	if opts.FormationVersion != 0 && opts.FormationVersion != release.FormationVersion {
+		return nil, &FormationConflictError{App: app, Version: opts.FormationVersion}
+	}
+
 	event := opts.Event()
 
-	var ps []*Process
+	// All of the updates are validated, and applied to a copy of the
+	// formation, before anything is saved, so that the formation is
+	// never left partially scaled.
+	f := make(Formation)
+	for name, p := range release.Formation {
+		f[name] = p
+	}
+
+	var (
+		ps     []*Process
+		types  []string
+		scaled = make(map[string]bool)
+	)
 	for i, up := range opts.Updates {
 		t, q, c := up.Process, up.Quantity, up.Constraints
 
-		p, ok := release.Formation[t]
+		if scaled[t] {
+			return nil, &ValidationError{Err: fmt.Errorf("%s process type is scaled more than once", t)}
+		}
+		scaled[t] = true
+
+		p, ok := f[t]
 		if !ok {
 			return nil, &ValidationError{Err: fmt.Errorf("no %s process type in release", t)}
 		}
@@ -193,17 +319,66 @@ func (s *appsService) Scale(ctx context.Context, db *gorm.DB, opts ScaleOpts) ([
 		eventUpdate.PreviousConstraints = p.Constraints()
 
 		// Update quantity for this process in the formation
+		if up.Change != nil {
+			q = up.Change.Apply(p.Quantity)
+			eventUpdate.Quantity = q
+		}
+		if q == 0 && p.Quantity > 0 {
+			if err := s.requireTwoFactor(opts.User, app, opts.TwoFactor); err != nil {
+				return nil, err
+			}
+		}
 		p.Quantity = q
 		if c != nil {
 			p.SetConstraints(*c)
 		}
+		if up.Args != nil {
+			eventUpdate.PreviousArgs = p.Args
+			p.Args = nil
+			if len(*up.Args) > 0 {
+				p.Args = *up.Args
+			}
+		}
 
-		release.Formation[t] = p
+		f[t] = p
 		ps = append(ps, &p)
+		types = append(types, t)
+	}
+
+	if err := s.checkPlacement(ctx, app, f, types); err != nil {
+		return nil, err
+	}
+
+	if err := s.preempt(ctx, app, release.Formation, f, types); err != nil {
+		return nil, err
+	}
+
+	release.Formation = f
+
+	if err := s.admit(ctx, &AdmissionRequest{
+		Operation: AdmissionScale,
+		User:      opts.User,
+		Release:   release,
+	}); err != nil {
+		return nil, err
 	}
 
 	// Save the new formation.
-	if err := releasesUpdate(db, release); err != nil {
+	if err := releasesUpdateFormation(db, release); err != nil {
+		return nil, err
+	}
+
+	if err := appsUpdateFormation(db, app, release.Formation); err != nil {
+		return nil, err
+	}
+
+	if _, err := activitiesCreate(db, &Activity{
+		AppID:       app.ID,
+		Type:        ActivityScale,
+		CreatedBy:   opts.User.Name,
+		Description: event.String(),
+		Release:     release.Version,
+	}); err != nil {
 		return nil, err
 	}
 
@@ -212,7 +387,7 @@ func (s *appsService) Scale(ctx context.Context, db *gorm.DB, opts ScaleOpts) ([
 		return ps, err
 	}
 
-	return ps, s.PublishEvent(event)
+	return ps, s.publishEvent(ctx, event)
 }
 
 // appsEnsureRepo will set the repo if it's not set.
@@ -262,8 +437,12 @@ func apps(db *gorm.DB, scope scope) ([]*App, error) {
 	return apps, find(db, scope, &apps)
 }
 
-// appsCreate inserts the app into the database.
+// appsCreate inserts the app into the database, in the namespace that its name
+// is in, if it's been claimed.
 func appsCreate(db *gorm.DB, app *App) (*App, error) {
+	if err := setNamespace(db, app); err != nil {
+		return app, err
+	}
 	return app, db.Create(app).Error
 }
 
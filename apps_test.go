@@ -25,6 +25,7 @@ func TestAppsQuery(t *testing.T) {
 	id := "1234"
 	name := "acme-inc"
 	repo := "remind101/acme-inc"
+	namespace := "acme"
 
 	tests := scopeTests{
 		{AppsQuery{}, "WHERE (deleted_at is null)", []interface{}{}},
@@ -32,7 +33,31 @@ func TestAppsQuery(t *testing.T) {
 		{AppsQuery{Name: &name}, "WHERE (deleted_at is null) AND (name = $1)", []interface{}{name}},
 		{AppsQuery{Repo: &repo}, "WHERE (deleted_at is null) AND (repo = $1)", []interface{}{repo}},
 		{AppsQuery{Name: &name, Repo: &repo}, "WHERE (deleted_at is null) AND (name = $1) AND (repo = $2)", []interface{}{name, repo}},
+		{AppsQuery{Namespace: &namespace}, "WHERE (deleted_at is null) AND (namespace = $1)", []interface{}{namespace}},
 	}
 
 	tests.Run(t)
 }
+
+func TestScaleOpts_operation(t *testing.T) {
+	app := &App{Name: "acme-inc"}
+	args := Command{"--threads=8"}
+	constraints := Constraints1X
+
+	tests := []struct {
+		updates []*ProcessUpdate
+		out     string
+	}{
+		{[]*ProcessUpdate{{Process: "web", Quantity: 2}}, "scale acme-inc web=2"},
+		{[]*ProcessUpdate{{Process: "worker", Quantity: 1}, {Process: "web", Quantity: 2}}, "scale acme-inc web=2 worker=1"},
+		{[]*ProcessUpdate{{Process: "web", Change: &QuantityChange{Delta: -50, Percent: true}}}, "scale acme-inc web=-50%"},
+		{[]*ProcessUpdate{{Process: "web", Quantity: 2, Constraints: &constraints}}, "scale acme-inc web=2:1X"},
+		{[]*ProcessUpdate{{Process: "web", Quantity: 2, Args: &args}}, `scale acme-inc web=2 args="--threads=8"`},
+	}
+
+	for _, tt := range tests {
+		if got := (ScaleOpts{App: app, Updates: tt.updates}).operation(); got != tt.out {
+			t.Errorf("operation() => %q; want %q", got, tt.out)
+		}
+	}
+}
@@ -0,0 +1,614 @@
+package empire
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/internal/yaml"
+	"github.com/remind101/empire/pkg/image"
+	"golang.org/x/net/context"
+)
+
+// ErrAppSpecName is returned when an AppSpec doesn't have a valid app name.
+var ErrAppSpecName = &ValidationError{
+	errors.New("An app spec needs a valid app name."),
+}
+
+// ErrApplyProtected is returned when applying an AppSpec would unset config
+// vars, or scale processes down, on a protected app, and there's no way to
+// verify a two factor code (e.g. when it's applied by the GitSyncer).
+var ErrApplyProtected = errors.New("Applying the spec would unset config vars, or scale processes down, on a protected app, which requires a two factor code. Apply it with `emp apply` instead.")
+
+// AppSpec declares the desired state of an app. Applying a spec converges the
+// app to it, by creating the app if it doesn't exist, and then changing
+// whatever differs, with the same operations that a user would run (e.g.
+// `emp set`, `emp deploy` and `emp scale`).
+//
+// Only the parts of the spec that are provided are managed. For example,
+// when Config is omitted, config vars can still be changed with `emp set`,
+// but when it's provided, any config vars that aren't in the spec are unset.
+type AppSpec struct {
+	// The name of the app.
+	Name string `json:"name" yaml:"name"`
+
+	// If provided, the image that should be deployed.
+	Image string `json:"image,omitempty" yaml:"image,omitempty"`
+
+	// If provided, the complete set of config vars for the app. Config vars
+	// set to RedactedValue keep their current value.
+	Config map[string]string `json:"config,omitempty" yaml:"config,omitempty"`
+
+	// If provided, the rules that the config vars of each release of the
+	// app need to satisfy, by the name of the config var.
+	ConfigRules ConfigRules `json:"config_rules,omitempty" yaml:"config_rules,omitempty"`
+
+	// If provided, the complete set of domains for the app.
+	Domains []string `json:"domains,omitempty" yaml:"domains,omitempty"`
+
+	// If provided, the quantity and size of each process. Processes that
+	// aren't listed keep their current scale.
+	Formation map[string]ProcessSpec `json:"formation,omitempty" yaml:"formation,omitempty"`
+}
+
+// ProcessSpec declares the scale of a process in an AppSpec.
+type ProcessSpec struct {
+	// The number of instances to run.
+	Quantity int `json:"quantity" yaml:"quantity"`
+
+	// If provided, the size of each instance (e.g. "2X" or "512:1GB").
+	Size string `json:"size,omitempty" yaml:"size,omitempty"`
+}
+
+// ParseAppSpec parses an AppSpec from YAML, or JSON.
+func ParseAppSpec(b []byte) (*AppSpec, error) {
+	var spec AppSpec
+	if err := yaml.Unmarshal(b, &spec); err != nil {
+		return nil, fmt.Errorf("error parsing app spec: %v", err)
+	}
+	return &spec, nil
+}
+
+// ReadAppSpec reads an AppSpec from a file.
+func ReadAppSpec(path string) (*AppSpec, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := ParseAppSpec(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return spec, nil
+}
+
+// IsValid returns an error if the spec isn't valid.
+func (s *AppSpec) IsValid() error {
+	if !NamePattern.MatchString(s.Name) {
+		return ErrAppSpecName
+	}
+
+	if s.Image != "" {
+		if _, err := image.Decode(s.Image); err != nil {
+			return &ValidationError{Err: fmt.Errorf("invalid image %s: %v", s.Image, err)}
+		}
+	}
+
+	if err := s.ConfigRules.IsValid(); err != nil {
+		return err
+	}
+
+	for _, d := range s.Domains {
+		if d == "" {
+			return &ValidationError{Err: errors.New("domains can't be empty")}
+		}
+	}
+
+	for name, p := range s.Formation {
+		if _, err := ParseConstraints(p.Size); err != nil {
+			return &ValidationError{Err: fmt.Errorf("invalid size for %s: %v", name, err)}
+		}
+	}
+
+	return nil
+}
+
+// appState is the current state of an app, which an AppSpec is compared to.
+type appState struct {
+	// The config vars of the app.
+	Config Vars
+
+	// The config rules of the app.
+	ConfigRules ConfigRules
+
+	// The config vars of the app whose values are sensitive.
+	SensitiveVars SensitiveVars
+
+	// The hostnames of the domains of the app.
+	Domains []string
+
+	// The image of the current release, if there is one.
+	Image string
+
+	// The image in the spec that was last applied, and the image of the
+	// release that it created. Images are usually resolved to a digest
+	// when they're deployed, so this is how a spec that references a tag
+	// is known to already be deployed.
+	AppliedImage, AppliedSlugImage string
+
+	// The formation of the current release.
+	Formation Formation
+}
+
+// appSpecPlan is the set of operations that converge an app to an AppSpec.
+type appSpecPlan struct {
+	// The config vars to set, or unset.
+	Vars Vars
+
+	// Sensitive config vars in the spec. Their values are never compared
+	// with the current values when planning, so that a plan can't be used
+	// to confirm a guess of a secret. They're only set when the plan is
+	// applied, and they differ.
+	SensitiveVars Vars
+
+	// If provided, the new config rules.
+	ConfigRules ConfigRules
+
+	// If provided, the image to deploy.
+	Deploy *image.Image
+
+	// Domains to add, and to remove.
+	AddDomains, RemoveDomains []string
+
+	// Processes to scale.
+	Scale []*ProcessUpdate
+}
+
+// plan compares the spec to the current state of the app, and returns the
+// operations that converge the app to the spec.
+func (s *AppSpec) plan(state appState) (*appSpecPlan, error) {
+	p := &appSpecPlan{}
+
+	if s.Config != nil {
+		for k, v := range s.Config {
+			v := v
+			if v == RedactedValue {
+				continue
+			}
+			if state.SensitiveVars.Contains(k) {
+				if p.SensitiveVars == nil {
+					p.SensitiveVars = make(Vars)
+				}
+				p.SensitiveVars[Variable(k)] = &v
+				continue
+			}
+			if current, ok := state.Config[Variable(k)]; !ok || current == nil || *current != v {
+				if p.Vars == nil {
+					p.Vars = make(Vars)
+				}
+				p.Vars[Variable(k)] = &v
+			}
+		}
+		for k := range state.Config {
+			if _, ok := s.Config[string(k)]; !ok {
+				if p.Vars == nil {
+					p.Vars = make(Vars)
+				}
+				p.Vars[k] = nil
+			}
+		}
+	}
+
+	if s.ConfigRules != nil && !reflect.DeepEqual(s.ConfigRules, state.ConfigRules) {
+		p.ConfigRules = s.ConfigRules
+	}
+
+	if s.Image != "" {
+		img, err := image.Decode(s.Image)
+		if err != nil {
+			return nil, err
+		}
+
+		// When the spec hasn't changed since it was last applied, and
+		// nothing else has been deployed since, it's already deployed.
+		applied := img.String() == state.AppliedImage && state.Image == state.AppliedSlugImage
+		if state.Image == "" || (img.String() != state.Image && !applied) {
+			p.Deploy = &img
+		}
+	}
+
+	if s.Domains != nil {
+		current := make(map[string]bool)
+		for _, d := range state.Domains {
+			current[d] = true
+		}
+		desired := make(map[string]bool)
+		for _, d := range s.Domains {
+			desired[d] = true
+			if !current[d] {
+				p.AddDomains = append(p.AddDomains, d)
+			}
+		}
+		for _, d := range state.Domains {
+			if !desired[d] {
+				p.RemoveDomains = append(p.RemoveDomains, d)
+			}
+		}
+		sort.Strings(p.AddDomains)
+		sort.Strings(p.RemoveDomains)
+	}
+
+	p.Scale = s.scale(state.Formation)
+
+	return p, nil
+}
+
+// scale returns the updates that bring the processes in the formation to the
+// scale in the spec. Processes that aren't in the formation are included, so
+// that scaling them fails if they aren't in the Procfile.
+func (s *AppSpec) scale(f Formation) []*ProcessUpdate {
+	var names []string
+	for name := range s.Formation {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var updates []*ProcessUpdate
+	for _, name := range names {
+		ps := s.Formation[name]
+
+		// Validated by IsValid.
+		c, _ := ParseConstraints(ps.Size)
+
+		current, ok := f[name]
+		if ok && current.Quantity == ps.Quantity && (c == nil || current.Constraints() == *c) {
+			continue
+		}
+
+		updates = append(updates, &ProcessUpdate{
+			Process:     name,
+			Quantity:    ps.Quantity,
+			Constraints: c,
+		})
+	}
+
+	return updates
+}
+
+// destructive returns true if the plan unsets config vars, or scales processes
+// in the formation down, which requires a two factor code on protected apps.
+func (p *appSpecPlan) destructive(f Formation) bool {
+	for _, v := range p.Vars {
+		if v == nil {
+			return true
+		}
+	}
+	return scalesDown(p.Scale, f)
+}
+
+// scalesDown returns true if any of the updates removes instances of a process
+// in the formation.
+func scalesDown(updates []*ProcessUpdate, f Formation) bool {
+	for _, up := range updates {
+		if current, ok := f[up.Process]; ok && up.Quantity < current.Quantity {
+			return true
+		}
+	}
+	return false
+}
+
+// vars returns the config vars to set, or unset, when the plan is applied,
+// which includes the sensitive vars in the spec that differ from their current
+// values.
+func (p *appSpecPlan) vars(state appState) Vars {
+	var vars Vars
+	for k, v := range p.Vars {
+		if vars == nil {
+			vars = make(Vars)
+		}
+		vars[k] = v
+	}
+	for k, v := range p.SensitiveVars {
+		if current := state.Config[k]; current != nil && *current == *v {
+			continue
+		}
+		if vars == nil {
+			vars = make(Vars)
+		}
+		vars[k] = v
+	}
+	return vars
+}
+
+// Changes returns a description of each operation in the plan.
+func (p *appSpecPlan) Changes() []string {
+	var changes []string
+
+	if p.ConfigRules != nil {
+		changes = append(changes, "set config rules")
+	}
+
+	var names []string
+	for k := range p.Vars {
+		names = append(names, string(k))
+	}
+	for k := range p.SensitiveVars {
+		names = append(names, string(k))
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		// Values aren't included, since they're often secrets.
+		if _, ok := p.SensitiveVars[Variable(k)]; ok {
+			changes = append(changes, fmt.Sprintf("may change %s", k))
+		} else if p.Vars[Variable(k)] == nil {
+			changes = append(changes, fmt.Sprintf("unset %s", k))
+		} else {
+			changes = append(changes, fmt.Sprintf("set %s", k))
+		}
+	}
+
+	if p.Deploy != nil {
+		changes = append(changes, fmt.Sprintf("deploy %s", p.Deploy))
+	}
+
+	for _, d := range p.AddDomains {
+		changes = append(changes, fmt.Sprintf("add domain %s", d))
+	}
+	for _, d := range p.RemoveDomains {
+		changes = append(changes, fmt.Sprintf("remove domain %s", d))
+	}
+
+	for _, up := range p.Scale {
+		scale := fmt.Sprintf("%s=%d", up.Process, up.Quantity)
+		if up.Constraints != nil {
+			scale = fmt.Sprintf("%s:%s", scale, up.Constraints)
+		}
+		changes = append(changes, fmt.Sprintf("scale %s", scale))
+	}
+
+	return changes
+}
+
+// ApplyOpts are options provided when applying an AppSpec.
+type ApplyOpts struct {
+	// User performing the action.
+	User *User
+
+	// The desired state of the app.
+	Spec *AppSpec
+
+	// If true, the changes are returned without being made.
+	DryRun bool
+
+	// If provided, where the image in the spec was built from, which is
+	// recorded when it's deployed.
+	Provenance Provenance
+
+	// Commit message
+	Message string
+
+	// Required before any changes are made when the app is protected, and
+	// the plan unsets config vars, or scales processes down. When nil,
+	// those plans are refused with ErrApplyProtected.
+	TwoFactor *TwoFactorCode
+}
+
+// Apply converges an app to the AppSpec, and returns a description of each
+// change that was made. Each change is made with its own operation, so if
+// one fails, the changes before it remain, and applying the spec again
+// continues from there.
+func (e *Empire) Apply(ctx context.Context, opts ApplyOpts) ([]string, error) {
+	spec := opts.Spec
+
+	if err := spec.IsValid(); err != nil {
+		return nil, err
+	}
+
+	var changes []string
+
+	app, err := appsFind(e.db, AppsQuery{Name: &spec.Name})
+	if err != nil {
+		if err != gorm.RecordNotFound {
+			return nil, err
+		}
+
+		changes = append(changes, fmt.Sprintf("create app %s", spec.Name))
+		if opts.DryRun {
+			p, err := spec.plan(appState{})
+			if err != nil {
+				return nil, err
+			}
+			return append(changes, p.Changes()...), nil
+		}
+
+		app, err = e.Create(ctx, CreateOpts{
+			User:    opts.User,
+			Name:    spec.Name,
+			Message: opts.Message,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The plan describes the current state of the app, so it requires the
+	// same access as reading it.
+	if err := e.Authorize(opts.User, app, RoleViewer); err != nil {
+		return nil, err
+	}
+	if spec.Config != nil {
+		if err := e.authorize(opts.User, app, ActionConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	state, err := e.appState(app)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := spec.plan(state)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		return append(changes, p.Changes()...), nil
+	}
+
+	verify := func() error {
+		if app.Protected && opts.TwoFactor == nil {
+			return ErrApplyProtected
+		}
+		return e.requireTwoFactor(opts.User, app, opts.TwoFactor)
+	}
+
+	if p.destructive(state.Formation) {
+		if err := verify(); err != nil {
+			return changes, err
+		}
+	}
+
+	// Rules are changed first, so that the release that's created when
+	// config vars are set is checked against them.
+	if p.ConfigRules != nil {
+		if err := e.SetConfigRules(ctx, SetConfigRulesOpts{
+			User:  opts.User,
+			App:   app,
+			Rules: p.ConfigRules,
+		}); err != nil {
+			return changes, err
+		}
+	}
+
+	vars := p.vars(state)
+	if vars != nil {
+		if _, err := e.Set(ctx, SetOpts{
+			User:      opts.User,
+			App:       app,
+			Vars:      vars,
+			Message:   opts.Message,
+			TwoFactor: opts.TwoFactor,
+		}); err != nil {
+			return changes, err
+		}
+	}
+
+	if p.Deploy != nil {
+		r, err := e.Deploy(ctx, DeployOpts{
+			User:       opts.User,
+			App:        app,
+			Image:      *p.Deploy,
+			Provenance: opts.Provenance,
+			Output:     NewDeploymentStream(ioutil.Discard),
+			Message:    opts.Message,
+		})
+		if err != nil {
+			return changes, err
+		}
+
+		if err := appsUpdateAppliedImage(e.db, app, p.Deploy.String(), r.Slug.Image.String()); err != nil {
+			return changes, err
+		}
+
+		// The processes to scale aren't known until the Procfile is
+		// extracted.
+		p.Scale = spec.scale(r.Formation)
+		if scalesDown(p.Scale, r.Formation) {
+			if err := verify(); err != nil {
+				return changes, err
+			}
+		}
+	}
+
+	for _, d := range p.AddDomains {
+		if _, err := e.DomainsCreate(ctx, DomainsCreateOpts{
+			User:   opts.User,
+			Domain: &Domain{AppID: app.ID, Hostname: d},
+		}); err != nil {
+			return changes, err
+		}
+	}
+
+	for _, d := range p.RemoveDomains {
+		domain, err := domainsFind(e.db, DomainsQuery{App: app, Hostname: &d})
+		if err != nil {
+			return changes, err
+		}
+		if err := e.DomainsDestroy(ctx, DomainsDestroyOpts{
+			User:   opts.User,
+			Domain: domain,
+		}); err != nil {
+			return changes, err
+		}
+	}
+
+	if len(p.Scale) > 0 {
+		if _, err := e.Scale(ctx, ScaleOpts{
+			User:      opts.User,
+			App:       app,
+			Updates:   p.Scale,
+			Message:   opts.Message,
+			TwoFactor: opts.TwoFactor,
+		}); err != nil {
+			return changes, err
+		}
+	}
+
+	return append(changes, p.Changes()...), nil
+}
+
+// appState returns the current state of the app.
+func (e *Empire) appState(app *App) (appState, error) {
+	var state appState
+
+	config, err := e.configs.Config(e.db, app)
+	if err != nil {
+		return state, err
+	}
+	state.Config = config.Vars
+	state.ConfigRules = app.ConfigRules
+	state.SensitiveVars = app.SensitiveVars
+
+	ds, err := domains(e.db, DomainsQuery{App: app})
+	if err != nil {
+		return state, err
+	}
+	for _, d := range ds {
+		state.Domains = append(state.Domains, d.Hostname)
+	}
+
+	release, err := releasesFind(e.db, ReleasesQuery{App: app})
+	if err == nil {
+		state.Image = release.Slug.Image.String()
+		state.Formation = release.Formation
+	} else if err != gorm.RecordNotFound {
+		return state, err
+	}
+
+	state.AppliedImage, state.AppliedSlugImage, err = appsAppliedImage(e.db, app)
+	return state, err
+}
+
+// appsAppliedImage returns the image in the AppSpec that was last applied to
+// the app, and the image of the release that it created.
+func appsAppliedImage(db *gorm.DB, app *App) (applied, slug string, err error) {
+	var a, s *string
+	if err := db.Raw(`select applied_image, applied_slug_image from apps where id = ?`, app.ID).Row().Scan(&a, &s); err != nil {
+		return "", "", err
+	}
+	if a != nil {
+		applied = *a
+	}
+	if s != nil {
+		slug = *s
+	}
+	return applied, slug, nil
+}
+
+func appsUpdateAppliedImage(db *gorm.DB, app *App, applied, slug string) error {
+	return db.Exec(`update apps set applied_image = ?, applied_slug_image = ? where id = ?`, applied, slug, app.ID).Error
+}
@@ -0,0 +1,232 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/pkg/image"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAppSpec(t *testing.T) {
+	expected := &AppSpec{
+		Name:    "acme-inc",
+		Image:   "remind101/acme-inc:v42",
+		Config:  map[string]string{"RAILS_ENV": "production"},
+		Domains: []string{"acme.com"},
+		Formation: map[string]ProcessSpec{
+			"web": {Quantity: 2, Size: "2X"},
+		},
+	}
+
+	spec, err := ParseAppSpec([]byte(`name: acme-inc
+image: remind101/acme-inc:v42
+config:
+  RAILS_ENV: production
+domains:
+  - acme.com
+formation:
+  web:
+    quantity: 2
+    size: 2X
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, spec)
+
+	spec, err = ParseAppSpec([]byte(`{"name": "acme-inc", "image": "remind101/acme-inc:v42", "config": {"RAILS_ENV": "production"}, "domains": ["acme.com"], "formation": {"web": {"quantity": 2, "size": "2X"}}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, spec)
+}
+
+func TestAppSpec_IsValid(t *testing.T) {
+	tests := []struct {
+		spec AppSpec
+		err  bool
+	}{
+		{AppSpec{Name: "acme-inc"}, false},
+		{AppSpec{Name: "acme-inc", Image: "remind101/acme-inc:v42"}, false},
+		{AppSpec{}, true},
+		{AppSpec{Name: "Acme"}, true},
+		{AppSpec{Name: "acme-inc", Domains: []string{""}}, true},
+		{AppSpec{Name: "acme-inc", ConfigRules: ConfigRules{"RAILS_ENV": {Enum: []string{"production", "staging"}}}}, false},
+		{AppSpec{Name: "acme-inc", ConfigRules: ConfigRules{"": {Required: true}}}, true},
+		{AppSpec{Name: "acme-inc", Formation: map[string]ProcessSpec{"web": {Quantity: 1, Size: "huge"}}}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.spec.IsValid()
+		if tt.err {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestAppSpec_plan(t *testing.T) {
+	production, debug := "production", "1"
+	img := image.Image{Repository: "remind101/acme-inc", Tag: "v42"}
+
+	tests := []struct {
+		spec    AppSpec
+		state   appState
+		changes []string
+	}{
+		// New apps get everything in the spec.
+		{
+			AppSpec{
+				Name:      "acme-inc",
+				Image:     "remind101/acme-inc:v42",
+				Config:    map[string]string{"RAILS_ENV": "production"},
+				Domains:   []string{"acme.com"},
+				Formation: map[string]ProcessSpec{"web": {Quantity: 2}},
+			},
+			appState{},
+			[]string{"set RAILS_ENV", "deploy remind101/acme-inc:v42", "add domain acme.com", "scale web=2"},
+		},
+
+		// Nothing changes when the app matches the spec.
+		{
+			AppSpec{
+				Name:      "acme-inc",
+				Image:     "remind101/acme-inc:v42",
+				Config:    map[string]string{"RAILS_ENV": "production"},
+				Domains:   []string{"acme.com"},
+				Formation: map[string]ProcessSpec{"web": {Quantity: 2, Size: "1X"}},
+			},
+			appState{
+				Config:    Vars{"RAILS_ENV": &production},
+				Domains:   []string{"acme.com"},
+				Image:     img.String(),
+				Formation: Formation{"web": Process{Quantity: 2, Memory: Constraints1X.Memory, CPUShare: Constraints1X.CPUShare, Nproc: Constraints1X.Nproc}},
+			},
+			nil,
+		},
+
+		// Parts of the spec that are omitted aren't managed.
+		{
+			AppSpec{Name: "acme-inc"},
+			appState{
+				Config:  Vars{"DEBUG": &debug},
+				Domains: []string{"acme.com"},
+				Image:   img.String(),
+			},
+			nil,
+		},
+
+		// Config vars and domains that aren't in the spec are removed.
+		{
+			AppSpec{Name: "acme-inc", Config: map[string]string{}, Domains: []string{}},
+			appState{
+				Config:  Vars{"DEBUG": &debug},
+				Domains: []string{"acme.com"},
+			},
+			[]string{"unset DEBUG", "remove domain acme.com"},
+		},
+
+		// A tag that was resolved to a digest when it was applied isn't
+		// deployed again.
+		{
+			AppSpec{Name: "acme-inc", Image: "remind101/acme-inc:v42"},
+			appState{
+				Image:            "remind101/acme-inc@sha256:c6f77d2098bc0e32aef3102e71b51831a9083dd9356a0ccadca860596a1e9007",
+				AppliedImage:     "remind101/acme-inc:v42",
+				AppliedSlugImage: "remind101/acme-inc@sha256:c6f77d2098bc0e32aef3102e71b51831a9083dd9356a0ccadca860596a1e9007",
+			},
+			nil,
+		},
+
+		// Unless something else has been deployed since.
+		{
+			AppSpec{Name: "acme-inc", Image: "remind101/acme-inc:v42"},
+			appState{
+				Image:            "remind101/acme-inc:v43",
+				AppliedImage:     "remind101/acme-inc:v42",
+				AppliedSlugImage: "remind101/acme-inc@sha256:c6f77d2098bc0e32aef3102e71b51831a9083dd9356a0ccadca860596a1e9007",
+			},
+			[]string{"deploy remind101/acme-inc:v42"},
+		},
+
+		// Changing only the size keeps the quantity.
+		{
+			AppSpec{Name: "acme-inc", Formation: map[string]ProcessSpec{"web": {Quantity: 2, Size: "2X"}}},
+			appState{
+				Formation: Formation{"web": Process{Quantity: 2, Memory: Constraints1X.Memory, CPUShare: Constraints1X.CPUShare, Nproc: Constraints1X.Nproc}},
+			},
+			[]string{"scale web=2:2X"},
+		},
+
+		// Redacted config vars keep their current value.
+		{
+			AppSpec{Name: "acme-inc", Config: map[string]string{"RAILS_ENV": "production", "DEBUG": RedactedValue}},
+			appState{
+				Config: Vars{"RAILS_ENV": &production, "DEBUG": &debug},
+			},
+			nil,
+		},
+
+		// Sensitive config vars are never compared with the spec.
+		{
+			AppSpec{Name: "acme-inc", Config: map[string]string{"RAILS_ENV": "production", "DEBUG": "1"}},
+			appState{
+				Config:        Vars{"RAILS_ENV": &production, "DEBUG": &debug},
+				SensitiveVars: SensitiveVars{"DEBUG"},
+			},
+			[]string{"may change DEBUG"},
+		},
+
+		// Config rules are set when they differ.
+		{
+			AppSpec{Name: "acme-inc", ConfigRules: ConfigRules{"DATABASE_URL": {Required: true, URL: true}}},
+			appState{},
+			[]string{"set config rules"},
+		},
+		{
+			AppSpec{Name: "acme-inc", ConfigRules: ConfigRules{"DATABASE_URL": {Required: true, URL: true}}},
+			appState{ConfigRules: ConfigRules{"DATABASE_URL": {Required: true, URL: true}}},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		p, err := tt.spec.plan(tt.state)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.changes, p.Changes())
+	}
+}
+
+func TestAppSpecPlan_vars(t *testing.T) {
+	current, guess := "hunter2", "hunter3"
+	state := appState{
+		Config:        Vars{"PASSWORD": &current},
+		SensitiveVars: SensitiveVars{"PASSWORD"},
+	}
+
+	p, err := (&AppSpec{Name: "acme-inc", Config: map[string]string{"PASSWORD": current}}).plan(state)
+	assert.NoError(t, err)
+	assert.Nil(t, p.vars(state))
+
+	p, err = (&AppSpec{Name: "acme-inc", Config: map[string]string{"PASSWORD": guess}}).plan(state)
+	assert.NoError(t, err)
+	assert.Equal(t, Vars{"PASSWORD": &guess}, p.vars(state))
+	assert.Equal(t, []string{"may change PASSWORD"}, p.Changes())
+}
+
+func TestAppSpecPlan_destructive(t *testing.T) {
+	production := "production"
+	f := Formation{"web": Process{Quantity: 2}}
+
+	tests := []struct {
+		plan        appSpecPlan
+		destructive bool
+	}{
+		{appSpecPlan{Vars: Vars{"RAILS_ENV": &production}}, false},
+		{appSpecPlan{Vars: Vars{"DEBUG": nil}}, true},
+		{appSpecPlan{Scale: []*ProcessUpdate{{Process: "web", Quantity: 3}}}, false},
+		{appSpecPlan{Scale: []*ProcessUpdate{{Process: "web", Quantity: 1}}}, true},
+		{appSpecPlan{Scale: []*ProcessUpdate{{Process: "worker", Quantity: 0}}}, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.destructive, tt.plan.destructive(f))
+	}
+}
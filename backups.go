@@ -0,0 +1,328 @@
+package empire
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/remind101/pkg/reporter"
+	"golang.org/x/net/context"
+)
+
+// BackupVersion is the version of the Backup format that this version of
+// Empire writes, and can restore.
+const BackupVersion = 1
+
+// ErrBackupVersion is returned when restoring a Backup with a version that
+// isn't supported.
+var ErrBackupVersion = errors.New("the backup was created by an unsupported version of Empire")
+
+// BackupTables are the tables that are included in a Backup, in the order
+// that they're restored, so that rows are inserted after the rows that they
+// reference. Only schema_migrations isn't included, since a backup can only
+// be restored into a database with the same schema.
+var BackupTables = []string{
+	"apps",
+	"slugs",
+	"configs",
+	"releases",
+	"batches",
+	"jobs",
+	"activities",
+	"api_tokens",
+	"certificates",
+	"chaos_kills",
+	"cron_runs",
+	"domains",
+	"ecs_environment",
+	"freeze_windows",
+	"grants",
+	"idempotency_keys",
+	"ingress_rules",
+	"namespaces",
+	"ports",
+	"quotas",
+	"registry_credentials",
+	"routing_rules",
+	"scheduler_migration",
+	"stacks",
+	"task_transitions",
+	"team_members",
+	"two_factor_secrets",
+	"usage_periods",
+}
+
+// Backup is a snapshot of the complete state of Empire: the rows of every
+// table, taken in a single transaction. Backups include secrets, like config
+// vars and API tokens, so they need to be stored as carefully as the
+// database.
+type Backup struct {
+	// The version of the format of the backup.
+	Version int `json:"version"`
+
+	// The version of the database schema that the backup was taken from.
+	SchemaVersion int `json:"schema_version"`
+
+	// The time that the backup was taken.
+	CreatedAt time.Time `json:"created_at"`
+
+	// The rows of each table, as a JSON array of objects.
+	Tables map[string]json.RawMessage `json:"tables"`
+}
+
+// BackupStore stores backups, by name.
+type BackupStore interface {
+	// Put stores a backup.
+	Put(ctx context.Context, name string, b []byte) error
+
+	// Get returns a backup.
+	Get(ctx context.Context, name string) ([]byte, error)
+
+	// List returns the names of the backups that are stored.
+	List(ctx context.Context) ([]string, error)
+
+	// Delete removes a backup.
+	Delete(ctx context.Context, name string) error
+}
+
+// Snapshot returns a Backup of every table. The tables are read in a read only
+// transaction, so the backup is consistent even when changes are being made.
+func (db *DB) Snapshot() (*Backup, error) {
+	schemaVersion, err := db.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Backup{
+		Version:       BackupVersion,
+		SchemaVersion: schemaVersion,
+		CreatedAt:     timex.Now(),
+		Tables:        make(map[string]json.RawMessage),
+	}
+
+	tx := db.Begin()
+	defer tx.Rollback()
+
+	if err := tx.Exec(`SET TRANSACTION ISOLATION LEVEL REPEATABLE READ READ ONLY`).Error; err != nil {
+		return nil, err
+	}
+
+	for _, table := range BackupTables {
+		var rows []byte
+		if err := tx.Raw(fmt.Sprintf(`SELECT coalesce(json_agg(t), '[]') FROM %s t`, table)).Row().Scan(&rows); err != nil {
+			return nil, fmt.Errorf("error backing up %s: %v", table, err)
+		}
+		b.Tables[table] = json.RawMessage(rows)
+	}
+
+	return b, nil
+}
+
+// Restore replaces the rows of every table with the rows in the backup, in a
+// single transaction. The backup must have been taken from a database with the
+// same schema version.
+func (db *DB) Restore(b *Backup) error {
+	if b.Version != BackupVersion {
+		return ErrBackupVersion
+	}
+
+	if expected := db.schema().latestSchema(); b.SchemaVersion != expected {
+		return &IncompatibleSchemaError{
+			SchemaVersion:         b.SchemaVersion,
+			ExpectedSchemaVersion: expected,
+		}
+	}
+
+	tx := db.Begin()
+
+	if err := tx.Exec(fmt.Sprintf(`TRUNCATE TABLE %s CASCADE`, strings.Join(BackupTables, ", "))).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, table := range BackupTables {
+		rows, ok := b.Tables[table]
+		if !ok {
+			continue
+		}
+		if err := tx.Exec(fmt.Sprintf(`INSERT INTO %s SELECT * FROM json_populate_recordset(NULL::%s, ?)`, table, table), string(rows)).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error restoring %s: %v", table, err)
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// backupName returns the name of a backup taken at t. Names sort in the order
+// that the backups were taken.
+func backupName(t time.Time) string {
+	return fmt.Sprintf("empire-%s.json", t.UTC().Format("20060102T150405Z"))
+}
+
+// Backup takes a Backup, and stores it. It returns the name of the backup.
+func (e *Empire) Backup(ctx context.Context, store BackupStore) (string, error) {
+	b, err := e.DB.Snapshot()
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return "", err
+	}
+
+	name := backupName(b.CreatedAt)
+	return name, store.Put(ctx, name, raw)
+}
+
+// RestoreOpts are options provided when restoring a backup.
+type RestoreOpts struct {
+	// The backup store, and the name of the backup to restore.
+	Store BackupStore
+	Name  string
+}
+
+// Restore restores the database from a backup, and then reconciles the cluster
+// with it: apps that aren't in the backup are removed from the scheduler, and
+// every app in the backup is released again, so that the processes that run
+// are the ones in the backup. It returns a description of each change that was
+// made. An app that fails to be released doesn't stop the others from being
+// released.
+func (e *Empire) Restore(ctx context.Context, opts RestoreOpts) ([]string, error) {
+	raw, err := opts.Store.Get(ctx, opts.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var b Backup
+	if err := json.Unmarshal(raw, &b); err != nil {
+		return nil, fmt.Errorf("error parsing backup %s: %v", opts.Name, err)
+	}
+
+	before, err := apps(e.db, AppsQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.DB.Restore(&b); err != nil {
+		return nil, err
+	}
+
+	changes := []string{fmt.Sprintf("restore database from %s", opts.Name)}
+
+	after, err := apps(e.db, AppsQuery{})
+	if err != nil {
+		return changes, err
+	}
+
+	restored := make(map[string]bool)
+	for _, app := range after {
+		restored[app.ID] = true
+	}
+
+	var failed []string
+	for _, app := range before {
+		if restored[app.ID] {
+			continue
+		}
+		if err := e.removeFromScheduler(ctx, app); err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%v)", app.Name, err))
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("remove %s", app.Name))
+	}
+
+	for _, app := range after {
+		if err := e.releases.ReleaseApp(ctx, e.db, app, nil); err != nil {
+			if err == ErrNoReleases {
+				continue
+			}
+			failed = append(failed, fmt.Sprintf("%s (%v)", app.Name, err))
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("release %s", app.Name))
+	}
+
+	if len(failed) > 0 {
+		return changes, fmt.Errorf("the cluster couldn't be reconciled with %d apps: %s", len(failed), strings.Join(failed, ", "))
+	}
+
+	return changes, nil
+}
+
+// removeFromScheduler removes an app that isn't in the database anymore from
+// its scheduler.
+func (e *Empire) removeFromScheduler(ctx context.Context, app *App) error {
+	scheduler, err := e.scheduler(app)
+	if err != nil {
+		return err
+	}
+	return scheduler.Remove(ctx, app.ID)
+}
+
+// Backuper periodically takes a backup, and stores it, keeping a number of the
+// most recent backups.
+type Backuper struct {
+	*Empire
+
+	// Where backups are stored.
+	Store BackupStore
+
+	// How often to take a backup.
+	Interval time.Duration
+
+	// The number of backups to keep. Older backups are deleted. The zero
+	// value keeps every backup.
+	Retain int
+}
+
+// Start takes backups, until the context is canceled. Errors, and panics, are
+// reported to the reporter in the context.
+func (b *Backuper) Start(ctx context.Context) {
+	defer reporter.Monitor(ctx)
+
+	ticker := time.NewTicker(b.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.Run(ctx); err != nil {
+				reporter.Report(ctx, err)
+			}
+		}
+	}
+}
+
+// Run takes a backup, and deletes the backups that aren't retained.
+func (b *Backuper) Run(ctx context.Context) error {
+	if _, err := b.Backup(ctx, b.Store); err != nil {
+		return fmt.Errorf("error taking backup: %v", err)
+	}
+
+	if b.Retain <= 0 {
+		return nil
+	}
+
+	names, err := b.Store.List(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for len(names) > b.Retain {
+		if err := b.Store.Delete(ctx, names[0]); err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+// Package backups provides implementations of the empire.BackupStore interface.
+package backups
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"golang.org/x/net/context"
+)
+
+// s3Client duck types the s3.S3 interface that we use.
+type s3Client interface {
+	PutObject(*s3.PutObjectInput) (*s3.PutObjectOutput, error)
+	GetObject(*s3.GetObjectInput) (*s3.GetObjectOutput, error)
+	ListObjectsPages(*s3.ListObjectsInput, func(*s3.ListObjectsOutput, bool) bool) error
+	DeleteObject(*s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error)
+}
+
+// S3Store is an empire.BackupStore that stores backups as objects in an S3
+// bucket. Backups contain secrets, so objects are encrypted at rest.
+type S3Store struct {
+	// The bucket that backups are stored in.
+	Bucket string
+
+	// If provided, a prefix for the keys of the objects, which usually
+	// ends with a / (e.g. "backups/").
+	Prefix string
+
+	s3 s3Client
+}
+
+// StoreInS3 returns an S3Store that stores backups in the bucket.
+func StoreInS3(bucket, prefix string, config client.ConfigProvider) *S3Store {
+	return &S3Store{
+		Bucket: bucket,
+		Prefix: prefix,
+		s3:     s3.New(config),
+	}
+}
+
+func (s *S3Store) Put(ctx context.Context, name string, b []byte) error {
+	_, err := s.s3.PutObject(&s3.PutObjectInput{
+		Bucket:               aws.String(s.Bucket),
+		Key:                  aws.String(s.key(name)),
+		Body:                 bytes.NewReader(b),
+		ContentType:          aws.String("application/json"),
+		ServerSideEncryption: aws.String(s3.ServerSideEncryptionAes256),
+	})
+	return err
+}
+
+func (s *S3Store) Get(ctx context.Context, name string) ([]byte, error) {
+	resp, err := s.s3.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(name)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (s *S3Store) List(ctx context.Context) ([]string, error) {
+	var names []string
+	err := s.s3.ListObjectsPages(&s3.ListObjectsInput{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(s.Prefix),
+	}, func(p *s3.ListObjectsOutput, lastPage bool) bool {
+		for _, o := range p.Contents {
+			name := strings.TrimPrefix(aws.StringValue(o.Key), s.Prefix)
+			// Only objects directly under the prefix are backups.
+			if name != "" && !strings.Contains(name, "/") {
+				names = append(names, name)
+			}
+		}
+		return true
+	})
+	return names, err
+}
+
+func (s *S3Store) Delete(ctx context.Context, name string) error {
+	_, err := s.s3.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(name)),
+	})
+	return err
+}
+
+func (s *S3Store) key(name string) string {
+	return s.Prefix + name
+}
@@ -0,0 +1,81 @@
+package backups
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestS3Store(t *testing.T) {
+	c := &fakeS3{objects: make(map[string][]byte)}
+	s := &S3Store{Bucket: "empire", Prefix: "backups/", s3: c}
+	ctx := context.Background()
+
+	assert.NoError(t, s.Put(ctx, "empire-20161001T000000Z.json", []byte(`{"version":1}`)))
+	assert.NoError(t, s.Put(ctx, "empire-20161002T000000Z.json", []byte(`{"version":1}`)))
+	c.objects["backups/old/empire-20160101T000000Z.json"] = nil
+	c.objects["other/empire-20160101T000000Z.json"] = nil
+
+	b, err := s.Get(ctx, "empire-20161001T000000Z.json")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"version":1}`, string(b))
+
+	names, err := s.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"empire-20161001T000000Z.json", "empire-20161002T000000Z.json"}, names)
+
+	assert.NoError(t, s.Delete(ctx, "empire-20161001T000000Z.json"))
+	names, err = s.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"empire-20161002T000000Z.json"}, names)
+}
+
+type fakeS3 struct {
+	objects map[string][]byte
+}
+
+func (c *fakeS3) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	b, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.objects[*input.Key] = b
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (c *fakeS3) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	return &s3.GetObjectOutput{
+		Body: ioutil.NopCloser(bytes.NewReader(c.objects[*input.Key])),
+	}, nil
+}
+
+func (c *fakeS3) ListObjectsPages(input *s3.ListObjectsInput, fn func(*s3.ListObjectsOutput, bool) bool) error {
+	// One object per page, in order.
+	var keys []string
+	for k := range c.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for i, k := range keys {
+		if !strings.HasPrefix(k, *input.Prefix) {
+			continue
+		}
+		if !fn(&s3.ListObjectsOutput{Contents: []*s3.Object{{Key: aws.String(k)}}}, i == len(keys)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (c *fakeS3) DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
+	delete(c.objects, *input.Key)
+	return &s3.DeleteObjectOutput{}, nil
+}
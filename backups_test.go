@@ -0,0 +1,31 @@
+package empire
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupName(t *testing.T) {
+	t1 := time.Date(2016, 9, 30, 23, 59, 59, 0, time.UTC)
+	t2 := time.Date(2016, 10, 1, 0, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+
+	assert.Equal(t, "empire-20160930T235959Z.json", backupName(t1))
+	assert.Equal(t, "empire-20161001T050000Z.json", backupName(t2))
+
+	names := []string{backupName(t2), backupName(t1)}
+	sort.Strings(names)
+	assert.Equal(t, []string{backupName(t1), backupName(t2)}, names)
+}
+
+func TestDB_Restore_Version(t *testing.T) {
+	db := &DB{}
+
+	err := db.Restore(&Backup{Version: 2})
+	assert.Equal(t, ErrBackupVersion, err)
+
+	err = db.Restore(&Backup{Version: BackupVersion, SchemaVersion: 1})
+	assert.IsType(t, &IncompatibleSchemaError{}, err)
+}
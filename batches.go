@@ -0,0 +1,265 @@
+package empire
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/timex"
+)
+
+// MaxBatchJobs is the maximum number of jobs that can be submitted in a single
+// batch.
+const MaxBatchJobs = 1000
+
+var (
+	// ErrBatchEmpty is returned when a batch doesn't have any commands to
+	// run.
+	ErrBatchEmpty = &ValidationError{
+		errors.New("A batch needs at least one command to run."),
+	}
+
+	// ErrBatchTooLarge is returned when a batch has more commands than
+	// MaxBatchJobs.
+	ErrBatchTooLarge = &ValidationError{
+		fmt.Errorf("A batch can have at most %d commands.", MaxBatchJobs),
+	}
+
+	// ErrBatchParallelism is returned when a batch has an invalid
+	// parallelism.
+	ErrBatchParallelism = &ValidationError{
+		errors.New("Parallelism must be at least 1."),
+	}
+)
+
+// Batch is a group of one-off jobs for an app, that are queued together and
+// run up to Parallelism at a time. Jobs are run in the order that they were
+// submitted, once there's room for them in the cluster of the app, and the exit
+// code of each one is recorded once it finishes.
+type Batch struct {
+	// A unique uuid that identifies the batch.
+	ID string
+
+	// The id of the app that the batch runs in.
+	AppID string
+
+	// The maximum number of jobs in the batch that can run at once.
+	Parallelism int
+
+	// The user that submitted the batch.
+	CreatedBy string
+
+	// The time that the batch was submitted.
+	CreatedAt *time.Time
+
+	// The jobs in the batch, in the order that they were submitted.
+	Jobs []*Job `sql:"-"`
+}
+
+// IsValid returns an error if the batch isn't valid.
+func (b *Batch) IsValid() error {
+	if b.Parallelism < 1 {
+		return ErrBatchParallelism
+	}
+	return nil
+}
+
+// BeforeCreate sets created_at before inserting.
+func (b *Batch) BeforeCreate() error {
+	t := timex.Now()
+	b.CreatedAt = &t
+	return b.IsValid()
+}
+
+// Status returns the number of jobs in the batch in each state.
+func (b *Batch) Status() map[string]int {
+	status := make(map[string]int)
+	for _, j := range b.Jobs {
+		status[j.State]++
+	}
+	return status
+}
+
+// Finished returns true once all of the jobs in the batch have finished.
+func (b *Batch) Finished() bool {
+	for _, j := range b.Jobs {
+		if !j.Finished() {
+			return false
+		}
+	}
+	return true
+}
+
+// FinishedAt returns the time that the last job in the batch finished.
+func (b *Batch) FinishedAt() time.Time {
+	var t time.Time
+	if b.CreatedAt != nil {
+		t = *b.CreatedAt
+	}
+	for _, j := range b.Jobs {
+		if j.FinishedAt != nil && j.FinishedAt.After(t) {
+			t = *j.FinishedAt
+		}
+	}
+	return t
+}
+
+// BatchesQuery is a scope implementation for common things to filter batches
+// by.
+type BatchesQuery struct {
+	// If provided, finds the batch with the given id.
+	ID *string
+
+	// If provided, finds batches for the given app.
+	App *App
+}
+
+// scope implements the scope interface.
+func (q BatchesQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.ID != nil {
+		scope = append(scope, idEquals(*q.ID))
+	}
+
+	if q.App != nil {
+		scope = append(scope, forApp(q.App))
+	}
+
+	scope = append(scope, order("created_at desc"))
+
+	return scope.scope(db)
+}
+
+// batchesFind returns the first matching batch, with its jobs.
+func batchesFind(db *gorm.DB, scope scope) (*Batch, error) {
+	var batch Batch
+	if err := first(db, scope, &batch); err != nil {
+		return &batch, err
+	}
+	return &batch, batchJobs(db, &batch)
+}
+
+// batches returns all batches matching the scope, most recent first, with
+// their jobs.
+func batches(db *gorm.DB, scope scope) ([]*Batch, error) {
+	var batches []*Batch
+	if err := find(db, scope, &batches); err != nil {
+		return batches, err
+	}
+	for _, b := range batches {
+		if err := batchJobs(db, b); err != nil {
+			return batches, err
+		}
+	}
+	return batches, nil
+}
+
+// batchJobs loads the jobs in the batch.
+func batchJobs(db *gorm.DB, b *Batch) error {
+	js, err := jobs(db, JobsQuery{Batch: b})
+	b.Jobs = js
+	return err
+}
+
+// batchesCreate inserts the batch, and queues its jobs.
+func batchesCreate(db *gorm.DB, batch *Batch) (*Batch, error) {
+	if err := db.Create(batch).Error; err != nil {
+		return batch, err
+	}
+	for _, j := range batch.Jobs {
+		j.AppID = batch.AppID
+		j.BatchID = &batch.ID
+		j.CreatedBy = batch.CreatedBy
+		if _, err := jobsCreate(db, j); err != nil {
+			return batch, err
+		}
+	}
+	return batch, nil
+}
+
+// batchesDestroy removes the batch, along with its jobs.
+func batchesDestroy(db *gorm.DB, batch *Batch) error {
+	return db.Delete(batch).Error
+}
+
+// BatchesCreateOpts are options provided when submitting a batch of jobs.
+type BatchesCreateOpts struct {
+	// User performing this action.
+	User *User
+
+	// Related app.
+	App *App
+
+	// The commands to run, one job for each. Like `emp run`, a command that
+	// starts with the name of a process in the Procfile runs as that
+	// process.
+	Commands []Command
+
+	// The maximum number of jobs that can run at once.
+	Parallelism int
+
+	// If provided, the size to run each job with.
+	Constraints *Constraints
+
+	// Commit message
+	Message string
+}
+
+func (opts BatchesCreateOpts) Event() BatchEvent {
+	return BatchEvent{
+		User:        opts.User.Name,
+		App:         opts.App.Name,
+		Jobs:        len(opts.Commands),
+		Parallelism: opts.Parallelism,
+		Message:     opts.Message,
+		app:         opts.App,
+	}
+}
+
+func (opts BatchesCreateOpts) Validate(e *Empire) error {
+	if err := e.authorize(opts.User, opts.App, ActionRun); err != nil {
+		return err
+	}
+	if len(opts.Commands) == 0 {
+		return ErrBatchEmpty
+	}
+	if len(opts.Commands) > MaxBatchJobs {
+		return ErrBatchTooLarge
+	}
+	if opts.Parallelism < 1 {
+		return ErrBatchParallelism
+	}
+	return e.requireMessages(opts.Message)
+}
+
+// batchJob returns the job that runs the command, using the formation of the
+// release in the same way that Run does.
+func (e *Empire) batchJob(release *Release, command Command, c *Constraints) (*Job, error) {
+	if len(command) == 0 {
+		return nil, ErrBatchEmpty
+	}
+
+	job := &Job{Type: command[0]}
+	constraints := DefaultConstraints
+	if p, ok := release.Formation[command[0]]; ok {
+		job.Command = append(append(Command{}, p.Command...), command[1:]...)
+		constraints = p.Constraints()
+	} else {
+		if e.AllowedCommands == AllowCommandProcfile {
+			return nil, commandNotInFormation(Command{command[0]}, release.Formation)
+		}
+		job.Type = GenericProcessName
+		job.Command = command
+	}
+
+	if c != nil {
+		constraints = *c
+	}
+	job.CPUShare = constraints.CPUShare
+	job.Memory = constraints.Memory
+	job.Nproc = constraints.Nproc
+
+	return job, nil
+}
@@ -0,0 +1,78 @@
+package empire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatch_Status(t *testing.T) {
+	b := &Batch{Jobs: []*Job{
+		{State: JobStateQueued},
+		{State: JobStateRunning},
+		{State: JobStateSucceeded},
+		{State: JobStateSucceeded},
+		{State: JobStateFailed},
+	}}
+	assert.Equal(t, map[string]int{
+		JobStateQueued:    1,
+		JobStateRunning:   1,
+		JobStateSucceeded: 2,
+		JobStateFailed:    1,
+	}, b.Status())
+	assert.False(t, b.Finished())
+
+	b.Jobs[0].State = JobStateLost
+	b.Jobs[1].State = JobStateSucceeded
+	assert.True(t, b.Finished())
+}
+
+func TestJob_Finish(t *testing.T) {
+	at := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	j := &Job{State: JobStateRunning}
+	j.finish(0, at)
+	assert.Equal(t, JobStateSucceeded, j.State)
+	assert.Equal(t, 0, *j.ExitCode)
+	assert.Equal(t, at, *j.FinishedAt)
+
+	j = &Job{State: JobStateRunning}
+	j.finish(137, at)
+	assert.Equal(t, JobStateFailed, j.State)
+	assert.Equal(t, 137, *j.ExitCode)
+}
+
+func TestEmpire_BatchJob(t *testing.T) {
+	release := &Release{
+		Formation: Formation{
+			"backfill": Process{
+				Command: Command{"./bin/backfill"},
+				Memory:  constraints.Memory(1024),
+			},
+		},
+	}
+
+	e := &Empire{}
+	size := Constraints{CPUShare: 512, Memory: 2048, Nproc: 0}
+	tests := []struct {
+		command     Command
+		constraints *Constraints
+		job         *Job
+	}{
+		{Command{"backfill", "--shard", "1"}, nil, &Job{Type: "backfill", Command: Command{"./bin/backfill", "--shard", "1"}, Memory: 1024}},
+		{Command{"backfill"}, &size, &Job{Type: "backfill", Command: Command{"./bin/backfill"}, CPUShare: 512, Memory: 2048}},
+		{Command{"echo", "hello"}, nil, &Job{Type: "run", Command: Command{"echo", "hello"}, CPUShare: DefaultConstraints.CPUShare, Memory: DefaultConstraints.Memory, Nproc: DefaultConstraints.Nproc}},
+	}
+
+	for _, tt := range tests {
+		job, err := e.batchJob(release, tt.command, tt.constraints)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.job, job)
+	}
+
+	e.AllowedCommands = AllowCommandProcfile
+	_, err := e.batchJob(release, Command{"echo", "hello"}, nil)
+	assert.IsType(t, &CommandNotAllowedError{}, err)
+}
@@ -0,0 +1,262 @@
+package empire
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/remind101/pkg/reporter"
+	"golang.org/x/net/context"
+)
+
+// ErrBlobNotFound is returned by a BlobStore when there's no blob with the key.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// ErrNoDeployLog is returned when there's no stored log for a release, either
+// because it wasn't created by a deploy, or because the log has expired.
+var ErrNoDeployLog = errors.New("no deploy log was stored for this release")
+
+// The prefixes of the keys of the artifacts that Empire stores in a
+// BlobStore.
+const (
+	// The output of each deploy, by app and release.
+	BlobPrefixDeployLogs = "deploys/"
+
+	// The captured output of one-off processes that have finished, by app
+	// and output id.
+	BlobPrefixOutputs = "outputs/"
+
+	// Backups of the database.
+	BlobPrefixBackups = "backups/"
+)
+
+// Blob describes an object in a BlobStore.
+type Blob struct {
+	// The key of the blob (e.g. "deploys/<app id>/v1.log").
+	Key string
+
+	// The size of the blob, in bytes.
+	Size int64
+
+	// The time that the blob was last written.
+	ModifiedAt time.Time
+}
+
+// BlobStore stores artifacts that don't belong in the database, like deploy
+// logs, the output of one-off processes, and backups, by key. Keys are
+// slash separated paths.
+type BlobStore interface {
+	// Put stores a blob, replacing the blob with the same key.
+	Put(ctx context.Context, key string, b []byte) error
+
+	// Get returns a blob, or ErrBlobNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// List returns the blobs with keys that start with prefix.
+	List(ctx context.Context, prefix string) ([]*Blob, error)
+
+	// Delete removes a blob.
+	Delete(ctx context.Context, key string) error
+}
+
+// deployLogKey returns the key of the log of the deploy that created the
+// release.
+func deployLogKey(appID string, version int) string {
+	return fmt.Sprintf("%s%s/v%d.log", BlobPrefixDeployLogs, appID, version)
+}
+
+// outputKey returns the key of the captured output of a one-off process.
+func outputKey(appID, id string) string {
+	return fmt.Sprintf("%s%s/%s.log", BlobPrefixOutputs, appID, id)
+}
+
+// storeDeployLog stores the output of the deploy that created the release. The
+// release has already been created, so errors are reported, rather than
+// returned.
+func (e *Empire) storeDeployLog(ctx context.Context, r *Release, b []byte) {
+	if err := e.Blobs.Put(ctx, deployLogKey(r.AppID, r.Version), b); err != nil {
+		reporter.Report(ctx, fmt.Errorf("error storing deploy log of %s v%d: %v", r.AppID, r.Version, err))
+	}
+}
+
+// DeployLogOpts are options provided when retrieving the log of a deploy.
+type DeployLogOpts struct {
+	// Related app.
+	App *App
+
+	// The version of the release that the deploy created.
+	Version int
+
+	// Where the log is written to.
+	Output io.Writer
+}
+
+// DeployLog writes the output of the deploy that created a release, in
+// jsonmessage format.
+func (e *Empire) DeployLog(ctx context.Context, opts DeployLogOpts) error {
+	if e.Blobs == nil {
+		return ErrBlobsDisabled
+	}
+
+	b, err := e.Blobs.Get(ctx, deployLogKey(opts.App.ID, opts.Version))
+	if err == ErrBlobNotFound {
+		return ErrNoDeployLog
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = opts.Output.Write(b)
+	return err
+}
+
+// archiveOutput copies the captured output of a one-off process that has
+// finished to the BlobStore, so that it outlives the retention of the
+// OutputStore.
+func (e *Empire) archiveOutput(ctx context.Context, app *App, id string) error {
+	var buf bytes.Buffer
+	if err := e.Outputs.Output(ctx, app, id, &buf); err != nil {
+		if err == ErrNoOutput {
+			return nil
+		}
+		return err
+	}
+
+	return e.Blobs.Put(ctx, outputKey(app.ID, id), buf.Bytes())
+}
+
+// archivedOutput writes the archived output of a one-off process to w. It
+// returns ErrNoOutput if the output hasn't been archived.
+func (e *Empire) archivedOutput(ctx context.Context, app *App, id string, w io.Writer) error {
+	b, err := e.Blobs.Get(ctx, outputKey(app.ID, id))
+	if err == ErrBlobNotFound {
+		return ErrNoOutput
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+// DefaultBlobExpireInterval is how often the BlobExpirer looks for expired
+// blobs.
+const DefaultBlobExpireInterval = time.Hour
+
+// BlobLifecycleRule expires the blobs with keys that start with Prefix, once
+// they're older than MaxAge.
+type BlobLifecycleRule struct {
+	Prefix string
+	MaxAge time.Duration
+}
+
+// BlobExpirer periodically deletes the blobs that have expired, according to
+// a set of lifecycle rules.
+type BlobExpirer struct {
+	// Where blobs are stored.
+	Store BlobStore
+
+	// The lifecycle rules. Blobs that don't match a rule are kept.
+	Rules []BlobLifecycleRule
+
+	// How often to look for expired blobs.
+	Interval time.Duration
+}
+
+// Start deletes expired blobs, until the context is canceled. Errors, and
+// panics, are reported to the reporter in the context.
+func (x *BlobExpirer) Start(ctx context.Context) {
+	defer reporter.Monitor(ctx)
+
+	ticker := time.NewTicker(x.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := x.Run(ctx); err != nil {
+				reporter.Report(ctx, err)
+			}
+		}
+	}
+}
+
+// Run deletes the blobs that have expired.
+func (x *BlobExpirer) Run(ctx context.Context) error {
+	now := timex.Now()
+
+	var errors []error
+	for _, rule := range x.Rules {
+		if rule.MaxAge <= 0 {
+			continue
+		}
+
+		blobs, err := x.Store.List(ctx, rule.Prefix)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		for _, b := range blobs {
+			if now.Sub(b.ModifiedAt) < rule.MaxAge {
+				continue
+			}
+			if err := x.Store.Delete(ctx, b.Key); err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return &multiError{Errors: errors}
+	}
+
+	return nil
+}
+
+// BackupsInBlobStore returns a BackupStore that stores backups in the
+// BlobStore, under BlobPrefixBackups.
+func BackupsInBlobStore(store BlobStore) BackupStore {
+	return &blobBackupStore{store}
+}
+
+// blobBackupStore is a BackupStore backed by a BlobStore.
+type blobBackupStore struct {
+	blobs BlobStore
+}
+
+func (s *blobBackupStore) Put(ctx context.Context, name string, b []byte) error {
+	return s.blobs.Put(ctx, BlobPrefixBackups+name, b)
+}
+
+func (s *blobBackupStore) Get(ctx context.Context, name string) ([]byte, error) {
+	return s.blobs.Get(ctx, BlobPrefixBackups+name)
+}
+
+func (s *blobBackupStore) List(ctx context.Context) ([]string, error) {
+	blobs, err := s.blobs.List(ctx, BlobPrefixBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, b := range blobs {
+		name := strings.TrimPrefix(b.Key, BlobPrefixBackups)
+		// Only blobs directly under the prefix are backups.
+		if name != "" && !strings.Contains(name, "/") {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func (s *blobBackupStore) Delete(ctx context.Context, name string) error {
+	return s.blobs.Delete(ctx, BlobPrefixBackups+name)
+}
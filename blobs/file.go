@@ -0,0 +1,102 @@
+package blobs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/remind101/empire"
+	"golang.org/x/net/context"
+)
+
+// FileStore is an empire.BlobStore that stores blobs as files in a directory
+// on local disk, which is only suitable when a single instance of Empire is
+// running, or the directory is shared (e.g. an NFS mount).
+type FileStore struct {
+	// The directory that blobs are stored in.
+	Dir string
+}
+
+// StoreOnDisk returns a FileStore that stores blobs in dir.
+func StoreOnDisk(dir string) *FileStore {
+	return &FileStore{Dir: dir}
+}
+
+func (s *FileStore) Put(ctx context.Context, key string, b []byte) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	// Written to a temporary file first, so that readers never see a
+	// partially written blob.
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".blob")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *FileStore) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, empire.ErrBlobNotFound
+	}
+	return b, err
+}
+
+func (s *FileStore) List(ctx context.Context, prefix string) ([]*empire.Blob, error) {
+	var blobs []*empire.Blob
+	err := filepath.Walk(s.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".blob") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.Dir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		blobs = append(blobs, &empire.Blob{
+			Key:        key,
+			Size:       info.Size(),
+			ModifiedAt: info.ModTime(),
+		})
+		return nil
+	})
+	return blobs, err
+}
+
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// path returns the path of the file that a blob is stored in. Keys are
+// cleaned, so that they can't refer to files outside of Dir.
+func (s *FileStore) path(key string) string {
+	return filepath.Join(s.Dir, filepath.Clean(string(filepath.Separator)+filepath.FromSlash(key)))
+}
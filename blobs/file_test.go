@@ -0,0 +1,60 @@
+package blobs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/remind101/empire"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "empire-blobs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := StoreOnDisk(dir)
+	ctx := context.Background()
+
+	// Nothing has been stored yet.
+	blobs, err := s.List(ctx, "deploys/")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(blobs))
+
+	assert.NoError(t, s.Put(ctx, "deploys/app/v1.log", []byte("v1")))
+	assert.NoError(t, s.Put(ctx, "deploys/app/v2.log", []byte("v2")))
+	assert.NoError(t, s.Put(ctx, "outputs/app/1234.log", []byte("output")))
+
+	b, err := s.Get(ctx, "deploys/app/v1.log")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(b))
+
+	_, err = s.Get(ctx, "deploys/app/v3.log")
+	assert.Equal(t, empire.ErrBlobNotFound, err)
+
+	blobs, err = s.List(ctx, "deploys/")
+	assert.NoError(t, err)
+	var keys []string
+	for _, b := range blobs {
+		keys = append(keys, b.Key)
+	}
+	sort.Strings(keys)
+	assert.Equal(t, []string{"deploys/app/v1.log", "deploys/app/v2.log"}, keys)
+
+	assert.NoError(t, s.Delete(ctx, "deploys/app/v1.log"))
+	assert.NoError(t, s.Delete(ctx, "deploys/app/v1.log"))
+	_, err = s.Get(ctx, "deploys/app/v1.log")
+	assert.Equal(t, empire.ErrBlobNotFound, err)
+}
+
+func TestFileStore_Path(t *testing.T) {
+	s := StoreOnDisk("/var/lib/empire")
+
+	// Keys can't refer to files outside of the directory.
+	assert.Equal(t, filepath.FromSlash("/var/lib/empire/etc/passwd"), s.path("../../../etc/passwd"))
+	assert.Equal(t, filepath.FromSlash("/var/lib/empire/deploys/app/v1.log"), s.path("deploys/app/v1.log"))
+}
@@ -0,0 +1,129 @@
+// Package blobs provides implementations of the empire.BlobStore interface.
+package blobs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/remind101/empire"
+	"golang.org/x/net/context"
+)
+
+// GCSEndpoint is the endpoint of the S3 compatible API of Google Cloud
+// Storage.
+const GCSEndpoint = "https://storage.googleapis.com"
+
+// s3Client duck types the s3.S3 interface that we use.
+type s3Client interface {
+	PutObject(*s3.PutObjectInput) (*s3.PutObjectOutput, error)
+	GetObject(*s3.GetObjectInput) (*s3.GetObjectOutput, error)
+	ListObjectsPages(*s3.ListObjectsInput, func(*s3.ListObjectsOutput, bool) bool) error
+	DeleteObject(*s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error)
+}
+
+// S3Store is an empire.BlobStore that stores blobs as objects in an S3
+// bucket, or any bucket with an S3 compatible API.
+type S3Store struct {
+	// The bucket that blobs are stored in.
+	Bucket string
+
+	// If provided, a prefix for the keys of the objects, which usually
+	// ends with a / (e.g. "empire/").
+	Prefix string
+
+	// When true, objects are encrypted at rest with S3 managed keys.
+	// Google Cloud Storage always encrypts objects at rest, and doesn't
+	// accept the header.
+	ServerSideEncryption bool
+
+	s3 s3Client
+}
+
+// StoreInS3 returns an S3Store that stores blobs in the bucket, encrypted at
+// rest.
+func StoreInS3(bucket, prefix string, config client.ConfigProvider) *S3Store {
+	return &S3Store{
+		Bucket:               bucket,
+		Prefix:               prefix,
+		ServerSideEncryption: true,
+		s3:                   s3.New(config),
+	}
+}
+
+// StoreInGCS returns an S3Store that stores blobs in a Google Cloud Storage
+// bucket, through its S3 compatible API. accessKeyID and secret are an HMAC
+// key of a service account that can read and write objects in the bucket.
+func StoreInGCS(bucket, prefix, accessKeyID, secret string, config client.ConfigProvider) *S3Store {
+	return &S3Store{
+		Bucket: bucket,
+		Prefix: prefix,
+		s3: s3.New(config, aws.NewConfig().
+			WithEndpoint(GCSEndpoint).
+			WithRegion("auto").
+			WithCredentials(credentials.NewStaticCredentials(accessKeyID, secret, ""))),
+	}
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, b []byte) error {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(key)),
+		Body:   bytes.NewReader(b),
+	}
+	if s.ServerSideEncryption {
+		input.ServerSideEncryption = aws.String(s3.ServerSideEncryptionAes256)
+	}
+	_, err := s.s3.PutObject(input)
+	return err
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.s3.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	if err != nil {
+		if err, ok := err.(awserr.Error); ok && err.Code() == s3.ErrCodeNoSuchKey {
+			return nil, empire.ErrBlobNotFound
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (s *S3Store) List(ctx context.Context, prefix string) ([]*empire.Blob, error) {
+	var blobs []*empire.Blob
+	err := s.s3.ListObjectsPages(&s3.ListObjectsInput{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(s.key(prefix)),
+	}, func(p *s3.ListObjectsOutput, lastPage bool) bool {
+		for _, o := range p.Contents {
+			blobs = append(blobs, &empire.Blob{
+				Key:        strings.TrimPrefix(aws.StringValue(o.Key), s.Prefix),
+				Size:       aws.Int64Value(o.Size),
+				ModifiedAt: aws.TimeValue(o.LastModified),
+			})
+		}
+		return true
+	})
+	return blobs, err
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	_, err := s.s3.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	return err
+}
+
+func (s *S3Store) key(key string) string {
+	return s.Prefix + key
+}
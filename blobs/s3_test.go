@@ -0,0 +1,113 @@
+package blobs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/remind101/empire"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestS3Store(t *testing.T) {
+	c := &fakeS3{objects: make(map[string]*s3Object)}
+	s := &S3Store{Bucket: "empire", Prefix: "empire/", ServerSideEncryption: true, s3: c}
+	ctx := context.Background()
+
+	assert.NoError(t, s.Put(ctx, "deploys/app/v1.log", []byte("v1")))
+	assert.NoError(t, s.Put(ctx, "deploys/app/v2.log", []byte("v2")))
+	assert.NoError(t, s.Put(ctx, "outputs/app/1234.log", []byte("output")))
+	assert.Equal(t, s3.ServerSideEncryptionAes256, c.objects["empire/deploys/app/v1.log"].sse)
+
+	b, err := s.Get(ctx, "deploys/app/v1.log")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(b))
+
+	_, err = s.Get(ctx, "deploys/app/v3.log")
+	assert.Equal(t, empire.ErrBlobNotFound, err)
+
+	blobs, err := s.List(ctx, "deploys/")
+	assert.NoError(t, err)
+	assert.Equal(t, []*empire.Blob{
+		{Key: "deploys/app/v1.log", Size: 2, ModifiedAt: c.now},
+		{Key: "deploys/app/v2.log", Size: 2, ModifiedAt: c.now},
+	}, blobs)
+
+	assert.NoError(t, s.Delete(ctx, "deploys/app/v1.log"))
+	blobs, err = s.List(ctx, "deploys/")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(blobs))
+	assert.Equal(t, "deploys/app/v2.log", blobs[0].Key)
+}
+
+func TestS3Store_NoServerSideEncryption(t *testing.T) {
+	c := &fakeS3{objects: make(map[string]*s3Object)}
+	s := &S3Store{Bucket: "empire", s3: c}
+
+	assert.NoError(t, s.Put(context.Background(), "deploys/app/v1.log", []byte("v1")))
+	assert.Equal(t, "", c.objects["deploys/app/v1.log"].sse)
+}
+
+type s3Object struct {
+	body []byte
+	sse  string
+}
+
+type fakeS3 struct {
+	objects map[string]*s3Object
+	now     time.Time
+}
+
+func (c *fakeS3) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	b, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.objects[*input.Key] = &s3Object{body: b, sse: aws.StringValue(input.ServerSideEncryption)}
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (c *fakeS3) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	o, ok := c.objects[*input.Key]
+	if !ok {
+		return nil, awserr.New(s3.ErrCodeNoSuchKey, "The specified key does not exist.", nil)
+	}
+	return &s3.GetObjectOutput{
+		Body: ioutil.NopCloser(bytes.NewReader(o.body)),
+	}, nil
+}
+
+func (c *fakeS3) ListObjectsPages(input *s3.ListObjectsInput, fn func(*s3.ListObjectsOutput, bool) bool) error {
+	// One object per page, in order.
+	var keys []string
+	for k := range c.objects {
+		if strings.HasPrefix(k, *input.Prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for i, k := range keys {
+		o := &s3.Object{
+			Key:          aws.String(k),
+			Size:         aws.Int64(int64(len(c.objects[k].body))),
+			LastModified: aws.Time(c.now),
+		}
+		if !fn(&s3.ListObjectsOutput{Contents: []*s3.Object{o}}, i == len(keys)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (c *fakeS3) DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
+	delete(c.objects, *input.Key)
+	return &s3.DeleteObjectOutput{}, nil
+}
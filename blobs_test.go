@@ -0,0 +1,140 @@
+package empire
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestBlobExpirer(t *testing.T) {
+	now := time.Date(2017, 1, 31, 0, 0, 0, 0, time.UTC)
+	defer func(n func() time.Time) { timex.Now = n }(timex.Now)
+	timex.Now = func() time.Time { return now }
+
+	s := newMemBlobStore()
+	s.put("deploys/app/v1.log", now.Add(-31*24*time.Hour))
+	s.put("deploys/app/v2.log", now.Add(-24*time.Hour))
+	s.put("outputs/app/1234.log", now.Add(-8*24*time.Hour))
+	s.put("backups/empire-20170101T000000Z.json", now.Add(-30*24*time.Hour))
+
+	x := &BlobExpirer{
+		Store: s,
+		Rules: []BlobLifecycleRule{
+			{Prefix: BlobPrefixDeployLogs, MaxAge: 30 * 24 * time.Hour},
+			{Prefix: BlobPrefixOutputs, MaxAge: 7 * 24 * time.Hour},
+			// Kept forever.
+			{Prefix: BlobPrefixBackups},
+		},
+	}
+	assert.NoError(t, x.Run(context.Background()))
+
+	assert.Equal(t, []string{
+		"backups/empire-20170101T000000Z.json",
+		"deploys/app/v2.log",
+	}, s.keys())
+}
+
+func TestBackupsInBlobStore(t *testing.T) {
+	s := newMemBlobStore()
+	backups := BackupsInBlobStore(s)
+	ctx := context.Background()
+
+	assert.NoError(t, backups.Put(ctx, "empire-20170101T000000Z.json", []byte(`{"version":1}`)))
+	s.put("backups/old/empire-20160101T000000Z.json", time.Time{})
+	s.put("deploys/app/v1.log", time.Time{})
+
+	b, err := backups.Get(ctx, "empire-20170101T000000Z.json")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"version":1}`, string(b))
+
+	names, err := backups.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"empire-20170101T000000Z.json"}, names)
+
+	assert.NoError(t, backups.Delete(ctx, "empire-20170101T000000Z.json"))
+	names, err = backups.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(names))
+}
+
+func TestEmpire_DeployLog(t *testing.T) {
+	s := newMemBlobStore()
+	e := &Empire{Blobs: s}
+	ctx := context.Background()
+	app := &App{ID: "appid"}
+
+	e.storeDeployLog(ctx, &Release{AppID: app.ID, Version: 1}, []byte(`{"status":"Pulling image"}`))
+	assert.Equal(t, []string{"deploys/appid/v1.log"}, s.keys())
+
+	var buf bytes.Buffer
+	assert.NoError(t, e.DeployLog(ctx, DeployLogOpts{App: app, Version: 1, Output: &buf}))
+	assert.Equal(t, `{"status":"Pulling image"}`, buf.String())
+
+	assert.Equal(t, ErrNoDeployLog, e.DeployLog(ctx, DeployLogOpts{App: app, Version: 2, Output: &buf}))
+
+	e.Blobs = nil
+	assert.Equal(t, ErrBlobsDisabled, e.DeployLog(ctx, DeployLogOpts{App: app, Version: 1, Output: &buf}))
+}
+
+// memBlobStore is an in memory BlobStore.
+type memBlobStore struct {
+	blobs map[string]*Blob
+	data  map[string][]byte
+}
+
+func newMemBlobStore() *memBlobStore {
+	return &memBlobStore{
+		blobs: make(map[string]*Blob),
+		data:  make(map[string][]byte),
+	}
+}
+
+func (s *memBlobStore) put(key string, modifiedAt time.Time) {
+	s.blobs[key] = &Blob{Key: key, ModifiedAt: modifiedAt}
+	s.data[key] = nil
+}
+
+func (s *memBlobStore) keys() []string {
+	var keys []string
+	for k := range s.blobs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *memBlobStore) Put(ctx context.Context, key string, b []byte) error {
+	s.blobs[key] = &Blob{Key: key, Size: int64(len(b)), ModifiedAt: timex.Now()}
+	s.data[key] = b
+	return nil
+}
+
+func (s *memBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	b, ok := s.data[key]
+	if !ok {
+		return nil, ErrBlobNotFound
+	}
+	return b, nil
+}
+
+func (s *memBlobStore) List(ctx context.Context, prefix string) ([]*Blob, error) {
+	var blobs []*Blob
+	for _, k := range s.keys() {
+		if strings.HasPrefix(k, prefix) {
+			blobs = append(blobs, s.blobs[k])
+		}
+	}
+	return blobs, nil
+}
+
+func (s *memBlobStore) Delete(ctx context.Context, key string) error {
+	delete(s.blobs, key)
+	delete(s.data, key)
+	return nil
+}
@@ -0,0 +1,255 @@
+package empire
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"golang.org/x/net/context"
+)
+
+// The operations that can be applied to many apps at once with Bulk.
+const (
+	BulkRestart = "restart"
+	BulkScale   = "scale"
+	BulkSet     = "set"
+)
+
+var (
+	// ErrBulkOperation is returned when a bulk operation isn't one of
+	// BulkRestart, BulkScale or BulkSet.
+	ErrBulkOperation = &ValidationError{
+		errors.New("Bulk operations must be one of restart, scale or set."),
+	}
+
+	// ErrBulkSelector is returned when a bulk operation doesn't have a
+	// label selector, which would apply it to every app.
+	ErrBulkSelector = &ValidationError{
+		errors.New("A label selector is required, to select the apps that the operation applies to."),
+	}
+
+	// ErrBulkPlanChanged is returned when the plan that's being confirmed
+	// doesn't match the operation, or the apps that it applies to now.
+	ErrBulkPlanChanged = &ValidationError{
+		errors.New("The operation, or the apps that it applies to, changed since it was planned. Plan it again, and confirm the new plan."),
+	}
+)
+
+// BulkOpts are options provided when restarting, scaling, or setting config vars
+// on, every app with labels matching a selector.
+type BulkOpts struct {
+	// User performing the action.
+	User *User
+
+	// Selects the apps that the operation applies to.
+	Selector LabelSelector
+
+	// The operation to apply. One of BulkRestart, BulkScale or BulkSet.
+	Operation string
+
+	// The processes to scale, for BulkScale.
+	Updates []*ProcessUpdate
+
+	// The config vars to change, for BulkSet.
+	Vars Vars
+
+	// Commit message
+	Message string
+
+	// The id of the plan to apply. If empty, the operation is only
+	// planned.
+	Confirm string
+
+	// Required to apply a destructive operation when any of the apps are
+	// protected.
+	TwoFactor *TwoFactorCode
+}
+
+// destructive returns true if the operation could scale processes to zero, or
+// unset config vars.
+func (opts BulkOpts) destructive() bool {
+	switch opts.Operation {
+	case BulkScale:
+		for _, up := range opts.Updates {
+			if (up.Change == nil && up.Quantity == 0) || (up.Change != nil && up.Change.Delta < 0) {
+				return true
+			}
+		}
+	case BulkSet:
+		for _, v := range opts.Vars {
+			if v == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BulkPlan is the set of apps that a bulk operation applies to.
+type BulkPlan struct {
+	// Identifies the operation, and the apps that it applies to. It has to
+	// be provided to apply the operation, which makes sure that the
+	// operation is only applied to the apps that were reviewed.
+	ID string
+
+	// The operation.
+	Operation string
+
+	// The apps that the operation applies to, sorted by name.
+	Apps []*App
+
+	// The result of the operation on each app, in the same order as Apps,
+	// once it was applied. Nil if the operation was only planned.
+	Results []*BulkResult
+}
+
+// BulkResult is the result of a bulk operation on a single app.
+type BulkResult struct {
+	// The app.
+	App *App
+
+	// The error that the operation failed with, if it failed.
+	Err error
+}
+
+// Failed returns the number of apps that the operation failed on.
+func (p *BulkPlan) Failed() int {
+	var n int
+	for _, r := range p.Results {
+		if r.Err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Bulk plans an operation for every app with labels matching the selector, and
+// applies it if the plan is confirmed. The operation is applied to each app on
+// its own, so if it fails on one app, it's still applied to the others, and the
+// error is returned in the result for that app.
+func (e *Empire) Bulk(ctx context.Context, opts BulkOpts) (*BulkPlan, error) {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return nil, err
+	}
+
+	if err := e.requireMessages(opts.Message); err != nil {
+		return nil, err
+	}
+
+	plan, err := e.bulkPlan(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Confirm == "" {
+		return plan, nil
+	}
+
+	if opts.Confirm != plan.ID {
+		return nil, ErrBulkPlanChanged
+	}
+
+	// The two factor code is checked before the operation is applied to
+	// any app, rather than failing on the protected apps only.
+	if opts.destructive() {
+		for _, app := range plan.Apps {
+			if err := e.requireTwoFactor(opts.User, app, opts.TwoFactor); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, app := range plan.Apps {
+		plan.Results = append(plan.Results, &BulkResult{
+			App: app,
+			Err: e.bulkApply(ctx, opts, app),
+		})
+	}
+
+	return plan, nil
+}
+
+// bulkPlan finds the apps that the operation applies to.
+func (e *Empire) bulkPlan(opts BulkOpts) (*BulkPlan, error) {
+	switch opts.Operation {
+	case BulkRestart, BulkScale, BulkSet:
+	default:
+		return nil, ErrBulkOperation
+	}
+
+	if len(opts.Selector) == 0 {
+		return nil, ErrBulkSelector
+	}
+
+	apps, err := apps(e.db, AppsQuery{Selector: opts.Selector})
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := bulkPlanID(opts, apps)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BulkPlan{
+		ID:        id,
+		Operation: opts.Operation,
+		Apps:      apps,
+	}, nil
+}
+
+// bulkApply applies the operation to a single app.
+func (e *Empire) bulkApply(ctx context.Context, opts BulkOpts, app *App) error {
+	switch opts.Operation {
+	case BulkRestart:
+		return e.Restart(ctx, RestartOpts{
+			User:    opts.User,
+			App:     app,
+			Message: opts.Message,
+		})
+	case BulkScale:
+		_, err := e.Scale(ctx, ScaleOpts{
+			User:      opts.User,
+			App:       app,
+			Updates:   opts.Updates,
+			Message:   opts.Message,
+			TwoFactor: opts.TwoFactor,
+		})
+		return err
+	case BulkSet:
+		_, err := e.Set(ctx, SetOpts{
+			User:      opts.User,
+			App:       app,
+			Vars:      opts.Vars,
+			Message:   opts.Message,
+			TwoFactor: opts.TwoFactor,
+		})
+		return err
+	}
+	return ErrBulkOperation
+}
+
+// bulkPlanID returns a hash of the operation, and the ids of the apps that it
+// applies to.
+func bulkPlanID(opts BulkOpts, apps []*App) (string, error) {
+	ids := make([]string, len(apps))
+	for i, a := range apps {
+		ids[i] = a.ID
+	}
+	sort.Strings(ids)
+
+	raw, err := json.Marshal(struct {
+		Operation string
+		Updates   []*ProcessUpdate
+		Vars      Vars
+		Apps      []string
+	}{opts.Operation, opts.Updates, opts.Vars, ids})
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.Sum256(raw)
+	return hex.EncodeToString(h[:8]), nil
+}
@@ -0,0 +1,47 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkPlanID(t *testing.T) {
+	a, b := &App{ID: "a"}, &App{ID: "b"}
+	value := "s3cret"
+	set := BulkOpts{Operation: BulkSet, Vars: Vars{"PASSWORD": &value}}
+
+	id := func(opts BulkOpts, apps ...*App) string {
+		id, err := bulkPlanID(opts, apps)
+		assert.NoError(t, err)
+		return id
+	}
+
+	assert.Equal(t, id(set, a, b), id(set, b, a))
+	assert.NotEqual(t, id(set, a, b), id(set, a))
+	assert.NotEqual(t, id(set, a), id(BulkOpts{Operation: BulkRestart}, a))
+
+	other := "other"
+	assert.NotEqual(t, id(set, a), id(BulkOpts{Operation: BulkSet, Vars: Vars{"PASSWORD": &other}}, a))
+}
+
+func TestBulkOpts_destructive(t *testing.T) {
+	value := "production"
+
+	tests := []struct {
+		opts        BulkOpts
+		destructive bool
+	}{
+		{BulkOpts{Operation: BulkRestart}, false},
+		{BulkOpts{Operation: BulkScale, Updates: []*ProcessUpdate{{Process: "web", Quantity: 2}}}, false},
+		{BulkOpts{Operation: BulkScale, Updates: []*ProcessUpdate{{Process: "web", Quantity: 0}}}, true},
+		{BulkOpts{Operation: BulkScale, Updates: []*ProcessUpdate{{Process: "web", Change: &QuantityChange{Delta: 1}}}}, false},
+		{BulkOpts{Operation: BulkScale, Updates: []*ProcessUpdate{{Process: "web", Change: &QuantityChange{Delta: -1}}}}, true},
+		{BulkOpts{Operation: BulkSet, Vars: Vars{"RAILS_ENV": &value}}, false},
+		{BulkOpts{Operation: BulkSet, Vars: Vars{"RAILS_ENV": nil}}, true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.destructive, tt.opts.destructive())
+	}
+}
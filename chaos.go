@@ -0,0 +1,481 @@
+package empire
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/headerutil"
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/remind101/pkg/reporter"
+	"golang.org/x/net/context"
+)
+
+// ChaosKillRetention is how long chaos kills are kept.
+const ChaosKillRetention = 30 * 24 * time.Hour
+
+// DefaultBusinessHours are the hours that the ChaosMonkey kills instances
+// during when it's not given any: 9am to 5pm UTC, Monday to Friday.
+var DefaultBusinessHours = BusinessHours{Start: 9 * time.Hour, End: 17 * time.Hour}
+
+// SetChaosOpts are options provided when changing the fraction of the instances
+// of an app that are killed by the ChaosMonkey.
+type SetChaosOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// The fraction of the instances of each process that are killed each
+	// time the ChaosMonkey runs, between 0 and 1. 0 opts the app out.
+	Fraction float64
+}
+
+// SetChaos changes the fraction of the instances of an app that are killed
+// each time the ChaosMonkey runs.
+func (e *Empire) SetChaos(ctx context.Context, opts SetChaosOpts) error {
+	if err := e.authorize(opts.User, opts.App, ActionAdmin); err != nil {
+		return err
+	}
+
+	if opts.Fraction < 0 || opts.Fraction > 1 {
+		return &ValidationError{Err: fmt.Errorf("The chaos fraction must be between 0 and 1.")}
+	}
+
+	opts.App.ChaosFraction = opts.Fraction
+	return appsUpdate(e.db, opts.App)
+}
+
+// ChaosKill records an instance that was killed by the ChaosMonkey, and how
+// long it took the process to recover from it.
+type ChaosKill struct {
+	// A unique uuid that identifies the kill.
+	ID string
+
+	// The id of the app that the instance belonged to.
+	AppID string
+
+	// The process that the instance was an instance of.
+	ProcessType string
+
+	// The id and name of the instance that was killed.
+	TaskID string
+	PID    string
+
+	// The release that the instance was running.
+	Release int
+
+	// The time that the instance was killed.
+	KilledAt *time.Time
+
+	// The time that the process was seen running as many instances as it's
+	// scaled to again, by the Rescheduler.
+	RecoveredAt *time.Time
+}
+
+// BeforeCreate sets killed_at before inserting.
+func (k *ChaosKill) BeforeCreate() error {
+	if k.KilledAt == nil {
+		t := timex.Now()
+		k.KilledAt = &t
+	}
+	return nil
+}
+
+// RecoveryTime returns how long the process took to recover, or 0 if it
+// hasn't recovered yet.
+func (k *ChaosKill) RecoveryTime() time.Duration {
+	if k.KilledAt == nil || k.RecoveredAt == nil {
+		return 0
+	}
+	return k.RecoveredAt.Sub(*k.KilledAt)
+}
+
+// ChaosKillsQuery is a scope implementation for common things to filter chaos
+// kills by.
+type ChaosKillsQuery struct {
+	// If provided, finds kills of the given app's instances.
+	App *App
+
+	// If true, only finds kills that the process hasn't recovered from.
+	Unrecovered bool
+
+	// If provided, finds kills after the given time.
+	Since *time.Time
+
+	// If provided, uses the limit and sorting parameters specified in the range.
+	Range headerutil.Range
+}
+
+// scope implements the scope interface.
+func (q ChaosKillsQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.App != nil {
+		scope = append(scope, forApp(q.App))
+	}
+
+	if q.Unrecovered {
+		scope = append(scope, isNull("recovered_at"))
+	}
+
+	if q.Since != nil {
+		since := *q.Since
+		scope = append(scope, scopeFunc(func(db *gorm.DB) *gorm.DB {
+			return db.Where("killed_at > ?", since)
+		}))
+	}
+
+	scope = append(scope, inRange(q.Range.WithDefaults(q.DefaultRange())))
+
+	return scope.scope(db)
+}
+
+// DefaultRange returns the default headerutil.Range used if values aren't
+// provided.
+func (q ChaosKillsQuery) DefaultRange() headerutil.Range {
+	sort, order := "killed_at", "desc"
+	return headerutil.Range{
+		Sort:  &sort,
+		Order: &order,
+	}
+}
+
+// chaosKills returns all chaos kills matching the scope.
+func chaosKills(db *gorm.DB, scope scope) ([]*ChaosKill, error) {
+	var kills []*ChaosKill
+	return kills, find(db, scope, &kills)
+}
+
+func chaosKillsCreate(db *gorm.DB, kill *ChaosKill) (*ChaosKill, error) {
+	return kill, db.Create(kill).Error
+}
+
+func chaosKillsUpdate(db *gorm.DB, kill *ChaosKill) error {
+	return db.Save(kill).Error
+}
+
+// chaosKillsDestroyBefore removes the kills that happened before the given
+// time.
+func chaosKillsDestroyBefore(db *gorm.DB, before time.Time) error {
+	return db.Where("killed_at < ?", before).Delete(ChaosKill{}).Error
+}
+
+// BusinessHours are the hours of the working week, Monday to Friday, when
+// people are around to notice, and fix, processes that don't tolerate losing
+// an instance.
+type BusinessHours struct {
+	// When the business day starts and ends, as offsets from midnight.
+	Start, End time.Duration
+
+	// The time zone that the hours are in. The zero value is UTC.
+	Location *time.Location
+}
+
+// ParseBusinessHours parses business hours in the form "09:00-17:00".
+func ParseBusinessHours(s string, loc *time.Location) (BusinessHours, error) {
+	parts := strings.SplitN(s, "-", 2)
+	if len(parts) != 2 {
+		return BusinessHours{}, fmt.Errorf("invalid business hours %q: must be <start>-<end> (e.g. 09:00-17:00)", s)
+	}
+
+	h := BusinessHours{Location: loc}
+	for i, part := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return BusinessHours{}, fmt.Errorf("invalid business hours %q: %v", s, err)
+		}
+		offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+		if i == 0 {
+			h.Start = offset
+		} else {
+			h.End = offset
+		}
+	}
+
+	if h.End <= h.Start {
+		return BusinessHours{}, fmt.Errorf("invalid business hours %q: must end after they start", s)
+	}
+
+	return h, nil
+}
+
+// Contains returns true if t is within business hours.
+func (h BusinessHours) Contains(t time.Time) bool {
+	loc := h.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	return offset >= h.Start && offset < h.End
+}
+
+// ChaosMonkey periodically kills a random fraction of the instances of the
+// long running processes of apps that have opted in with a ChaosFraction, so
+// that teams can verify that their processes tolerate losing instances. It only
+// kills instances during business hours, and the Rescheduler records how long
+// each process took to recover.
+//
+// A process isn't killed again until it has recovered, and its last running
+// instance is never killed.
+type ChaosMonkey struct {
+	*Empire
+
+	// How often to kill instances.
+	Interval time.Duration
+
+	// When instances can be killed. The zero value is
+	// DefaultBusinessHours.
+	Hours BusinessHours
+
+	rand *rand.Rand
+}
+
+// Start starts killing instances, until the context is canceled. Errors, and
+// panics, are reported to the reporter in the context.
+func (m *ChaosMonkey) Start(ctx context.Context) {
+	defer reporter.Monitor(ctx)
+
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := m.Unleash(ctx); err != nil {
+				reporter.Report(ctx, err)
+			}
+		}
+	}
+}
+
+// Unleash kills a random fraction of the instances of each app that has opted
+// in, if it's within business hours, and returns the kills. A ChaosKillEvent
+// is published for each instance that's killed.
+func (m *ChaosMonkey) Unleash(ctx context.Context) ([]*ChaosKill, error) {
+	now := timex.Now()
+
+	if err := chaosKillsDestroyBefore(m.db, now.Add(-ChaosKillRetention)); err != nil {
+		return nil, err
+	}
+
+	hours := m.Hours
+	if hours == (BusinessHours{}) {
+		hours = DefaultBusinessHours
+	}
+	if !hours.Contains(now) {
+		return nil, nil
+	}
+
+	if m.rand == nil {
+		m.rand = rand.New(rand.NewSource(now.UnixNano()))
+	}
+
+	apps, err := apps(m.db, AppsQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		kills  []*ChaosKill
+		errors []error
+	)
+	for _, app := range apps {
+		if app.ChaosFraction <= 0 {
+			continue
+		}
+
+		k, err := m.unleashApp(ctx, app)
+		kills = append(kills, k...)
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return kills, &multiError{Errors: errors}
+	}
+
+	return kills, nil
+}
+
+// unleashApp kills a random fraction of the instances of each long running
+// process of the current release of the app.
+func (m *ChaosMonkey) unleashApp(ctx context.Context, app *App) ([]*ChaosKill, error) {
+	release, err := releasesFind(m.db, ReleasesQuery{App: app})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// Processes that haven't recovered from the last kill are left alone.
+	unrecovered, err := chaosKills(m.db, ChaosKillsQuery{App: app, Unrecovered: true})
+	if err != nil {
+		return nil, err
+	}
+	recovering := make(map[string]bool)
+	for _, k := range unrecovered {
+		recovering[k.ProcessType] = true
+	}
+
+	scheduler, err := m.scheduler(app)
+	if err != nil {
+		return nil, err
+	}
+
+	instances, err := scheduler.Tasks(ctx, app.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	running := make(map[string][]*Task)
+	for _, i := range instances {
+		if i.State != "RUNNING" || i.Process.Labels[userLabel] != "" || i.Process.Labels[cronLabel] != "" {
+			continue
+		}
+		p, ok := release.Formation[i.Process.Type]
+		if !ok || p.Cron != nil || p.NoService || recovering[i.Process.Type] {
+			continue
+		}
+		t := taskFromInstance(i)
+		if t.Version != release.Version {
+			continue
+		}
+		running[t.Type] = append(running[t.Type], t)
+	}
+
+	var (
+		kills  []*ChaosKill
+		errors []error
+	)
+	for process, tasks := range running {
+		n := chaosKillCount(len(tasks), app.ChaosFraction, m.rand)
+		for _, i := range m.rand.Perm(len(tasks))[:n] {
+			t := tasks[i]
+			if err := unscheduleTask(m.db, app, t, "killed by chaos testing"); err != nil {
+				errors = append(errors, err)
+			}
+
+			if err := scheduler.Stop(ctx, t.ID); err != nil {
+				errors = append(errors, err)
+				continue
+			}
+
+			kill, err := chaosKillsCreate(m.db, &ChaosKill{
+				AppID:       app.ID,
+				ProcessType: process,
+				TaskID:      t.ID,
+				PID:         t.Name,
+				Release:     release.Version,
+			})
+			if err != nil {
+				errors = append(errors, err)
+				continue
+			}
+			kills = append(kills, kill)
+
+			if err := m.PublishEvent(ChaosKillEvent{
+				App: app.Name,
+				PID: t.Name,
+				app: app,
+			}); err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return kills, &multiError{Errors: errors}
+	}
+
+	return kills, nil
+}
+
+// chaosKillCount returns how many of the n running instances of a process to
+// kill. The fraction of n is rounded up, or down, at random, in proportion to
+// its remainder, so that a fraction of a small process still kills instances
+// over time. The last instance is never killed.
+func chaosKillCount(n int, fraction float64, r *rand.Rand) int {
+	x := fraction * float64(n)
+	k := int(x)
+	if r.Float64() < x-float64(k) {
+		k++
+	}
+	if k > n-1 {
+		k = n - 1
+	}
+	if k < 0 {
+		k = 0
+	}
+	return k
+}
+
+// recordChaosRecoveries records the recovery of each process of the app that
+// had an instance killed by the ChaosMonkey, and is running as many instances
+// as it's scaled to again. A ChaosRecoveryEvent is published for each one.
+func (s *rescheduleService) recordChaosRecoveries(ctx context.Context, app *App, tasks []*Task) error {
+	kills, err := chaosKills(s.db, ChaosKillsQuery{App: app, Unrecovered: true})
+	if err != nil || len(kills) == 0 {
+		return err
+	}
+
+	release, err := releasesFind(s.db, ReleasesQuery{App: app})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	// The number of healthy instances of each process, and the instances
+	// that haven't stopped yet.
+	running := make(map[string]int)
+	alive := make(map[string]bool)
+	for _, t := range tasks {
+		if t.State == "STOPPED" {
+			continue
+		}
+		alive[t.ID] = true
+		if t.State == "RUNNING" && t.Version == release.Version && !t.Host.Lost {
+			running[t.Type]++
+		}
+	}
+
+	now := timex.Now()
+	for _, k := range kills {
+		p, ok := release.Formation[k.ProcessType]
+		if !ok || alive[k.TaskID] || running[k.ProcessType] < p.Quantity {
+			continue
+		}
+
+		k.RecoveredAt = &now
+		if err := chaosKillsUpdate(s.db, k); err != nil {
+			return err
+		}
+
+		if err := s.PublishEvent(ChaosRecoveryEvent{
+			App:          app.Name,
+			PID:          k.PID,
+			Process:      k.ProcessType,
+			RecoveryTime: k.RecoveryTime(),
+			app:          app,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
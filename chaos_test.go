@@ -0,0 +1,70 @@
+package empire
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBusinessHours(t *testing.T) {
+	h, err := ParseBusinessHours("09:30-17:00", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, BusinessHours{Start: 9*time.Hour + 30*time.Minute, End: 17 * time.Hour, Location: time.UTC}, h)
+
+	for _, s := range []string{"", "9-5", "09:00", "17:00-09:00"} {
+		_, err := ParseBusinessHours(s, time.UTC)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestBusinessHours_Contains(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	h := BusinessHours{Start: 9 * time.Hour, End: 17 * time.Hour, Location: ny}
+
+	tests := []struct {
+		time     time.Time
+		contains bool
+	}{
+		// Wednesday
+		{time.Date(2018, 6, 13, 9, 0, 0, 0, ny), true},
+		{time.Date(2018, 6, 13, 16, 59, 0, 0, ny), true},
+		{time.Date(2018, 6, 13, 8, 59, 0, 0, ny), false},
+		{time.Date(2018, 6, 13, 17, 0, 0, 0, ny), false},
+
+		// 10am in New York.
+		{time.Date(2018, 6, 13, 14, 0, 0, 0, time.UTC), true},
+
+		// Saturday
+		{time.Date(2018, 6, 16, 12, 0, 0, 0, ny), false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.contains, h.Contains(tt.time), tt.time.String())
+	}
+
+	// The zero value of Location is UTC.
+	assert.True(t, DefaultBusinessHours.Contains(time.Date(2018, 6, 13, 9, 0, 0, 0, time.UTC)))
+}
+
+func TestChaosKillCount(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	// The last instance is never killed.
+	assert.Equal(t, 0, chaosKillCount(1, 1, r))
+	assert.Equal(t, 3, chaosKillCount(4, 1, r))
+
+	assert.Equal(t, 5, chaosKillCount(10, 0.5, r))
+	assert.Equal(t, 0, chaosKillCount(10, 0, r))
+
+	// Fractions of an instance are rounded up in proportion to the
+	// remainder.
+	var killed int
+	for i := 0; i < 1000; i++ {
+		killed += chaosKillCount(2, 0.25, r)
+	}
+	assert.InDelta(t, 500, killed, 75)
+}
@@ -0,0 +1,256 @@
+package empire
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/remind101/empire/twelvefactor"
+	"golang.org/x/net/context"
+)
+
+// Placement strategies that a process, or cluster, can use.
+const (
+	PlacementBinpack = twelvefactor.PlacementBinpack
+	PlacementSpread  = twelvefactor.PlacementSpread
+)
+
+// Placements are the valid placement strategies.
+var Placements = []string{PlacementBinpack, PlacementSpread}
+
+// UnknownClusterError is returned when an app is assigned to a cluster that
+// hasn't been configured.
+type UnknownClusterError struct {
+	Cluster string
+}
+
+func (e *UnknownClusterError) Error() string {
+	return fmt.Sprintf("cluster %s is not configured", e.Cluster)
+}
+
+// scheduler returns the Scheduler for the cluster that the app is assigned to.
+func (e *Empire) scheduler(app *App) (Scheduler, error) {
+	if app.Cluster == "" {
+		return e.Scheduler, nil
+	}
+
+	s, ok := e.Clusters[app.Cluster]
+	if !ok {
+		return nil, &UnknownClusterError{Cluster: app.Cluster}
+	}
+
+	return s, nil
+}
+
+// anyScheduler calls fn with the Scheduler for each cluster, including the
+// default cluster. This is used for operations on hosts, which only belong to
+// one cluster, so it succeeds if fn succeeds for any of them. Otherwise, the
+// error from the default cluster is returned.
+func (e *Empire) anyScheduler(fn func(Scheduler) error) error {
+	err := fn(e.Scheduler)
+	if err == nil {
+		return nil
+	}
+
+	for _, s := range e.Clusters {
+		if fn(s) == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+// Resources represents an amount of CPU, memory and GPUs.
+type Resources struct {
+	CPU    constraints.CPUShare
+	Memory constraints.Memory
+	GPU    constraints.GPU
+}
+
+// Machine represents a host in a cluster, and how much of its resources are
+// reserved by running processes.
+type Machine struct {
+	Host Host
+
+	// The resources that the host makes available to processes.
+	Total Resources
+
+	// The resources reserved by processes running on the host.
+	Allocated Resources
+
+	// The number of processes running on the host.
+	Tasks int
+}
+
+// Available returns the resources that are not reserved by any process.
+func (m *Machine) Available() Resources {
+	return Resources{
+		CPU:    m.Total.CPU - m.Allocated.CPU,
+		Memory: m.Total.Memory - m.Allocated.Memory,
+		GPU:    m.Total.GPU - m.Allocated.GPU,
+	}
+}
+
+// Fits returns how many more processes with the given constraints can be
+// placed on the host. Lost hosts can't have anything placed on them.
+func (m *Machine) Fits(c Constraints) int {
+	if m.Host.Lost || c.CPUShare == 0 || c.Memory == 0 {
+		return 0
+	}
+
+	a := m.Available()
+	n := int(a.CPU / c.CPUShare)
+	if mem := int(a.Memory / c.Memory); mem < n {
+		n = mem
+	}
+	if c.GPU > 0 {
+		if gpu := int(a.GPU / c.GPU); gpu < n {
+			n = gpu
+		}
+	}
+	return n
+}
+
+// ClusterCapacity represents the capacity of a cluster.
+type ClusterCapacity struct {
+	// The name of the cluster. The default cluster has no name.
+	Name string
+
+	// The hosts in the cluster.
+	Machines []*Machine
+}
+
+// Total returns the sum of the resources of all hosts in the cluster.
+func (c *ClusterCapacity) Total() Resources {
+	var r Resources
+	for _, m := range c.Machines {
+		r.CPU += m.Total.CPU
+		r.Memory += m.Total.Memory
+		r.GPU += m.Total.GPU
+	}
+	return r
+}
+
+// Allocated returns the sum of the reserved resources of all hosts in the
+// cluster.
+func (c *ClusterCapacity) Allocated() Resources {
+	var r Resources
+	for _, m := range c.Machines {
+		r.CPU += m.Allocated.CPU
+		r.Memory += m.Allocated.Memory
+		r.GPU += m.Allocated.GPU
+	}
+	return r
+}
+
+// Fits returns how many more processes with the given constraints can be
+// placed in the cluster. Because a process has to fit on a single host, this
+// can be less than the cluster's total available resources would suggest.
+func (c *ClusterCapacity) Fits(con Constraints) int {
+	var n int
+	for _, m := range c.Machines {
+		n += m.Fits(con)
+	}
+	return n
+}
+
+// capacity returns the capacity of the default cluster, followed by each
+// additional cluster, ordered by name.
+func (e *Empire) capacity(ctx context.Context) ([]*ClusterCapacity, error) {
+	names := []string{""}
+	for name := range e.Clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+
+	var capacity []*ClusterCapacity
+	for _, name := range names {
+		s, err := e.scheduler(&App{Cluster: name})
+		if err != nil {
+			return nil, err
+		}
+
+		ms, err := machines(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+
+		capacity = append(capacity, &ClusterCapacity{Name: name, Machines: ms})
+	}
+
+	return capacity, nil
+}
+
+// machines returns the hosts that the scheduler is placing processes on.
+func machines(ctx context.Context, s Scheduler) ([]*Machine, error) {
+	ms, err := s.Machines(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var machines []*Machine
+	for _, m := range ms {
+		machines = append(machines, &Machine{
+			Host: newHost(m.Host),
+			Total: Resources{
+				CPU:    constraints.CPUShare(m.Total.CPU),
+				Memory: constraints.Memory(m.Total.Memory),
+				GPU:    constraints.GPU(m.Total.GPU),
+			},
+			Allocated: Resources{
+				CPU:    constraints.CPUShare(m.Allocated.CPU),
+				Memory: constraints.Memory(m.Allocated.Memory),
+				GPU:    constraints.GPU(m.Allocated.GPU),
+			},
+			Tasks: m.Tasks,
+		})
+	}
+
+	return machines, nil
+}
+
+// checkPlacement returns an error if any of the given processes couldn't be
+// placed on any host in the app's cluster, even if the host were empty. These
+// processes would never start, since they don't fit anywhere. Clusters that
+// don't report any hosts (e.g. because they're scaled to zero) aren't checked.
+func (e *Empire) checkPlacement(ctx context.Context, app *App, f Formation, types []string) error {
+	s, err := e.scheduler(app)
+	if err != nil {
+		return err
+	}
+
+	ms, err := machines(ctx, s)
+	if err != nil {
+		return err
+	}
+	if len(ms) == 0 {
+		return nil
+	}
+
+	for _, t := range types {
+		p := f[t]
+		if p.Quantity <= 0 {
+			continue
+		}
+
+		c := p.Constraints()
+		if !fitsAnywhere(ms, c) {
+			return &ValidationError{Err: fmt.Errorf("%s processes need %s, which is more than any host in the cluster has", t, c)}
+		}
+	}
+
+	return nil
+}
+
+// fitsAnywhere returns true if a process with the given constraints fits on
+// at least one of the hosts, ignoring what's already running on them.
+func fitsAnywhere(ms []*Machine, c Constraints) bool {
+	for _, m := range ms {
+		empty := &Machine{Host: m.Host, Total: m.Total}
+		if empty.Fits(c) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package empire
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmpire_scheduler(t *testing.T) {
+	a, b := NewFakeScheduler(), NewFakeScheduler()
+	e := &Empire{
+		Scheduler: a,
+		Clusters:  map[string]Scheduler{"batch": b},
+	}
+
+	s, err := e.scheduler(&App{})
+	assert.NoError(t, err)
+	assert.True(t, s == a)
+
+	s, err = e.scheduler(&App{Cluster: "batch"})
+	assert.NoError(t, err)
+	assert.True(t, s == b)
+
+	_, err = e.scheduler(&App{Cluster: "gpu"})
+	assert.Equal(t, &UnknownClusterError{Cluster: "gpu"}, err)
+}
+
+func TestEmpire_anyScheduler(t *testing.T) {
+	a, b := NewFakeScheduler(), NewFakeScheduler()
+	e := &Empire{
+		Scheduler: a,
+		Clusters:  map[string]Scheduler{"batch": b},
+	}
+
+	errNotFound := errors.New("not found")
+
+	var called []Scheduler
+	err := e.anyScheduler(func(s Scheduler) error {
+		called = append(called, s)
+		if s == b {
+			return nil
+		}
+		return errNotFound
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(called))
+
+	err = e.anyScheduler(func(s Scheduler) error {
+		return errNotFound
+	})
+	assert.Equal(t, errNotFound, err)
+}
+
+func TestClusterCapacity(t *testing.T) {
+	c := &ClusterCapacity{
+		Machines: []*Machine{
+			{
+				Host:      Host{ID: "i-1"},
+				Total:     Resources{CPU: 2048, Memory: constraints.Memory(8 * GB)},
+				Allocated: Resources{CPU: 1024, Memory: constraints.Memory(1 * GB)},
+			},
+			{
+				Host:      Host{ID: "i-2"},
+				Total:     Resources{CPU: 2048, Memory: constraints.Memory(8 * GB)},
+				Allocated: Resources{CPU: 256, Memory: constraints.Memory(7 * GB)},
+			},
+			{
+				Host:  Host{ID: "i-3", Lost: true},
+				Total: Resources{CPU: 2048, Memory: constraints.Memory(8 * GB)},
+			},
+		},
+	}
+
+	assert.Equal(t, Resources{CPU: 6144, Memory: constraints.Memory(24 * GB)}, c.Total())
+	assert.Equal(t, Resources{CPU: 1280, Memory: constraints.Memory(8 * GB)}, c.Allocated())
+
+	// i-1 is limited by CPU, i-2 by memory, and nothing can be placed on
+	// i-3.
+	assert.Equal(t, 2, c.Machines[0].Fits(Constraints2X))
+	assert.Equal(t, 1, c.Machines[1].Fits(Constraints2X))
+	assert.Equal(t, 0, c.Machines[2].Fits(Constraints2X))
+	assert.Equal(t, 3, c.Fits(Constraints2X))
+	assert.Equal(t, 1, c.Fits(ConstraintsPX))
+
+	gpu := &ClusterCapacity{
+		Machines: []*Machine{
+			{
+				Host:      Host{ID: "i-4"},
+				Total:     Resources{CPU: 4096, Memory: constraints.Memory(61 * GB), GPU: 4},
+				Allocated: Resources{CPU: 1024, Memory: constraints.Memory(6 * GB), GPU: 3},
+			},
+		},
+	}
+
+	// Only a single GPU remains on i-4.
+	assert.Equal(t, 1, gpu.Fits(Constraints{CPUShare: 100, Memory: constraints.Memory(1 * GB), GPU: 1}))
+	assert.Equal(t, 0, gpu.Fits(Constraints{CPUShare: 100, Memory: constraints.Memory(1 * GB), GPU: 2}))
+}
+
+func TestFitsAnywhere(t *testing.T) {
+	ms := []*Machine{
+		{
+			Host:      Host{ID: "i-1"},
+			Total:     Resources{CPU: 2048, Memory: constraints.Memory(8 * GB)},
+			Allocated: Resources{CPU: 2048, Memory: constraints.Memory(8 * GB)},
+		},
+		{
+			Host:  Host{ID: "i-2", Lost: true},
+			Total: Resources{CPU: 4096, Memory: constraints.Memory(32 * GB)},
+		},
+	}
+
+	// i-1 is full, but the process would fit if it wasn't.
+	assert.True(t, fitsAnywhere(ms, Constraints2X))
+	// Only the lost host is big enough.
+	assert.False(t, fitsAnywhere(ms, Constraints{CPUShare: 1024, Memory: constraints.Memory(16 * GB)}))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var (
+	activitySince time.Duration
+	activityMax   int
+)
+
+var cmdActivity = &Command{
+	Run:      runActivity,
+	Usage:    "activity [--since <duration>] [-n <max>]",
+	NeedsApp: true,
+	Category: "release",
+	NumArgs:  0,
+	Short:    "list what happened to an app",
+	Long: `
+Lists what happened to an app, most recent first: its deploys, rollbacks and
+config changes, when it was scaled or restarted, and when its dynos crashed,
+along with who did it.
+
+Options:
+
+    --since <duration>  only list activity within this long (e.g. 24h)
+    -n <max>            list at most this many activities (default 50)
+
+Examples:
+
+    $ emp activity -a acme-inc
+    Jun 13 03:11  crash    v12                 v12.web.4d91e7a2 crashed
+    Jun 12 18:45  scale    v12  ejholmes       ejholmes scaled ` + "`web`" + ` on acme-inc from 2(1X) to 4(1X)
+    Jun 12 18:39  deploy   v12  ejholmes       Deploy remind101/acme-inc:latest (ejholmes)
+    Jun 12 17:02  restart       ejholmes       ejholmes restarted acme-inc
+`,
+}
+
+func init() {
+	cmdActivity.Flag.DurationVar(&activitySince, "since", 0, "how far back to list activity")
+	cmdActivity.Flag.IntVarP(&activityMax, "max", "n", 50, "maximum number of activities")
+}
+
+func runActivity(cmd *Command, args []string) {
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+
+	appname := mustApp()
+	cmd.AssertNumArgsCorrect(args)
+
+	opts := &heroku.ActivityListOpts{}
+	if activitySince > 0 {
+		since := time.Now().Add(-activitySince)
+		opts.Since = &since
+	}
+
+	activities, err := client.ActivityList(appname, opts, &heroku.ListRange{
+		Field:      "created_at",
+		Max:        activityMax,
+		Descending: true,
+	})
+	must(err)
+
+	for _, a := range activities {
+		var version string
+		if a.Release > 0 {
+			version = fmt.Sprintf("v%d", a.Release)
+		}
+		listRec(w, prettyTime{a.CreatedAt}, a.Type, version, a.User, a.Description)
+	}
+}
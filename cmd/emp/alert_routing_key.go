@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var cmdAlertRoutingKey = &Command{
+	Run:      runAlertRoutingKey,
+	Usage:    "alert-routing-key [<key>]",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "show or set where incidents about an app are routed",
+	Long: `
+Shows, or sets, the key that incidents about an app, like failed deploys and
+crash loops, are routed with when Empire is configured with an alerting
+backend. For PagerDuty, this is the integration key of a service. For
+Opsgenie, this is the API key of an integration. An empty key uses the default
+key of the backend.
+
+Examples:
+
+    $ emp alert-routing-key -a acme-inc
+
+    $ emp alert-routing-key 0123456789abcdef0123456789abcdef -a acme-inc
+    Incidents about acme-inc will be routed with 0123456789abcdef0123456789abcdef.
+`,
+}
+
+func runAlertRoutingKey(cmd *Command, args []string) {
+	appname := mustApp()
+
+	switch len(args) {
+	case 0:
+		app, err := client.AppInfo(appname)
+		must(err)
+		fmt.Println(app.AlertRoutingKey)
+	case 1:
+		key := args[0]
+		_, err := client.AppUpdate(appname, &heroku.AppUpdateOpts{AlertRoutingKey: &key}, "")
+		must(err)
+		if key == "" {
+			log.Printf("Incidents about %s will be routed with the default key.", appname)
+		} else {
+			log.Printf("Incidents about %s will be routed with %s.", appname, key)
+		}
+	default:
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+}
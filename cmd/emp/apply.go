@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var applyDryRun bool
+
+var cmdApply = &Command{
+	Run:             maybeMessage(runApply),
+	Usage:           "apply [-n] <file>",
+	OptionalMessage: true,
+	Category:        "deploy",
+	NumArgs:         1,
+	Short:           "converge an app to an app spec",
+	Long: `
+Apply reads an app spec from a YAML or JSON file (or stdin, when the file is
+-), and converges the app to it. The app is created if it doesn't exist, and
+then config vars are changed, the image is deployed, domains are added and
+removed, and processes are scaled, wherever they differ from the spec. Parts
+of the spec that are omitted aren't changed.
+
+    name: acme-inc
+    image: remind101/acme-inc:v42
+    config:
+      RAILS_ENV: production
+    domains:
+      - acme.com
+    formation:
+      web:
+        quantity: 2
+        size: 2X
+
+Options:
+
+    -n, --dry-run
+    only print the changes that would be made.
+
+Examples:
+
+    $ emp apply acme-inc.yml
+    set RAILS_ENV
+    deploy remind101/acme-inc:v42
+    scale web=2:2X
+`,
+}
+
+func init() {
+	cmdApply.Flag.BoolVarP(&applyDryRun, "dry-run", "n", false, "only print the changes that would be made")
+}
+
+func runApply(cmd *Command, args []string) {
+	cmd.AssertNumArgsCorrect(args)
+
+	var (
+		b   []byte
+		err error
+	)
+	if args[0] == "-" {
+		b, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		b, err = ioutil.ReadFile(args[0])
+	}
+	must(err)
+
+	// Two factor codes are verified before any changes are made, so the
+	// apply can be retried with one.
+	var result *heroku.AppSpecApplyResult
+	must(withTwoFactor(func() error {
+		result, err = client.AppSpecApply(&heroku.AppSpecApplyOpts{
+			Spec:   string(b),
+			DryRun: applyDryRun,
+		}, getMessage())
+		return err
+	}))
+
+	if len(result.Changes) == 0 {
+		log.Printf("%s is up to date.", result.App)
+		return
+	}
+
+	for _, change := range result.Changes {
+		log.Println(change)
+	}
+}
@@ -14,13 +14,19 @@ import (
 
 var cmdApps = &Command{
 	Run:      runApps,
-	Usage:    "apps [<name>...]",
+	Usage:    "apps [-n <namespace>] [-l <selector>] [<name>...]",
 	Category: "app",
 	Short:    "list apps",
 	Long: `
 Lists apps. Shows the app name, owner, and last release time (or
 time the app was created, if it's never been released).
 
+Options:
+
+    -n <namespace>  only list the apps in the namespace
+    -l <selector>   only list apps with labels matching the selector (e.g.
+                    tier=web,!deprecated)
+
 Examples:
 
     $ emp apps
@@ -29,11 +35,22 @@ Examples:
 
     $ emp apps myapp
     myapp  user@test.com  us  Jan 2 12:34
+
+    $ emp apps -n payments
+    payments-api     user@test.com  us  Jan 2 12:34
+    payments-worker  user@test.com  us  Jan 2 12:35
+
+    $ emp apps -l tier=web
+    myapp     user@test.com         us  Jan 2 12:34
 `,
 }
 
+var flagNamespace string
+
 func init() {
 	cmdApps.Flag.StringVarP(&flagOrgName, "org", "o", "", "organization name")
+	cmdApps.Flag.StringVarP(&flagNamespace, "namespace", "n", "", "namespace name")
+	cmdApps.Flag.StringVarP(&flagLabels, "labels", "l", "", "label selector")
 }
 
 func runApps(cmd *Command, names []string) {
@@ -42,7 +59,7 @@ func runApps(cmd *Command, names []string) {
 	var apps []hkapp
 	if len(names) == 0 {
 		var err error
-		apps, err = getAppList(flagOrgName)
+		apps, err = getAppList(flagOrgName, flagNamespace, flagLabels)
 		must(err)
 	} else {
 		appch := make(chan *heroku.App, len(names))
@@ -74,7 +91,23 @@ func runApps(cmd *Command, names []string) {
 	printAppList(w, apps)
 }
 
-func getAppList(orgName string) ([]hkapp, error) {
+func getAppList(orgName, namespace, labels string) ([]hkapp, error) {
+	if namespace != "" {
+		apps, err := client.NamespaceAppList(namespace, &heroku.ListRange{Field: "name", Max: 1000})
+		if err != nil {
+			return nil, err
+		}
+		return fromApps(apps), nil
+	}
+
+	if labels != "" {
+		apps, err := client.AppListByLabels(labels, &heroku.ListRange{Field: "name", Max: 1000})
+		if err != nil {
+			return nil, err
+		}
+		return fromApps(apps), nil
+	}
+
 	if orgName != "" {
 		apps, err := client.OrganizationAppListForOrganization(orgName, &heroku.ListRange{Field: "name", Max: 1000})
 		if err != nil {
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var (
+	batchParallelism int
+	batchSize        string
+)
+
+var cmdBatchRun = &Command{
+	Run:             maybeMessage(runBatchRun),
+	Usage:           "batch-run [-p <parallelism>] [-s <size>] [<file>]",
+	NeedsApp:        true,
+	OptionalMessage: true,
+	Category:        "dyno",
+	Short:           "queue a batch of one-off processes",
+	Long: `
+Queues a batch of detached one-off processes, one for each line of the file, or
+of stdin if no file is given. Blank lines, and lines starting with #, are
+skipped. Processes are run in the order that they're listed, up to the given
+parallelism at a time, and the exit code of each one is recorded.
+
+Options:
+
+    -p <parallelism>  maximum number of processes to run at once (default 1)
+    -s <size>         set the size for each process (e.g. 2X)
+
+Examples:
+
+    $ seq 1 100 | sed 's/^/backfill --shard /' | emp batch-run -p 10 -a acme-inc
+    Queued 100 jobs on acme-inc as batch 01234567-89ab-cdef-0123-456789abcdef.
+`,
+}
+
+func init() {
+	cmdBatchRun.Flag.IntVarP(&batchParallelism, "parallelism", "p", 1, "maximum number of processes to run at once")
+	cmdBatchRun.Flag.StringVarP(&batchSize, "size", "s", "", "dyno size")
+}
+
+func runBatchRun(cmd *Command, args []string) {
+	if len(args) > 1 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+	appname := mustApp()
+
+	var r io.Reader = os.Stdin
+	if len(args) == 1 {
+		f, err := os.Open(args[0])
+		must(err)
+		defer f.Close()
+		r = f
+	}
+
+	var commands []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		commands = append(commands, line)
+	}
+	must(s.Err())
+
+	opts := &heroku.BatchCreateOpts{
+		Commands:    commands,
+		Parallelism: batchParallelism,
+		Message:     getMessage(),
+	}
+	if batchSize != "" {
+		opts.Size = &batchSize
+	}
+
+	batch, err := client.BatchCreate(appname, opts)
+	must(err)
+	log.Printf("Queued %d jobs on %s as batch %s.", len(batch.Jobs), appname, batch.Id)
+}
+
+var cmdBatches = &Command{
+	Run:      runBatches,
+	Usage:    "batches",
+	NeedsApp: true,
+	Category: "dyno",
+	NumArgs:  0,
+	Short:    "list batches of one-off processes",
+	Long: `
+Lists the batches of an app, most recent first, with the number of jobs in each
+state.
+
+Examples:
+
+    $ emp batches -a acme-inc
+    01234567-89ab-cdef-0123-456789abcdef  ejholmes  Jun 13 18:14  queued=80 running=10 succeeded=9 failed=1
+`,
+}
+
+func runBatches(cmd *Command, args []string) {
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+
+	appname := mustApp()
+	cmd.AssertNumArgsCorrect(args)
+	batches, err := client.BatchList(appname, &heroku.ListRange{
+		Field:      "created_at",
+		Max:        20,
+		Descending: true,
+	})
+	must(err)
+
+	for _, b := range batches {
+		listRec(w, b.Id, b.CreatedBy, prettyTime{b.CreatedAt}, batchStatus(b.Status))
+	}
+}
+
+var cmdBatchInfo = &Command{
+	Run:      runBatchInfo,
+	Usage:    "batch-info <id>",
+	NeedsApp: true,
+	Category: "dyno",
+	NumArgs:  1,
+	Short:    "show the jobs in a batch",
+	Long: `
+Shows the state, and exit code, of each job in a batch.
+
+Examples:
+
+    $ emp batch-info 01234567-89ab-cdef-0123-456789abcdef -a acme-inc
+    Parallelism: 10
+    Status:      queued=80 running=10 succeeded=9 failed=1
+
+    12345678-9abc-def0-1234-56789abcdef0  succeeded  0  backfill --shard 1
+    23456789-abcd-ef01-2345-6789abcdef01  failed     1  backfill --shard 2
+    3456789a-bcde-f012-3456-789abcdef012  running       backfill --shard 3
+`,
+}
+
+func runBatchInfo(cmd *Command, args []string) {
+	appname := mustApp()
+	cmd.AssertNumArgsCorrect(args)
+	batch, err := client.BatchInfo(appname, args[0])
+	must(err)
+
+	fmt.Printf("Parallelism: %d\n", batch.Parallelism)
+	fmt.Printf("Status:      %s\n\n", batchStatus(batch.Status))
+
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	for _, j := range batch.Jobs {
+		exitCode := ""
+		if j.ExitCode != nil {
+			exitCode = fmt.Sprintf("%d", *j.ExitCode)
+		}
+		listRec(w, j.Id, j.State, exitCode, j.Command)
+	}
+}
+
+// batchStatus formats the number of jobs in each state, in the order that jobs
+// move through them.
+func batchStatus(status map[string]int) string {
+	var parts []string
+	for _, state := range []string{"queued", "running", "succeeded", "failed", "lost"} {
+		if n := status[state]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", state, n))
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var (
+	bulkSelector string
+	bulkConfirm  string
+)
+
+var cmdBulk = &Command{
+	Run:             maybeMessage(runBulk),
+	Usage:           "bulk -l <selector> [--confirm <plan>] (restart | scale <type>=<qty>... | set <name>=<value>... | unset <name>...)",
+	OptionalMessage: true,
+	Category:        "app",
+	Short:           "restart, scale or set env vars on many apps",
+	Long: `
+Bulk restarts, scales, or sets env vars on, every app with labels matching
+a selector, in one call. This is useful during incidents, like rotating a
+shared credential everywhere. It requires the admin role.
+
+The apps that the operation applies to are listed first, with the id of the
+plan, and the operation is only applied once the plan id is entered to
+confirm it. If the apps that match the selector change in the meantime, the
+operation isn't applied, and has to be planned again. The plan id can also be
+passed with --confirm, to apply the operation without a prompt.
+
+The operation is applied to each app on its own, so if it fails on one app,
+it's still applied to the others.
+
+Options:
+
+    -l <selector>       apply the operation to apps with labels matching the
+                        selector (e.g. "tier=web")
+    --confirm <plan>    apply the plan with the given id without a prompt
+
+Examples:
+
+    $ emp bulk -l uses=redis set REDIS_PASSWORD=s3cret
+    Plan 6f1c2a4b0d9e8f7a will set REDIS_PASSWORD on 2 apps:
+        api
+        worker
+    Enter the plan id to confirm:
+    > 6f1c2a4b0d9e8f7a
+    api      done
+    worker   done
+
+    $ emp bulk -l tier=web --confirm 6f1c2a4b0d9e8f7a restart
+`,
+}
+
+func init() {
+	cmdBulk.Flag.StringVarP(&bulkSelector, "labels", "l", "", "label selector")
+	cmdBulk.Flag.StringVar(&bulkConfirm, "confirm", "", "plan id to apply")
+}
+
+func runBulk(cmd *Command, args []string) {
+	if bulkSelector == "" || len(args) == 0 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	opts, description, err := parseBulkArgs(args)
+	if err != nil {
+		printError("%s", err)
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+	opts.Selector = bulkSelector
+	message := getMessage()
+
+	if bulkConfirm == "" {
+		plan, err := client.Bulk(opts, message)
+		must(err)
+
+		if len(plan.Apps) == 0 {
+			log.Printf("No apps match %s.", bulkSelector)
+			return
+		}
+
+		fmt.Printf("Plan %s will %s on %d apps:\n", plan.Id, description, len(plan.Apps))
+		for _, a := range plan.Apps {
+			fmt.Printf("    %s\n", a.Name)
+		}
+		mustConfirm("Enter the plan id to confirm:", plan.Id)
+		bulkConfirm = plan.Id
+	}
+
+	opts.Confirm = bulkConfirm
+
+	var plan *heroku.BulkPlan
+	must(withTwoFactor(func() (err error) {
+		plan, err = client.Bulk(opts, message)
+		return err
+	}))
+
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+
+	var failed int
+	for _, r := range plan.Results {
+		status := "done"
+		if r.Error != "" {
+			status = "failed: " + r.Error
+			failed++
+		}
+		listRec(w, r.App.Name, status)
+	}
+
+	if failed > 0 {
+		w.Flush()
+		printFatal("Failed on %d of %d apps.", failed, len(plan.Results))
+	}
+}
+
+// parseBulkArgs parses the operation, and its arguments, and returns a
+// description of it.
+func parseBulkArgs(args []string) (*heroku.BulkOpts, string, error) {
+	op, args := args[0], args[1:]
+
+	switch op {
+	case "restart":
+		if len(args) != 0 {
+			return nil, "", fmt.Errorf("restart doesn't take any arguments")
+		}
+		return &heroku.BulkOpts{Operation: "restart"}, "restart all dynos", nil
+	case "scale":
+		if len(args) == 0 {
+			return nil, "", fmt.Errorf("scale needs at least one process")
+		}
+		updates, err := parseScaleArgs(args)
+		if err != nil {
+			return nil, "", err
+		}
+		return &heroku.BulkOpts{Operation: "scale", Updates: updates}, "scale " + strings.Join(args, " "), nil
+	case "set", "unset":
+		if len(args) == 0 {
+			return nil, "", fmt.Errorf("%s needs at least one env var", op)
+		}
+		vars := make(map[string]*string)
+		var names []string
+		for _, arg := range args {
+			if op == "unset" {
+				vars[arg] = nil
+				names = append(names, arg)
+				continue
+			}
+			i := strings.Index(arg, "=")
+			if i < 0 {
+				return nil, "", fmt.Errorf("bad format: %#q. See 'emp help bulk'", arg)
+			}
+			val := arg[i+1:]
+			vars[arg[:i]] = &val
+			names = append(names, arg[:i])
+		}
+		return &heroku.BulkOpts{Operation: "set", Vars: vars}, op + " " + strings.Join(names, " "), nil
+	}
+
+	return nil, "", fmt.Errorf("unknown operation %q", op)
+}
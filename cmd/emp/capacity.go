@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var capacitySize string
+
+var cmdCapacity = &Command{
+	Run:      runCapacity,
+	Usage:    "capacity [-s <size>]",
+	Category: "dyno",
+	NumArgs:  0,
+	Short:    "show cluster capacity",
+	Long: `
+Shows the CPU and memory reserved by dynos, out of the total available, for
+each host and cluster, along with the availability zone of each host. CPU is
+in units of 1024 per core. Lost hosts are marked with an asterisk. This
+requires admin access.
+
+Options:
+
+    -s <size>  also show how many more dynos of this size will fit (e.g. 1X
+               or 512:1GB). Because a dyno has to fit on a single host, this
+               can be less than the free resources of the cluster suggest.
+
+Examples:
+
+    $ emp capacity -s 2X
+    default  i-042f39dc  us-east-1a  768/2048   1.50gb/7.50gb   3 dynos   fits 2
+    default  i-0b71a9c3  us-east-1b  1792/2048  6.50gb/7.50gb   9 dynos   fits 0
+    default  total                   2560/4096  8.00gb/15.00gb  12 dynos  fits 2
+`,
+}
+
+func init() {
+	cmdCapacity.Flag.StringVarP(&capacitySize, "size", "s", "", "dyno size")
+}
+
+func runCapacity(cmd *Command, args []string) {
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	cmd.AssertNumArgsCorrect(args)
+
+	capacity, err := client.CapacityList(capacitySize)
+	must(err)
+
+	for _, c := range capacity {
+		name := c.Name
+		if name == "" {
+			name = "default"
+		}
+
+		dynos := 0
+		for _, m := range c.Machines {
+			host := m.Host.Id
+			if m.Lost {
+				host += "*"
+			}
+			listCapacity(w, name, host, m.Zone, m.Total, m.Allocated, m.Dynos, m.Fits)
+			dynos += m.Dynos
+		}
+		listCapacity(w, name, "total", "", c.Total, c.Allocated, dynos, c.Fits)
+	}
+}
+
+func listCapacity(w *tabwriter.Writer, cluster, host, zone string, total, allocated heroku.Resources, dynos int, fits *int) {
+	rec := []interface{}{
+		cluster,
+		host,
+		zone,
+		fmt.Sprintf("%d/%d", allocated.CPU, total.CPU),
+		fmt.Sprintf("%s/%s", constraints.Memory(allocated.Memory), constraints.Memory(total.Memory)),
+		fmt.Sprintf("%d dynos", dynos),
+	}
+	if fits != nil {
+		rec = append(rec, fmt.Sprintf("fits %d", *fits))
+	}
+	listRec(w, rec...)
+}
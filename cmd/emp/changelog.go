@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var changelogCount int
+
+var cmdChangelog = &Command{
+	Run:      runChangelog,
+	Usage:    "changelog [-n <limit>]",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "show what changed in each release",
+	Long: `
+Changelog lists recent releases, with who created them, what created them,
+and what changed since the release before: the image, the names of config
+vars that were added (+), changed (~) or removed (-), and the scale of
+processes.
+
+Options:
+
+    -n <limit>  maximum number of recent releases to display
+
+Examples:
+
+    $ emp changelog -n 2
+    v2  Jun 13 18:14  john  deploy  Deploy remind101/acme-inc:0fda0ae
+        image: remind101/acme-inc:3ae20c2 => remind101/acme-inc:0fda0ae
+    v3  Jun 13 18:31  john  config  Set RAILS_ENV config var
+        ~ RAILS_ENV
+`,
+}
+
+func init() {
+	cmdChangelog.Flag.IntVarP(&changelogCount, "number", "n", 20, "max number of recent releases to display")
+}
+
+func runChangelog(cmd *Command, args []string) {
+	appname := mustApp()
+	cmd.AssertNumArgsCorrect(args)
+
+	hrels, err := client.ReleaseList(appname, &heroku.ListRange{
+		Field:      "version",
+		Max:        changelogCount,
+		Descending: true,
+	})
+	must(err)
+
+	rels := make([]*Release, len(hrels))
+	for i := range hrels {
+		rels[i] = newRelease(&hrels[i])
+	}
+	sort.Sort(releasesByVersion(rels))
+
+	for _, r := range rels {
+		fmt.Printf("v%d  %s  %s  %s  %s\n", r.Version, prettyTime{r.CreatedAt}, r.User.Name, r.Source, r.Description)
+		for _, line := range formatReleaseChanges(r.Changes) {
+			fmt.Printf("    %s\n", line)
+		}
+	}
+}
+
+// formatReleaseChanges returns a line for each change in a release.
+func formatReleaseChanges(c *heroku.ReleaseChanges) []string {
+	if c == nil {
+		return nil
+	}
+
+	var lines []string
+	if c.Image != nil {
+		if c.Image.From == "" {
+			lines = append(lines, fmt.Sprintf("image: %s", c.Image.To))
+		} else {
+			lines = append(lines, fmt.Sprintf("image: %s => %s", c.Image.From, c.Image.To))
+		}
+	}
+	for _, name := range c.VarsAdded {
+		lines = append(lines, "+ "+name)
+	}
+	for _, name := range c.VarsChanged {
+		lines = append(lines, "~ "+name)
+	}
+	for _, name := range c.VarsRemoved {
+		lines = append(lines, "- "+name)
+	}
+	for _, p := range c.Formation {
+		lines = append(lines, fmt.Sprintf("%s: %s => %s", p.Type, formatProcessScale(p.Previous), formatProcessScale(p.Current)))
+	}
+	return lines
+}
+
+func formatProcessScale(s *heroku.ReleaseProcessScale) string {
+	if s == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%d:%s", s.Quantity, s.Size)
+}
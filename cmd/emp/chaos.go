@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var cmdChaos = &Command{
+	Run:      runChaos,
+	Usage:    "chaos [<fraction>]",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "show or set the fraction of dynos killed by chaos testing",
+	Long: `
+Shows, or sets, the fraction of the dynos of each process of an app that chaos
+testing kills each time it runs, between 0 and 1. Dynos are only killed during
+business hours, the last dyno of a process is never killed, and a process
+isn't killed again until it has recovered. A fraction of 0 opts the app out.
+Use emp chaos-kills to see how long processes took to recover.
+
+Examples:
+
+    $ emp chaos -a acme-inc
+    0
+    $ emp chaos 0.1 -a acme-inc
+    Chaos testing will kill 10% of the dynos of each process of acme-inc.
+    $ emp chaos 0 -a acme-inc
+    acme-inc has opted out of chaos testing.
+`,
+}
+
+func runChaos(cmd *Command, args []string) {
+	appname := mustApp()
+
+	switch len(args) {
+	case 0:
+		app, err := client.AppInfo(appname)
+		must(err)
+		fmt.Println(strconv.FormatFloat(app.ChaosFraction, 'f', -1, 64))
+	case 1:
+		fraction, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			printFatal("invalid fraction %q", args[0])
+		}
+		_, err = client.AppUpdate(appname, &heroku.AppUpdateOpts{ChaosFraction: &fraction}, "")
+		must(err)
+		if fraction == 0 {
+			log.Printf("%s has opted out of chaos testing.", appname)
+		} else {
+			log.Printf("Chaos testing will kill %s%% of the dynos of each process of %s.", strconv.FormatFloat(fraction*100, 'f', -1, 64), appname)
+		}
+	default:
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+}
+
+var cmdChaosKills = &Command{
+	Run:      runChaosKills,
+	Usage:    "chaos-kills",
+	NeedsApp: true,
+	Category: "dyno",
+	NumArgs:  0,
+	Short:    "list the dynos killed by chaos testing",
+	Long: `
+Lists the dynos of an app that were killed by chaos testing, most recent
+first, with how long their process took to be running as many dynos as it's
+scaled to again. Processes that haven't recovered yet have no recovery time.
+
+Examples:
+
+    $ emp chaos-kills -a acme-inc
+    v12.web.8ab2c1f3     Jun 13 14:00  45s
+    v12.worker.4d91e7a2  Jun 13 13:00   2m
+    v11.web.b0c35f1e     Jun 12 11:00
+`,
+}
+
+func runChaosKills(cmd *Command, args []string) {
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+
+	appname := mustApp()
+	cmd.AssertNumArgsCorrect(args)
+	kills, err := client.ChaosKillList(appname, &heroku.ListRange{
+		Field:      "killed_at",
+		Max:        20,
+		Descending: true,
+	})
+	must(err)
+
+	for _, k := range kills {
+		recovery := ""
+		if k.RecoveryTime != nil {
+			recovery = prettyDuration{time.Duration(*k.RecoveryTime) * time.Second}.String()
+		}
+		listRec(w, k.Pid, prettyTime{k.KilledAt}, recovery)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var cmdCp = &Command{
+	Run:             maybeMessage(runCp),
+	Usage:           "cp <source> <destination>",
+	NeedsApp:        true,
+	OptionalMessage: true,
+	Category:        "dyno",
+	Short:           "copy files to or from a running dyno",
+	Long: `
+Copy a file to, or a file or directory from, a running dyno. Remote paths are
+prefixed with the name of the dyno, as displayed by 'emp ps'.
+
+When copying to a dyno, a remote path ending in "/" is treated as the directory
+to copy the file into, keeping its name.
+
+Examples:
+
+    $ emp cp v1.web.8d3a0de3:/tmp/heap.hprof heap.hprof
+    Copied v1.web.8d3a0de3:/tmp/heap.hprof to heap.hprof.
+
+    $ emp cp fixture.json v1.web.8d3a0de3:/tmp/
+    Copied fixture.json to v1.web.8d3a0de3:/tmp/.
+`,
+}
+
+func runCp(cmd *Command, args []string) {
+	if len(args) != 2 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+	appname := mustApp()
+	message := getMessage()
+
+	srcDyno, src := parseCopyArg(args[0])
+	dstDyno, dst := parseCopyArg(args[1])
+
+	switch {
+	case srcDyno != "" && dstDyno == "":
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(client.DynoCopyFrom(appname, srcDyno, src, pw, message))
+		}()
+		must(extractArchive(pr, dst))
+	case srcDyno == "" && dstDyno != "":
+		dir, name := path.Dir(dst), path.Base(dst)
+		if strings.HasSuffix(dst, "/") {
+			dir, name = dst, filepath.Base(src)
+		}
+
+		f, err := os.Open(src)
+		must(err)
+		defer f.Close()
+
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(archiveFile(pw, f, name))
+		}()
+		must(client.DynoCopyTo(appname, dstDyno, dir, pr, message))
+	default:
+		printFatal("exactly one of the source or destination must be a path in a dyno (e.g. v1.web.8d3a0de3:/tmp)")
+	}
+
+	log.Printf("Copied %s to %s.", args[0], args[1])
+}
+
+// parseCopyArg parses an argument to `emp cp`, returning the name of the dyno
+// if the path is inside of a dyno.
+func parseCopyArg(arg string) (dyno, path string) {
+	i := strings.Index(arg, ":")
+	if i <= 0 || strings.ContainsAny(arg[:i], `/\`) {
+		return "", arg
+	}
+	return arg[:i], arg[i+1:]
+}
+
+// archiveFile writes a tar archive containing the file, with the given name, to
+// w.
+func archiveFile(w io.Writer, f *os.File, name string) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", f.Name())
+	}
+
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+
+	tw := tar.NewWriter(w)
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, f); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// extractArchive extracts the tar archive to dst. If dst is an existing
+// directory, the archive is extracted into it. Otherwise, the root of the
+// archive is written to dst.
+func extractArchive(r io.Reader, dst string) error {
+	info, err := os.Stat(dst)
+	dstIsDir := err == nil && info.IsDir()
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("refusing to extract %s outside of %s", hdr.Name, dst)
+		}
+
+		target := filepath.Join(dst, filepath.FromSlash(name))
+		if !dstIsDir {
+			// Replace the root of the archive with dst.
+			parts := strings.SplitN(name, "/", 2)
+			target = dst
+			if len(parts) == 2 {
+				target = filepath.Join(dst, filepath.FromSlash(parts[1]))
+			}
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(hdr.Mode)|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := writeFile(target, tr, os.FileMode(hdr.Mode)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeFile(name string, r io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	return err
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCopyArg(t *testing.T) {
+	tests := []struct {
+		in string
+
+		dyno, path string
+	}{
+		{"v1.web.8d3a0de3:/tmp/heap.hprof", "v1.web.8d3a0de3", "/tmp/heap.hprof"},
+		{"heap.hprof", "", "heap.hprof"},
+		{"./dir:with:colons", "", "./dir:with:colons"},
+		{":/tmp", "", ":/tmp"},
+	}
+
+	for _, tt := range tests {
+		dyno, path := parseCopyArg(tt.in)
+		assert.Equal(t, tt.dyno, dyno)
+		assert.Equal(t, tt.path, path)
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emp-cp")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Extracting into an existing directory.
+	err = extractArchive(newArchive(t, map[string]string{"heap.hprof": "heap"}), dir)
+	assert.NoError(t, err)
+	assertFile(t, filepath.Join(dir, "heap.hprof"), "heap")
+
+	// Extracting to a new file.
+	err = extractArchive(newArchive(t, map[string]string{"heap.hprof": "heap"}), filepath.Join(dir, "dump"))
+	assert.NoError(t, err)
+	assertFile(t, filepath.Join(dir, "dump"), "heap")
+
+	// Entries outside of the destination are rejected.
+	err = extractArchive(newArchive(t, map[string]string{"../evil": "evil"}), dir)
+	assert.Error(t, err)
+}
+
+func newArchive(t testing.TB, files map[string]string) *bytes.Buffer {
+	b := new(bytes.Buffer)
+	tw := tar.NewWriter(b)
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: 0644,
+			Size: int64(len(content)),
+		}))
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	return b
+}
+
+func assertFile(t testing.TB, name, content string) {
+	raw, err := ioutil.ReadFile(name)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(raw))
+}
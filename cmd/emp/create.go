@@ -18,7 +18,7 @@ app is created with a random haiku name.
 
 Options:
 
-    -r <region>  Heroku region to create app in
+    -r <region>  cluster to create app in
     -o <org>     name of Heroku organization to create app in
     <name>       optional name for the app
 
@@ -27,7 +27,7 @@ Examples:
     $ emp create
     Created dodging-samurai-42.
 
-    $ emp create -r eu myapp
+    $ emp create -r batch myapp
     Created myapp.
 `,
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var cmdCronRuns = &Command{
+	Run:      runCronRuns,
+	Usage:    "cron-runs <process>",
+	NeedsApp: true,
+	Category: "dyno",
+	NumArgs:  1,
+	Short:    "list the invocations of a scheduled process",
+	Long: `
+Lists the recent invocations of a scheduled process, most recent first, with
+what triggered them, their state, their exit code, and where their output can
+be found.
+
+Examples:
+
+    $ emp cron-runs vacuum -a acme-inc
+    01234567-89ab-cdef-0123-456789abcdef  schedule  running       Jun 13 18:00
+    12345678-9abc-def0-1234-56789abcdef0  manual    succeeded  0  Jun 13 17:12  ejholmes
+    23456789-abcd-ef01-2345-6789abcdef01  schedule  failed     1  Jun 13 17:00
+`,
+}
+
+func runCronRuns(cmd *Command, args []string) {
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+
+	appname := mustApp()
+	cmd.AssertNumArgsCorrect(args)
+	runs, err := client.CronRunList(appname, args[0], &heroku.ListRange{
+		Field:      "created_at",
+		Max:        20,
+		Descending: true,
+	})
+	must(err)
+
+	for _, r := range runs {
+		exitCode := ""
+		if r.ExitCode != nil {
+			exitCode = fmt.Sprintf("%d", *r.ExitCode)
+		}
+		listRec(w, r.Id, r.Trigger, r.State, exitCode, prettyTime{r.CreatedAt}, r.CreatedBy, r.Output)
+	}
+}
+
+var cmdCronTrigger = &Command{
+	Run:             maybeMessage(runCronTrigger),
+	Usage:           "cron-trigger <process>",
+	NeedsApp:        true,
+	OptionalMessage: true,
+	Category:        "dyno",
+	NumArgs:         1,
+	Short:           "run a scheduled process now",
+	Long: `
+Runs a scheduled process right away, instead of waiting for its schedule. If
+the process is still running, its overlap policy decides what happens: the
+invocation is rejected when it's "skip", runs once the running instances stop
+when it's "queue", and stops them when it's "replace".
+
+Examples:
+
+    $ emp cron-trigger vacuum -a acme-inc
+    Triggered vacuum on acme-inc as 01234567-89ab-cdef-0123-456789abcdef (running).
+`,
+}
+
+func runCronTrigger(cmd *Command, args []string) {
+	appname := mustApp()
+	cmd.AssertNumArgsCorrect(args)
+	run, err := client.CronTrigger(appname, args[0], getMessage())
+	must(err)
+	log.Printf("Triggered %s on %s as %s (%s).", args[0], appname, run.Id, run.State)
+}
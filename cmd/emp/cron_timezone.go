@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var cmdCronTimezone = &Command{
+	Run:      runCronTimezone,
+	Usage:    "cron-timezone [<zone>]",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "show or set the time zone of scheduled processes",
+	Long: `
+Shows, or sets, the time zone that the schedules of the scheduled processes of
+an app are evaluated in, unless their cron expression sets its own with a
+CRON_TZ= prefix. Schedules follow the wall clock of the time zone, including
+when daylight saving time starts and ends. An empty zone evaluates schedules
+in UTC.
+
+Examples:
+
+    $ emp cron-timezone -a acme-inc
+    UTC
+    $ emp cron-timezone America/New_York -a acme-inc
+    Schedules of acme-inc will be evaluated in America/New_York.
+    $ emp cron-timezone "" -a acme-inc
+    Schedules of acme-inc will be evaluated in UTC.
+`,
+}
+
+func runCronTimezone(cmd *Command, args []string) {
+	appname := mustApp()
+
+	switch len(args) {
+	case 0:
+		app, err := client.AppInfo(appname)
+		must(err)
+		if app.CronTimezone == "" {
+			fmt.Println("UTC")
+		} else {
+			fmt.Println(app.CronTimezone)
+		}
+	case 1:
+		tz := args[0]
+		_, err := client.AppUpdate(appname, &heroku.AppUpdateOpts{CronTimezone: &tz}, "")
+		must(err)
+		if tz == "" {
+			tz = "UTC"
+		}
+		log.Printf("Schedules of %s will be evaluated in %s.", appname, tz)
+	default:
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+}
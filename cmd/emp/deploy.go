@@ -10,11 +10,16 @@ import (
 	"github.com/remind101/empire/pkg/heroku"
 )
 
-var stream bool
+var (
+	stream         bool
+	freezeOverride string
+	provenance     heroku.Provenance
+	simulate       bool
+)
 
 var cmdDeploy = &Command{
 	Run:             maybeMessage(runDeploy),
-	Usage:           "deploy [<registry>]<image>:[<tag>] [-s]",
+	Usage:           "deploy [<registry>]<image>:[<tag>] [-s] [--freeze-override <reason>] [--repo <repo>] [--commit <sha>] [--build-url <url>] [--builder <builder>] [--idempotency-key <key>] [--simulate]",
 	OptionalApp:     true,
 	OptionalMessage: true,
 	Category:        "deploy",
@@ -24,9 +29,32 @@ Deploy is used to deploy a docker image to an app.
 
 Options:
 
-    -s enable the status stream during the deployment. If this is enabled, the
-    command will wait until the scheduler has finished deploying the new
-    release.
+    -s, --stream
+    stream the status of the deployment (enabled by default). The command
+    will wait until the scheduler has finished deploying the new release,
+    showing progress as the image is pulled, new tasks are scheduled and
+    pass health checks, and old tasks are removed. Use --stream=false to
+    return as soon as the release is created.
+
+    --freeze-override <reason>
+    deploy during a release freeze window. The reason is recorded in the
+    audit log.
+
+    --repo <repo>, --commit <sha>, --build-url <url>, --builder <builder>
+    record where the image was built from: the source repository, the git
+    SHA of the commit, a link to the CI build, and what built it. They're
+    shown in 'emp releases' and 'emp release-info', and in deploy events.
+
+    --idempotency-key <key>
+    a unique key for the deploy, like the id of the CI build. Retrying the
+    deploy with the same key, within 24 hours, doesn't deploy again, so CI
+    systems can safely retry deploys after network errors.
+
+    --simulate
+    show what the deploy would release, without releasing it. The image is
+    pulled, and the release is checked against quotas, freeze windows and
+    admission policies, but nothing is saved or changed in the cluster. A
+    commit message isn't required. Useful as a pre-merge check in CI.
 
 Examples:
 
@@ -41,18 +69,44 @@ Examples:
     133fcef559c4: Download complete
     Status: Image is up to date for remind101/acme-inc:latest
     Status: Created new release v1 for acme-inc
+    Status: Stack update submitted
+    web: Status: Service web is waiting for new tasks to pass health checks
+    web: Status: Service web became stable
+    web: Status: Service web removed tasks from the previous release
+    Status: Finished processing events for release v1 of acme-inc
     $ emp releases
     v1    Jan 1 12:55  Deploy remind101/acme-inc:latest
+
+    $ emp deploy remind101/acme-inc:c6f77d2 --commit c6f77d2098bc --build-url https://ci.example.com/builds/42
+
+    $ emp deploy remind101/acme-inc:62b3059 --simulate
+    Status: Image is up to date for remind101/acme-inc:62b3059
+    Status: Simulated release v5 for acme-inc
+    Status: image: remind101/acme-inc:3ae20c2 => remind101/acme-inc:62b3059
+    Status: + REDIS_URL
+    Status: worker: none => 1:1X
+    Status: web: would run 2 instance(s) of ./bin/web
+    Status: worker: would run 1 instance(s) of ./bin/worker
+    Status: Nothing was released to acme-inc
 `,
 }
 
 func init() {
-	cmdDeploy.Flag.BoolVarP(&stream, "stream", "s", false, "boolean to enable the status stream")
+	cmdDeploy.Flag.BoolVarP(&stream, "stream", "s", true, "boolean to enable the status stream")
+	cmdDeploy.Flag.StringVar(&freezeOverride, "freeze-override", "", "reason for deploying during a release freeze")
+	cmdDeploy.Flag.StringVar(&provenance.Repo, "repo", "", "source repository of the image")
+	cmdDeploy.Flag.StringVar(&provenance.Commit, "commit", "", "git SHA the image was built from")
+	cmdDeploy.Flag.StringVar(&provenance.BuildURL, "build-url", "", "link to the CI build of the image")
+	cmdDeploy.Flag.StringVar(&provenance.Builder, "builder", "", "what built the image")
+	cmdDeploy.Flag.StringVar(&idempotencyKey, "idempotency-key", "", "unique key that deduplicates retries")
+	cmdDeploy.Flag.BoolVar(&simulate, "simulate", false, "show what would be released, without releasing it")
 }
 
 type PostDeployForm struct {
-	Image  string `json:"image"`
-	Stream bool   `json:"stream"`
+	Image      string             `json:"image"`
+	Stream     bool               `json:"stream"`
+	Simulate   bool               `json:"simulate,omitempty"`
+	Provenance *heroku.Provenance `json:"provenance,omitempty"`
 }
 
 func runDeploy(cmd *Command, args []string) {
@@ -64,7 +118,10 @@ func runDeploy(cmd *Command, args []string) {
 
 	image := args[0]
 	message := getMessage()
-	form := &PostDeployForm{Image: image, Stream: stream}
+	form := &PostDeployForm{Image: image, Stream: stream, Simulate: simulate}
+	if provenance != (heroku.Provenance{}) {
+		form.Provenance = &provenance
+	}
 
 	var endpoint string
 	appName, _ := app()
@@ -74,7 +131,7 @@ func runDeploy(cmd *Command, args []string) {
 		endpoint = "/deploys"
 	}
 
-	rh := heroku.RequestHeaders{CommitMessage: message}
+	rh := heroku.RequestHeaders{CommitMessage: message, FreezeOverride: freezeOverride, IdempotencyKey: idempotencyKey}
 	go func() {
 		retry := func() {
 			runDeploy(cmd, args)
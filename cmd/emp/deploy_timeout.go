@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var cmdDeployTimeout = &Command{
+	Run:      runDeployTimeout,
+	Usage:    "deploy-timeout [<duration>]",
+	NeedsApp: true,
+	Category: "deploy",
+	Short:    "show or set the deploy timeout",
+	Long: `
+Shows, or sets, how long a deploy waits for the new release to become healthy.
+When the timeout is exceeded, the deploy is aborted: the processes of the new
+release are stopped, and the previous release is restored as a new release.
+A timeout of 0 waits indefinitely.
+
+Examples:
+
+    $ emp deploy-timeout -a myapp
+    0s
+    $ emp deploy-timeout 10m -a myapp
+    Deploys of myapp will be aborted after 10m0s.
+`,
+}
+
+func runDeployTimeout(cmd *Command, args []string) {
+	appname := mustApp()
+
+	switch len(args) {
+	case 0:
+		app, err := client.AppInfo(appname)
+		must(err)
+		fmt.Println(time.Duration(app.DeployTimeout) * time.Second)
+	case 1:
+		d, err := time.ParseDuration(args[0])
+		if err != nil || d < 0 {
+			printFatal("invalid timeout: %s", args[0])
+		}
+		seconds := int(d.Seconds())
+		_, err = client.AppUpdate(appname, &heroku.AppUpdateOpts{DeployTimeout: &seconds}, "")
+		must(err)
+		if seconds == 0 {
+			log.Printf("Deploys of %s will wait indefinitely.", appname)
+		} else {
+			log.Printf("Deploys of %s will be aborted after %v.", appname, time.Duration(seconds)*time.Second)
+		}
+	default:
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+}
@@ -44,7 +44,9 @@ func runDestroy(cmd *Command, args []string) {
 	message := getMessage()
 	appname := args[0]
 
-	must(client.AppDelete(appname, message))
+	must(withTwoFactor(func() error {
+		return client.AppDelete(appname, message)
+	}))
 	log.Printf("Destroyed %s.", appname)
 	remotes, _ := gitRemotes()
 	for remote, remoteApp := range remotes {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 	"sort"
@@ -10,12 +11,24 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/docker/docker/pkg/term"
 	"github.com/remind101/empire/pkg/heroku"
 )
 
+var (
+	dynosType    string
+	dynosVersion string
+	dynosState   string
+	dynosHost    string
+	dynosMax     int
+	dynosAfter   string
+	dynosImage   string
+	dynosWatch   bool
+)
+
 var cmdDynos = &Command{
 	Run:      runDynos,
-	Usage:    "ps",
+	Usage:    "ps [-t <type>] [-v <version>] [-s <state>] [-H <host>] [-n <max>] [--after <name>] [-w]",
 	Alias:    "dynos",
 	NeedsApp: true,
 	Category: "dyno",
@@ -24,26 +37,77 @@ var cmdDynos = &Command{
 	Long: `
 Lists processes. Shows the name, size, host, state, age, and command.
 
+Options:
+
+    -t <type>       only list processes of this type (e.g. web)
+    -v <version>    only list processes running this release (e.g. v12)
+    -s <state>      only list processes in this state (e.g. RUNNING)
+    -H <host>       only list processes running on this host
+    -n <max>        list at most this many processes
+    --after <name>  list processes after this name, to page through results
+    -w, --watch     keep listing processes as they change, until interrupted.
+                    When the output isn't a terminal, each change is printed
+                    as it happens.
+
 Examples:
 
     $ emp ps
     v1.run.e97e1f75e8ff                             2X  RUNNING   1m  bash
     v1.web.dcc9a8c4-c0f8-4478-aa8a-f9148b362401     1X  RUNNING  15h  "blog /app /tmp/dst"
     v1.web.2bcb6e08-ef99-447f-8e7a-416d94769010     1X  RUNNING   8h  "blog /app /tmp/dst"
+
+    $ emp ps -t web -n 1
+    v1.web.2bcb6e08-ef99-447f-8e7a-416d94769010     1X  RUNNING   8h  "blog /app /tmp/dst"
+
+    $ emp ps -w > deploy.log
+    $ cat deploy.log
+    added    v1.web.2bcb6e08-ef99-447f-8e7a-416d94769010  1X  RUNNING  8h  "blog /app /tmp/dst"
+    added    v2.web.d0a9b46e-5a1f-4c1f-b3c5-9d2b4f0e3e1c  1X  PENDING  0s  "blog /app /tmp/dst"
+    updated  v2.web.d0a9b46e-5a1f-4c1f-b3c5-9d2b4f0e3e1c  1X  RUNNING  5s  "blog /app /tmp/dst"
+    removed  v1.web.2bcb6e08-ef99-447f-8e7a-416d94769010  1X  RUNNING  8h  "blog /app /tmp/dst"
 `,
 }
 
+func init() {
+	cmdDynos.Flag.StringVarP(&dynosType, "type", "t", "", "process type")
+	cmdDynos.Flag.StringVarP(&dynosVersion, "version", "v", "", "release version")
+	cmdDynos.Flag.StringVarP(&dynosState, "state", "s", "", "process state")
+	cmdDynos.Flag.StringVarP(&dynosHost, "host", "H", "", "host id")
+	cmdDynos.Flag.IntVarP(&dynosMax, "max", "n", 0, "maximum number of processes")
+	cmdDynos.Flag.StringVar(&dynosAfter, "after", "", "name to start listing after")
+	cmdDynos.Flag.BoolVarP(&dynosWatch, "watch", "w", false, "watch processes")
+}
+
 func runDynos(cmd *Command, args []string) {
 	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
 	defer w.Flush()
 	cmd.AssertNumArgsCorrect(args)
 
+	if dynosWatch {
+		watchDynos()
+		return
+	}
+
 	listDynos(w)
 }
 
 func listDynos(w io.Writer) {
 	appname := mustApp()
-	dynos, err := client.DynoList(appname, nil)
+
+	var lr *heroku.ListRange
+	if dynosMax > 0 || dynosAfter != "" {
+		lr = &heroku.ListRange{Field: "name", Max: dynosMax}
+		if dynosAfter != "" {
+			lr.FirstId = "]" + dynosAfter
+		}
+	}
+
+	dynos, err := client.DynoListWithOpts(appname, &heroku.DynoListOpts{
+		Type:    dynosType,
+		Version: dynosVersion,
+		State:   dynosState,
+		Host:    dynosHost,
+	}, lr)
 	must(err)
 	sort.Sort(DynosByName(dynos))
 
@@ -53,6 +117,140 @@ func listDynos(w io.Writer) {
 	return
 }
 
+// watchDynos prints the processes of the app as they change, until the stream
+// is closed. On a terminal, the whole list is redrawn after every change.
+func watchDynos() {
+	appname := mustApp()
+
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(client.DynoWatch(appname, &heroku.DynoListOpts{
+			Type:    dynosType,
+			Version: dynosVersion,
+			State:   dynosState,
+			Host:    dynosHost,
+		}, w))
+	}()
+
+	_, isTerminal := term.GetFdInfo(os.Stdout)
+	dynos := make(map[string]heroku.Dyno)
+	dec := json.NewDecoder(r)
+	for {
+		var changes []heroku.DynoChange
+		if err := dec.Decode(&changes); err != nil {
+			if err == io.EOF {
+				return
+			}
+			must(err)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+		for _, c := range changes {
+			if c.Type == "removed" {
+				delete(dynos, c.Dyno.Name)
+			} else {
+				dynos[c.Dyno.Name] = c.Dyno
+			}
+			if !isTerminal {
+				fmt.Fprintf(tw, "%s\t", c.Type)
+				listDyno(tw, &c.Dyno)
+			}
+		}
+
+		if isTerminal {
+			// Clear the screen, and move the cursor to the top.
+			fmt.Print("\033[H\033[2J")
+			var all []heroku.Dyno
+			for _, d := range dynos {
+				all = append(all, d)
+			}
+			sort.Sort(DynosByName(all))
+			for _, d := range all {
+				listDyno(tw, &d)
+			}
+		}
+		tw.Flush()
+	}
+}
+
+var cmdClusterDynos = &Command{
+	Run:      runClusterDynos,
+	Usage:    "cluster-ps [-t <type>] [-s <state>] [-H <host>] [-i <image>] [-l <selector>] [-n <max>] [--after <app>/<name>]",
+	Category: "dyno",
+	NumArgs:  0,
+	Short:    "list processes for all apps",
+	Long: `
+Lists processes for all apps. Shows the app, name, host, state, age, and
+image. This requires admin access.
+
+Options:
+
+    -t <type>              only list processes of this type (e.g. web)
+    -s <state>             only list processes in this state (e.g. RUNNING)
+    -H <host>              only list processes running on this host
+    -i <image>             only list processes running this image. Without a
+                           tag, matches any tag of the repository.
+    -l <selector>          only list processes of apps with labels matching
+                           the selector (e.g. tier=web)
+    -n <max>               list at most this many processes
+    --after <app>/<name>   list processes after this one, to page through
+                           results
+
+Examples:
+
+    $ emp cluster-ps -H i-042f39dc
+    acme-inc  v1.web.dcc9a8c4-c0f8-4478-aa8a-f9148b362401  i-042f39dc  RUNNING  15h  remind101/acme-inc:latest
+    blog      v3.web.2bcb6e08-ef99-447f-8e7a-416d94769010  i-042f39dc  RUNNING   8h  remind101/blog:master
+
+    $ emp cluster-ps -i remind101/blog
+    blog      v3.web.2bcb6e08-ef99-447f-8e7a-416d94769010  i-042f39dc  RUNNING   8h  remind101/blog:master
+`,
+}
+
+func init() {
+	cmdClusterDynos.Flag.StringVarP(&dynosType, "type", "t", "", "process type")
+	cmdClusterDynos.Flag.StringVarP(&dynosState, "state", "s", "", "process state")
+	cmdClusterDynos.Flag.StringVarP(&dynosHost, "host", "H", "", "host id")
+	cmdClusterDynos.Flag.StringVarP(&dynosImage, "image", "i", "", "docker image")
+	cmdClusterDynos.Flag.StringVarP(&flagLabels, "labels", "l", "", "label selector")
+	cmdClusterDynos.Flag.IntVarP(&dynosMax, "max", "n", 0, "maximum number of processes")
+	cmdClusterDynos.Flag.StringVar(&dynosAfter, "after", "", "app/name to start listing after")
+}
+
+func runClusterDynos(cmd *Command, args []string) {
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	cmd.AssertNumArgsCorrect(args)
+
+	var lr *heroku.ListRange
+	if dynosMax > 0 || dynosAfter != "" {
+		lr = &heroku.ListRange{Field: "name", Max: dynosMax}
+		if dynosAfter != "" {
+			lr.FirstId = "]" + dynosAfter
+		}
+	}
+
+	dynos, err := client.DynoListAll(&heroku.DynoListOpts{
+		Type:   dynosType,
+		State:  dynosState,
+		Host:   dynosHost,
+		Image:  dynosImage,
+		Labels: flagLabels,
+	}, lr)
+	must(err)
+
+	for _, d := range dynos {
+		listRec(w,
+			d.App.Name,
+			d.Name,
+			d.Host.Id,
+			d.State,
+			prettyDuration{dynoAge(&d)},
+			d.Image,
+		)
+	}
+}
+
 func listDyno(w io.Writer, d *heroku.Dyno) {
 	listRec(w,
 		d.Name,
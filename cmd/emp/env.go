@@ -64,9 +64,11 @@ func runGet(cmd *Command, args []string) {
 	fmt.Println(value)
 }
 
+var setSensitive bool
+
 var cmdSet = &Command{
 	Run:             maybeMessage(runSet),
-	Usage:           "set <name>=<value>...",
+	Usage:           "set [--sensitive] <name>=<value>...",
 	NeedsApp:        true,
 	OptionalMessage: true,
 	Category:        "config",
@@ -74,10 +76,17 @@ var cmdSet = &Command{
 	Long: `
 Set the value of an env var.
 
-Example:
+With --sensitive, the env vars are marked as sensitive, and their values are
+redacted when they're read (e.g. by emp env). They stay sensitive, even after
+they're unset, so that the values in earlier releases stay redacted.
+
+Examples:
 
     $ emp set BUILDPACK_URL=http://github.com/kr/heroku-buildpack-inline.git
     Set env vars and restarted myapp.
+
+    $ emp set --sensitive DATABASE_PASSWORD=hunter2
+    Set env vars and restarted myapp.
 `,
 }
 
@@ -97,7 +106,12 @@ func runSet(cmd *Command, args []string) {
 		val := arg[i+1:]
 		config[arg[:i]] = &val
 	}
-	_, err := client.ConfigVarUpdate(appname, config, message)
+	var err error
+	if setSensitive {
+		_, err = client.ConfigVarUpdateSensitive(appname, config, message)
+	} else {
+		_, err = client.ConfigVarUpdate(appname, config, message)
+	}
 	must(err)
 	log.Printf("Set env vars and restarted " + appname + ".")
 }
@@ -130,8 +144,10 @@ func runUnset(cmd *Command, args []string) {
 	for _, key := range args {
 		config[key] = nil
 	}
-	_, err := client.ConfigVarUpdate(appname, config, message)
-	must(err)
+	must(withTwoFactor(func() error {
+		_, err := client.ConfigVarUpdate(appname, config, message)
+		return err
+	}))
 	log.Printf("Unset env vars and restarted %s.", appname)
 }
 
@@ -175,6 +191,7 @@ func runEnvLoad(cmd *Command, args []string) {
 func init() {
 	cmdEnv.Flag.StringVarP(&version, "version", "v", "", "view configs at this release version")
 	cmdGet.Flag.StringVarP(&version, "version", "v", "", "view config at this release version")
+	cmdSet.Flag.BoolVar(&setSensitive, "sensitive", false, "redact the values when they're read")
 }
 
 func getConfigInfo() (map[string]string, error) {
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var execTty bool
+
+var cmdExec = &Command{
+	Run:             maybeMessage(runExec),
+	Usage:           "exec [-t] <name> <command> [<argument>...]",
+	NeedsApp:        true,
+	OptionalMessage: true,
+	Category:        "dyno",
+	Short:           "run a command inside of a running dyno",
+	Long: `
+Run a command inside of an already running dyno, like a shell or a
+diagnostic tool. The name of the dyno is the name displayed by 'emp ps'.
+
+Options:
+
+    -t  allocate a tty for the command
+
+Examples:
+
+    $ emp exec v1.web.8d3a0de3 ps aux
+    USER       PID %CPU %MEM    VSZ   RSS TTY      STAT START   TIME COMMAND
+    root         1  0.0  0.1  18020  2876 ?        Ss   17:02   0:00 ./bin/web
+
+    $ emp exec -t v1.web.8d3a0de3 bash
+    root@8d3a0de3:/app#
+`,
+}
+
+func init() {
+	cmdExec.Flag.BoolVarP(&execTty, "tty", "t", false, "allocate a tty")
+}
+
+func runExec(cmd *Command, args []string) {
+	if len(args) < 2 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+	appname := mustApp()
+	message := getMessage()
+
+	params := struct {
+		Command string `json:"command"`
+		Tty     bool   `json:"tty"`
+	}{
+		Command: strings.Join(args[1:], " "),
+		Tty:     execTty,
+	}
+
+	rh := heroku.RequestHeaders{CommitMessage: message}
+	req, err := client.NewRequest("POST", "/apps/"+appname+"/dynos/"+args[0]+"/exec", params, rh.Headers())
+	must(err)
+
+	attach(req)
+}
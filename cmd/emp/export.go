@@ -0,0 +1,124 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var (
+	exportOutput  string
+	importCluster string
+)
+
+var cmdExport = &Command{
+	Run:      runExport,
+	Usage:    "export [-o <file>]",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "export an app to a portable document",
+	Long: `
+Export writes a JSON document that describes the app completely: its config
+vars, formation, domains, settings, and the image of its current release. It
+can be imported into another Empire with 'emp import', for example to move
+the app, or to rebuild it after a disaster. The document includes the values
+of config vars, so keep it somewhere safe. It requires the admin role on the
+app.
+
+Options:
+
+    -o, --output <file>
+    write the document to a file, instead of stdout.
+
+Examples:
+
+    $ emp export -a acme-inc -o acme-inc.json
+`,
+}
+
+var cmdImport = &Command{
+	Run:             maybeMessage(runImport),
+	Usage:           "import [--cluster <cluster>] <file>",
+	OptionalMessage: true,
+	Category:        "app",
+	NumArgs:         1,
+	Short:           "import an app from an exported document",
+	Long: `
+Import reads a document written by 'emp export' (or stdin, when the file is
+-), and recreates the app. When the app doesn't exist, it's created with the
+settings in the document. Then, like 'emp apply', config vars are set, the
+image is deployed, domains are added and processes are scaled, wherever they
+differ from the document. It requires the admin role.
+
+Options:
+
+    --cluster <cluster>
+    the cluster that the app is scheduled to, when it's created.
+
+Examples:
+
+    $ emp import acme-inc.json
+    create app acme-inc
+    update settings
+    set RAILS_ENV
+    deploy remind101/acme-inc@sha256:c6f77d2098bc0e32aef3102e71b51831a9083dd9356a0ccadca860596a1e9007
+    add domain acme.com
+    scale web=2:2X
+`,
+}
+
+func init() {
+	cmdExport.Flag.StringVarP(&exportOutput, "output", "o", "", "file to write the document to")
+	cmdImport.Flag.StringVar(&importCluster, "cluster", "", "cluster to schedule the app to")
+}
+
+func runExport(cmd *Command, args []string) {
+	appname := mustApp()
+
+	w := os.Stdout
+	if exportOutput != "" {
+		f, err := os.OpenFile(exportOutput, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		must(err)
+		defer f.Close()
+		w = f
+	}
+
+	must(client.AppExport(appname, w))
+}
+
+func runImport(cmd *Command, args []string) {
+	cmd.AssertNumArgsCorrect(args)
+
+	var (
+		b   []byte
+		err error
+	)
+	if args[0] == "-" {
+		b, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		b, err = ioutil.ReadFile(args[0])
+	}
+	must(err)
+
+	// Imports into protected apps that unset config vars, or scale down
+	// processes, need a two factor code.
+	var result *heroku.AppImportResult
+	must(withTwoFactor(func() error {
+		result, err = client.AppImport(&heroku.AppImportOpts{
+			Export:  b,
+			Cluster: importCluster,
+		}, getMessage())
+		return err
+	}))
+
+	if len(result.Changes) == 0 {
+		log.Printf("%s is up to date.", result.App)
+		return
+	}
+
+	for _, change := range result.Changes {
+		log.Println(change)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"log"
+	"os"
+	"text/tabwriter"
+)
+
+var cmdCordon = &Command{
+	Run:             maybeMessage(runCordon),
+	Usage:           "cordon <host>",
+	OptionalMessage: true,
+	Category:        "dyno",
+	NumArgs:         1,
+	Short:           "stop placing new dynos on a host",
+	Long: `
+Cordons a host, so that no new dynos are placed on it. Dynos that are already
+running on the host are left alone. This requires admin access.
+
+Example:
+
+    $ emp cordon i-042f39dc
+    Cordoned i-042f39dc.
+`,
+}
+
+func runCordon(cmd *Command, args []string) {
+	cmd.AssertNumArgsCorrect(args)
+	message := getMessage()
+	host := args[0]
+
+	must(client.HostCordon(host, message))
+	log.Printf("Cordoned %s.", host)
+}
+
+var cmdUncordon = &Command{
+	Run:             maybeMessage(runUncordon),
+	Usage:           "uncordon <host>",
+	OptionalMessage: true,
+	Category:        "dyno",
+	NumArgs:         1,
+	Short:           "allow dynos to be placed on a host again",
+	Long: `
+Uncordons a cordoned or drained host, so that new dynos can be placed on it
+again. This requires admin access.
+
+Example:
+
+    $ emp uncordon i-042f39dc
+    Uncordoned i-042f39dc.
+`,
+}
+
+func runUncordon(cmd *Command, args []string) {
+	cmd.AssertNumArgsCorrect(args)
+	message := getMessage()
+	host := args[0]
+
+	must(client.HostUncordon(host, message))
+	log.Printf("Uncordoned %s.", host)
+}
+
+var cmdDrain = &Command{
+	Run:             maybeMessage(runDrain),
+	Usage:           "drain <host>",
+	OptionalMessage: true,
+	Category:        "dyno",
+	NumArgs:         1,
+	Short:           "move all dynos off of a host",
+	Long: `
+Drains a host. The host is cordoned, then the dynos running on it are replaced
+by dynos on other hosts, without letting the number of healthy dynos for a
+process drop below what's allowed during a deploy. One-off dynos are left
+running until they exit. Use 'emp cluster-ps -H <host>' to follow progress,
+and 'emp uncordon <host>' to start using the host again. This requires admin
+access.
+
+Example:
+
+    $ emp drain i-042f39dc
+    acme-inc  v1.web.dcc9a8c4-c0f8-4478-aa8a-f9148b362401  RUNNING
+    blog      v3.web.2bcb6e08-ef99-447f-8e7a-416d94769010  RUNNING
+    Draining i-042f39dc. 2 dynos will be moved to other hosts.
+`,
+}
+
+func runDrain(cmd *Command, args []string) {
+	cmd.AssertNumArgsCorrect(args)
+	message := getMessage()
+	host := args[0]
+
+	dynos, err := client.HostDrain(host, message)
+	must(err)
+
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	for _, d := range dynos {
+		listRec(w, d.App.Name, d.Name, d.State)
+	}
+	w.Flush()
+
+	log.Printf("Draining %s. %d dynos will be moved to other hosts.", host, len(dynos))
+}
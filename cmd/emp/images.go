@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/term"
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var (
+	imageLayer       string
+	imageTag         string
+	imageParallelism int
+	imageInterval    int
+)
+
+var cmdImageUsage = &Command{
+	Run:      runImageUsage,
+	Usage:    "image-usage [--layer <digest>] [<image>]",
+	Category: "deploy",
+	Short:    "list apps running an image",
+	Long: `
+Lists the apps whose current release runs an image, or an image with a
+layer. Without a tag or digest, every image in the repository matches. A
+layer matches apps whose image was built on it, like the top layer of a base
+image with a CVE. It requires the admin role.
+
+Options:
+
+    --layer <digest>    only list apps whose image has this layer
+
+Examples:
+
+    $ emp image-usage remind101/acme-inc
+    acme-inc  v12  remind101/acme-inc@sha256:c6f77d2098bc...
+
+    $ emp image-usage --layer sha256:5bef08742407...
+    acme-inc  v12  remind101/acme-inc@sha256:c6f77d2098bc...
+    api       v40  remind101/api@sha256:1d2a3f0c4b5e...
+`,
+}
+
+var cmdImageRedeploy = &Command{
+	Run:             maybeMessage(runImageRedeploy),
+	Usage:           "image-redeploy [--layer <digest>] [-t <tag>] [-p <parallelism>] [-i <seconds>] [<image>]",
+	OptionalMessage: true,
+	Category:        "deploy",
+	Short:           "redeploy apps running an image, in waves",
+	Long: `
+Redeploys the apps that 'emp image-usage' lists, a few at a time, for example
+to roll out images that were rebuilt on a patched base image. Each deploy
+waits until the new release is running, and no more apps are deployed once a
+deploy fails. It requires the admin role.
+
+Options:
+
+    --layer <digest>  redeploy apps whose image has this layer
+    -t <tag>          deploy this tag of each app's repository, instead of
+                      its current image
+    -p <parallelism>  the number of apps deployed at once (default: 1)
+    -i <seconds>      seconds to wait between each wave of deploys
+
+Examples:
+
+    $ emp image-redeploy --layer sha256:5bef08742407... -t latest -p 2 -i 60
+    Status: Redeploying 3 apps, 2 at a time
+    Status: Deploying remind101/acme-inc:latest to acme-inc (1/3)
+    Status: Deploying remind101/api:latest to api (2/3)
+    ...
+`,
+}
+
+func init() {
+	cmdImageUsage.Flag.StringVar(&imageLayer, "layer", "", "layer digest")
+
+	cmdImageRedeploy.Flag.StringVar(&imageLayer, "layer", "", "layer digest")
+	cmdImageRedeploy.Flag.StringVarP(&imageTag, "tag", "t", "", "tag to deploy")
+	cmdImageRedeploy.Flag.IntVarP(&imageParallelism, "parallelism", "p", 1, "apps deployed at once")
+	cmdImageRedeploy.Flag.IntVarP(&imageInterval, "interval", "i", 0, "seconds between waves")
+}
+
+func runImageUsage(cmd *Command, args []string) {
+	img := imageArg(cmd, args)
+
+	usage, err := client.ImageUsageList(img, imageLayer)
+	must(err)
+
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+
+	for _, u := range usage {
+		listRec(w, u.App.Name, fmt.Sprintf("v%d", u.Release.Version), u.Image)
+	}
+}
+
+func runImageRedeploy(cmd *Command, args []string) {
+	img := imageArg(cmd, args)
+	message := getMessage()
+
+	r, w := io.Pipe()
+	go func() {
+		defer w.Close()
+		must(client.ImageRedeploy(&heroku.ImageRedeployOpts{
+			Image:       img,
+			Layer:       imageLayer,
+			Tag:         imageTag,
+			Parallelism: imageParallelism,
+			Interval:    imageInterval,
+		}, message, w))
+	}()
+
+	outFd, isTerminalOut := term.GetFdInfo(os.Stdout)
+	must(jsonmessage.DisplayJSONMessagesStream(r, os.Stdout, outFd, isTerminalOut, nil))
+}
+
+// imageArg returns the image argument, which is required unless a layer is
+// given.
+func imageArg(cmd *Command, args []string) string {
+	if len(args) > 1 || (len(args) == 0 && imageLayer == "") {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+	return strings.Join(args, "")
+}
@@ -1,6 +1,10 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
 
 var cmdInfo = &Command{
 	Run:      runInfo,
@@ -9,16 +13,84 @@ var cmdInfo = &Command{
 	Category: "app",
 	NumArgs:  0,
 	Short:    "show app info",
-	Long:     `Info shows general information about the current app.`,
+	Long: `
+Info shows general information about the current app, along with its
+current release, processes, domains and recent releases.
+
+Example:
+
+    $ emp info -a myapp
+    Name: myapp
+    ID: 0e4a7b4b-2c10-4c3e-9b1f-43d1b6a4e2a1
+    Maintenance: off
+    Cert:
+    Release: v12
+    Config: 8a2d7bbc-0f3a-4a4c-a62c-7d4f1cbe1a0d
+    Processes:
+      web: 2 x 1X (RUNNING: 2)
+      worker: 1 x 1X (PENDING: 1)
+    Domains:
+      myapp.example.com
+    Recent releases:
+      v12  Deploy remind101/myapp:0fda0ae
+      v11  Set FOO config var
+`,
 }
 
 func runInfo(cmd *Command, args []string) {
 	cmd.AssertNumArgsCorrect(args)
 
-	app, err := client.AppInfo(mustApp())
+	info, err := client.AppOverviewInfo(mustApp())
 	must(err)
+	app := info.App
 	fmt.Printf("Name: %s\n", app.Name)
 	fmt.Printf("ID: %s\n", app.Id)
 	fmt.Printf("Maintenance: %s\n", fmtMaintenance(app.Maintenance))
 	fmt.Printf("Cert: %s\n", app.Cert)
+	if info.Release != nil {
+		fmt.Printf("Release: v%d\n", info.Release.Version)
+	}
+	if info.ConfigId != "" {
+		fmt.Printf("Config: %s\n", info.ConfigId)
+	}
+
+	if len(info.Processes) > 0 {
+		fmt.Println("Processes:")
+		for _, p := range info.Processes {
+			fmt.Printf("  %s: %d x %s (%s)\n", p.Type, p.Quantity, p.Size, fmtStates(p.States))
+		}
+	}
+
+	if len(info.Domains) > 0 {
+		fmt.Println("Domains:")
+		for _, d := range info.Domains {
+			fmt.Printf("  %s\n", d.Hostname)
+		}
+	}
+
+	if len(info.Releases) > 0 {
+		fmt.Println("Recent releases:")
+		for _, r := range info.Releases {
+			fmt.Printf("  v%d  %s\n", r.Version, r.Description)
+		}
+	}
+}
+
+// fmtStates formats the number of dynos in each state (e.g. "RUNNING: 2").
+func fmtStates(states map[string]int) string {
+	if len(states) == 0 {
+		return "no dynos"
+	}
+
+	var names []string
+	for state := range states {
+		names = append(names, state)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, state := range names {
+		parts[i] = fmt.Sprintf("%s: %d", state, states[state])
+	}
+	return strings.Join(parts, ", ")
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var cmdIngress = &Command{
+	Run:      runIngress,
+	Usage:    "ingress",
+	NeedsApp: true,
+	Category: "ingress",
+	NumArgs:  0,
+	Short:    "list ingress rules",
+	Long: `
+Lists the apps that are allowed to connect to an app. Apps without any
+ingress rules can be reached by anything in the network.
+
+Examples:
+
+    $ emp ingress -a users
+    01234567-89ab-cdef-0123-456789abcdef  api      50051
+    12345678-9abc-def0-1234-56789abcdef0  billing  50051
+`,
+}
+
+func runIngress(cmd *Command, args []string) {
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+
+	appname := mustApp()
+	cmd.AssertNumArgsCorrect(args)
+	rules, err := client.IngressRuleList(appname, &heroku.ListRange{
+		Field: "port",
+		Max:   1000,
+	})
+	must(err)
+
+	for _, r := range rules {
+		listRec(w, r.Id, r.SourceApp.Name, r.Port)
+	}
+}
+
+var cmdIngressAllow = &Command{
+	Run:      runIngressAllow,
+	Usage:    "ingress-allow <source-app> <port>",
+	NeedsApp: true,
+	Category: "ingress",
+	NumArgs:  2,
+	Short:    "allow an app to connect",
+	Long: `
+Allows the processes of another app to connect to a port of an app's load
+balancer. Once an app has an ingress rule, only connections allowed by its
+rules will be accepted. Rules take effect the next time the app is released.
+
+Examples:
+
+    $ emp ingress-allow api 50051 -a users
+`,
+}
+
+func runIngressAllow(cmd *Command, args []string) {
+	appname := mustApp()
+	cmd.AssertNumArgsCorrect(args)
+	port, err := strconv.Atoi(args[1])
+	if err != nil {
+		printFatal("invalid port: %s", args[1])
+	}
+	_, err = client.IngressRuleCreate(appname, &heroku.IngressRuleCreateOpts{
+		SourceApp: args[0],
+		Port:      port,
+	})
+	must(err)
+	log.Printf("Allowed %s to connect to port %d of %s.", args[0], port, appname)
+}
+
+var cmdIngressRevoke = &Command{
+	Run:      runIngressRevoke,
+	Usage:    "ingress-revoke <id>",
+	NeedsApp: true,
+	Category: "ingress",
+	NumArgs:  1,
+	Short:    "remove an ingress rule",
+}
+
+func runIngressRevoke(cmd *Command, args []string) {
+	appname := mustApp()
+	cmd.AssertNumArgsCorrect(args)
+	must(client.IngressRuleDelete(appname, args[0]))
+	log.Printf("Removed ingress rule %s from %s.", args[0], appname)
+}
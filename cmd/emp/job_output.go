@@ -0,0 +1,29 @@
+package main
+
+import "os"
+
+var cmdJobOutput = &Command{
+	Run:      runJobOutput,
+	Usage:    "job-output <id>",
+	NeedsApp: true,
+	Category: "dyno",
+	NumArgs:  1,
+	Short:    "show the captured output of a one-off process",
+	Long: `
+Shows the output of a detached one-off process that was run with
+` + "`emp run -d --capture`" + `. The output includes everything that the process has
+written so far, so it can be retrieved while the process is still running.
+
+Examples:
+
+    $ emp job-output 01234567-89ab-cdef-0123-456789abcdef -a acme-inc
+    Cleaning up expired sessions...
+    Removed 1024 sessions.
+`,
+}
+
+func runJobOutput(cmd *Command, args []string) {
+	appname := mustApp()
+	cmd.AssertNumArgsCorrect(args)
+	must(client.JobOutput(appname, args[0], os.Stdout))
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+// flagLabels is a label selector (e.g. "tier=web,!deprecated") that commands
+// which list apps, processes or usage can filter by.
+var flagLabels string
+
+var cmdLabels = &Command{
+	Run:      runLabels,
+	Usage:    "labels",
+	NeedsApp: true,
+	Category: "app",
+	NumArgs:  0,
+	Short:    "list app labels",
+	Long: `
+Lists the labels of an app. Labels are key/value pairs, like the tier or cost
+center of an app, which apps can be selected by with -l in 'emp apps',
+'emp cluster-ps' and 'emp usage'.
+
+Example:
+
+    $ emp labels -a acme-inc
+    cost-center=1234
+    tier=web
+`,
+}
+
+func runLabels(cmd *Command, args []string) {
+	cmd.AssertNumArgsCorrect(args)
+	app, err := client.AppInfo(mustApp())
+	must(err)
+
+	var keys []string
+	for k := range app.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("%s=%s\n", k, app.Labels[k])
+	}
+}
+
+var cmdLabelSet = &Command{
+	Run:      runLabelSet,
+	Usage:    "label-set <key>=<value>...",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "set app labels",
+	Long: `
+Sets labels of an app.
+
+Example:
+
+    $ emp label-set tier=web cost-center=1234 -a acme-inc
+    Set labels of acme-inc.
+`,
+}
+
+func runLabelSet(cmd *Command, args []string) {
+	appname := mustApp()
+	if len(args) == 0 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+	labels := make(map[string]*string)
+	for _, arg := range args {
+		i := strings.Index(arg, "=")
+		if i < 0 {
+			printFatal("bad format: %#q. See 'emp help label-set'", arg)
+		}
+		val := arg[i+1:]
+		labels[arg[:i]] = &val
+	}
+	_, err := client.AppUpdate(appname, &heroku.AppUpdateOpts{Labels: labels}, "")
+	must(err)
+	log.Printf("Set labels of %s.", appname)
+}
+
+var cmdLabelUnset = &Command{
+	Run:      runLabelUnset,
+	Usage:    "label-unset <key>...",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "unset app labels",
+	Long: `
+Removes labels from an app.
+
+Example:
+
+    $ emp label-unset cost-center -a acme-inc
+    Unset labels of acme-inc.
+`,
+}
+
+func runLabelUnset(cmd *Command, args []string) {
+	appname := mustApp()
+	if len(args) == 0 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+	labels := make(map[string]*string)
+	for _, key := range args {
+		labels[key] = nil
+	}
+	_, err := client.AppUpdate(appname, &heroku.AppUpdateOpts{Labels: labels}, "")
+	must(err)
+	log.Printf("Unset labels of %s.", appname)
+}
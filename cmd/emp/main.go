@@ -116,9 +116,19 @@ var commands = []*Command{
 	cmdCreate,
 	cmdApps,
 	cmdDynos,
+	cmdClusterDynos,
+	cmdCordon,
+	cmdUncordon,
+	cmdDrain,
+	cmdCapacity,
+	cmdUsage,
 	cmdReleases,
 	cmdReleaseInfo,
+	cmdDeployLog,
+	cmdChangelog,
+	cmdActivity,
 	cmdRollback,
+	cmdTraffic,
 	cmdScale,
 	cmdRestart,
 	cmdEnvLoad,
@@ -126,6 +136,24 @@ var commands = []*Command{
 	cmdUnset,
 	cmdEnv,
 	cmdRun,
+	cmdBatchRun,
+	cmdBatches,
+	cmdBatchInfo,
+	cmdJobOutput,
+	cmdCronRuns,
+	cmdCronTrigger,
+	cmdCronTimezone,
+	cmdAlertRoutingKey,
+	cmdChaos,
+	cmdChaosKills,
+	cmdTimeline,
+	cmdLabels,
+	cmdLabelSet,
+	cmdLabelUnset,
+	cmdBulk,
+	cmdExec,
+	cmdPortForward,
+	cmdCp,
 	cmdLog,
 	cmdInfo,
 	cmdRename,
@@ -133,8 +161,22 @@ var commands = []*Command{
 	cmdDomains,
 	cmdDomainAdd,
 	cmdDomainRemove,
+	cmdIngress,
+	cmdIngressAllow,
+	cmdIngressRevoke,
+	cmdRouter,
+	cmdRoutes,
+	cmdRouteAdd,
+	cmdRouteRemove,
 	cmdCertAttach,
 	cmdDeploy,
+	cmdDeployTimeout,
+	cmdApply,
+	cmdExport,
+	cmdImport,
+	cmdImageUsage,
+	cmdImageRedeploy,
+	cmdReloadConfig,
 	cmdVersion,
 	cmdHelp,
 
@@ -154,6 +196,9 @@ var commands = []*Command{
 	cmdMaintenance,
 	cmdMaintenanceEnable,
 	cmdMaintenanceDisable,
+	cmdProtect,
+	cmdUnprotect,
+	cmdTwoFactorEnroll,
 	cmdSSL,
 	cmdSSLCertAdd,
 	cmdSSLCertRollback,
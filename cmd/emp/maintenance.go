@@ -53,8 +53,11 @@ func runMaintenanceEnable(cmd *Command, args []string) {
 		os.Exit(2)
 	}
 	newmode := true
-	app, err := client.AppUpdate(mustApp(), &heroku.AppUpdateOpts{Maintenance: &newmode}, message)
-	must(err)
+	var app *heroku.App
+	must(withTwoFactor(func() (err error) {
+		app, err = client.AppUpdate(mustApp(), &heroku.AppUpdateOpts{Maintenance: &newmode}, message)
+		return err
+	}))
 	log.Printf("Enabled maintenance mode on %s.", app.Name)
 }
 
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var cmdPortForward = &Command{
+	Run:             maybeMessage(runPortForward),
+	Usage:           "port-forward <name> [<local port>:]<port>",
+	NeedsApp:        true,
+	OptionalMessage: true,
+	Category:        "dyno",
+	Short:           "forward a local port to a running dyno",
+	Long: `
+Forward a local port to a port inside of a running dyno, without exposing the
+port publicly. This is useful for debugging things like admin interfaces or
+pprof endpoints. The name of the dyno is the name displayed by 'emp ps'.
+
+The local port defaults to the same port as the dyno. Connections are accepted
+on 127.0.0.1 until the command is interrupted.
+
+Examples:
+
+    $ emp port-forward v1.web.8d3a0de3 6060
+    Forwarding 127.0.0.1:6060 to port 6060 on v1.web.8d3a0de3.
+
+    $ emp port-forward v1.web.8d3a0de3 8080:6060
+    Forwarding 127.0.0.1:8080 to port 6060 on v1.web.8d3a0de3.
+`,
+}
+
+func runPortForward(cmd *Command, args []string) {
+	if len(args) != 2 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+	appname := mustApp()
+	message := getMessage()
+
+	name := args[0]
+	localPort, port, err := parsePortMapping(args[1])
+	if err != nil {
+		printFatal("%s", err)
+	}
+
+	l, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort)))
+	must(err)
+	defer l.Close()
+
+	log.Printf("Forwarding %s to port %d on %s.", l.Addr(), port, name)
+
+	for {
+		conn, err := l.Accept()
+		must(err)
+
+		go func() {
+			defer conn.Close()
+			if err := forwardPort(appname, name, port, message, conn); err != nil {
+				log.Printf("error forwarding connection: %v", err)
+			}
+		}()
+	}
+}
+
+// forwardPort opens a new port forwarding stream through the Empire API, and
+// copies data between it and the local connection.
+func forwardPort(appname, name string, port int, message string, conn net.Conn) error {
+	params := struct {
+		Port int `json:"port"`
+	}{
+		Port: port,
+	}
+
+	rh := heroku.RequestHeaders{CommitMessage: message}
+	req, err := client.NewRequest("POST", "/apps/"+appname+"/dynos/"+name+"/port-forward", params, rh.Headers())
+	if err != nil {
+		return err
+	}
+
+	rwc, br, _, err := hijack(req)
+	if err != nil {
+		return err
+	}
+	defer rwc.Close()
+
+	errCh := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(rwc, conn)
+		errCh <- err
+	}()
+	go func() {
+		_, err := io.Copy(conn, br)
+		errCh <- err
+	}()
+	return <-errCh
+}
+
+// parsePortMapping parses a port mapping in the form [<local port>:]<port>.
+func parsePortMapping(s string) (localPort, port int, err error) {
+	parts := strings.SplitN(s, ":", 2)
+
+	port, err = strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port mapping %q, expected [<local port>:]<port>", s)
+	}
+
+	localPort = port
+	if len(parts) == 2 {
+		localPort, err = strconv.Atoi(parts[0])
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid port mapping %q, expected [<local port>:]<port>", s)
+		}
+	}
+
+	return localPort, port, nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePortMapping(t *testing.T) {
+	tests := []struct {
+		in string
+
+		localPort, port int
+		err             bool
+	}{
+		{"6060", 6060, 6060, false},
+		{"8080:6060", 8080, 6060, false},
+		{"", 0, 0, true},
+		{"abc", 0, 0, true},
+		{"abc:6060", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		localPort, port, err := parsePortMapping(tt.in)
+		if tt.err {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, tt.localPort, localPort)
+		assert.Equal(t, tt.port, port)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"log"
@@ -11,6 +12,8 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/term"
 	"github.com/remind101/empire/pkg/heroku"
 )
 
@@ -156,7 +159,14 @@ func (a releasesByVersion) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a releasesByVersion) Less(i, j int) bool { return a[i].Version < a[j].Version }
 
 func newRelease(rel *heroku.Release) *Release {
-	return &Release{*rel, "", ""}
+	r := &Release{*rel, "", ""}
+	if rel.Provenance != nil {
+		r.Commit = rel.Provenance.Commit
+		if len(r.Commit) > 7 {
+			r.Commit = r.Commit[:7]
+		}
+	}
+	return r
 }
 
 var cmdReleaseInfo = &Command{
@@ -174,10 +184,17 @@ Examples:
     $ emp release-info v116
     Version:  v116
     By:       user@test.com
+    Source:   deploy
     Change:   Deploy 62b3059
     When:     2014-01-13T21:20:57Z
     Id:       abcd1234-5678-def0-8190-12347060474d
     Slug:     98765432-82ba-10ba-fedc-8d206789d062
+    Repo:     github.com/remind101/acme-inc
+    Commit:   62b3059f1b2c4d5e6f708192a3b4c5d6e7f80912
+    Build:    https://ci.example.com/builds/42
+    Builder:  github
+    Changes:  image: remind101/acme-inc:3ae20c2 => remind101/acme-inc:62b3059
+              web: 1:1X => 2:1X
 `,
 }
 
@@ -190,13 +207,39 @@ func runReleaseInfo(cmd *Command, args []string) {
 	must(err)
 
 	fmt.Printf("Version:  v%d\n", rel.Version)
-	fmt.Printf("By:       %s\n", rel.User.Email)
+	by := rel.User.Email
+	if by == "" {
+		by = rel.User.Name
+	}
+	fmt.Printf("By:       %s\n", by)
+	if rel.Source != "" {
+		fmt.Printf("Source:   %s\n", rel.Source)
+	}
 	fmt.Printf("Change:   %s\n", rel.Description)
 	fmt.Printf("When:     %s\n", rel.CreatedAt.UTC().Format(time.RFC3339))
 	fmt.Printf("Id:       %s\n", rel.Id)
 	if rel.Slug != nil {
 		fmt.Printf("Slug:     %s\n", rel.Slug.Id)
 	}
+	if p := rel.Provenance; p != nil {
+		printProvenance("Repo", p.Repo)
+		printProvenance("Commit", p.Commit)
+		printProvenance("Build", p.BuildURL)
+		printProvenance("Builder", p.Builder)
+	}
+	for i, line := range formatReleaseChanges(rel.Changes) {
+		if i == 0 {
+			fmt.Printf("Changes:  %s\n", line)
+		} else {
+			fmt.Printf("          %s\n", line)
+		}
+	}
+}
+
+func printProvenance(name, value string) {
+	if value != "" {
+		fmt.Printf("%-10s%s\n", name+":", value)
+	}
 }
 
 var cmdRollback = &Command{
@@ -251,3 +294,37 @@ func rollbackSafetyCheck(verStr, appname string) {
 		mustConfirm(warning, fmt.Sprintf("v%s", verStr))
 	}
 }
+
+var cmdDeployLog = &Command{
+	Run:      runDeployLog,
+	Usage:    "deploy-log <version>",
+	NeedsApp: true,
+	Category: "release",
+	NumArgs:  1,
+	Short:    "show the output of the deploy that created a release",
+	Long: `
+Shows the output of the deploy that created a release, as it was streamed
+when the release was deployed. Logs are only stored when Empire is configured
+with a blob store, and may expire.
+
+Examples:
+
+    $ emp deploy-log v116
+    Pulling repository remind101/acme-inc
+    345c7524bc96: Download complete
+    Status: Image is up to date for remind101/acme-inc:62b3059
+    Status: Created new release v116 for acme-inc
+    Status: Finished processing events for release v116 of acme-inc
+`,
+}
+
+func runDeployLog(cmd *Command, args []string) {
+	appname := mustApp()
+	cmd.AssertNumArgsCorrect(args)
+
+	var buf bytes.Buffer
+	must(client.ReleaseDeployLog(appname, strings.TrimPrefix(args[0], "v"), &buf))
+
+	outFd, isTerminalOut := term.GetFdInfo(os.Stdout)
+	must(jsonmessage.DisplayJSONMessagesStream(&buf, os.Stdout, outFd, isTerminalOut, nil))
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"log"
+	"strings"
+)
+
+var cmdReloadConfig = &Command{
+	Run:      runReloadConfig,
+	Usage:    "reload-config",
+	Category: "emp",
+	NumArgs:  0,
+	Short:    "reload the operator configuration",
+	Long: `
+Reloads the operator configuration (process sizes, quotas, freeze windows and
+notification targets) from the file that Empire was started with, without
+restarting Empire. Deploys that are in progress aren't affected. This
+requires admin access.
+
+Example:
+
+    $ emp reload-config
+    Reloaded operator config: sizes 4X, 8X; 2 quotas; 1 freeze windows.
+`,
+}
+
+func runReloadConfig(cmd *Command, args []string) {
+	cmd.AssertNumArgsCorrect(args)
+
+	config, err := client.OperatorConfigReload()
+	must(err)
+
+	sizes := "none"
+	if len(config.Sizes) > 0 {
+		sizes = strings.Join(config.Sizes, ", ")
+	}
+	log.Printf("Reloaded operator config: sizes %s; %d quotas; %d freeze windows.", sizes, config.Quotas, config.FreezeWindows)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var cmdRouter = &Command{
+	Run:      runRouter,
+	Usage:    "router [<setting>=<value>...]",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "show or change router settings",
+	Long: `
+Shows, or changes, how the load balancers of an app handle connections to its
+web processes. The available settings are:
+
+    idle-timeout      How long a connection can be idle before it's closed
+                      (e.g. 5m). 0 uses the default of 60 seconds.
+    drain-timeout     How long in flight requests to old dynos are given to
+                      finish when a release is deployed (e.g. 2m). Can be
+                      "immediate" to stop old dynos right away, "max" to
+                      wait as long as possible (1 hour) for connections to
+                      drain, or "default" for the default of 30 seconds.
+                      Processes can override it with drain_timeout in the
+                      Procfile.
+    websockets        Whether connections can be upgraded to WebSockets.
+    sticky-sessions   Whether requests from the same client are sent to the
+                      same dyno.
+    protocol-version  The version of HTTP that requests are sent to dynos
+                      with: http1 (the default), http2 (h2c) or grpc.
+    allow             A comma separated list of CIDR blocks that connections
+                      are accepted from. Empty accepts connections from
+                      anywhere.
+    basic-auth        Credentials, as username:password, that requests need
+                      to have. Empty disables basic auth.
+
+Changing a setting releases the app.
+
+Examples:
+
+    $ emp router -a myapp
+    idle-timeout:      0s
+    drain-timeout:     default
+    websockets:        false
+    sticky-sessions:   false
+    protocol-version:  http1
+    allow:             anywhere
+    basic-auth:        off
+    $ emp router idle-timeout=5m websockets=true -a myapp
+    Updated router settings for myapp.
+    $ emp router drain-timeout=immediate -a myapp
+    Updated router settings for myapp.
+    $ emp router allow=203.0.113.0/24 basic-auth=staging:secret -a myapp
+    Updated router settings for myapp.
+`,
+}
+
+func runRouter(cmd *Command, args []string) {
+	appname := mustApp()
+
+	if len(args) == 0 {
+		app, err := client.AppInfo(appname)
+		must(err)
+		w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+		defer w.Flush()
+		fmt.Fprintf(w, "idle-timeout:\t%s\n", time.Duration(app.Router.IdleTimeout)*time.Second)
+		drainTimeout := "default"
+		if app.Router.DrainTimeout != nil {
+			drainTimeout = (time.Duration(*app.Router.DrainTimeout) * time.Second).String()
+		}
+		fmt.Fprintf(w, "drain-timeout:\t%s\n", drainTimeout)
+		fmt.Fprintf(w, "websockets:\t%t\n", app.Router.WebSockets)
+		fmt.Fprintf(w, "sticky-sessions:\t%t\n", app.Router.StickySessions)
+		protocolVersion := app.Router.ProtocolVersion
+		if protocolVersion == "" {
+			protocolVersion = "http1"
+		}
+		fmt.Fprintf(w, "protocol-version:\t%s\n", protocolVersion)
+		allow := "anywhere"
+		if len(app.Router.AllowedCIDRs) > 0 {
+			allow = strings.Join(app.Router.AllowedCIDRs, ",")
+		}
+		fmt.Fprintf(w, "allow:\t%s\n", allow)
+		basicAuth := "off"
+		if app.Router.BasicAuthUsername != "" {
+			basicAuth = app.Router.BasicAuthUsername
+		}
+		fmt.Fprintf(w, "basic-auth:\t%s\n", basicAuth)
+		return
+	}
+
+	opts := new(heroku.AppRouterUpdateOpts)
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			printFatal("invalid setting: %s", arg)
+		}
+		key, value := parts[0], parts[1]
+		switch key {
+		case "idle-timeout":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				printFatal("invalid idle timeout: %s", value)
+			}
+			seconds := int(d.Seconds())
+			opts.IdleTimeout = &seconds
+		case "drain-timeout":
+			var seconds int
+			switch value {
+			case "default":
+				seconds = -1
+			case "immediate":
+				seconds = 0
+			case "max":
+				seconds = int(time.Hour.Seconds())
+			default:
+				d, err := time.ParseDuration(value)
+				if err != nil || d < 0 {
+					printFatal("invalid drain timeout: %s", value)
+				}
+				seconds = int(d.Seconds())
+			}
+			opts.DrainTimeout = &seconds
+		case "websockets":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				printFatal("invalid value for websockets: %s", value)
+			}
+			opts.WebSockets = &b
+		case "sticky-sessions":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				printFatal("invalid value for sticky-sessions: %s", value)
+			}
+			opts.StickySessions = &b
+		case "protocol-version":
+			opts.ProtocolVersion = &value
+		case "allow":
+			cidrs := []string{}
+			if value != "" {
+				cidrs = strings.Split(value, ",")
+			}
+			opts.AllowedCIDRs = &cidrs
+		case "basic-auth":
+			opts.BasicAuth = &value
+		default:
+			printFatal("unknown router setting: %s", key)
+		}
+	}
+
+	_, err := client.AppUpdate(appname, &heroku.AppUpdateOpts{Router: opts}, "")
+	must(err)
+	log.Printf("Updated router settings for %s.", appname)
+}
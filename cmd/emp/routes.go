@@ -0,0 +1,107 @@
+package main
+
+import (
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var (
+	routeHost string
+	routePath string
+)
+
+var cmdRoutes = &Command{
+	Run:      runRoutes,
+	Usage:    "routes",
+	NeedsApp: true,
+	Category: "routing",
+	NumArgs:  0,
+	Short:    "list routing rules",
+	Long: `
+Lists the rules that route requests to the processes of an app, in the order
+that they're evaluated. Requests that don't match a rule go to the web process.
+
+Examples:
+
+    $ emp routes -a acme-inc
+    01234567-89ab-cdef-0123-456789abcdef  api.acme.com  /v2/*  api-v2
+    12345678-9abc-def0-1234-56789abcdef0                /api/*  api
+`,
+}
+
+func runRoutes(cmd *Command, args []string) {
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+
+	appname := mustApp()
+	cmd.AssertNumArgsCorrect(args)
+	rules, err := client.RoutingRuleList(appname, &heroku.ListRange{
+		Field: "id",
+		Max:   1000,
+	})
+	must(err)
+
+	for _, r := range rules {
+		listRec(w, r.Id, r.Host, r.Path, r.Process)
+	}
+}
+
+var cmdRouteAdd = &Command{
+	Run:      runRouteAdd,
+	Usage:    "route-add [--host <host>] [--path <path>] <process>",
+	NeedsApp: true,
+	Category: "routing",
+	NumArgs:  1,
+	Short:    "route requests to a process",
+	Long: `
+Routes the requests that the web process of an app receives to another process,
+when they match a host, a path, or both. "*" and "?" can be used as wildcards.
+When multiple rules match a request, the most specific one wins: rules with both
+a host and a path, then rules with longer paths. Rules take effect the next
+time the app is released.
+
+The web process, and the processes that requests are routed to, need to use an
+Application Load Balancer.
+
+Examples:
+
+    $ emp route-add --path '/api/*' api -a acme-inc
+    $ emp route-add --host admin.acme.com admin -a acme-inc
+`,
+}
+
+func init() {
+	cmdRouteAdd.Flag.StringVar(&routeHost, "host", "", "host that requests must be for")
+	cmdRouteAdd.Flag.StringVar(&routePath, "path", "", "path that requests must be for")
+}
+
+func runRouteAdd(cmd *Command, args []string) {
+	appname := mustApp()
+	cmd.AssertNumArgsCorrect(args)
+	rule, err := client.RoutingRuleCreate(appname, &heroku.RoutingRuleCreateOpts{
+		Host:    routeHost,
+		Path:    routePath,
+		Process: args[0],
+	})
+	must(err)
+	log.Printf("Added routing rule %s to %s.", rule.Id, appname)
+}
+
+var cmdRouteRemove = &Command{
+	Run:      runRouteRemove,
+	Usage:    "route-remove <id>",
+	NeedsApp: true,
+	Category: "routing",
+	NumArgs:  1,
+	Short:    "remove a routing rule",
+}
+
+func runRouteRemove(cmd *Command, args []string) {
+	appname := mustApp()
+	cmd.AssertNumArgsCorrect(args)
+	must(client.RoutingRuleDelete(appname, args[0]))
+	log.Printf("Removed routing rule %s from %s.", args[0], appname)
+}
@@ -1,16 +1,19 @@
 package main
 
 import (
+	"bufio"
 	"crypto/tls"
 	"fmt"
 	"io"
 	"log"
 	"net"
+	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/pkg/term"
 	"github.com/remind101/empire/pkg/heroku"
@@ -20,11 +23,15 @@ import (
 var (
 	detachedRun bool
 	dynoSize    string
+	runTimeout  time.Duration
+	runCPU      string
+	runMemory   string
+	runCapture  bool
 )
 
 var cmdRun = &Command{
 	Run:             maybeMessage(runRun),
-	Usage:           "run [-s <size>] [-d] <command> [<argument>...]",
+	Usage:           "run [-s <size>] [--cpu <shares>] [--memory <memory>] [-d [--capture] [--idempotency-key <key>]] [-t <timeout>] <command> [<argument>...]",
 	NeedsApp:        true,
 	OptionalMessage: true,
 	Category:        "dyno",
@@ -40,8 +47,15 @@ See https://github.com/remind101/empire/issues/609
 
 Options:
 
-    -s <size>  set the size for this dyno (e.g. 2X)
-    -d         run in detached mode instead of attached to terminal
+    -s <size>          set the size for this dyno (e.g. 2X)
+    --cpu <shares>     set the cpu shares for this dyno, overriding its size
+    --memory <memory>  set the memory for this dyno, overriding its size (e.g. 16GB)
+    -d                 run in detached mode instead of attached to terminal
+    --capture          capture the output of a detached dyno, to retrieve later with job-output
+    -t <timeout>       kill the process if it runs for longer than this (e.g. 1h)
+    --idempotency-key <key>
+                       a unique key for a detached run; retrying with the same
+                       key doesn't run the process again
 
 Examples:
 
@@ -57,6 +71,16 @@ Examples:
     $ emp run -d -s 2X bin/my_worker
     Ran ` + "`bin/my_worker`" + ` on myapp as run.4321, detached.
 
+    $ emp run -d -t 1h bin/backfill
+    Ran ` + "`bin/backfill`" + ` on myapp as run.2468, detached.
+
+    $ emp run -d --memory 16GB migrate
+    Ran ` + "`migrate`" + ` on myapp as run.1357, detached.
+
+    $ emp run -d --capture bin/cleanup
+    Ran ` + "`bin/cleanup`" + ` on myapp as run.9753, detached.
+    Output is being captured as 01234567-89ab-cdef-0123-456789abcdef. Retrieve it with ` + "`emp job-output 01234567-89ab-cdef-0123-456789abcdef`" + `.
+
     $ emp run -a myapp -- ls -a /
     Running ` + "`ls -a bin /`" + ` on myapp as run.8650:
     /:
@@ -67,6 +91,11 @@ Examples:
 func init() {
 	cmdRun.Flag.BoolVarP(&detachedRun, "detached", "d", false, "detached")
 	cmdRun.Flag.StringVarP(&dynoSize, "size", "s", "", "dyno size")
+	cmdRun.Flag.DurationVarP(&runTimeout, "timeout", "t", 0, "maximum runtime")
+	cmdRun.Flag.StringVar(&runCPU, "cpu", "", "cpu shares")
+	cmdRun.Flag.StringVar(&runMemory, "memory", "", "memory")
+	cmdRun.Flag.BoolVar(&runCapture, "capture", false, "capture output")
+	cmdRun.Flag.StringVar(&idempotencyKey, "idempotency-key", "", "unique key that deduplicates retries")
 }
 
 func runRun(cmd *Command, args []string) {
@@ -74,6 +103,9 @@ func runRun(cmd *Command, args []string) {
 		cmd.PrintUsage()
 		os.Exit(2)
 	}
+	if runCapture && !detachedRun {
+		printFatal("--capture can only be used with detached processes (-d)")
+	}
 	appname := mustApp()
 	message := getMessage()
 
@@ -104,13 +136,30 @@ func runRun(cmd *Command, args []string) {
 	if dynoSize != "" {
 		opts.Size = &dynoSize
 	}
+	if runTimeout != 0 {
+		seconds := int(runTimeout.Seconds())
+		opts.Timeout = &seconds
+	}
+	if runCPU != "" {
+		opts.CPU = &runCPU
+	}
+	if runMemory != "" {
+		opts.Memory = &runMemory
+	}
+	if runCapture {
+		opts.Capture = &runCapture
+	}
 
 	command := strings.Join(args, " ")
 	if detachedRun {
+		setIdempotencyKey()
 		dyno, err := client.DynoCreate(appname, command, &opts)
 		must(err)
 
 		log.Printf("Ran `%s` on %s as %s, detached.", dyno.Command, appname, dyno.Name)
+		if dyno.Id != "" {
+			log.Printf("Output is being captured as %s. Retrieve it with `emp job-output %s`.", dyno.Id, dyno.Id)
+		}
 		return
 	}
 
@@ -119,11 +168,17 @@ func runRun(cmd *Command, args []string) {
 		Attach  *bool              `json:"attach,omitempty"`
 		Env     *map[string]string `json:"env,omitempty"`
 		Size    *string            `json:"size,omitempty"`
+		Timeout *int               `json:"timeout,omitempty"`
+		CPU     *string            `json:"cpu,omitempty"`
+		Memory  *string            `json:"memory,omitempty"`
 	}{
 		Command: command,
 		Attach:  opts.Attach,
 		Env:     opts.Env,
 		Size:    opts.Size,
+		Timeout: opts.Timeout,
+		CPU:     opts.CPU,
+		Memory:  opts.Memory,
 	}
 
 	rh := heroku.RequestHeaders{CommitMessage: message}
@@ -132,35 +187,16 @@ func runRun(cmd *Command, args []string) {
 	req, err := client.NewRequest("POST", "/apps/"+appname+"/dynos", params, header)
 	must(err)
 
-	u, err := url.Parse(apiURL)
-	must(err)
-
-	proto, address := dialParams(u)
-
-	var dial net.Conn
-	if proto == "tls" {
-		dial, err = tlsDial("tcp", address, &tls.Config{})
-		if err != nil {
-			printFatal(err.Error())
-		}
-	} else {
-		dial, err = net.Dial(proto, address)
-		if err != nil {
-			printFatal(err.Error())
-		}
-	}
+	attach(req)
+}
 
-	clientconn := httputil.NewClientConn(dial, nil)
-	defer clientconn.Close()
-	res, err := clientconn.Do(req)
-	defer res.Body.Close()
-	if err = heroku.CheckResp(res); err != nil {
-		printFatal(err.Error())
-	}
-	if err != nil && err != httputil.ErrPersistEOF {
-		printFatal(err.Error())
+// attach performs the request, then hijacks the connection to stream stdin to
+// the process, and the process' stdout and stderr to the terminal.
+func attach(req *http.Request) {
+	rwc, br, res, err := hijack(req)
+	if err != nil {
+		printFatal("%s", err)
 	}
-	rwc, br := clientconn.Hijack()
 	defer rwc.Close()
 
 	if isTerminalIn && isTerminalOut {
@@ -201,6 +237,43 @@ func runRun(cmd *Command, args []string) {
 	}
 }
 
+// hijack performs the request against the Empire API, then hijacks the
+// underlying connection, returning it along with any data that was buffered
+// after the response headers.
+func hijack(req *http.Request) (net.Conn, *bufio.Reader, *http.Response, error) {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	proto, address := dialParams(u)
+
+	var dial net.Conn
+	if proto == "tls" {
+		dial, err = tlsDial("tcp", address, &tls.Config{})
+	} else {
+		dial, err = net.Dial(proto, address)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	clientconn := httputil.NewClientConn(dial, nil)
+	res, err := clientconn.Do(req)
+	if err != nil && err != httputil.ErrPersistEOF {
+		clientconn.Close()
+		return nil, nil, nil, err
+	}
+	if err := heroku.CheckResp(res); err != nil {
+		res.Body.Close()
+		clientconn.Close()
+		return nil, nil, nil, err
+	}
+
+	rwc, br := clientconn.Hijack()
+	return rwc, br, res, nil
+}
+
 func dialParams(u *url.URL) (proto, address string) {
 	// u.Host can be either host or host:port
 	host, port := splitHost(u.Host)
@@ -2,12 +2,15 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"os"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/remind101/empire/internal/shellwords"
 	"github.com/remind101/empire/pkg/heroku"
 )
 
@@ -15,7 +18,7 @@ var listMode bool
 
 var cmdScale = &Command{
 	Run:             maybeMessage(runScale),
-	Usage:           "scale [-l] <type>=[<qty>]:[<size>]...",
+	Usage:           "scale [-l] [--idempotency-key <key>] [--args <type>=<args>]... (<type>=[<qty>]:[<size>] | <type>(+|-)<n>[%])...",
 	NeedsApp:        true,
 	OptionalMessage: true,
 	Category:        "dyno",
@@ -46,10 +49,36 @@ The other format is a set of preset values:
 	2X: 512 cpu share, 1024mb of memory
 	PX: 1024 cpu share, 6gb of memory
 
+The quantity can also be changed relative to the current quantity,
+either by a number of dynos, or by a percentage (rounded up to
+at least one dyno):
+
+	# Adds 5 web dynos, and removes 2 worker dynos.
+	$ emp scale -a acme-inc web+5 worker-2
+
+	# Adds 50% more web dynos.
+	$ emp scale -a acme-inc web+50%
+
+Arguments can be appended to the command of a process type, without
+deploying a new release. They're kept by later releases, until
+they're changed again, and changing them restarts all dynos of that
+type:
+
+	# Runs 8 worker threads in each worker dyno.
+	$ emp scale -a acme-inc --args 'worker=--threads=8'
+
+	# Removes the arguments of the worker process.
+	$ emp scale -a acme-inc --args 'worker='
 
 Options:
 
     -l display the current scale
+    --args <type>=<args>
+       set the arguments that are appended to the command of a
+       process type. Can be given more than once.
+    --idempotency-key <key>
+       a unique key for the scale. Retrying with the same key doesn't
+       scale again, which matters for relative changes.
 
 Examples:
 
@@ -61,11 +90,46 @@ Examples:
 
     $ emp scale web=PX worker=1X
     Scaled myapp to web=2:PX, worker=5:1X.
+
+    $ emp scale worker=10 --args 'worker=--threads=8'
+    Scaled myapp to worker=10:1X[--threads=8].
 `,
 }
 
+// scaleArgs are the arguments to set, by process type.
+var scaleArgs = make(scaleArgsFlag)
+
 func init() {
 	cmdScale.Flag.BoolVarP(&listMode, "list", "l", false, "display the current scale")
+	cmdScale.Flag.StringVar(&idempotencyKey, "idempotency-key", "", "unique key that deduplicates retries")
+	cmdScale.Flag.Var(scaleArgs, "args", "set the arguments of a process type (<type>=<args>)")
+}
+
+// scaleArgsFlag is a flag that can be given more than once, which parses
+// <type>=<args> into the arguments of each process type.
+type scaleArgsFlag map[string][]string
+
+func (f scaleArgsFlag) String() string {
+	return ""
+}
+
+func (f scaleArgsFlag) Set(v string) error {
+	i := strings.IndexRune(v, '=')
+	if i <= 0 || strings.HasPrefix(v, "-") {
+		return fmt.Errorf("invalid arguments %q: must be <type>=<args>", v)
+	}
+
+	args, err := shellwords.Parse(v[i+1:])
+	if err != nil {
+		return err
+	}
+	// An empty list removes the arguments.
+	if args == nil {
+		args = []string{}
+	}
+
+	f[v[:i]] = args
+	return nil
 }
 
 // takes args of the form "web=1", "worker=3X", web=4:2X etc
@@ -76,38 +140,28 @@ func runScale(cmd *Command, args []string) {
 		listScale(appname)
 		os.Exit(0)
 	}
-	if len(args) == 0 {
+	if len(args) == 0 && len(scaleArgs) == 0 {
 		cmd.PrintUsage()
 		os.Exit(2)
 	}
-	todo := make([]heroku.FormationBatchUpdateOpts, len(args))
-	types := make(map[string]bool)
-	for i, arg := range args {
-		pstype, qty, size, err := parseScaleArg(arg)
-		if err != nil {
-			cmd.PrintUsage()
-			os.Exit(2)
-		}
-		if _, exists := types[pstype]; exists {
-			// can only specify each process type once
-			printError("process type '%s' specified more than once", pstype)
-			cmd.PrintUsage()
-			os.Exit(2)
-		}
-		types[pstype] = true
-
-		opt := heroku.FormationBatchUpdateOpts{Process: pstype}
-		if qty != nil {
-			opt.Quantity = qty
+	todo, err := parseScaleArgs(args)
+	if err != nil {
+		if err != errInvalidScaleArg {
+			printError("%s", err)
 		}
-		if size != "" {
-			opt.Size = &size
-		}
-		todo[i] = opt
+		cmd.PrintUsage()
+		os.Exit(2)
 	}
 
-	formations, err := client.FormationBatchUpdate(appname, todo, message)
-	must(err)
+	todo = withScaleArgs(todo, scaleArgs)
+
+	setIdempotencyKey()
+
+	var formations []heroku.Formation
+	must(withTwoFactor(func() (err error) {
+		formations, err = client.FormationBatchUpdate(appname, todo, message)
+		return err
+	}))
 
 	sortedFormations := formationsByType(formations)
 	sort.Sort(sortedFormations)
@@ -130,6 +184,9 @@ func formatResults(formations []heroku.Formation) []string {
 	rindex := 0
 	for _, f := range formations {
 		results[rindex] = f.Type + "=" + strconv.Itoa(f.Quantity) + ":" + f.Size
+		if len(f.Args) > 0 {
+			results[rindex] += "[" + strings.Join(f.Args, " ") + "]"
+		}
 		rindex++
 	}
 	return results
@@ -137,6 +194,73 @@ func formatResults(formations []heroku.Formation) []string {
 
 var errInvalidScaleArg = errors.New("invalid argument")
 
+// parseScaleArgs parses args of the form "web=1", "worker=3X", web=4:2X or
+// "web+5" into updates to the formation.
+func parseScaleArgs(args []string) ([]heroku.FormationBatchUpdateOpts, error) {
+	todo := make([]heroku.FormationBatchUpdateOpts, len(args))
+	types := make(map[string]bool)
+	for i, arg := range args {
+		var (
+			pstype, size string
+			qty          *int
+			change       string
+			err          error
+		)
+		if t, c, ok := parseScaleChange(arg); ok {
+			pstype, change = t, c
+		} else {
+			pstype, qty, size, err = parseScaleArg(arg)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := types[pstype]; exists {
+			// can only specify each process type once
+			return nil, fmt.Errorf("process type '%s' specified more than once", pstype)
+		}
+		types[pstype] = true
+
+		opt := heroku.FormationBatchUpdateOpts{Process: pstype}
+		if qty != nil {
+			opt.Quantity = qty
+		}
+		if change != "" {
+			opt.Change = &change
+		}
+		if size != "" {
+			opt.Size = &size
+		}
+		todo[i] = opt
+	}
+	return todo, nil
+}
+
+// withScaleArgs sets the arguments of the process types in the updates.
+// Process types that only have their arguments set keep their quantity.
+func withScaleArgs(todo []heroku.FormationBatchUpdateOpts, args scaleArgsFlag) []heroku.FormationBatchUpdateOpts {
+	updated := make(map[string]bool)
+	for i := range todo {
+		if a, ok := args[todo[i].Process]; ok {
+			todo[i].Args = &a
+		}
+		updated[todo[i].Process] = true
+	}
+
+	var types []string
+	for t := range args {
+		if !updated[t] {
+			types = append(types, t)
+		}
+	}
+	sort.Strings(types)
+
+	for _, t := range types {
+		a, change := args[t], "+0"
+		todo = append(todo, heroku.FormationBatchUpdateOpts{Process: t, Change: &change, Args: &a})
+	}
+	return todo
+}
+
 func parseScaleArg(arg string) (pstype string, qty *int, size string, err error) {
 	iEquals := strings.IndexRune(arg, '=')
 	if fields := strings.Fields(arg); len(fields) > 1 || iEquals == -1 {
@@ -179,6 +303,18 @@ func parseScaleArg(arg string) (pstype string, qty *int, size string, err error)
 	return
 }
 
+var scaleChangeRegexp = regexp.MustCompile(`^([^=\s]+?)([+-][0-9]+%?)$`)
+
+// parseScaleChange parses args of the form "web+5", "worker-2" or "web+50%",
+// which change the quantity relative to the current quantity.
+func parseScaleChange(arg string) (pstype string, change string, ok bool) {
+	m := scaleChangeRegexp.FindStringSubmatch(arg)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
 type formationsByType []heroku.Formation
 
 func (f formationsByType) Len() int           { return len(f) }
@@ -3,6 +3,8 @@ package main
 import (
 	"reflect"
 	"testing"
+
+	"github.com/remind101/empire/pkg/heroku"
 )
 
 func qty(v int) *int {
@@ -51,3 +53,70 @@ func TestParseScaleArg(t *testing.T) {
 		}
 	}
 }
+
+var parseScaleChangeTests = []struct {
+	in     string
+	pstype string
+	change string
+	ok     bool
+}{
+	{"web+5", "web", "+5", true},
+	{"worker-2", "worker", "-2", true},
+	{"web+50%", "web", "+50%", true},
+	{"bg-worker-2", "bg-worker", "-2", true},
+	{"web=5", "", "", false},
+	{"worker=-1", "", "", false},
+	{"web+", "", "", false},
+	{"web+2X", "", "", false},
+	{"+5", "", "", false},
+}
+
+func TestParseScaleChange(t *testing.T) {
+	for i, pt := range parseScaleChangeTests {
+		pstype, change, ok := parseScaleChange(pt.in)
+		if pstype != pt.pstype || change != pt.change || ok != pt.ok {
+			t.Errorf("%d. parseScaleChange(%q) => %q, %q, %v, want %q, %q, %v", i, pt.in, pstype, change, ok, pt.pstype, pt.change, pt.ok)
+		}
+	}
+}
+
+func TestScaleArgsFlag(t *testing.T) {
+	f := make(scaleArgsFlag)
+	if err := f.Set(`worker=--threads=8 --queue "high priority"`); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Set("web="); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Set("--threads=8"); err == nil {
+		t.Errorf("expected an error without a process type")
+	}
+
+	want := scaleArgsFlag{
+		"worker": {"--threads=8", "--queue", "high priority"},
+		"web":    {},
+	}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("scaleArgsFlag => %#v, want %#v", f, want)
+	}
+}
+
+func TestWithScaleArgs(t *testing.T) {
+	todo := withScaleArgs([]heroku.FormationBatchUpdateOpts{
+		{Process: "web", Quantity: qty(2)},
+		{Process: "worker", Quantity: qty(5)},
+	}, scaleArgsFlag{
+		"worker": {"--threads=8"},
+		"clock":  {},
+	})
+
+	change := "+0"
+	want := []heroku.FormationBatchUpdateOpts{
+		{Process: "web", Quantity: qty(2)},
+		{Process: "worker", Quantity: qty(5), Args: &[]string{"--threads=8"}},
+		{Process: "clock", Change: &change, Args: &[]string{}},
+	}
+	if !reflect.DeepEqual(todo, want) {
+		t.Errorf("withScaleArgs => %v, want %v", todo, want)
+	}
+}
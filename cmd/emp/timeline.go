@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var (
+	timelineType  string
+	timelinePid   string
+	timelineSince time.Duration
+	timelineMax   int
+)
+
+var cmdTimeline = &Command{
+	Run:      runTimeline,
+	Usage:    "timeline [-t <type>] [--pid <name>] [--since <duration>] [-n <max>]",
+	NeedsApp: true,
+	Category: "dyno",
+	NumArgs:  0,
+	Short:    "list what happened to dynos",
+	Long: `
+Lists the transitions of the dynos of an app's long running processes, most
+recent first: when each dyno was scheduled, started, and passed its health
+checks, and when it crashed, or was unscheduled (e.g. by a deploy, scaling
+down, a restart, or because its host was lost), and why.
+
+Options:
+
+    -t <type>           only list dynos of this type (e.g. web)
+    --pid <name>        only list the dyno with this name, or id
+    --since <duration>  only list transitions within this long (e.g. 12h)
+    -n <max>            list at most this many transitions (default 50)
+
+Examples:
+
+    $ emp timeline -t web --since 12h -a acme-inc
+    Jun 13 03:12  v12.web.8ab2c1f3  started
+    Jun 13 03:12  v12.web.8ab2c1f3  scheduled
+    Jun 13 03:11  v12.web.4d91e7a2  crashed
+    Jun 12 18:40  v12.web.4d91e7a2  health_passed
+    Jun 12 18:39  v11.web.b0c35f1e  unscheduled    replaced by v12
+`,
+}
+
+func init() {
+	cmdTimeline.Flag.StringVarP(&timelineType, "type", "t", "", "process type")
+	cmdTimeline.Flag.StringVar(&timelinePid, "pid", "", "dyno name or id")
+	cmdTimeline.Flag.DurationVar(&timelineSince, "since", 0, "how far back to list transitions")
+	cmdTimeline.Flag.IntVarP(&timelineMax, "max", "n", 50, "maximum number of transitions")
+}
+
+func runTimeline(cmd *Command, args []string) {
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+
+	appname := mustApp()
+	cmd.AssertNumArgsCorrect(args)
+
+	opts := &heroku.TransitionListOpts{Process: timelineType, Pid: timelinePid}
+	if timelineSince > 0 {
+		since := time.Now().Add(-timelineSince)
+		opts.Since = &since
+	}
+
+	transitions, err := client.TransitionList(appname, opts, &heroku.ListRange{
+		Field:      "occurred_at",
+		Max:        timelineMax,
+		Descending: true,
+	})
+	must(err)
+
+	for _, t := range transitions {
+		listRec(w, prettyTime{t.OccurredAt}, t.Pid, t.State, t.Reason)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var cmdTraffic = &Command{
+	Run:      maybeMessage(runTraffic),
+	Usage:    "traffic [<percent>]",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "split traffic between releases",
+	Long: `
+Shows, or sets, the percentage of traffic that's sent to the current release
+of an app. The rest of the traffic is sent to the previous release, which
+keeps running until all of the traffic is sent to the current release.
+
+Traffic can only be split for processes that use an Application Load
+Balancer.
+
+Examples:
+
+    $ emp traffic -a myapp
+    current: 100%
+    previous: 0%
+    $ emp traffic 10 -a myapp
+    Sending 10% of traffic to the current release of myapp.
+    $ emp traffic 100 -a myapp
+    Sending 100% of traffic to the current release of myapp.
+`,
+}
+
+func runTraffic(cmd *Command, args []string) {
+	message := getMessage()
+	appname := mustApp()
+
+	switch len(args) {
+	case 0:
+		app, err := client.AppInfo(appname)
+		must(err)
+		fmt.Printf("current: %d%%\n", 100-app.PreviousReleaseWeight)
+		fmt.Printf("previous: %d%%\n", app.PreviousReleaseWeight)
+	case 1:
+		current, err := strconv.Atoi(args[0])
+		if err != nil || current < 0 || current > 100 {
+			printFatal("invalid percentage: %s", args[0])
+		}
+		previous := 100 - current
+		_, err = client.AppUpdate(appname, &heroku.AppUpdateOpts{PreviousReleaseWeight: &previous}, message)
+		must(err)
+		log.Printf("Sending %d%% of traffic to the current release of %s.", current, appname)
+	default:
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var cmdTwoFactorEnroll = &Command{
+	Run:      runTwoFactorEnroll,
+	Usage:    "two-factor-enroll [<username>]",
+	Category: "account",
+	Short:    "enroll in two factor authentication" + extra,
+	Long: `
+Enrolls in two factor authentication, which is required to destroy,
+scale to zero, or unset env vars on protected apps. Add the secret,
+or scan the url, with an authenticator app. If already enrolled, the
+command will prompt for a code from the current secret.
+
+Only admins can enroll users that aren't enrolled yet. Admins enroll
+another user by providing their username, and give them the secret.
+
+Example:
+
+    $ emp two-factor-enroll
+    Secret: JBSWY3DPEHPK3PXP
+    URL:    otpauth://totp/Empire:ejholmes?...
+`,
+}
+
+func runTwoFactorEnroll(cmd *Command, args []string) {
+	if len(args) > 1 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	var (
+		secret *heroku.TwoFactorSecret
+		err    error
+	)
+	if len(args) == 1 {
+		secret, err = client.TwoFactorEnrollUser(args[0])
+	} else {
+		secret, err = client.TwoFactorEnroll("")
+		if herror, ok := err.(heroku.Error); ok && herror.Id == "two_factor" {
+			secret, err = client.TwoFactorEnroll(askForTwoFactorCode())
+		}
+	}
+	must(err)
+
+	fmt.Printf("Secret: %s\n", secret.Secret)
+	fmt.Printf("URL:    %s\n", secret.URL)
+}
+
+var cmdProtect = &Command{
+	Run:      runProtect,
+	Usage:    "protect",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "protect an app" + extra,
+	Long: `
+Protects an app. Destroying a protected app, scaling it to zero,
+enabling maintenance mode, or unsetting env vars requires a two
+factor code.
+
+Example:
+
+    $ emp protect -a <myapp>
+    Protected myapp.
+`,
+}
+
+func runProtect(cmd *Command, args []string) {
+	if len(args) != 0 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+	protected := true
+	app, err := client.AppUpdate(mustApp(), &heroku.AppUpdateOpts{Protected: &protected}, "")
+	must(err)
+	log.Printf("Protected %s.", app.Name)
+}
+
+var cmdUnprotect = &Command{
+	Run:      runUnprotect,
+	Usage:    "unprotect",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "unprotect an app" + extra,
+	Long: `
+Removes protection from an app. This requires a two factor code.
+
+Example:
+
+    $ emp unprotect -a <myapp>
+    Enter two-factor auth code: 123456
+    Unprotected myapp.
+`,
+}
+
+func runUnprotect(cmd *Command, args []string) {
+	if len(args) != 0 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+	protected := false
+	must(withTwoFactor(func() error {
+		_, err := client.AppUpdate(mustApp(), &heroku.AppUpdateOpts{Protected: &protected}, "")
+		return err
+	}))
+	log.Printf("Unprotected %s.", mustApp())
+}
+
+// withTwoFactor calls fn, and if the API responds that a two factor code is
+// required, prompts for one and calls fn again.
+func withTwoFactor(fn func() error) error {
+	err := fn()
+	if herror, ok := err.(heroku.Error); ok && herror.Id == "two_factor" && isTerminalIn {
+		client.AdditionalHeaders.Set(heroku.TwoFactorCodeHeader, askForTwoFactorCode())
+		return fn()
+	}
+	return err
+}
+
+func askForTwoFactorCode() string {
+	var code string
+	fmt.Printf("Enter two-factor auth code: ")
+	if _, err := fmt.Scanln(&code); err != nil {
+		printFatal("reading two-factor auth code: %s", err)
+	}
+	return code
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var (
+	usageMonth  string
+	usageByTeam bool
+	usageCSV    bool
+)
+
+var cmdUsage = &Command{
+	Run:      runUsage,
+	Usage:    "usage [-m <month>] [-l <selector>] [--team] [--csv]",
+	Category: "app",
+	NumArgs:  0,
+	Short:    "show resource usage for chargeback",
+	Long: `
+Shows the instance hours and memory hours (in GB) that each app reserved in a
+month, so that platform costs can be attributed to the teams that own them.
+This requires admin access.
+
+Options:
+
+    -m <month>     month to report usage for, as YYYY-MM. Defaults to the
+                   current month.
+    -l <selector>  only report usage of apps with labels matching the
+                   selector (e.g. cost-center=1234)
+    --team         sum usage by team, rather than by app
+    --csv          output CSV
+
+Examples:
+
+    $ emp usage -m 2016-12
+    acme-inc  platform   744.00   372.00
+    blog      marketing  1488.00  744.00
+
+    $ emp usage -m 2016-12 --team --csv
+    month,team,app,instance_hours,memory_hours
+    2016-12,platform,,744.00,372.00
+    2016-12,marketing,,1488.00,744.00
+`,
+}
+
+func init() {
+	cmdUsage.Flag.StringVarP(&usageMonth, "month", "m", "", "month")
+	cmdUsage.Flag.StringVarP(&flagLabels, "labels", "l", "", "label selector")
+	cmdUsage.Flag.BoolVar(&usageByTeam, "team", false, "sum usage by team")
+	cmdUsage.Flag.BoolVar(&usageCSV, "csv", false, "output CSV")
+}
+
+func runUsage(cmd *Command, args []string) {
+	cmd.AssertNumArgsCorrect(args)
+
+	usage, err := client.UsageList(&heroku.UsageListOpts{
+		Month:  usageMonth,
+		ByTeam: usageByTeam,
+		Labels: flagLabels,
+	})
+	must(err)
+
+	if usageCSV {
+		w := csv.NewWriter(os.Stdout)
+		must(w.Write([]string{"month", "team", "app", "instance_hours", "memory_hours"}))
+		for _, u := range usage {
+			must(w.Write([]string{
+				u.Month.Format("2006-01"),
+				u.Team,
+				u.App,
+				fmt.Sprintf("%.2f", u.InstanceHours),
+				fmt.Sprintf("%.2f", u.MemoryHours),
+			}))
+		}
+		w.Flush()
+		must(w.Error())
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+
+	for _, u := range usage {
+		var rec []interface{}
+		if !usageByTeam {
+			rec = append(rec, u.App)
+		}
+		rec = append(rec, u.Team, fmt.Sprintf("%.2f", u.InstanceHours), fmt.Sprintf("%.2f", u.MemoryHours))
+		listRec(w, rec...)
+	}
+}
@@ -115,6 +115,12 @@ func must(err error) {
 				os.Exit(79)
 			case "unauthorized":
 				printFatal(err.Error() + " Log in with `emp login`.")
+			case "":
+				// Unexpected errors don't have an id, and the
+				// request id is needed to find them in the logs.
+				if herror.RequestID != "" {
+					printFatal("%s (request id: %s)", err.Error(), herror.RequestID)
+				}
 			}
 		}
 		printFatal(err.Error())
@@ -305,3 +311,15 @@ func maybeMessage(action func(cmd *Command, args []string)) func(cmd *Command, a
 		action(cmd, args)
 	}
 }
+
+// idempotencyKey is the key that's sent with requests that deploy, scale or
+// run, so that retries of them aren't performed again.
+var idempotencyKey string
+
+// setIdempotencyKey sends the idempotency key with each request, if one was
+// provided.
+func setIdempotencyKey() {
+	if idempotencyKey != "" {
+		client.AdditionalHeaders.Set(heroku.IdempotencyKeyHeader, idempotencyKey)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/remind101/empire"
+	"github.com/urfave/cli"
+)
+
+func runBackup(c *cli.Context) {
+	ctx, err := newContext(c)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := newDB(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	e, err := newEmpire(db, ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	store, err := newBackupStore(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	name, err := e.Backup(ctx, store)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(name)
+}
+
+func runRestore(c *cli.Context) {
+	ctx, err := newContext(c)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	store, err := newBackupStore(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Without a backup, list the backups that can be restored.
+	if len(c.Args()) == 0 {
+		names, err := store.List(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return
+	}
+
+	db, err := newDB(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	e, err := newEmpire(db, ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	changes, err := e.Restore(ctx, empire.RestoreOpts{
+		Store: store,
+		Name:  c.Args().First(),
+	})
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -12,7 +12,10 @@ import (
 	"github.com/urfave/cli"
 	"github.com/remind101/empire"
 	"github.com/remind101/empire/internal/saml"
+	"github.com/remind101/empire/server/auth"
+	"github.com/remind101/empire/server/auth/oidc"
 	"github.com/remind101/empire/stats"
+	"github.com/remind101/empire/trace"
 	"github.com/remind101/pkg/logger"
 	"github.com/remind101/pkg/reporter"
 	"golang.org/x/net/context"
@@ -28,15 +31,21 @@ type Context struct {
 	*cli.Context
 	netCtx
 
-	// Error reporting, logging and stats.
+	// Error reporting, logging, stats and tracing.
 	reporter reporter.Reporter
 	logger   logger.Logger
 	stats    stats.Stats
+	tracer   trace.Tracer
 
 	// AWS stuff
 	awsConfigProvider client.ConfigProvider
 
 	samlServiceProvider *saml.ServiceProvider
+	oidcProvider        *oidc.Provider
+
+	// Schedulers that finish work in the background, which are waited
+	// for when the server shuts down.
+	waiters []empire.Waiter
 }
 
 // newContext builds a new base Context object.
@@ -61,11 +70,24 @@ func newContext(c *cli.Context) (ctx *Context, err error) {
 		return
 	}
 
+	ctx.tracer, err = newTracer(ctx)
+	if err != nil {
+		return
+	}
+
 	ctx.netCtx = ctx.embed(ctx.netCtx)
 
 	return
 }
 
+// withCancel makes the context cancelable, and returns the function that
+// cancels it.
+func (c *Context) withCancel() context.CancelFunc {
+	ctx, cancel := context.WithCancel(c.netCtx)
+	c.netCtx = ctx
+	return cancel
+}
+
 func (c *Context) embed(ctx context.Context) context.Context {
 	if c.reporter != nil {
 		ctx = reporter.WithReporter(ctx, c.reporter)
@@ -76,6 +98,9 @@ func (c *Context) embed(ctx context.Context) context.Context {
 	if c.stats != nil {
 		ctx = stats.WithStats(ctx, c.stats)
 	}
+	if c.tracer != nil {
+		ctx = trace.WithTracer(ctx, c.tracer)
+	}
 	return ctx
 }
 
@@ -147,6 +172,48 @@ func (c *Context) SAMLServiceProvider() (*saml.ServiceProvider, error) {
 	return c.samlServiceProvider, nil
 }
 
+func (c *Context) OIDCProvider() (*oidc.Provider, error) {
+	if c.oidcProvider == nil {
+		issuer := c.String(FlagOIDCIssuer)
+		if issuer == "" {
+			// No OpenID Connect
+			return nil, nil
+		}
+
+		groups, err := c.SSOGroups()
+		if err != nil {
+			return nil, err
+		}
+
+		baseURL := c.URL(FlagURL)
+
+		c.oidcProvider = oidc.NewProvider(
+			issuer,
+			c.String(FlagOIDCClientID),
+			c.String(FlagOIDCClientSecret),
+			fmt.Sprintf("%s/oidc/callback", baseURL),
+		)
+		c.oidcProvider.UsernameClaim = c.String(FlagOIDCUsernameClaim)
+		c.oidcProvider.Groups = groups
+	}
+
+	return c.oidcProvider, nil
+}
+
+// SSOGroups returns the auth.GroupMapper used to map identity provider groups
+// to Empire teams.
+func (c *Context) SSOGroups() (*auth.GroupMapper, error) {
+	teams, err := auth.ParseGroupMapping(c.StringSlice(FlagSSOGroupsMapping))
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.GroupMapper{
+		Attribute: c.String(FlagSSOGroupsAttribute),
+		Teams:     teams,
+	}, nil
+}
+
 // uriContentOrValue uses the following algorithm:
 //
 // 1. If the input is a URI, it will use uriContent to fetch the content from
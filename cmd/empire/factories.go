@@ -1,6 +1,9 @@
 package main
 
 import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"html/template"
@@ -8,6 +11,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -20,18 +24,27 @@ import (
 	"github.com/aws/aws-sdk-go/service/ecs"
 	"github.com/inconshreveable/log15"
 	"github.com/remind101/empire"
+	"github.com/remind101/empire/admission/opa"
+	"github.com/remind101/empire/backups"
+	"github.com/remind101/empire/blobs"
 	"github.com/remind101/empire/events/app"
+	"github.com/remind101/empire/events/opsgenie"
+	"github.com/remind101/empire/events/pagerduty"
 	"github.com/remind101/empire/events/sns"
 	"github.com/remind101/empire/events/stdout"
 	"github.com/remind101/empire/logs"
+	"github.com/remind101/empire/pkg/constraints"
 	"github.com/remind101/empire/pkg/dockerauth"
 	"github.com/remind101/empire/pkg/dockerutil"
+	"github.com/remind101/empire/pkg/image"
 	"github.com/remind101/empire/pkg/troposphere"
 	"github.com/remind101/empire/procfile"
 	"github.com/remind101/empire/registry"
+	"github.com/remind101/empire/scheduler"
 	"github.com/remind101/empire/scheduler/cloudformation"
 	"github.com/remind101/empire/scheduler/docker"
 	"github.com/remind101/empire/stats"
+	"github.com/remind101/empire/trace"
 	"github.com/remind101/empire/twelvefactor"
 	"github.com/remind101/pkg/reporter"
 	"github.com/remind101/pkg/reporter/config"
@@ -52,6 +65,12 @@ func newDB(c *Context) (*empire.DB, error) {
 		},
 	}
 
+	if uri := c.String(FlagDBReplica); uri != "" {
+		if err := db.OpenReplica(uri); err != nil {
+			return nil, err
+		}
+	}
+
 	return db, nil
 }
 
@@ -63,7 +82,12 @@ func newEmpire(db *empire.DB, c *Context) (*empire.Empire, error) {
 		return nil, err
 	}
 
-	scheduler, err := newScheduler(db, c)
+	scheduler, err := newScheduler(db, c, "", c.String(FlagECSCluster))
+	if err != nil {
+		return nil, err
+	}
+
+	clusters, err := newClusters(db, c)
 	if err != nil {
 		return nil, err
 	}
@@ -73,11 +97,22 @@ func newEmpire(db *empire.DB, c *Context) (*empire.Empire, error) {
 		return nil, err
 	}
 
-	streams, err := newEventStreams(c)
+	blobStore, err := newBlobStore(c)
+	if err != nil {
+		return nil, err
+	}
+
+	operator, err := newOperatorConfig(c)
 	if err != nil {
 		return nil, err
 	}
 
+	streams, err := newEventStreams(c, operator.Notifications)
+	if err != nil {
+		return nil, err
+	}
+	events := empire.NewSwitchableEventStream(streams)
+
 	runRecorder, err := newRunRecorder(c)
 	if err != nil {
 		return nil, err
@@ -88,13 +123,52 @@ func newEmpire(db *empire.DB, c *Context) (*empire.Empire, error) {
 		return nil, err
 	}
 
+	verifier, err := newImageVerifier(c)
+	if err != nil {
+		return nil, err
+	}
+
+	admission, err := newAdmissionController(c)
+	if err != nil {
+		return nil, err
+	}
+
+	mesh, err := newMesh(c)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := newIdentityIssuer(c)
+	if err != nil {
+		return nil, err
+	}
+
 	e := empire.New(db)
 	e.Scheduler = scheduler
-	e.EventStream = empire.AsyncEvents(streams)
+	e.Clusters = clusters
+	e.Waiters = c.waiters
+	e.EventStream = empire.AsyncEvents(events)
 	e.ImageRegistry = reg
+	e.ImageVerifier = verifier
 	e.Environment = c.String(FlagEnvironment)
 	e.RunRecorder = runRecorder
+	e.Outputs = newOutputStore(c)
+	e.Blobs = blobStore
 	e.MessagesRequired = c.Bool(FlagMessagesRequired)
+	e.MaxConcurrentDeploys = c.Int(FlagDeploysConcurrency)
+	e.MaxZoneSkew = c.Int(FlagServerMaxZoneSkew)
+	e.AdmissionController = admission
+	e.RBAC = c.Bool(FlagRBAC)
+	e.Admins = c.StringSlice(FlagRBACAdmins)
+	e.SecurityPolicy = empire.SecurityPolicy{
+		AllowPrivileged:     c.Bool(FlagSecurityAllowPrivileged),
+		AllowedCapabilities: c.StringSlice(FlagSecurityAllowedCapabilities),
+		AllowUnconfined:     c.Bool(FlagSecurityAllowUnconfined),
+		Role:                c.String(FlagSecurityRole),
+	}
+	e.Mesh = mesh
+	e.Identity = identity
+	e.Metadata = newMetadataService(c)
 
 	switch c.String(FlagAllowedCommands) {
 	case "procfile":
@@ -106,45 +180,254 @@ func newEmpire(db *empire.DB, c *Context) (*empire.Empire, error) {
 		e.LogsStreamer = logs
 	}
 
+	e.SetOperatorConfig(operator)
+	if c.String(FlagOperatorConfig) != "" {
+		e.LoadOperatorConfig = func() (*empire.OperatorConfig, error) {
+			config, err := newOperatorConfig(c)
+			if err != nil {
+				return nil, err
+			}
+
+			// Notification targets are changed by switching to
+			// new event streams.
+			streams, err := newEventStreams(c, config.Notifications)
+			if err != nil {
+				return nil, err
+			}
+			events.Switch(streams)
+
+			return config, nil
+		}
+	}
+
 	return e, nil
 }
 
+// AdmissionController ==================
+
+func newAdmissionController(c *Context) (empire.AdmissionController, error) {
+	u := c.String(FlagOPAURL)
+	if u == "" {
+		return nil, nil
+	}
+
+	a := opa.NewAdmissionController(u)
+	a.Path = c.String(FlagOPAPath)
+	return a, nil
+}
+
 // Scheduler ============================
 
-func newScheduler(db *empire.DB, c *Context) (empire.Scheduler, error) {
-	var (
-		s   empire.Scheduler
-		err error
-	)
+// newClusters returns the additional schedulers that apps can be assigned to,
+// keyed by name. Each cluster is configured with a name=ecs-cluster pair.
+func newClusters(db *empire.DB, c *Context) (map[string]empire.Scheduler, error) {
+	clusters := make(map[string]empire.Scheduler)
+	for _, v := range c.StringSlice(FlagECSClusters) {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid cluster %q, expected name=ecs-cluster", v)
+		}
+
+		s, err := newScheduler(db, c, parts[0], parts[1])
+		if err != nil {
+			return nil, err
+		}
+		clusters[parts[0]] = s
+	}
+	return clusters, nil
+}
+
+// newClusterSecurityGroups returns the security groups of the container
+// instances in each cluster, keyed by name. The default cluster has an empty
+// name.
+func newClusterSecurityGroups(c *Context) (map[string]string, error) {
+	sgs := make(map[string]string)
+	if sg := c.String(FlagECSSecurityGroup); sg != "" {
+		sgs[""] = sg
+	}
+	for _, v := range c.StringSlice(FlagECSClusterSecurityGroups) {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid cluster security group %q, expected name=security-group", v)
+		}
+		sgs[parts[0]] = parts[1]
+	}
+	return sgs, nil
+}
+
+// newSlackUsers returns the Empire users that Slack users are mapped to, keyed
+// by Slack user id.
+func newSlackUsers(c *Context) (map[string]string, error) {
+	users := make(map[string]string)
+	for _, v := range c.StringSlice(FlagSlackUsers) {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid slack user %q, expected slack-user-id=empire-user", v)
+		}
+		users[parts[0]] = parts[1]
+	}
+	return users, nil
+}
+
+// newClusterPlacementStrategies returns the default placement strategy of the
+// processes in each cluster, keyed by name. The default cluster has an empty
+// name.
+func newClusterPlacementStrategies(c *Context) (map[string]string, error) {
+	strategies := make(map[string]string)
+	if s := c.String(FlagECSPlacementStrategy); s != "" {
+		strategies[""] = s
+	}
+	for _, v := range c.StringSlice(FlagECSClusterPlacementStrategies) {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid cluster placement strategy %q, expected name=strategy", v)
+		}
+		strategies[parts[0]] = parts[1]
+	}
+	for name, s := range strategies {
+		if s != empire.PlacementBinpack && s != empire.PlacementSpread {
+			return nil, fmt.Errorf("unknown placement strategy %q for cluster %q, must be one of: %s", s, name, strings.Join(empire.Placements, ", "))
+		}
+	}
+	return strategies, nil
+}
+
+// newChaosBusinessHours returns the hours that chaos testing kills instances
+// during.
+func newChaosBusinessHours(c *Context) (empire.BusinessHours, error) {
+	loc, err := time.LoadLocation(c.String(FlagChaosTimezone))
+	if err != nil {
+		return empire.BusinessHours{}, fmt.Errorf("invalid chaos time zone: %v", err)
+	}
+	return empire.ParseBusinessHours(c.String(FlagChaosBusinessHours), loc)
+}
+
+// newMesh returns the configuration for the service mesh, or nil if it's not
+// enabled.
+func newMesh(c *Context) (*empire.Mesh, error) {
+	if c.String(FlagMeshEnvoyImage) == "" {
+		return nil, nil
+	}
+
+	if c.String(FlagMeshControlPlane) == "" {
+		return nil, fmt.Errorf("--%s is required when the service mesh is enabled", FlagMeshControlPlane)
+	}
+
+	img, err := image.Decode(c.String(FlagMeshEnvoyImage))
+	if err != nil {
+		return nil, err
+	}
+
+	memory, err := constraints.ParseMemory(c.String(FlagMeshEnvoyMemory))
+	if err != nil {
+		return nil, err
+	}
+
+	return &empire.Mesh{
+		Image:        img,
+		ControlPlane: c.String(FlagMeshControlPlane),
+		Memory:       memory,
+	}, nil
+}
+
+// newMetadataService returns the service that lets processes query their own
+// metadata, or nil if it's not enabled.
+func newMetadataService(c *Context) *empire.MetadataService {
+	if c.String(FlagMetadataURL) == "" {
+		return nil
+	}
+
+	return &empire.MetadataService{
+		URL:    c.String(FlagMetadataURL),
+		Secret: []byte(c.String(FlagSecret)),
+	}
+}
+
+// newIdentityIssuer returns the issuer of identity certificates, or nil if
+// they're not enabled.
+func newIdentityIssuer(c *Context) (*empire.IdentityIssuer, error) {
+	if c.String(FlagIdentityTrustDomain) == "" {
+		return nil, nil
+	}
+
+	certPEM, err := uriContentOrValue(c.String(FlagIdentityCACert))
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := uriContentOrValue(c.String(FlagIdentityCAKey))
+	if err != nil {
+		return nil, err
+	}
 
-	switch c.String(FlagScheduler) {
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load identity CA: %v", err)
+	}
+	ca, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("unable to load identity CA: %v", err)
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unable to load identity CA: unsupported private key")
+	}
+
+	return &empire.IdentityIssuer{
+		TrustDomain: c.String(FlagIdentityTrustDomain),
+		CA:          ca,
+		Key:         key,
+		TTL:         c.Duration(FlagIdentityTTL),
+	}, nil
+}
+
+func newScheduler(db *empire.DB, c *Context, name, cluster string) (empire.Scheduler, error) {
+	strategies, err := newClusterPlacementStrategies(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var s empire.Scheduler
+	switch backend := c.String(FlagScheduler); backend {
 	case "cloudformation":
-		s, err = newCloudFormationScheduler(db, c)
+		s, err = newCloudFormationScheduler(db, c, cluster, strategies[name])
 	default:
-		return nil, fmt.Errorf("unknown scheduler: %s", c.String(FlagScheduler))
+		// Backends that were compiled in, and registered themselves.
+		f, ok := scheduler.Lookup(backend)
+		if !ok {
+			return nil, fmt.Errorf("unknown scheduler: %s", backend)
+		}
+		s, err = f(scheduler.Config{DB: db.DB.DB(), Cluster: cluster, PlacementStrategy: strategies[name]})
 	}
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize %s scheduler: %v", c.String(FlagScheduler), err)
 	}
 
+	if w, ok := s.(empire.Waiter); ok {
+		c.waiters = append(c.waiters, w)
+	}
+
 	// If ECS tasks support being attached to with a TTY + stdin, let the
 	// CloudFormation backend run attached processes.
-	if c.Bool(FlagECSAttachedEnabled) {
-		return s, nil
+	if !c.Bool(FlagECSAttachedEnabled) {
+		d, err := newDockerClient(c)
+		if err != nil {
+			return nil, err
+		}
+
+		a := docker.RunAttachedWithDocker(s, d)
+		a.ShowAttached = c.Bool(FlagXShowAttached)
+		s = a
 	}
 
-	d, err := newDockerClient(c)
-	if err != nil {
-		return nil, err
+	if ttl := c.Duration(FlagSchedulerCacheTTL); ttl > 0 {
+		s = twelvefactor.Use(s, twelvefactor.CacheTasks(ttl))
 	}
 
-	a := docker.RunAttachedWithDocker(s, d)
-	a.ShowAttached = c.Bool(FlagXShowAttached)
-	return a, nil
+	return s, nil
 }
 
-func newCloudFormationScheduler(db *empire.DB, c *Context) (twelvefactor.Scheduler, error) {
+func newCloudFormationScheduler(db *empire.DB, c *Context, cluster, placementStrategy string) (twelvefactor.Scheduler, error) {
 	logDriver := c.String(FlagECSLogDriver)
 	logOpts := c.StringSlice(FlagECSLogOpts)
 	logConfiguration := newLogConfiguration(logDriver, logOpts)
@@ -155,9 +438,14 @@ func newCloudFormationScheduler(db *empire.DB, c *Context) (twelvefactor.Schedul
 		return nil, err
 	}
 
+	clusterSecurityGroups, err := newClusterSecurityGroups(c)
+	if err != nil {
+		return nil, err
+	}
+
 	t := &cloudformation.EmpireTemplate{
 		VpcId:                   c.String(FlagELBVpcId),
-		Cluster:                 c.String(FlagECSCluster),
+		Cluster:                 cluster,
 		InternalSecurityGroupID: c.String(FlagELBSGPrivate),
 		ExternalSecurityGroupID: c.String(FlagELBSGPublic),
 		InternalSubnetIDs:       c.StringSlice(FlagEC2SubnetsPrivate),
@@ -166,6 +454,10 @@ func newCloudFormationScheduler(db *empire.DB, c *Context) (twelvefactor.Schedul
 		ServiceRole:             c.String(FlagECSServiceRole),
 		CustomResourcesTopic:    c.String(FlagCustomResourcesTopic),
 		LogConfiguration:        logConfiguration,
+		VolumeDriver:            c.String(FlagECSVolumeDriver),
+		GPUConstraint:           c.String(FlagECSGPUConstraint),
+		PlacementStrategy:       placementStrategy,
+		ClusterSecurityGroups:   clusterSecurityGroups,
 		ExtraOutputs: map[string]troposphere.Output{
 			"EmpireVersion": troposphere.Output{Value: empire.Version},
 		},
@@ -181,7 +473,8 @@ func newCloudFormationScheduler(db *empire.DB, c *Context) (twelvefactor.Schedul
 	}
 
 	s := cloudformation.NewScheduler(db.DB.DB(), c)
-	s.Cluster = c.String(FlagECSCluster)
+	s.Cluster = cluster
+	s.PlacementStrategy = placementStrategy
 	s.Template = t
 	if v := c.String(FlagCloudFormationStackNameTemplate); v != "" {
 		s.StackNameTemplate = stackNameTemplate(v)
@@ -217,16 +510,18 @@ func newCloudFormationScheduler(db *empire.DB, c *Context) (twelvefactor.Schedul
 	log.Println(fmt.Sprintf("  ZoneID: %v", zoneID))
 	log.Println(fmt.Sprintf("  LogConfiguration: %v", t.LogConfiguration))
 
+	var scheduler twelvefactor.Scheduler = s
+
 	if v := c.String(FlagECSPlacementConstraintsDefault); v != "" {
 		var placementConstraints []*ecs.PlacementConstraint
 		if err := json.Unmarshal([]byte(v), &placementConstraints); err != nil {
 			return nil, fmt.Errorf("unable to unmarshal placement constraints: %v", err)
 		}
 		log.Println(fmt.Sprintf("  DefaultPlacementConstraints: %v", placementConstraints))
-		return twelvefactor.Transform(s, setDefaultPlacementConstraints(placementConstraints)), nil
+		scheduler = twelvefactor.Transform(scheduler, setDefaultPlacementConstraints(placementConstraints))
 	}
 
-	return s, nil
+	return scheduler, nil
 }
 
 func setDefaultPlacementConstraints(placementConstraints []*ecs.PlacementConstraint) func(*twelvefactor.Manifest) *twelvefactor.Manifest {
@@ -309,6 +604,103 @@ func newRegistry(client *dockerutil.Client, c *Context) (empire.ImageRegistry, e
 	return r, nil
 }
 
+func newImageVerifier(c *Context) (empire.ImageVerifier, error) {
+	switch c.String(FlagDockerVerify) {
+	case "":
+		return nil, nil
+	case "cosign":
+		keys := c.StringSlice(FlagDockerVerifyKeys)
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("%s is required to verify images with cosign", FlagDockerVerifyKeys)
+		}
+		log.Println("Image signatures are verified with cosign")
+		return &registry.CosignVerifier{Keys: keys}, nil
+	case "notary":
+		log.Println("Image signatures are verified with notary")
+		return &registry.NotaryVerifier{
+			Server:   c.String(FlagDockerVerifyNotaryServer),
+			TrustDir: c.String(FlagDockerVerifyNotaryTrustDir),
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid value for %s: %s", FlagDockerVerify, c.String(FlagDockerVerify))
+	}
+}
+
+// BackupStore =========================
+
+func newBackupStore(c *Context) (empire.BackupStore, error) {
+	bucket := c.String(FlagBackupBucket)
+	if bucket != "" {
+		return backups.StoreInS3(bucket, c.String(FlagBackupPrefix), c), nil
+	}
+
+	blobStore, err := newBlobStore(c)
+	if err != nil {
+		return nil, err
+	}
+	if blobStore == nil {
+		return nil, fmt.Errorf("%s, or %s, is required to back up, or restore, the database", FlagBackupBucket, FlagBlobsBackend)
+	}
+	return empire.BackupsInBlobStore(blobStore), nil
+}
+
+// BlobStore ===========================
+
+func newBlobStore(c *Context) (empire.BlobStore, error) {
+	switch backend := c.String(FlagBlobsBackend); backend {
+	case "s3":
+		return newS3BlobStore(c)
+	case "gcs":
+		return newGCSBlobStore(c)
+	case "file":
+		return newFileBlobStore(c)
+	case "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown blobs backend: %s", backend)
+	}
+}
+
+func newS3BlobStore(c *Context) (empire.BlobStore, error) {
+	bucket := c.String(FlagBlobsBucket)
+	if bucket == "" {
+		return nil, fmt.Errorf("%s is required for the s3 blobs backend", FlagBlobsBucket)
+	}
+	log.Printf("Using S3 blobs backend with bucket %s", bucket)
+	return blobs.StoreInS3(bucket, c.String(FlagBlobsPrefix), c), nil
+}
+
+func newGCSBlobStore(c *Context) (empire.BlobStore, error) {
+	bucket := c.String(FlagBlobsBucket)
+	if bucket == "" {
+		return nil, fmt.Errorf("%s is required for the gcs blobs backend", FlagBlobsBucket)
+	}
+	id, secret := c.String(FlagBlobsGCSAccessKeyID), c.String(FlagBlobsGCSSecret)
+	if id == "" || secret == "" {
+		return nil, fmt.Errorf("%s and %s are required for the gcs blobs backend", FlagBlobsGCSAccessKeyID, FlagBlobsGCSSecret)
+	}
+	log.Printf("Using GCS blobs backend with bucket %s", bucket)
+	return blobs.StoreInGCS(bucket, c.String(FlagBlobsPrefix), id, secret, c), nil
+}
+
+func newFileBlobStore(c *Context) (empire.BlobStore, error) {
+	dir := c.String(FlagBlobsDir)
+	if dir == "" {
+		return nil, fmt.Errorf("%s is required for the file blobs backend", FlagBlobsDir)
+	}
+	log.Printf("Using file blobs backend in %s", dir)
+	return blobs.StoreOnDisk(dir), nil
+}
+
+// newBlobLifecycleRules returns the lifecycle rules of the blob store.
+func newBlobLifecycleRules(c *Context) []empire.BlobLifecycleRule {
+	return []empire.BlobLifecycleRule{
+		{Prefix: empire.BlobPrefixDeployLogs, MaxAge: c.Duration(FlagBlobsExpireDeployLogs)},
+		{Prefix: empire.BlobPrefixOutputs, MaxAge: c.Duration(FlagBlobsExpireOutputs)},
+		{Prefix: empire.BlobPrefixBackups, MaxAge: c.Duration(FlagBlobsExpireBackups)},
+	}
+}
+
 // LogStreamer =========================
 
 func newLogsStreamer(c *Context) (empire.LogsStreamer, error) {
@@ -326,13 +718,32 @@ func newKinesisLogsStreamer(c *Context) (empire.LogsStreamer, error) {
 	return logs.NewKinesisLogsStreamer(), nil
 }
 
+// Operator Config =====================
+
+// newOperatorConfig loads the operator configuration file, or returns an empty
+// configuration when there isn't one.
+func newOperatorConfig(c *Context) (*empire.OperatorConfig, error) {
+	path := c.String(FlagOperatorConfig)
+	if path == "" {
+		return &empire.OperatorConfig{}, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return empire.ParseOperatorConfig(f)
+}
+
 // Events ==============================
 
-func newEventStreams(c *Context) (empire.MultiEventStream, error) {
+func newEventStreams(c *Context, n empire.OperatorNotifications) (empire.MultiEventStream, error) {
 	var streams empire.MultiEventStream
 	switch c.String(FlagEventsBackend) {
 	case "sns":
-		e, err := newSNSEventStream(c)
+		e, err := newSNSEventStream(c, n)
 		if err != nil {
 			return streams, err
 		}
@@ -355,18 +766,53 @@ func newEventStreams(c *Context) (empire.MultiEventStream, error) {
 		}
 		streams = append(streams, e)
 	}
+
+	if c.String(FlagAlertsBackend) != "" {
+		e, err := newAlertsEventStream(c, n)
+		if err != nil {
+			return streams, err
+		}
+		streams = append(streams, e)
+	}
 	return streams, nil
 }
 
+func newAlertsEventStream(c *Context, n empire.OperatorNotifications) (empire.EventStream, error) {
+	source := c.String(FlagEnvironment)
+	routingKey := c.String(FlagAlertsRoutingKey)
+	if n.AlertsRoutingKey != "" {
+		routingKey = n.AlertsRoutingKey
+	}
+	switch backend := c.String(FlagAlertsBackend); backend {
+	case "pagerduty":
+		e := pagerduty.NewEventStream(routingKey)
+		e.Source = source
+		e.URL = c.String(FlagAlertsURL)
+		log.Println("Using PagerDuty alerts backend")
+		return e, nil
+	case "opsgenie":
+		e := opsgenie.NewEventStream(routingKey)
+		e.Source = source
+		e.URL = c.String(FlagAlertsURL)
+		log.Println("Using Opsgenie alerts backend")
+		return e, nil
+	default:
+		return nil, fmt.Errorf("unknown alerts backend: %v", backend)
+	}
+}
+
 func newAppEventStream(c *Context) (empire.EventStream, error) {
 	e := app.NewEventStream(c)
 	log.Println("Using App (Kinesis) events backend")
 	return e, nil
 }
 
-func newSNSEventStream(c *Context) (empire.EventStream, error) {
+func newSNSEventStream(c *Context, n empire.OperatorNotifications) (empire.EventStream, error) {
 	e := sns.NewEventStream(c)
 	e.TopicARN = c.String(FlagSNSTopic)
+	if n.SNSTopic != "" {
+		e.TopicARN = n.SNSTopic
+	}
 
 	log.Println("Using SNS events backend with the following configuration:")
 	log.Println(fmt.Sprintf("  TopicARN: %s", e.TopicARN))
@@ -400,6 +846,15 @@ func newRunRecorder(c *Context) (empire.RunRecorder, error) {
 	}
 }
 
+// newOutputStore returns the store that captures the output of detached runs,
+// which is only supported by the cloudwatch run logs backend.
+func newOutputStore(c *Context) empire.OutputStore {
+	if c.String(FlagRunLogsBackend) != "cloudwatch" {
+		return nil
+	}
+	return logs.CaptureToCloudWatch(c.String(FlagCloudWatchLogGroup), c)
+}
+
 // Logger ==============================
 
 func newLogger(c *Context) (log15.Logger, error) {
@@ -428,6 +883,19 @@ func newReporter(c *Context) (reporter.Reporter, error) {
 	return rep, nil
 }
 
+// Trace =======================
+
+func newTracer(c *Context) (trace.Tracer, error) {
+	switch c.String(FlagTrace) {
+	case "":
+		return trace.Null, nil
+	case "log":
+		return trace.NewLogger(c.logger), nil
+	default:
+		return nil, fmt.Errorf("unknown trace backend: %s", c.String(FlagTrace))
+	}
+}
+
 // Stats =======================
 
 func newStats(c *Context) (stats.Stats, error) {
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"time"
 
 	"github.com/urfave/cli"
 	"github.com/remind101/empire"
+	"github.com/remind101/empire/admission/opa"
 	"github.com/remind101/empire/server/github"
 )
 
@@ -14,59 +16,116 @@ const hbExampleURL = "hb://api.honeybadger.io?key=<key>&environment=<environment
 const rollbarExampleURL = "rollbar://api.rollbar.com?key=<key>&environment=<environment>"
 
 const (
-	FlagURL            = "url"
-	FlagPort           = "port"
-	FlagAutoMigrate    = "automigrate"
-	FlagScheduler      = "scheduler"
-	FlagEventsBackend  = "events.backend"
-	FlagRunLogsBackend = "runlogs.backend"
-	FlagLogLevel       = "log.level"
+	FlagURL               = "url"
+	FlagPort              = "port"
+	FlagAutoMigrate       = "automigrate"
+	FlagScheduler         = "scheduler"
+	FlagSchedulerCacheTTL = "scheduler.cache-ttl"
+	FlagEventsBackend     = "events.backend"
+	FlagRunLogsBackend    = "runlogs.backend"
+	FlagLogLevel          = "log.level"
 
 	FlagMessagesRequired = "messages.required"
 	FlagAllowedCommands  = "commands.allowed"
 
+	FlagDeploysConcurrency = "deploys.concurrency"
+
 	FlagStats = "stats"
+	FlagTrace = "trace"
 
 	FlagServerAuth              = "server.auth"
 	FlagServerSessionExpiration = "server.session.expiration"
 	FlagServerRealIp            = "server.realip"
+	FlagServerReschedule        = "server.reschedule"
+	FlagServerMaxZoneSkew       = "server.max-zone-skew"
+	FlagServerRotateIdentities  = "server.rotate-identities"
+	FlagServerScaleDaemons      = "server.scale-daemons"
+	FlagServerRebalanceSpot     = "server.rebalance-spot"
+	FlagServerRunQueuedJobs     = "server.run-queued-jobs"
+	FlagServerReapRuns          = "server.reap-runs"
+	FlagServerRecordCronRuns    = "server.record-cron-runs"
+	FlagServerDetectCrashLoops  = "server.detect-crash-loops"
+	FlagServerTrackTasks        = "server.track-tasks"
+	FlagServerChaos             = "server.chaos"
+	FlagServerHealthTimeout     = "server.health.timeout"
+	FlagServerReadiness         = "server.readiness"
+	FlagServerShutdownTimeout   = "server.shutdown-timeout"
+	FlagChaosBusinessHours      = "chaos.business-hours"
+	FlagChaosTimezone           = "chaos.timezone"
+	FlagServerRateLimitWindow   = "server.ratelimit.window"
+	FlagServerRateLimitGlobal   = "server.ratelimit.global"
+	FlagServerRateLimitToken    = "server.ratelimit.token"
+	FlagServerRateLimitApp      = "server.ratelimit.app"
+
+	FlagGitOpsRepo     = "gitops.repo"
+	FlagGitOpsBranch   = "gitops.branch"
+	FlagGitOpsPath     = "gitops.path"
+	FlagGitOpsDir      = "gitops.dir"
+	FlagGitOpsUser     = "gitops.user"
+	FlagGitOpsInterval = "gitops.interval"
 
 	FlagSAMLMetadata       = "saml.metadata"
 	FlagSAMLKey            = "saml.key"
 	FlagSAMLCert           = "saml.cert"
+
+	FlagOIDCIssuer        = "oidc.issuer"
+	FlagOIDCClientID      = "oidc.client.id"
+	FlagOIDCClientSecret  = "oidc.client.secret"
+	FlagOIDCUsernameClaim = "oidc.username.claim"
+
+	FlagSSOGroupsAttribute = "sso.groups.attribute"
+	FlagSSOGroupsMapping   = "sso.groups.mapping"
+
 	FlagGithubClient       = "github.client.id"
 	FlagGithubClientSecret = "github.client.secret"
 	FlagGithubClientRedirectURL = "github.client.redirect.url"
 	FlagGithubOrg          = "github.organization"
 	FlagGithubApiURL       = "github.api.url"
 	FlagGithubTeam         = "github.team.id"
+	FlagGithubTeamsSync    = "github.teams.sync"
 
 	FlagGithubWebhooksSecret           = "github.webhooks.secret"
 	FlagGithubDeploymentsEnvironments  = "github.deployments.environment"
 	FlagGithubDeploymentsImageBuilder  = "github.deployments.image_builder"
 	FlagGithubDeploymentsImageTemplate = "github.deployments.template"
 	FlagGithubDeploymentsTugboatURL    = "github.deployments.tugboat.url"
+	FlagGithubDeploymentsToken         = "github.deployments.token"
+
+	FlagSlackSigningSecret = "slack.signing_secret"
+	FlagSlackUsers         = "slack.users"
 
 	FlagConveyorURL = "conveyor.url"
 
-	FlagDB = "db"
+	FlagDB        = "db"
+	FlagDBReplica = "db.replica"
 
-	FlagDockerHost    = "docker.socket"
-	FlagDockerCert    = "docker.cert"
-	FlagDockerAuth    = "docker.auth"
-	FlagDockerDigests = "docker.digests"
+	FlagDockerHost                 = "docker.socket"
+	FlagDockerCert                 = "docker.cert"
+	FlagDockerAuth                 = "docker.auth"
+	FlagDockerDigests              = "docker.digests"
+	FlagDockerVerify               = "docker.verify"
+	FlagDockerVerifyKeys           = "docker.verify.keys"
+	FlagDockerVerifyNotaryServer   = "docker.verify.notary-server"
+	FlagDockerVerifyNotaryTrustDir = "docker.verify.notary-trust-dir"
 
 	FlagAWSDebug                       = "aws.debug"
 	FlagS3TemplateBucket               = "s3.templatebucket"
 	FlagCustomResourcesTopic           = "customresources.topic"
 	FlagCustomResourcesQueue           = "customresources.queue"
 	FlagECSCluster                     = "ecs.cluster"
+	FlagECSClusters                    = "ecs.clusters"
 	FlagECSServiceRole                 = "ecs.service.role"
 	FlagECSLogDriver                   = "ecs.logdriver"
 	FlagECSLogOpts                     = "ecs.logopt"
 	FlagECSAttachedEnabled             = "ecs.attached.enabled"
 	FlagECSDockerCert                  = "ecs.docker.cert"
 	FlagECSPlacementConstraintsDefault = "ecs.placement-constraints.default"
+	FlagECSVolumeDriver                = "ecs.volume-driver"
+	FlagECSGPUConstraint               = "ecs.gpu-constraint"
+	FlagECSSecurityGroup               = "ecs.security-group"
+	FlagECSClusterSecurityGroups       = "ecs.cluster-security-groups"
+	FlagECSPlacementStrategy           = "ecs.placement-strategy"
+	FlagECSClusterPlacementStrategies  = "ecs.cluster-placement-strategies"
 
 	FlagELBSGPrivate = "elb.sg.private"
 	FlagELBSGPublic  = "elb.sg.public"
@@ -85,6 +144,10 @@ const (
 	FlagSNSTopic           = "sns.topic"
 	FlagCloudWatchLogGroup = "cloudwatch.loggroup"
 
+	FlagAlertsBackend    = "alerts.backend"
+	FlagAlertsRoutingKey = "alerts.routing-key"
+	FlagAlertsURL        = "alerts.url"
+
 	FlagSecret       = "secret"
 	FlagReporter     = "reporter"
 	FlagRunner       = "runner"
@@ -92,6 +155,45 @@ const (
 
 	FlagEnvironment = "environment"
 
+	FlagOperatorConfig = "operator.config"
+
+	FlagBlobsBackend          = "blobs.backend"
+	FlagBlobsBucket           = "blobs.bucket"
+	FlagBlobsPrefix           = "blobs.prefix"
+	FlagBlobsDir              = "blobs.dir"
+	FlagBlobsGCSAccessKeyID   = "blobs.gcs.access-key-id"
+	FlagBlobsGCSSecret        = "blobs.gcs.secret"
+	FlagBlobsExpireDeployLogs = "blobs.expire.deploy-logs"
+	FlagBlobsExpireOutputs    = "blobs.expire.outputs"
+	FlagBlobsExpireBackups    = "blobs.expire.backups"
+
+	FlagOPAURL  = "opa.url"
+	FlagOPAPath = "opa.path"
+
+	FlagRBAC       = "rbac"
+	FlagRBACAdmins = "rbac.admins"
+
+	FlagSecurityAllowPrivileged     = "security.allow-privileged"
+	FlagSecurityAllowedCapabilities = "security.allowed-capabilities"
+	FlagSecurityAllowUnconfined     = "security.allow-unconfined"
+	FlagSecurityRole                = "security.role"
+
+	FlagMeshEnvoyImage   = "mesh.envoy-image"
+	FlagMeshEnvoyMemory  = "mesh.envoy-memory"
+	FlagMeshControlPlane = "mesh.control-plane"
+
+	FlagIdentityTrustDomain = "identity.trust-domain"
+	FlagIdentityCACert      = "identity.ca.cert"
+	FlagIdentityCAKey       = "identity.ca.key"
+	FlagIdentityTTL         = "identity.ttl"
+
+	FlagMetadataURL = "metadata.url"
+
+	FlagBackupBucket   = "backup.bucket"
+	FlagBackupPrefix   = "backup.prefix"
+	FlagBackupInterval = "backup.interval"
+	FlagBackupRetain   = "backup.retain"
+
 	// Expiremental flags.
 	FlagXShowAttached = "x.showattached"
 )
@@ -122,13 +224,19 @@ var Commands = []cli.Command{
 			cli.StringFlag{
 				Name:   FlagScheduler,
 				Value:  "cloudformation",
-				Usage:  "The scheduling backend to use. Current options are `cloudformation`.",
+				Usage:  "The scheduling backend to use. Current options are `cloudformation`, or any backend registered with the scheduler package.",
 				EnvVar: "EMPIRE_SCHEDULER",
 			},
+			cli.DurationFlag{
+				Name:   FlagSchedulerCacheTTL,
+				Value:  0,
+				Usage:  "How long the processes that the scheduler returns are cached for, so that clients polling the processes of many apps don't overload the scheduler. The cache of an app is invalidated when it's deployed, scaled, restarted or run. Set to 0 to disable.",
+				EnvVar: "EMPIRE_SCHEDULER_CACHE_TTL",
+			},
 			cli.StringFlag{
 				Name:   FlagServerAuth,
 				Value:  "",
-				Usage:  "The authentication backend to use to authenticate requests to the API. Can be `fake`, `github`, or `saml`.",
+				Usage:  "The authentication backend to use to authenticate requests to the API. Can be `fake`, `github`, `saml` or `oidc`.",
 				EnvVar: "EMPIRE_SERVER_AUTH",
 			},
 			cli.DurationFlag{
@@ -143,6 +251,162 @@ var Commands = []cli.Command{
 				Usage:  "Determines the headers that can be trusted to determine the real ip. By default, no headers are trusted and the ip is extracted from the remote address. If you're using ELB, you should set this to X-Forwarded-For. If you're using something like nginx + real_ip module, you can set this to X-Real-Ip.",
 				EnvVar: "EMPIRE_SERVER_REAL_IP",
 			},
+			cli.DurationFlag{
+				Name:   FlagServerReschedule,
+				Value:  time.Minute,
+				Usage:  "How often to look for processes running on hosts that the scheduler has lost contact with, and stop them so that they're replaced on healthy hosts. Set to 0 to disable.",
+				EnvVar: "EMPIRE_SERVER_RESCHEDULE",
+			},
+			cli.IntFlag{
+				Name:   FlagServerMaxZoneSkew,
+				Value:  0,
+				Usage:  "The number of instances that a process can have in an availability zone above its share, which is weighted by the memory of the machines in the zone, before they're stopped so that they're replaced in other zones. Rebalancing happens when looking for processes on lost hosts. Set to 0 to disable.",
+				EnvVar: "EMPIRE_SERVER_MAX_ZONE_SKEW",
+			},
+			cli.DurationFlag{
+				Name:   FlagServerScaleDaemons,
+				Value:  time.Minute,
+				Usage:  "How often to check for machines that joined, or left, the cluster, and re-release apps with daemon processes so that they run on every machine. Set to 0 to disable.",
+				EnvVar: "EMPIRE_SERVER_SCALE_DAEMONS",
+			},
+			cli.DurationFlag{
+				Name:   FlagServerRebalanceSpot,
+				Value:  time.Minute,
+				Usage:  "How often to check for spot capacity that's being reclaimed, and re-release apps with spot processes so that they're moved to on-demand capacity, and back once spot capacity is available. Set to 0 to disable.",
+				EnvVar: "EMPIRE_SERVER_REBALANCE_SPOT",
+			},
+			cli.DurationFlag{
+				Name:   FlagServerRunQueuedJobs,
+				Value:  30 * time.Second,
+				Usage:  "How often to run one-off processes that were queued, for example because they were preempted, once there's room for them in the cluster. Set to 0 to disable.",
+				EnvVar: "EMPIRE_SERVER_RUN_QUEUED_JOBS",
+			},
+			cli.DurationFlag{
+				Name:   FlagServerReapRuns,
+				Value:  time.Minute,
+				Usage:  "How often to kill one-off processes that have run for longer than their timeout, and clean up after one-off processes that have finished. Set to 0 to disable.",
+				EnvVar: "EMPIRE_SERVER_REAP_RUNS",
+			},
+			cli.DurationFlag{
+				Name:   FlagServerRecordCronRuns,
+				Value:  time.Minute,
+				Usage:  "How often to record the invocations of scheduled processes, and start manually triggered invocations that were queued. Set to 0 to disable.",
+				EnvVar: "EMPIRE_SERVER_RECORD_CRON_RUNS",
+			},
+			cli.DurationFlag{
+				Name:   FlagServerDetectCrashLoops,
+				Value:  time.Minute,
+				Usage:  "How often to look for long running processes whose instances keep being replaced, and publish a crash_loop event for them. Set to 0 to disable.",
+				EnvVar: "EMPIRE_SERVER_DETECT_CRASH_LOOPS",
+			},
+			cli.DurationFlag{
+				Name:   FlagServerTrackTasks,
+				Value:  time.Minute,
+				Usage:  "How often to record when the instances of long running processes are scheduled, start, crash or are unscheduled, for `emp timeline`. Set to 0 to disable.",
+				EnvVar: "EMPIRE_SERVER_TRACK_TASKS",
+			},
+			cli.DurationFlag{
+				Name:   FlagServerChaos,
+				Value:  0,
+				Usage:  "How often to kill a random fraction of the instances of apps that have opted in to chaos testing with `emp chaos`, during business hours. Set to 0 to disable.",
+				EnvVar: "EMPIRE_SERVER_CHAOS",
+			},
+			cli.StringFlag{
+				Name:   FlagChaosBusinessHours,
+				Value:  "09:00-17:00",
+				Usage:  "The hours, Monday to Friday, that chaos testing kills instances during.",
+				EnvVar: "EMPIRE_CHAOS_BUSINESS_HOURS",
+			},
+			cli.StringFlag{
+				Name:   FlagChaosTimezone,
+				Value:  "UTC",
+				Usage:  "The time zone that the business hours of chaos testing are in (e.g. America/New_York).",
+				EnvVar: "EMPIRE_CHAOS_TIMEZONE",
+			},
+			cli.DurationFlag{
+				Name:   FlagServerRotateIdentities,
+				Value:  24 * time.Hour,
+				Usage:  "When identity certificates are enabled, how often to release every app so that its processes get a new certificate. This should be well below the TTL of the certificates. Set to 0 to disable.",
+				EnvVar: "EMPIRE_SERVER_ROTATE_IDENTITIES",
+			},
+			cli.DurationFlag{
+				Name:   FlagServerHealthTimeout,
+				Value:  empire.DefaultHealthCheckTimeout,
+				Usage:  "How long each dependency (the database, and the scheduler of each cluster) has to respond to /health and /readiness, before it's reported as unavailable.",
+				EnvVar: "EMPIRE_SERVER_HEALTH_TIMEOUT",
+			},
+			cli.StringFlag{
+				Name:   FlagServerReadiness,
+				Value:  "database,scheduler",
+				Usage:  "A comma separated list of the dependencies that need to be reachable for /readiness to pass. One or more of database and scheduler.",
+				EnvVar: "EMPIRE_SERVER_READINESS",
+			},
+			cli.DurationFlag{
+				Name:   FlagServerShutdownTimeout,
+				Value:  time.Minute,
+				Usage:  "On SIGTERM or SIGINT, how long to wait for requests, deploys and stack updates that are in progress to finish, before exiting. This should be shorter than the time that the process is given to stop.",
+				EnvVar: "EMPIRE_SERVER_SHUTDOWN_TIMEOUT",
+			},
+			cli.DurationFlag{
+				Name:   FlagServerRateLimitWindow,
+				Value:  time.Minute,
+				Usage:  "The window that rate limited API requests are counted in.",
+				EnvVar: "EMPIRE_SERVER_RATELIMIT_WINDOW",
+			},
+			cli.IntFlag{
+				Name:   FlagServerRateLimitGlobal,
+				Value:  0,
+				Usage:  "The number of API requests that change things (e.g. deploys, scales and config changes) that are allowed in each window, across all users. Set to 0 to disable.",
+				EnvVar: "EMPIRE_SERVER_RATELIMIT_GLOBAL",
+			},
+			cli.IntFlag{
+				Name:   FlagServerRateLimitToken,
+				Value:  0,
+				Usage:  "The number of API requests that change things that are allowed in each window, with each user or API token. Set to 0 to disable.",
+				EnvVar: "EMPIRE_SERVER_RATELIMIT_TOKEN",
+			},
+			cli.IntFlag{
+				Name:   FlagServerRateLimitApp,
+				Value:  0,
+				Usage:  "The number of API requests that change things that are allowed in each window, to each app. Set to 0 to disable.",
+				EnvVar: "EMPIRE_SERVER_RATELIMIT_APP",
+			},
+			cli.StringFlag{
+				Name:   FlagGitOpsRepo,
+				Value:  "",
+				Usage:  "If provided, app specs are pulled from this git repository, and applied, so that apps follow the repository. (e.g. git@github.com:acme/apps.git)",
+				EnvVar: "EMPIRE_GITOPS_REPO",
+			},
+			cli.StringFlag{
+				Name:   FlagGitOpsBranch,
+				Value:  "master",
+				Usage:  "The branch of the app spec repository to follow.",
+				EnvVar: "EMPIRE_GITOPS_BRANCH",
+			},
+			cli.StringFlag{
+				Name:   FlagGitOpsPath,
+				Value:  ".",
+				Usage:  "The directory within the app spec repository that contains the app specs.",
+				EnvVar: "EMPIRE_GITOPS_PATH",
+			},
+			cli.StringFlag{
+				Name:   FlagGitOpsDir,
+				Value:  "/tmp/empire-gitops",
+				Usage:  "The directory that the app spec repository is cloned into.",
+				EnvVar: "EMPIRE_GITOPS_DIR",
+			},
+			cli.StringFlag{
+				Name:   FlagGitOpsUser,
+				Value:  "gitops",
+				Usage:  "The user that app specs from the repository are applied as.",
+				EnvVar: "EMPIRE_GITOPS_USER",
+			},
+			cli.DurationFlag{
+				Name:   FlagGitOpsInterval,
+				Value:  time.Minute,
+				Usage:  "How often to pull the app spec repository.",
+				EnvVar: "EMPIRE_GITOPS_INTERVAL",
+			},
 			cli.StringFlag{
 				Name:   FlagSAMLMetadata,
 				Value:  "",
@@ -161,6 +425,42 @@ var Commands = []cli.Command{
 				Usage:  "The location of the public key for this service provider. (e.g. file:///etc/empire/saml.cert)",
 				EnvVar: "EMPIRE_SAML_CERT",
 			},
+			cli.StringFlag{
+				Name:   FlagOIDCIssuer,
+				Value:  "",
+				Usage:  "The issuer url of the OpenID Connect identity provider. The provider configuration is discovered from /.well-known/openid-configuration. (e.g. https://example.okta.com, https://login.microsoftonline.com/<tenant>/v2.0)",
+				EnvVar: "EMPIRE_OIDC_ISSUER",
+			},
+			cli.StringFlag{
+				Name:   FlagOIDCClientID,
+				Value:  "",
+				Usage:  "The client id of the application registered with the OpenID Connect identity provider",
+				EnvVar: "EMPIRE_OIDC_CLIENT_ID",
+			},
+			cli.StringFlag{
+				Name:   FlagOIDCClientSecret,
+				Value:  "",
+				Usage:  "The client secret of the application registered with the OpenID Connect identity provider",
+				EnvVar: "EMPIRE_OIDC_CLIENT_SECRET",
+			},
+			cli.StringFlag{
+				Name:   FlagOIDCUsernameClaim,
+				Value:  "email",
+				Usage:  "The claim in the ID token that is used as the Empire username",
+				EnvVar: "EMPIRE_OIDC_USERNAME_CLAIM",
+			},
+			cli.StringFlag{
+				Name:   FlagSSOGroupsAttribute,
+				Value:  "groups",
+				Usage:  "The SAML attribute, or OpenID Connect claim, that contains the groups a user is a member of. Groups are mapped to Empire teams.",
+				EnvVar: "EMPIRE_SSO_GROUPS_ATTRIBUTE",
+			},
+			cli.StringSliceFlag{
+				Name:   FlagSSOGroupsMapping,
+				Value:  &cli.StringSlice{},
+				Usage:  "A list of group=team pairs that map identity provider groups to Empire teams. When provided, groups without a mapping are ignored. By default, group names are used as team names.",
+				EnvVar: "EMPIRE_SSO_GROUPS_MAPPING",
+			},
 			cli.StringFlag{
 				Name:   FlagGithubClient,
 				Value:  "",
@@ -191,6 +491,11 @@ var Commands = []cli.Command{
 				Usage:  "The ID of the github team to allow access to",
 				EnvVar: "EMPIRE_GITHUB_TEAM_ID",
 			},
+			cli.BoolFlag{
+				Name:   FlagGithubTeamsSync,
+				Usage:  "If true, GitHub team memberships will be mapped to Empire teams when users log in. When an organization is provided, only teams within that organization are mapped.",
+				EnvVar: "EMPIRE_GITHUB_TEAMS_SYNC",
+			},
 			cli.StringFlag{
 				Name:   FlagGithubApiURL,
 				Value:  "",
@@ -227,13 +532,43 @@ var Commands = []cli.Command{
 				Usage:  "If provided, logs from deployments triggered via GitHub deployments will be sent to this tugboat instance.",
 				EnvVar: "EMPIRE_TUGBOAT_URL",
 			},
+			cli.StringFlag{
+				Name:   FlagGithubDeploymentsToken,
+				Value:  "",
+				Usage:  "If provided, Empire creates deployment statuses on GitHub with this token, as deployments triggered via GitHub deployments progress.",
+				EnvVar: "EMPIRE_GITHUB_DEPLOYMENTS_TOKEN",
+			},
+			cli.StringFlag{
+				Name:   FlagSlackSigningSecret,
+				Value:  "",
+				Usage:  "If provided, enables Slack slash commands at /slack/commands. Requests are verified with this signing secret of the Slack app.",
+				EnvVar: "EMPIRE_SLACK_SIGNING_SECRET",
+			},
+			cli.StringSliceFlag{
+				Name:   FlagSlackUsers,
+				Value:  &cli.StringSlice{},
+				Usage:  "A list of slack-user-id=empire-user pairs that map Slack users to Empire users. Slash commands are authorized as the Empire user, and commands from Slack users without a mapping are rejected.",
+				EnvVar: "EMPIRE_SLACK_USERS",
+			},
 			cli.StringFlag{
 				Name:   FlagConveyorURL,
 				Value:  "",
 				Usage:  "When combined with the `--" + FlagGithubDeploymentsImageBuilder + "` flag when set to `conveyor`, this determines where the location of a Conveyor instance is to perform Docker image builds.",
 				EnvVar: "EMPIRE_CONVEYOR_URL",
 			},
-		}, append(CommonFlags, append(EmpireFlags, DBFlags...)...)...),
+			cli.DurationFlag{
+				Name:   FlagBackupInterval,
+				Value:  0,
+				Usage:  "How often to back up the database to the backup bucket. Set to 0 to disable.",
+				EnvVar: "EMPIRE_BACKUP_INTERVAL",
+			},
+			cli.IntFlag{
+				Name:   FlagBackupRetain,
+				Value:  0,
+				Usage:  "The number of scheduled backups to keep. Older backups are deleted. Set to 0 to keep every backup.",
+				EnvVar: "EMPIRE_BACKUP_RETAIN",
+			},
+		}, append(CommonFlags, append(EmpireFlags, append(DBFlags, BackupFlags...)...)...)...),
 		Action: runServer,
 	},
 	{
@@ -242,6 +577,19 @@ var Commands = []cli.Command{
 		Flags:  append(CommonFlags, DBFlags...),
 		Action: runMigrate,
 	},
+	{
+		Name:   "backup",
+		Usage:  "Back up the database to the backup bucket",
+		Flags:  append(CommonFlags, append(EmpireFlags, append(DBFlags, BackupFlags...)...)...),
+		Action: runBackup,
+	},
+	{
+		Name:      "restore",
+		Usage:     "Restore the database from a backup, and reconcile the cluster with it. Lists the backups when no backup is given.",
+		ArgsUsage: "[<backup>]",
+		Flags:     append(CommonFlags, append(EmpireFlags, append(DBFlags, BackupFlags...)...)...),
+		Action:    runRestore,
+	},
 }
 
 var CommonFlags = []cli.Flag{
@@ -257,6 +605,12 @@ var CommonFlags = []cli.Flag{
 		Usage:  "The stats backend to use. (e.g. statsd://localhost:8125)",
 		EnvVar: "EMPIRE_STATS",
 	},
+	cli.StringFlag{
+		Name:   FlagTrace,
+		Value:  "",
+		Usage:  "The tracing backend to use, which records a span for each step of a deploy. `log` logs the spans.",
+		EnvVar: "EMPIRE_TRACE",
+	},
 	cli.StringSliceFlag{
 		Name:  FlagReporter,
 		Value: &cli.StringSlice{},
@@ -273,6 +627,27 @@ var DBFlags = []cli.Flag{
 		Usage:  "SQL connection string for the database",
 		EnvVar: "EMPIRE_DATABASE_URL",
 	},
+	cli.StringFlag{
+		Name:   FlagDBReplica,
+		Value:  "",
+		Usage:  "If provided, SQL connection string for a read replica of the database. Read heavy queries, like listing apps and releases, are sent to it to reduce load on the primary.",
+		EnvVar: "EMPIRE_DATABASE_REPLICA_URL",
+	},
+}
+
+var BackupFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:   FlagBackupBucket,
+		Value:  "",
+		Usage:  "The S3 bucket that backups of the database are stored in. Backups include secrets, like config vars, and are encrypted at rest.",
+		EnvVar: "EMPIRE_BACKUP_BUCKET",
+	},
+	cli.StringFlag{
+		Name:   FlagBackupPrefix,
+		Value:  "backups/",
+		Usage:  "The prefix of the keys of backups in the backup bucket.",
+		EnvVar: "EMPIRE_BACKUP_PREFIX",
+	},
 }
 
 var EmpireFlags = []cli.Flag{
@@ -300,6 +675,30 @@ var EmpireFlags = []cli.Flag{
 		Usage:  "Determines how Empire stores Docker image references. By default, Empire will try to resolve a mutable reference (e.g. remind101/acme-inc:master) to an immutable reference using the images content adressable digest (e.g. remind101/acme-inc@sha256:c6f77d2098bc0e32aef3102e71b51831a9083dd9356a0ccadca860596a1e9007) if the Docker daemon supports it. This can be disabled by setting to \"disable\" or enforce digests by setting to \"enforce\".",
 		EnvVar: "DOCKER_DIGESTS",
 	},
+	cli.StringFlag{
+		Name:   FlagDockerVerify,
+		Value:  "",
+		Usage:  "If set, the signature of the image of every release is verified before it's submitted to the scheduler, and releases of unsigned, or tampered, images fail. Can be \"cosign\" or \"notary\".",
+		EnvVar: "EMPIRE_DOCKER_VERIFY",
+	},
+	cli.StringSliceFlag{
+		Name:   FlagDockerVerifyKeys,
+		Value:  &cli.StringSlice{},
+		Usage:  "When verifying images with cosign, the public keys (paths or KMS URIs) that images can be signed with.",
+		EnvVar: "EMPIRE_DOCKER_VERIFY_KEYS",
+	},
+	cli.StringFlag{
+		Name:   FlagDockerVerifyNotaryServer,
+		Value:  "https://notary.docker.io",
+		Usage:  "When verifying images with notary, the url of the Notary server.",
+		EnvVar: "EMPIRE_DOCKER_VERIFY_NOTARY_SERVER",
+	},
+	cli.StringFlag{
+		Name:   FlagDockerVerifyNotaryTrustDir,
+		Value:  "",
+		Usage:  "When verifying images with notary, the directory that trusted root keys are pinned in.",
+		EnvVar: "EMPIRE_DOCKER_VERIFY_NOTARY_TRUST_DIR",
+	},
 	cli.BoolFlag{
 		Name:   FlagAWSDebug,
 		Usage:  "Enable verbose debug output for AWS integration.",
@@ -326,6 +725,12 @@ var EmpireFlags = []cli.Flag{
 		Usage:  "The ECS cluster to create services within",
 		EnvVar: "EMPIRE_ECS_CLUSTER",
 	},
+	cli.StringSliceFlag{
+		Name:   FlagECSClusters,
+		Value:  &cli.StringSlice{},
+		Usage:  "A list of name=ecs-cluster pairs for additional ECS clusters that apps can be assigned to when they're created with 'emp create -r <name>'. Apps that aren't assigned to a cluster use the cluster from --ecs.cluster.",
+		EnvVar: "EMPIRE_ECS_CLUSTERS",
+	},
 	cli.StringFlag{
 		Name:   FlagECSServiceRole,
 		Value:  "ecsServiceRole",
@@ -361,6 +766,42 @@ var EmpireFlags = []cli.Flag{
 		Usage:  `ECS placement constraints to set when a process does not set any. This should be a JSON formatted array for ECS placement constraints (e.g. '[{"type":"memberOf","expression":"attribute:profile == default"}]'). See http://docs.aws.amazon.com/AmazonECS/latest/developerguide/task-placement-constraints.html.`,
 		EnvVar: "EMPIRE_ECS_PLACEMENT_CONSTRAINTS_DEFAULT",
 	},
+	cli.StringFlag{
+		Name:   FlagECSVolumeDriver,
+		Value:  "rexray/ebs",
+		Usage:  "The Docker volume driver that provisions ebs volumes declared in a Procfile. The driver must be installed on the ECS container instances.",
+		EnvVar: "EMPIRE_ECS_VOLUME_DRIVER",
+	},
+	cli.StringFlag{
+		Name:   FlagECSGPUConstraint,
+		Value:  "attribute:empire.gpu exists",
+		Usage:  "A placement constraint expression used to place processes that require GPUs on hosts with GPUs. Set the attribute on GPU hosts with ECS_INSTANCE_ATTRIBUTES in the ECS agent configuration. Set to an empty string to only rely on ECS GPU reservations.",
+		EnvVar: "EMPIRE_ECS_GPU_CONSTRAINT",
+	},
+	cli.StringFlag{
+		Name:   FlagECSSecurityGroup,
+		Value:  "",
+		Usage:  "The security group of the container instances in the ECS cluster from --ecs.cluster. Required to allow connections from apps in this cluster to apps with ingress rules.",
+		EnvVar: "EMPIRE_ECS_SECURITY_GROUP",
+	},
+	cli.StringSliceFlag{
+		Name:   FlagECSClusterSecurityGroups,
+		Value:  &cli.StringSlice{},
+		Usage:  "A list of name=security-group pairs with the security group of the container instances in each of the clusters from --ecs.clusters.",
+		EnvVar: "EMPIRE_ECS_CLUSTER_SECURITY_GROUPS",
+	},
+	cli.StringFlag{
+		Name:   FlagECSPlacementStrategy,
+		Value:  "",
+		Usage:  "The placement strategy of processes in the ECS cluster from --ecs.cluster: `binpack` places processes on as few hosts as possible, to reduce cost, and `spread` spreads them across availability zones and hosts, for resilience. Processes can override it with `placement` in an extended Procfile. When empty, ECS's default placement is used.",
+		EnvVar: "EMPIRE_ECS_PLACEMENT_STRATEGY",
+	},
+	cli.StringSliceFlag{
+		Name:   FlagECSClusterPlacementStrategies,
+		Value:  &cli.StringSlice{},
+		Usage:  "A list of name=strategy pairs with the placement strategy of processes in each of the clusters from --ecs.clusters.",
+		EnvVar: "EMPIRE_ECS_CLUSTER_PLACEMENT_STRATEGIES",
+	},
 	cli.StringFlag{
 		Name:   FlagELBSGPrivate,
 		Value:  "",
@@ -450,6 +891,24 @@ var EmpireFlags = []cli.Flag{
 		Usage:  "When using the SNS events backend, this is the SNS topic that gets published to",
 		EnvVar: "EMPIRE_SNS_TOPIC",
 	},
+	cli.StringFlag{
+		Name:   FlagAlertsBackend,
+		Value:  "",
+		Usage:  "If provided, opens incidents for failed deploys, crash loops, and failures to restore the desired state of an app. Supports `pagerduty` and `opsgenie`.",
+		EnvVar: "EMPIRE_ALERTS_BACKEND",
+	},
+	cli.StringFlag{
+		Name:   FlagAlertsRoutingKey,
+		Value:  "",
+		Usage:  "The key that incidents are routed with, for apps that don't set their own with `emp alert-routing-key`. This is the integration key of a PagerDuty service, or the API key of an Opsgenie integration.",
+		EnvVar: "EMPIRE_ALERTS_ROUTING_KEY",
+	},
+	cli.StringFlag{
+		Name:   FlagAlertsURL,
+		Value:  "",
+		Usage:  "If provided, the url of the API of the alerting backend (e.g. https://api.eu.opsgenie.com).",
+		EnvVar: "EMPIRE_ALERTS_URL",
+	},
 	cli.StringFlag{
 		Name:   FlagEnvironment,
 		Value:  "",
@@ -473,6 +932,165 @@ var EmpireFlags = []cli.Flag{
 		Usage:  "Specifies what commands are allowed when using `emp run`. Can be `any`, or `procfile`.",
 		EnvVar: "EMPIRE_ALLOWED_COMMANDS",
 	},
+	cli.IntFlag{
+		Name:   FlagDeploysConcurrency,
+		Value:  0,
+		Usage:  "The maximum number of apps that deploys can release at once. Deploys of the same app are always released one at a time. 0 doesn't limit concurrency.",
+		EnvVar: "EMPIRE_DEPLOYS_CONCURRENCY",
+	},
+	cli.StringFlag{
+		Name:   FlagOperatorConfig,
+		Value:  "",
+		Usage:  "If provided, the path to a JSON file with process sizes, quotas, freeze windows and notification targets, which is reloaded on SIGHUP, or with `emp reload-config`, without restarting Empire.",
+		EnvVar: "EMPIRE_OPERATOR_CONFIG",
+	},
+	cli.StringFlag{
+		Name:   FlagBlobsBackend,
+		Value:  "",
+		Usage:  "If provided, where deploy logs, the output of one-off processes that have finished, and backups are stored. Possible options are s3, gcs and file.",
+		EnvVar: "EMPIRE_BLOBS_BACKEND",
+	},
+	cli.StringFlag{
+		Name:   FlagBlobsBucket,
+		Value:  "",
+		Usage:  "The bucket that blobs are stored in, when the s3 or gcs blobs backend is used.",
+		EnvVar: "EMPIRE_BLOBS_BUCKET",
+	},
+	cli.StringFlag{
+		Name:   FlagBlobsPrefix,
+		Value:  "",
+		Usage:  "If provided, a prefix for the keys of blobs in the bucket (e.g. `empire/`).",
+		EnvVar: "EMPIRE_BLOBS_PREFIX",
+	},
+	cli.StringFlag{
+		Name:   FlagBlobsDir,
+		Value:  "",
+		Usage:  "The directory that blobs are stored in, when the file blobs backend is used.",
+		EnvVar: "EMPIRE_BLOBS_DIR",
+	},
+	cli.StringFlag{
+		Name:   FlagBlobsGCSAccessKeyID,
+		Value:  "",
+		Usage:  "The access id of an HMAC key of a service account, which is used to access the bucket when the gcs blobs backend is used.",
+		EnvVar: "EMPIRE_BLOBS_GCS_ACCESS_KEY_ID",
+	},
+	cli.StringFlag{
+		Name:   FlagBlobsGCSSecret,
+		Value:  "",
+		Usage:  "The secret of the HMAC key, when the gcs blobs backend is used.",
+		EnvVar: "EMPIRE_BLOBS_GCS_SECRET",
+	},
+	cli.DurationFlag{
+		Name:   FlagBlobsExpireDeployLogs,
+		Value:  0,
+		Usage:  "How long deploy logs are kept in the blob store. Set to 0 to keep them forever.",
+		EnvVar: "EMPIRE_BLOBS_EXPIRE_DEPLOY_LOGS",
+	},
+	cli.DurationFlag{
+		Name:   FlagBlobsExpireOutputs,
+		Value:  0,
+		Usage:  "How long the output of one-off processes is kept in the blob store. Set to 0 to keep it forever.",
+		EnvVar: "EMPIRE_BLOBS_EXPIRE_OUTPUTS",
+	},
+	cli.DurationFlag{
+		Name:   FlagBlobsExpireBackups,
+		Value:  0,
+		Usage:  "How long backups are kept in the blob store. Set to 0 to keep them forever.",
+		EnvVar: "EMPIRE_BLOBS_EXPIRE_BACKUPS",
+	},
+	cli.StringFlag{
+		Name:   FlagOPAURL,
+		Value:  "",
+		Usage:  "If provided, releases and scale changes will be evaluated against policies in the Open Policy Agent server at this url before being submitted to the scheduler.",
+		EnvVar: "EMPIRE_OPA_URL",
+	},
+	cli.StringFlag{
+		Name:   FlagOPAPath,
+		Value:  opa.DefaultPath,
+		Usage:  "The path to the admission policy document within Open Policy Agent.",
+		EnvVar: "EMPIRE_OPA_PATH",
+	},
+	cli.BoolFlag{
+		Name:   FlagRBAC,
+		Usage:  "If true, users must be granted a role on an app before they can view or change it.",
+		EnvVar: "EMPIRE_RBAC",
+	},
+	cli.StringSliceFlag{
+		Name:   FlagRBACAdmins,
+		Value:  &cli.StringSlice{},
+		Usage:  "A list of users that have the admin role on all apps, regardless of grants.",
+		EnvVar: "EMPIRE_RBAC_ADMINS",
+	},
+	cli.BoolFlag{
+		Name:   FlagSecurityAllowPrivileged,
+		Usage:  "If true, processes can run privileged containers.",
+		EnvVar: "EMPIRE_SECURITY_ALLOW_PRIVILEGED",
+	},
+	cli.StringSliceFlag{
+		Name:   FlagSecurityAllowedCapabilities,
+		Value:  &cli.StringSlice{},
+		Usage:  "A list of Linux capabilities that processes can add (e.g. NET_ADMIN), or ALL to allow any capability.",
+		EnvVar: "EMPIRE_SECURITY_ALLOWED_CAPABILITIES",
+	},
+	cli.BoolFlag{
+		Name:   FlagSecurityAllowUnconfined,
+		Usage:  "If true, processes can disable seccomp filtering with an unconfined seccomp profile.",
+		EnvVar: "EMPIRE_SECURITY_ALLOW_UNCONFINED",
+	},
+	cli.StringFlag{
+		Name:   FlagSecurityRole,
+		Value:  empire.RoleAdmin,
+		Usage:  "The role that a user must have on an app to run its processes privileged, add capabilities or disable seccomp filtering.",
+		EnvVar: "EMPIRE_SECURITY_ROLE",
+	},
+	cli.StringFlag{
+		Name:   FlagMeshEnvoyImage,
+		Value:  "",
+		Usage:  "If provided, processes can join the service mesh, which injects a sidecar running this Envoy image into them.",
+		EnvVar: "EMPIRE_MESH_ENVOY_IMAGE",
+	},
+	cli.StringFlag{
+		Name:   FlagMeshEnvoyMemory,
+		Value:  "128mb",
+		Usage:  "The amount of memory to reserve for the Envoy sidecar.",
+		EnvVar: "EMPIRE_MESH_ENVOY_MEMORY",
+	},
+	cli.StringFlag{
+		Name:   FlagMeshControlPlane,
+		Value:  "",
+		Usage:  "The address of the xDS control plane that Envoy sidecars register with.",
+		EnvVar: "EMPIRE_MESH_CONTROL_PLANE",
+	},
+	cli.StringFlag{
+		Name:   FlagIdentityTrustDomain,
+		Value:  "",
+		Usage:  "If provided, each app is issued a SPIFFE identity certificate in this trust domain (e.g. empire.acme.com) when it's released.",
+		EnvVar: "EMPIRE_IDENTITY_TRUST_DOMAIN",
+	},
+	cli.StringFlag{
+		Name:   FlagIdentityCACert,
+		Value:  "",
+		Usage:  "The location of the CA certificate that signs identity certificates. (e.g. file:///etc/empire/identity-ca.crt)",
+		EnvVar: "EMPIRE_IDENTITY_CA_CERT",
+	},
+	cli.StringFlag{
+		Name:   FlagIdentityCAKey,
+		Value:  "",
+		Usage:  "The location of the private key of the identity CA. (e.g. file:///etc/empire/identity-ca.key)",
+		EnvVar: "EMPIRE_IDENTITY_CA_KEY",
+	},
+	cli.DurationFlag{
+		Name:   FlagIdentityTTL,
+		Value:  empire.DefaultIdentityTTL,
+		Usage:  "How long identity certificates are valid for.",
+		EnvVar: "EMPIRE_IDENTITY_TTL",
+	},
+	cli.StringFlag{
+		Name:   FlagMetadataURL,
+		Value:  "",
+		Usage:  "If provided, processes can query their own metadata from this URL, which should route to the /metadata path of this Empire server (e.g. http://empire.internal/metadata).",
+		EnvVar: "EMPIRE_METADATA_URL",
+	},
 	cli.BoolFlag{
 		Name:   FlagXShowAttached,
 		Usage:  "If true, attached runs will be shown in `emp ps` output.",
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"text/template"
 	"time"
 
@@ -20,6 +23,7 @@ import (
 	"github.com/remind101/empire/server/heroku"
 	"github.com/remind101/empire/server/middleware"
 	"github.com/remind101/empire/stats"
+	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
 )
 
@@ -29,6 +33,9 @@ func runServer(c *cli.Context) {
 		log.Fatal(err)
 	}
 
+	// Background processes are stopped when the server shuts down.
+	stop := ctx.withCancel()
+
 	// Send runtime metrics to stats backend.
 	go stats.Runtime(ctx.Stats())
 
@@ -66,9 +73,160 @@ func runServer(c *cli.Context) {
 		go p.Start()
 	}
 
-	s := newServer(ctx, e)
-	log.Printf("Starting on port %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, s))
+	if d := c.Duration(FlagServerReschedule); d != 0 {
+		r := &empire.Rescheduler{Empire: e, Interval: d}
+		log.Printf("Rescheduling processes on lost hosts every %v", d)
+		if n := e.MaxZoneSkew; n > 0 {
+			log.Printf("Rebalancing processes with more than %d instances above their share in an availability zone every %v", n, d)
+		}
+		go r.Start(ctx)
+	}
+
+	if d := c.Duration(FlagServerScaleDaemons); d != 0 {
+		s := &empire.DaemonScaler{Empire: e, Interval: d}
+		log.Printf("Scaling daemon processes to the machines in the cluster every %v", d)
+		go s.Start(ctx)
+	}
+
+	if d := c.Duration(FlagServerRebalanceSpot); d != 0 {
+		r := &empire.SpotRebalancer{Empire: e, Interval: d}
+		log.Printf("Rebalancing spot processes every %v", d)
+		go r.Start(ctx)
+	}
+
+	if d := c.Duration(FlagServerRunQueuedJobs); d != 0 {
+		q := &empire.JobQueue{Empire: e, Interval: d}
+		log.Printf("Running queued jobs every %v", d)
+		go q.Start(ctx)
+	}
+
+	if d := c.Duration(FlagServerReapRuns); d != 0 {
+		r := &empire.RunReaper{Empire: e, Interval: d}
+		log.Printf("Reaping one-off processes every %v", d)
+		go r.Start(ctx)
+	}
+
+	if d := c.Duration(FlagServerRecordCronRuns); d != 0 {
+		m := &empire.CronMonitor{Empire: e, Interval: d}
+		log.Printf("Recording invocations of scheduled processes every %v", d)
+		go m.Start(ctx)
+	}
+
+	if d := c.Duration(FlagServerDetectCrashLoops); d != 0 {
+		l := &empire.CrashLoopDetector{Empire: e, Interval: d}
+		log.Printf("Detecting crash looping processes every %v", d)
+		go l.Start(ctx)
+	}
+
+	if d := c.Duration(FlagServerTrackTasks); d != 0 {
+		t := &empire.TaskTracker{Empire: e, Interval: d}
+		log.Printf("Recording the lifecycle of processes every %v", d)
+		go t.Start(ctx)
+	}
+
+	if d := c.Duration(FlagServerChaos); d != 0 {
+		hours, err := newChaosBusinessHours(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		m := &empire.ChaosMonkey{Empire: e, Interval: d, Hours: hours}
+		log.Printf("Killing instances of apps that opted in to chaos testing every %v, during business hours", d)
+		go m.Start(ctx)
+	}
+
+	if d := c.Duration(FlagServerRotateIdentities); d != 0 && e.Identity != nil {
+		r := &empire.IdentityRotator{Empire: e, Interval: d}
+		log.Printf("Rotating identity certificates every %v", d)
+		go r.Start(ctx)
+	}
+
+	if repo := c.String(FlagGitOpsRepo); repo != "" {
+		g := &empire.GitSyncer{
+			Empire:   e,
+			Repo:     repo,
+			Branch:   c.String(FlagGitOpsBranch),
+			Path:     c.String(FlagGitOpsPath),
+			Dir:      c.String(FlagGitOpsDir),
+			User:     &empire.User{Name: c.String(FlagGitOpsUser)},
+			Interval: c.Duration(FlagGitOpsInterval),
+		}
+		log.Printf("Syncing app specs from %s every %v", repo, g.Interval)
+		go g.Start(ctx)
+	}
+
+	if d := c.Duration(FlagBackupInterval); d != 0 {
+		store, err := newBackupStore(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		b := &empire.Backuper{Empire: e, Store: store, Interval: d, Retain: c.Int(FlagBackupRetain)}
+		log.Printf("Backing up the database every %v", d)
+		go b.Start(ctx)
+	}
+
+	if e.Blobs != nil {
+		x := &empire.BlobExpirer{Store: e.Blobs, Rules: newBlobLifecycleRules(ctx), Interval: empire.DefaultBlobExpireInterval}
+		log.Printf("Expiring blobs every %v", x.Interval)
+		go x.Start(ctx)
+	}
+
+	s := &http.Server{Addr: ":" + port, Handler: newServer(ctx, e)}
+	go func() {
+		log.Printf("Starting on port %s", port)
+		if err := s.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, os.Interrupt, syscall.SIGHUP)
+	for received := range sig {
+		if received != syscall.SIGHUP {
+			log.Printf("Received %v, shutting down", received)
+			break
+		}
+		reloadOperatorConfig(e)
+	}
+
+	if err := shutdown(s, e, stop, c.Duration(FlagServerShutdownTimeout)); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("Shut down cleanly")
+}
+
+// reloadOperatorConfig reloads the operator configuration, and keeps the
+// current configuration if the new one isn't valid.
+func reloadOperatorConfig(e *empire.Empire) {
+	if e.LoadOperatorConfig == nil {
+		log.Println("Received SIGHUP, but there's no operator config to reload")
+		return
+	}
+
+	config, err := e.LoadOperatorConfig()
+	if err != nil {
+		log.Printf("Error reloading the operator config, keeping the current one: %v", err)
+		return
+	}
+
+	e.SetOperatorConfig(config)
+	log.Println("Reloaded the operator config")
+}
+
+// shutdown stops accepting requests, and waits for the ones in progress to
+// finish. Background processes are then stopped, and Empire waits for the
+// deploys and stack updates that are still in progress, before closing its
+// connections. Anything that doesn't finish within timeout is logged.
+func shutdown(s *http.Server, e *empire.Empire, stop func(), timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := s.Shutdown(ctx); err != nil {
+		log.Printf("Requests still in progress after %v: %v", timeout, err)
+	}
+
+	stop()
+
+	return e.Shutdown(ctx)
 }
 
 func newServer(c *Context, e *empire.Empire) http.Handler {
@@ -77,15 +235,32 @@ func newServer(c *Context, e *empire.Empire) http.Handler {
 	opts.GitHub.Deployments.Environments = strings.Split(c.String(FlagGithubDeploymentsEnvironments), ",")
 	opts.GitHub.Deployments.ImageBuilder = newImageBuilder(c)
 	opts.GitHub.Deployments.TugboatURL = c.String(FlagGithubDeploymentsTugboatURL)
+	opts.GitHub.Deployments.Token = c.String(FlagGithubDeploymentsToken)
+	opts.GitHub.Deployments.APIURL = c.String(FlagGithubApiURL)
 	opts.GitHub.OAuth.ClientID = c.String(FlagGithubClient)
 	opts.GitHub.OAuth.ClientSecret = c.String(FlagGithubClientSecret)
 	opts.GitHub.OAuth.RedirectURL = c.String(FlagGithubClientRedirectURL)
 	opts.GitHub.OAuth.Scopes = []string{"read:org", "user:email"}
+	opts.Slack.SigningSecret = c.String(FlagSlackSigningSecret)
+
+	slackUsers, err := newSlackUsers(c)
+	if err != nil {
+		panic(err)
+	}
+	opts.Slack.Users = slackUsers
+
+	readiness, err := newReadiness(c)
+	if err != nil {
+		panic(err)
+	}
+	opts.Health.Timeout = c.Duration(FlagServerHealthTimeout)
+	opts.Health.Readiness = readiness
 
 	s := server.New(e, opts)
 	s.URL = c.URL(FlagURL)
 	s.Heroku.Auth = newAuth(c, e)
 	s.Heroku.Secret = []byte(c.String(FlagSecret))
+	s.Heroku.RateLimit = newRateLimit(c)
 
 	sp, err := c.SAMLServiceProvider()
 	if err != nil {
@@ -97,6 +272,22 @@ func newServer(c *Context, e *empire.Empire) http.Handler {
 		s.Heroku.Unauthorized = heroku.SAMLUnauthorized(c.String(FlagURL) + "/saml/login")
 	}
 
+	op, err := c.OIDCProvider()
+	if err != nil {
+		panic(err)
+	}
+
+	if op != nil {
+		s.OIDCProvider = op
+		s.Heroku.Unauthorized = heroku.SAMLUnauthorized(c.String(FlagURL) + "/oidc/login")
+	}
+
+	groups, err := c.SSOGroups()
+	if err != nil {
+		panic(err)
+	}
+	s.Groups = groups
+
 	m := middleware.Common(s, realipResolver(c))
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := c.embed(r.Context())
@@ -104,6 +295,40 @@ func newServer(c *Context, e *empire.Empire) http.Handler {
 	})
 }
 
+// newReadiness returns the dependencies that need to be healthy for
+// /readiness to pass.
+func newReadiness(c *Context) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(c.String(FlagServerReadiness), ",") {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "":
+			continue
+		case empire.DependencyDatabase, empire.DependencyScheduler:
+			names = append(names, name)
+		default:
+			return nil, fmt.Errorf("unknown readiness dependency %q, must be %s or %s", name, empire.DependencyDatabase, empire.DependencyScheduler)
+		}
+	}
+	return names, nil
+}
+
+// newRateLimit returns the rate limit for API requests that change things, or
+// nil when none of the limits are configured.
+func newRateLimit(c *Context) *heroku.RateLimit {
+	global, token, app := c.Int(FlagServerRateLimitGlobal), c.Int(FlagServerRateLimitToken), c.Int(FlagServerRateLimitApp)
+	if global == 0 && token == 0 && app == 0 {
+		return nil
+	}
+
+	return &heroku.RateLimit{
+		Window:   c.Duration(FlagServerRateLimitWindow),
+		Global:   global,
+		PerToken: token,
+		PerApp:   app,
+	}
+}
+
 func realipResolver(c *Context) *realip.Resolver {
 	r := &realip.Resolver{}
 	for _, header := range c.StringSlice(FlagServerRealIp) {
@@ -200,6 +425,16 @@ func newAuth(c *Context, e *empire.Empire) *auth.Auth {
 		// an authenticator for authenticating requests with a users github
 		// credentials.
 		authenticator := githubauth.NewAuthenticator(client)
+
+		// Map GitHub team memberships to Empire teams, so that team
+		// grants apply to members of the GitHub team.
+		if c.Bool(FlagGithubTeamsSync) {
+			authenticator.SyncTeams = true
+			authenticator.Organization = c.String(FlagGithubOrg)
+
+			log.Println("Syncing GitHub teams with the following configuration:")
+			log.Println(fmt.Sprintf("  Organization: %v ", authenticator.Organization))
+		}
 		a := &auth.Auth{
 			Strategies: auth.Strategies{
 				{
@@ -233,12 +468,12 @@ func newAuth(c *Context, e *empire.Empire) *auth.Auth {
 		}
 
 		return a
-	case "saml":
-		loginURL := c.String(FlagURL) + "/saml/login"
+	case "saml", "oidc":
+		loginURL := c.String(FlagURL) + "/" + authBackend + "/login"
 
-		// When using the SAML authentication backend, access tokens are
-		// created through the browser, so username/password
-		// authentication should be disabled.
+		// When using the SAML or OpenID Connect authentication
+		// backends, access tokens are created through the browser, so
+		// username/password authentication should be disabled.
 		usernamePasswordDisabled := auth.AuthenticatorFunc(func(username, password, otp string) (*auth.Session, error) {
 			return nil, fmt.Errorf("Authentication via username/password is disabled. Login at %s", loginURL)
 		})
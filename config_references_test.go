@@ -0,0 +1,123 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvironment(t *testing.T) {
+	attrs := map[string]string{
+		"EMPIRE_APPID":   "1234",
+		"EMPIRE_APPNAME": "acme-inc",
+		"EMPIRE_RELEASE": "v2",
+	}
+
+	tests := []struct {
+		vars map[string]string
+		env  map[string]string
+		err  error
+	}{
+		// No references.
+		{
+			map[string]string{"RAILS_ENV": "production"},
+			map[string]string{"RAILS_ENV": "production"},
+			nil,
+		},
+
+		// References to app attributes.
+		{
+			map[string]string{"WEB_URL": "https://${EMPIRE_APPNAME}.example.com"},
+			map[string]string{"WEB_URL": "https://acme-inc.example.com"},
+			nil,
+		},
+
+		// References to other vars, which can be nested.
+		{
+			map[string]string{
+				"DATABASE_URL": "postgres://${DB_USER}@${DB_HOST}/app",
+				"DB_USER":      "${EMPIRE_APPNAME}",
+				"DB_HOST":      "db.example.com",
+			},
+			map[string]string{
+				"DATABASE_URL": "postgres://acme-inc@db.example.com/app",
+				"DB_USER":      "acme-inc",
+				"DB_HOST":      "db.example.com",
+			},
+			nil,
+		},
+
+		// App attributes take precedence over config vars.
+		{
+			map[string]string{"EMPIRE_APPNAME": "foo", "NAME": "${EMPIRE_APPNAME}"},
+			map[string]string{"EMPIRE_APPNAME": "acme-inc", "NAME": "acme-inc"},
+			nil,
+		},
+
+		// Escaped, unknown and unterminated references.
+		{
+			map[string]string{
+				"ESCAPED":      "$${EMPIRE_APPNAME}",
+				"UNKNOWN":      "${MISSING}",
+				"UNTERMINATED": "${EMPIRE_APPNAME",
+				"PASSWORD":     "pa$$word",
+			},
+			map[string]string{
+				"ESCAPED":      "${EMPIRE_APPNAME}",
+				"UNKNOWN":      "${MISSING}",
+				"UNTERMINATED": "${EMPIRE_APPNAME",
+				"PASSWORD":     "pa$$word",
+			},
+			nil,
+		},
+
+		// Cycles.
+		{
+			map[string]string{"A": "${A}"},
+			nil,
+			&referenceCycleError{Vars: []string{"A", "A"}},
+		},
+		{
+			map[string]string{"A": "${B}", "B": "x${A}"},
+			nil,
+			&referenceCycleError{Vars: []string{"A", "B", "A"}},
+		},
+	}
+
+	for _, tt := range tests {
+		vars := make(Vars)
+		for k, v := range tt.vars {
+			v := v
+			vars[Variable(k)] = &v
+		}
+
+		env, err := environment(vars, attrs)
+		if tt.err != nil {
+			// The cycle is reported starting from whichever var is
+			// resolved first.
+			if assert.IsType(t, tt.err, err) {
+				assert.Equal(t, len(tt.err.(*referenceCycleError).Vars), len(err.(*referenceCycleError).Vars))
+			}
+			continue
+		}
+		assert.NoError(t, err)
+
+		expected := make(map[string]string)
+		for k, v := range tt.env {
+			expected[k] = v
+		}
+		for k, v := range attrs {
+			expected[k] = v
+		}
+		assert.Equal(t, expected, env)
+	}
+}
+
+func TestValidateReferences(t *testing.T) {
+	a, b := "${B}", "${A}"
+	err := validateReferences(&App{Name: "acme-inc"}, Vars{"A": &a, "B": &b})
+	assert.IsType(t, &ValidationError{}, err)
+
+	a = "${EMPIRE_APPNAME}"
+	assert.NoError(t, validateReferences(&App{Name: "acme-inc"}, Vars{"A": &a, "B": &b}))
+}
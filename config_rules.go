@@ -0,0 +1,201 @@
+package empire
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// ConfigRule validates the value of a config var. Rules are declared in an
+// AppSpec, and are checked when a release is created, so that a release with
+// missing, or invalid, config vars fails before it's scheduled.
+type ConfigRule struct {
+	// When true, the config var needs to be set.
+	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
+
+	// When true, the config var can't be set to an empty value.
+	NonEmpty bool `json:"non_empty,omitempty" yaml:"non_empty,omitempty"`
+
+	// When true, the value needs to be an absolute URL (e.g.
+	// "postgres://db.example.com/app").
+	URL bool `json:"url,omitempty" yaml:"url,omitempty"`
+
+	// If provided, the value needs to be one of these.
+	Enum []string `json:"enum,omitempty" yaml:"enum,omitempty"`
+}
+
+// check returns the reason that the value isn't valid, or an empty string.
+func (r ConfigRule) check(v string) string {
+	if v == "" {
+		if r.NonEmpty {
+			return "can't be empty"
+		}
+		return ""
+	}
+
+	if r.URL {
+		if u, err := url.Parse(v); err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be a URL"
+		}
+	}
+
+	if len(r.Enum) > 0 {
+		for _, e := range r.Enum {
+			if v == e {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of %s", strings.Join(r.Enum, ", "))
+	}
+
+	return ""
+}
+
+// ConfigRules are the rules for the config vars of an app, by name.
+type ConfigRules map[string]ConfigRule
+
+// IsValid returns an error if the rules aren't valid.
+func (rules ConfigRules) IsValid() error {
+	for name := range rules {
+		if name == "" || strings.ContainsAny(name, "= ") {
+			return &ValidationError{Err: fmt.Errorf("invalid config var name %q", name)}
+		}
+	}
+	return nil
+}
+
+// Check returns a ConfigRulesError if the environment doesn't satisfy the
+// rules.
+func (rules ConfigRules) Check(env map[string]string) error {
+	var names []string
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	err := &ConfigRulesError{}
+	for _, name := range names {
+		r := rules[name]
+		v, ok := env[name]
+		if !ok {
+			if r.Required {
+				err.Missing = append(err.Missing, name)
+			}
+			continue
+		}
+		if reason := r.check(v); reason != "" {
+			err.Invalid = append(err.Invalid, fmt.Sprintf("%s %s", name, reason))
+		}
+	}
+
+	if len(err.Missing) > 0 || len(err.Invalid) > 0 {
+		return err
+	}
+	return nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (rules *ConfigRules) Scan(src interface{}) error {
+	if src == nil {
+		*rules = nil
+		return nil
+	}
+
+	bytes, ok := src.([]byte)
+	if !ok {
+		return error(errors.New("Scan source was not []bytes"))
+	}
+
+	return json.Unmarshal(bytes, rules)
+}
+
+// Value implements the driver.Value interface.
+func (rules ConfigRules) Value() (driver.Value, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.Value(raw), nil
+}
+
+// ConfigRulesError is returned when a release is created with config vars that
+// don't satisfy the config rules of the app.
+type ConfigRulesError struct {
+	// The required config vars that aren't set.
+	Missing []string
+
+	// The config vars that are invalid, along with the reason (e.g.
+	// "DATABASE_URL must be a URL").
+	Invalid []string
+}
+
+func (e *ConfigRulesError) Error() string {
+	var problems []string
+	if len(e.Missing) > 0 {
+		problems = append(problems, fmt.Sprintf("missing %s", strings.Join(e.Missing, ", ")))
+	}
+	problems = append(problems, e.Invalid...)
+	return fmt.Sprintf("Config vars don't satisfy the config rules of the app: %s", strings.Join(problems, "; "))
+}
+
+// checkConfigRules returns a ConfigRulesError if the config vars of the release
+// don't satisfy the config rules of its app. References between config vars
+// are resolved first.
+func checkConfigRules(release *Release) error {
+	if len(release.App.ConfigRules) == 0 {
+		return nil
+	}
+
+	var vars Vars
+	if release.Config != nil {
+		vars = release.Config.Vars
+	}
+
+	env, err := environment(vars, releaseAttributes(release))
+	if err != nil {
+		return &ValidationError{Err: err}
+	}
+
+	return release.App.ConfigRules.Check(env)
+}
+
+// SetConfigRulesOpts are options provided when changing the config rules of an
+// app.
+type SetConfigRulesOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// The new rules. Nil removes all of the rules.
+	Rules ConfigRules
+}
+
+// SetConfigRules changes the config rules of an app. The rules are checked
+// when the next release is created, so the current release isn't affected.
+func (e *Empire) SetConfigRules(ctx context.Context, opts SetConfigRulesOpts) error {
+	if err := e.authorize(opts.User, opts.App, ActionConfig); err != nil {
+		return err
+	}
+
+	if err := opts.Rules.IsValid(); err != nil {
+		return err
+	}
+
+	app := opts.App
+	app.ConfigRules = opts.Rules
+
+	return appsUpdate(e.db, app)
+}
@@ -0,0 +1,61 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigRules_Check(t *testing.T) {
+	rules := ConfigRules{
+		"DATABASE_URL": {Required: true, URL: true},
+		"SECRET_KEY":   {Required: true, NonEmpty: true},
+		"LOG_LEVEL":    {Enum: []string{"debug", "info"}},
+		"API_TOKEN":    {NonEmpty: true},
+	}
+
+	tests := []struct {
+		env map[string]string
+		err error
+	}{
+		{
+			map[string]string{"DATABASE_URL": "postgres://db.example.com/app", "SECRET_KEY": "s3cr3t"},
+			nil,
+		},
+		{
+			map[string]string{"DATABASE_URL": "postgres://db.example.com/app", "SECRET_KEY": "s3cr3t", "LOG_LEVEL": "info"},
+			nil,
+		},
+		{
+			map[string]string{},
+			&ConfigRulesError{Missing: []string{"DATABASE_URL", "SECRET_KEY"}},
+		},
+		{
+			map[string]string{"DATABASE_URL": "db.example.com", "SECRET_KEY": "", "LOG_LEVEL": "trace", "API_TOKEN": ""},
+			&ConfigRulesError{Invalid: []string{
+				"API_TOKEN can't be empty",
+				"DATABASE_URL must be a URL",
+				"LOG_LEVEL must be one of debug, info",
+				"SECRET_KEY can't be empty",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.err, rules.Check(tt.env))
+	}
+}
+
+func TestCheckConfigRules(t *testing.T) {
+	url := "https://${EMPIRE_APPNAME}.example.com"
+	release := &Release{
+		App:    &App{Name: "acme-inc", ConfigRules: ConfigRules{"WEB_URL": {Required: true, URL: true}}},
+		Config: &Config{Vars: Vars{"WEB_URL": &url}},
+	}
+
+	// References are resolved before the rules are checked.
+	assert.NoError(t, checkConfigRules(release))
+
+	release.Config.Vars = Vars{}
+	assert.Equal(t, &ConfigRulesError{Missing: []string{"WEB_URL"}}, checkConfigRules(release))
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
+	"reflect"
 	"sort"
 	"strings"
 
@@ -28,7 +29,8 @@ type Config struct {
 }
 
 // newConfig initializes a new config based on the old config, with the new
-// variables provided.
+// variables provided. The old config isn't modified, since releases that use
+// it still need to be scheduled with the same environment.
 func newConfig(old *Config, vars Vars) *Config {
 	v := mergeVars(old.Vars, vars)
 
@@ -132,7 +134,19 @@ func (s *configsService) Set(ctx context.Context, db *gorm.DB, opts SetOpts) (*C
 		return nil, err
 	}
 
-	c, err := configsCreate(db, newConfig(old, vars))
+	config := newConfig(old, vars)
+	if err := validateReferences(app, config.Vars); err != nil {
+		return nil, err
+	}
+
+	if sensitive := app.SensitiveVars.update(vars, opts.Sensitive); !reflect.DeepEqual(sensitive, app.SensitiveVars) {
+		app.SensitiveVars = sensitive
+		if err := appsUpdate(db, app); err != nil {
+			return nil, err
+		}
+	}
+
+	c, err := configsCreate(db, config)
 	if err != nil {
 		return c, err
 	}
@@ -147,13 +161,29 @@ func (s *configsService) Set(ctx context.Context, db *gorm.DB, opts SetOpts) (*C
 	}
 
 	// Create new release based on new config and old slug
-	_, err = s.releases.CreateAndRelease(ctx, db, &Release{
+	r, err := s.releases.Create(ctx, db, &Release{
 		App:         release.App,
 		Config:      c,
 		Slug:        release.Slug,
 		Description: configsApplyReleaseDesc(opts),
-	}, nil)
-	return c, err
+		CreatedBy:   opts.User.Name,
+		Source:      ReleaseSourceConfig,
+		Message:     opts.Message,
+	})
+	if err != nil {
+		return c, err
+	}
+
+	if err := s.admit(ctx, &AdmissionRequest{
+		Operation:      AdmissionConfig,
+		User:           opts.User,
+		Release:        r,
+		FreezeOverride: opts.FreezeOverride,
+	}); err != nil {
+		return c, err
+	}
+
+	return c, s.releases.Release(ctx, r, nil)
 }
 
 // Returns configs for latest release or the latest configs if there are no releases.
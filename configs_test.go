@@ -125,3 +125,21 @@ func TestReleaseDesc(t *testing.T) {
 		}
 	}
 }
+
+func TestNewConfig_OldConfigUnchanged(t *testing.T) {
+	var (
+		PRODUCTION = "production"
+		STAGING    = "staging"
+	)
+
+	old := &Config{AppID: "1234", Vars: Vars{"RAILS_ENV": &PRODUCTION}}
+	c := newConfig(old, Vars{"RAILS_ENV": &STAGING, "DEBUG": &STAGING})
+
+	if got, want := old.Vars, (Vars{"RAILS_ENV": &PRODUCTION}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("old config => %v; want %v", got, want)
+	}
+
+	if got, want := c.Vars, (Vars{"RAILS_ENV": &STAGING, "DEBUG": &STAGING}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("new config => %v; want %v", got, want)
+	}
+}
@@ -3,15 +3,17 @@ package empire
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
 
 	. "github.com/remind101/empire/pkg/bytesize"
 	"github.com/remind101/empire/pkg/constraints"
 )
 
 var (
-	Constraints1X = Constraints{constraints.CPUShare(256), constraints.Memory(512 * MB), constraints.Nproc(256)}
-	Constraints2X = Constraints{constraints.CPUShare(512), constraints.Memory(1 * GB), constraints.Nproc(512)}
-	ConstraintsPX = Constraints{constraints.CPUShare(1024), constraints.Memory(6 * GB), 0}
+	Constraints1X = Constraints{constraints.CPUShare(256), constraints.Memory(512 * MB), constraints.Nproc(256), 0}
+	Constraints2X = Constraints{constraints.CPUShare(512), constraints.Memory(1 * GB), constraints.Nproc(512), 0}
+	ConstraintsPX = Constraints{constraints.CPUShare(1024), constraints.Memory(6 * GB), 0, 0}
 
 	// NamedConstraints maps a heroku dynos size to a Constraints.
 	NamedConstraints = map[string]Constraints{
@@ -22,18 +24,68 @@ var (
 
 	// DefaultConstraints defaults to 1X process size.
 	DefaultConstraints = Constraints1X
+
+	// builtinConstraints are the sizes that can't be changed by the
+	// operator configuration.
+	builtinConstraints = map[string]Constraints{
+		"1X": Constraints1X,
+		"2X": Constraints2X,
+		"PX": ConstraintsPX,
+	}
+
+	// Guards NamedConstraints, which changes when the operator
+	// configuration is reloaded.
+	namedConstraintsMu sync.RWMutex
 )
 
+// setNamedConstraints replaces the sizes in NamedConstraints with the builtin
+// sizes, and the given sizes.
+func setNamedConstraints(sizes map[string]Constraints) {
+	named := make(map[string]Constraints)
+	for n, c := range builtinConstraints {
+		named[n] = c
+	}
+	for n, c := range sizes {
+		named[n] = c
+	}
+
+	namedConstraintsMu.Lock()
+	defer namedConstraintsMu.Unlock()
+	NamedConstraints = named
+}
+
+// namedConstraints returns the size with the given name.
+func namedConstraints(name string) (Constraints, bool) {
+	namedConstraintsMu.RLock()
+	defer namedConstraintsMu.RUnlock()
+	c, ok := NamedConstraints[name]
+	return c, ok
+}
+
+// constraintsName returns the name of the size with the given constraints.
+func constraintsName(c Constraints) (string, bool) {
+	namedConstraintsMu.RLock()
+	defer namedConstraintsMu.RUnlock()
+	for n, constraint := range NamedConstraints {
+		if c == constraint {
+			return n, true
+		}
+	}
+	return "", false
+}
+
 // Constraints aliases the constraints.Constraints type to implement the
 // json.Unmarshaller interface.
 type Constraints constraints.Constraints
 
-func parseConstraints(con string) (*Constraints, error) {
+// ParseConstraints parses a named process size, like "1X", or a constraints
+// string, like "512:1GB". It returns nil if con is empty.
+func ParseConstraints(con string) (*Constraints, error) {
 	if con == "" {
 		return nil, nil
 	}
 
-	if n, ok := NamedConstraints[con]; ok {
+	if n, ok := namedConstraints(con); ok {
 		c := Constraints(n)
 		return &c, nil
 	}
@@ -54,7 +106,7 @@ func (c *Constraints) UnmarshalJSON(b []byte) error {
 		return err
 	}
 
-	cc, err := parseConstraints(s)
+	cc, err := ParseConstraints(s)
 	if err != nil {
 		return err
 	}
@@ -67,15 +119,20 @@ func (c *Constraints) UnmarshalJSON(b []byte) error {
 }
 
 func (c Constraints) String() string {
-	for n, constraint := range NamedConstraints {
-		if c == Constraints(constraint) {
-			return n
-		}
+	if n, ok := constraintsName(c); ok {
+		return n
+	}
+
+	var opts []string
+	if c.Nproc != 0 {
+		opts = append(opts, fmt.Sprintf("nproc=%d", c.Nproc))
+	}
+	if c.GPU != 0 {
+		opts = append(opts, fmt.Sprintf("gpu=%d", c.GPU))
 	}
 
-	if c.Nproc == 0 {
+	if len(opts) == 0 {
 		return fmt.Sprintf("%d:%s", c.CPUShare, c.Memory)
-	} else {
-		return fmt.Sprintf("%d:%s:nproc=%d", c.CPUShare, c.Memory, c.Nproc)
 	}
+	return fmt.Sprintf("%d:%s:%s", c.CPUShare, c.Memory, strings.Join(opts, ","))
 }
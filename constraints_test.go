@@ -16,9 +16,10 @@ func TestConstraints_UnmarshalJSON(t *testing.T) {
 		out Constraints
 		err error
 	}{
-		{"512:1KB", Constraints{512, 1024, 0}, nil},
-		{"512:1KB:nproc=512", Constraints{512, 1024, 512}, nil},
-		{"1025:1KB", Constraints{1025, 1024, 0}, nil},
+		{"512:1KB", Constraints{512, 1024, 0, 0}, nil},
+		{"512:1KB:nproc=512", Constraints{512, 1024, 512, 0}, nil},
+		{"1025:1KB", Constraints{1025, 1024, 0, 0}, nil},
+		{"1024:6GB:gpu=1", Constraints{1024, constraints.Memory(6 * GB), 0, 1}, nil},
 		{"0:1KB", Constraints{}, constraints.ErrInvalidCPUShare},
 
 		{"1024", Constraints{}, constraints.ErrInvalidConstraint},
@@ -52,8 +53,10 @@ func TestConstraints_String(t *testing.T) {
 		{Constraints2X, "2X"},
 		{ConstraintsPX, "PX"},
 
-		{Constraints{100, constraints.Memory(1 * MB), 0}, "100:1.00mb"},
-		{Constraints{100, constraints.Memory(1 * MB), 512}, "100:1.00mb:nproc=512"},
+		{Constraints{100, constraints.Memory(1 * MB), 0, 0}, "100:1.00mb"},
+		{Constraints{100, constraints.Memory(1 * MB), 512, 0}, "100:1.00mb:nproc=512"},
+		{Constraints{100, constraints.Memory(1 * MB), 0, 1}, "100:1.00mb:gpu=1"},
+		{Constraints{100, constraints.Memory(1 * MB), 512, 2}, "100:1.00mb:nproc=512,gpu=2"},
 	}
 
 	for _, tt := range tests {
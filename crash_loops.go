@@ -0,0 +1,284 @@
+package empire
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/remind101/pkg/reporter"
+	"golang.org/x/net/context"
+)
+
+const (
+	// DefaultCrashLoopThreshold is the number of instances of a process that
+	// have to be replaced within the crash loop window for the process to be
+	// considered to be crash looping.
+	DefaultCrashLoopThreshold = 3
+
+	// DefaultCrashLoopWindow is how far back replaced instances are
+	// counted.
+	DefaultCrashLoopWindow = 10 * time.Minute
+)
+
+// CrashLoopDetector periodically compares the instances of the long running
+// processes of each app with the ones that it saw before, and publishes a
+// CrashLoopEvent when the instances of a process of the current release keep
+// being replaced.
+//
+// An instance is considered to have been replaced when it disappears, and a new
+// instance of the same process appears in its place, which isn't the case when
+// a process is scaled down, or a new release is deployed. Instances that were
+// running on lost hosts aren't counted, since the Rescheduler replaces them, and
+// neither are instances that were killed by the ChaosMonkey.
+type CrashLoopDetector struct {
+	*Empire
+
+	// How often to look at the instances of each app.
+	Interval time.Duration
+
+	// The number of replaced instances that's considered a crash loop. The
+	// zero value is DefaultCrashLoopThreshold.
+	Threshold int
+
+	// How far back replaced instances are counted. The zero value is
+	// DefaultCrashLoopWindow.
+	Window time.Duration
+
+	// What was seen of each app, by the id of the app.
+	apps map[string]*crashLoopState
+}
+
+// Start starts looking for crash loops, until the context is canceled. Errors,
+// and panics, are reported to the reporter in the context.
+func (d *CrashLoopDetector) Start(ctx context.Context) {
+	defer reporter.Monitor(ctx)
+
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.DetectCrashLoops(ctx); err != nil {
+				reporter.Report(ctx, err)
+			}
+		}
+	}
+}
+
+// DetectCrashLoops looks at the instances of every app, and publishes a
+// CrashLoopEvent for each process that's crash looping. A process is only
+// reported once per window. It returns the events that were published.
+func (d *CrashLoopDetector) DetectCrashLoops(ctx context.Context) ([]CrashLoopEvent, error) {
+	apps, err := apps(d.db, AppsQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	if d.apps == nil {
+		d.apps = make(map[string]*crashLoopState)
+	}
+
+	var (
+		events []CrashLoopEvent
+		errors []error
+		exists = make(map[string]bool)
+	)
+	for _, app := range apps {
+		exists[app.ID] = true
+
+		e, err := d.detectAppCrashLoops(ctx, app)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		for _, event := range e {
+			if err := d.PublishEvent(event); err != nil {
+				errors = append(errors, err)
+				continue
+			}
+			events = append(events, event)
+		}
+	}
+
+	// Forget about apps that were destroyed.
+	for id := range d.apps {
+		if !exists[id] {
+			delete(d.apps, id)
+		}
+	}
+
+	if len(errors) > 0 {
+		return events, &multiError{Errors: errors}
+	}
+
+	return events, nil
+}
+
+// detectAppCrashLoops returns a CrashLoopEvent for each process of the app
+// that's crash looping.
+func (d *CrashLoopDetector) detectAppCrashLoops(ctx context.Context, app *App) ([]CrashLoopEvent, error) {
+	release, err := releasesFind(d.db, ReleasesQuery{App: app})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	scheduler, err := d.scheduler(app)
+	if err != nil {
+		return nil, err
+	}
+
+	instances, err := scheduler.Tasks(ctx, app.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The instances of each long running process of the current release,
+	// and whether their host was lost.
+	running := make(map[string]map[string]bool)
+	for _, i := range instances {
+		if i.State == "STOPPED" || i.Process.Labels[userLabel] != "" || i.Process.Labels[cronLabel] != "" {
+			continue
+		}
+		p, ok := release.Formation[i.Process.Type]
+		if !ok || p.Cron != nil || p.NoService {
+			continue
+		}
+		t := taskFromInstance(i)
+		if t.Version != release.Version {
+			continue
+		}
+		if running[t.Type] == nil {
+			running[t.Type] = make(map[string]bool)
+		}
+		running[t.Type][t.ID] = t.Host.Lost
+	}
+
+	state := d.apps[app.ID]
+	if state == nil || state.release != release.Version {
+		state = newCrashLoopState(release.Version)
+		d.apps[app.ID] = state
+	}
+
+	threshold, window := d.Threshold, d.Window
+	if threshold == 0 {
+		threshold = DefaultCrashLoopThreshold
+	}
+	if window == 0 {
+		window = DefaultCrashLoopWindow
+	}
+
+	now := timex.Now()
+
+	// Instances that were killed by the ChaosMonkey are replaced, but
+	// aren't crashing, so they're treated like instances on lost hosts.
+	since := now.Add(-window)
+	kills, err := chaosKills(d.db, ChaosKillsQuery{App: app, Since: &since})
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range kills {
+		if ids, ok := state.instances[k.ProcessType]; ok {
+			if _, ok := ids[k.TaskID]; ok {
+				ids[k.TaskID] = true
+			}
+		}
+	}
+
+	var events []CrashLoopEvent
+	for process, ids := range running {
+		restarts := state.observe(process, ids, now, window)
+		if restarts < threshold {
+			continue
+		}
+		if at, ok := state.reported[process]; ok && now.Sub(at) < window {
+			continue
+		}
+		state.reported[process] = now
+
+		events = append(events, CrashLoopEvent{
+			App:      app.Name,
+			Process:  process,
+			Release:  release.Version,
+			Restarts: restarts,
+			Window:   window,
+			app:      app,
+		})
+	}
+
+	return events, nil
+}
+
+// crashLoopState is what the CrashLoopDetector has seen of the processes of a
+// single release of an app.
+type crashLoopState struct {
+	// The release of the app.
+	release int
+
+	// The instances that were last seen for each process, and whether their
+	// host was lost, or they were killed by the ChaosMonkey.
+	instances map[string]map[string]bool
+
+	// When instances of each process were replaced.
+	restarts map[string][]time.Time
+
+	// When each process was last reported to be crash looping.
+	reported map[string]time.Time
+}
+
+func newCrashLoopState(release int) *crashLoopState {
+	return &crashLoopState{
+		release:   release,
+		instances: make(map[string]map[string]bool),
+		restarts:  make(map[string][]time.Time),
+		reported:  make(map[string]time.Time),
+	}
+}
+
+// observe records the instances of the process that are running now, and
+// returns the number of instances that were replaced within the window.
+func (s *crashLoopState) observe(process string, running map[string]bool, now time.Time, window time.Duration) int {
+	if previous, ok := s.instances[process]; ok {
+		for i := replacements(previous, running); i > 0; i-- {
+			s.restarts[process] = append(s.restarts[process], now)
+		}
+	}
+	s.instances[process] = running
+
+	var restarts []time.Time
+	for _, at := range s.restarts[process] {
+		if now.Sub(at) < window {
+			restarts = append(restarts, at)
+		}
+	}
+	s.restarts[process] = restarts
+
+	return len(restarts)
+}
+
+// replacements returns the number of instances that disappeared, and were
+// replaced by a new instance. Instances that were running on a lost host aren't
+// counted.
+func replacements(previous, running map[string]bool) int {
+	var gone, added int
+	for id, lost := range previous {
+		if _, ok := running[id]; !ok && !lost {
+			gone++
+		}
+	}
+	for id := range running {
+		if _, ok := previous[id]; !ok {
+			added++
+		}
+	}
+	if gone < added {
+		return gone
+	}
+	return added
+}
@@ -0,0 +1,49 @@
+package empire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplacements(t *testing.T) {
+	tests := []struct {
+		previous, running map[string]bool
+		replaced          int
+	}{
+		// Nothing changed.
+		{map[string]bool{"a": false}, map[string]bool{"a": false}, 0},
+
+		// Replaced.
+		{map[string]bool{"a": false, "b": false}, map[string]bool{"a": false, "c": false}, 1},
+
+		// Scaled down, or up.
+		{map[string]bool{"a": false, "b": false}, map[string]bool{"a": false}, 0},
+		{map[string]bool{"a": false}, map[string]bool{"a": false, "b": false}, 0},
+
+		// Moved off of a lost host.
+		{map[string]bool{"a": true, "b": false}, map[string]bool{"b": false, "c": false}, 0},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.replaced, replacements(tt.previous, tt.running))
+	}
+}
+
+func TestCrashLoopState_Observe(t *testing.T) {
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	window := 10 * time.Minute
+	s := newCrashLoopState(1)
+
+	assert.Equal(t, 0, s.observe("web", map[string]bool{"a": false}, now, window))
+	assert.Equal(t, 1, s.observe("web", map[string]bool{"b": false}, now.Add(time.Minute), window))
+	assert.Equal(t, 2, s.observe("web", map[string]bool{"c": false}, now.Add(2*time.Minute), window))
+	assert.Equal(t, 2, s.observe("web", map[string]bool{"c": false}, now.Add(3*time.Minute), window))
+
+	// Replacements outside of the window aren't counted.
+	assert.Equal(t, 2, s.observe("web", map[string]bool{"d": false}, now.Add(11*time.Minute+30*time.Second), window))
+
+	// Other processes are counted separately.
+	assert.Equal(t, 0, s.observe("worker", map[string]bool{"e": false}, now.Add(12*time.Minute), window))
+}
@@ -0,0 +1,124 @@
+package empire
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/remind101/empire/pkg/cron"
+	"golang.org/x/net/context"
+)
+
+// cronCatchUp is how long after it was due a scheduled invocation of a process
+// in a time zone is still triggered, when it was missed (e.g. because Empire
+// was restarting).
+const cronCatchUp = 10 * time.Minute
+
+// SetCronTimezoneOpts are options provided when changing the time zone that
+// the schedules of an app are evaluated in.
+type SetCronTimezoneOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// The name of the time zone (e.g. "America/New_York"). Empty evaluates
+	// schedules in UTC.
+	Timezone string
+}
+
+// SetCronTimezone changes the time zone that the schedules of the scheduled
+// processes of an app are evaluated in, and releases it so that processes
+// that are scheduled in a time zone are triggered by Empire, instead of
+// CloudWatch Events.
+func (e *Empire) SetCronTimezone(ctx context.Context, opts SetCronTimezoneOpts) error {
+	if err := e.authorize(opts.User, opts.App, ActionAdmin); err != nil {
+		return err
+	}
+
+	if _, err := time.LoadLocation(opts.Timezone); err != nil {
+		return &ValidationError{Err: fmt.Errorf("Unknown time zone %q.", opts.Timezone)}
+	}
+
+	tx := e.db.Begin()
+
+	app := opts.App
+
+	app.CronTimezone = opts.Timezone
+
+	// The schedules of the current release need to be understood by Empire
+	// in the new time zone.
+	release, err := releasesFind(tx, ReleasesQuery{App: app})
+	if err == nil {
+		for name, p := range release.Formation {
+			if _, err := cronSchedule(app, p); err != nil {
+				tx.Rollback()
+				return &ValidationError{Err: fmt.Errorf("%s: %v", name, err)}
+			}
+		}
+	}
+
+	if err := appsUpdate(tx, app); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := e.releases.ReleaseApp(ctx, tx, app, nil); err != nil && err != ErrNoReleases {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// cronTimezone returns the name of the time zone that the schedule of the
+// process is evaluated in, which is the time zone in its cron expression, or
+// the time zone of the app.
+func cronTimezone(app *App, p Process) string {
+	if p.Cron == nil {
+		return ""
+	}
+	if tz, _ := cron.SplitTimezone(*p.Cron); tz != "" {
+		return tz
+	}
+	return app.CronTimezone
+}
+
+// cronSchedule returns the parsed schedule of a process that's scheduled in a
+// time zone other than UTC, which Empire triggers itself, since CloudWatch
+// Events only evaluates schedules in UTC. It returns nil for processes that
+// are triggered by CloudWatch Events.
+func cronSchedule(app *App, p Process) (*cron.Schedule, error) {
+	tz := cronTimezone(app, p)
+	if tz == "" || tz == "UTC" {
+		return nil, nil
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", tz)
+	}
+
+	s, err := cron.Parse(*p.Cron, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression: %v", err)
+	}
+	return s, nil
+}
+
+// dueCronInvocation returns the latest time that the schedule was due at, after
+// the last time that it was triggered at and up until now, or the zero time if
+// it isn't due. Invocations that are more than cronCatchUp late aren't
+// triggered, and when several invocations were missed, only the latest one is.
+func dueCronInvocation(s *cron.Schedule, last *time.Time, now time.Time) time.Time {
+	from := now.Add(-cronCatchUp)
+	if last != nil && last.After(from) {
+		from = *last
+	}
+
+	var due time.Time
+	for at := s.Next(from); !at.IsZero() && !at.After(now); at = s.Next(at) {
+		due = at
+	}
+	return due
+}
@@ -0,0 +1,84 @@
+package empire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronSchedule(t *testing.T) {
+	utc := "0 9 ? * MON-FRI *"
+	newYork := "CRON_TZ=America/New_York 0 9 ? * MON-FRI *"
+	last := "0 9 L * ? *"
+
+	tests := []struct {
+		app      *App
+		cron     *string
+		location string
+		err      string
+	}{
+		{&App{}, nil, "", ""},
+		{&App{}, &utc, "", ""},
+		{&App{CronTimezone: "UTC"}, &utc, "", ""},
+		{&App{}, &newYork, "America/New_York", ""},
+		{&App{CronTimezone: "Europe/London"}, &newYork, "America/New_York", ""},
+		{&App{CronTimezone: "Europe/London"}, &utc, "Europe/London", ""},
+		{&App{}, &last, "", ""},
+		{&App{CronTimezone: "Europe/London"}, &last, "", `invalid cron expression: day-of-month: "L" isn't supported`},
+	}
+
+	for _, tt := range tests {
+		s, err := cronSchedule(tt.app, Process{Cron: tt.cron})
+		if tt.err != "" {
+			assert.EqualError(t, err, tt.err)
+			continue
+		}
+		assert.NoError(t, err)
+		if tt.location == "" {
+			assert.Nil(t, s)
+		} else {
+			assert.Equal(t, tt.location, s.Location.String())
+		}
+	}
+}
+
+func TestDueCronInvocation(t *testing.T) {
+	cron := "CRON_TZ=America/New_York 0/5 * * * ? *"
+	s, err := cronSchedule(&App{}, Process{Cron: &cron})
+	if err != nil {
+		t.Skip(err)
+	}
+
+	at := func(v string) *time.Time {
+		t, _ := time.Parse(time.RFC3339, v)
+		return &t
+	}
+
+	tests := []struct {
+		last *time.Time
+		now  time.Time
+		due  time.Time
+	}{
+		// Not triggered before.
+		{nil, *at("2016-06-13T18:07:00Z"), *at("2016-06-13T18:05:00Z")},
+
+		// Already triggered.
+		{at("2016-06-13T18:05:00Z"), *at("2016-06-13T18:07:00Z"), time.Time{}},
+
+		// Next one is due.
+		{at("2016-06-13T18:05:00Z"), *at("2016-06-13T18:10:30Z"), *at("2016-06-13T18:10:00Z")},
+
+		// Several were missed, so only the latest one is triggered.
+		{at("2016-06-13T18:05:00Z"), *at("2016-06-13T18:21:00Z"), *at("2016-06-13T18:20:00Z")},
+
+		// Missed by more than 10 minutes.
+		{at("2016-06-13T17:00:00Z"), *at("2016-06-13T18:04:00Z"), *at("2016-06-13T18:00:00Z")},
+		{at("2016-06-13T17:00:00Z"), *at("2016-06-13T18:14:00Z"), *at("2016-06-13T18:10:00Z")},
+	}
+
+	for _, tt := range tests {
+		due := dueCronInvocation(s, tt.last, tt.now)
+		assert.True(t, tt.due.Equal(due), "expected %v, got %v", tt.due, due)
+	}
+}
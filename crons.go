@@ -0,0 +1,664 @@
+package empire
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/headerutil"
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/remind101/empire/twelvefactor"
+	"github.com/remind101/pkg/reporter"
+	"golang.org/x/net/context"
+)
+
+// Overlap policies of scheduled processes, which decide what happens when a
+// scheduled process is triggered while a previous instance of it is still
+// running. Processes without an overlap policy allow overlapping instances.
+const (
+	OverlapAllow   = "allow"
+	OverlapSkip    = "skip"
+	OverlapQueue   = "queue"
+	OverlapReplace = "replace"
+)
+
+// OverlapPolicies are the valid overlap policies.
+var OverlapPolicies = []string{OverlapAllow, OverlapSkip, OverlapQueue, OverlapReplace}
+
+// What triggered an invocation of a scheduled process.
+const (
+	CronTriggerSchedule = "schedule"
+	CronTriggerManual   = "manual"
+)
+
+// CronRunStateSkipped is the state of a scheduled invocation that wasn't
+// started, because a previous invocation was still running and the overlap
+// policy of the process is to skip.
+const CronRunStateSkipped = "skipped"
+
+// cronLabel is the label that the processes of invocations that Empire started
+// are labeled with, which holds the id of the cron run.
+const cronLabel = "empire.cron"
+
+// cronRunRetention is how long the history of a scheduled process is kept.
+const cronRunRetention = 30 * 24 * time.Hour
+
+// CronRun is a single invocation of a scheduled process.
+type CronRun struct {
+	// A unique uuid that identifies the invocation.
+	ID string
+
+	// The id of the app that the process belongs to.
+	AppID string
+
+	// The scheduled process that was invoked.
+	ProcessType string
+
+	// What triggered the invocation: "schedule" or "manual".
+	Trigger string
+
+	// The state of the invocation. One of the JobState values, or
+	// CronRunStateSkipped.
+	State string
+
+	// The id of the instance that the scheduler started, once it's known.
+	TaskID string
+
+	// The exit code of the process, once it has finished.
+	ExitCode *int
+
+	// Where the output of the process can be found, when it's known (e.g.
+	// "group/prefix/vacuum/1234" for the awslogs log driver).
+	Output string
+
+	// The user that triggered the invocation, for manual invocations.
+	CreatedBy string
+
+	// The time that the invocation was recorded.
+	CreatedAt *time.Time
+
+	// The times that the process started and finished running.
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+
+	// The time that the invocation was due at, for processes that are
+	// scheduled in a time zone, which Empire triggers itself.
+	ScheduledAt *time.Time
+}
+
+// BeforeCreate sets created_at before inserting.
+func (r *CronRun) BeforeCreate() error {
+	t := timex.Now()
+	r.CreatedAt = &t
+	return nil
+}
+
+// Finished returns true if the process has stopped running.
+func (r *CronRun) Finished() bool {
+	return r.FinishedAt != nil
+}
+
+// finish records the exit code of the process.
+func (r *CronRun) finish(exitCode int, at time.Time) {
+	r.ExitCode = &exitCode
+	r.FinishedAt = &at
+	if exitCode == 0 {
+		r.State = JobStateSucceeded
+	} else {
+		r.State = JobStateFailed
+	}
+}
+
+// CronRunsQuery is a scope implementation for common things to filter cron
+// runs by.
+type CronRunsQuery struct {
+	// If provided, finds runs for the given app.
+	App *App
+
+	// If provided, finds runs of the given process.
+	ProcessType *string
+
+	// If provided, finds the run of the instance with the given id.
+	TaskID *string
+
+	// If true, only finds runs that haven't finished.
+	Open bool
+
+	// If provided, uses the limit and sorting parameters specified in the range.
+	Range headerutil.Range
+}
+
+// scope implements the scope interface.
+func (q CronRunsQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.App != nil {
+		scope = append(scope, forApp(q.App))
+	}
+
+	if q.ProcessType != nil {
+		scope = append(scope, fieldEquals("process_type", *q.ProcessType))
+	}
+
+	if q.TaskID != nil {
+		scope = append(scope, fieldEquals("task_id", *q.TaskID))
+	}
+
+	if q.Open {
+		scope = append(scope, isNull("finished_at"))
+	}
+
+	scope = append(scope, inRange(q.Range.WithDefaults(q.DefaultRange())))
+
+	return scope.scope(db)
+}
+
+// DefaultRange returns the default headerutil.Range used if values aren't
+// provided.
+func (q CronRunsQuery) DefaultRange() headerutil.Range {
+	sort, order := "created_at", "desc"
+	return headerutil.Range{
+		Sort:  &sort,
+		Order: &order,
+	}
+}
+
+// cronRuns returns all cron runs matching the scope.
+func cronRuns(db *gorm.DB, scope scope) ([]*CronRun, error) {
+	var runs []*CronRun
+	return runs, find(db, scope, &runs)
+}
+
+func cronRunsCreate(db *gorm.DB, run *CronRun) (*CronRun, error) {
+	return run, db.Create(run).Error
+}
+
+func cronRunsUpdate(db *gorm.DB, run *CronRun) error {
+	return db.Save(run).Error
+}
+
+// cronRunsLastScheduled returns the time that the last invocation of the
+// process that Empire triggered on its schedule was due at, or nil if there
+// isn't one.
+func cronRunsLastScheduled(db *gorm.DB, app *App, process string) (*time.Time, error) {
+	var run CronRun
+	err := db.Where("app_id = ? AND process_type = ? AND scheduled_at is not null", app.ID, process).Order("scheduled_at desc").First(&run).Error
+	if err == gorm.RecordNotFound {
+		return nil, nil
+	}
+	return run.ScheduledAt, err
+}
+
+// cronRunsDestroyBefore removes the runs that finished before the given time.
+func cronRunsDestroyBefore(db *gorm.DB, before time.Time) error {
+	return db.Where("finished_at < ?", before).Delete(CronRun{}).Error
+}
+
+// CronTriggerOpts are options provided when triggering a scheduled process.
+type CronTriggerOpts struct {
+	// User performing this action.
+	User *User
+
+	// Related app.
+	App *App
+
+	// The scheduled process to trigger.
+	Process string
+
+	// Commit message
+	Message string
+}
+
+func (opts CronTriggerOpts) Event() CronTriggerEvent {
+	return CronTriggerEvent{
+		User:    opts.User.Name,
+		App:     opts.App.Name,
+		Process: opts.Process,
+		Message: opts.Message,
+		app:     opts.App,
+	}
+}
+
+func (opts CronTriggerOpts) Validate(e *Empire) error {
+	if err := e.authorize(opts.User, opts.App, ActionRun); err != nil {
+		return err
+	}
+	return e.requireMessages(opts.Message)
+}
+
+// cronTrigger starts an invocation of a scheduled process right away, applying
+// its overlap policy to the instances of the process that are still running.
+func (e *Empire) cronTrigger(ctx context.Context, opts CronTriggerOpts) (*CronRun, error) {
+	release, err := releasesFind(e.db, ReleasesQuery{App: opts.App})
+	if err != nil {
+		return nil, err
+	}
+
+	p, ok := release.Formation[opts.Process]
+	if !ok || p.Cron == nil {
+		return nil, &ValidationError{Err: fmt.Errorf("`%s` isn't a scheduled process.", opts.Process)}
+	}
+
+	scheduler, err := e.scheduler(opts.App)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := scheduler.Tasks(ctx, opts.App.ID)
+	if err != nil {
+		return nil, err
+	}
+	running := runningInstances(tasks, opts.Process)
+
+	run := &CronRun{
+		AppID:       opts.App.ID,
+		ProcessType: opts.Process,
+		Trigger:     CronTriggerManual,
+		State:       JobStateQueued,
+		CreatedBy:   opts.User.Name,
+	}
+
+	if len(running) > 0 {
+		switch p.Overlap {
+		case OverlapSkip:
+			return nil, &ValidationError{Err: fmt.Errorf("`%s` is still running, and its overlap policy is to skip.", opts.Process)}
+		case OverlapQueue:
+			// The CronMonitor starts it once the running instances
+			// have stopped.
+			return cronRunsCreate(e.db, run)
+		case OverlapReplace:
+			for _, t := range running {
+				if err := scheduler.Stop(ctx, t.ID); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if _, err := cronRunsCreate(e.db, run); err != nil {
+		return run, err
+	}
+
+	return run, e.startCronRun(ctx, opts.App, release, run)
+}
+
+// startCronRun runs the scheduled process of an invocation that Empire
+// triggered. Manually triggered invocations run a single instance, while
+// scheduled invocations run as many instances as the process is scaled to, like
+// CloudWatch Events does. If capturing output is enabled, the output of the
+// process is captured under the id of the run.
+func (e *Empire) startCronRun(ctx context.Context, app *App, release *Release, run *CronRun) error {
+	proc := release.Formation[run.ProcessType]
+	if run.Trigger == CronTriggerManual {
+		proc.Quantity = 1
+	}
+	if e.Outputs != nil {
+		proc.Logging = e.Outputs.Logging(app, run.ID)
+	}
+
+	err := e.runner.run(ctx, release, run.ProcessType, proc, RunOpts{
+		User: &User{Name: run.CreatedBy},
+		App:  app,
+	}, map[string]string{cronLabel: run.ID})
+
+	t := timex.Now()
+	if err != nil {
+		// The invocation is recorded as failed, so that it isn't
+		// started again.
+		run.State = JobStateFailed
+		run.FinishedAt = &t
+		if err := cronRunsUpdate(e.db, run); err != nil {
+			return err
+		}
+		return err
+	}
+
+	run.State = JobStateRunning
+	run.StartedAt = &t
+	return cronRunsUpdate(e.db, run)
+}
+
+// runningInstances returns the instances of the process that haven't stopped.
+func runningInstances(tasks []*twelvefactor.Task, process string) []*twelvefactor.Task {
+	var running []*twelvefactor.Task
+	for _, t := range tasks {
+		if t.Process.Type == process && t.State != "STOPPED" {
+			running = append(running, t)
+		}
+	}
+	return running
+}
+
+// isCronInvocation returns true if the instance was started by the schedule of
+// a scheduled process, or by Empire, rather than by `emp run`.
+func isCronInvocation(t *twelvefactor.Task) bool {
+	return t.Process.Labels[cronLabel] != "" || t.Process.Labels[userLabel] == ""
+}
+
+// cronOutput returns where the output of an instance of the process is written
+// to, when it can be known. The awslogs log driver writes to a stream named
+// after the stream prefix, the container (which is named after the process),
+// and the id of the instance.
+func cronOutput(l *Logging, process, taskID string) string {
+	if l == nil || l.Driver != "awslogs" || l.Options["awslogs-stream-prefix"] == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", l.Options["awslogs-group"], l.Options["awslogs-stream-prefix"], process, taskID)
+}
+
+// CronMonitor periodically records the invocations of scheduled processes, with
+// their exit code once they finish, starts manually triggered invocations that
+// were queued behind a running one, and triggers the processes that are
+// scheduled in a time zone.
+type CronMonitor struct {
+	*Empire
+
+	// How often to look for invocations of scheduled processes.
+	Interval time.Duration
+}
+
+// Start starts recording invocations, until the context is canceled. Errors,
+// and panics, are reported to the reporter in the context.
+func (m *CronMonitor) Start(ctx context.Context) {
+	defer reporter.Monitor(ctx)
+
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.RecordCronRuns(ctx); err != nil {
+				reporter.Report(ctx, err)
+			}
+		}
+	}
+}
+
+// RecordCronRuns records the invocations of the scheduled processes of every
+// app, and removes the history that's older than 30 days.
+func (m *CronMonitor) RecordCronRuns(ctx context.Context) error {
+	apps, err := apps(m.db, AppsQuery{})
+	if err != nil {
+		return err
+	}
+
+	var errors []error
+	for _, app := range apps {
+		if err := m.recordAppCronRuns(ctx, app); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if err := cronRunsDestroyBefore(m.db, timex.Now().Add(-cronRunRetention)); err != nil {
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return &multiError{Errors: errors}
+	}
+
+	return nil
+}
+
+// recordAppCronRuns records the invocations of the scheduled processes of a
+// single app.
+func (m *CronMonitor) recordAppCronRuns(ctx context.Context, app *App) error {
+	release, err := releasesFind(m.db, ReleasesQuery{App: app})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	scheduled := make(map[string]Process)
+	for name, p := range release.Formation {
+		if p.Cron != nil {
+			scheduled[name] = p
+		}
+	}
+
+	open, err := cronRuns(m.db, CronRunsQuery{App: app, Open: true})
+	if err != nil {
+		return err
+	}
+
+	if len(scheduled) == 0 && len(open) == 0 {
+		return nil
+	}
+
+	scheduler, err := m.scheduler(app)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := scheduler.Tasks(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+
+	stopped, err := scheduler.StoppedTasks(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+
+	// The runs that haven't finished, by the id of the run, and by the id
+	// of their instance.
+	byID := make(map[string]*CronRun)
+	byTask := make(map[string]*CronRun)
+	for _, r := range open {
+		byID[r.ID] = r
+		if r.TaskID != "" {
+			byTask[r.TaskID] = r
+		}
+	}
+
+	// find returns the run of the instance, recording a new one for
+	// instances that were started by a schedule.
+	find := func(t *twelvefactor.Task) (*CronRun, error) {
+		if id := t.Process.Labels[cronLabel]; id != "" {
+			return byID[id], nil
+		}
+		if r, ok := byTask[t.ID]; ok {
+			return r, nil
+		}
+
+		// The instance might have been recorded, and already finished,
+		// or been marked as lost.
+		id := t.ID
+		if err := first(m.db, CronRunsQuery{App: app, TaskID: &id}, &CronRun{}); err != gorm.RecordNotFound {
+			return nil, err
+		}
+
+		r := &CronRun{
+			AppID:       app.ID,
+			ProcessType: t.Process.Type,
+			Trigger:     CronTriggerSchedule,
+			State:       JobStateRunning,
+		}
+		if t.State != "STOPPED" {
+			startedAt := t.UpdatedAt
+			r.StartedAt = &startedAt
+		}
+		return cronRunsCreate(m.db, r)
+	}
+
+	var errors []error
+	seen := make(map[string]bool)
+	alive := make(map[string]bool)
+	record := func(t *twelvefactor.Task) {
+		if _, ok := scheduled[t.Process.Type]; !ok || !isCronInvocation(t) {
+			return
+		}
+
+		r, err := find(t)
+		if err != nil {
+			errors = append(errors, err)
+			return
+		}
+		if r == nil || r.Finished() {
+			return
+		}
+		seen[r.ID] = true
+
+		// Scheduled invocations can run several instances, but only
+		// the first one is recorded.
+		if r.TaskID != "" && r.TaskID != t.ID {
+			return
+		}
+
+		if r.TaskID == "" {
+			l := scheduled[t.Process.Type].Logging
+			if t.Process.Labels[cronLabel] != "" && m.Outputs != nil {
+				l = m.Outputs.Logging(app, r.ID)
+			}
+			r.TaskID = t.ID
+			r.Output = cronOutput(l, t.Process.Type, t.ID)
+		}
+		if t.State == "STOPPED" {
+			if t.ExitCode == nil {
+				return
+			}
+			r.finish(*t.ExitCode, t.UpdatedAt)
+
+			// Output that was captured under the id of the run is
+			// archived, now that it's complete.
+			if t.Process.Labels[cronLabel] != "" && m.Outputs != nil && m.Blobs != nil {
+				if err := m.archiveOutput(ctx, app, r.ID); err != nil {
+					errors = append(errors, err)
+				}
+			}
+		}
+
+		if err := cronRunsUpdate(m.db, r); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	for _, t := range tasks {
+		if t.State == "STOPPED" {
+			continue
+		}
+		alive[t.Process.Type] = true
+		record(t)
+	}
+	for _, t := range stopped {
+		record(t)
+	}
+
+	// Runs that weren't seen are either queued, or their instance has
+	// disappeared.
+	for i := len(open) - 1; i >= 0; i-- {
+		r := open[i]
+		if seen[r.ID] {
+			continue
+		}
+
+		switch r.State {
+		case JobStateQueued:
+			if alive[r.ProcessType] {
+				continue
+			}
+			if _, ok := scheduled[r.ProcessType]; !ok {
+				t := timex.Now()
+				r.State = JobStateLost
+				r.FinishedAt = &t
+				break
+			}
+			// Oldest first, so that only one queued run starts.
+			alive[r.ProcessType] = true
+			if err := m.startCronRun(ctx, app, release, r); err != nil {
+				errors = append(errors, err)
+			}
+			continue
+		case JobStateRunning:
+			if r.StartedAt == nil || timex.Now().Sub(*r.StartedAt) <= jobLostAfter {
+				continue
+			}
+			t := timex.Now()
+			r.State = JobStateLost
+			r.FinishedAt = &t
+		}
+
+		if err := cronRunsUpdate(m.db, r); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	for name, p := range scheduled {
+		if err := m.triggerScheduled(ctx, app, release, name, p, runningInstances(tasks, name), alive[name]); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return &multiError{Errors: errors}
+	}
+
+	return nil
+}
+
+// triggerScheduled triggers an invocation of a process that's scheduled in a
+// time zone, when one is due, applying its overlap policy to the instances of
+// the process that are still running. When Empire runs on several machines,
+// only one of them triggers each invocation.
+func (m *CronMonitor) triggerScheduled(ctx context.Context, app *App, release *Release, name string, p Process, running []*twelvefactor.Task, alive bool) error {
+	s, err := cronSchedule(app, p)
+	if err != nil || s == nil || p.Quantity <= 0 {
+		return err
+	}
+
+	last, err := cronRunsLastScheduled(m.db, app, name)
+	if err != nil {
+		return err
+	}
+
+	due := dueCronInvocation(s, last, timex.Now())
+	if due.IsZero() {
+		return nil
+	}
+	due = due.UTC()
+
+	run := &CronRun{
+		AppID:       app.ID,
+		ProcessType: name,
+		Trigger:     CronTriggerSchedule,
+		State:       JobStateQueued,
+		ScheduledAt: &due,
+	}
+	if _, err := cronRunsCreate(m.db, run); err != nil {
+		if isUniqueViolation(err) {
+			// Another instance of Empire triggered it.
+			return nil
+		}
+		return err
+	}
+
+	if alive || len(running) > 0 {
+		switch p.Overlap {
+		case OverlapSkip:
+			t := timex.Now()
+			run.State = CronRunStateSkipped
+			run.FinishedAt = &t
+			return cronRunsUpdate(m.db, run)
+		case OverlapQueue:
+			// Started once the running instances have stopped.
+			return nil
+		case OverlapReplace:
+			scheduler, err := m.scheduler(app)
+			if err != nil {
+				return err
+			}
+			for _, t := range running {
+				if err := scheduler.Stop(ctx, t.ID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return m.startCronRun(ctx, app, release, run)
+}
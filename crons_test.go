@@ -0,0 +1,52 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/twelvefactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsCronInvocation(t *testing.T) {
+	tests := []struct {
+		labels map[string]string
+		cron   bool
+	}{
+		{map[string]string{}, true},
+		{map[string]string{userLabel: "ejholmes", cronLabel: "1234"}, true},
+		{map[string]string{userLabel: "ejholmes"}, false},
+	}
+
+	for _, tt := range tests {
+		task := &twelvefactor.Task{Process: &twelvefactor.Process{Type: "vacuum", Labels: tt.labels}}
+		assert.Equal(t, tt.cron, isCronInvocation(task))
+	}
+}
+
+func TestRunningInstances(t *testing.T) {
+	tasks := []*twelvefactor.Task{
+		{ID: "a", State: "RUNNING", Process: &twelvefactor.Process{Type: "vacuum"}},
+		{ID: "b", State: "STOPPED", Process: &twelvefactor.Process{Type: "vacuum"}},
+		{ID: "c", State: "PENDING", Process: &twelvefactor.Process{Type: "vacuum"}},
+		{ID: "d", State: "RUNNING", Process: &twelvefactor.Process{Type: "web"}},
+	}
+
+	running := runningInstances(tasks, "vacuum")
+	assert.Equal(t, []*twelvefactor.Task{tasks[0], tasks[2]}, running)
+}
+
+func TestCronOutput(t *testing.T) {
+	tests := []struct {
+		logging *Logging
+		output  string
+	}{
+		{nil, ""},
+		{&Logging{Driver: "syslog"}, ""},
+		{&Logging{Driver: "awslogs", Options: map[string]string{"awslogs-group": "acme-inc"}}, ""},
+		{&Logging{Driver: "awslogs", Options: map[string]string{"awslogs-group": "acme-inc", "awslogs-stream-prefix": "cron"}}, "acme-inc/cron/vacuum/1234"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.output, cronOutput(tt.logging, "vacuum", "1234"))
+	}
+}
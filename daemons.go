@@ -0,0 +1,165 @@
+package empire
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/twelvefactor"
+	"github.com/remind101/pkg/reporter"
+	"golang.org/x/net/context"
+)
+
+// expandDaemons sets the quantity of the daemon processes in the manifest, and
+// its previous manifest, to the number of machines that the scheduler can
+// place instances on.
+func expandDaemons(ctx context.Context, s twelvefactor.Scheduler, a *twelvefactor.Manifest) error {
+	if !hasDaemons(a) && (a.Previous == nil || !hasDaemons(a.Previous)) {
+		return nil
+	}
+
+	n, err := daemonQuantity(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range []*twelvefactor.Manifest{a, a.Previous} {
+		if m == nil {
+			continue
+		}
+		for _, p := range m.Processes {
+			if p.Daemon {
+				p.Quantity = n
+			}
+		}
+	}
+	return nil
+}
+
+// daemonQuantity returns the number of machines that daemon processes should
+// run on, which excludes machines that the scheduler has lost contact with.
+func daemonQuantity(ctx context.Context, s twelvefactor.Scheduler) (int, error) {
+	machines, err := s.Machines(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for _, m := range machines {
+		if !m.Host.Lost {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func hasDaemons(a *twelvefactor.Manifest) bool {
+	for _, p := range a.Processes {
+		if p.Daemon {
+			return true
+		}
+	}
+	return false
+}
+
+// DaemonScaler periodically checks the number of machines in the cluster of
+// each app with daemon processes, and re-releases the app when it changes, so
+// that daemons are started on machines that join the cluster, and aren't
+// expected on machines that leave it.
+type DaemonScaler struct {
+	*Empire
+
+	// How often to check the number of machines.
+	Interval time.Duration
+
+	// The number of machines that the daemons of each app were last
+	// released with, keyed by app id.
+	quantities map[string]int
+}
+
+// Start starts checking the number of machines, until the context is canceled.
+// Errors, and panics, are reported to the reporter in the context.
+func (s *DaemonScaler) Start(ctx context.Context) {
+	defer reporter.Monitor(ctx)
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ScaleDaemons(ctx); err != nil {
+				reporter.Report(ctx, err)
+			}
+		}
+	}
+}
+
+// ScaleDaemons re-releases each app with daemon processes whose cluster has
+// gained, or lost, machines since it was last checked. Apps are re-released
+// the first time that they're checked, since machines may have changed while
+// nothing was checking.
+func (s *DaemonScaler) ScaleDaemons(ctx context.Context) error {
+	if s.quantities == nil {
+		s.quantities = make(map[string]int)
+	}
+
+	apps, err := apps(s.db, AppsQuery{})
+	if err != nil {
+		return err
+	}
+
+	var errors []error
+	for _, app := range apps {
+		release, err := releasesFind(s.db, ReleasesQuery{App: app})
+		if err == gorm.RecordNotFound {
+			continue
+		}
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		if !formationHasDaemons(release.Formation) {
+			delete(s.quantities, app.ID)
+			continue
+		}
+
+		scheduler, err := s.scheduler(app)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		n, err := daemonQuantity(ctx, scheduler)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		if last, ok := s.quantities[app.ID]; ok && last == n {
+			continue
+		}
+
+		if err := s.releases.Release(ctx, release, nil); err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		s.quantities[app.ID] = n
+	}
+
+	if len(errors) > 0 {
+		return &multiError{Errors: errors}
+	}
+
+	return nil
+}
+
+func formationHasDaemons(f Formation) bool {
+	for _, p := range f {
+		if p.Daemon {
+			return true
+		}
+	}
+	return false
+}
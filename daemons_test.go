@@ -0,0 +1,62 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/twelvefactor"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestExpandDaemons(t *testing.T) {
+	s := &machinesScheduler{
+		FakeScheduler: NewFakeScheduler(),
+		machines: []*twelvefactor.Machine{
+			{Host: twelvefactor.Host{ID: "i-1"}},
+			{Host: twelvefactor.Host{ID: "i-2"}},
+			{Host: twelvefactor.Host{ID: "i-3", Lost: true}},
+		},
+	}
+
+	a := &twelvefactor.Manifest{
+		Processes: []*twelvefactor.Process{
+			{Type: "web", Quantity: 2},
+			{Type: "logs", Daemon: true},
+		},
+		Previous: &twelvefactor.Manifest{
+			Processes: []*twelvefactor.Process{
+				{Type: "logs", Daemon: true},
+			},
+		},
+	}
+	err := expandDaemons(context.Background(), s, a)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, a.Processes[0].Quantity)
+	assert.Equal(t, 2, a.Processes[1].Quantity)
+	assert.Equal(t, 2, a.Previous.Processes[0].Quantity)
+}
+
+func TestExpandDaemons_NoDaemons(t *testing.T) {
+	s := &machinesScheduler{FakeScheduler: NewFakeScheduler()}
+
+	a := &twelvefactor.Manifest{
+		Processes: []*twelvefactor.Process{
+			{Type: "web", Quantity: 2},
+		},
+	}
+	err := expandDaemons(context.Background(), s, a)
+	assert.NoError(t, err)
+	assert.False(t, s.called)
+}
+
+// machinesScheduler is a FakeScheduler that returns the given machines.
+type machinesScheduler struct {
+	*FakeScheduler
+	machines []*twelvefactor.Machine
+	called   bool
+}
+
+func (s *machinesScheduler) Machines(ctx context.Context) ([]*twelvefactor.Machine, error) {
+	s.called = true
+	return s.machines, nil
+}
@@ -6,6 +6,7 @@ import (
 	"net/url"
 
 	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
 	"github.com/remind101/empire/internal/migrate"
 	"github.com/remind101/empire/pkg/headerutil"
 )
@@ -33,6 +34,11 @@ type DB struct {
 
 	*gorm.DB
 
+	// If provided, a read replica of the database that read heavy queries,
+	// which can tolerate replication lag, are sent to, to reduce load on
+	// the primary.
+	Replica *gorm.DB
+
 	uri string
 
 	migrator *migrate.Migrator
@@ -74,6 +80,32 @@ func NewDB(conn *sql.DB) (*DB, error) {
 	}, nil
 }
 
+// OpenReplica opens a connection to a read replica of the database, and uses it
+// as the Replica.
+func (db *DB) OpenReplica(uri string) error {
+	if _, err := url.Parse(uri); err != nil {
+		return err
+	}
+
+	replica, err := gorm.Open(DBDriver, uri)
+	if err != nil {
+		return err
+	}
+
+	db.Replica = &replica
+	return nil
+}
+
+// Reader returns the connection that read only queries, which can tolerate
+// replication lag, should use. It's the Replica, if there is one, and
+// otherwise the primary.
+func (db *DB) Reader() *gorm.DB {
+	if db.Replica != nil {
+		return db.Replica
+	}
+	return db.DB
+}
+
 // MigrateUp migrates the database to the latest version of the schema.
 func (db *DB) MigrateUp() error {
 	return db.migrator.Exec(migrate.Up, db.migrations()...)
@@ -95,17 +127,40 @@ func (db *DB) Reset() error {
 	exec(`TRUNCATE TABLE apps CASCADE`)
 	exec(`TRUNCATE TABLE ports CASCADE`)
 	exec(`TRUNCATE TABLE slugs CASCADE`)
+	exec(`TRUNCATE TABLE registry_credentials CASCADE`)
+	exec(`TRUNCATE TABLE freeze_windows CASCADE`)
+	exec(`TRUNCATE TABLE grants CASCADE`)
+	exec(`TRUNCATE TABLE team_members CASCADE`)
+	exec(`TRUNCATE TABLE api_tokens CASCADE`)
+	exec(`TRUNCATE TABLE two_factor_secrets CASCADE`)
+	exec(`TRUNCATE TABLE idempotency_keys CASCADE`)
 	exec(`UPDATE ports SET app_id = NULL`)
 
 	return err
 }
 
+// Close closes the connections to the database, and to the read replica.
+func (db *DB) Close() error {
+	if db.Replica != nil {
+		if err := db.Replica.Close(); err != nil {
+			return err
+		}
+	}
+	return db.DB.Close()
+}
+
 // IsHealthy checks that we can connect to the database.
 func (db *DB) IsHealthy() error {
 	if err := db.DB.DB().Ping(); err != nil {
 		return err
 	}
 
+	if db.Replica != nil {
+		if err := db.Replica.DB().Ping(); err != nil {
+			return fmt.Errorf("error connecting to replica: %v", err)
+		}
+	}
+
 	if err := db.CheckSchemaVersion(); err != nil {
 		return err
 	}
@@ -246,6 +301,15 @@ func inRange(r headerutil.Range) scope {
 	return scope
 }
 
+// isUniqueViolation returns true if the error is from inserting a row that
+// violates a unique index.
+func isUniqueViolation(err error) bool {
+	if err, ok := err.(*pq.Error); ok {
+		return err.Code.Name() == "unique_violation"
+	}
+	return false
+}
+
 // first is a small helper that finds the first record matching a scope, and
 // returns the error.
 func first(db *gorm.DB, scope scope, v interface{}) error {
@@ -82,3 +82,17 @@ func conditionSql(scope scope) (sql string, vars []interface{}) {
 	vars = ds.SqlVars
 	return
 }
+
+func TestDB_Reader(t *testing.T) {
+	primary, replica := &gorm.DB{}, &gorm.DB{}
+
+	db := &DB{DB: primary}
+	if got := db.Reader(); got != primary {
+		t.Fatal("expected the primary to be used without a replica")
+	}
+
+	db.Replica = replica
+	if got := db.Reader(); got != replica {
+		t.Fatal("expected the replica to be used")
+	}
+}
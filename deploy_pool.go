@@ -0,0 +1,108 @@
+package empire
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// deployPool schedules the releases of deploys. Each app has a single worker
+// slot, so the releases of an app are submitted to the scheduler one at a
+// time, and in order, while different apps are released concurrently. A
+// global limit bounds how many apps are released at once, so that a wave of
+// deploys doesn't overwhelm the scheduler. Since an app only ever holds one of
+// the global slots, an app with a large formation, or many queued deploys,
+// can't starve the others.
+type deployPool struct {
+	// Global slots, or nil when the number of concurrent releases isn't
+	// limited.
+	slots chan struct{}
+
+	mu   sync.Mutex
+	apps map[string]*appDeployQueue
+}
+
+// appDeployQueue holds the worker slot of an app, and how many deploys are
+// holding, or waiting for, it.
+type appDeployQueue struct {
+	slot    chan struct{}
+	waiters int
+}
+
+// newDeployPool returns a deployPool that allows up to max apps to be released
+// at once. A max of 0 doesn't limit concurrency.
+func newDeployPool(max int) *deployPool {
+	p := &deployPool{apps: make(map[string]*appDeployQueue)}
+	if max > 0 {
+		p.slots = make(chan struct{}, max)
+	}
+	return p
+}
+
+// Do calls fn once the app has its worker slot, and there's a global slot
+// available. If the context is done while waiting, fn isn't called and the
+// error from the context is returned. waiting is called before blocking, if
+// the deploy has to wait.
+func (p *deployPool) Do(ctx context.Context, appID string, waiting func(), fn func() error) error {
+	q := p.acquire(appID)
+	defer p.release(appID, q)
+
+	select {
+	case q.slot <- struct{}{}:
+	default:
+		if waiting != nil {
+			waiting()
+			waiting = nil
+		}
+		select {
+		case q.slot <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	defer func() { <-q.slot }()
+
+	if p.slots != nil {
+		select {
+		case p.slots <- struct{}{}:
+		default:
+			if waiting != nil {
+				waiting()
+			}
+			select {
+			case p.slots <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		defer func() { <-p.slots }()
+	}
+
+	return fn()
+}
+
+// acquire returns the queue for the app, creating it if needed.
+func (p *deployPool) acquire(appID string) *appDeployQueue {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	q, ok := p.apps[appID]
+	if !ok {
+		q = &appDeployQueue{slot: make(chan struct{}, 1)}
+		p.apps[appID] = q
+	}
+	q.waiters++
+	return q
+}
+
+// release removes the queue for the app once nothing is waiting on it, so that
+// apps that aren't being deployed don't hold on to a worker.
+func (p *deployPool) release(appID string, q *appDeployQueue) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	q.waiters--
+	if q.waiters == 0 {
+		delete(p.apps, appID)
+	}
+}
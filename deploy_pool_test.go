@@ -0,0 +1,97 @@
+package empire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestDeployPool_SameApp(t *testing.T) {
+	p := newDeployPool(0)
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	go p.Do(context.Background(), "app", nil, func() error {
+		close(started)
+		<-finish
+		return nil
+	})
+	<-started
+
+	waited := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- p.Do(context.Background(), "app", func() { close(waited) }, func() error {
+			return nil
+		})
+	}()
+
+	<-waited
+	select {
+	case <-done:
+		t.Fatal("expected the second deploy of the app to wait for the first")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(finish)
+	assert.NoError(t, <-done)
+}
+
+func TestDeployPool_DifferentApps(t *testing.T) {
+	p := newDeployPool(0)
+
+	finish := make(chan struct{})
+	defer close(finish)
+	started := make(chan struct{})
+	go p.Do(context.Background(), "a", nil, func() error {
+		close(started)
+		<-finish
+		return nil
+	})
+	<-started
+
+	err := p.Do(context.Background(), "b", func() {
+		t.Fatal("expected a different app not to wait")
+	}, func() error {
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestDeployPool_Concurrency(t *testing.T) {
+	p := newDeployPool(1)
+
+	finish := make(chan struct{})
+	defer close(finish)
+	started := make(chan struct{})
+	go p.Do(context.Background(), "a", nil, func() error {
+		close(started)
+		<-finish
+		return nil
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var waited, called bool
+	err := p.Do(ctx, "b", func() { waited = true }, func() error {
+		called = true
+		return nil
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, waited)
+	assert.False(t, called)
+}
+
+func TestDeployPool_RemovesIdleApps(t *testing.T) {
+	p := newDeployPool(0)
+
+	err := p.Do(context.Background(), "app", nil, func() error {
+		assert.Equal(t, 1, len(p.apps))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(p.apps))
+}
@@ -0,0 +1,136 @@
+package empire
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/remind101/empire/twelvefactor"
+	"golang.org/x/net/context"
+)
+
+// Simulation is what a deploy would have released, had it not been simulated.
+type Simulation struct {
+	// The release that would have been created. It isn't saved, so it
+	// doesn't have an id.
+	Release *Release
+
+	// What would have changed from the current release of the app.
+	Changes *ReleaseChanges
+
+	// The instances that the scheduler would have run.
+	Tasks []*twelvefactor.Task
+}
+
+// simulate runs the deploy without releasing anything. The image is pulled,
+// and the release is created and admitted, in a transaction that's always
+// rolled back. The release is then submitted to a FakeScheduler, so that
+// what would have been run is known without touching the real scheduler.
+func (s *deployerService) simulate(ctx context.Context, opts DeployOpts) (*Simulation, error) {
+	tx := s.db.Begin()
+	defer tx.Rollback()
+
+	r, err := s.createRelease(ctx, tx, nil, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	prev, err := previousRelease(tx, r)
+	if err != nil && err != ErrNoPreviousRelease {
+		return nil, err
+	}
+
+	// The real scheduler is only asked about the capacity in the cluster,
+	// so that the manifest is the same as it would have been.
+	scheduler, err := s.scheduler(r.App)
+	if err != nil {
+		return nil, err
+	}
+	a, err := s.releases.manifest(ctx, scheduler, r)
+	if err != nil {
+		return nil, err
+	}
+
+	fake := NewFakeScheduler()
+	if err := fake.Submit(ctx, a, nil); err != nil {
+		return nil, err
+	}
+	tasks, err := fake.Tasks(ctx, a.AppID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Simulation{
+		Release: r,
+		Changes: diffReleases(prev, r),
+		Tasks:   tasks,
+	}, nil
+}
+
+// simulateDeploy simulates the deploy, and writes what would have been
+// released to the output.
+func (s *deployerService) simulateDeploy(ctx context.Context, opts DeployOpts) (*Release, error) {
+	w := opts.Output
+
+	sim, err := s.simulate(ctx, opts)
+	if err != nil {
+		return nil, w.Error(err)
+	}
+
+	r := sim.Release
+	if err := w.Status(fmt.Sprintf("Simulated release v%d for %s", r.Version, r.App.Name)); err != nil {
+		return r, err
+	}
+	for _, line := range sim.lines() {
+		if err := w.Status(line); err != nil {
+			return r, err
+		}
+	}
+	return r, w.Status(fmt.Sprintf("Nothing was released to %s", r.App.Name))
+}
+
+// lines describes the changes, and the instances that would have been run,
+// one per line.
+func (sim *Simulation) lines() []string {
+	var lines []string
+
+	c := sim.Changes
+	if c.Image != nil {
+		if c.Image.From == "" {
+			lines = append(lines, fmt.Sprintf("image: %s", c.Image.To))
+		} else {
+			lines = append(lines, fmt.Sprintf("image: %s => %s", c.Image.From, c.Image.To))
+		}
+	}
+	for _, name := range c.VarsAdded {
+		lines = append(lines, "+ "+name)
+	}
+	for _, name := range c.VarsChanged {
+		lines = append(lines, "~ "+name)
+	}
+	for _, name := range c.VarsRemoved {
+		lines = append(lines, "- "+name)
+	}
+	for _, p := range c.Formation {
+		lines = append(lines, fmt.Sprintf("%s: %s => %s", p.Process, p.Previous, p.Current))
+	}
+
+	quantities := make(map[string]int)
+	processes := make(map[string]*twelvefactor.Process)
+	var names []string
+	for _, t := range sim.Tasks {
+		name := t.Process.Type
+		if _, ok := processes[name]; !ok {
+			names = append(names, name)
+			processes[name] = t.Process
+		}
+		quantities[name]++
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := processes[name]
+		lines = append(lines, fmt.Sprintf("%s: would run %d instance(s) of %s", name, quantities[name], strings.Join(p.Command, " ")))
+	}
+
+	return lines
+}
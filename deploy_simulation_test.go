@@ -0,0 +1,39 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/twelvefactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulation_Lines(t *testing.T) {
+	web := &twelvefactor.Process{Type: "web", Command: []string{"./bin/web"}}
+	worker := &twelvefactor.Process{Type: "worker", Command: []string{"./bin/worker", "-q", "default"}}
+
+	sim := &Simulation{
+		Changes: &ReleaseChanges{
+			Image:     &ImageChange{From: "remind101/acme-inc:v1", To: "remind101/acme-inc:v2"},
+			VarsAdded: []string{"REDIS_URL"},
+			Formation: []*ProcessChange{
+				{Process: "worker", Current: &ProcessScale{Quantity: 1, Constraints: Constraints1X}},
+			},
+		},
+		Tasks: []*twelvefactor.Task{
+			{ID: "1", Process: worker},
+			{ID: "1", Process: web},
+			{ID: "2", Process: web},
+		},
+	}
+
+	assert.Equal(t, []string{
+		"image: remind101/acme-inc:v1 => remind101/acme-inc:v2",
+		"+ REDIS_URL",
+		"worker: none => 1:1X",
+		"web: would run 2 instance(s) of ./bin/web",
+		"worker: would run 1 instance(s) of ./bin/worker -q default",
+	}, sim.lines())
+
+	// Nothing changed.
+	assert.Nil(t, (&Simulation{Changes: &ReleaseChanges{}}).lines())
+}
@@ -0,0 +1,173 @@
+package empire
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/remind101/empire/twelvefactor"
+	"golang.org/x/net/context"
+)
+
+// MaxDeployTimeout is the longest deploy timeout that can be set on an app.
+const MaxDeployTimeout = 2 * time.Hour
+
+// ErrDeployTimeout is returned when the deploy timeout of an app isn't valid.
+var ErrDeployTimeout = &ValidationError{
+	errors.New("The deploy timeout must be between 0 seconds and 2 hours."),
+}
+
+// DeployTimeoutError is returned when the processes of a release didn't become
+// healthy within the deploy timeout of the app, and the deploy was aborted.
+type DeployTimeoutError struct {
+	// The release that was aborted.
+	Release *Release
+
+	// The timeout that was exceeded.
+	Timeout time.Duration
+
+	// The release that was created to restore the previous release, or nil
+	// if the aborted release was the first release of the app.
+	Restored *Release
+}
+
+func (e *DeployTimeoutError) Error() string {
+	msg := fmt.Sprintf("v%d of %s didn't become healthy within %v", e.Release.Version, e.Release.App.Name, e.Timeout)
+	if e.Restored != nil {
+		return fmt.Sprintf("%s, restored v%d as v%d", msg, e.Release.Version-1, e.Restored.Version)
+	}
+	return fmt.Sprintf("%s, removed its processes", msg)
+}
+
+// SetDeployTimeoutOpts are options provided when changing the deploy timeout of
+// an app.
+type SetDeployTimeoutOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// The new timeout. 0 disables the timeout.
+	Timeout time.Duration
+}
+
+// SetDeployTimeout changes how long deploys of the app wait for the new
+// processes to become healthy before they're aborted.
+func (e *Empire) SetDeployTimeout(ctx context.Context, opts SetDeployTimeoutOpts) error {
+	if err := e.authorize(opts.User, opts.App, ActionAdmin); err != nil {
+		return err
+	}
+
+	if opts.Timeout < 0 || opts.Timeout > MaxDeployTimeout {
+		return ErrDeployTimeout
+	}
+
+	opts.App.DeployTimeout = opts.Timeout
+	return appsUpdate(e.db, opts.App)
+}
+
+// releaseWithTimeout submits the release to the scheduler, and waits for its
+// processes to become healthy, for up to the deploy timeout of the app. If
+// they don't, the deploy is aborted: the previous release is restored as a new
+// release, so that it remains the current release, and a DeployFailedEvent is
+// published. A ReconcileFailedEvent is published if the deploy can't be
+// aborted.
+func (s *deployerService) releaseWithTimeout(ctx context.Context, r *Release, ss twelvefactor.StatusStream, opts DeployOpts) error {
+	timeout := r.App.DeployTimeout
+	if timeout == 0 {
+		return s.releases.Release(ctx, r, s.recordTaskHealth(ctx, r, ss))
+	}
+
+	// Schedulers only wait for the deployment to complete when there's
+	// somewhere to publish its status to.
+	if ss == nil {
+		ss = twelvefactor.NullStatusStream
+	}
+	ss = s.recordTaskHealth(ctx, r, ss)
+
+	tctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := s.releases.Release(tctx, r, ss)
+	if tctx.Err() != context.DeadlineExceeded {
+		return err
+	}
+
+	restored, err := s.abort(ctx, r)
+	if err != nil {
+		err = fmt.Errorf("aborting v%d of %s after %v: %v", r.Version, r.App.Name, timeout, err)
+		if perr := s.publishEvent(ctx, ReconcileFailedEvent{
+			App:    r.App.Name,
+			Reason: err.Error(),
+			app:    r.App,
+		}); perr != nil {
+			return perr
+		}
+		return err
+	}
+
+	timeoutErr := &DeployTimeoutError{
+		Release:  r,
+		Timeout:  timeout,
+		Restored: restored,
+	}
+
+	event := opts.Event()
+	failed := DeployFailedEvent{
+		User:    event.User,
+		App:     r.App.Name,
+		Image:   event.Image,
+		Release: r.Version,
+		Reason:  timeoutErr.Error(),
+		app:     r.App,
+	}
+	if err := s.publishEvent(ctx, failed); err != nil {
+		return err
+	}
+
+	return timeoutErr
+}
+
+// abort marks a release that failed to deploy as failed, and unschedules its
+// processes. If there's a previous release, it's restored by creating a new
+// release from it, and submitting it to the scheduler. Otherwise, the app is
+// removed from the scheduler.
+func (s *deployerService) abort(ctx context.Context, r *Release) (*Release, error) {
+	r.Description = fmt.Sprintf("%s (failed)", r.Description)
+	if err := releasesUpdateDescription(s.db, r); err != nil {
+		return nil, err
+	}
+
+	previous, err := previousRelease(s.db, r)
+	if err == ErrNoPreviousRelease {
+		scheduler, err := s.scheduler(r.App)
+		if err != nil {
+			return nil, err
+		}
+		return nil, scheduler.Remove(ctx, r.App.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tx := s.db.Begin()
+	restored, err := s.releases.Create(ctx, tx, &Release{
+		App:         r.App,
+		Config:      previous.Config,
+		Slug:        previous.Slug,
+		Formation:   previous.Formation,
+		Description: fmt.Sprintf("Rollback to v%d (v%d didn't become healthy)", previous.Version, r.Version),
+		CreatedBy:   r.CreatedBy,
+		Source:      ReleaseSourceRollback,
+	})
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	return restored, s.releases.Release(ctx, restored, nil)
+}
@@ -3,6 +3,7 @@ package empire
 import (
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/jinzhu/gorm"
 	"github.com/remind101/empire/pkg/jsonmessage"
@@ -14,6 +15,18 @@ import (
 // the core business logic to deploy.
 type deployerService struct {
 	*Empire
+
+	poolOnce sync.Once
+	pool     *deployPool
+}
+
+// deployPool returns the pool that releases are scheduled with, which is
+// created on first use, since MaxConcurrentDeploys is set after New.
+func (s *deployerService) deployPool() *deployPool {
+	s.poolOnce.Do(func() {
+		s.pool = newDeployPool(s.MaxConcurrentDeploys)
+	})
+	return s.pool
 }
 
 // createRelease creates a new release that can be deployed
@@ -23,8 +36,26 @@ func (s *deployerService) createRelease(ctx context.Context, db *gorm.DB, ss twe
 	// If no app is specified, attempt to find the app that relates to this
 	// images repository, or create it if not found.
 	if app == nil {
+		// Only the team that owns a namespace can create apps in it.
+		name := appNameFromRepo(img.Repository)
+		if _, err := appsFind(db, AppsQuery{Name: &name}); err == gorm.RecordNotFound {
+			namespace, err := appNamespace(db, name)
+			if err != nil {
+				return nil, err
+			}
+			if namespace != nil {
+				if err := s.authorizeTeam(opts.User, namespace.Team); err != nil {
+					return nil, err
+				}
+			}
+		} else if err != nil {
+			return nil, err
+		}
+
 		var err error
+		_, span := startSpan(ctx, "db.apps.find_or_create", &App{Name: name})
 		app, err = appsFindOrCreateByRepo(db, img.Repository)
+		span.Finish(err)
 		if err != nil {
 			return nil, err
 		}
@@ -36,14 +67,33 @@ func (s *deployerService) createRelease(ctx context.Context, db *gorm.DB, ss twe
 		}
 	}
 
+	// The app isn't known until now when deploying by repo, so the role is
+	// checked here instead of in DeployOpts.Validate.
+	if err := s.authorize(opts.User, app, ActionDeploy); err != nil {
+		return nil, err
+	}
+
 	// Grab the latest config.
+	_, span := startSpan(ctx, "db.configs.find", app)
 	config, err := s.configs.Config(db, app)
+	span.Finish(err)
 	if err != nil {
 		return nil, err
 	}
 
+	if ss != nil {
+		if err := ss.Publish(twelvefactor.Status{
+			Message: fmt.Sprintf("Pulling image %s", img),
+			Phase:   twelvefactor.PhaseImagePull,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create a new slug for the docker image.
-	slug, err := s.slugs.Create(ctx, db, img, opts.Output)
+	sctx, span := startSpan(ctx, "deploy.slug", app)
+	slug, err := s.slugs.Create(sctx, db, img, opts.Provenance, opts.Output)
+	span.Finish(err)
 	if err != nil {
 		return nil, err
 	}
@@ -58,8 +108,21 @@ func (s *deployerService) createRelease(ctx context.Context, db *gorm.DB, ss twe
 		Config:      config,
 		Slug:        slug,
 		Description: desc,
+		CreatedBy:   opts.User.Name,
+		Source:      ReleaseSourceDeploy,
+		Message:     opts.Message,
+	})
+	if err != nil {
+		return r, err
+	}
+
+	return r, s.admit(ctx, &AdmissionRequest{
+		Operation:      AdmissionDeploy,
+		User:           opts.User,
+		Release:        r,
+		FreezeOverride: opts.FreezeOverride,
+		Simulate:       opts.Simulate,
 	})
-	return r, err
 }
 
 func (s *deployerService) createInTransaction(ctx context.Context, stream twelvefactor.StatusStream, opts DeployOpts) (*Release, error) {
@@ -75,6 +138,10 @@ func (s *deployerService) createInTransaction(ctx context.Context, stream twelve
 // Deploy is a thin wrapper around deploy to that adds the error to the
 // jsonmessage stream.
 func (s *deployerService) Deploy(ctx context.Context, opts DeployOpts) (*Release, error) {
+	if opts.Simulate {
+		return s.simulateDeploy(ctx, opts)
+	}
+
 	w := opts.Output
 
 	var stream twelvefactor.StatusStream
@@ -82,7 +149,23 @@ func (s *deployerService) Deploy(ctx context.Context, opts DeployOpts) (*Release
 		stream = w
 	}
 
-	r, err := s.createInTransaction(ctx, stream, opts)
+	ctx, span := startSpan(ctx, "deploy", opts.App)
+	r, err := s.deploy(ctx, w, stream, opts)
+	if opts.App == nil && r != nil && r.App != nil {
+		span.Tags = appTags(r.App)
+	}
+	span.Finish(err)
+	return r, err
+}
+
+// deploy creates the release, and submits it to the scheduler.
+func (s *deployerService) deploy(ctx context.Context, w *DeploymentStream, stream twelvefactor.StatusStream, opts DeployOpts) (*Release, error) {
+	cctx, span := startSpan(ctx, "deploy.create", opts.App)
+	r, err := s.createInTransaction(cctx, stream, opts)
+	if opts.App == nil && r != nil && r.App != nil {
+		span.Tags = appTags(r.App)
+	}
+	span.Finish(err)
 	if err != nil {
 		return r, w.Error(err)
 	}
@@ -91,7 +174,12 @@ func (s *deployerService) Deploy(ctx context.Context, opts DeployOpts) (*Release
 		return r, err
 	}
 
-	if err := s.releases.Release(ctx, r, stream); err != nil {
+	waiting := func() {
+		w.Status(fmt.Sprintf("Waiting for other deploys to finish before releasing v%d of %s", r.Version, r.App.Name))
+	}
+	if err := s.deployPool().Do(ctx, r.App.ID, waiting, func() error {
+		return s.releaseWithTimeout(ctx, r, stream, opts)
+	}); err != nil {
 		return r, w.Error(err)
 	}
 
@@ -109,9 +197,16 @@ func NewDeploymentStream(w io.Writer) *DeploymentStream {
 	return &DeploymentStream{jsonmessage.NewStream(w)}
 }
 
-// Publish implements the scheduler.StatusStream interface.
+// Publish implements the scheduler.StatusStream interface. The phase and
+// process are included in the jsonmessage, so that clients can track the
+// progress of the deployment.
 func (w *DeploymentStream) Publish(status twelvefactor.Status) error {
-	return w.Status(status.Message)
+	m := jsonmessage.JSONMessage{
+		ID:     status.Process,
+		Status: fmt.Sprintf("Status: %s", status.Message),
+		Phase:  status.Phase,
+	}
+	return w.Encode(m)
 }
 
 // Status writes a simple status update to the jsonmessage stream.
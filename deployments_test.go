@@ -0,0 +1,24 @@
+package empire
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/remind101/empire/twelvefactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeploymentStream_Publish(t *testing.T) {
+	b := new(bytes.Buffer)
+	w := NewDeploymentStream(b)
+
+	err := w.Publish(twelvefactor.Status{Message: "Stack update submitted", Phase: twelvefactor.PhaseScheduled})
+	assert.NoError(t, err)
+
+	err = w.Publish(twelvefactor.Status{Message: "Service web became stable", Phase: twelvefactor.PhaseHealthPassed, Process: "web"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, `{"status":"Status: Stack update submitted","phase":"scheduled"}
+{"id":"web","status":"Status: Service web became stable","phase":"health_passed"}
+`, b.String())
+}
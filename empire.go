@@ -1,13 +1,19 @@
 package empire // import "github.com/remind101/empire"
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/constraints"
 	"github.com/remind101/empire/pkg/image"
+	"github.com/remind101/empire/pkg/totp"
+	"github.com/remind101/empire/twelvefactor"
 	"golang.org/x/net/context"
 )
 
@@ -23,6 +29,15 @@ var (
 	ErrDomainNotFound     = errors.New("Domain could not be found.")
 	ErrUserName           = errors.New("Name is required")
 	ErrNoReleases         = errors.New("no releases")
+	ErrInvalidPort        = &ValidationError{errors.New("Port must be between 1 and 65535.")}
+	ErrCopyPath           = &ValidationError{errors.New("A path is required.")}
+	ErrHostRequired       = &ValidationError{errors.New("A host is required.")}
+	ErrRunTimeout         = &ValidationError{errors.New("Timeout can't be negative.")}
+	ErrOutputAttached     = &ValidationError{errors.New("Output can only be captured for detached processes.")}
+	ErrOutputDisabled     = &ValidationError{errors.New("Capturing output isn't enabled.")}
+	ErrBlobsDisabled      = &ValidationError{errors.New("A blob store isn't configured.")}
+
+	ErrIdempotencyKeyAttached = &ValidationError{errors.New("Idempotency keys can only be used with detached processes.")}
 	// ErrInvalidName is used to indicate that the app name is not valid.
 	ErrInvalidName = &ValidationError{
 		errors.New("An app name must be alphanumeric and dashes only, 3-30 chars in length."),
@@ -73,25 +88,55 @@ type Empire struct {
 	DB *DB
 	db *gorm.DB
 
-	apps     *appsService
-	configs  *configsService
-	domains  *domainsService
-	tasks    *tasksService
-	releases *releasesService
-	deployer *deployerService
-	runner   *runnerService
-	slugs    *slugsService
-	certs    *certsService
+	// The reader half of the database, which the listings of the API
+	// use. This is a read replica, when one is configured. Reads that
+	// follow a write, or that need to be consistent with one, and every
+	// write, use db, the writer half, instead.
+	dbr *gorm.DB
+
+	apps       *appsService
+	configs    *configsService
+	domains    *domainsService
+	tasks      *tasksService
+	releases   *releasesService
+	reschedule *rescheduleService
+	deployer   *deployerService
+	runner     *runnerService
+	slugs      *slugsService
+	certs      *certsService
+
+	// The operations in progress that change what's scheduled.
+	ops operations
+
+	// The operator configuration that's in effect.
+	operator operatorConfig
 
 	// Scheduler is the backend scheduler used to run applications.
 	Scheduler Scheduler
 
+	// Clusters are additional schedulers, keyed by cluster name, that apps
+	// can be assigned to when they're created. Apps that aren't assigned to
+	// a cluster are scheduled with Scheduler.
+	Clusters map[string]Scheduler
+
+	// Waiters are waited for when Empire shuts down, so that work that
+	// finishes in the background (e.g. stack updates) isn't dropped.
+	Waiters []Waiter
+
+	// If provided, loads the operator configuration when it's reloaded.
+	LoadOperatorConfig func() (*OperatorConfig, error)
+
 	// LogsStreamer is the backend used to stream application logs.
 	LogsStreamer LogsStreamer
 
 	// ImageRegistry is used to interract with container images.
 	ImageRegistry ImageRegistry
 
+	// If provided, the image of every release is verified before it's
+	// submitted to the scheduler, so that unsigned, or tampered, images
+	// can't be run.
+	ImageVerifier ImageVerifier
+
 	// Environment represents the environment this Empire server is responsible for
 	Environment string
 
@@ -101,12 +146,63 @@ type Empire struct {
 	// RunRecorder is used to record the logs from interactive runs.
 	RunRecorder RunRecorder
 
+	// Outputs is used to capture the output of detached runs. If nil,
+	// output can't be captured.
+	Outputs OutputStore
+
+	// Blobs stores deploy logs, and the output of one-off processes once
+	// they've finished. If nil, they aren't stored.
+	Blobs BlobStore
+
 	// MessagesRequired is a boolean used to determine if messages should be required for events.
 	MessagesRequired bool
 
 	// Configures what type of commands are allowed to be run with the Run
 	// method. The zero value allows all commands to be run.
 	AllowedCommands AllowedCommands
+
+	// AdmissionController, if provided, is evaluated before new releases and
+	// scale changes are submitted to the scheduler.
+	AdmissionController AdmissionController
+
+	// Hooks are called, in order, around the operations that change apps.
+	// Any of them can reject an operation.
+	Hooks []Hook
+
+	// When true, users must be granted a role (viewer, deployer or admin)
+	// on an app before they can read or change it. The zero value allows
+	// any authenticated user to do anything.
+	RBAC bool
+
+	// Users that have the admin role on all apps, regardless of grants.
+	// This is used to bootstrap RBAC.
+	Admins []string
+
+	// SecurityPolicy restricts the security settings that processes can
+	// relax, and who can relax them.
+	SecurityPolicy SecurityPolicy
+
+	// If provided, processes can join the service mesh, which injects an
+	// Envoy sidecar into them.
+	Mesh *Mesh
+
+	// If provided, issues an identity certificate to each app when it's
+	// released.
+	Identity *IdentityIssuer
+
+	// If provided, processes can query their own metadata.
+	Metadata *MetadataService
+
+	// The maximum number of apps that can be released to the scheduler at
+	// once by deploys. Deploys of the same app are always released one at
+	// a time. The zero value doesn't limit concurrency.
+	MaxConcurrentDeploys int
+
+	// The number of instances that a process can have in an availability
+	// zone above its share, which is weighted by the capacity of the
+	// zone, before the Rescheduler moves them to other zones. The zero
+	// value doesn't rebalance zones.
+	MaxZoneSkew int
 }
 
 // New returns a new Empire instance.
@@ -115,8 +211,9 @@ func New(db *DB) *Empire {
 		LogsStreamer: logsDisabled,
 		EventStream:  NullEventStream,
 
-		DB: db,
-		db: db.DB,
+		DB:  db,
+		db:  db.DB,
+		dbr: db.Reader(),
 	}
 
 	e.apps = &appsService{Empire: e}
@@ -125,6 +222,7 @@ func New(db *DB) *Empire {
 	e.domains = &domainsService{Empire: e}
 	e.slugs = &slugsService{Empire: e}
 	e.tasks = &tasksService{Empire: e}
+	e.reschedule = &rescheduleService{Empire: e}
 	e.runner = &runnerService{Empire: e}
 	e.releases = &releasesService{Empire: e}
 	e.certs = &certsService{Empire: e}
@@ -138,7 +236,7 @@ func (e *Empire) AppsFind(q AppsQuery) (*App, error) {
 
 // Apps returns all Apps.
 func (e *Empire) Apps(q AppsQuery) ([]*App, error) {
-	return apps(e.db, q)
+	return apps(e.dbr, q)
 }
 
 func (e *Empire) requireMessages(m string) error {
@@ -156,6 +254,12 @@ type CreateOpts struct {
 	// Name of the application.
 	Name string
 
+	// If provided, the team that owns the application.
+	Team string
+
+	// If provided, the cluster that the application will be scheduled to.
+	Cluster string
+
 	// Commit message
 	Message string
 }
@@ -169,6 +273,30 @@ func (opts CreateOpts) Event() CreateEvent {
 }
 
 func (opts CreateOpts) Validate(e *Empire) error {
+	// Apps in a namespace can be created by users with a grant on the
+	// namespace, who are members of the team that owns it.
+	var app *App
+	namespace, err := appNamespace(e.db, opts.Name)
+	if err != nil {
+		return err
+	}
+	if namespace != nil {
+		app = &App{Name: opts.Name, Namespace: namespace.Name}
+	}
+	if err := e.authorize(opts.User, app, ActionCreate); err != nil {
+		return err
+	}
+	if namespace != nil {
+		if err := e.authorizeTeam(opts.User, namespace.Team); err != nil {
+			return err
+		}
+	}
+	if err := e.authorizeTeam(opts.User, opts.Team); err != nil {
+		return err
+	}
+	if _, err := e.scheduler(&App{Cluster: opts.Cluster}); err != nil {
+		return &ValidationError{Err: err}
+	}
 	return e.requireMessages(opts.Message)
 }
 
@@ -178,12 +306,12 @@ func (e *Empire) Create(ctx context.Context, opts CreateOpts) (*App, error) {
 		return nil, err
 	}
 
-	a, err := appsCreate(e.db, &App{Name: opts.Name})
+	a, err := appsCreate(e.db, &App{Name: opts.Name, Team: opts.Team, Cluster: opts.Cluster})
 	if err != nil {
 		return a, err
 	}
 
-	return a, e.PublishEvent(opts.Event())
+	return a, e.publishEvent(ctx, opts.Event())
 }
 
 // DestroyOpts are options provided when destroying an application.
@@ -196,6 +324,9 @@ type DestroyOpts struct {
 
 	// Commit message
 	Message string
+
+	// Required when the app is protected.
+	TwoFactor *TwoFactorCode
 }
 
 func (opts DestroyOpts) Event() DestroyEvent {
@@ -207,7 +338,13 @@ func (opts DestroyOpts) Event() DestroyEvent {
 }
 
 func (opts DestroyOpts) Validate(e *Empire) error {
-	return e.requireMessages(opts.Message)
+	if err := e.authorize(opts.User, opts.App, ActionAdmin); err != nil {
+		return err
+	}
+	if err := e.requireMessages(opts.Message); err != nil {
+		return err
+	}
+	return e.requireTwoFactor(opts.User, opts.App, opts.TwoFactor)
 }
 
 // Destroy destroys an app.
@@ -216,18 +353,25 @@ func (e *Empire) Destroy(ctx context.Context, opts DestroyOpts) error {
 		return err
 	}
 
-	tx := e.db.Begin()
+	if err := e.hook(ctx, &HookRequest{
+		Operation: OperationDestroy,
+		User:      opts.User,
+		App:       opts.App,
+		Opts:      opts,
+	}, func() error {
+		tx := e.db.Begin()
 
-	if err := e.apps.Destroy(ctx, tx, opts.App); err != nil {
-		tx.Rollback()
-		return err
-	}
+		if err := e.apps.Destroy(ctx, tx, opts.App); err != nil {
+			tx.Rollback()
+			return err
+		}
 
-	if err := tx.Commit().Error; err != nil {
+		return tx.Commit().Error
+	}); err != nil {
 		return err
 	}
 
-	return e.PublishEvent(opts.Event())
+	return e.publishEvent(ctx, opts.Event())
 }
 
 // Config returns the current Config for a given app.
@@ -259,6 +403,10 @@ type SetMaintenanceModeOpts struct {
 
 	// Commit message
 	Message string
+
+	// Required to enable maintenance mode when the app is protected, since
+	// it scales every process to zero.
+	TwoFactor *TwoFactorCode
 }
 
 func (opts SetMaintenanceModeOpts) Event() MaintenanceEvent {
@@ -271,7 +419,16 @@ func (opts SetMaintenanceModeOpts) Event() MaintenanceEvent {
 }
 
 func (opts SetMaintenanceModeOpts) Validate(e *Empire) error {
-	return e.requireMessages(opts.Message)
+	if err := e.authorize(opts.User, opts.App, ActionScale); err != nil {
+		return err
+	}
+	if err := e.requireMessages(opts.Message); err != nil {
+		return err
+	}
+	if opts.Maintenance {
+		return e.requireTwoFactor(opts.User, opts.App, opts.TwoFactor)
+	}
+	return nil
 }
 
 // SetMaintenanceMode enables or disables "maintenance mode" on the app. When an
@@ -307,7 +464,43 @@ func (e *Empire) SetMaintenanceMode(ctx context.Context, opts SetMaintenanceMode
 		return err
 	}
 
-	return e.PublishEvent(opts.Event())
+	return e.publishEvent(ctx, opts.Event())
+}
+
+// SetTraffic splits the traffic to the load balancers of an app between the
+// current and previous release, by running the processes of both. This allows
+// a new release to be rolled out gradually, by shifting traffic away from the
+// previous release. Setting the weight to 0 stops the previous release.
+func (e *Empire) SetTraffic(ctx context.Context, opts SetTrafficOpts) error {
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
+	tx := e.db.Begin()
+
+	app := opts.App
+
+	app.PreviousReleaseWeight = opts.PreviousReleaseWeight
+
+	if err := appsUpdate(tx, app); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := e.releases.ReleaseApp(ctx, tx, app, nil); err != nil {
+		tx.Rollback()
+		if err == ErrNoReleases {
+			return ErrNoPreviousRelease
+		}
+
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	return e.publishEvent(ctx, opts.Event())
 }
 
 // SetOpts are options provided when setting new config vars on an app.
@@ -321,8 +514,19 @@ type SetOpts struct {
 	// The new vars to merge into the old config.
 	Vars Vars
 
+	// If true, the vars that are set are marked as sensitive, so their
+	// values are redacted when they're read.
+	Sensitive bool
+
 	// Commit message
 	Message string
+
+	// If provided, allows the release to proceed during a freeze window.
+	// The reason will be published in a FreezeOverrideEvent.
+	FreezeOverride string
+
+	// Required to unset config vars when the app is protected.
+	TwoFactor *TwoFactorCode
 }
 
 func (opts SetOpts) Event() SetEvent {
@@ -341,30 +545,56 @@ func (opts SetOpts) Event() SetEvent {
 }
 
 func (opts SetOpts) Validate(e *Empire) error {
-	return e.requireMessages(opts.Message)
+	if err := e.authorize(opts.User, opts.App, ActionConfig); err != nil {
+		return err
+	}
+	if err := e.requireMessages(opts.Message); err != nil {
+		return err
+	}
+	for _, v := range opts.Vars {
+		if v == nil {
+			return e.requireTwoFactor(opts.User, opts.App, opts.TwoFactor)
+		}
+	}
+	return nil
 }
 
 // Set applies the new config vars to the apps current Config, returning the new
 // Config. If the app has a running release, a new release will be created and
 // run.
 func (e *Empire) Set(ctx context.Context, opts SetOpts) (*Config, error) {
-	if err := opts.Validate(e); err != nil {
+	done, err := e.ops.start()
+	if err != nil {
 		return nil, err
 	}
+	defer done()
 
-	tx := e.db.Begin()
-
-	c, err := e.configs.Set(ctx, tx, opts)
-	if err != nil {
-		tx.Rollback()
-		return c, err
+	if err := opts.Validate(e); err != nil {
+		return nil, err
 	}
 
-	if err := tx.Commit().Error; err != nil {
+	var c *Config
+	if err := e.hook(ctx, &HookRequest{
+		Operation: OperationConfig,
+		User:      opts.User,
+		App:       opts.App,
+		Opts:      opts,
+	}, func() error {
+		tx := e.db.Begin()
+
+		var err error
+		c, err = e.configs.Set(ctx, tx, opts)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		return tx.Commit().Error
+	}); err != nil {
 		return c, err
 	}
 
-	return c, e.PublishEvent(opts.Event())
+	return c, e.publishEvent(ctx, opts.Event())
 }
 
 // DomainsFind returns the first domain matching the query.
@@ -377,8 +607,23 @@ func (e *Empire) Domains(q DomainsQuery) ([]*Domain, error) {
 	return domains(e.db, q)
 }
 
+// DomainsCreateOpts are options provided when adding a domain to an app.
+type DomainsCreateOpts struct {
+	// User performing the action.
+	User *User
+
+	// The domain to add.
+	Domain *Domain
+}
+
 // DomainsCreate adds a new Domain for an App.
-func (e *Empire) DomainsCreate(ctx context.Context, domain *Domain) (*Domain, error) {
+func (e *Empire) DomainsCreate(ctx context.Context, opts DomainsCreateOpts) (*Domain, error) {
+	domain := opts.Domain
+
+	if err := e.authorizeAppID(opts.User, domain.AppID, ActionAdmin); err != nil {
+		return domain, err
+	}
+
 	tx := e.db.Begin()
 
 	d, err := e.domains.DomainsCreate(ctx, tx, domain)
@@ -394,8 +639,23 @@ func (e *Empire) DomainsCreate(ctx context.Context, domain *Domain) (*Domain, er
 	return d, nil
 }
 
+// DomainsDestroyOpts are options provided when removing a domain from an app.
+type DomainsDestroyOpts struct {
+	// User performing the action.
+	User *User
+
+	// The domain to remove.
+	Domain *Domain
+}
+
 // DomainsDestroy removes a Domain for an App.
-func (e *Empire) DomainsDestroy(ctx context.Context, domain *Domain) error {
+func (e *Empire) DomainsDestroy(ctx context.Context, opts DomainsDestroyOpts) error {
+	domain := opts.Domain
+
+	if err := e.authorizeAppID(opts.User, domain.AppID, ActionAdmin); err != nil {
+		return err
+	}
+
 	tx := e.db.Begin()
 
 	if err := e.domains.DomainsDestroy(ctx, tx, domain); err != nil {
@@ -410,157 +670,1015 @@ func (e *Empire) DomainsDestroy(ctx context.Context, domain *Domain) error {
 	return nil
 }
 
-// Tasks returns the Tasks for the given app.
-func (e *Empire) Tasks(ctx context.Context, app *App) ([]*Task, error) {
-	return e.tasks.Tasks(ctx, app)
+// IngressRulesFind returns the first ingress rule matching the query.
+func (e *Empire) IngressRulesFind(q IngressRulesQuery) (*IngressRule, error) {
+	return ingressRulesFind(e.db, q)
 }
 
-// RestartOpts are options provided when restarting an app.
-type RestartOpts struct {
-	// User performing the action.
-	User *User
-
-	// The associated app.
-	App *App
-
-	// If provided, a PID that will be killed. Generally used for killing
-	// detached processes.
-	PID string
+// IngressRules returns all ingress rules matching the query.
+func (e *Empire) IngressRules(q IngressRulesQuery) ([]*IngressRule, error) {
+	return ingressRules(e.db, q)
+}
 
-	// Commit message
-	Message string
+// IngressRulesCreate allows an app to connect to a port of another app. Rules
+// are enforced by the scheduler the next time the app is released.
+func (e *Empire) IngressRulesCreate(ctx context.Context, opts IngressRulesCreateOpts) (*IngressRule, error) {
+	if err := e.authorizeAppID(opts.User, opts.Rule.AppID, ActionAdmin); err != nil {
+		return opts.Rule, err
+	}
+	return ingressRulesCreate(e.db, opts.Rule)
 }
 
-func (opts RestartOpts) Event() RestartEvent {
-	return RestartEvent{
-		User:    opts.User.Name,
-		App:     opts.App.Name,
-		PID:     opts.PID,
-		Message: opts.Message,
-		app:     opts.App,
+// IngressRulesDestroy removes an ingress rule.
+func (e *Empire) IngressRulesDestroy(ctx context.Context, opts IngressRulesDestroyOpts) error {
+	if err := e.authorizeAppID(opts.User, opts.Rule.AppID, ActionAdmin); err != nil {
+		return err
 	}
+	return ingressRulesDestroy(e.db, opts.Rule)
 }
 
-func (opts RestartOpts) Validate(e *Empire) error {
-	return e.requireMessages(opts.Message)
+// RoutingRulesFind returns the first routing rule matching the query.
+func (e *Empire) RoutingRulesFind(q RoutingRulesQuery) (*RoutingRule, error) {
+	return routingRulesFind(e.db, q)
 }
 
-// Restart restarts processes matching the given prefix for the given Release.
-// If the prefix is empty, it will match all processes for the release.
-func (e *Empire) Restart(ctx context.Context, opts RestartOpts) error {
-	if err := opts.Validate(e); err != nil {
-		return err
+// RoutingRules returns all routing rules matching the query.
+func (e *Empire) RoutingRules(q RoutingRulesQuery) ([]*RoutingRule, error) {
+	return routingRules(e.db, q)
+}
+
+// RoutingRulesCreate adds a rule that routes requests to a process of an app.
+// Rules are applied by the scheduler the next time the app is released.
+func (e *Empire) RoutingRulesCreate(ctx context.Context, opts RoutingRulesCreateOpts) (*RoutingRule, error) {
+	if err := e.authorizeAppID(opts.User, opts.Rule.AppID, ActionAdmin); err != nil {
+		return opts.Rule, err
 	}
+	return routingRulesCreate(e.db, opts.Rule)
+}
 
-	if err := e.apps.Restart(ctx, e.db, opts); err != nil {
+// RoutingRulesDestroy removes a routing rule.
+func (e *Empire) RoutingRulesDestroy(ctx context.Context, opts RoutingRulesDestroyOpts) error {
+	if err := e.authorizeAppID(opts.User, opts.Rule.AppID, ActionAdmin); err != nil {
 		return err
 	}
+	return routingRulesDestroy(e.db, opts.Rule)
+}
 
-	return e.PublishEvent(opts.Event())
+// RegistryCredentialsFind returns the first registry credential matching the
+// query.
+func (e *Empire) RegistryCredentialsFind(q RegistryCredentialsQuery) (*RegistryCredential, error) {
+	return registryCredentialsFind(e.db, q)
+}
 
+// RegistryCredentials returns all registry credentials matching the query.
+func (e *Empire) RegistryCredentials(q RegistryCredentialsQuery) ([]*RegistryCredential, error) {
+	return registryCredentials(e.db, q)
 }
 
-// RunOpts are options provided when running an attached/detached process.
-type RunOpts struct {
-	// User performing this action.
-	User *User
+// RegistryCredentialsCreate adds new credentials for a private registry. The
+// credentials will be provided to the scheduler on the next release of any app
+// that they apply to.
+func (e *Empire) RegistryCredentialsCreate(ctx context.Context, opts RegistryCredentialsCreateOpts) (*RegistryCredential, error) {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return opts.Credential, err
+	}
+	return registryCredentialsCreate(e.db, opts.Credential)
+}
 
-	// Related app.
-	App *App
+// RegistryCredentialsDestroy removes credentials for a private registry.
+func (e *Empire) RegistryCredentialsDestroy(ctx context.Context, opts RegistryCredentialsDestroyOpts) error {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return err
+	}
+	return registryCredentialsDestroy(e.db, opts.Credential)
+}
 
-	// The command to run.
-	Command Command
+// FreezeWindowsFind returns the first freeze window matching the query.
+func (e *Empire) FreezeWindowsFind(q FreezeWindowsQuery) (*FreezeWindow, error) {
+	return freezeWindowsFind(e.db, q)
+}
 
-	// Commit message
-	Message string
+// FreezeWindows returns all freeze windows matching the query.
+func (e *Empire) FreezeWindows(q FreezeWindowsQuery) ([]*FreezeWindow, error) {
+	return freezeWindows(e.db, q)
+}
 
-	// Input/Output streams. The caller is responsible for closing these
-	// streams.
-	Stdin          io.Reader
-	Stdout, Stderr io.Writer
+// FreezeWindowsCreate declares a new freeze window.
+func (e *Empire) FreezeWindowsCreate(ctx context.Context, opts FreezeWindowsCreateOpts) (*FreezeWindow, error) {
+	w := opts.Window
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return w, err
+	}
+	w.CreatedBy = opts.User.Name
+	return freezeWindowsCreate(e.db, w)
+}
 
-	// Extra environment variables to set.
-	Env map[string]string
+// FreezeWindowsDestroy removes a freeze window.
+func (e *Empire) FreezeWindowsDestroy(ctx context.Context, opts FreezeWindowsDestroyOpts) error {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return err
+	}
+	return freezeWindowsDestroy(e.db, opts.Window)
+}
 
-	// Optional memory/cpu/nproc constraints.
-	Constraints *Constraints
+// NamespacesFind returns the first namespace matching the query.
+func (e *Empire) NamespacesFind(q NamespacesQuery) (*Namespace, error) {
+	return namespacesFind(e.db, q)
 }
 
-func (opts RunOpts) Event() RunEvent {
-	var attached bool
-	if opts.Stdout != nil || opts.Stderr != nil {
-		attached = true
+// Namespaces returns all namespaces matching the query.
+func (e *Empire) Namespaces(q NamespacesQuery) ([]*Namespace, error) {
+	return namespaces(e.db, q)
+}
+
+// NamespacesCreate claims a namespace for a team. Apps that already exist in
+// the namespace have to be owned by the team.
+func (e *Empire) NamespacesCreate(ctx context.Context, opts NamespacesCreateOpts) (*Namespace, error) {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return opts.Namespace, err
 	}
 
-	return RunEvent{
-		User:     opts.User.Name,
-		App:      opts.App.Name,
-		Command:  opts.Command,
-		Attached: attached,
-		Message:  opts.Message,
-		app:      opts.App,
+	tx := e.db.Begin()
+
+	n, err := namespacesCreate(tx, opts.Namespace)
+	if err != nil {
+		tx.Rollback()
+		return n, err
 	}
-}
 
-func (opts RunOpts) Validate(e *Empire) error {
-	return e.requireMessages(opts.Message)
+	return n, tx.Commit().Error
 }
 
-// Run runs a one-off process for a given App and command.
-func (e *Empire) Run(ctx context.Context, opts RunOpts) error {
-	event := opts.Event()
+// NamespacesDestroy releases a namespace, and removes the quotas and grants
+// that were scoped to it.
+func (e *Empire) NamespacesDestroy(ctx context.Context, opts NamespacesDestroyOpts) error {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return err
+	}
 
-	if err := opts.Validate(e); err != nil {
+	tx := e.db.Begin()
+
+	if err := namespacesDestroy(tx, opts.Namespace); err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	if e.RunRecorder != nil && (opts.Stdout != nil || opts.Stderr != nil) {
-		w, err := e.RunRecorder()
-		if err != nil {
-			return err
-		}
+	return tx.Commit().Error
+}
 
-		// Add the log url to the event, if there is one.
-		if w, ok := w.(interface {
-			URL() string
-		}); ok {
-			event.URL = w.URL()
-		}
+// QuotasFind returns the first quota matching the query.
+func (e *Empire) QuotasFind(q QuotasQuery) (*Quota, error) {
+	return quotasFind(e.db, q)
+}
 
-		msg := fmt.Sprintf("Running `%s` on %s as %s", opts.Command, opts.App.Name, opts.User.Name)
-		msg = appendCommitMessage(msg, opts.Message)
-		io.WriteString(w, fmt.Sprintf("%s\n", msg))
+// Quotas returns all quotas matching the query.
+func (e *Empire) Quotas(q QuotasQuery) ([]*Quota, error) {
+	return quotas(e.db, q)
+}
 
-		// Write output to both the original output as well as the
-		// record.
-		if opts.Stdout != nil {
-			opts.Stdout = io.MultiWriter(w, opts.Stdout)
-		}
-		if opts.Stderr != nil {
-			opts.Stderr = io.MultiWriter(w, opts.Stderr)
-		}
+// QuotasCreate sets a quota for a team, a namespace or an app. The quota is enforced the
+// next time the app is released, scaled or run.
+func (e *Empire) QuotasCreate(ctx context.Context, opts QuotasCreateOpts) (*Quota, error) {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return opts.Quota, err
 	}
+	return quotasCreate(e.db, opts.Quota)
+}
 
-	if err := e.PublishEvent(event); err != nil {
+// QuotasDestroy removes a quota.
+func (e *Empire) QuotasDestroy(ctx context.Context, opts QuotasDestroyOpts) error {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
 		return err
 	}
+	return quotasDestroy(e.db, opts.Quota)
+}
 
-	if err := e.runner.Run(ctx, opts); err != nil {
-		return err
-	}
+// GrantsFind returns the first grant matching the query.
+func (e *Empire) GrantsFind(q GrantsQuery) (*Grant, error) {
+	return grantsFind(e.db, q)
+}
 
-	event.Finish()
-	return e.PublishEvent(event)
+// Grants returns all grants matching the query.
+func (e *Empire) Grants(q GrantsQuery) ([]*Grant, error) {
+	return grants(e.db, q)
 }
 
-// Releases returns all Releases for a given App.
-func (e *Empire) Releases(q ReleasesQuery) ([]*Release, error) {
-	return releases(e.db, q)
+// GrantsCreate gives a role to a user or team. Grants on a single app can be
+// managed by admins of that app.
+func (e *Empire) GrantsCreate(ctx context.Context, opts GrantsCreateOpts) (*Grant, error) {
+	if err := e.authorizeGrant(opts.User, opts.Grant); err != nil {
+		return opts.Grant, err
+	}
+	return grantsCreate(e.db, opts.Grant)
 }
 
-// ReleasesFind returns the first releases for a given App.
-func (e *Empire) ReleasesFind(q ReleasesQuery) (*Release, error) {
-	return releasesFind(e.db, q)
+// GrantsDestroy removes a grant.
+func (e *Empire) GrantsDestroy(ctx context.Context, opts GrantsDestroyOpts) error {
+	if err := e.authorizeGrant(opts.User, opts.Grant); err != nil {
+		return err
+	}
+	return grantsDestroy(e.db, opts.Grant)
+}
+
+// TeamMembersFind returns the first team member matching the query.
+func (e *Empire) TeamMembersFind(q TeamMembersQuery) (*TeamMember, error) {
+	return teamMembersFind(e.db, q)
+}
+
+// TeamMembers returns all team members matching the query.
+func (e *Empire) TeamMembers(q TeamMembersQuery) ([]*TeamMember, error) {
+	return teamMembers(e.db, q)
+}
+
+// TeamMembersCreate adds a user to a team.
+func (e *Empire) TeamMembersCreate(ctx context.Context, opts TeamMembersCreateOpts) (*TeamMember, error) {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return opts.Member, err
+	}
+	return teamMembersCreate(e.db, opts.Member)
+}
+
+// TeamMembersDestroy removes a user from a team.
+func (e *Empire) TeamMembersDestroy(ctx context.Context, opts TeamMembersDestroyOpts) error {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return err
+	}
+	return teamMembersDestroy(e.db, opts.Member)
+}
+
+// APITokensCreate creates a new API token. The plaintext token is only
+// available on the returned APIToken.
+func (e *Empire) APITokensCreate(ctx context.Context, opts APITokensCreateOpts) (*APIToken, error) {
+	if err := e.authorizeScope(opts.User, opts.Scope); err != nil {
+		return nil, err
+	}
+
+	secret, err := generateAPIToken()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &APIToken{
+		Name:      opts.Name,
+		Token:     secret,
+		TokenHash: hashAPIToken(secret),
+		Scope:     opts.Scope,
+		CreatedBy: opts.User.Name,
+	}
+
+	return apiTokensCreate(e.db, t)
+}
+
+// APITokensDestroy revokes an API token.
+func (e *Empire) APITokensDestroy(ctx context.Context, opts APITokensDestroyOpts) error {
+	if err := e.authorizeScope(opts.User, opts.Token.Scope); err != nil {
+		return err
+	}
+
+	return apiTokensDestroy(e.db, opts.Token)
+}
+
+// APITokensFind returns the first API token matching the query.
+func (e *Empire) APITokensFind(q APITokensQuery) (*APIToken, error) {
+	return apiTokensFind(e.db, q)
+}
+
+// APITokens returns all API tokens matching the query.
+func (e *Empire) APITokens(q APITokensQuery) ([]*APIToken, error) {
+	return apiTokens(e.db, q)
+}
+
+// APITokensAuthenticate finds the API token matching the plaintext token, and
+// records that it was used. If no token matches, gorm.RecordNotFound is
+// returned.
+func (e *Empire) APITokensAuthenticate(token string) (*APIToken, error) {
+	hash := hashAPIToken(token)
+
+	t, err := apiTokensFind(e.db, APITokensQuery{TokenHash: &hash})
+	if err != nil {
+		return t, err
+	}
+
+	return t, apiTokensTouch(e.db, t)
+}
+
+// Tasks returns the Tasks for the given app, matching the query.
+func (e *Empire) Tasks(ctx context.Context, q TasksQuery) ([]*Task, error) {
+	return e.tasks.Tasks(ctx, q)
+}
+
+// ClusterTasksOpts are options provided when listing the tasks for all apps.
+type ClusterTasksOpts struct {
+	// User performing the action.
+	User *User
+
+	// Filters and range for the tasks.
+	Query TasksQuery
+}
+
+// ClusterTasks returns the Tasks for all apps, matching the query. This allows
+// operators to find what's running on a host, or which apps are still running
+// an image.
+func (e *Empire) ClusterTasks(ctx context.Context, opts ClusterTasksOpts) ([]*Task, error) {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return nil, err
+	}
+
+	opts.Query.App = nil
+	return e.tasks.ClusterTasks(ctx, opts.Query)
+}
+
+// HostOpts are options provided when cordoning, uncordoning or draining a
+// host.
+type HostOpts struct {
+	// User performing the action.
+	User *User
+
+	// The id of the host (e.g. the EC2 instance id).
+	Host string
+
+	// Commit message
+	Message string
+}
+
+func (opts HostOpts) Event(action string) HostEvent {
+	return HostEvent{
+		User:    opts.User.Name,
+		Host:    opts.Host,
+		Action:  action,
+		Message: opts.Message,
+	}
+}
+
+func (opts HostOpts) Validate(e *Empire) error {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return err
+	}
+	if opts.Host == "" {
+		return ErrHostRequired
+	}
+	return e.requireMessages(opts.Message)
+}
+
+// Cordon marks a host as unschedulable, so that no new processes are placed on
+// it.
+func (e *Empire) Cordon(ctx context.Context, opts HostOpts) error {
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
+	if err := e.anyScheduler(func(s Scheduler) error {
+		return s.Cordon(ctx, opts.Host)
+	}); err != nil {
+		return err
+	}
+
+	return e.publishEvent(ctx, opts.Event("cordon"))
+}
+
+// Uncordon marks a cordoned or drained host as schedulable again.
+func (e *Empire) Uncordon(ctx context.Context, opts HostOpts) error {
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
+	if err := e.anyScheduler(func(s Scheduler) error {
+		return s.Uncordon(ctx, opts.Host)
+	}); err != nil {
+		return err
+	}
+
+	return e.publishEvent(ctx, opts.Event("uncordon"))
+}
+
+// Drain cordons a host, and moves the processes running on it to other hosts.
+// It returns the processes that were running on the host when it started
+// draining, so the caller can follow them as they're replaced.
+func (e *Empire) Drain(ctx context.Context, opts HostOpts) ([]*Task, error) {
+	if err := opts.Validate(e); err != nil {
+		return nil, err
+	}
+
+	tasks, err := e.tasks.ClusterTasks(ctx, TasksQuery{Host: &opts.Host})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.anyScheduler(func(s Scheduler) error {
+		return s.Drain(ctx, opts.Host)
+	}); err != nil {
+		return nil, err
+	}
+
+	return tasks, e.publishEvent(ctx, opts.Event("drain"))
+}
+
+// CapacityOpts are options provided when getting the capacity of the
+// clusters.
+type CapacityOpts struct {
+	// User performing the action.
+	User *User
+}
+
+// Capacity returns the total and allocated resources of each host in each
+// cluster, so that operators can tell whether a scale up will fit before
+// running it.
+func (e *Empire) Capacity(ctx context.Context, opts CapacityOpts) ([]*ClusterCapacity, error) {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return nil, err
+	}
+
+	return e.capacity(ctx)
+}
+
+// Reschedule stops processes that are running on hosts that the scheduler has
+// lost contact with, so that the scheduler replaces them on healthy hosts. It
+// returns the processes that were stopped.
+func (e *Empire) Reschedule(ctx context.Context) ([]*Task, error) {
+	return e.reschedule.Reschedule(ctx)
+}
+
+// RestartOpts are options provided when restarting an app.
+type RestartOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// If provided, a PID that will be killed. Generally used for killing
+	// detached processes.
+	PID string
+
+	// Commit message
+	Message string
+}
+
+func (opts RestartOpts) Event() RestartEvent {
+	return RestartEvent{
+		User:    opts.User.Name,
+		App:     opts.App.Name,
+		PID:     opts.PID,
+		Message: opts.Message,
+		app:     opts.App,
+	}
+}
+
+func (opts RestartOpts) Validate(e *Empire) error {
+	if err := e.authorize(opts.User, opts.App, ActionRestart); err != nil {
+		return err
+	}
+	return e.requireMessages(opts.Message)
+}
+
+// Restart restarts processes matching the given prefix for the given Release.
+// If the prefix is empty, it will match all processes for the release.
+func (e *Empire) Restart(ctx context.Context, opts RestartOpts) error {
+	done, err := e.ops.start()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
+	if err := e.hook(ctx, &HookRequest{
+		Operation: OperationRestart,
+		User:      opts.User,
+		App:       opts.App,
+		Opts:      opts,
+	}, func() error {
+		return e.apps.Restart(ctx, e.db, opts)
+	}); err != nil {
+		return err
+	}
+
+	return e.publishEvent(ctx, opts.Event())
+
+}
+
+// RunOpts are options provided when running an attached/detached process.
+type RunOpts struct {
+	// User performing this action.
+	User *User
+
+	// Related app.
+	App *App
+
+	// The command to run.
+	Command Command
+
+	// Commit message
+	Message string
+
+	// Input/Output streams. The caller is responsible for closing these
+	// streams.
+	Stdin          io.Reader
+	Stdout, Stderr io.Writer
+
+	// Extra environment variables to set.
+	Env map[string]string
+
+	// Optional memory/cpu/nproc constraints.
+	Constraints *Constraints
+
+	// Optional overrides for the cpu shares, and memory, of the process.
+	// These are applied on top of Constraints, or the constraints of the
+	// process in the formation, so that a one-off process can be given
+	// more memory without changing the app's processes.
+	CPUShare *constraints.CPUShare
+	Memory   *constraints.Memory
+
+	// If provided, the output of the detached process is captured under
+	// this id, and can be retrieved later with JobOutput.
+	OutputID string
+
+	// If provided, the process is killed once it has been running for
+	// longer than this.
+	Timeout time.Duration
+
+	// If provided, retries of the run with the same key, by the same user,
+	// don't run the process again. Only detached runs can have one.
+	IdempotencyKey string
+}
+
+func (opts RunOpts) Event() RunEvent {
+	var attached bool
+	if opts.Stdout != nil || opts.Stderr != nil {
+		attached = true
+	}
+
+	return RunEvent{
+		User:     opts.User.Name,
+		App:      opts.App.Name,
+		Command:  opts.Command,
+		Attached: attached,
+		Message:  opts.Message,
+		app:      opts.App,
+	}
+}
+
+func (opts RunOpts) Validate(e *Empire) error {
+	if err := e.authorize(opts.User, opts.App, ActionRun); err != nil {
+		return err
+	}
+	if opts.Timeout < 0 {
+		return ErrRunTimeout
+	}
+	if opts.IdempotencyKey != "" && (opts.Stdout != nil || opts.Stderr != nil) {
+		return ErrIdempotencyKeyAttached
+	}
+	if opts.OutputID != "" {
+		if opts.Stdout != nil || opts.Stderr != nil {
+			return ErrOutputAttached
+		}
+		if e.Outputs == nil {
+			return ErrOutputDisabled
+		}
+	}
+	return e.requireMessages(opts.Message)
+}
+
+// Run runs a one-off process for a given App and command.
+func (e *Empire) Run(ctx context.Context, opts RunOpts) error {
+	done, err := e.ops.start()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
+	var result struct{}
+	_, err = e.idempotent(opts.User, opts.IdempotencyKey, fmt.Sprintf("run `%s` on %s", opts.Command, opts.App.Name), &result, func() error {
+		return e.hook(ctx, &HookRequest{
+			Operation: OperationRun,
+			User:      opts.User,
+			App:       opts.App,
+			Opts:      opts,
+		}, func() error {
+			return e.run(ctx, opts)
+		})
+	})
+	return err
+}
+
+func (e *Empire) run(ctx context.Context, opts RunOpts) error {
+	event := opts.Event()
+
+	if e.RunRecorder != nil && (opts.Stdout != nil || opts.Stderr != nil) {
+		w, err := e.RunRecorder()
+		if err != nil {
+			return err
+		}
+
+		// Add the log url to the event, if there is one.
+		if w, ok := w.(interface {
+			URL() string
+		}); ok {
+			event.URL = w.URL()
+		}
+
+		msg := fmt.Sprintf("Running `%s` on %s as %s", opts.Command, opts.App.Name, opts.User.Name)
+		msg = appendCommitMessage(msg, opts.Message)
+		io.WriteString(w, fmt.Sprintf("%s\n", msg))
+
+		// Write output to both the original output as well as the
+		// record.
+		if opts.Stdout != nil {
+			opts.Stdout = io.MultiWriter(w, opts.Stdout)
+		}
+		if opts.Stderr != nil {
+			opts.Stderr = io.MultiWriter(w, opts.Stderr)
+		}
+	}
+
+	if err := e.publishEvent(ctx, event); err != nil {
+		return err
+	}
+
+	if err := e.runner.Run(ctx, opts); err != nil {
+		return err
+	}
+
+	event.Finish()
+	return e.publishEvent(ctx, event)
+}
+
+// JobOutputOpts are options provided when retrieving the captured output of a
+// detached one-off process.
+type JobOutputOpts struct {
+	// User performing this action.
+	User *User
+
+	// Related app.
+	App *App
+
+	// The id that the output was captured under.
+	ID string
+
+	// Where the output is written to.
+	Output io.Writer
+}
+
+// JobOutput writes the output that was captured for a detached one-off process
+// of the app.
+func (e *Empire) JobOutput(ctx context.Context, opts JobOutputOpts) error {
+	if err := e.authorize(opts.User, opts.App, ActionRun); err != nil {
+		return err
+	}
+	if e.Blobs != nil {
+		if err := e.archivedOutput(ctx, opts.App, opts.ID, opts.Output); err != ErrNoOutput {
+			return err
+		}
+	}
+	if e.Outputs == nil {
+		return ErrOutputDisabled
+	}
+	return e.Outputs.Output(ctx, opts.App, opts.ID, opts.Output)
+}
+
+// BatchesCreate queues a batch of one-off jobs for an app, which are run in
+// the background, up to opts.Parallelism at a time.
+func (e *Empire) BatchesCreate(ctx context.Context, opts BatchesCreateOpts) (*Batch, error) {
+	if err := opts.Validate(e); err != nil {
+		return nil, err
+	}
+
+	release, err := releasesFind(e.db, ReleasesQuery{App: opts.App})
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &Batch{
+		AppID:       opts.App.ID,
+		Parallelism: opts.Parallelism,
+		CreatedBy:   opts.User.Name,
+	}
+	for _, command := range opts.Commands {
+		job, err := e.batchJob(release, command, opts.Constraints)
+		if err != nil {
+			return nil, err
+		}
+		batch.Jobs = append(batch.Jobs, job)
+	}
+
+	tx := e.db.Begin()
+	if _, err := batchesCreate(tx, batch); err != nil {
+		tx.Rollback()
+		return batch, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return batch, err
+	}
+
+	return batch, e.publishEvent(ctx, opts.Event())
+}
+
+// CronTrigger starts an invocation of a scheduled process of an app right away,
+// rather than waiting for its schedule. If the process is still running, its
+// overlap policy decides whether the invocation is rejected, queued until the
+// running instances stop, or replaces them.
+func (e *Empire) CronTrigger(ctx context.Context, opts CronTriggerOpts) (*CronRun, error) {
+	if err := opts.Validate(e); err != nil {
+		return nil, err
+	}
+
+	run, err := e.cronTrigger(ctx, opts)
+	if err != nil {
+		return run, err
+	}
+
+	return run, e.publishEvent(ctx, opts.Event())
+}
+
+// CronRuns returns the invocations of scheduled processes matching the query,
+// most recent first.
+func (e *Empire) CronRuns(q CronRunsQuery) ([]*CronRun, error) {
+	return cronRuns(e.dbr, q)
+}
+
+// ChaosKills returns the instances that were killed by the ChaosMonkey, most
+// recent first.
+func (e *Empire) ChaosKills(q ChaosKillsQuery) ([]*ChaosKill, error) {
+	return chaosKills(e.dbr, q)
+}
+
+// TaskTransitions returns the transitions of the tasks of long running
+// processes matching the query, most recent first.
+func (e *Empire) TaskTransitions(q TaskTransitionsQuery) ([]*TaskTransition, error) {
+	return taskTransitions(e.dbr, q)
+}
+
+// ActivityFeed returns the releases, scales, restarts and crashes of an app,
+// most recent first.
+func (e *Empire) ActivityFeed(q ActivityFeedQuery) ([]*Activity, error) {
+	return activityFeed(e.dbr, q)
+}
+
+// BatchesFind returns the first batch matching the query, with its jobs.
+func (e *Empire) BatchesFind(q BatchesQuery) (*Batch, error) {
+	return batchesFind(e.db, q)
+}
+
+// Batches returns all batches matching the query, most recent first, with
+// their jobs.
+func (e *Empire) Batches(q BatchesQuery) ([]*Batch, error) {
+	return batches(e.dbr, q)
+}
+
+// ExecOpts are options provided when running a command inside of a running
+// process.
+type ExecOpts struct {
+	// User performing this action.
+	User *User
+
+	// Related app.
+	App *App
+
+	// The PID of the running process to exec into.
+	PID string
+
+	// The command to run.
+	Command Command
+
+	// Whether a TTY should be allocated for the command.
+	Tty bool
+
+	// Commit message
+	Message string
+
+	// Input/Output streams. The caller is responsible for closing these
+	// streams.
+	Stdin          io.Reader
+	Stdout, Stderr io.Writer
+}
+
+func (opts ExecOpts) Event() ExecEvent {
+	return ExecEvent{
+		User:    opts.User.Name,
+		App:     opts.App.Name,
+		PID:     opts.PID,
+		Command: opts.Command,
+		Message: opts.Message,
+		app:     opts.App,
+	}
+}
+
+func (opts ExecOpts) Validate(e *Empire) error {
+	if err := e.authorize(opts.User, opts.App, ActionRun); err != nil {
+		return err
+	}
+	return e.requireMessages(opts.Message)
+}
+
+// Exec runs a command inside of an already running process for the App.
+func (e *Empire) Exec(ctx context.Context, opts ExecOpts) error {
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
+	scheduler, err := e.scheduler(opts.App)
+	if err != nil {
+		return err
+	}
+
+	if err := e.publishEvent(ctx, opts.Event()); err != nil {
+		return err
+	}
+
+	return scheduler.Exec(ctx, opts.App.ID, opts.PID, twelvefactor.ExecOpts{
+		Command: opts.Command,
+		Tty:     opts.Tty,
+		Stdin:   opts.Stdin,
+		Stdout:  opts.Stdout,
+		Stderr:  opts.Stderr,
+	})
+}
+
+// PortForwardOpts are options provided when forwarding a port to a running
+// process.
+type PortForwardOpts struct {
+	// User performing this action.
+	User *User
+
+	// Related app.
+	App *App
+
+	// The PID of the running process to connect to.
+	PID string
+
+	// The port inside of the process to connect to.
+	Port int
+
+	// Commit message
+	Message string
+
+	// The stream that will be connected to the port. The caller is
+	// responsible for closing this stream.
+	Stream io.ReadWriter
+}
+
+func (opts PortForwardOpts) Event() PortForwardEvent {
+	return PortForwardEvent{
+		User:    opts.User.Name,
+		App:     opts.App.Name,
+		PID:     opts.PID,
+		Port:    opts.Port,
+		Message: opts.Message,
+		app:     opts.App,
+	}
+}
+
+func (opts PortForwardOpts) Validate(e *Empire) error {
+	if err := e.authorize(opts.User, opts.App, ActionRun); err != nil {
+		return err
+	}
+	if opts.Port <= 0 || opts.Port > 65535 {
+		return ErrInvalidPort
+	}
+	return e.requireMessages(opts.Message)
+}
+
+// PortForward connects the stream to a port inside of an already running
+// process for the App. It returns when either side of the connection is
+// closed.
+func (e *Empire) PortForward(ctx context.Context, opts PortForwardOpts) error {
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
+	scheduler, err := e.scheduler(opts.App)
+	if err != nil {
+		return err
+	}
+
+	conn, err := scheduler.Dial(ctx, opts.App.ID, opts.PID, opts.Port)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := e.publishEvent(ctx, opts.Event()); err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(conn, opts.Stream)
+		errCh <- err
+	}()
+	go func() {
+		_, err := io.Copy(opts.Stream, conn)
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CopyOpts are options provided when copying files to or from a running
+// process.
+type CopyOpts struct {
+	// User performing this action.
+	User *User
+
+	// Related app.
+	App *App
+
+	// The PID of the running process to copy to or from.
+	PID string
+
+	// The path inside of the process. When copying to the process, this is
+	// the directory that the archive is extracted into.
+	Path string
+
+	// Commit message
+	Message string
+}
+
+func (opts CopyOpts) Event(to bool) CopyEvent {
+	return CopyEvent{
+		User:    opts.User.Name,
+		App:     opts.App.Name,
+		PID:     opts.PID,
+		Path:    opts.Path,
+		To:      to,
+		Message: opts.Message,
+		app:     opts.App,
+	}
+}
+
+func (opts CopyOpts) Validate(e *Empire) error {
+	if err := e.authorize(opts.User, opts.App, ActionRun); err != nil {
+		return err
+	}
+	if opts.Path == "" {
+		return ErrCopyPath
+	}
+	return e.requireMessages(opts.Message)
+}
+
+// CopyTo extracts the tar archive into a directory inside of an already running
+// process for the App.
+func (e *Empire) CopyTo(ctx context.Context, opts CopyOpts, archive io.Reader) error {
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
+	scheduler, err := e.scheduler(opts.App)
+	if err != nil {
+		return err
+	}
+
+	if err := scheduler.CopyTo(ctx, opts.App.ID, opts.PID, opts.Path, archive); err != nil {
+		return err
+	}
+
+	return e.publishEvent(ctx, opts.Event(true))
+}
+
+// CopyFrom writes a tar archive of a file or directory inside of an already
+// running process for the App to w.
+func (e *Empire) CopyFrom(ctx context.Context, opts CopyOpts, w io.Writer) error {
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
+	scheduler, err := e.scheduler(opts.App)
+	if err != nil {
+		return err
+	}
+
+	if err := scheduler.CopyFrom(ctx, opts.App.ID, opts.PID, opts.Path, w); err != nil {
+		return err
+	}
+
+	return e.publishEvent(ctx, opts.Event(false))
+}
+
+// Releases returns all Releases for a given App.
+func (e *Empire) Releases(q ReleasesQuery) ([]*Release, error) {
+	return releases(e.dbr, q)
+}
+
+// ReleasesFind returns the first releases for a given App.
+func (e *Empire) ReleasesFind(q ReleasesQuery) (*Release, error) {
+	return releasesFind(e.db, q)
+}
+
+// ReleasesChanges returns what changed in each release, compared to the
+// release before it.
+func (e *Empire) ReleasesChanges(rs []*Release) ([]*ReleaseChanges, error) {
+	return releasesChanges(e.db, rs)
 }
 
 // RollbackOpts are options provided when rolling back to an old release.
@@ -576,6 +1694,10 @@ type RollbackOpts struct {
 
 	// Commit message
 	Message string
+
+	// If provided, allows the release to proceed during a freeze window.
+	// The reason will be published in a FreezeOverrideEvent.
+	FreezeOverride string
 }
 
 func (opts RollbackOpts) Event() RollbackEvent {
@@ -589,29 +1711,47 @@ func (opts RollbackOpts) Event() RollbackEvent {
 }
 
 func (opts RollbackOpts) Validate(e *Empire) error {
+	if err := e.authorize(opts.User, opts.App, ActionRollback); err != nil {
+		return err
+	}
 	return e.requireMessages(opts.Message)
 }
 
 // Rollback rolls an app back to a specific release version. Returns a
 // new release.
 func (e *Empire) Rollback(ctx context.Context, opts RollbackOpts) (*Release, error) {
-	if err := opts.Validate(e); err != nil {
+	done, err := e.ops.start()
+	if err != nil {
 		return nil, err
 	}
+	defer done()
 
-	tx := e.db.Begin()
-
-	r, err := e.releases.Rollback(ctx, tx, opts)
-	if err != nil {
-		tx.Rollback()
-		return r, err
+	if err := opts.Validate(e); err != nil {
+		return nil, err
 	}
 
-	if err := tx.Commit().Error; err != nil {
+	var r *Release
+	if err := e.hook(ctx, &HookRequest{
+		Operation: OperationRollback,
+		User:      opts.User,
+		App:       opts.App,
+		Opts:      opts,
+	}, func() error {
+		tx := e.db.Begin()
+
+		var err error
+		r, err = e.releases.Rollback(ctx, tx, opts)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		return tx.Commit().Error
+	}); err != nil {
 		return r, err
 	}
 
-	return r, e.PublishEvent(opts.Event())
+	return r, e.publishEvent(ctx, opts.Event())
 }
 
 // DeployOpts represents options that can be passed when deploying to
@@ -636,15 +1776,58 @@ type DeployOpts struct {
 	// Commit message
 	Message string
 
+	// If provided, allows the release to proceed during a freeze window.
+	// The reason will be published in a FreezeOverrideEvent.
+	FreezeOverride string
+
 	// Stream boolean for whether or not a status stream should be created.
 	Stream bool
+
+	// If provided, the id of the request that triggered the deployment,
+	// which is included in the DeployEvent so that it can be correlated
+	// with the logs and errors of the request.
+	RequestID string
+
+	// If provided, where the image came from (e.g. the commit and CI build
+	// that it was built from). This is recorded with the slug, so it's
+	// kept by releases that reuse the image, like config changes.
+	Provenance Provenance
+
+	// If provided, retries of the deploy with the same key, by the same
+	// user, don't deploy again, so that CI systems can retry deploys that
+	// they don't know the outcome of.
+	IdempotencyKey string
+
+	// If true, the deploy is simulated: the image is pulled, and the
+	// release is created, admitted and submitted to a fake scheduler, but
+	// nothing is saved or submitted to the real scheduler. What would have
+	// been released is written to the Output instead.
+	Simulate bool
+}
+
+// operation describes the deploy, for idempotency keys.
+func (opts DeployOpts) operation() string {
+	if opts.App != nil {
+		return fmt.Sprintf("deploy %s to %s", opts.Image, opts.App.Name)
+	}
+	return fmt.Sprintf("deploy %s", opts.Image)
+}
+
+// deployResult is the result of a deploy that's stored with its idempotency
+// key.
+type deployResult struct {
+	AppID   string `json:"app_id"`
+	Version int    `json:"version"`
 }
 
 func (opts DeployOpts) Event() DeployEvent {
 	e := DeployEvent{
-		User:    opts.User.Name,
-		Image:   opts.Image.String(),
-		Message: opts.Message,
+		User:      opts.User.Name,
+		Image:     opts.Image.String(),
+		Message:   opts.Message,
+		RequestID: opts.RequestID,
+
+		Provenance: opts.Provenance,
 	}
 	if opts.App != nil {
 		e.App = opts.App.Name
@@ -655,16 +1838,69 @@ func (opts DeployOpts) Event() DeployEvent {
 }
 
 func (opts DeployOpts) Validate(e *Empire) error {
+	if err := opts.Provenance.IsValid(); err != nil {
+		return err
+	}
+	// Simulations don't change anything, so there's nothing to explain.
+	if opts.Simulate {
+		return nil
+	}
 	return e.requireMessages(opts.Message)
 }
 
 // Deploy deploys an image and streams the output to w.
 func (e *Empire) Deploy(ctx context.Context, opts DeployOpts) (*Release, error) {
+	done, err := e.ops.start()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
 	if err := opts.Validate(e); err != nil {
 		return nil, err
 	}
 
-	r, err := e.deployer.Deploy(ctx, opts)
+	// Simulated deploys don't release anything, so they're not idempotent,
+	// and no DeployEvent is published.
+	if opts.Simulate {
+		return e.deployer.Deploy(ctx, opts)
+	}
+
+	// The output of the deploy is kept, so that it can be retrieved once
+	// the deploy has finished.
+	var deployLog bytes.Buffer
+	if e.Blobs != nil {
+		opts.Output = NewDeploymentStream(io.MultiWriter(opts.Output.Writer, &deployLog))
+	}
+
+	var (
+		r      *Release
+		result deployResult
+	)
+	ran, err := e.idempotent(opts.User, opts.IdempotencyKey, opts.operation(), &result, func() error {
+		return e.hook(ctx, &HookRequest{
+			Operation: OperationDeploy,
+			User:      opts.User,
+			App:       opts.App,
+			Opts:      opts,
+		}, func() error {
+			var err error
+			r, err = e.deployer.Deploy(ctx, opts)
+			if r != nil && r.App != nil {
+				result = deployResult{AppID: r.App.ID, Version: r.Version}
+			}
+			return err
+		})
+	})
+	if !ran {
+		if err != nil {
+			return nil, opts.Output.Error(err)
+		}
+		return e.deployed(opts, result)
+	}
+	if r != nil && e.Blobs != nil {
+		e.storeDeployLog(ctx, r, deployLog.Bytes())
+	}
 	if err != nil {
 		return r, err
 	}
@@ -678,7 +1914,23 @@ func (e *Empire) Deploy(ctx context.Context, opts DeployOpts) (*Release, error)
 		event.app = r.App
 	}
 
-	return r, e.PublishEvent(event)
+	return r, e.publishEvent(ctx, event)
+}
+
+// deployed returns the release that was created by a deploy with the same
+// idempotency key.
+func (e *Empire) deployed(opts DeployOpts, result deployResult) (*Release, error) {
+	app, err := appsFind(e.db, AppsQuery{ID: &result.AppID})
+	if err != nil {
+		return nil, opts.Output.Error(err)
+	}
+
+	r, err := releasesFind(e.db, ReleasesQuery{App: app, Version: &result.Version})
+	if err != nil {
+		return nil, opts.Output.Error(err)
+	}
+
+	return r, opts.Output.Status(fmt.Sprintf("Release v%d of %s was already deployed with idempotency key %q", r.Version, app.Name, opts.IdempotencyKey))
 }
 
 type ProcessUpdate struct {
@@ -688,8 +1940,16 @@ type ProcessUpdate struct {
 	// The desired quantity of processes.
 	Quantity int
 
+	// If provided, the quantity is changed relative to the current quantity
+	// of the process, and Quantity is ignored.
+	Change *QuantityChange
+
 	// If provided, new memory and CPU constraints for the process.
 	Constraints *Constraints
+
+	// If provided, replaces the arguments that are appended to the command
+	// of the process. An empty Command removes them.
+	Args *Command
 }
 
 // ScaleOpts are options provided when scaling a process.
@@ -702,8 +1962,21 @@ type ScaleOpts struct {
 
 	Updates []*ProcessUpdate
 
+	// If provided, the formation is only scaled if it's still at this
+	// version, which is the version that the caller read it at. Otherwise,
+	// the formation is only scaled if nothing else changes it while it's
+	// being scaled.
+	FormationVersion int
+
+	// If provided, retries of the scale with the same key, by the same
+	// user, don't scale again. This matters for relative changes.
+	IdempotencyKey string
+
 	// Commit message
 	Message string
+
+	// Required to scale a process to zero when the app is protected.
+	TwoFactor *TwoFactorCode
 }
 
 func (opts ScaleOpts) Event() ScaleEvent {
@@ -723,31 +1996,77 @@ func (opts ScaleOpts) Event() ScaleEvent {
 		if up.Constraints != nil {
 			event.Constraints = *up.Constraints
 		}
+		if up.Args != nil {
+			args := *up.Args
+			event.Args = &args
+		}
 		updates = append(updates, event)
 	}
 	e.Updates = updates
 	return e
 }
 
+// operation describes the scale, for idempotency keys, so that a key can't be
+// reused to scale the processes differently.
+func (opts ScaleOpts) operation() string {
+	var updates []string
+	for _, up := range opts.Updates {
+		s := fmt.Sprintf("%s=%d", up.Process, up.Quantity)
+		if up.Change != nil {
+			s = fmt.Sprintf("%s=%s", up.Process, up.Change)
+		}
+		if up.Constraints != nil {
+			s += ":" + up.Constraints.String()
+		}
+		if up.Args != nil {
+			s += fmt.Sprintf(" args=%q", up.Args.String())
+		}
+		updates = append(updates, s)
+	}
+	sort.Strings(updates)
+	return fmt.Sprintf("scale %s %s", opts.App.Name, strings.Join(updates, " "))
+}
+
 func (opts ScaleOpts) Validate(e *Empire) error {
+	if err := e.authorize(opts.User, opts.App, ActionScale); err != nil {
+		return err
+	}
 	return e.requireMessages(opts.Message)
 }
 
 // Scale scales an apps processes.
 func (e *Empire) Scale(ctx context.Context, opts ScaleOpts) ([]*Process, error) {
-	if err := opts.Validate(e); err != nil {
+	done, err := e.ops.start()
+	if err != nil {
 		return nil, err
 	}
+	defer done()
 
-	tx := e.db.Begin()
-
-	ps, err := e.apps.Scale(ctx, tx, opts)
-	if err != nil {
-		tx.Rollback()
-		return ps, err
+	if err := opts.Validate(e); err != nil {
+		return nil, err
 	}
 
-	return ps, tx.Commit().Error
+	var ps []*Process
+	_, err = e.idempotent(opts.User, opts.IdempotencyKey, opts.operation(), &ps, func() error {
+		return e.hook(ctx, &HookRequest{
+			Operation: OperationScale,
+			User:      opts.User,
+			App:       opts.App,
+			Opts:      opts,
+		}, func() error {
+			tx := e.db.Begin()
+
+			var err error
+			ps, err = e.apps.Scale(ctx, tx, opts)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			return tx.Commit().Error
+		})
+	})
+	return ps, err
 }
 
 // ListScale lists the current scale settings for a given App
@@ -765,6 +2084,9 @@ func (e *Empire) StreamLogs(app *App, w io.Writer, duration time.Duration) error
 }
 
 type CertsAttachOpts struct {
+	// User performing the action.
+	User *User
+
 	// The certificate to attach.
 	Cert string
 	// The app to attach the cert to.
@@ -777,6 +2099,10 @@ type CertsAttachOpts struct {
 
 // CertsAttach attaches an SSL certificate to the app.
 func (e *Empire) CertsAttach(ctx context.Context, opts CertsAttachOpts) error {
+	if err := e.authorize(opts.User, opts.App, ActionAdmin); err != nil {
+		return err
+	}
+
 	tx := e.db.Begin()
 
 	if err := e.certs.CertsAttach(ctx, tx, opts); err != nil {
@@ -814,3 +2140,104 @@ type MessageRequiredError struct{}
 func (e *MessageRequiredError) Error() string {
 	return "Missing required option: 'Message'"
 }
+
+// TwoFactorEnroll generates a new TOTP secret for a user, replacing any
+// existing secret. Users can replace their own secret with a code from it, but
+// only admins can enroll users that aren't enrolled, so that a stolen session
+// can't be used to enroll a new factor.
+func (e *Empire) TwoFactorEnroll(ctx context.Context, opts TwoFactorEnrollOpts) (*TwoFactorSecret, error) {
+	if opts.User == nil {
+		return nil, &ForbiddenError{Role: RoleViewer}
+	}
+
+	username := opts.Username
+	if username == "" {
+		username = opts.User.Name
+	}
+
+	// Service accounts can't hold a second factor.
+	if (username == opts.User.Name && opts.User.Scope != nil) || strings.HasPrefix(username, APITokenUserPrefix) {
+		return nil, &ForbiddenError{User: opts.User.Name, Action: "enroll in two factor authentication"}
+	}
+
+	existing, err := twoFactorSecretsFind(e.db, TwoFactorSecretsQuery{Username: &username})
+	if err != nil && err != gorm.RecordNotFound {
+		return nil, err
+	}
+
+	if err == nil && username == opts.User.Name {
+		if err := verifyTwoFactorSecret(e.db, existing, opts.Code); err != nil {
+			return nil, err
+		}
+	} else if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return nil, err
+	}
+
+	s, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	tx := e.db.Begin()
+
+	if err := twoFactorSecretsDestroy(tx, username); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	secret, err := twoFactorSecretsCreate(tx, &TwoFactorSecret{
+		Username: username,
+		Secret:   s,
+	})
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return secret, tx.Commit().Error
+}
+
+// TwoFactorVerify returns nil if the code is valid for the user. Each code is
+// only accepted once.
+func (e *Empire) TwoFactorVerify(user *User, code string) error {
+	if user == nil {
+		return ErrTwoFactorNotEnrolled
+	}
+
+	secret, err := twoFactorSecretsFind(e.db, TwoFactorSecretsQuery{Username: &user.Name})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return ErrTwoFactorNotEnrolled
+		}
+		return err
+	}
+
+	return verifyTwoFactorSecret(e.db, secret, code)
+}
+
+// TwoFactorReset removes the user's two factor secret, so that they can enroll
+// again. Only admins of all apps can reset another user's secret.
+func (e *Empire) TwoFactorReset(ctx context.Context, opts TwoFactorResetOpts) error {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return err
+	}
+
+	return twoFactorSecretsDestroy(e.db, opts.Username)
+}
+
+// SetProtected protects, or unprotects, the app. Destroying a protected app,
+// scaling it to zero, or removing its config vars requires a two factor code.
+func (e *Empire) SetProtected(ctx context.Context, opts SetProtectedOpts) error {
+	if err := e.authorize(opts.User, opts.App, ActionAdmin); err != nil {
+		return err
+	}
+
+	if !opts.Protected {
+		if err := e.requireTwoFactor(opts.User, opts.App, opts.TwoFactor); err != nil {
+			return err
+		}
+	}
+
+	opts.App.Protected = opts.Protected
+	return appsUpdate(e.db, opts.App)
+}
@@ -4,6 +4,11 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/remind101/pkg/httpx"
+	"golang.org/x/net/context"
 )
 
 type multiError struct {
@@ -31,13 +36,14 @@ func appendCommitMessage(main, commit string) string {
 
 // RunEvent is triggered when a user starts or stops a one off process.
 type RunEvent struct {
-	User     string
-	App      string
-	Command  Command
-	URL      string
-	Attached bool
-	Message  string
-	Finished bool
+	User      string
+	App       string
+	Command   Command
+	URL       string
+	Attached  bool
+	Message   string
+	Finished  bool
+	RequestID string
 
 	app *App
 }
@@ -46,6 +52,11 @@ func (e RunEvent) Event() string {
 	return "run"
 }
 
+func (e RunEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
 func (e *RunEvent) Finish() {
 	e.Finished = true
 }
@@ -71,12 +82,111 @@ func (e RunEvent) GetApp() *App {
 	return e.app
 }
 
+// ExecEvent is triggered when a user runs a command inside of a running
+// process.
+type ExecEvent struct {
+	User      string
+	App       string
+	PID       string
+	Command   Command
+	Message   string
+	RequestID string
+
+	app *App
+}
+
+func (e ExecEvent) Event() string {
+	return "exec"
+}
+
+func (e ExecEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
+func (e ExecEvent) String() string {
+	msg := fmt.Sprintf("%s ran `%s` in `%s` on %s", e.User, e.Command.String(), e.PID, e.App)
+	return appendCommitMessage(msg, e.Message)
+}
+
+func (e ExecEvent) GetApp() *App {
+	return e.app
+}
+
+// PortForwardEvent is triggered when a user forwards a port to a running
+// process.
+type PortForwardEvent struct {
+	User      string
+	App       string
+	PID       string
+	Port      int
+	Message   string
+	RequestID string
+
+	app *App
+}
+
+func (e PortForwardEvent) Event() string {
+	return "port_forward"
+}
+
+func (e PortForwardEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
+func (e PortForwardEvent) String() string {
+	msg := fmt.Sprintf("%s forwarded port %d on `%s` on %s", e.User, e.Port, e.PID, e.App)
+	return appendCommitMessage(msg, e.Message)
+}
+
+func (e PortForwardEvent) GetApp() *App {
+	return e.app
+}
+
+// CopyEvent is triggered when a user copies files to or from a running
+// process.
+type CopyEvent struct {
+	User      string
+	App       string
+	PID       string
+	Path      string
+	To        bool
+	Message   string
+	RequestID string
+
+	app *App
+}
+
+func (e CopyEvent) Event() string {
+	return "copy"
+}
+
+func (e CopyEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
+func (e CopyEvent) String() string {
+	direction := "from"
+	if e.To {
+		direction = "to"
+	}
+	msg := fmt.Sprintf("%s copied files %s `%s` in `%s` on %s", e.User, direction, e.Path, e.PID, e.App)
+	return appendCommitMessage(msg, e.Message)
+}
+
+func (e CopyEvent) GetApp() *App {
+	return e.app
+}
+
 // RestartEvent is triggered when a user restarts an application.
 type RestartEvent struct {
-	User    string
-	App     string
-	PID     string
-	Message string
+	User      string
+	App       string
+	PID       string
+	Message   string
+	RequestID string
 
 	app *App
 }
@@ -85,6 +195,11 @@ func (e RestartEvent) Event() string {
 	return "restart"
 }
 
+func (e RestartEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
 func (e RestartEvent) String() string {
 	msg := ""
 	if e.PID == "" {
@@ -104,6 +219,7 @@ type MaintenanceEvent struct {
 	App         string
 	Maintenance bool
 	Message     string
+	RequestID   string
 
 	app *App
 }
@@ -112,6 +228,11 @@ func (e MaintenanceEvent) Event() string {
 	return "maintenance"
 }
 
+func (e MaintenanceEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
 func (e MaintenanceEvent) String() string {
 	state := "disabled"
 	if e.Maintenance {
@@ -125,20 +246,55 @@ func (e MaintenanceEvent) GetApp() *App {
 	return e.app
 }
 
+// TrafficEvent is triggered when the traffic to an app is split between its
+// current and previous release.
+type TrafficEvent struct {
+	User                  string
+	App                   string
+	PreviousReleaseWeight int
+	Message               string
+	RequestID             string
+
+	app *App
+}
+
+func (e TrafficEvent) Event() string {
+	return "traffic"
+}
+
+func (e TrafficEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
+func (e TrafficEvent) String() string {
+	msg := fmt.Sprintf("%s sent %d%% of traffic to the current release of %s", e.User, 100-e.PreviousReleaseWeight, e.App)
+	return appendCommitMessage(msg, e.Message)
+}
+
+func (e TrafficEvent) GetApp() *App {
+	return e.app
+}
+
 type ScaleEventUpdate struct {
 	Process             string
 	Quantity            int
 	PreviousQuantity    int
 	Constraints         Constraints
 	PreviousConstraints Constraints
+
+	// If the arguments of the process were changed, the new arguments.
+	Args         *Command
+	PreviousArgs Command
 }
 
 // ScaleEvent is triggered when a manual scaling event happens.
 type ScaleEvent struct {
-	User    string
-	App     string
-	Updates []*ScaleEventUpdate
-	Message string
+	User      string
+	App       string
+	Updates   []*ScaleEventUpdate
+	Message   string
+	RequestID string
 
 	app *App
 }
@@ -147,6 +303,11 @@ func (e ScaleEvent) Event() string {
 	return "scale"
 }
 
+func (e ScaleEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
 func (e ScaleEvent) String() string {
 	var msg, sep string
 	for _, up := range e.Updates {
@@ -176,6 +337,13 @@ func (e ScaleEvent) String() string {
 			up.Quantity,
 			newConstraints,
 		)
+		if up.Args != nil {
+			if len(*up.Args) == 0 {
+				msg += " without arguments"
+			} else {
+				msg += fmt.Sprintf(" with arguments `%s`", *up.Args)
+			}
+		}
 		sep = "\n"
 	}
 	return appendCommitMessage(msg, e.Message)
@@ -194,6 +362,12 @@ type DeployEvent struct {
 	Release     int
 	Message     string
 
+	// The id of the request that triggered the deployment, if known.
+	RequestID string
+
+	// Where the image came from, if it was provided.
+	Provenance Provenance
+
 	app *App
 }
 
@@ -201,6 +375,11 @@ func (e DeployEvent) Event() string {
 	return "deploy"
 }
 
+func (e DeployEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
 func (e DeployEvent) String() string {
 	msg := ""
 	if e.App == "" {
@@ -208,6 +387,9 @@ func (e DeployEvent) String() string {
 	} else {
 		msg = fmt.Sprintf("%s deployed %s to %s %s (v%d)", e.User, e.Image, e.App, e.Environment, e.Release)
 	}
+	if e.Provenance.Commit != "" {
+		msg = fmt.Sprintf("%s from %s", msg, e.Provenance.ShortCommit())
+	}
 	return appendCommitMessage(msg, e.Message)
 }
 
@@ -215,12 +397,108 @@ func (e DeployEvent) GetApp() *App {
 	return e.app
 }
 
+// DeployFailedEvent is triggered when a deploy is aborted, because the processes
+// of the new release didn't become healthy within the deploy timeout of the
+// app.
+type DeployFailedEvent struct {
+	User      string
+	App       string
+	Image     string
+	Release   int
+	Reason    string
+	RequestID string
+
+	app *App
+}
+
+func (e DeployFailedEvent) Event() string {
+	return "deploy_failed"
+}
+
+func (e DeployFailedEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
+func (e DeployFailedEvent) String() string {
+	return fmt.Sprintf("%s's deploy of %s to %s failed: %s", e.User, e.Image, e.App, e.Reason)
+}
+
+func (e DeployFailedEvent) GetApp() *App {
+	return e.app
+}
+
+func (e DeployFailedEvent) IncidentKey() string {
+	return fmt.Sprintf("%s/deploy/v%d", e.App, e.Release)
+}
+
+// CrashLoopEvent is triggered when the instances of a long running process keep
+// stopping, and being replaced by the scheduler.
+type CrashLoopEvent struct {
+	App      string
+	Process  string
+	Release  int
+	Restarts int
+	Window   time.Duration
+
+	app *App
+}
+
+func (e CrashLoopEvent) Event() string {
+	return "crash_loop"
+}
+
+func (e CrashLoopEvent) String() string {
+	return fmt.Sprintf("`%s` on %s (v%d) is crash looping: %d instances were replaced in the last %v", e.Process, e.App, e.Release, e.Restarts, e.Window)
+}
+
+func (e CrashLoopEvent) GetApp() *App {
+	return e.app
+}
+
+func (e CrashLoopEvent) IncidentKey() string {
+	return fmt.Sprintf("%s/crash_loop/%s/v%d", e.App, e.Process, e.Release)
+}
+
+// ReconcileFailedEvent is triggered when Empire can't restore the desired state
+// of an app, like when the processes on a lost host can't be stopped, or a
+// failed deploy can't be rolled back.
+type ReconcileFailedEvent struct {
+	App       string
+	Reason    string
+	RequestID string
+
+	app *App
+}
+
+func (e ReconcileFailedEvent) Event() string {
+	return "reconcile_failed"
+}
+
+func (e ReconcileFailedEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
+func (e ReconcileFailedEvent) String() string {
+	return fmt.Sprintf("Couldn't restore the desired state of %s: %s", e.App, e.Reason)
+}
+
+func (e ReconcileFailedEvent) GetApp() *App {
+	return e.app
+}
+
+func (e ReconcileFailedEvent) IncidentKey() string {
+	return fmt.Sprintf("%s/reconcile", e.App)
+}
+
 // RollbackEvent is triggered when a user rolls back to an old version.
 type RollbackEvent struct {
-	User    string
-	App     string
-	Version int
-	Message string
+	User      string
+	App       string
+	Version   int
+	Message   string
+	RequestID string
 
 	app *App
 }
@@ -229,6 +507,11 @@ func (e RollbackEvent) Event() string {
 	return "rollback"
 }
 
+func (e RollbackEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
 func (e RollbackEvent) String() string {
 	msg := fmt.Sprintf("%s rolled back %s to v%d", e.User, e.App, e.Version)
 	return appendCommitMessage(msg, e.Message)
@@ -241,10 +524,11 @@ func (e RollbackEvent) GetApp() *App {
 // SetEvent is triggered when environment variables are changed on an
 // application.
 type SetEvent struct {
-	User    string
-	App     string
-	Changed []string
-	Message string
+	User      string
+	App       string
+	Changed   []string
+	Message   string
+	RequestID string
 
 	app *App
 }
@@ -253,6 +537,11 @@ func (e SetEvent) Event() string {
 	return "set"
 }
 
+func (e SetEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
 func (e SetEvent) String() string {
 	msg := fmt.Sprintf("%s changed environment variables on %s (%s)", e.User, e.App, strings.Join(e.Changed, ", "))
 	return appendCommitMessage(msg, e.Message)
@@ -267,12 +556,19 @@ type CreateEvent struct {
 	User    string
 	Name    string
 	Message string
+
+	RequestID string
 }
 
 func (e CreateEvent) Event() string {
 	return "create"
 }
 
+func (e CreateEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
 func (e CreateEvent) String() string {
 	msg := fmt.Sprintf("%s created %s", e.User, e.Name)
 	return appendCommitMessage(msg, e.Message)
@@ -283,17 +579,340 @@ type DestroyEvent struct {
 	User    string
 	App     string
 	Message string
+
+	RequestID string
 }
 
 func (e DestroyEvent) Event() string {
 	return "destroy"
 }
 
+func (e DestroyEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
 func (e DestroyEvent) String() string {
 	msg := fmt.Sprintf("%s destroyed %s", e.User, e.App)
 	return appendCommitMessage(msg, e.Message)
 }
 
+// RescheduleEvent is triggered when Empire stops a process that was running on
+// a lost host, so that it can be replaced on a healthy host.
+type RescheduleEvent struct {
+	App  string
+	PID  string
+	Host string
+
+	app *App
+}
+
+func (e RescheduleEvent) Event() string {
+	return "reschedule"
+}
+
+func (e RescheduleEvent) String() string {
+	return fmt.Sprintf("Rescheduled `%s` on %s, because host %s was lost", e.PID, e.App, e.Host)
+}
+
+func (e RescheduleEvent) GetApp() *App {
+	return e.app
+}
+
+// ChaosKillEvent is triggered when the ChaosMonkey kills an instance of an app
+// that has opted in to chaos testing.
+type ChaosKillEvent struct {
+	App string
+	PID string
+
+	app *App
+}
+
+func (e ChaosKillEvent) Event() string {
+	return "chaos_kill"
+}
+
+func (e ChaosKillEvent) String() string {
+	return fmt.Sprintf("Chaos killed `%s` on %s", e.PID, e.App)
+}
+
+func (e ChaosKillEvent) GetApp() *App {
+	return e.app
+}
+
+// ChaosRecoveryEvent is triggered when a process has recovered from an
+// instance being killed by the ChaosMonkey.
+type ChaosRecoveryEvent struct {
+	App          string
+	PID          string
+	Process      string
+	RecoveryTime time.Duration
+
+	app *App
+}
+
+func (e ChaosRecoveryEvent) Event() string {
+	return "chaos_recovery"
+}
+
+func (e ChaosRecoveryEvent) String() string {
+	return fmt.Sprintf("%s on %s recovered from chaos killing `%s` in %v", e.Process, e.App, e.PID, e.RecoveryTime)
+}
+
+func (e ChaosRecoveryEvent) GetApp() *App {
+	return e.app
+}
+
+// ZoneRebalanceEvent is triggered when Empire stops a process in an
+// availability zone that has more than its share of the process's instances,
+// so that it can be replaced in another zone.
+type ZoneRebalanceEvent struct {
+	App     string
+	PID     string
+	Process string
+	Zone    string
+
+	app *App
+}
+
+func (e ZoneRebalanceEvent) Event() string {
+	return "zone_rebalance"
+}
+
+func (e ZoneRebalanceEvent) String() string {
+	return fmt.Sprintf("Rescheduled `%s` on %s, because %s has more than its share of %s processes", e.PID, e.App, e.Zone, e.Process)
+}
+
+func (e ZoneRebalanceEvent) GetApp() *App {
+	return e.app
+}
+
+// SpotInterruptionEvent is triggered when a process is running on spot
+// capacity that's being reclaimed, and will be replaced on another host.
+type SpotInterruptionEvent struct {
+	App  string
+	PID  string
+	Host string
+
+	app *App
+}
+
+func (e SpotInterruptionEvent) Event() string {
+	return "spot_interruption"
+}
+
+func (e SpotInterruptionEvent) String() string {
+	return fmt.Sprintf("`%s` on %s was interrupted, because spot host %s is being reclaimed", e.PID, e.App, e.Host)
+}
+
+func (e SpotInterruptionEvent) GetApp() *App {
+	return e.app
+}
+
+// CapacityFallbackEvent is triggered when Empire moves the spot processes of
+// an app to on-demand capacity, because no spot capacity is available, or back
+// to spot capacity, once it's available again.
+type CapacityFallbackEvent struct {
+	App       string
+	Processes []string
+
+	// True when the processes were moved back to spot capacity.
+	Spot bool
+
+	app *App
+}
+
+func (e CapacityFallbackEvent) Event() string {
+	return "capacity_fallback"
+}
+
+func (e CapacityFallbackEvent) String() string {
+	processes := strings.Join(e.Processes, ", ")
+	if e.Spot {
+		return fmt.Sprintf("Moved %s on %s back to spot capacity", processes, e.App)
+	}
+	return fmt.Sprintf("Moved %s on %s to on-demand capacity, because no spot capacity is available", processes, e.App)
+}
+
+func (e CapacityFallbackEvent) GetApp() *App {
+	return e.app
+}
+
+// RunTimeoutEvent is triggered when a one-off process is killed because it ran
+// for longer than its timeout.
+type RunTimeoutEvent struct {
+	App     string
+	PID     string
+	Command Command
+	Timeout time.Duration
+
+	app *App
+}
+
+func (e RunTimeoutEvent) Event() string {
+	return "run_timeout"
+}
+
+func (e RunTimeoutEvent) String() string {
+	return fmt.Sprintf("Killed `%s` (`%s`) on %s, because it ran for longer than %v", e.PID, e.Command.String(), e.App, e.Timeout)
+}
+
+func (e RunTimeoutEvent) GetApp() *App {
+	return e.app
+}
+
+// PreemptEvent is triggered when Empire stops a low priority one-off process to
+// make room for a high priority process, and queues it to run again.
+type PreemptEvent struct {
+	App       string
+	PID       string
+	Job       string
+	Reason    string
+	RequestID string
+
+	app *App
+}
+
+func (e PreemptEvent) Event() string {
+	return "preempt"
+}
+
+func (e PreemptEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
+func (e PreemptEvent) String() string {
+	return fmt.Sprintf("Preempted `%s` on %s %s, and queued it to run again", e.PID, e.App, e.Reason)
+}
+
+func (e PreemptEvent) GetApp() *App {
+	return e.app
+}
+
+// BatchEvent is triggered when a user submits a batch of jobs.
+type BatchEvent struct {
+	User        string
+	App         string
+	Jobs        int
+	Parallelism int
+	Message     string
+	RequestID   string
+
+	app *App
+}
+
+func (e BatchEvent) Event() string {
+	return "batch"
+}
+
+func (e BatchEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
+func (e BatchEvent) String() string {
+	msg := fmt.Sprintf("%s queued %d jobs on %s, running %d at a time", e.User, e.Jobs, e.App, e.Parallelism)
+	return appendCommitMessage(msg, e.Message)
+}
+
+func (e BatchEvent) GetApp() *App {
+	return e.app
+}
+
+// CronTriggerEvent is triggered when a user triggers a scheduled process
+// manually.
+type CronTriggerEvent struct {
+	User      string
+	App       string
+	Process   string
+	Message   string
+	RequestID string
+
+	app *App
+}
+
+func (e CronTriggerEvent) Event() string {
+	return "cron_trigger"
+}
+
+func (e CronTriggerEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
+func (e CronTriggerEvent) String() string {
+	msg := fmt.Sprintf("%s triggered `%s` on %s", e.User, e.Process, e.App)
+	return appendCommitMessage(msg, e.Message)
+}
+
+func (e CronTriggerEvent) GetApp() *App {
+	return e.app
+}
+
+// HostEvent is triggered when an operator cordons, uncordons or drains a host.
+type HostEvent struct {
+	User    string
+	Host    string
+	Action  string
+	Message string
+
+	RequestID string
+}
+
+func (e HostEvent) Event() string {
+	return e.Action
+}
+
+func (e HostEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
+func (e HostEvent) String() string {
+	verbs := map[string]string{
+		"cordon":   "cordoned",
+		"uncordon": "uncordoned",
+		"drain":    "drained",
+	}
+	msg := fmt.Sprintf("%s %s host %s", e.User, verbs[e.Action], e.Host)
+	return appendCommitMessage(msg, e.Message)
+}
+
+// FreezeOverrideEvent is triggered when a user releases an application during a
+// freeze window, by providing an override reason.
+type FreezeOverrideEvent struct {
+	User      string
+	App       string
+	Operation string
+	Reason    string
+	Freeze    string
+	RequestID string
+
+	app *App
+}
+
+func (e FreezeOverrideEvent) Event() string {
+	return "freeze_override"
+}
+
+func (e FreezeOverrideEvent) withRequestID(id string) Event {
+	e.RequestID = id
+	return e
+}
+
+func (e FreezeOverrideEvent) String() string {
+	msg := fmt.Sprintf("%s overrode the release freeze to %s %s", e.User, e.Operation, e.App)
+	if e.Freeze != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, e.Freeze)
+	}
+	return appendCommitMessage(msg, e.Reason)
+}
+
+func (e FreezeOverrideEvent) GetApp() *App {
+	return e.app
+}
+
 // Event represents an event triggered within Empire.
 type Event interface {
 	// Returns the name of the event.
@@ -303,12 +922,41 @@ type Event interface {
 	String() string
 }
 
+// requestEvent is an Event that can be caused by an API request. The id of the
+// request is added to it when it's published, so that the event can be
+// correlated with the logs and errors of the request.
+type requestEvent interface {
+	Event
+	withRequestID(id string) Event
+}
+
+// publishEvent publishes the event, along with the id of the API request in the
+// context, if there is one.
+func (e *Empire) publishEvent(ctx context.Context, event Event) error {
+	if id := httpx.RequestID(ctx); id != "" {
+		if re, ok := event.(requestEvent); ok {
+			event = re.withRequestID(id)
+		}
+	}
+	return e.PublishEvent(event)
+}
+
 // AppEvent is an Event that relates to a specific App.
 type AppEvent interface {
 	Event
 	GetApp() *App
 }
 
+// IncidentEvent is an AppEvent that needs the attention of whoever is on call
+// for the app, like a failed deploy.
+type IncidentEvent interface {
+	AppEvent
+
+	// Returns a key that identifies the problem, so that repeated events
+	// about the same problem are grouped into a single incident.
+	IncidentKey() string
+}
+
 // EventStream is an interface for publishing events that happen within
 // Empire.
 type EventStream interface {
@@ -343,6 +991,34 @@ func (streams MultiEventStream) PublishEvent(e Event) error {
 	return result
 }
 
+// SwitchableEventStream is an EventStream that publishes events to another
+// EventStream, which can be switched while events are being published (e.g.
+// when the notification targets in the operator configuration are reloaded).
+type SwitchableEventStream struct {
+	mu sync.RWMutex
+	e  EventStream
+}
+
+// NewSwitchableEventStream returns a new SwitchableEventStream that publishes
+// events to e.
+func NewSwitchableEventStream(e EventStream) *SwitchableEventStream {
+	return &SwitchableEventStream{e: e}
+}
+
+// Switch publishes events to e from now on.
+func (s *SwitchableEventStream) Switch(e EventStream) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.e = e
+}
+
+func (s *SwitchableEventStream) PublishEvent(event Event) error {
+	s.mu.RLock()
+	e := s.e
+	s.mu.RUnlock()
+	return e.PublishEvent(event)
+}
+
 // asyncEventStream wraps an array of EventStreams to publish events
 // asynchronously in a goroutine
 type asyncEventStream struct {
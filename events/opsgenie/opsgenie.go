@@ -0,0 +1,132 @@
+// Package opsgenie provides an empire.EventStream implementation that opens
+// Opsgenie alerts for events that need the attention of whoever is on call for
+// an app, like failed deploys and crash loops.
+//
+// Alerts are created through the Alert API, with the API key of the app, or the
+// default API key when the app doesn't have one. Since the API key belongs to
+// an integration, which is owned by a team, the key decides who's notified.
+// Events about the same problem share an alias, so that they're grouped into a
+// single alert.
+package opsgenie
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/remind101/empire"
+)
+
+// DefaultURL is the url of the Opsgenie API. Accounts in the EU use
+// https://api.eu.opsgenie.com.
+const DefaultURL = "https://api.opsgenie.com"
+
+// maxMessage is the maximum length of the message of an alert.
+const maxMessage = 130
+
+// EventStream is an implementation of the empire.EventStream interface that
+// creates Opsgenie alerts.
+type EventStream struct {
+	// The API key that's used for apps that don't have their own.
+	APIKey string
+
+	// The source of alerts, like the environment that Empire manages. The
+	// zero value is "empire".
+	Source string
+
+	// The url of the Opsgenie API. The zero value is DefaultURL.
+	URL string
+
+	client *http.Client
+}
+
+// NewEventStream returns a new EventStream that creates alerts with the given
+// API key by default.
+func NewEventStream(apiKey string) *EventStream {
+	return &EventStream{
+		APIKey: apiKey,
+		client: http.DefaultClient,
+	}
+}
+
+// PublishEvent implements the empire.EventStream interface. Events that aren't
+// an empire.IncidentEvent are ignored.
+func (s *EventStream) PublishEvent(event empire.Event) error {
+	e, ok := event.(empire.IncidentEvent)
+	if !ok {
+		return nil
+	}
+
+	key := s.APIKey
+	if app := e.GetApp(); app != nil && app.AlertRoutingKey != "" {
+		key = app.AlertRoutingKey
+	}
+	if key == "" {
+		return nil
+	}
+
+	source := s.Source
+	if source == "" {
+		source = "empire"
+	}
+
+	a := &alert{
+		Message:     e.String(),
+		Alias:       e.IncidentKey(),
+		Description: e.String(),
+		Source:      source,
+		Tags:        []string{"empire", e.Event()},
+		Details:     map[string]string{"event": e.Event()},
+		Priority:    "P2",
+	}
+	if len(a.Message) > maxMessage {
+		a.Message = a.Message[:maxMessage-3] + "..."
+	}
+	if app := e.GetApp(); app != nil {
+		a.Entity = app.Name
+		a.Details["app"] = app.Name
+	}
+
+	raw, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	url := s.URL
+	if url == "" {
+		url = DefaultURL
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/v2/alerts", strings.TrimSuffix(url, "/")), bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("GenieKey %s", key))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("opsgenie: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("opsgenie: unexpected response status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// alert is the body of a request to create an alert.
+type alert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description"`
+	Source      string            `json:"source"`
+	Entity      string            `json:"entity,omitempty"`
+	Tags        []string          `json:"tags"`
+	Details     map[string]string `json:"details"`
+	Priority    string            `json:"priority"`
+}
@@ -0,0 +1,79 @@
+package opsgenie
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/remind101/empire"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventStream_PublishEvent(t *testing.T) {
+	var (
+		auth string
+		body alert
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/alerts", r.URL.Path)
+		auth = r.Header.Get("Authorization")
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(202)
+	}))
+	defer s.Close()
+
+	e := NewEventStream("default")
+	e.URL = s.URL
+	e.Source = "production"
+
+	err := e.PublishEvent(fakeEvent{app: &empire.App{Name: "acme-inc", AlertRoutingKey: "acme"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "GenieKey acme", auth)
+	assert.Equal(t, alert{
+		Message:     "acme-inc is broken",
+		Alias:       "acme-inc/fake",
+		Description: "acme-inc is broken",
+		Source:      "production",
+		Entity:      "acme-inc",
+		Tags:        []string{"empire", "fake"},
+		Details:     map[string]string{"event": "fake", "app": "acme-inc"},
+		Priority:    "P2",
+	}, body)
+
+	// Apps without a key use the default, and long messages are
+	// truncated.
+	name := strings.Repeat("a", 200)
+	err = e.PublishEvent(fakeEvent{app: &empire.App{Name: name}})
+	assert.NoError(t, err)
+	assert.Equal(t, "GenieKey default", auth)
+	assert.Equal(t, maxMessage, len(body.Message))
+	assert.True(t, strings.HasSuffix(body.Message, "..."))
+}
+
+func TestEventStream_PublishEvent_Ignored(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("unexpected request")
+	}))
+	defer s.Close()
+
+	e := NewEventStream("")
+	e.URL = s.URL
+
+	// Events that aren't incidents.
+	assert.NoError(t, e.PublishEvent(empire.CreateEvent{User: "ejholmes", Name: "acme-inc"}))
+
+	// No API key.
+	assert.NoError(t, e.PublishEvent(fakeEvent{app: &empire.App{Name: "acme-inc"}}))
+}
+
+type fakeEvent struct {
+	app *empire.App
+}
+
+func (e fakeEvent) Event() string       { return "fake" }
+func (e fakeEvent) String() string      { return fmt.Sprintf("%s is broken", e.app.Name) }
+func (e fakeEvent) GetApp() *empire.App { return e.app }
+func (e fakeEvent) IncidentKey() string { return fmt.Sprintf("%s/fake", e.app.Name) }
@@ -0,0 +1,130 @@
+// Package pagerduty provides an empire.EventStream implementation that opens
+// PagerDuty incidents for events that need the attention of whoever is on call
+// for an app, like failed deploys and crash loops.
+//
+// Incidents are triggered through the Events API v2, with the routing key of
+// the app, or the default routing key when the app doesn't have one. Events
+// about the same problem share a dedup key, so that they're grouped into a
+// single incident.
+package pagerduty
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/remind101/empire"
+)
+
+// DefaultURL is the url of the PagerDuty Events API v2.
+const DefaultURL = "https://events.pagerduty.com/v2/enqueue"
+
+// EventStream is an implementation of the empire.EventStream interface that
+// triggers PagerDuty incidents.
+type EventStream struct {
+	// The routing key that's used for apps that don't have their own.
+	RoutingKey string
+
+	// The source of incidents, like the environment that Empire manages.
+	// The zero value is "empire".
+	Source string
+
+	// The url of the Events API. The zero value is DefaultURL.
+	URL string
+
+	client *http.Client
+}
+
+// NewEventStream returns a new EventStream that routes incidents with the
+// given routing key by default.
+func NewEventStream(routingKey string) *EventStream {
+	return &EventStream{
+		RoutingKey: routingKey,
+		client:     http.DefaultClient,
+	}
+}
+
+// PublishEvent implements the empire.EventStream interface. Events that aren't
+// an empire.IncidentEvent are ignored.
+func (s *EventStream) PublishEvent(event empire.Event) error {
+	e, ok := event.(empire.IncidentEvent)
+	if !ok {
+		return nil
+	}
+
+	key := s.RoutingKey
+	if app := e.GetApp(); app != nil && app.AlertRoutingKey != "" {
+		key = app.AlertRoutingKey
+	}
+	if key == "" {
+		return nil
+	}
+
+	source := s.Source
+	if source == "" {
+		source = "empire"
+	}
+
+	component := ""
+	if app := e.GetApp(); app != nil {
+		component = app.Name
+	}
+
+	raw, err := json.Marshal(&trigger{
+		RoutingKey:  key,
+		EventAction: "trigger",
+		DedupKey:    e.IncidentKey(),
+		Payload: payload{
+			Summary:       e.String(),
+			Source:        source,
+			Severity:      "error",
+			Component:     component,
+			Class:         e.Event(),
+			CustomDetails: e,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	url := s.URL
+	if url == "" {
+		url = DefaultURL
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("pagerduty: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("pagerduty: unexpected response status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// trigger is the body of a request to trigger an incident.
+type trigger struct {
+	RoutingKey  string  `json:"routing_key"`
+	EventAction string  `json:"event_action"`
+	DedupKey    string  `json:"dedup_key"`
+	Payload     payload `json:"payload"`
+}
+
+type payload struct {
+	Summary       string      `json:"summary"`
+	Source        string      `json:"source"`
+	Severity      string      `json:"severity"`
+	Component     string      `json:"component,omitempty"`
+	Class         string      `json:"class"`
+	CustomDetails interface{} `json:"custom_details"`
+}
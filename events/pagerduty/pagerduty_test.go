@@ -0,0 +1,85 @@
+package pagerduty
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/remind101/empire"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventStream_PublishEvent(t *testing.T) {
+	var body map[string]interface{}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(202)
+	}))
+	defer s.Close()
+
+	e := NewEventStream("default")
+	e.URL = s.URL
+	e.Source = "production"
+
+	err := e.PublishEvent(fakeEvent{app: &empire.App{Name: "acme-inc", AlertRoutingKey: "acme"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", body["routing_key"])
+	assert.Equal(t, "trigger", body["event_action"])
+	assert.Equal(t, "acme-inc/fake", body["dedup_key"])
+	assert.Equal(t, map[string]interface{}{
+		"summary":        "acme-inc is broken",
+		"source":         "production",
+		"severity":       "error",
+		"component":      "acme-inc",
+		"class":          "fake",
+		"custom_details": map[string]interface{}{"Reason": "boom"},
+	}, body["payload"])
+
+	// Apps without a routing key use the default.
+	err = e.PublishEvent(fakeEvent{app: &empire.App{Name: "acme-inc"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "default", body["routing_key"])
+}
+
+func TestEventStream_PublishEvent_Ignored(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("unexpected request")
+	}))
+	defer s.Close()
+
+	e := NewEventStream("")
+	e.URL = s.URL
+
+	// Events that aren't incidents.
+	assert.NoError(t, e.PublishEvent(empire.CreateEvent{User: "ejholmes", Name: "acme-inc"}))
+
+	// No routing key.
+	assert.NoError(t, e.PublishEvent(fakeEvent{app: &empire.App{Name: "acme-inc"}}))
+}
+
+func TestEventStream_PublishEvent_Error(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+	}))
+	defer s.Close()
+
+	e := NewEventStream("default")
+	e.URL = s.URL
+
+	err := e.PublishEvent(fakeEvent{app: &empire.App{Name: "acme-inc"}})
+	assert.EqualError(t, err, "pagerduty: unexpected response status 400")
+}
+
+type fakeEvent struct {
+	app *empire.App
+}
+
+func (e fakeEvent) Event() string       { return "fake" }
+func (e fakeEvent) String() string      { return fmt.Sprintf("%s is broken", e.app.Name) }
+func (e fakeEvent) GetApp() *empire.App { return e.app }
+func (e fakeEvent) IncidentKey() string { return fmt.Sprintf("%s/fake", e.app.Name) }
+func (e fakeEvent) MarshalJSON() ([]byte, error) {
+	return []byte(`{"Reason":"boom"}`), nil
+}
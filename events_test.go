@@ -3,8 +3,10 @@ package empire
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
 )
 
 func TestMultiEventStream(t *testing.T) {
@@ -17,6 +19,25 @@ func TestMultiEventStream(t *testing.T) {
 
 }
 
+func TestEmpire_publishEvent(t *testing.T) {
+	var events []Event
+	e := &Empire{EventStream: EventStreamFunc(func(event Event) error {
+		events = append(events, event)
+		return nil
+	})}
+
+	ctx := context.WithValue(context.Background(), "http.request.id", "abcd")
+	assert.NoError(t, e.publishEvent(ctx, SetEvent{User: "ejholmes", App: "acme-inc"}))
+	assert.NoError(t, e.publishEvent(context.Background(), SetEvent{User: "ejholmes", App: "acme-inc"}))
+	assert.NoError(t, e.publishEvent(ctx, CrashLoopEvent{App: "acme-inc"}))
+
+	assert.Equal(t, []Event{
+		SetEvent{User: "ejholmes", App: "acme-inc", RequestID: "abcd"},
+		SetEvent{User: "ejholmes", App: "acme-inc"},
+		CrashLoopEvent{App: "acme-inc"},
+	}, events)
+}
+
 func TestEvents_String(t *testing.T) {
 	tests := []struct {
 		event Event
@@ -31,6 +52,58 @@ func TestEvents_String(t *testing.T) {
 		{RunEvent{User: "ejholmes", App: "acme-inc", Attached: true, Command: []string{"bash"}, Message: "commit message"}, "ejholmes started running `bash` (attached) on acme-inc: 'commit message'"},
 		{RunEvent{User: "ejholmes", App: "acme-inc", URL: "https://console.aws.amazon.com/cloudwatch/home?region=us-east-1#logEvent:group=runs;stream=dac6eaff-6e0b-4708-9277-9f38aea2f528", Attached: true, Command: []string{"bash"}, Message: "commit message"}, "ejholmes started running `bash` (attached) on acme-inc (<https://console.aws.amazon.com/cloudwatch/home?region=us-east-1#logEvent:group=runs;stream=dac6eaff-6e0b-4708-9277-9f38aea2f528|logs>): 'commit message'"},
 
+		// FreezeOverrideEvent
+		{FreezeOverrideEvent{User: "ejholmes", App: "acme-inc", Operation: "deploy", Reason: "hotfix for outage"}, "ejholmes overrode the release freeze to deploy acme-inc: 'hotfix for outage'"},
+		{FreezeOverrideEvent{User: "ejholmes", App: "acme-inc", Operation: "deploy", Reason: "hotfix for outage", Freeze: "Holiday freeze"}, "ejholmes overrode the release freeze to deploy acme-inc (Holiday freeze): 'hotfix for outage'"},
+
+		// ExecEvent
+		{ExecEvent{User: "ejholmes", App: "acme-inc", PID: "abcd", Command: []string{"bash"}}, "ejholmes ran `bash` in `abcd` on acme-inc"},
+		{ExecEvent{User: "ejholmes", App: "acme-inc", PID: "abcd", Command: []string{"bash"}, Message: "commit message"}, "ejholmes ran `bash` in `abcd` on acme-inc: 'commit message'"},
+
+		// PortForwardEvent
+		{PortForwardEvent{User: "ejholmes", App: "acme-inc", PID: "abcd", Port: 6060}, "ejholmes forwarded port 6060 on `abcd` on acme-inc"},
+
+		// CopyEvent
+		{CopyEvent{User: "ejholmes", App: "acme-inc", PID: "abcd", Path: "/tmp/heap.hprof"}, "ejholmes copied files from `/tmp/heap.hprof` in `abcd` on acme-inc"},
+		{CopyEvent{User: "ejholmes", App: "acme-inc", PID: "abcd", Path: "/tmp", To: true, Message: "commit message"}, "ejholmes copied files to `/tmp` in `abcd` on acme-inc: 'commit message'"},
+
+		// RescheduleEvent
+		{RescheduleEvent{App: "acme-inc", PID: "v1.web.abcd", Host: "i-042f39dc"}, "Rescheduled `v1.web.abcd` on acme-inc, because host i-042f39dc was lost"},
+
+		// ChaosKillEvent
+		{ChaosKillEvent{App: "acme-inc", PID: "v1.web.abcd"}, "Chaos killed `v1.web.abcd` on acme-inc"},
+
+		// ChaosRecoveryEvent
+		{ChaosRecoveryEvent{App: "acme-inc", PID: "v1.web.abcd", Process: "web", RecoveryTime: 90 * time.Second}, "web on acme-inc recovered from chaos killing `v1.web.abcd` in 1m30s"},
+
+		// ZoneRebalanceEvent
+		{ZoneRebalanceEvent{App: "acme-inc", PID: "v1.web.abcd", Process: "web", Zone: "us-east-1a"}, "Rescheduled `v1.web.abcd` on acme-inc, because us-east-1a has more than its share of web processes"},
+
+		// RunTimeoutEvent
+		{RunTimeoutEvent{App: "acme-inc", PID: "v1.run.abcd", Command: Command{"rake", "db:migrate"}, Timeout: time.Hour}, "Killed `v1.run.abcd` (`rake db:migrate`) on acme-inc, because it ran for longer than 1h0m0s"},
+
+		// SpotInterruptionEvent
+		{SpotInterruptionEvent{App: "acme-inc", PID: "v1.worker.abcd", Host: "i-042f39dc"}, "`v1.worker.abcd` on acme-inc was interrupted, because spot host i-042f39dc is being reclaimed"},
+
+		// CapacityFallbackEvent
+		{CapacityFallbackEvent{App: "acme-inc", Processes: []string{"scheduler", "worker"}}, "Moved scheduler, worker on acme-inc to on-demand capacity, because no spot capacity is available"},
+		{CapacityFallbackEvent{App: "acme-inc", Processes: []string{"worker"}, Spot: true}, "Moved worker on acme-inc back to spot capacity"},
+
+		// PreemptEvent
+		{PreemptEvent{App: "batch", PID: "v3.report.abcd", Job: "1234", Reason: "to make room for acme-inc web"}, "Preempted `v3.report.abcd` on batch to make room for acme-inc web, and queued it to run again"},
+
+		// BatchEvent
+		{BatchEvent{User: "ejholmes", App: "acme-inc", Jobs: 20, Parallelism: 5}, "ejholmes queued 20 jobs on acme-inc, running 5 at a time"},
+		{BatchEvent{User: "ejholmes", App: "acme-inc", Jobs: 20, Parallelism: 5, Message: "backfill"}, "ejholmes queued 20 jobs on acme-inc, running 5 at a time: 'backfill'"},
+
+		// CronTriggerEvent
+		{CronTriggerEvent{User: "ejholmes", App: "acme-inc", Process: "vacuum"}, "ejholmes triggered `vacuum` on acme-inc"},
+		{CronTriggerEvent{User: "ejholmes", App: "acme-inc", Process: "vacuum", Message: "bloat"}, "ejholmes triggered `vacuum` on acme-inc: 'bloat'"},
+
+		// HostEvent
+		{HostEvent{User: "ejholmes", Host: "i-042f39dc", Action: "cordon"}, "ejholmes cordoned host i-042f39dc"},
+		{HostEvent{User: "ejholmes", Host: "i-042f39dc", Action: "drain", Message: "kernel upgrade"}, "ejholmes drained host i-042f39dc: 'kernel upgrade'"},
+
 		// RestartEvent
 		{RestartEvent{User: "ejholmes", App: "acme-inc"}, "ejholmes restarted acme-inc"},
 		{RestartEvent{User: "ejholmes", App: "acme-inc", PID: "abcd"}, "ejholmes restarted `abcd` on acme-inc"},
@@ -42,6 +115,10 @@ func TestEvents_String(t *testing.T) {
 		{MaintenanceEvent{User: "ejholmes", App: "acme-inc", Maintenance: true}, "ejholmes enabled maintenance mode on acme-inc"},
 		{MaintenanceEvent{User: "ejholmes", App: "acme-inc", Maintenance: true, Message: "upgrading db"}, "ejholmes enabled maintenance mode on acme-inc: 'upgrading db'"},
 
+		// TrafficEvent
+		{TrafficEvent{User: "ejholmes", App: "acme-inc", PreviousReleaseWeight: 90}, "ejholmes sent 10% of traffic to the current release of acme-inc"},
+		{TrafficEvent{User: "ejholmes", App: "acme-inc", PreviousReleaseWeight: 0, Message: "looks good"}, "ejholmes sent 100% of traffic to the current release of acme-inc: 'looks good'"},
+
 		// ScaleEvent
 		{ScaleEvent{
 			User: "ejholmes",
@@ -103,12 +180,36 @@ func TestEvents_String(t *testing.T) {
 			},
 			Message: "commit message",
 		}, "ejholmes scaled `web` on acme-inc from 5(512:1.00kb) to 10(512:1.00kb): 'commit message'"},
+		{ScaleEvent{
+			User: "ejholmes",
+			App:  "acme-inc",
+			Updates: []*ScaleEventUpdate{
+				&ScaleEventUpdate{Process: "worker", Quantity: 5, PreviousQuantity: 5, PreviousConstraints: Constraints{CPUShare: 512, Memory: 1024}, Args: &Command{"--threads=8"}},
+			},
+		}, "ejholmes scaled `worker` on acme-inc from 5(512:1.00kb) to 5(512:1.00kb) with arguments `--threads=8`"},
+		{ScaleEvent{
+			User: "ejholmes",
+			App:  "acme-inc",
+			Updates: []*ScaleEventUpdate{
+				&ScaleEventUpdate{Process: "worker", Quantity: 5, PreviousQuantity: 5, PreviousConstraints: Constraints{CPUShare: 512, Memory: 1024}, Args: &Command{}, PreviousArgs: Command{"--threads=8"}},
+			},
+		}, "ejholmes scaled `worker` on acme-inc from 5(512:1.00kb) to 5(512:1.00kb) without arguments"},
 
 		// DeployEvent
 		{DeployEvent{User: "ejholmes", App: "acme-inc", Image: "remind101/acme-inc:master", Environment: "production", Release: 32}, "ejholmes deployed remind101/acme-inc:master to acme-inc production (v32)"},
 		{DeployEvent{User: "ejholmes", Image: "remind101/acme-inc:master"}, "ejholmes deployed remind101/acme-inc:master"},
 		{DeployEvent{User: "ejholmes", App: "acme-inc", Image: "remind101/acme-inc:master", Environment: "production", Release: 32, Message: "commit message"}, "ejholmes deployed remind101/acme-inc:master to acme-inc production (v32): 'commit message'"},
 		{DeployEvent{User: "ejholmes", Image: "remind101/acme-inc:master", Message: "commit message"}, "ejholmes deployed remind101/acme-inc:master: 'commit message'"},
+		{DeployEvent{User: "ejholmes", App: "acme-inc", Image: "remind101/acme-inc:master", Environment: "production", Release: 32, Provenance: Provenance{Commit: "c6f77d2098bc0e32aef3102e71b51831a9083dd9"}}, "ejholmes deployed remind101/acme-inc:master to acme-inc production (v32) from c6f77d2"},
+
+		// DeployFailedEvent
+		{DeployFailedEvent{User: "ejholmes", App: "acme-inc", Image: "remind101/acme-inc:master", Release: 32, Reason: "v32 of acme-inc didn't become healthy within 10m0s, restored v31 as v33"}, "ejholmes's deploy of remind101/acme-inc:master to acme-inc failed: v32 of acme-inc didn't become healthy within 10m0s, restored v31 as v33"},
+
+		// CrashLoopEvent
+		{CrashLoopEvent{App: "acme-inc", Process: "web", Release: 32, Restarts: 3, Window: 10 * time.Minute}, "`web` on acme-inc (v32) is crash looping: 3 instances were replaced in the last 10m0s"},
+
+		// ReconcileFailedEvent
+		{ReconcileFailedEvent{App: "acme-inc", Reason: "stopping v1.web.abcd on lost host i-042f39dc: throttled"}, "Couldn't restore the desired state of acme-inc: stopping v1.web.abcd on lost host i-042f39dc: throttled"},
 
 		// RollbackEvent
 		{RollbackEvent{User: "ejholmes", App: "acme-inc", Version: 1}, "ejholmes rolled back acme-inc to v1"},
@@ -0,0 +1,227 @@
+package empire
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/timex"
+	"golang.org/x/net/context"
+)
+
+// ErrFreezeWindowRange is returned when a FreezeWindow ends before it starts.
+var ErrFreezeWindowRange = &ValidationError{
+	errors.New("A freeze window must end after it starts."),
+}
+
+// FreezeWindow represents a period of time where new releases are rejected,
+// unless the user provides a reason to override the freeze. Freeze windows can
+// be global, scoped to a team, or scoped to a single app.
+//
+// Freeze windows apply to operations that create new releases (deploys,
+// rollbacks and config changes). Scaling and restarting are still allowed, so
+// that incidents can be responded to during a freeze.
+type FreezeWindow struct {
+	// A unique uuid that identifies the freeze window.
+	ID string
+
+	// The time that the freeze starts.
+	StartsAt time.Time
+
+	// The time that the freeze ends.
+	EndsAt time.Time
+
+	// The reason for the freeze (e.g. "Holiday change freeze").
+	Reason string
+
+	// If provided, the team that this freeze is scoped to.
+	Team string
+
+	// If provided, the id of the app that this freeze is scoped to.
+	AppID *string
+
+	// The user that created the freeze.
+	CreatedBy string
+
+	// The time that the freeze window was created.
+	CreatedAt *time.Time
+}
+
+// IsValid returns an error if the freeze window isn't valid.
+func (w *FreezeWindow) IsValid() error {
+	if !w.EndsAt.After(w.StartsAt) {
+		return ErrFreezeWindowRange
+	}
+
+	return nil
+}
+
+// BeforeCreate sets created_at before inserting.
+func (w *FreezeWindow) BeforeCreate() error {
+	t := timex.Now()
+	w.CreatedAt = &t
+	return w.IsValid()
+}
+
+// Active returns true if the freeze window is in effect at the given time.
+func (w *FreezeWindow) Active(t time.Time) bool {
+	return !t.Before(w.StartsAt) && t.Before(w.EndsAt)
+}
+
+// appliesTo returns true if the freeze window applies to the given app.
+func (w *FreezeWindow) appliesTo(app *App) bool {
+	if w.AppID != nil {
+		return *w.AppID == app.ID
+	}
+
+	if w.Team != "" {
+		return w.Team == app.Team
+	}
+
+	return true
+}
+
+// FreezeError is returned when a release is attempted during a freeze window,
+// without an override reason.
+type FreezeError struct {
+	Window *FreezeWindow
+}
+
+func (e *FreezeError) Error() string {
+	msg := fmt.Sprintf("releases are frozen until %s", e.Window.EndsAt.UTC().Format(time.RFC3339))
+	if e.Window.Reason != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, e.Window.Reason)
+	}
+	return fmt.Sprintf("%s. Provide an override reason to release anyway.", msg)
+}
+
+// FreezeWindowsQuery is a scope implementation for common things to filter
+// freeze windows by.
+type FreezeWindowsQuery struct {
+	// If provided, finds the freeze window with the given id.
+	ID *string
+
+	// If provided, finds freeze windows that are in effect at, or after,
+	// this time.
+	EndsAfter *time.Time
+
+	// If provided, finds freeze windows scoped to the given app.
+	App *App
+}
+
+// scope implements the scope interface.
+func (q FreezeWindowsQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.ID != nil {
+		scope = append(scope, idEquals(*q.ID))
+	}
+
+	if q.EndsAfter != nil {
+		scope = append(scope, scopeFunc(func(db *gorm.DB) *gorm.DB {
+			return db.Where("ends_at > ?", *q.EndsAfter)
+		}))
+	}
+
+	if q.App != nil {
+		scope = append(scope, forApp(q.App))
+	}
+
+	scope = append(scope, order("starts_at asc"))
+
+	return scope.scope(db)
+}
+
+// freezeWindowsFind returns the first matching freeze window.
+func freezeWindowsFind(db *gorm.DB, scope scope) (*FreezeWindow, error) {
+	var window FreezeWindow
+	return &window, first(db, scope, &window)
+}
+
+// freezeWindows returns all freeze windows matching the scope.
+func freezeWindows(db *gorm.DB, scope scope) ([]*FreezeWindow, error) {
+	var windows []*FreezeWindow
+	return windows, find(db, scope, &windows)
+}
+
+func freezeWindowsCreate(db *gorm.DB, window *FreezeWindow) (*FreezeWindow, error) {
+	return window, db.Create(window).Error
+}
+
+func freezeWindowsDestroy(db *gorm.DB, window *FreezeWindow) error {
+	return db.Delete(window).Error
+}
+
+// activeFreeze returns the first freeze window that applies to the app at the
+// given time, or nil if the app is not frozen.
+func activeFreeze(windows []*FreezeWindow, app *App, t time.Time) *FreezeWindow {
+	for _, w := range windows {
+		if w.Active(t) && w.appliesTo(app) {
+			return w
+		}
+	}
+	return nil
+}
+
+// checkFreeze returns a FreezeError if the app is frozen. If an override
+// reason was provided, the release is allowed and a FreezeOverrideEvent is
+// published so that it can be audited, unless the release is being simulated.
+func (e *Empire) checkFreeze(ctx context.Context, req *AdmissionRequest) error {
+	if req.Operation == AdmissionScale {
+		return nil
+	}
+
+	now := timex.Now()
+	windows, err := freezeWindows(e.db, FreezeWindowsQuery{EndsAfter: &now})
+	if err != nil {
+		return err
+	}
+	windows = append(windows, e.OperatorConfig().FreezeWindows...)
+
+	w := activeFreeze(windows, req.App, now)
+	if w == nil {
+		return nil
+	}
+
+	if req.FreezeOverride == "" {
+		return &FreezeError{Window: w}
+	}
+
+	// Nothing is released by a simulation, so there's nothing to audit.
+	if req.Simulate {
+		return nil
+	}
+
+	var user string
+	if req.User != nil {
+		user = req.User.Name
+	}
+
+	return e.publishEvent(ctx, FreezeOverrideEvent{
+		User:      user,
+		App:       req.App.Name,
+		Operation: req.Operation,
+		Reason:    req.FreezeOverride,
+		Freeze:    w.Reason,
+		app:       req.App,
+	})
+}
+
+// FreezeWindowsCreateOpts are options provided when creating a freeze window.
+type FreezeWindowsCreateOpts struct {
+	// User performing the action.
+	User *User
+
+	// The freeze window to create.
+	Window *FreezeWindow
+}
+
+// FreezeWindowsDestroyOpts are options provided when removing a freeze window.
+type FreezeWindowsDestroyOpts struct {
+	// User performing the action.
+	User *User
+
+	// The freeze window to remove.
+	Window *FreezeWindow
+}
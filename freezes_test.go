@@ -0,0 +1,74 @@
+package empire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreezeWindowsQuery(t *testing.T) {
+	var (
+		id  = "1234"
+		now = time.Date(2016, 12, 24, 0, 0, 0, 0, time.UTC)
+		app = &App{ID: "4321"}
+	)
+
+	tests := scopeTests{
+		{FreezeWindowsQuery{}, "ORDER BY starts_at asc", []interface{}{}},
+		{FreezeWindowsQuery{ID: &id}, "WHERE (id = $1) ORDER BY starts_at asc", []interface{}{"1234"}},
+		{FreezeWindowsQuery{EndsAfter: &now}, "WHERE (ends_at > $1) ORDER BY starts_at asc", []interface{}{now}},
+		{FreezeWindowsQuery{App: app}, "WHERE (app_id = $1) ORDER BY starts_at asc", []interface{}{"4321"}},
+	}
+
+	tests.Run(t)
+}
+
+func TestFreezeWindow_IsValid(t *testing.T) {
+	start := time.Date(2016, 12, 24, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		window FreezeWindow
+		err    error
+	}{
+		{FreezeWindow{StartsAt: start, EndsAt: start.Add(time.Hour)}, nil},
+		{FreezeWindow{StartsAt: start, EndsAt: start}, ErrFreezeWindowRange},
+		{FreezeWindow{StartsAt: start, EndsAt: start.Add(-time.Hour)}, ErrFreezeWindowRange},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.err, tt.window.IsValid())
+	}
+}
+
+func TestActiveFreeze(t *testing.T) {
+	start := time.Date(2016, 12, 24, 0, 0, 0, 0, time.UTC)
+	end := start.Add(48 * time.Hour)
+
+	appID, otherAppID := "4321", "1234"
+	app := &App{ID: appID, Team: "platform"}
+
+	global := &FreezeWindow{StartsAt: start, EndsAt: end, Reason: "global"}
+	team := &FreezeWindow{StartsAt: start, EndsAt: end, Reason: "team", Team: "platform"}
+	otherTeam := &FreezeWindow{StartsAt: start, EndsAt: end, Reason: "other-team", Team: "data"}
+	scoped := &FreezeWindow{StartsAt: start, EndsAt: end, Reason: "app", AppID: &appID}
+	otherApp := &FreezeWindow{StartsAt: start, EndsAt: end, Reason: "other-app", AppID: &otherAppID}
+
+	tests := []struct {
+		windows []*FreezeWindow
+		t       time.Time
+		active  *FreezeWindow
+	}{
+		{nil, start, nil},
+		{[]*FreezeWindow{global}, start, global},
+		{[]*FreezeWindow{global}, start.Add(-time.Second), nil},
+		{[]*FreezeWindow{global}, end, nil},
+		{[]*FreezeWindow{otherTeam, team}, start, team},
+		{[]*FreezeWindow{otherApp, scoped}, start, scoped},
+		{[]*FreezeWindow{otherTeam, otherApp}, start, nil},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.active, activeFreeze(tt.windows, app, tt.t))
+	}
+}
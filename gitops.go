@@ -0,0 +1,167 @@
+package empire
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/remind101/pkg/reporter"
+	"golang.org/x/net/context"
+)
+
+// GitSyncer periodically pulls app specs from a git repository, and applies
+// them, so that apps follow the repository. Each .yml, .yaml or .json file in
+// the directory is the AppSpec of one app. Apps that don't have a spec in the
+// repository aren't changed.
+type GitSyncer struct {
+	*Empire
+
+	// The url of the git repository.
+	Repo string
+
+	// The branch to follow.
+	Branch string
+
+	// The directory within the repository that contains the specs.
+	Path string
+
+	// The directory that the repository is cloned into.
+	Dir string
+
+	// The user that specs are applied as, which needs permission to make
+	// the changes.
+	User *User
+
+	// How often to pull the repository.
+	Interval time.Duration
+
+	// Runs git. The zero value runs the git binary.
+	git func(ctx context.Context, dir string, args ...string) (string, error)
+}
+
+// Start starts syncing, until the context is canceled. Errors, and panics, are
+// reported to the reporter in the context.
+func (s *GitSyncer) Start(ctx context.Context) {
+	defer reporter.Monitor(ctx)
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Sync(ctx); err != nil {
+			reporter.Report(ctx, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync pulls the repository, and applies every spec in it. Changes made by
+// the sync have the commit in their message.
+func (s *GitSyncer) Sync(ctx context.Context) error {
+	commit, err := s.pull(ctx)
+	if err != nil {
+		return fmt.Errorf("error pulling %s: %v", s.Repo, err)
+	}
+
+	files, err := appSpecFiles(filepath.Join(s.Dir, s.Path))
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Synced from %s@%s", s.Repo, commit)
+
+	var errors []error
+	for _, file := range files {
+		spec, err := ReadAppSpec(file)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		// There's nobody to provide a two factor code, so specs that
+		// unset config vars, or scale processes down, on protected apps
+		// are refused.
+		if _, err := s.Apply(ctx, ApplyOpts{
+			User:    s.User,
+			Spec:    spec,
+			Message: message,
+		}); err != nil {
+			errors = append(errors, fmt.Errorf("error applying %s: %v", file, err))
+		}
+	}
+
+	if len(errors) > 0 {
+		return &multiError{Errors: errors}
+	}
+
+	return nil
+}
+
+// pull clones the repository, or updates the clone to the latest commit on
+// the branch, and returns the commit.
+func (s *GitSyncer) pull(ctx context.Context) (string, error) {
+	git := s.git
+	if git == nil {
+		git = runGit
+	}
+
+	if _, err := os.Stat(filepath.Join(s.Dir, ".git")); os.IsNotExist(err) {
+		if _, err := git(ctx, "", "clone", "--depth", "1", "--branch", s.Branch, s.Repo, s.Dir); err != nil {
+			return "", err
+		}
+	} else {
+		if _, err := git(ctx, s.Dir, "fetch", "--depth", "1", "origin", s.Branch); err != nil {
+			return "", err
+		}
+		if _, err := git(ctx, s.Dir, "reset", "--hard", "FETCH_HEAD"); err != nil {
+			return "", err
+		}
+	}
+
+	commit, err := git(ctx, s.Dir, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(commit), nil
+}
+
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+// appSpecFiles returns the files in the directory that contain app specs.
+func appSpecFiles(dir string) ([]string, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, fi := range fis {
+		if fi.IsDir() {
+			continue
+		}
+		switch filepath.Ext(fi.Name()) {
+		case ".yml", ".yaml", ".json":
+			files = append(files, filepath.Join(dir, fi.Name()))
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,70 @@
+package empire
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestGitSyncer_pull(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitops")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var calls []string
+	s := &GitSyncer{
+		Repo:   "git@github.com:acme/apps.git",
+		Branch: "master",
+		Dir:    filepath.Join(dir, "apps"),
+		git: func(ctx context.Context, dir string, args ...string) (string, error) {
+			calls = append(calls, strings.Join(args, " "))
+			if args[0] == "clone" {
+				return "", os.MkdirAll(filepath.Join(args[len(args)-1], ".git"), 0755)
+			}
+			return "abc1234\n", nil
+		},
+	}
+
+	// The first pull clones the repository.
+	commit, err := s.pull(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "abc1234", commit)
+	assert.Equal(t, []string{
+		"clone --depth 1 --branch master git@github.com:acme/apps.git " + s.Dir,
+		"rev-parse --short HEAD",
+	}, calls)
+
+	// Later pulls update the clone.
+	calls = nil
+	_, err = s.pull(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"fetch --depth 1 origin master",
+		"reset --hard FETCH_HEAD",
+		"rev-parse --short HEAD",
+	}, calls)
+}
+
+func TestAppSpecFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitops")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"acme-inc.yml", "api.json", "README.md", "worker.yaml"} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "archive.yml"), 0755))
+
+	files, err := appSpecFiles(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "acme-inc.yml"),
+		filepath.Join(dir, "api.json"),
+		filepath.Join(dir, "worker.yaml"),
+	}, files)
+}
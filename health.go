@@ -0,0 +1,131 @@
+package empire
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// The dependencies of Empire that are health checked. Each cluster has its own
+// scheduler, which is checked as "scheduler:<cluster>".
+const (
+	DependencyDatabase  = "database"
+	DependencyScheduler = "scheduler"
+)
+
+// DefaultHealthCheckTimeout is how long a dependency has to respond to a
+// health check, before it's considered unhealthy.
+const DefaultHealthCheckTimeout = 5 * time.Second
+
+// DependencyCheck checks that Empire can reach something that it depends on.
+type DependencyCheck struct {
+	// The name of the dependency (e.g. database).
+	Name string
+
+	// Returns an error if the dependency can't be reached.
+	Check func(context.Context) error
+}
+
+// Is returns true if the check is of the named dependency. The
+// scheduler dependency includes the scheduler of every cluster.
+func (c DependencyCheck) Is(name string) bool {
+	return c.Name == name || strings.HasPrefix(c.Name, name+":")
+}
+
+// DependencyHealth is the result of a DependencyCheck.
+type DependencyHealth struct {
+	// The name of the dependency.
+	Name string
+
+	// The error that the check returned, or nil if the dependency is
+	// healthy.
+	Err error
+
+	// How long the check took.
+	Duration time.Duration
+}
+
+// Healthy returns true if the check passed.
+func (h *DependencyHealth) Healthy() bool {
+	return h.Err == nil
+}
+
+// DependencyChecks returns the checks of the dependencies of Empire: the
+// database, and the scheduler of each cluster, which is reachable if it can
+// list the machines in the cluster.
+func (e *Empire) DependencyChecks() []DependencyCheck {
+	checks := []DependencyCheck{
+		{Name: DependencyDatabase, Check: func(ctx context.Context) error {
+			return e.DB.IsHealthy()
+		}},
+		schedulerCheck(DependencyScheduler, e.Scheduler),
+	}
+
+	var clusters []string
+	for name := range e.Clusters {
+		clusters = append(clusters, name)
+	}
+	sort.Strings(clusters)
+	for _, name := range clusters {
+		checks = append(checks, schedulerCheck(fmt.Sprintf("%s:%s", DependencyScheduler, name), e.Clusters[name]))
+	}
+
+	return checks
+}
+
+func schedulerCheck(name string, s Scheduler) DependencyCheck {
+	return DependencyCheck{Name: name, Check: func(ctx context.Context) error {
+		_, err := s.Machines(ctx)
+		return err
+	}}
+}
+
+// CheckHealth runs the health checks concurrently, and returns their results
+// in the same order. A check that doesn't return within timeout fails.
+func CheckHealth(ctx context.Context, checks []DependencyCheck, timeout time.Duration) []*DependencyHealth {
+	if timeout == 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results := make([]*DependencyHealth, len(checks))
+	done := make(chan struct{}, len(checks))
+	for i, c := range checks {
+		go func(i int, c DependencyCheck) {
+			results[i] = checkHealth(ctx, c, timeout)
+			done <- struct{}{}
+		}(i, c)
+	}
+	for range checks {
+		<-done
+	}
+
+	return results
+}
+
+// checkHealth runs a single check, and gives up on it when ctx is done, since
+// not every check (e.g. the database) can be canceled.
+func checkHealth(ctx context.Context, c DependencyCheck, timeout time.Duration) *DependencyHealth {
+	start := time.Now()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- c.Check(ctx) }()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = fmt.Errorf("didn't respond within %v", timeout)
+	}
+
+	return &DependencyHealth{
+		Name:     c.Name,
+		Err:      err,
+		Duration: time.Since(start),
+	}
+}
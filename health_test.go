@@ -0,0 +1,47 @@
+package empire
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestCheckHealth(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	checks := []DependencyCheck{
+		{Name: "database", Check: func(ctx context.Context) error {
+			return nil
+		}},
+		{Name: "scheduler", Check: func(ctx context.Context) error {
+			return errors.New("connection refused")
+		}},
+		{Name: "scheduler:gpu", Check: func(ctx context.Context) error {
+			<-block
+			return nil
+		}},
+	}
+
+	results := CheckHealth(context.Background(), checks, 10*time.Millisecond)
+	assert.Equal(t, 3, len(results))
+
+	assert.Equal(t, "database", results[0].Name)
+	assert.True(t, results[0].Healthy())
+
+	assert.Equal(t, "scheduler", results[1].Name)
+	assert.EqualError(t, results[1].Err, "connection refused")
+
+	assert.Equal(t, "scheduler:gpu", results[2].Name)
+	assert.EqualError(t, results[2].Err, "didn't respond within 10ms")
+}
+
+func TestDependencyCheck_Is(t *testing.T) {
+	assert.True(t, DependencyCheck{Name: "scheduler"}.Is("scheduler"))
+	assert.True(t, DependencyCheck{Name: "scheduler:gpu"}.Is("scheduler"))
+	assert.False(t, DependencyCheck{Name: "database"}.Is("scheduler"))
+	assert.False(t, DependencyCheck{Name: "schedulers"}.Is("scheduler"))
+}
@@ -0,0 +1,90 @@
+package empire
+
+import "golang.org/x/net/context"
+
+// Operations that are passed to Hooks.
+const (
+	OperationDeploy   = "deploy"
+	OperationRollback = "rollback"
+	OperationScale    = "scale"
+	OperationRestart  = "restart"
+	OperationConfig   = "config"
+	OperationDestroy  = "destroy"
+	OperationRun      = "run"
+)
+
+// HookRequest describes an operation that a Hook is called around.
+type HookRequest struct {
+	// The operation being performed (e.g. OperationScale).
+	Operation string
+
+	// The user performing the operation.
+	User *User
+
+	// The app that the operation is being performed on. This is nil when a
+	// deploy creates the app.
+	App *App
+
+	// The options that were passed to the operation (e.g. ScaleOpts), so
+	// that hooks can inspect the details of the change.
+	Opts interface{}
+}
+
+// Hook is called around the operations that change apps, like deploys,
+// rollbacks, scaling, restarts, config changes, destroys and runs. This allows
+// cross-cutting concerns like auditing, metrics, policy checks or
+// notifications to be added without changing the operations themselves.
+type Hook interface {
+	// Before is called after the user has been authorized, but before the
+	// operation is performed. If an error is returned, the operation isn't
+	// performed, and the error is returned to the caller.
+	Before(context.Context, *HookRequest) error
+
+	// After is called once the operation has finished, or was rejected by
+	// a Hook, with the resulting error, if any.
+	After(context.Context, *HookRequest, error)
+}
+
+// HookFuncs implements the Hook interface with functions. Either function can
+// be nil.
+type HookFuncs struct {
+	BeforeFunc func(context.Context, *HookRequest) error
+	AfterFunc  func(context.Context, *HookRequest, error)
+}
+
+func (h HookFuncs) Before(ctx context.Context, req *HookRequest) error {
+	if h.BeforeFunc == nil {
+		return nil
+	}
+	return h.BeforeFunc(ctx, req)
+}
+
+func (h HookFuncs) After(ctx context.Context, req *HookRequest, err error) {
+	if h.AfterFunc != nil {
+		h.AfterFunc(ctx, req, err)
+	}
+}
+
+// hook performs the operation with fn, calling the Before method of each
+// configured Hook first, in order, and the After method of each afterwards,
+// in reverse order. When a Hook rejects the operation, fn isn't called, and
+// only the hooks that were called before it see the error.
+func (e *Empire) hook(ctx context.Context, req *HookRequest, fn func() error) error {
+	for i, h := range e.Hooks {
+		if err := h.Before(ctx, req); err != nil {
+			e.afterHooks(ctx, req, i, err)
+			return err
+		}
+	}
+
+	err := fn()
+	e.afterHooks(ctx, req, len(e.Hooks), err)
+	return err
+}
+
+// afterHooks calls the After method of the first n hooks, in reverse order.
+func (e *Empire) afterHooks(ctx context.Context, req *HookRequest, n int, err error) {
+	for i := n - 1; i >= 0; i-- {
+		e.Hooks[i].After(ctx, req, err)
+	}
+}
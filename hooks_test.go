@@ -0,0 +1,71 @@
+package empire
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestEmpire_hook(t *testing.T) {
+	var calls []string
+	record := func(name string, veto error) Hook {
+		return HookFuncs{
+			BeforeFunc: func(ctx context.Context, req *HookRequest) error {
+				calls = append(calls, "before "+name)
+				return veto
+			},
+			AfterFunc: func(ctx context.Context, req *HookRequest, err error) {
+				if err != nil {
+					calls = append(calls, "after "+name+": "+err.Error())
+				} else {
+					calls = append(calls, "after "+name)
+				}
+			},
+		}
+	}
+
+	e := &Empire{Hooks: []Hook{record("a", nil), record("b", nil)}}
+	err := e.hook(context.Background(), &HookRequest{Operation: OperationScale}, func() error {
+		calls = append(calls, "scale")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"before a", "before b", "scale", "after b", "after a"}, calls)
+
+	// A hook can veto the operation.
+	calls = nil
+	e.Hooks = []Hook{record("a", nil), record("b", errors.New("denied")), record("c", nil)}
+	err = e.hook(context.Background(), &HookRequest{Operation: OperationScale}, func() error {
+		calls = append(calls, "scale")
+		return nil
+	})
+	assert.EqualError(t, err, "denied")
+	assert.Equal(t, []string{"before a", "before b", "after a: denied"}, calls)
+}
+
+func TestEmpire_Hooks_Veto(t *testing.T) {
+	user := &User{Name: "ejholmes"}
+	app := &App{Name: "acme-inc"}
+
+	var reqs []*HookRequest
+	e := &Empire{Hooks: []Hook{HookFuncs{
+		BeforeFunc: func(ctx context.Context, req *HookRequest) error {
+			reqs = append(reqs, req)
+			return errors.New("denied")
+		},
+	}}}
+
+	// The operations would need a database if they weren't vetoed.
+	err := e.Destroy(context.Background(), DestroyOpts{User: user, App: app})
+	assert.EqualError(t, err, "denied")
+
+	err = e.Restart(context.Background(), RestartOpts{User: user, App: app})
+	assert.EqualError(t, err, "denied")
+
+	assert.Equal(t, []*HookRequest{
+		{Operation: OperationDestroy, User: user, App: app, Opts: DestroyOpts{User: user, App: app}},
+		{Operation: OperationRestart, User: user, App: app, Opts: RestartOpts{User: user, App: app}},
+	}, reqs)
+}
@@ -0,0 +1,107 @@
+package empire
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/timex"
+)
+
+// IdempotencyKeyTTL is how long an idempotency key is remembered. Requests
+// with a key that was first used longer ago than this are run again.
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// idempotencyKeyAbandonedAfter is how long a request with an idempotency key
+// can be in progress before it's assumed that the Empire instance that was
+// handling it went away, so that the request can be retried.
+const idempotencyKeyAbandonedAfter = time.Hour
+
+// IdempotencyKeyError is returned when an idempotency key can't be used for a
+// request, because it's being used by a request that's still in progress, or
+// was used for a different request.
+type IdempotencyKeyError struct {
+	Key    string
+	Reason string
+}
+
+func (e *IdempotencyKeyError) Error() string {
+	return fmt.Sprintf("idempotency key %q %s", e.Key, e.Reason)
+}
+
+// idempotencyKey records a request that was made with an idempotency key, and
+// its result, once it has finished.
+type idempotencyKey struct {
+	Key      string
+	UserName string
+
+	// Describes the request (e.g. "scale acme-inc"), so that a key can't be
+	// reused for a different request.
+	Operation string
+
+	// The JSON encoded result of the request, or nil if it's still in
+	// progress.
+	Result []byte
+
+	CreatedAt *time.Time
+}
+
+// idempotent runs fn once for each idempotency key of a user, so that clients
+// can safely retry requests, like deploys, when they don't know whether the
+// first attempt succeeded. The first time a key is used, fn is run, and the
+// result that it sets in v is stored. Each retry with the same key doesn't run
+// fn, and decodes the stored result into v instead. It returns true if fn was
+// run.
+//
+// When fn fails, the key is forgotten, so that the request can be retried. An
+// empty key always runs fn.
+func (e *Empire) idempotent(user *User, key, operation string, v interface{}, fn func() error) (bool, error) {
+	if key == "" {
+		return true, fn()
+	}
+
+	now := timex.Now()
+	if err := e.db.Exec(`delete from idempotency_keys where created_at < ? or (result is null and created_at < ?)`, now.Add(-IdempotencyKeyTTL), now.Add(-idempotencyKeyAbandonedAfter)).Error; err != nil {
+		return false, err
+	}
+
+	err := e.db.Exec(`insert into idempotency_keys (key, user_name, operation, created_at) values (?, ?, ?, ?)`, key, user.Name, operation, now).Error
+	if isUniqueViolation(err) {
+		return false, idempotencyKeysResult(e.db, user, key, operation, v)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := fn(); err != nil {
+		e.db.Exec(`delete from idempotency_keys where user_name = ? and key = ?`, user.Name, key)
+		return true, err
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return true, err
+	}
+
+	return true, e.db.Exec(`update idempotency_keys set result = ? where user_name = ? and key = ?`, raw, user.Name, key).Error
+}
+
+// idempotencyKeysResult decodes the result of the request that the key was
+// first used for into v.
+func idempotencyKeysResult(db *gorm.DB, user *User, key, operation string, v interface{}) error {
+	var k idempotencyKey
+	if err := db.Where("user_name = ? and key = ?", user.Name, key).First(&k).Error; err != nil {
+		return err
+	}
+
+	if k.Operation != operation {
+		return &IdempotencyKeyError{Key: key, Reason: fmt.Sprintf("was already used to %s", k.Operation)}
+	}
+
+	if k.Result == nil {
+		return &IdempotencyKeyError{Key: key, Reason: "is being used by a request that's still in progress; try again once it has finished"}
+	}
+
+	return json.Unmarshal(k.Result, v)
+}
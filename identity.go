@@ -0,0 +1,182 @@
+package empire
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/remind101/empire/twelvefactor"
+	"github.com/remind101/pkg/reporter"
+	"golang.org/x/net/context"
+)
+
+// DefaultIdentityTTL is how long identity certificates are valid for when the
+// IdentityIssuer doesn't set a TTL.
+const DefaultIdentityTTL = 72 * time.Hour
+
+// IdentityIssuer issues SPIFFE style identity certificates to apps, so that
+// they can authenticate each other with mTLS, instead of sharing secrets in
+// config vars. Each app gets a certificate with a URI SAN of
+// spiffe://<trust domain>/app/<name>, which is provided to its processes,
+// along with the CA that signed it, through environment variables:
+//
+//	EMPIRE_IDENTITY_ID       The SPIFFE ID of the app.
+//	EMPIRE_IDENTITY_CERT     The PEM encoded certificate.
+//	EMPIRE_IDENTITY_KEY      The PEM encoded private key.
+//	EMPIRE_IDENTITY_CA       The PEM encoded CA certificate, to verify peers.
+//	EMPIRE_IDENTITY_EXPIRES  When the certificate expires, in RFC 3339 format.
+//
+// A new certificate is issued every time the app is released.
+type IdentityIssuer struct {
+	// The trust domain of the SPIFFE IDs (e.g. "empire.acme.com").
+	TrustDomain string
+
+	// The CA certificate, and its private key, that sign identity
+	// certificates.
+	CA  *x509.Certificate
+	Key crypto.Signer
+
+	// How long certificates are valid for. The zero value is
+	// DefaultIdentityTTL.
+	TTL time.Duration
+}
+
+// ID returns the SPIFFE ID for the app.
+func (i *IdentityIssuer) ID(app *App) *url.URL {
+	return &url.URL{
+		Scheme: "spiffe",
+		Host:   i.TrustDomain,
+		Path:   fmt.Sprintf("/app/%s", app.Name),
+	}
+}
+
+// Issue issues a new identity certificate for the app, and returns the
+// environment variables that provide it to the app.
+func (i *IdentityIssuer) Issue(app *App) (map[string]string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := i.TTL
+	if ttl == 0 {
+		ttl = DefaultIdentityTTL
+	}
+
+	now := timex.Now()
+	id := i.ID(app)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: app.Name},
+		URIs:         []*url.URL{id},
+		// Allow for some clock skew between hosts.
+		NotBefore:   now.Add(-5 * time.Minute),
+		NotAfter:    now.Add(ttl),
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	cert, err := x509.CreateCertificate(rand.Reader, template, i.CA, key.Public(), i.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"EMPIRE_IDENTITY_ID":      id.String(),
+		"EMPIRE_IDENTITY_CERT":    string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})),
+		"EMPIRE_IDENTITY_KEY":     string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+		"EMPIRE_IDENTITY_CA":      string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: i.CA.Raw})),
+		"EMPIRE_IDENTITY_EXPIRES": template.NotAfter.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// injectIdentity issues an identity certificate for the app, and adds it to
+// the environment of the manifest.
+func injectIdentity(i *IdentityIssuer, app *App, m *twelvefactor.Manifest) error {
+	if i == nil {
+		return nil
+	}
+
+	env, err := i.Issue(app)
+	if err != nil {
+		return fmt.Errorf("unable to issue identity certificate: %v", err)
+	}
+
+	for k, v := range env {
+		m.Env[k] = v
+	}
+
+	return nil
+}
+
+// IdentityRotator periodically releases every app, so that their processes
+// are replaced with ones that have a new identity certificate before the
+// current one expires. The Interval should be well below the TTL of the
+// certificates.
+type IdentityRotator struct {
+	*Empire
+
+	// How often to rotate identity certificates.
+	Interval time.Duration
+}
+
+// Start starts rotating identity certificates, until the context is canceled.
+// Errors, and panics, are reported to the reporter in the context.
+func (r *IdentityRotator) Start(ctx context.Context) {
+	defer reporter.Monitor(ctx)
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.RotateIdentities(ctx); err != nil {
+				reporter.Report(ctx, err)
+			}
+		}
+	}
+}
+
+// RotateIdentities releases every app that has been released, which issues a
+// new identity certificate to each of them.
+func (e *Empire) RotateIdentities(ctx context.Context) error {
+	apps, err := apps(e.db, AppsQuery{})
+	if err != nil {
+		return err
+	}
+
+	var errors []error
+	for _, app := range apps {
+		if err := e.releases.ReleaseApp(ctx, e.db, app, nil); err != nil && err != ErrNoReleases {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return &multiError{Errors: errors}
+	}
+
+	return nil
+}
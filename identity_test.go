@@ -0,0 +1,77 @@
+package empire
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityIssuer_Issue(t *testing.T) {
+	i := newTestIdentityIssuer(t)
+
+	env, err := i.Issue(&App{Name: "acme-inc"})
+	assert.NoError(t, err)
+	assert.Equal(t, "spiffe://empire.acme.com/app/acme-inc", env["EMPIRE_IDENTITY_ID"])
+
+	// The certificate and key should be usable as a key pair.
+	pair, err := tls.X509KeyPair([]byte(env["EMPIRE_IDENTITY_CERT"]), []byte(env["EMPIRE_IDENTITY_KEY"]))
+	assert.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "spiffe://empire.acme.com/app/acme-inc", cert.URIs[0].String())
+	assert.Equal(t, cert.NotAfter.UTC().Format(time.RFC3339), env["EMPIRE_IDENTITY_EXPIRES"])
+
+	// Peers should be able to verify it with the CA.
+	block, _ := pem.Decode([]byte(env["EMPIRE_IDENTITY_CA"]))
+	ca, err := x509.ParseCertificate(block.Bytes)
+	assert.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	assert.NoError(t, err)
+}
+
+func newTestIdentityIssuer(t testing.TB) *IdentityIssuer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Empire Identity CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &IdentityIssuer{
+		TrustDomain: "empire.acme.com",
+		CA:          ca,
+		Key:         key,
+		TTL:         time.Hour,
+	}
+}
@@ -0,0 +1,284 @@
+package empire
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/image"
+	"golang.org/x/net/context"
+)
+
+var (
+	// ErrImageUsageQuery is returned when looking for the apps that use an
+	// image, without an image or a layer.
+	ErrImageUsageQuery = &ValidationError{
+		errors.New("An image, or a layer, is required."),
+	}
+
+	// ErrRedeployParallelism is returned when redeploying with an invalid
+	// parallelism.
+	ErrRedeployParallelism = &ValidationError{
+		errors.New("Parallelism must be at least 1."),
+	}
+)
+
+// ImageUsageQuery selects the apps whose current release runs an image, or an
+// image with a given layer.
+type ImageUsageQuery struct {
+	// If provided, finds apps running an image from the same repository.
+	// If the image has a digest, only apps running that digest match. Tags
+	// are only compared for releases that were deployed by tag, since
+	// images are resolved to a digest when they're deployed.
+	Image *image.Image
+
+	// If provided, finds apps running an image with the layer with this
+	// digest (e.g. the top layer of a base image). Layers are only known
+	// for releases that were deployed after Empire started recording them.
+	Layer string
+}
+
+// IsValid returns an error if the query doesn't have an image, or a layer.
+func (q ImageUsageQuery) IsValid() error {
+	if q.Image == nil && q.Layer == "" {
+		return ErrImageUsageQuery
+	}
+	return nil
+}
+
+// matches returns true if the slug matches the query.
+func (q ImageUsageQuery) matches(s *Slug) bool {
+	if q.Image != nil {
+		img := s.Image
+		if img.Registry != q.Image.Registry || img.Repository != q.Image.Repository {
+			return false
+		}
+		if q.Image.Digest != "" && img.Digest != q.Image.Digest {
+			return false
+		}
+		if q.Image.Tag != "" && img.Tag != "" && img.Tag != q.Image.Tag {
+			return false
+		}
+	}
+
+	if q.Layer != "" && !s.Layers.Contains(q.Layer) {
+		return false
+	}
+
+	return true
+}
+
+// ImageUsage is an app whose current release runs an image that matches an
+// ImageUsageQuery.
+type ImageUsage struct {
+	// The app.
+	App *App
+
+	// The current release of the app, with the slug of the image that it
+	// runs.
+	Release *Release
+}
+
+// ImageUsageOpts are options provided when finding the apps that run an image.
+type ImageUsageOpts struct {
+	// User performing the action.
+	User *User
+
+	// The image, or layer, to look for.
+	Query ImageUsageQuery
+}
+
+// ImageUsage returns the apps whose current release runs an image matching the
+// query, sorted by the name of the app.
+func (e *Empire) ImageUsage(ctx context.Context, opts ImageUsageOpts) ([]*ImageUsage, error) {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return nil, err
+	}
+
+	return imageUsage(e.dbr, opts.Query)
+}
+
+// imageUsage returns the apps whose current release matches the query.
+func imageUsage(db *gorm.DB, q ImageUsageQuery) ([]*ImageUsage, error) {
+	if err := q.IsValid(); err != nil {
+		return nil, err
+	}
+
+	apps, err := apps(db, AppsQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	var usage []*ImageUsage
+	for _, app := range apps {
+		release, err := releasesFind(db, ReleasesQuery{App: app})
+		if err != nil {
+			if err == gorm.RecordNotFound {
+				continue
+			}
+			return nil, err
+		}
+
+		if q.matches(release.Slug) {
+			usage = append(usage, &ImageUsage{App: app, Release: release})
+		}
+	}
+
+	return usage, nil
+}
+
+// RedeployOpts are options provided when redeploying the apps that run an
+// image.
+type RedeployOpts struct {
+	// User performing the action.
+	User *User
+
+	// Selects the apps to redeploy.
+	Query ImageUsageQuery
+
+	// If provided, each app is deployed with this tag of the repository of
+	// its current image (e.g. "latest"), which picks up images that were
+	// rebuilt on a patched base image. Otherwise, the current image is
+	// deployed again.
+	Tag string
+
+	// The number of apps that are deployed at once, in each wave.
+	Parallelism int
+
+	// How long to wait after each wave, before the next one starts.
+	Interval time.Duration
+
+	// Progress is written here, as each app is deployed.
+	Output *DeploymentStream
+
+	// Commit message
+	Message string
+}
+
+// Validate returns an error if the options aren't valid.
+func (opts RedeployOpts) Validate(e *Empire) error {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return err
+	}
+	if opts.Parallelism < 1 {
+		return ErrRedeployParallelism
+	}
+	if err := opts.Query.IsValid(); err != nil {
+		return err
+	}
+	return e.requireMessages(opts.Message)
+}
+
+// Redeploy deploys the apps that run an image again, in waves of
+// opts.Parallelism apps, waiting opts.Interval between each wave, so that
+// rolling out a patched base image doesn't replace every process in the
+// cluster at once. Each deploy waits until the new release is running. If any
+// deploy in a wave fails, no more waves are started.
+func (e *Empire) Redeploy(ctx context.Context, opts RedeployOpts) error {
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
+	usage, err := imageUsage(e.db, opts.Query)
+	if err != nil {
+		return err
+	}
+
+	w := &redeployStream{DeploymentStream: opts.Output}
+	w.Status(fmt.Sprintf("Redeploying %d apps, %d at a time", len(usage), opts.Parallelism))
+
+	for i := 0; i < len(usage); i += opts.Parallelism {
+		if i > 0 && opts.Interval > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(opts.Interval):
+			}
+		}
+
+		end := i + opts.Parallelism
+		if end > len(usage) {
+			end = len(usage)
+		}
+
+		var (
+			wg     sync.WaitGroup
+			mu     sync.Mutex
+			failed []string
+		)
+		for n, u := range usage[i:end] {
+			wg.Add(1)
+			go func(n int, u *ImageUsage) {
+				defer wg.Done()
+				if err := e.redeploy(ctx, opts, w, u, n, len(usage)); err != nil {
+					mu.Lock()
+					failed = append(failed, u.App.Name)
+					mu.Unlock()
+				}
+			}(i+n+1, u)
+		}
+		wg.Wait()
+
+		if len(failed) > 0 {
+			return w.Error(fmt.Errorf("stopped after deploys to %d apps failed; %d apps weren't redeployed", len(failed), len(usage)-end))
+		}
+	}
+
+	return nil
+}
+
+// redeploy deploys a single app again.
+func (e *Empire) redeploy(ctx context.Context, opts RedeployOpts, w *redeployStream, u *ImageUsage, n, total int) error {
+	// The same image was built from the same source, so the provenance
+	// carries over, unless another tag is deployed.
+	img, provenance := u.Release.Slug.Image, u.Release.Slug.Provenance
+	if opts.Tag != "" {
+		provenance = Provenance{}
+		img = image.Image{
+			Registry:   img.Registry,
+			Repository: img.Repository,
+			Tag:        opts.Tag,
+		}
+	}
+
+	w.Status(fmt.Sprintf("Deploying %s to %s (%d/%d)", img, u.App.Name, n, total))
+
+	r, err := e.Deploy(ctx, DeployOpts{
+		User:       opts.User,
+		App:        u.App,
+		Image:      img,
+		Provenance: provenance,
+		Output:     NewDeploymentStream(ioutil.Discard),
+		Message:    opts.Message,
+		Stream:     true,
+	})
+	if err != nil {
+		w.Error(fmt.Errorf("deploying %s to %s: %v", img, u.App.Name, err))
+		return err
+	}
+
+	w.Status(fmt.Sprintf("Deployed %s to %s as v%d", img, u.App.Name, r.Version))
+	return nil
+}
+
+// redeployStream serializes writes to a DeploymentStream, from the deploys in
+// a wave.
+type redeployStream struct {
+	*DeploymentStream
+	mu sync.Mutex
+}
+
+func (w *redeployStream) Status(message string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.DeploymentStream.Status(message)
+}
+
+func (w *redeployStream) Error(err error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.DeploymentStream.Error(err)
+}
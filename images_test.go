@@ -0,0 +1,38 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/pkg/image"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImageUsageQuery_Matches(t *testing.T) {
+	slug := &Slug{
+		Image:  image.Image{Repository: "remind101/acme-inc", Digest: "sha256:abc"},
+		Layers: Layers{"sha256:base", "sha256:app"},
+	}
+
+	tests := []struct {
+		q       ImageUsageQuery
+		matches bool
+	}{
+		{ImageUsageQuery{Image: &image.Image{Repository: "remind101/acme-inc"}}, true},
+		{ImageUsageQuery{Image: &image.Image{Repository: "remind101/acme-inc", Tag: "latest"}}, true},
+		{ImageUsageQuery{Image: &image.Image{Repository: "remind101/acme-inc", Digest: "sha256:abc"}}, true},
+		{ImageUsageQuery{Image: &image.Image{Repository: "remind101/acme-inc", Digest: "sha256:def"}}, false},
+		{ImageUsageQuery{Image: &image.Image{Registry: "quay.io", Repository: "remind101/acme-inc"}}, false},
+		{ImageUsageQuery{Image: &image.Image{Repository: "remind101/api"}}, false},
+		{ImageUsageQuery{Layer: "sha256:base"}, true},
+		{ImageUsageQuery{Layer: "sha256:other"}, false},
+		{ImageUsageQuery{Image: &image.Image{Repository: "remind101/acme-inc"}, Layer: "sha256:other"}, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.matches, tt.q.matches(slug), "%v", tt.q)
+	}
+
+	// Tags are compared for releases deployed by tag.
+	tagged := &Slug{Image: image.Image{Repository: "remind101/acme-inc", Tag: "v1"}}
+	assert.False(t, ImageUsageQuery{Image: &image.Image{Repository: "remind101/acme-inc", Tag: "v2"}}.matches(tagged))
+}
@@ -0,0 +1,170 @@
+package empire
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/remind101/empire/twelvefactor"
+)
+
+var (
+	// ErrIngressRulePort is returned when an IngressRule has an invalid
+	// port.
+	ErrIngressRulePort = &ValidationError{
+		errors.New("Port must be between 1 and 65535."),
+	}
+
+	// ErrIngressRuleSource is returned when an IngressRule allows an app to
+	// connect to itself.
+	ErrIngressRuleSource = &ValidationError{
+		errors.New("An app can't be the source of its own ingress rule."),
+	}
+)
+
+// IngressRule allows the processes of another app to connect to a port of an
+// app (e.g. "api may connect to port 50051 of users").
+//
+// Apps without any ingress rules can be reached by anything in the network.
+// Once an app has an ingress rule, it's isolated, and its internal load
+// balancers only accept connections that an ingress rule allows. Rules are
+// provided to the scheduler, which enforces them, the next time the app is
+// released.
+type IngressRule struct {
+	// A unique uuid that identifies the rule.
+	ID string
+
+	// The id of the app that connections are allowed to.
+	AppID string
+
+	// The id of the app that's allowed to connect.
+	SourceAppID string
+
+	// The port, on the load balancer of the app, that the source app can
+	// connect to.
+	Port int
+
+	// The time that the rule was created.
+	CreatedAt *time.Time
+}
+
+// IsValid returns an error if the rule isn't valid.
+func (r *IngressRule) IsValid() error {
+	if r.Port < 1 || r.Port > 65535 {
+		return ErrIngressRulePort
+	}
+
+	if r.AppID == r.SourceAppID {
+		return ErrIngressRuleSource
+	}
+
+	return nil
+}
+
+// BeforeCreate sets created_at before inserting.
+func (r *IngressRule) BeforeCreate() error {
+	t := timex.Now()
+	r.CreatedAt = &t
+	return r.IsValid()
+}
+
+// IngressRulesQuery is a scope implementation for common things to filter
+// ingress rules by.
+type IngressRulesQuery struct {
+	// If provided, finds the rule with the given id.
+	ID *string
+
+	// If provided, finds rules that allow connections to the given app.
+	App *App
+}
+
+// scope implements the scope interface.
+func (q IngressRulesQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.ID != nil {
+		scope = append(scope, idEquals(*q.ID))
+	}
+
+	if q.App != nil {
+		scope = append(scope, forApp(q.App))
+	}
+
+	scope = append(scope, order("port asc, created_at asc"))
+
+	return scope.scope(db)
+}
+
+// ingressRulesFind returns the first matching rule.
+func ingressRulesFind(db *gorm.DB, scope scope) (*IngressRule, error) {
+	var rule IngressRule
+	return &rule, first(db, scope, &rule)
+}
+
+// ingressRules returns all rules matching the scope.
+func ingressRules(db *gorm.DB, scope scope) ([]*IngressRule, error) {
+	var rules []*IngressRule
+	return rules, find(db, scope, &rules)
+}
+
+func ingressRulesCreate(db *gorm.DB, rule *IngressRule) (*IngressRule, error) {
+	return rule, db.Create(rule).Error
+}
+
+func ingressRulesDestroy(db *gorm.DB, rule *IngressRule) error {
+	return db.Delete(rule).Error
+}
+
+// appIngress returns the ingress rules that should be provided to the scheduler
+// for the given app. It returns nil if the app isn't isolated.
+func appIngress(db *gorm.DB, app *App) ([]*twelvefactor.Ingress, error) {
+	rules, err := ingressRules(db, IngressRulesQuery{App: app})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	// The app stays isolated, even if all of the source apps have been
+	// destroyed.
+	ingress := []*twelvefactor.Ingress{}
+	for _, r := range rules {
+		source, err := appsFind(db, AppsQuery{ID: &r.SourceAppID})
+		if err == gorm.RecordNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ingress = append(ingress, &twelvefactor.Ingress{
+			App:     source.Name,
+			Cluster: source.Cluster,
+			Port:    r.Port,
+		})
+	}
+
+	return ingress, nil
+}
+
+// IngressRulesCreateOpts are options provided when allowing an app to connect
+// to another app.
+type IngressRulesCreateOpts struct {
+	// User performing the action.
+	User *User
+
+	// The rule to create.
+	Rule *IngressRule
+}
+
+// IngressRulesDestroyOpts are options provided when removing an ingress rule.
+type IngressRulesDestroyOpts struct {
+	// User performing the action.
+	User *User
+
+	// The rule to remove.
+	Rule *IngressRule
+}
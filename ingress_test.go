@@ -0,0 +1,38 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIngressRulesQuery(t *testing.T) {
+	var (
+		id  = "1234"
+		app = &App{ID: "4321"}
+	)
+
+	tests := scopeTests{
+		{IngressRulesQuery{}, "ORDER BY port asc, created_at asc", []interface{}{}},
+		{IngressRulesQuery{ID: &id}, "WHERE (id = $1) ORDER BY port asc, created_at asc", []interface{}{"1234"}},
+		{IngressRulesQuery{App: app}, "WHERE (app_id = $1) ORDER BY port asc, created_at asc", []interface{}{"4321"}},
+	}
+
+	tests.Run(t)
+}
+
+func TestIngressRule_IsValid(t *testing.T) {
+	tests := []struct {
+		rule IngressRule
+		err  error
+	}{
+		{IngressRule{AppID: "users", SourceAppID: "api", Port: 50051}, nil},
+		{IngressRule{AppID: "users", SourceAppID: "api", Port: 0}, ErrIngressRulePort},
+		{IngressRule{AppID: "users", SourceAppID: "api", Port: 65536}, ErrIngressRulePort},
+		{IngressRule{AppID: "users", SourceAppID: "users", Port: 80}, ErrIngressRuleSource},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.err, tt.rule.IsValid())
+	}
+}
@@ -0,0 +1,425 @@
+package empire
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/remind101/empire/twelvefactor"
+	"github.com/remind101/pkg/reporter"
+	"golang.org/x/net/context"
+)
+
+// The states that a job can be in. Jobs that aren't part of a batch are removed
+// from the queue once they're run, so they're only ever queued.
+const (
+	JobStateQueued    = "queued"
+	JobStateRunning   = "running"
+	JobStateSucceeded = "succeeded"
+	JobStateFailed    = "failed"
+	JobStateLost      = "lost"
+)
+
+// jobLabel is the label that the processes of batch jobs are labeled with,
+// which holds the id of the job.
+const jobLabel = "empire.job"
+
+// jobLostAfter is how long a running batch job can go without the scheduler
+// knowing about its process before it's considered lost.
+const jobLostAfter = 10 * time.Minute
+
+// Job is a one-off process that's queued to run once there's room for it in
+// the cluster of its app.
+type Job struct {
+	// A unique uuid that identifies the job.
+	ID string
+
+	// The id of the app that the job runs in.
+	AppID string
+
+	// The process type that the job runs as (e.g. "run", or a process
+	// defined in the Procfile).
+	Type string
+
+	// The full command to run.
+	Command Command
+
+	// The constraints of the process.
+	CPUShare constraints.CPUShare
+	Memory   constraints.Memory
+	Nproc    constraints.Nproc
+
+	// The user that ran the job.
+	CreatedBy string
+
+	// Why the job was queued (e.g. "to make room for acme-inc web").
+	Reason string
+
+	// The time that the job was queued.
+	CreatedAt *time.Time
+
+	// The batch that the job is part of, if any.
+	BatchID *string
+
+	// The state of the job.
+	State string
+
+	// The exit code of the process, once a batch job has finished.
+	ExitCode *int
+
+	// The times that a batch job started and finished running.
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+}
+
+// BeforeCreate sets created_at before inserting.
+func (j *Job) BeforeCreate() error {
+	t := timex.Now()
+	j.CreatedAt = &t
+	if j.State == "" {
+		j.State = JobStateQueued
+	}
+	return nil
+}
+
+// Finished returns true if the job has stopped running, or won't run.
+func (j *Job) Finished() bool {
+	return j.State == JobStateSucceeded || j.State == JobStateFailed || j.State == JobStateLost
+}
+
+// finish records the exit code of the job's process.
+func (j *Job) finish(exitCode int, at time.Time) {
+	j.ExitCode = &exitCode
+	j.FinishedAt = &at
+	if exitCode == 0 {
+		j.State = JobStateSucceeded
+	} else {
+		j.State = JobStateFailed
+	}
+}
+
+// Constraints returns the constraints of the job.
+func (j *Job) Constraints() Constraints {
+	return Constraints{
+		CPUShare: j.CPUShare,
+		Memory:   j.Memory,
+		Nproc:    j.Nproc,
+	}
+}
+
+// JobsQuery is a scope implementation for common things to filter jobs by.
+type JobsQuery struct {
+	// If provided, finds jobs for the given app.
+	App *App
+
+	// If provided, finds jobs that are part of the given batch.
+	Batch *Batch
+
+	// If provided, finds jobs in the given state.
+	State *string
+}
+
+// scope implements the scope interface.
+func (q JobsQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.App != nil {
+		scope = append(scope, forApp(q.App))
+	}
+
+	if q.Batch != nil {
+		scope = append(scope, fieldEquals("batch_id", q.Batch.ID))
+	}
+
+	if q.State != nil {
+		scope = append(scope, fieldEquals("state", *q.State))
+	}
+
+	scope = append(scope, order("created_at asc"))
+
+	return scope.scope(db)
+}
+
+// jobs returns all jobs matching the scope, oldest first.
+func jobs(db *gorm.DB, scope scope) ([]*Job, error) {
+	var jobs []*Job
+	return jobs, find(db, scope, &jobs)
+}
+
+func jobsCreate(db *gorm.DB, job *Job) (*Job, error) {
+	return job, db.Create(job).Error
+}
+
+func jobsUpdate(db *gorm.DB, job *Job) error {
+	return db.Save(job).Error
+}
+
+func jobsDestroy(db *gorm.DB, job *Job) error {
+	return db.Delete(job).Error
+}
+
+// JobQueue periodically runs queued jobs, oldest first, once there's room for
+// them in the cluster of their app.
+type JobQueue struct {
+	*Empire
+
+	// How often to look for queued jobs that can be run.
+	Interval time.Duration
+}
+
+// Start starts running queued jobs, until the context is canceled. Errors, and
+// panics, are reported to the reporter in the context.
+func (q *JobQueue) Start(ctx context.Context) {
+	defer reporter.Monitor(ctx)
+
+	ticker := time.NewTicker(q.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := q.RunQueuedJobs(ctx); err != nil {
+				reporter.Report(ctx, err)
+			}
+		}
+	}
+}
+
+// RunQueuedJobs records the exit codes of the batch jobs that have finished,
+// then runs the queued jobs that fit in the cluster of their app, and returns
+// the jobs that were run. A job that doesn't fit holds back the jobs queued
+// after it, in the same cluster, so that jobs run in the order they were
+// queued. Jobs in a batch that's already running as many jobs as its
+// parallelism allows are skipped, without holding back other jobs.
+func (q *JobQueue) RunQueuedJobs(ctx context.Context) ([]*Job, error) {
+	var errors []error
+	if err := q.updateBatchJobs(ctx); err != nil {
+		errors = append(errors, err)
+	}
+
+	running, err := q.runningBatchJobs()
+	if err != nil {
+		return nil, err
+	}
+
+	state := JobStateQueued
+	queued, err := jobs(q.db, JobsQuery{State: &state})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ran      []*Job
+		blocked  = make(map[string]bool)
+		clusters = make(map[string][]*Machine)
+		batches  = make(map[string]*Batch)
+	)
+	for _, j := range queued {
+		var batch *Batch
+		if j.BatchID != nil {
+			batch = batches[*j.BatchID]
+			if batch == nil {
+				batch, err = batchesFind(q.db, BatchesQuery{ID: j.BatchID})
+				if err != nil {
+					errors = append(errors, err)
+					continue
+				}
+				batches[*j.BatchID] = batch
+			}
+
+			if running[batch.ID] >= batch.Parallelism {
+				continue
+			}
+		}
+
+		app, err := appsFind(q.db, AppsQuery{ID: &j.AppID})
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		if blocked[app.Cluster] {
+			continue
+		}
+
+		ms, ok := clusters[app.Cluster]
+		if !ok {
+			s, err := q.scheduler(app)
+			if err != nil {
+				errors = append(errors, err)
+				continue
+			}
+			ms, err = machines(ctx, s)
+			if err != nil {
+				errors = append(errors, err)
+				continue
+			}
+			clusters[app.Cluster] = ms
+		}
+
+		if !place(ms, j.Constraints(), 1) {
+			blocked[app.Cluster] = true
+			continue
+		}
+
+		if err := q.runJob(ctx, app, j); err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		if batch != nil {
+			running[batch.ID]++
+		}
+		ran = append(ran, j)
+	}
+
+	if len(errors) > 0 {
+		return ran, &multiError{Errors: errors}
+	}
+
+	return ran, nil
+}
+
+// runningBatchJobs returns the number of running jobs in each batch, keyed by
+// batch id.
+func (q *JobQueue) runningBatchJobs() (map[string]int, error) {
+	state := JobStateRunning
+	js, err := jobs(q.db, JobsQuery{State: &state})
+	if err != nil {
+		return nil, err
+	}
+
+	running := make(map[string]int)
+	for _, j := range js {
+		if j.BatchID != nil {
+			running[*j.BatchID]++
+		}
+	}
+	return running, nil
+}
+
+// runJob runs the job as a detached one-off process, using the current release
+// of the app. Jobs that aren't part of a batch are removed from the queue,
+// while batch jobs are kept, so that their exit code can be recorded.
+func (q *JobQueue) runJob(ctx context.Context, app *App, j *Job) error {
+	release, err := releasesFind(q.db, ReleasesQuery{App: app})
+	if err != nil {
+		return err
+	}
+
+	proc, ok := release.Formation[j.Type]
+	if !ok {
+		proc = Process{}
+	}
+	proc.Command = j.Command
+	proc.NoService = false
+	proc.Quantity = 1
+	proc.SetConstraints(j.Constraints())
+
+	var labels map[string]string
+	if j.BatchID != nil {
+		labels = map[string]string{jobLabel: j.ID}
+	}
+
+	if err := q.runner.run(ctx, release, j.Type, proc, RunOpts{
+		User: &User{Name: j.CreatedBy},
+		App:  app,
+	}, labels); err != nil {
+		return err
+	}
+
+	if j.BatchID == nil {
+		return jobsDestroy(q.db, j)
+	}
+
+	t := timex.Now()
+	j.State = JobStateRunning
+	j.StartedAt = &t
+	return jobsUpdate(q.db, j)
+}
+
+// updateBatchJobs records the exit codes of the running batch jobs whose
+// process has stopped. Jobs whose process the scheduler no longer knows about
+// are marked as lost.
+func (q *JobQueue) updateBatchJobs(ctx context.Context) error {
+	state := JobStateRunning
+	running, err := jobs(q.db, JobsQuery{State: &state})
+	if err != nil {
+		return err
+	}
+
+	byApp := make(map[string][]*Job)
+	for _, j := range running {
+		byApp[j.AppID] = append(byApp[j.AppID], j)
+	}
+
+	var errors []error
+	for appID, js := range byApp {
+		if err := q.updateAppBatchJobs(ctx, appID, js); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return &multiError{Errors: errors}
+	}
+
+	return nil
+}
+
+// updateAppBatchJobs updates the running batch jobs of a single app.
+func (q *JobQueue) updateAppBatchJobs(ctx context.Context, appID string, js []*Job) error {
+	app, err := appsFind(q.db, AppsQuery{ID: &appID})
+	if err != nil {
+		return err
+	}
+
+	scheduler, err := q.scheduler(app)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := scheduler.Tasks(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+
+	stopped, err := scheduler.StoppedTasks(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+
+	alive := make(map[string]bool)
+	for _, t := range tasks {
+		if id := t.Process.Labels[jobLabel]; id != "" && t.State != "STOPPED" {
+			alive[id] = true
+		}
+	}
+
+	exited := make(map[string]*twelvefactor.Task)
+	for _, t := range stopped {
+		if id := t.Process.Labels[jobLabel]; id != "" && t.ExitCode != nil {
+			exited[id] = t
+		}
+	}
+
+	for _, j := range js {
+		if t, ok := exited[j.ID]; ok {
+			j.finish(*t.ExitCode, t.UpdatedAt)
+		} else if !alive[j.ID] && j.StartedAt != nil && timex.Now().Sub(*j.StartedAt) > jobLostAfter {
+			t := timex.Now()
+			j.State = JobStateLost
+			j.FinishedAt = &t
+		} else {
+			continue
+		}
+
+		if err := jobsUpdate(q.db, j); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
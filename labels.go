@@ -0,0 +1,255 @@
+package empire
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"golang.org/x/net/context"
+)
+
+// MaxLabels is the maximum number of labels that an app can have.
+const MaxLabels = 64
+
+// appLabelPrefix is the prefix of the Docker labels that the labels of an app
+// are added to its containers with (e.g. "empire.app.label.tier").
+const appLabelPrefix = "empire.app.label."
+
+var (
+	// LabelKeyPattern is a regex pattern that label keys must conform to
+	// (e.g. "tier", "cost-center" or "example.com/owner").
+	LabelKeyPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9._/-]{0,61}[a-z0-9])?$`)
+
+	// LabelValuePattern is a regex pattern that label values must conform
+	// to.
+	LabelValuePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]{0,61}[A-Za-z0-9])?$`)
+)
+
+// ErrTooManyLabels is returned when an app would have more than MaxLabels
+// labels.
+var ErrTooManyLabels = &ValidationError{
+	fmt.Errorf("An app can have at most %d labels.", MaxLabels),
+}
+
+// Labels are arbitrary key/value pairs that are attached to an app, like its
+// tier, or cost center, so that apps can be selected with a LabelSelector.
+type Labels map[string]string
+
+// Scan implements the sql.Scanner interface.
+func (l *Labels) Scan(src interface{}) error {
+	if src == nil {
+		*l = nil
+		return nil
+	}
+
+	bytes, ok := src.([]byte)
+	if !ok {
+		return error(errors.New("Scan source was not []bytes"))
+	}
+
+	labels := make(Labels)
+	if err := json.Unmarshal(bytes, &labels); err != nil {
+		return err
+	}
+	*l = labels
+
+	return nil
+}
+
+// Value implements the driver.Value interface.
+func (l Labels) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.Value(raw), nil
+}
+
+// IsValid returns an error if a key, or value, isn't valid, or if there are too
+// many labels.
+func (l Labels) IsValid() error {
+	if len(l) > MaxLabels {
+		return ErrTooManyLabels
+	}
+
+	for k, v := range l {
+		if !LabelKeyPattern.MatchString(k) {
+			return &ValidationError{Err: fmt.Errorf("invalid label key %q", k)}
+		}
+		if !LabelValuePattern.MatchString(v) {
+			return &ValidationError{Err: fmt.Errorf("invalid value for label %s: %q", k, v)}
+		}
+	}
+
+	return nil
+}
+
+// Operators that a label requirement can use.
+const (
+	labelEquals    = "="
+	labelNotEquals = "!="
+	labelExists    = "exists"
+	labelNotExists = "!exists"
+)
+
+// labelRequirement is a single requirement of a LabelSelector.
+type labelRequirement struct {
+	Key      string
+	Operator string
+	Value    string
+}
+
+func (r labelRequirement) matches(l Labels) bool {
+	v, ok := l[r.Key]
+	switch r.Operator {
+	case labelEquals:
+		return ok && v == r.Value
+	case labelNotEquals:
+		return !ok || v != r.Value
+	case labelExists:
+		return ok
+	case labelNotExists:
+		return !ok
+	}
+	return false
+}
+
+func (r labelRequirement) String() string {
+	switch r.Operator {
+	case labelExists:
+		return r.Key
+	case labelNotExists:
+		return "!" + r.Key
+	}
+	return r.Key + r.Operator + r.Value
+}
+
+// LabelSelector selects apps by their labels. Selectors are a comma separated
+// list of requirements, all of which have to match:
+//
+//	tier=web       the tier label is "web"
+//	tier!=web      the tier label isn't "web", or isn't set
+//	cost-center    the cost-center label is set
+//	!cost-center   the cost-center label isn't set
+//
+// An empty selector matches every app.
+type LabelSelector []labelRequirement
+
+// ParseLabelSelector parses a label selector, like "tier=web,!deprecated".
+func ParseLabelSelector(s string) (LabelSelector, error) {
+	var selector LabelSelector
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var r labelRequirement
+		switch {
+		case strings.Contains(part, "!="):
+			kv := strings.SplitN(part, "!=", 2)
+			r = labelRequirement{Key: kv[0], Operator: labelNotEquals, Value: kv[1]}
+		case strings.Contains(part, "="):
+			kv := strings.SplitN(strings.Replace(part, "==", "=", 1), "=", 2)
+			r = labelRequirement{Key: kv[0], Operator: labelEquals, Value: kv[1]}
+		case strings.HasPrefix(part, "!"):
+			r = labelRequirement{Key: part[1:], Operator: labelNotExists}
+		default:
+			r = labelRequirement{Key: part, Operator: labelExists}
+		}
+
+		r.Key, r.Value = strings.TrimSpace(r.Key), strings.TrimSpace(r.Value)
+		if !LabelKeyPattern.MatchString(r.Key) {
+			return nil, &ValidationError{Err: fmt.Errorf("invalid label selector %q: invalid key %q", s, r.Key)}
+		}
+		if (r.Operator == labelEquals || r.Operator == labelNotEquals) && !LabelValuePattern.MatchString(r.Value) {
+			return nil, &ValidationError{Err: fmt.Errorf("invalid label selector %q: invalid value %q", s, r.Value)}
+		}
+
+		selector = append(selector, r)
+	}
+	return selector, nil
+}
+
+// Matches returns true if the labels match every requirement of the selector.
+func (s LabelSelector) Matches(l Labels) bool {
+	for _, r := range s {
+		if !r.matches(l) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s LabelSelector) String() string {
+	parts := make([]string, len(s))
+	for i, r := range s {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// scope implements the scope interface, by filtering apps with the labels
+// column.
+func (s LabelSelector) scope(db *gorm.DB) *gorm.DB {
+	for _, r := range s {
+		switch r.Operator {
+		case labelEquals:
+			db = db.Where("labels->>? = ?", r.Key, r.Value)
+		case labelNotEquals:
+			db = db.Where("(labels->>? is null or labels->>? != ?)", r.Key, r.Key, r.Value)
+		case labelExists:
+			db = db.Where("labels->>? is not null", r.Key)
+		case labelNotExists:
+			db = db.Where("labels->>? is null", r.Key)
+		}
+	}
+	return db
+}
+
+// SetLabelsOpts are options provided when changing the labels of an app.
+type SetLabelsOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// The labels to change. A nil value removes the label.
+	Labels map[string]*string
+}
+
+// SetLabels adds, changes or removes labels of the app.
+func (e *Empire) SetLabels(ctx context.Context, opts SetLabelsOpts) error {
+	if err := e.authorize(opts.User, opts.App, ActionAdmin); err != nil {
+		return err
+	}
+
+	labels := make(Labels)
+	for k, v := range opts.App.Labels {
+		labels[k] = v
+	}
+	for k, v := range opts.Labels {
+		if v == nil {
+			delete(labels, k)
+			continue
+		}
+		labels[k] = *v
+	}
+
+	if err := labels.IsValid(); err != nil {
+		return err
+	}
+
+	opts.App.Labels = labels
+	return appsUpdate(e.db, opts.App)
+}
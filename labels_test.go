@@ -0,0 +1,114 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	tests := []struct {
+		in       string
+		selector LabelSelector
+		err      string
+	}{
+		{"", nil, ""},
+		{"tier=web", LabelSelector{{Key: "tier", Operator: labelEquals, Value: "web"}}, ""},
+		{"tier==web", LabelSelector{{Key: "tier", Operator: labelEquals, Value: "web"}}, ""},
+		{"tier!=web", LabelSelector{{Key: "tier", Operator: labelNotEquals, Value: "web"}}, ""},
+		{"cost-center", LabelSelector{{Key: "cost-center", Operator: labelExists}}, ""},
+		{"!deprecated", LabelSelector{{Key: "deprecated", Operator: labelNotExists}}, ""},
+		{"tier=web, !deprecated", LabelSelector{
+			{Key: "tier", Operator: labelEquals, Value: "web"},
+			{Key: "deprecated", Operator: labelNotExists},
+		}, ""},
+		{"Tier=web", nil, `invalid label selector "Tier=web": invalid key "Tier"`},
+		{"tier=", nil, `invalid label selector "tier=": invalid value ""`},
+		{"tier=web app", nil, `invalid label selector "tier=web app": invalid value "web app"`},
+	}
+
+	for _, tt := range tests {
+		selector, err := ParseLabelSelector(tt.in)
+		if tt.err != "" {
+			assert.EqualError(t, err, tt.err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, tt.selector, selector)
+	}
+}
+
+func TestLabelSelector_Matches(t *testing.T) {
+	labels := Labels{"tier": "web", "cost-center": "1234"}
+
+	tests := []struct {
+		selector string
+		matches  bool
+	}{
+		{"", true},
+		{"tier=web", true},
+		{"tier=worker", false},
+		{"tier!=worker", true},
+		{"tier!=web", false},
+		{"team!=platform", true},
+		{"cost-center", true},
+		{"team", false},
+		{"!team", true},
+		{"!tier", false},
+		{"tier=web,cost-center=1234", true},
+		{"tier=web,cost-center=5678", false},
+	}
+
+	for _, tt := range tests {
+		selector, err := ParseLabelSelector(tt.selector)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.matches, selector.Matches(labels), tt.selector)
+		assert.Equal(t, tt.selector, selector.String())
+	}
+
+	selector, err := ParseLabelSelector("!team")
+	assert.NoError(t, err)
+	assert.True(t, selector.Matches(nil))
+}
+
+func TestLabelSelector_Scope(t *testing.T) {
+	selector, err := ParseLabelSelector("tier=web,team!=platform,cost-center,!deprecated")
+	assert.NoError(t, err)
+
+	tests := scopeTests{
+		{LabelSelector{}, "", []interface{}{}},
+		{selector, "WHERE (labels->>$1 = $2) AND ((labels->>$3 is null or labels->>$4 != $5)) AND (labels->>$6 is not null) AND (labels->>$7 is null)", []interface{}{"tier", "web", "team", "team", "platform", "cost-center", "deprecated"}},
+		{AppsQuery{Selector: selector[:1]}, "WHERE (deleted_at is null) AND (labels->>$1 = $2)", []interface{}{"tier", "web"}},
+	}
+
+	tests.Run(t)
+}
+
+func TestLabels_IsValid(t *testing.T) {
+	tests := []struct {
+		labels Labels
+		err    string
+	}{
+		{nil, ""},
+		{Labels{"tier": "web", "cost-center": "1234", "example.com/owner": "payments"}, ""},
+		{Labels{"Tier": "web"}, `invalid label key "Tier"`},
+		{Labels{"tier-": "web"}, `invalid label key "tier-"`},
+		{Labels{"tier": ""}, `invalid value for label tier: ""`},
+		{Labels{"tier": "web app"}, `invalid value for label tier: "web app"`},
+	}
+
+	for _, tt := range tests {
+		err := tt.labels.IsValid()
+		if tt.err == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, tt.err)
+		}
+	}
+
+	tooMany := make(Labels)
+	for i := 0; i <= MaxLabels; i++ {
+		tooMany[string(rune('a'+i%26))+string(rune('a'+i/26))] = "x"
+	}
+	assert.Equal(t, ErrTooManyLabels, tooMany.IsValid())
+}
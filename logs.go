@@ -1,8 +1,11 @@
 package empire
 
 import (
+	"errors"
 	"io"
 	"time"
+
+	"golang.org/x/net/context"
 )
 
 type LogsStreamer interface {
@@ -17,3 +20,19 @@ func (s *nullLogsStreamer) StreamLogs(app *App, w io.Writer, duration time.Durat
 	io.WriteString(w, "Logs are disabled\n")
 	return nil
 }
+
+// ErrNoOutput is returned when there's no captured output for a one-off
+// process, either because the id is unknown, or because the process hasn't
+// written anything yet.
+var ErrNoOutput = errors.New("no output was captured for this job")
+
+// OutputStore captures the output of detached one-off processes, so that it
+// can be retrieved after they've finished, without staying attached to them.
+type OutputStore interface {
+	// Logging returns the logging configuration that sends the output of a
+	// process to the store, under the given id.
+	Logging(app *App, id string) *Logging
+
+	// Output writes the output captured under the given id to w.
+	Output(ctx context.Context, app *App, id string, w io.Writer) error
+}
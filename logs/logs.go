@@ -5,12 +5,14 @@ import (
 	"io"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
 	"github.com/ejholmes/cloudwatch"
 	"github.com/remind101/empire"
 	"github.com/remind101/empire/internal/uuid"
 	"github.com/remind101/kinesumer"
+	"golang.org/x/net/context"
 )
 
 type KinesisLogsStreamer struct{}
@@ -57,6 +59,79 @@ func RecordToCloudWatch(group string, config client.ConfigProvider) empire.RunRe
 	}
 }
 
+// CaptureToCloudWatch returns an OutputStore that captures the output of
+// detached runs in CloudWatch Logs, using the awslogs log driver.
+func CaptureToCloudWatch(group string, config client.ConfigProvider) empire.OutputStore {
+	return &cloudWatchOutputs{
+		group:          group,
+		cloudwatchlogs: cloudwatchlogs.New(config),
+	}
+}
+
+// cloudWatchOutputs is an empire.OutputStore backed by CloudWatch Logs. The
+// output of a process is written to a log stream prefixed with the app id and
+// the output id.
+type cloudWatchOutputs struct {
+	group          string
+	cloudwatchlogs *cloudwatchlogs.CloudWatchLogs
+}
+
+func (s *cloudWatchOutputs) Logging(app *empire.App, id string) *empire.Logging {
+	return &empire.Logging{
+		Driver: "awslogs",
+		Options: map[string]string{
+			"awslogs-group":         s.group,
+			"awslogs-region":        *s.cloudwatchlogs.Config.Region,
+			"awslogs-stream-prefix": streamPrefix(app, id),
+		},
+	}
+}
+
+func (s *cloudWatchOutputs) Output(ctx context.Context, app *empire.App, id string, w io.Writer) error {
+	resp, err := s.cloudwatchlogs.DescribeLogStreams(&cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName:        aws.String(s.group),
+		LogStreamNamePrefix: aws.String(streamPrefix(app, id) + "/"),
+	})
+	if err != nil {
+		return err
+	}
+	if len(resp.LogStreams) == 0 {
+		return empire.ErrNoOutput
+	}
+
+	input := &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  aws.String(s.group),
+		LogStreamName: resp.LogStreams[0].LogStreamName,
+		StartFromHead: aws.Bool(true),
+	}
+	for {
+		resp, err := s.cloudwatchlogs.GetLogEvents(input)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range resp.Events {
+			if _, err := io.WriteString(w, aws.StringValue(e.Message)+"\n"); err != nil {
+				return err
+			}
+		}
+
+		// The same token is returned once the end of the stream is
+		// reached.
+		if resp.NextForwardToken == nil || aws.StringValue(resp.NextForwardToken) == aws.StringValue(input.NextToken) {
+			return nil
+		}
+		input.NextToken = resp.NextForwardToken
+	}
+}
+
+// streamPrefix returns the prefix of the log stream that the output of a
+// process is written to. The awslogs log driver appends the container name,
+// and the task id.
+func streamPrefix(app *empire.App, id string) string {
+	return fmt.Sprintf("%s/%s", app.ID, id)
+}
+
 // writerWithURL is an io.Writer that has a URL() method.
 type writerWithURL struct {
 	io.Writer
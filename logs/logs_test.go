@@ -0,0 +1,23 @@
+package logs
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/remind101/empire"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloudWatchOutputs_Logging(t *testing.T) {
+	s := CaptureToCloudWatch("runs", session.New(&aws.Config{Region: aws.String("us-east-1")}))
+	l := s.Logging(&empire.App{ID: "1234"}, "abcd")
+	assert.Equal(t, &empire.Logging{
+		Driver: "awslogs",
+		Options: map[string]string{
+			"awslogs-group":         "runs",
+			"awslogs-region":        "us-east-1",
+			"awslogs-stream-prefix": "1234/abcd",
+		},
+	}, l)
+}
@@ -0,0 +1,102 @@
+package empire
+
+import (
+	"fmt"
+
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/remind101/empire/pkg/image"
+	"github.com/remind101/empire/twelvefactor"
+	"golang.org/x/net/context"
+)
+
+// MeshSidecarName is the name of the Envoy sidecar that's injected into
+// processes that join the service mesh.
+const MeshSidecarName = "envoy"
+
+// Mesh configures the Envoy sidecar that's injected into processes that join
+// the service mesh. Envoy gets its listeners, routes and certificates from the
+// control plane, which is what provides mTLS and retries between apps.
+//
+// The sidecar is configured through environment variables, so the image is
+// expected to render an Envoy bootstrap config from them:
+//
+//	EMPIRE_MESH_CONTROL_PLANE  The address of the xDS control plane.
+//	EMPIRE_MESH_CLUSTER        The service that the node belongs to (the app name).
+//	EMPIRE_MESH_NODE           A unique id for the node (e.g. "acme-inc.web").
+type Mesh struct {
+	// The Envoy image to inject.
+	Image image.Image
+
+	// The address of the xDS control plane (e.g. "xds.empire:18000").
+	ControlPlane string
+
+	// The amount of memory to reserve for the sidecar. The zero value
+	// uses DefaultSidecarMemory.
+	Memory constraints.Memory
+}
+
+// sidecar returns the Envoy sidecar for the given process.
+func (m *Mesh) sidecar(app *twelvefactor.Manifest, p *twelvefactor.Process) *twelvefactor.Sidecar {
+	memory := m.Memory
+	if memory == 0 {
+		memory = DefaultSidecarMemory
+	}
+
+	return &twelvefactor.Sidecar{
+		Name:  MeshSidecarName,
+		Image: m.Image,
+		Env: map[string]string{
+			"EMPIRE_MESH_CONTROL_PLANE": m.ControlPlane,
+			"EMPIRE_MESH_CLUSTER":       app.Name,
+			"EMPIRE_MESH_NODE":          fmt.Sprintf("%s.%s", app.Name, p.Type),
+		},
+		Memory: uint(memory),
+		// If Envoy exits, the process can't talk to other apps, so it
+		// should be replaced.
+		Essential: true,
+	}
+}
+
+// injectMesh adds the Envoy sidecar to the processes in the manifest that have
+// joined the service mesh.
+func injectMesh(m *Mesh, app *twelvefactor.Manifest, f Formation) {
+	if m == nil {
+		return
+	}
+
+	for _, p := range app.Processes {
+		if f[p.Type].Mesh {
+			p.Sidecars = append(p.Sidecars, m.sidecar(app, p))
+		}
+	}
+}
+
+// checkMesh returns an AdmissionDeniedError if a process joins the service
+// mesh when it's not enabled, or if it already has a sidecar with the name of
+// the Envoy sidecar.
+func (e *Empire) checkMesh(ctx context.Context, req *AdmissionRequest) error {
+	var reasons []string
+	for _, name := range req.Release.Formation.Types() {
+		p := req.Release.Formation[name]
+		if !p.Mesh {
+			continue
+		}
+
+		if e.Mesh == nil {
+			reasons = append(reasons, fmt.Sprintf("%s can't join the service mesh, since it's not enabled", name))
+			continue
+		}
+
+		for _, s := range p.Sidecars {
+			if s.Name == MeshSidecarName {
+				reasons = append(reasons, fmt.Sprintf("%s can't join the service mesh, since it already has a sidecar named %s", name, MeshSidecarName))
+			}
+		}
+	}
+
+	if len(reasons) > 0 {
+		return &AdmissionDeniedError{Reasons: reasons}
+	}
+
+	return nil
+}
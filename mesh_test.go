@@ -0,0 +1,71 @@
+package empire
+
+import (
+	"testing"
+
+	. "github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/remind101/empire/pkg/image"
+	"github.com/remind101/empire/twelvefactor"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestInjectMesh(t *testing.T) {
+	mesh := &Mesh{
+		Image:        image.Image{Repository: "envoyproxy/envoy", Tag: "v1.8.0"},
+		ControlPlane: "xds.empire:18000",
+		Memory:       constraints.Memory(64 * MB),
+	}
+
+	app := &twelvefactor.Manifest{
+		Name: "acme-inc",
+		Processes: []*twelvefactor.Process{
+			{Type: "web"},
+			{Type: "worker"},
+		},
+	}
+
+	injectMesh(mesh, app, Formation{
+		"web":    Process{Mesh: true},
+		"worker": Process{},
+	})
+
+	assert.Equal(t, []*twelvefactor.Sidecar{
+		{
+			Name:  "envoy",
+			Image: mesh.Image,
+			Env: map[string]string{
+				"EMPIRE_MESH_CONTROL_PLANE": "xds.empire:18000",
+				"EMPIRE_MESH_CLUSTER":       "acme-inc",
+				"EMPIRE_MESH_NODE":          "acme-inc.web",
+			},
+			Memory:    uint(64 * MB),
+			Essential: true,
+		},
+	}, app.Processes[0].Sidecars)
+	assert.Nil(t, app.Processes[1].Sidecars)
+}
+
+func TestCheckMesh(t *testing.T) {
+	release := &Release{
+		Formation: Formation{
+			"web":    Process{Mesh: true, Sidecars: []*Sidecar{{Name: "envoy"}}},
+			"api":    Process{Mesh: true},
+			"worker": Process{},
+		},
+	}
+
+	e := &Empire{}
+	err := e.checkMesh(context.Background(), &AdmissionRequest{Release: release})
+	assert.Equal(t, &AdmissionDeniedError{Reasons: []string{
+		"api can't join the service mesh, since it's not enabled",
+		"web can't join the service mesh, since it's not enabled",
+	}}, err)
+
+	e.Mesh = &Mesh{}
+	err = e.checkMesh(context.Background(), &AdmissionRequest{Release: release})
+	assert.Equal(t, &AdmissionDeniedError{Reasons: []string{
+		"web can't join the service mesh, since it already has a sidecar named envoy",
+	}}, err)
+}
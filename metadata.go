@@ -0,0 +1,212 @@
+package empire
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/remind101/empire/twelvefactor"
+	"golang.org/x/net/context"
+)
+
+var (
+	// ErrInvalidMetadataToken is returned when a metadata token wasn't
+	// issued by the MetadataService.
+	ErrInvalidMetadataToken = errors.New("invalid metadata token")
+
+	// ErrMetadataTask is returned when the task that's querying its
+	// metadata isn't running for the process that the token was issued to.
+	ErrMetadataTask = errors.New("task not found")
+)
+
+// MetadataService lets processes query their own metadata at runtime, instead
+// of relying on environment variables that were set when they were scheduled
+// (e.g. EMPIRE_PROCESS_SCALE is stale after `emp scale`). Each process is
+// provided with two environment variables:
+//
+//	EMPIRE_METADATA_URL    The URL of the metadata endpoint.
+//	EMPIRE_METADATA_TOKEN  A token that identifies the app and process.
+//
+// A token can only be used to query the metadata of the process that it was
+// issued to.
+type MetadataService struct {
+	// The URL that processes can reach the metadata endpoint at.
+	URL string
+
+	// The secret used to sign tokens.
+	Secret []byte
+}
+
+// Token returns the token for a process of an app.
+func (m *MetadataService) Token(app *App, process string) string {
+	payload := fmt.Sprintf("%s:%s", app.ID, process)
+	return encodeSegment([]byte(payload)) + "." + encodeSegment(m.sign(payload))
+}
+
+// ParseToken verifies a token, and returns the id of the app and the process
+// that it was issued to.
+func (m *MetadataService) ParseToken(token string) (appID, process string, err error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return "", "", ErrInvalidMetadataToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", "", ErrInvalidMetadataToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", "", ErrInvalidMetadataToken
+	}
+
+	if !hmac.Equal(signature, m.sign(string(payload))) {
+		return "", "", ErrInvalidMetadataToken
+	}
+
+	fields := strings.SplitN(string(payload), ":", 2)
+	if len(fields) != 2 {
+		return "", "", ErrInvalidMetadataToken
+	}
+
+	return fields[0], fields[1], nil
+}
+
+func (m *MetadataService) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, m.Secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// injectMetadata adds the environment variables for querying the metadata
+// endpoint to each process in the manifest.
+func injectMetadata(m *MetadataService, app *App, manifest *twelvefactor.Manifest) {
+	if m == nil {
+		return
+	}
+
+	for _, p := range manifest.Processes {
+		p.Env["EMPIRE_METADATA_URL"] = m.URL
+		p.Env["EMPIRE_METADATA_TOKEN"] = m.Token(app, p.Type)
+	}
+}
+
+// DynoMetadata is the metadata that a process can query about itself.
+type DynoMetadata struct {
+	// The app that the process belongs to.
+	App *App
+
+	// The release that the process is running. If the task isn't known,
+	// this is the current release of the app.
+	Release *Release
+
+	// The name of the process.
+	Process string
+
+	// The number of instances of the process that should be running, and
+	// their constraints, in the current release.
+	Quantity    int
+	Constraints Constraints
+
+	// If the task was provided, the task, and its number within the
+	// running instances of the process (starting at 1).
+	Task     *Task
+	Instance int
+}
+
+// DynoMetadataOpts are options provided when querying the metadata of a
+// process.
+type DynoMetadataOpts struct {
+	// The token that was issued to the process.
+	Token string
+
+	// If provided, the id (or ARN) of the task that's asking.
+	Task string
+}
+
+// DynoMetadata returns the metadata of the process that the token was issued
+// to.
+func (e *Empire) DynoMetadata(ctx context.Context, opts DynoMetadataOpts) (*DynoMetadata, error) {
+	if e.Metadata == nil {
+		return nil, errors.New("the metadata endpoint isn't enabled")
+	}
+
+	appID, process, err := e.Metadata.ParseToken(opts.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := appsFind(e.db, AppsQuery{ID: &appID})
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := releasesFind(e.db, ReleasesQuery{App: app})
+	if err != nil {
+		return nil, err
+	}
+
+	m := &DynoMetadata{
+		App:     app,
+		Release: release,
+		Process: process,
+	}
+
+	if p, ok := release.Formation[process]; ok {
+		m.Quantity = p.Quantity
+		m.Constraints = p.Constraints()
+	}
+
+	if opts.Task == "" {
+		return m, nil
+	}
+
+	// Accept a task ARN, which is what the ECS task metadata endpoint
+	// returns.
+	id := opts.Task
+	if i := strings.LastIndex(id, "/"); i >= 0 {
+		id = id[i+1:]
+	}
+
+	tasks, err := e.tasks.Tasks(ctx, TasksQuery{App: app})
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, t := range tasks {
+		if t.Type != process {
+			continue
+		}
+		ids = append(ids, t.ID)
+		if t.ID == id {
+			m.Task = t
+		}
+	}
+
+	if m.Task == nil {
+		return nil, ErrMetadataTask
+	}
+
+	// Instances are numbered in the order of their ids, so the numbers
+	// stay stable until instances are added or removed.
+	sort.Strings(ids)
+	m.Instance = sort.SearchStrings(ids, id) + 1
+
+	if m.Task.Version != release.Version {
+		m.Release, err = releasesFind(e.db, ReleasesQuery{App: app, Version: &m.Task.Version})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,50 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/twelvefactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataService_Token(t *testing.T) {
+	m := &MetadataService{Secret: []byte("secret")}
+
+	token := m.Token(&App{ID: "1234"}, "web")
+	appID, process, err := m.ParseToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "1234", appID)
+	assert.Equal(t, "web", process)
+
+	// A token for another process shouldn't be accepted with the
+	// signature of this one.
+	other := m.Token(&App{ID: "1234"}, "worker")
+	_, _, err = m.ParseToken(other[:len(other)-43] + token[len(token)-43:])
+	assert.Equal(t, ErrInvalidMetadataToken, err)
+
+	// Tokens signed with a different secret shouldn't be accepted.
+	_, _, err = (&MetadataService{Secret: []byte("other")}).ParseToken(token)
+	assert.Equal(t, ErrInvalidMetadataToken, err)
+
+	_, _, err = m.ParseToken("")
+	assert.Equal(t, ErrInvalidMetadataToken, err)
+}
+
+func TestInjectMetadata(t *testing.T) {
+	m := &MetadataService{URL: "http://empire.internal/metadata", Secret: []byte("secret")}
+	app := &App{ID: "1234"}
+	manifest := &twelvefactor.Manifest{
+		Processes: []*twelvefactor.Process{
+			{Type: "web", Env: map[string]string{}},
+		},
+	}
+
+	injectMetadata(m, app, manifest)
+	assert.Equal(t, "http://empire.internal/metadata", manifest.Processes[0].Env["EMPIRE_METADATA_URL"])
+	assert.Equal(t, m.Token(app, "web"), manifest.Processes[0].Env["EMPIRE_METADATA_TOKEN"])
+
+	// Nothing is injected when the metadata endpoint isn't enabled.
+	manifest.Processes[0].Env = map[string]string{}
+	injectMetadata(nil, app, manifest)
+	assert.Empty(t, manifest.Processes[0].Env)
+}
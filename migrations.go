@@ -695,4 +695,596 @@ ALTER TABLE apps ADD COLUMN exposure TEXT NOT NULL default 'private'`,
 			`ALTER TABLE apps DROP COLUMN deleted_at`,
 		}),
 	},
+
+	// Adds team ownership of apps and scoped credentials for private
+	// registries.
+	{
+		ID: 22,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE apps ADD COLUMN team text NOT NULL DEFAULT ''`,
+			`CREATE TABLE registry_credentials (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  registry text NOT NULL,
+  username text NOT NULL DEFAULT '',
+  password text NOT NULL DEFAULT '',
+  credentials_parameter text NOT NULL DEFAULT '',
+  team text NOT NULL DEFAULT '',
+  app_id uuid references apps(id) ON DELETE CASCADE,
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE UNIQUE INDEX index_registry_credentials_on_scope ON registry_credentials USING btree (registry, team, COALESCE(app_id, '00000000-0000-0000-0000-000000000000'))`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE registry_credentials`,
+			`ALTER TABLE apps DROP COLUMN team`,
+		}),
+	},
+
+	// Adds freeze windows, which block new releases.
+	{
+		ID: 23,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE freeze_windows (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  starts_at timestamp without time zone NOT NULL,
+  ends_at timestamp without time zone NOT NULL,
+  reason text NOT NULL DEFAULT '',
+  team text NOT NULL DEFAULT '',
+  app_id uuid references apps(id) ON DELETE CASCADE,
+  created_by text NOT NULL DEFAULT '',
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE INDEX index_freeze_windows_on_ends_at ON freeze_windows USING btree (ends_at)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE freeze_windows`,
+		}),
+	},
+
+	// Adds grants and team memberships for role based access control.
+	{
+		ID: 24,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE grants (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  username text NOT NULL DEFAULT '',
+  team text NOT NULL DEFAULT '',
+  app_id uuid references apps(id) ON DELETE CASCADE,
+  role text NOT NULL,
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE TABLE team_members (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  team text NOT NULL,
+  username text NOT NULL,
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE UNIQUE INDEX index_team_members_on_team_and_username ON team_members USING btree (team, username)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE grants`,
+			`DROP TABLE team_members`,
+		}),
+	},
+
+	// Adds API tokens for service accounts.
+	{
+		ID: 25,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE api_tokens (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  name text NOT NULL,
+  token_hash text NOT NULL,
+  scope json NOT NULL,
+  created_by text NOT NULL DEFAULT '',
+  created_at timestamp without time zone default (now() at time zone 'utc'),
+  last_used_at timestamp without time zone
+)`,
+			`CREATE UNIQUE INDEX index_api_tokens_on_token_hash ON api_tokens USING btree (token_hash)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE api_tokens`,
+		}),
+	},
+
+	// Adds protected apps, and TOTP secrets for two factor confirmation of
+	// destructive operations on them.
+	{
+		ID: 26,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE apps ADD COLUMN protected bool NOT NULL DEFAULT false`,
+			`CREATE TABLE two_factor_secrets (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  username text NOT NULL,
+  secret text NOT NULL,
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE UNIQUE INDEX index_two_factor_secrets_on_username ON two_factor_secrets USING btree (username)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE two_factor_secrets`,
+			`ALTER TABLE apps DROP COLUMN protected`,
+		}),
+	},
+
+	// Adds the cluster that an app is assigned to.
+	{
+		ID: 27,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE apps ADD COLUMN cluster text NOT NULL DEFAULT ''`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE apps DROP COLUMN cluster`,
+		}),
+	},
+
+	// Adds quotas for teams and apps.
+	{
+		ID: 28,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE quotas (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  team text NOT NULL DEFAULT '',
+  app_id uuid references apps(id) ON DELETE CASCADE,
+  max_instances integer NOT NULL DEFAULT 0,
+  max_memory bigint NOT NULL DEFAULT 0,
+  max_runs integer NOT NULL DEFAULT 0,
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE UNIQUE INDEX index_quotas_on_scope ON quotas USING btree (team, COALESCE(app_id, '00000000-0000-0000-0000-000000000000'::uuid))`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE quotas`,
+		}),
+	},
+
+	// Adds usage periods, for reporting the resources that apps reserved.
+	{
+		ID: 29,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE usage_periods (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  app_id uuid NOT NULL references apps(id) ON DELETE CASCADE,
+  app_name text NOT NULL,
+  team text NOT NULL DEFAULT '',
+  instances integer NOT NULL,
+  memory bigint NOT NULL,
+  started_at timestamp without time zone NOT NULL,
+  ended_at timestamp without time zone
+)`,
+			`CREATE INDEX index_usage_periods_on_app_id ON usage_periods USING btree (app_id)`,
+			`CREATE INDEX index_usage_periods_on_started_at ON usage_periods USING btree (started_at)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE usage_periods`,
+		}),
+	},
+
+	// Adds ingress rules, for isolating apps from each other.
+	{
+		ID: 30,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE ingress_rules (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  app_id uuid NOT NULL references apps(id) ON DELETE CASCADE,
+  source_app_id uuid NOT NULL references apps(id) ON DELETE CASCADE,
+  port integer NOT NULL,
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE UNIQUE INDEX index_ingress_rules_on_app_id_and_source_app_id_and_port ON ingress_rules USING btree (app_id, source_app_id, port)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE ingress_rules`,
+		}),
+	},
+
+	// Adds the share of traffic that's sent to the previous release of an
+	// app.
+	{
+		ID: 31,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE apps ADD COLUMN previous_release_weight integer NOT NULL DEFAULT 0`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE apps DROP COLUMN previous_release_weight`,
+		}),
+	},
+
+	// Adds router settings to apps.
+	{
+		ID: 32,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE apps ADD COLUMN router_settings json`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE apps DROP COLUMN router_settings`,
+		}),
+	},
+
+	// Adds routing rules, for routing requests to the processes of an app
+	// by host and path.
+	{
+		ID: 33,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE routing_rules (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  app_id uuid NOT NULL references apps(id) ON DELETE CASCADE,
+  host text NOT NULL DEFAULT '',
+  path text NOT NULL DEFAULT '',
+  process text NOT NULL,
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE UNIQUE INDEX index_routing_rules_on_app_id_and_host_and_path ON routing_rules USING btree (app_id, host, path)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE routing_rules`,
+		}),
+	},
+
+	// Persists the scale of each process on the app, so that it survives
+	// processes being removed from, and added back to, the Procfile.
+	{
+		ID: 34,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE apps ADD COLUMN formation json`,
+			`UPDATE apps SET formation = (SELECT formation FROM releases WHERE releases.app_id = apps.id ORDER BY version DESC LIMIT 1)`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE apps DROP COLUMN formation`,
+		}),
+	},
+
+	// Records the image from the last app spec that was applied, and what
+	// it resolved to, so that specs which reference tags aren't redeployed.
+	{
+		ID: 35,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE apps ADD COLUMN applied_image text`,
+			`ALTER TABLE apps ADD COLUMN applied_slug_image text`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE apps DROP COLUMN applied_image`,
+			`ALTER TABLE apps DROP COLUMN applied_slug_image`,
+		}),
+	},
+
+	// Records who created each release, and from what.
+	{
+		ID: 36,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE releases ADD COLUMN created_by text NOT NULL DEFAULT ''`,
+			`ALTER TABLE releases ADD COLUMN source text NOT NULL DEFAULT ''`,
+			`ALTER TABLE releases ADD COLUMN message text NOT NULL DEFAULT ''`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE releases DROP COLUMN created_by`,
+			`ALTER TABLE releases DROP COLUMN source`,
+			`ALTER TABLE releases DROP COLUMN message`,
+		}),
+	},
+
+	// Adds a timeout to deploys, stored in nanoseconds.
+	{
+		ID: 37,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE apps ADD COLUMN deploy_timeout bigint NOT NULL DEFAULT 0`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE apps DROP COLUMN deploy_timeout`,
+		}),
+	},
+
+	// Adds a queue for one-off processes that are waiting to run.
+	{
+		ID: 38,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE jobs (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  app_id uuid NOT NULL references apps(id) ON DELETE CASCADE,
+  type text NOT NULL,
+  command json NOT NULL,
+  cpu_share integer NOT NULL DEFAULT 0,
+  memory bigint NOT NULL DEFAULT 0,
+  nproc bigint NOT NULL DEFAULT 0,
+  created_by text NOT NULL DEFAULT '',
+  reason text NOT NULL DEFAULT '',
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE INDEX index_jobs_on_created_at ON jobs USING btree (created_at)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE jobs`,
+		}),
+	},
+
+	// Adds batches of one-off jobs, and tracks the state of batch jobs.
+	{
+		ID: 39,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE batches (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  app_id uuid NOT NULL references apps(id) ON DELETE CASCADE,
+  parallelism integer NOT NULL DEFAULT 1,
+  created_by text NOT NULL DEFAULT '',
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE INDEX index_batches_on_app_id ON batches USING btree (app_id)`,
+			`ALTER TABLE jobs ADD COLUMN batch_id uuid references batches(id) ON DELETE CASCADE`,
+			`ALTER TABLE jobs ADD COLUMN state text NOT NULL DEFAULT 'queued'`,
+			`ALTER TABLE jobs ADD COLUMN exit_code integer`,
+			`ALTER TABLE jobs ADD COLUMN started_at timestamp without time zone`,
+			`ALTER TABLE jobs ADD COLUMN finished_at timestamp without time zone`,
+			`CREATE INDEX index_jobs_on_batch_id ON jobs USING btree (batch_id)`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE jobs DROP COLUMN batch_id`,
+			`ALTER TABLE jobs DROP COLUMN state`,
+			`ALTER TABLE jobs DROP COLUMN exit_code`,
+			`ALTER TABLE jobs DROP COLUMN started_at`,
+			`ALTER TABLE jobs DROP COLUMN finished_at`,
+			`DROP TABLE batches`,
+		}),
+	},
+
+	// Adds a limit to the memory that a single one-off process can reserve.
+	{
+		ID: 40,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE quotas ADD COLUMN max_run_memory bigint NOT NULL DEFAULT 0`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE quotas DROP COLUMN max_run_memory`,
+		}),
+	},
+
+	// Adds a history of the invocations of scheduled processes.
+	{
+		ID: 41,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE cron_runs (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  app_id uuid NOT NULL references apps(id) ON DELETE CASCADE,
+  process_type text NOT NULL,
+  trigger text NOT NULL,
+  state text NOT NULL,
+  task_id text NOT NULL DEFAULT '',
+  exit_code integer,
+  output text NOT NULL DEFAULT '',
+  created_by text NOT NULL DEFAULT '',
+  created_at timestamp without time zone default (now() at time zone 'utc'),
+  started_at timestamp without time zone,
+  finished_at timestamp without time zone
+)`,
+			`CREATE INDEX index_cron_runs_on_app_id_and_process_type ON cron_runs USING btree (app_id, process_type)`,
+			`CREATE INDEX index_cron_runs_on_task_id ON cron_runs USING btree (task_id)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE cron_runs`,
+		}),
+	},
+
+	// Adds the time zone that the schedules of an app are evaluated in,
+	// and the time that a scheduled invocation was due at.
+	{
+		ID: 42,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE apps ADD COLUMN cron_timezone text NOT NULL DEFAULT ''`,
+			`ALTER TABLE cron_runs ADD COLUMN scheduled_at timestamp without time zone`,
+			`CREATE UNIQUE INDEX index_cron_runs_on_app_id_and_process_type_and_scheduled_at ON cron_runs USING btree (app_id, process_type, scheduled_at)`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE apps DROP COLUMN cron_timezone`,
+			`ALTER TABLE cron_runs DROP COLUMN scheduled_at`,
+		}),
+	},
+
+	// Adds the key that routes incidents about an app to whoever is on
+	// call for it.
+	{
+		ID: 43,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE apps ADD COLUMN alert_routing_key text NOT NULL DEFAULT ''`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE apps DROP COLUMN alert_routing_key`,
+		}),
+	},
+
+	// Adds namespaces, which give a team ownership of a prefix of app
+	// names, and lets quotas and grants be scoped to one.
+	{
+		ID: 44,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE namespaces (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  name text NOT NULL,
+  team text NOT NULL,
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE UNIQUE INDEX index_namespaces_on_name ON namespaces USING btree (name)`,
+			`ALTER TABLE apps ADD COLUMN namespace text NOT NULL DEFAULT ''`,
+			`CREATE INDEX index_apps_on_namespace ON apps USING btree (namespace)`,
+			`ALTER TABLE grants ADD COLUMN namespace text NOT NULL DEFAULT ''`,
+			`ALTER TABLE quotas ADD COLUMN namespace text NOT NULL DEFAULT ''`,
+			`DROP INDEX index_quotas_on_scope`,
+			`CREATE UNIQUE INDEX index_quotas_on_scope ON quotas USING btree (team, namespace, COALESCE(app_id, '00000000-0000-0000-0000-000000000000'::uuid))`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP INDEX index_quotas_on_scope`,
+			`ALTER TABLE quotas DROP COLUMN namespace`,
+			`CREATE UNIQUE INDEX index_quotas_on_scope ON quotas USING btree (team, COALESCE(app_id, '00000000-0000-0000-0000-000000000000'::uuid))`,
+			`ALTER TABLE grants DROP COLUMN namespace`,
+			`ALTER TABLE apps DROP COLUMN namespace`,
+			`DROP TABLE namespaces`,
+		}),
+	},
+
+	// Adds labels to apps.
+	{
+		ID: 45,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE apps ADD COLUMN labels json`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE apps DROP COLUMN labels`,
+		}),
+	},
+
+	// Adds the layers of the image to slugs.
+	{
+		ID: 46,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE slugs ADD COLUMN layers json`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE slugs DROP COLUMN layers`,
+		}),
+	},
+
+	// Adds the provenance of the image to slugs.
+	{
+		ID: 47,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE slugs ADD COLUMN provenance json`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE slugs DROP COLUMN provenance`,
+		}),
+	},
+
+	// Adds a version to the formation of releases, so that concurrent
+	// scales can be detected.
+	{
+		ID: 48,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE releases ADD COLUMN formation_version integer NOT NULL DEFAULT 1`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE releases DROP COLUMN formation_version`,
+		}),
+	},
+
+	// Adds idempotency keys, which deduplicate retried requests.
+	{
+		ID: 49,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE idempotency_keys (
+  key text NOT NULL,
+  user_name text NOT NULL,
+  operation text NOT NULL,
+  result json,
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE UNIQUE INDEX index_idempotency_keys_on_user_name_and_key ON idempotency_keys USING btree (user_name, key)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE idempotency_keys`,
+		}),
+	},
+
+	// Adds chaos testing, which kills a fraction of the instances of apps
+	// that opt in, and records how long their processes take to recover.
+	{
+		ID: 50,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE apps ADD COLUMN chaos_fraction double precision NOT NULL DEFAULT 0`,
+			`CREATE TABLE chaos_kills (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  app_id uuid NOT NULL references apps(id) ON DELETE CASCADE,
+  process_type text NOT NULL,
+  task_id text NOT NULL,
+  pid text NOT NULL,
+  release integer NOT NULL,
+  killed_at timestamp without time zone default (now() at time zone 'utc'),
+  recovered_at timestamp without time zone
+)`,
+			`CREATE INDEX index_chaos_kills_on_app_id ON chaos_kills USING btree (app_id)`,
+			`CREATE INDEX index_chaos_kills_on_killed_at ON chaos_kills USING btree (killed_at)`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE apps DROP COLUMN chaos_fraction`,
+			`DROP TABLE chaos_kills`,
+		}),
+	},
+
+	// Adds the history of the transitions of the tasks of long running
+	// processes (e.g. started, or crashed).
+	{
+		ID: 51,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE task_transitions (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  app_id uuid NOT NULL references apps(id) ON DELETE CASCADE,
+  process_type text NOT NULL,
+  task_id text NOT NULL,
+  pid text NOT NULL,
+  release integer NOT NULL,
+  host text NOT NULL DEFAULT '',
+  state text NOT NULL,
+  reason text NOT NULL DEFAULT '',
+  occurred_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE INDEX index_task_transitions_on_app_id_and_occurred_at ON task_transitions USING btree (app_id, occurred_at)`,
+			`CREATE UNIQUE INDEX index_task_transitions_on_task_id_and_state ON task_transitions USING btree (task_id, state)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE task_transitions`,
+		}),
+	},
+
+	// Adds the scales and restarts of apps, which are shown in the activity
+	// feed of an app, along with its releases and crashes.
+	{
+		ID: 52,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE activities (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  app_id uuid NOT NULL references apps(id) ON DELETE CASCADE,
+  type text NOT NULL,
+  created_by text NOT NULL DEFAULT '',
+  description text NOT NULL DEFAULT '',
+  release integer NOT NULL DEFAULT 0,
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE INDEX index_activities_on_app_id_and_created_at ON activities USING btree (app_id, created_at)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE activities`,
+		}),
+	},
+
+	// Adds the rules that the config vars of an app need to satisfy.
+	{
+		ID: 53,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE apps ADD COLUMN config_rules json`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE apps DROP COLUMN config_rules`,
+		}),
+	},
+
+	// Adds the names of the config vars of an app whose values are
+	// redacted.
+	{
+		ID: 54,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE apps ADD COLUMN sensitive_vars json`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE apps DROP COLUMN sensitive_vars`,
+		}),
+	},
+
+	// Adds the time step of the last two factor code that was accepted, so
+	// that codes can't be replayed.
+	{
+		ID: 55,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE two_factor_secrets ADD COLUMN last_step bigint NOT NULL DEFAULT 0`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE two_factor_secrets DROP COLUMN last_step`,
+		}),
+	},
 }
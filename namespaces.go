@@ -0,0 +1,255 @@
+package empire
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/timex"
+)
+
+// NamespacePattern is a regex pattern that namespace names must conform to.
+// Namespaces can't contain a dash, since the first dash in the name of an app
+// separates its namespace from the rest of the name.
+var NamespacePattern = regexp.MustCompile(`^[a-z][a-z0-9]{1,15}$`)
+
+var (
+	// ErrInvalidNamespaceName is returned when a namespace has an invalid
+	// name.
+	ErrInvalidNamespaceName = &ValidationError{
+		errors.New("Namespace names must be 2 to 16 lowercase letters or digits, and start with a letter."),
+	}
+
+	// ErrNamespaceTeam is returned when a namespace isn't owned by a team.
+	ErrNamespaceTeam = &ValidationError{
+		errors.New("A namespace must be owned by a team."),
+	}
+)
+
+// Namespace gives a team ownership of a prefix of app names. An app is in the
+// namespace when its name is the name of the namespace, or starts with the
+// name of the namespace followed by a dash (e.g. the "payments-api" app is in
+// the "payments" namespace).
+//
+// Only the team that owns a namespace can create apps in it, which prevents two
+// teams from claiming conflicting app names. Quotas and grants can be scoped to
+// a namespace, to apply to every app in it.
+type Namespace struct {
+	// A unique uuid that identifies the namespace.
+	ID string
+
+	// The name of the namespace.
+	Name string
+
+	// The team that owns the namespace.
+	Team string
+
+	// The time that the namespace was claimed.
+	CreatedAt *time.Time
+}
+
+// IsValid returns an error if the namespace isn't valid.
+func (n *Namespace) IsValid() error {
+	if !NamespacePattern.MatchString(n.Name) {
+		return ErrInvalidNamespaceName
+	}
+
+	if n.Team == "" {
+		return ErrNamespaceTeam
+	}
+
+	return nil
+}
+
+// BeforeCreate sets created_at before inserting.
+func (n *Namespace) BeforeCreate() error {
+	t := timex.Now()
+	n.CreatedAt = &t
+	return n.IsValid()
+}
+
+// namespaceName returns the name of the namespace that an app with the given
+// name would be in, if it was claimed.
+func namespaceName(appName string) string {
+	return strings.SplitN(appName, "-", 2)[0]
+}
+
+// NamespaceOwnedError is returned when an app would be owned by a different
+// team than the namespace that it's in.
+type NamespaceOwnedError struct {
+	Namespace *Namespace
+
+	// The name of the app, and the team that would own it.
+	App, Team string
+}
+
+func (e *NamespaceOwnedError) Error() string {
+	msg := fmt.Sprintf("%s is in the %s namespace, which is owned by %s", e.App, e.Namespace.Name, e.Namespace.Team)
+	if e.Team == "" {
+		return msg
+	}
+	return fmt.Sprintf("%s, not %s", msg, e.Team)
+}
+
+// NamespacesQuery is a scope implementation for common things to filter
+// namespaces by.
+type NamespacesQuery struct {
+	// If provided, finds the namespace with the given id.
+	ID *string
+
+	// If provided, finds the namespace with the given name.
+	Name *string
+
+	// If provided, finds namespaces owned by the given team.
+	Team *string
+}
+
+// scope implements the scope interface.
+func (q NamespacesQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.ID != nil {
+		scope = append(scope, idEquals(*q.ID))
+	}
+
+	if q.Name != nil {
+		scope = append(scope, fieldEquals("name", *q.Name))
+	}
+
+	if q.Team != nil {
+		scope = append(scope, fieldEquals("team", *q.Team))
+	}
+
+	scope = append(scope, order("name asc"))
+
+	return scope.scope(db)
+}
+
+// namespacesFind returns the first matching namespace.
+func namespacesFind(db *gorm.DB, scope scope) (*Namespace, error) {
+	var namespace Namespace
+	return &namespace, first(db, scope, &namespace)
+}
+
+// namespaces returns all namespaces matching the scope.
+func namespaces(db *gorm.DB, scope scope) ([]*Namespace, error) {
+	var namespaces []*Namespace
+	return namespaces, find(db, scope, &namespaces)
+}
+
+// namespacesCreate claims the namespace, and moves the apps that already exist
+// under its prefix into it. The apps have to be owned by the team that claims
+// the namespace.
+func namespacesCreate(db *gorm.DB, namespace *Namespace) (*Namespace, error) {
+	if err := namespace.IsValid(); err != nil {
+		return namespace, err
+	}
+
+	existing, err := apps(db, appsWithPrefix(namespace.Name))
+	if err != nil {
+		return namespace, err
+	}
+
+	for _, a := range existing {
+		if a.Team != namespace.Team {
+			return namespace, &ValidationError{Err: &NamespaceOwnedError{Namespace: namespace, App: a.Name, Team: a.Team}}
+		}
+	}
+
+	if err := db.Create(namespace).Error; err != nil {
+		return namespace, err
+	}
+
+	for _, a := range existing {
+		a.Namespace = namespace.Name
+		if err := appsUpdate(db, a); err != nil {
+			return namespace, err
+		}
+	}
+
+	return namespace, nil
+}
+
+// namespacesDestroy releases the namespace. The apps in it are moved out of it,
+// and the quotas and grants that were scoped to it are removed, so that they
+// don't apply if another team claims the namespace later.
+func namespacesDestroy(db *gorm.DB, namespace *Namespace) error {
+	for _, sql := range []string{
+		`update apps set namespace = '' where namespace = ?`,
+		`delete from quotas where namespace = ?`,
+		`delete from grants where namespace = ?`,
+	} {
+		if err := db.Exec(sql, namespace.Name).Error; err != nil {
+			return err
+		}
+	}
+
+	return db.Delete(namespace).Error
+}
+
+// appsWithPrefix returns a scope that finds the apps whose names would put them
+// in the namespace with the given name.
+func appsWithPrefix(namespace string) scope {
+	return composedScope{
+		isNull("deleted_at"),
+		scopeFunc(func(db *gorm.DB) *gorm.DB {
+			return db.Where("name = ? OR name LIKE ?", namespace, namespace+"-%")
+		}),
+	}
+}
+
+// appNamespace returns the claimed namespace that an app with the given name
+// would be in, or nil if its namespace hasn't been claimed.
+func appNamespace(db *gorm.DB, appName string) (*Namespace, error) {
+	name := namespaceName(appName)
+	namespace, err := namespacesFind(db, NamespacesQuery{Name: &name})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return namespace, nil
+}
+
+// setNamespace puts a new app into the namespace that its name is in. Apps
+// without a team take the team of the namespace, and apps owned by another team
+// aren't allowed.
+func setNamespace(db *gorm.DB, app *App) error {
+	namespace, err := appNamespace(db, app.Name)
+	if err != nil || namespace == nil {
+		return err
+	}
+
+	if app.Team == "" {
+		app.Team = namespace.Team
+	}
+
+	if app.Team != namespace.Team {
+		return &ValidationError{Err: &NamespaceOwnedError{Namespace: namespace, App: app.Name, Team: app.Team}}
+	}
+
+	app.Namespace = namespace.Name
+	return nil
+}
+
+// NamespacesCreateOpts are options provided when claiming a namespace.
+type NamespacesCreateOpts struct {
+	// User performing the action.
+	User *User
+
+	// The namespace to claim.
+	Namespace *Namespace
+}
+
+// NamespacesDestroyOpts are options provided when releasing a namespace.
+type NamespacesDestroyOpts struct {
+	// User performing the action.
+	User *User
+
+	// The namespace to release.
+	Namespace *Namespace
+}
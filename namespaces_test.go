@@ -0,0 +1,69 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespacesQuery(t *testing.T) {
+	var (
+		id   = "1234"
+		name = "payments"
+		team = "platform"
+	)
+
+	tests := scopeTests{
+		{NamespacesQuery{}, "ORDER BY name asc", []interface{}{}},
+		{NamespacesQuery{ID: &id}, "WHERE (id = $1) ORDER BY name asc", []interface{}{"1234"}},
+		{NamespacesQuery{Name: &name}, "WHERE (name = $1) ORDER BY name asc", []interface{}{"payments"}},
+		{NamespacesQuery{Team: &team}, "WHERE (team = $1) ORDER BY name asc", []interface{}{"platform"}},
+	}
+
+	tests.Run(t)
+}
+
+func TestNamespace_IsValid(t *testing.T) {
+	tests := []struct {
+		namespace Namespace
+		err       error
+	}{
+		{Namespace{Name: "payments", Team: "payments"}, nil},
+		{Namespace{Name: "r101", Team: "platform"}, nil},
+		{Namespace{Name: "payments"}, ErrNamespaceTeam},
+		{Namespace{Name: "a", Team: "platform"}, ErrInvalidNamespaceName},
+		{Namespace{Name: "payments-api", Team: "payments"}, ErrInvalidNamespaceName},
+		{Namespace{Name: "101", Team: "platform"}, ErrInvalidNamespaceName},
+		{Namespace{Name: "Payments", Team: "payments"}, ErrInvalidNamespaceName},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.err, tt.namespace.IsValid())
+	}
+}
+
+func TestNamespaceName(t *testing.T) {
+	tests := []struct {
+		app       string
+		namespace string
+	}{
+		{"payments", "payments"},
+		{"payments-api", "payments"},
+		{"payments-api-worker", "payments"},
+		{"acme", "acme"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.namespace, namespaceName(tt.app))
+	}
+}
+
+func TestNamespaceOwnedError(t *testing.T) {
+	namespace := &Namespace{Name: "payments", Team: "payments"}
+
+	err := &NamespaceOwnedError{Namespace: namespace, App: "payments-api", Team: "platform"}
+	assert.EqualError(t, err, "payments-api is in the payments namespace, which is owned by payments, not platform")
+
+	err = &NamespaceOwnedError{Namespace: namespace, App: "payments-api"}
+	assert.EqualError(t, err, "payments-api is in the payments namespace, which is owned by payments")
+}
@@ -0,0 +1,202 @@
+package empire
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/remind101/empire/pkg/constraints"
+	"golang.org/x/net/context"
+)
+
+// ErrNoOperatorConfig is returned when the operator configuration is reloaded,
+// but Empire wasn't started with an operator configuration file.
+var ErrNoOperatorConfig = &ValidationError{
+	errors.New("Empire wasn't started with an operator configuration file, so there's nothing to reload."),
+}
+
+// OperatorConfig is configuration that operators can change without restarting
+// Empire, or interrupting deploys that are in progress. It's loaded from a
+// file, and can be reloaded on SIGHUP, or through the API.
+//
+// Quotas and freeze windows in the operator configuration apply in addition to
+// the ones that are created through the API.
+type OperatorConfig struct {
+	// Process sizes, in addition to 1X, 2X and PX, keyed by name.
+	Sizes map[string]Constraints
+
+	// Quotas, which can only be scoped to a team, or a namespace.
+	Quotas []*Quota
+
+	// Freeze windows, which can only be scoped to a team.
+	FreezeWindows []*FreezeWindow
+
+	// Where events and alerts are sent.
+	Notifications OperatorNotifications
+}
+
+// OperatorNotifications are the notification targets in the operator
+// configuration. Targets that aren't provided use the value that Empire was
+// started with.
+type OperatorNotifications struct {
+	// The SNS topic that events are published to, when the sns events
+	// backend is used.
+	SNSTopic string `json:"sns_topic"`
+
+	// The routing key (or integration key) of the alerts backend.
+	AlertsRoutingKey string `json:"alerts_routing_key"`
+}
+
+// operatorConfigFile is the format of the operator configuration file.
+type operatorConfigFile struct {
+	Sizes         map[string]string            `json:"sizes"`
+	Quotas        []operatorConfigQuota        `json:"quotas"`
+	FreezeWindows []operatorConfigFreezeWindow `json:"freeze_windows"`
+	Notifications OperatorNotifications        `json:"notifications"`
+}
+
+type operatorConfigQuota struct {
+	Team         string `json:"team"`
+	Namespace    string `json:"namespace"`
+	MaxInstances int    `json:"max_instances"`
+	MaxMemory    string `json:"max_memory"`
+	MaxRuns      int    `json:"max_runs"`
+	MaxRunMemory string `json:"max_run_memory"`
+}
+
+type operatorConfigFreezeWindow struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason"`
+	Team     string    `json:"team"`
+}
+
+// ParseOperatorConfig parses an operator configuration file, which is JSON:
+//
+//	{
+//	  "sizes": {"4X": "1024:4GB"},
+//	  "quotas": [{"team": "platform", "max_instances": 100, "max_memory": "200GB"}],
+//	  "freeze_windows": [{"starts_at": "2017-12-22T00:00:00Z", "ends_at": "2018-01-02T00:00:00Z", "reason": "Holidays"}],
+//	  "notifications": {"sns_topic": "arn:aws:sns:us-east-1:066251891493:empire-events"}
+//	}
+func ParseOperatorConfig(r io.Reader) (*OperatorConfig, error) {
+	var f operatorConfigFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("error parsing operator config: %v", err)
+	}
+
+	config := &OperatorConfig{
+		Sizes:         make(map[string]Constraints),
+		Notifications: f.Notifications,
+	}
+
+	for name, v := range f.Sizes {
+		if _, ok := builtinConstraints[name]; ok {
+			return nil, fmt.Errorf("size %s can't be changed", name)
+		}
+		c, err := constraints.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid size %s: %v", name, err)
+		}
+		config.Sizes[name] = Constraints(c)
+	}
+
+	for i, v := range f.Quotas {
+		q := &Quota{
+			Team:         v.Team,
+			Namespace:    v.Namespace,
+			MaxInstances: v.MaxInstances,
+			MaxRuns:      v.MaxRuns,
+		}
+		if err := q.IsValid(); err != nil {
+			return nil, fmt.Errorf("invalid quota %d: %v", i+1, err)
+		}
+		for _, m := range []struct {
+			s string
+			m *constraints.Memory
+		}{{v.MaxMemory, &q.MaxMemory}, {v.MaxRunMemory, &q.MaxRunMemory}} {
+			if m.s == "" {
+				continue
+			}
+			mem, err := constraints.ParseMemory(m.s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quota %d: %v", i+1, err)
+			}
+			*m.m = mem
+		}
+		config.Quotas = append(config.Quotas, q)
+	}
+
+	for i, v := range f.FreezeWindows {
+		w := &FreezeWindow{
+			StartsAt:  v.StartsAt,
+			EndsAt:    v.EndsAt,
+			Reason:    v.Reason,
+			Team:      v.Team,
+			CreatedBy: "operator config",
+		}
+		if err := w.IsValid(); err != nil {
+			return nil, fmt.Errorf("invalid freeze window %d: %v", i+1, err)
+		}
+		config.FreezeWindows = append(config.FreezeWindows, w)
+	}
+
+	return config, nil
+}
+
+// operatorConfig holds the operator configuration that's in effect.
+type operatorConfig struct {
+	sync.RWMutex
+	config *OperatorConfig
+}
+
+// OperatorConfig returns the operator configuration that's in effect.
+func (e *Empire) OperatorConfig() *OperatorConfig {
+	e.operator.RLock()
+	defer e.operator.RUnlock()
+
+	if e.operator.config == nil {
+		return &OperatorConfig{}
+	}
+	return e.operator.config
+}
+
+// SetOperatorConfig puts the operator configuration into effect. Deploys that
+// are in progress aren't affected, since they've already been admitted.
+func (e *Empire) SetOperatorConfig(config *OperatorConfig) {
+	e.operator.Lock()
+	defer e.operator.Unlock()
+
+	setNamedConstraints(config.Sizes)
+	e.operator.config = config
+}
+
+// ReloadOperatorConfigOpts are options provided when reloading the operator
+// configuration.
+type ReloadOperatorConfigOpts struct {
+	// User performing the action.
+	User *User
+}
+
+// ReloadOperatorConfig loads the operator configuration with
+// LoadOperatorConfig, and puts it into effect. This requires admin access.
+func (e *Empire) ReloadOperatorConfig(ctx context.Context, opts ReloadOperatorConfigOpts) (*OperatorConfig, error) {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return nil, err
+	}
+
+	if e.LoadOperatorConfig == nil {
+		return nil, ErrNoOperatorConfig
+	}
+
+	config, err := e.LoadOperatorConfig()
+	if err != nil {
+		return nil, &ValidationError{Err: err}
+	}
+
+	e.SetOperatorConfig(config)
+	return config, nil
+}
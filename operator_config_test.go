@@ -0,0 +1,91 @@
+package empire
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestParseOperatorConfig(t *testing.T) {
+	config, err := ParseOperatorConfig(strings.NewReader(`{
+  "sizes": {"4X": "1024:4GB"},
+  "quotas": [{"team": "platform", "max_instances": 100, "max_memory": "200GB"}],
+  "freeze_windows": [{"starts_at": "2017-12-22T00:00:00Z", "ends_at": "2018-01-02T00:00:00Z", "reason": "Holidays"}],
+  "notifications": {"sns_topic": "arn:aws:sns:us-east-1:066251891493:empire-events"}
+}`))
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]Constraints{
+		"4X": {CPUShare: 1024, Memory: constraints.Memory(4 * GB)},
+	}, config.Sizes)
+	assert.Equal(t, 1, len(config.Quotas))
+	assert.Equal(t, "platform", config.Quotas[0].Team)
+	assert.Equal(t, 100, config.Quotas[0].MaxInstances)
+	assert.Equal(t, constraints.Memory(200*GB), config.Quotas[0].MaxMemory)
+	assert.Equal(t, 1, len(config.FreezeWindows))
+	assert.Equal(t, "Holidays", config.FreezeWindows[0].Reason)
+	assert.Equal(t, "operator config", config.FreezeWindows[0].CreatedBy)
+	assert.Equal(t, "arn:aws:sns:us-east-1:066251891493:empire-events", config.Notifications.SNSTopic)
+}
+
+func TestParseOperatorConfig_Invalid(t *testing.T) {
+	tests := []string{
+		`{"sizes": {"1X": "1024:4GB"}}`,
+		`{"sizes": {"4X": "1024"}}`,
+		`{"quotas": [{"max_instances": 100}]}`,
+		`{"quotas": [{"team": "platform", "max_memory": "lots"}]}`,
+		`{"freeze_windows": [{"starts_at": "2018-01-02T00:00:00Z", "ends_at": "2017-12-22T00:00:00Z"}]}`,
+		`{`,
+	}
+
+	for _, tt := range tests {
+		_, err := ParseOperatorConfig(strings.NewReader(tt))
+		assert.Error(t, err, tt)
+	}
+}
+
+func TestEmpire_SetOperatorConfig(t *testing.T) {
+	e := &Empire{}
+	defer setNamedConstraints(nil)
+
+	e.SetOperatorConfig(&OperatorConfig{
+		Sizes: map[string]Constraints{
+			"4X": {CPUShare: 1024, Memory: constraints.Memory(4 * GB)},
+		},
+	})
+
+	c, err := ParseConstraints("4X")
+	assert.NoError(t, err)
+	assert.Equal(t, &Constraints{CPUShare: 1024, Memory: constraints.Memory(4 * GB)}, c)
+	assert.Equal(t, "4X", c.String())
+
+	// Sizes that are removed from the operator config go away.
+	e.SetOperatorConfig(&OperatorConfig{})
+	_, err = ParseConstraints("4X")
+	assert.Error(t, err)
+
+	c, err = ParseConstraints("1X")
+	assert.NoError(t, err)
+	assert.Equal(t, &Constraints1X, c)
+}
+
+func TestEmpire_ReloadOperatorConfig(t *testing.T) {
+	e := &Empire{}
+	_, err := e.ReloadOperatorConfig(context.Background(), ReloadOperatorConfigOpts{})
+	assert.Equal(t, ErrNoOperatorConfig, err)
+
+	e.LoadOperatorConfig = func() (*OperatorConfig, error) {
+		return nil, errors.New("boom")
+	}
+	_, err = e.ReloadOperatorConfig(context.Background(), ReloadOperatorConfigOpts{})
+	assert.Equal(t, &ValidationError{Err: errors.New("boom")}, err)
+
+	// A failed reload keeps the configuration that's in effect.
+	e.SetOperatorConfig(&OperatorConfig{Quotas: []*Quota{{Team: "platform"}}})
+	assert.Equal(t, 1, len(e.OperatorConfig().Quotas))
+}
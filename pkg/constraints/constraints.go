@@ -166,11 +166,25 @@ func ParseNproc(s string) (Nproc, error) {
 	return Nproc(n), nil
 }
 
-// Constraints is a composition of CPUShares, Memory and Nproc constraints.
+// GPU represents a number of GPUs.
+type GPU uint
+
+func ParseGPU(s string) (GPU, error) {
+	n, err := strconv.ParseUint(s, 10, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return GPU(n), nil
+}
+
+// Constraints is a composition of CPUShares, Memory, Nproc and GPU
+// constraints.
 type Constraints struct {
 	CPUShare
 	Memory
 	Nproc
+	GPU
 }
 
 func Parse(s string) (Constraints, error) {
@@ -202,13 +216,20 @@ func Parse(s string) (Constraints, error) {
 				return c, ErrInvalidConstraint
 			}
 
-			if kv[0] == "nproc" {
+			switch kv[0] {
+			case "nproc":
 				n, err := ParseNproc(kv[1])
 				if err != nil {
 					return c, err
 				}
 				c.Nproc = n
-			} else {
+			case "gpu":
+				n, err := ParseGPU(kv[1])
+				if err != nil {
+					return c, err
+				}
+				c.GPU = n
+			default:
 				return c, ErrInvalidConstraint
 			}
 		}
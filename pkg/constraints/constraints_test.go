@@ -105,9 +105,11 @@ func TestConstraints_Parse(t *testing.T) {
 		out Constraints
 		err error
 	}{
-		{"512:1KB", Constraints{512, 1024, 0}, nil},
-		{"512:1KB:nproc=512", Constraints{512, 1024, 512}, nil},
-		{"2048:1KB:nproc=512", Constraints{2048, 1024, 512}, nil},
+		{"512:1KB", Constraints{512, 1024, 0, 0}, nil},
+		{"512:1KB:nproc=512", Constraints{512, 1024, 512, 0}, nil},
+		{"2048:1KB:nproc=512", Constraints{2048, 1024, 512, 0}, nil},
+		{"2048:1KB:gpu=1", Constraints{2048, 1024, 0, 1}, nil},
+		{"2048:1KB:nproc=512,gpu=2", Constraints{2048, 1024, 512, 2}, nil},
 		{"0:1KB", Constraints{}, ErrInvalidCPUShare},
 
 		{"1024", Constraints{}, ErrInvalidConstraint},
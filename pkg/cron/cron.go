@@ -0,0 +1,303 @@
+// Package cron parses the cron expressions of scheduled processes, which use
+// the syntax of CloudWatch Events, and finds the times that they trigger at in
+// a given time zone.
+//
+// Times are matched against the wall clock of the time zone, so a schedule of
+// "0 9 ? * MON-FRI *" triggers at 9am local time, whether or not daylight
+// saving time is in effect. When the clocks go forward, times that are skipped
+// trigger at the moment of the change. When the clocks go back, times that are
+// repeated only trigger the first time around.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimezonePrefix is the prefix that sets the time zone of a cron expression
+// (e.g. "CRON_TZ=America/New_York 0 9 ? * MON-FRI *").
+const TimezonePrefix = "CRON_TZ="
+
+// maxSearch is how far in the future Next looks for a matching time.
+const maxSearch = 5 * 365 * 24 * time.Hour
+
+var (
+	months = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+	days   = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+)
+
+// bounds describes the values that a field can have.
+type bounds struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var (
+	minutes     = bounds{name: "minutes", min: 0, max: 59}
+	hours       = bounds{name: "hours", min: 0, max: 23}
+	daysOfMonth = bounds{name: "day-of-month", min: 1, max: 31}
+	monthsOf    = bounds{name: "month", min: 1, max: 12, names: months}
+	daysOfWeek  = bounds{name: "day-of-week", min: 1, max: 7, names: days}
+	years       = bounds{name: "year", min: 1970, max: 2199}
+)
+
+// field is the set of values that a field of an expression matches. A nil
+// field matches any value.
+type field map[int]bool
+
+func (f field) matches(v int) bool {
+	return f == nil || f[v]
+}
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	// The time zone that the expression is evaluated in.
+	Location *time.Location
+
+	minute, hour, dom, month, dow, year field
+}
+
+// SplitTimezone splits the time zone prefix off of a cron expression, if it
+// has one.
+func SplitTimezone(expr string) (tz string, rest string) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, TimezonePrefix) {
+		return "", expr
+	}
+	parts := strings.SplitN(strings.TrimPrefix(expr, TimezonePrefix), " ", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], strings.TrimSpace(parts[1])
+}
+
+// Parse parses a cron expression with the six fields of CloudWatch Events:
+// minutes, hours, day-of-month, month, day-of-week and year. The expression is
+// evaluated in loc, unless it has a time zone prefix.
+//
+// The L, W and # wildcards aren't supported.
+func Parse(expr string, loc *time.Location) (*Schedule, error) {
+	tz, expr := SplitTimezone(expr)
+	if tz != "" {
+		var err error
+		loc, err = time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone %q", tz)
+		}
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("expected 6 fields in cron expression %q, got %d", expr, len(fields))
+	}
+
+	s := &Schedule{Location: loc}
+	var err error
+	if s.minute, err = parseField(fields[0], minutes); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], hours); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[2], daysOfMonth); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], monthsOf); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[4], daysOfWeek); err != nil {
+		return nil, err
+	}
+	if s.year, err = parseField(fields[5], years); err != nil {
+		return nil, err
+	}
+	if (fields[2] == "?") == (fields[4] == "?") {
+		return nil, fmt.Errorf("exactly one of day-of-month and day-of-week must be ?")
+	}
+
+	return s, nil
+}
+
+// parseField parses a comma separated list of values, ranges and steps.
+func parseField(s string, b bounds) (field, error) {
+	if s == "*" || s == "?" {
+		return nil, nil
+	}
+
+	f := make(field)
+	for _, part := range strings.Split(s, ",") {
+		if err := parseRange(part, b, f); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// parseRange parses a single value (e.g. "5"), range (e.g. "MON-FRI") or step
+// (e.g. "0/15", "*/2" or "1-10/3"), and adds the values it matches to f.
+func parseRange(s string, b bounds, f field) error {
+	step := 1
+	if i := strings.Index(s, "/"); i >= 0 {
+		var err error
+		step, err = strconv.Atoi(s[i+1:])
+		if err != nil || step < 1 {
+			return fmt.Errorf("%s: invalid step in %q", b.name, s)
+		}
+		s = s[:i]
+	}
+
+	if strings.ContainsAny(s, "LW#") && !isName(s, b) {
+		return fmt.Errorf("%s: %q isn't supported", b.name, s)
+	}
+
+	start, end := b.min, b.max
+	switch {
+	case s == "*":
+	case strings.Contains(s, "-"):
+		parts := strings.SplitN(s, "-", 2)
+		var err error
+		if start, err = parseValue(parts[0], b); err != nil {
+			return err
+		}
+		if end, err = parseValue(parts[1], b); err != nil {
+			return err
+		}
+		if end < start {
+			return fmt.Errorf("%s: invalid range %q", b.name, s)
+		}
+	default:
+		var err error
+		if start, err = parseValue(s, b); err != nil {
+			return err
+		}
+		if step == 1 {
+			end = start
+		}
+	}
+
+	for v := start; v <= end; v += step {
+		f[v] = true
+	}
+	return nil
+}
+
+// parseValue parses a number, or the name of a month or day.
+func parseValue(s string, b bounds) (int, error) {
+	for i, name := range b.names {
+		if strings.EqualFold(s, name) {
+			return b.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < b.min || v > b.max {
+		return 0, fmt.Errorf("%s: %q must be between %d and %d", b.name, s, b.min, b.max)
+	}
+	return v, nil
+}
+
+// isName returns true if s only contains names of months or days (e.g.
+// "JUL-WED"), which can contain the letters of the unsupported wildcards.
+func isName(s string, b bounds) bool {
+	for _, part := range strings.Split(s, "-") {
+		found := false
+		for _, name := range b.names {
+			if strings.EqualFold(part, name) {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Next returns the first time after t that the schedule triggers at, or the
+// zero time if it doesn't trigger in the next 5 years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	// w is a wall clock time in the time zone of the schedule, represented
+	// as a time in UTC, so that it can be moved by minutes, hours and days
+	// without being affected by changes to the offset of the time zone.
+	w := s.wall(t).Truncate(time.Minute).Add(time.Minute)
+	end := w.Add(maxSearch)
+
+	for w.Before(end) {
+		switch {
+		case !s.year.matches(w.Year()):
+			w = time.Date(w.Year()+1, 1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.month.matches(int(w.Month())):
+			w = time.Date(w.Year(), w.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.matchesDay(w):
+			w = time.Date(w.Year(), w.Month(), w.Day()+1, 0, 0, 0, 0, time.UTC)
+		case !s.hour.matches(w.Hour()):
+			w = w.Truncate(time.Hour).Add(time.Hour)
+		case !s.minute.matches(w.Minute()):
+			w = w.Add(time.Minute)
+		default:
+			if at := s.instant(w); at.After(t) {
+				return at
+			}
+			w = w.Add(time.Minute)
+		}
+	}
+
+	return time.Time{}
+}
+
+// matchesDay returns true if the day of the wall clock time matches the
+// day-of-month, or day-of-week, field.
+func (s *Schedule) matchesDay(w time.Time) bool {
+	return s.dom.matches(w.Day()) && s.dow.matches(int(w.Weekday())+1)
+}
+
+// wall returns the time on the wall clock in the time zone of the schedule at
+// t, represented as a time in UTC.
+func (s *Schedule) wall(t time.Time) time.Time {
+	t = t.In(s.Location)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// instant returns the first time that the wall clock in the time zone of the
+// schedule reaches, or passes, the wall clock time w.
+func (s *Schedule) instant(w time.Time) time.Time {
+	// The time that w is at with each offset that the time zone has around
+	// it. When the clocks go back, w is at both of them.
+	var candidates []time.Time
+	for _, d := range []time.Duration{-24 * time.Hour, 24 * time.Hour} {
+		n := w.Add(d)
+		_, offset := time.Date(n.Year(), n.Month(), n.Day(), n.Hour(), n.Minute(), 0, 0, s.Location).Zone()
+		candidates = append(candidates, w.Add(-time.Duration(offset)*time.Second))
+	}
+	lo, hi := candidates[0], candidates[1]
+	if hi.Before(lo) {
+		lo, hi = hi, lo
+	}
+
+	if s.wall(lo).Equal(w) {
+		return lo.In(s.Location)
+	}
+	if s.wall(hi).Equal(w) {
+		return hi.In(s.Location)
+	}
+
+	// w was skipped when the clocks went forward, so the wall clock passes
+	// it at the moment of the change, which is between lo and hi.
+	if !s.wall(lo).Before(w) || s.wall(hi).Before(w) {
+		return time.Date(w.Year(), w.Month(), w.Day(), w.Hour(), w.Minute(), 0, 0, s.Location)
+	}
+	for hi.Sub(lo) > time.Second {
+		mid := lo.Add(hi.Sub(lo) / 2).Truncate(time.Second)
+		if s.wall(mid).Before(w) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi.In(s.Location)
+}
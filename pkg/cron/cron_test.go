@@ -0,0 +1,89 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{"* * * * *", `expected 6 fields in cron expression "* * * * *", got 5`},
+		{"60 * * * ? *", `minutes: "60" must be between 0 and 59`},
+		{"0 9 * * MON-FRI *", `exactly one of day-of-month and day-of-week must be ?`},
+		{"0 9 L * ? *", `day-of-month: "L" isn't supported`},
+		{"0 9 ? * 2#1 *", `day-of-week: "2#1" isn't supported`},
+		{"0 9 ? * FRI-MON *", `day-of-week: invalid range "FRI-MON"`},
+		{"0/0 * * * ? *", `minutes: invalid step in "0/0"`},
+		{"CRON_TZ=Mars/Olympus 0 9 * * ? *", `unknown time zone "Mars/Olympus"`},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.expr, nil)
+		assert.EqualError(t, err, tt.err, tt.expr)
+	}
+}
+
+func TestSplitTimezone(t *testing.T) {
+	tz, expr := SplitTimezone("CRON_TZ=America/New_York 0 9 ? * MON-FRI *")
+	assert.Equal(t, "America/New_York", tz)
+	assert.Equal(t, "0 9 ? * MON-FRI *", expr)
+
+	tz, expr = SplitTimezone("0 9 ? * MON-FRI *")
+	assert.Equal(t, "", tz)
+	assert.Equal(t, "0 9 ? * MON-FRI *", expr)
+}
+
+func TestSchedule_Next(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	tests := []struct {
+		expr  string
+		after string
+		next  []string
+	}{
+		// UTC
+		{"0/15 * * * ? *", "2016-06-13T18:07:00Z", []string{"2016-06-13T18:15:00Z", "2016-06-13T18:30:00Z"}},
+		{"0 12 1 JAN,JUL ? *", "2016-06-13T18:07:00Z", []string{"2016-07-01T12:00:00Z", "2017-01-01T12:00:00Z"}},
+		{"0 0 ? * SAT 2017", "2016-06-13T18:07:00Z", []string{"2017-01-07T00:00:00Z"}},
+
+		// Business hours, before and after the clocks go forward.
+		{"CRON_TZ=America/New_York 0 9 ? * MON-FRI *", "2024-03-08T15:00:00Z", []string{"2024-03-11T13:00:00Z", "2024-03-12T13:00:00Z"}},
+		{"CRON_TZ=America/New_York 0 9 ? * MON-FRI *", "2024-03-07T15:00:00Z", []string{"2024-03-08T14:00:00Z", "2024-03-11T13:00:00Z"}},
+
+		// 2:30am doesn't happen when the clocks go forward, so it
+		// triggers at 3am.
+		{"CRON_TZ=America/New_York 30 2 * * ? *", "2024-03-09T12:00:00Z", []string{"2024-03-10T07:00:00Z", "2024-03-11T06:30:00Z"}},
+
+		// 1:30am happens twice when the clocks go back, but only
+		// triggers once.
+		{"CRON_TZ=America/New_York 30 1 * * ? *", "2024-11-02T12:00:00Z", []string{"2024-11-03T05:30:00Z", "2024-11-04T06:30:00Z"}},
+		{"CRON_TZ=America/New_York 0/30 * * * ? *", "2024-11-03T05:15:00Z", []string{"2024-11-03T05:30:00Z", "2024-11-03T07:00:00Z"}},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse(tt.expr, time.UTC)
+		if !assert.NoError(t, err, tt.expr) {
+			continue
+		}
+
+		at, _ := time.Parse(time.RFC3339, tt.after)
+		for _, want := range tt.next {
+			at = s.Next(at)
+			assert.Equal(t, want, at.UTC().Format(time.RFC3339), tt.expr)
+		}
+	}
+
+	// The time zone can also be given when parsing.
+	s, err := Parse("0 9 ? * MON-FRI *", newYork)
+	assert.NoError(t, err)
+	at := s.Next(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2024, 7, 1, 13, 0, 0, 0, time.UTC), at.UTC())
+}
@@ -69,6 +69,17 @@ func newClient(authProvider dockerauth.AuthProvider, c *docker.Client) (*Client,
 
 // PullImage wraps the docker clients PullImage to handle authentication.
 func (c *Client) PullImage(ctx context.Context, opts docker.PullImageOptions) error {
+	authConf, err := authConfiguration(c.AuthProvider, opts.Registry)
+	if err != nil {
+		return err
+	}
+
+	return c.PullImageWithAuth(ctx, opts, authConf)
+}
+
+// PullImageWithAuth pulls an image using the given credentials, instead of the
+// credentials from the AuthProvider.
+func (c *Client) PullImageWithAuth(ctx context.Context, opts docker.PullImageOptions, authConf docker.AuthConfiguration) error {
 	// This is to workaround an issue in the Docker API, where it doesn't
 	// respect the registry param. We have to put the registry in the
 	// repository field.
@@ -76,11 +87,6 @@ func (c *Client) PullImage(ctx context.Context, opts docker.PullImageOptions) er
 		opts.Repository = fmt.Sprintf("%s/%s", opts.Registry, opts.Repository)
 	}
 
-	authConf, err := authConfiguration(c.AuthProvider, opts.Registry)
-	if err != nil {
-		return err
-	}
-
 	return c.Client.PullImage(opts, authConf)
 }
 
@@ -0,0 +1,1276 @@
+// Code generated by openapi.GenerateClient. DO NOT EDIT.
+
+package empireapi
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"time"
+)
+
+type APIToken struct {
+	Actions    []string   `json:"actions,omitempty"`
+	Apps       []string   `json:"apps,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	CreatedBy  string     `json:"created_by"`
+	ID         string     `json:"id"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Name       string     `json:"name"`
+	Token      string     `json:"token,omitempty"`
+}
+
+type APITokenCreateOpts struct {
+	Actions []string `json:"actions,omitempty"`
+	Apps    []string `json:"apps,omitempty"`
+	Name    string   `json:"name"`
+}
+
+type Activity struct {
+	CreatedAt   time.Time `json:"created_at"`
+	Description string    `json:"description"`
+	Release     int       `json:"release"`
+	Type        string    `json:"type"`
+	User        string    `json:"user"`
+}
+
+type App struct {
+	AlertRoutingKey              string            `json:"alert_routing_key"`
+	ArchivedAt                   *time.Time        `json:"archived_at"`
+	BuildpackProvidedDescription *string           `json:"buildpack_provided_description"`
+	Cert                         string            `json:"cert,omitempty"`
+	Certs                        map[string]string `json:"certs,omitempty"`
+	ChaosFraction                float64           `json:"chaos_fraction"`
+	CreatedAt                    time.Time         `json:"created_at"`
+	CronTimezone                 string            `json:"cron_timezone"`
+	DeployTimeout                int               `json:"deploy_timeout"`
+	GitURL                       string            `json:"git_url"`
+	ID                           string            `json:"id"`
+	Labels                       map[string]string `json:"labels,omitempty"`
+	Maintenance                  bool              `json:"maintenance"`
+	Name                         string            `json:"name"`
+	Namespace                    string            `json:"namespace,omitempty"`
+	Owner                        struct {
+		Email string `json:"email"`
+		ID    string `json:"id"`
+	} `json:"owner"`
+	PreviousReleaseWeight int  `json:"previous_release_weight"`
+	Protected             bool `json:"protected"`
+	Region                struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"region"`
+	ReleasedAt *time.Time `json:"released_at"`
+	RepoSize   *int       `json:"repo_size"`
+	Router     AppRouter  `json:"router"`
+	SlugSize   *int       `json:"slug_size"`
+	Stack      struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"stack"`
+	Team      string    `json:"team,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+	WebURL    string    `json:"web_url"`
+}
+
+type AppExport struct {
+	Config      map[string]string      `json:"config,omitempty"`
+	ConfigRules map[string]ConfigRule  `json:"config_rules,omitempty"`
+	Domains     []string               `json:"domains,omitempty"`
+	ExportedAt  time.Time              `json:"exported_at"`
+	Formation   map[string]ProcessSpec `json:"formation,omitempty"`
+	Image       string                 `json:"image,omitempty"`
+	Name        string                 `json:"name"`
+	Release     *ReleaseExport         `json:"release,omitempty"`
+	Settings    AppSettings            `json:"settings"`
+	Version     int                    `json:"version"`
+}
+
+type AppImportOpts struct {
+	Cluster string      `json:"cluster,omitempty"`
+	Export  interface{} `json:"export"`
+}
+
+type AppImportResult struct {
+	App     string   `json:"app"`
+	Changes []string `json:"changes"`
+}
+
+type AppOverview struct {
+	App       App                  `json:"app"`
+	ConfigID  string               `json:"config_id"`
+	Domains   []Domain             `json:"domains"`
+	Processes []AppOverviewProcess `json:"processes"`
+	Release   *Release             `json:"release"`
+	Releases  []Release            `json:"releases"`
+}
+
+type AppOverviewProcess struct {
+	Quantity int            `json:"quantity"`
+	Size     string         `json:"size"`
+	States   map[string]int `json:"states"`
+	Type     string         `json:"type"`
+}
+
+type AppRouter struct {
+	AllowedCidrs      []string `json:"allowed_cidrs"`
+	BasicAuthUsername string   `json:"basic_auth_username"`
+	DrainTimeout      *int     `json:"drain_timeout"`
+	IdleTimeout       int      `json:"idle_timeout"`
+	ProtocolVersion   string   `json:"protocol_version"`
+	StickySessions    bool     `json:"sticky_sessions"`
+	Websockets        bool     `json:"websockets"`
+}
+
+type AppRouterUpdateOpts struct {
+	AllowedCidrs    []string `json:"allowed_cidrs,omitempty"`
+	BasicAuth       *string  `json:"basic_auth,omitempty"`
+	DrainTimeout    *int     `json:"drain_timeout,omitempty"`
+	IdleTimeout     *int     `json:"idle_timeout,omitempty"`
+	ProtocolVersion *string  `json:"protocol_version,omitempty"`
+	StickySessions  *bool    `json:"sticky_sessions,omitempty"`
+	Websockets      *bool    `json:"websockets,omitempty"`
+}
+
+type AppSettings struct {
+	AlertRoutingKey string            `json:"alert_routing_key,omitempty"`
+	Certs           map[string]string `json:"certs,omitempty"`
+	ChaosFraction   float64           `json:"chaos_fraction,omitempty"`
+	CronTimezone    string            `json:"cron_timezone,omitempty"`
+	DeployTimeout   int64             `json:"deploy_timeout,omitempty"`
+	Exposure        string            `json:"exposure,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Maintenance     bool              `json:"maintenance,omitempty"`
+	Protected       bool              `json:"protected,omitempty"`
+	Repo            *string           `json:"repo,omitempty"`
+	RouterSettings  RouterSettings    `json:"router_settings"`
+	SensitiveVars   []string          `json:"sensitive_vars,omitempty"`
+	Team            string            `json:"team,omitempty"`
+}
+
+type AppSpecApplyOpts struct {
+	DryRun bool   `json:"dry_run,omitempty"`
+	Spec   string `json:"spec"`
+}
+
+type AppSpecApplyResult struct {
+	App     string   `json:"app"`
+	Changes []string `json:"changes"`
+}
+
+type AppUpdateOpts struct {
+	AlertRoutingKey       *string              `json:"alert_routing_key,omitempty"`
+	Cert                  *string              `json:"cert,omitempty"`
+	ChaosFraction         *float64             `json:"chaos_fraction,omitempty"`
+	CronTimezone          *string              `json:"cron_timezone,omitempty"`
+	DeployTimeout         *int                 `json:"deploy_timeout,omitempty"`
+	Labels                map[string]*string   `json:"labels,omitempty"`
+	Maintenance           *bool                `json:"maintenance,omitempty"`
+	Name                  *string              `json:"name,omitempty"`
+	PreviousReleaseWeight *int                 `json:"previous_release_weight,omitempty"`
+	Protected             *bool                `json:"protected,omitempty"`
+	Router                *AppRouterUpdateOpts `json:"router,omitempty"`
+}
+
+type Authorization struct {
+	AccessToken *struct {
+		ExpiresIn *int   `json:"expires_in"`
+		ID        string `json:"id"`
+		Token     string `json:"token"`
+	} `json:"access_token"`
+	Client *struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		RedirectURI string `json:"redirect_uri"`
+	} `json:"client"`
+	CreatedAt time.Time `json:"created_at"`
+	Grant     *struct {
+		Code      string `json:"code"`
+		ExpiresIn int    `json:"expires_in"`
+		ID        string `json:"id"`
+	} `json:"grant"`
+	ID           string `json:"id"`
+	RefreshToken *struct {
+		ExpiresIn *int   `json:"expires_in"`
+		ID        string `json:"id"`
+		Token     string `json:"token"`
+	} `json:"refresh_token"`
+	Scope     []string  `json:"scope"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type BasicAuth struct {
+	Password string `json:"Password"`
+	Username string `json:"Username"`
+}
+
+type Batch struct {
+	App struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app"`
+	CreatedAt   time.Time      `json:"created_at"`
+	CreatedBy   string         `json:"created_by"`
+	ID          string         `json:"id"`
+	Jobs        []BatchJob     `json:"jobs"`
+	Parallelism int            `json:"parallelism"`
+	Status      map[string]int `json:"status"`
+}
+
+type BatchJob struct {
+	Command    string     `json:"command"`
+	ExitCode   *int       `json:"exit_code"`
+	FinishedAt *time.Time `json:"finished_at"`
+	ID         string     `json:"id"`
+	StartedAt  *time.Time `json:"started_at"`
+	State      string     `json:"state"`
+}
+
+type BulkApp struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type BulkPlan struct {
+	Apps      []BulkApp    `json:"apps"`
+	ID        string       `json:"id"`
+	Operation string       `json:"operation"`
+	Results   []BulkResult `json:"results,omitempty"`
+}
+
+type BulkResult struct {
+	App   BulkApp `json:"app"`
+	Error string  `json:"error,omitempty"`
+}
+
+type Capacity struct {
+	Allocated Resources `json:"allocated"`
+	Fits      *int      `json:"fits,omitempty"`
+	Machines  []Machine `json:"machines"`
+	Name      string    `json:"name"`
+	Total     Resources `json:"total"`
+}
+
+type CertsAttachOpts struct {
+	Cert    *string `json:"cert,omitempty"`
+	Process *string `json:"process,omitempty"`
+}
+
+type ChaosKill struct {
+	ID           string     `json:"id"`
+	KilledAt     time.Time  `json:"killed_at"`
+	Pid          string     `json:"pid"`
+	Process      string     `json:"process"`
+	RecoveredAt  *time.Time `json:"recovered_at"`
+	RecoveryTime *int       `json:"recovery_time"`
+	Release      int        `json:"release"`
+}
+
+type ConfigRule struct {
+	Enum     []string `json:"enum,omitempty"`
+	NonEmpty bool     `json:"non_empty,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	URL      bool     `json:"url,omitempty"`
+}
+
+type CronRun struct {
+	CreatedAt   time.Time  `json:"created_at"`
+	CreatedBy   string     `json:"created_by"`
+	ExitCode    *int       `json:"exit_code"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	ID          string     `json:"id"`
+	Output      string     `json:"output"`
+	Process     string     `json:"process"`
+	ScheduledAt *time.Time `json:"scheduled_at"`
+	StartedAt   *time.Time `json:"started_at"`
+	State       string     `json:"state"`
+	TaskID      string     `json:"task_id"`
+	Trigger     string     `json:"trigger"`
+}
+
+type Domain struct {
+	CreatedAt time.Time `json:"created_at"`
+	Hostname  string    `json:"hostname"`
+	ID        string    `json:"id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Dyno struct {
+	App struct {
+		Name string `json:"name"`
+	} `json:"app"`
+	AttachURL *string   `json:"attach_url"`
+	Command   string    `json:"command"`
+	CreatedAt time.Time `json:"created_at"`
+	Host      Host      `json:"host"`
+	ID        string    `json:"id"`
+	Image     string    `json:"image,omitempty"`
+	Name      string    `json:"name"`
+	Release   struct {
+		ID      string `json:"id"`
+		Version int    `json:"version"`
+	} `json:"release"`
+	Size      string    `json:"size"`
+	State     string    `json:"state"`
+	Type      string    `json:"type"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type EmpireProvenance struct {
+	BuildURL string `json:"BuildURL,omitempty"`
+	Builder  string `json:"Builder,omitempty"`
+	Commit   string `json:"Commit,omitempty"`
+	Repo     string `json:"Repo,omitempty"`
+}
+
+type ErrorResource struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	URL     string `json:"url"`
+}
+
+type Formation struct {
+	Args      []string  `json:"args,omitempty"`
+	Command   string    `json:"command"`
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+	Quantity  int       `json:"quantity"`
+	Size      string    `json:"size"`
+	Type      string    `json:"type"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type FreezeWindow struct {
+	App *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy string    `json:"created_by"`
+	EndsAt    time.Time `json:"ends_at"`
+	ID        string    `json:"id"`
+	Reason    string    `json:"reason"`
+	StartsAt  time.Time `json:"starts_at"`
+	Team      string    `json:"team,omitempty"`
+}
+
+type FreezeWindowCreateOpts struct {
+	App      *string   `json:"app,omitempty"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   *string   `json:"reason,omitempty"`
+	StartsAt time.Time `json:"starts_at"`
+	Team     *string   `json:"team,omitempty"`
+}
+
+type Grant struct {
+	App *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+	Namespace string    `json:"namespace,omitempty"`
+	Role      string    `json:"role"`
+	Team      string    `json:"team,omitempty"`
+	Username  string    `json:"username,omitempty"`
+}
+
+type GrantCreateOpts struct {
+	App       *string `json:"app,omitempty"`
+	Namespace *string `json:"namespace,omitempty"`
+	Role      string  `json:"role"`
+	Team      *string `json:"team,omitempty"`
+	Username  *string `json:"username,omitempty"`
+}
+
+type Host struct {
+	ID string `json:"id"`
+}
+
+type ImageRedeployOpts struct {
+	Image       string `json:"image,omitempty"`
+	Interval    int    `json:"interval,omitempty"`
+	Layer       string `json:"layer,omitempty"`
+	Parallelism int    `json:"parallelism,omitempty"`
+	Tag         string `json:"tag,omitempty"`
+}
+
+type ImageUsage struct {
+	App struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app"`
+	Image   string   `json:"image"`
+	Layers  []string `json:"layers"`
+	Release struct {
+		ID      string `json:"id"`
+		Version int    `json:"version"`
+	} `json:"release"`
+}
+
+type IngressRule struct {
+	App struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app"`
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+	Port      int       `json:"port"`
+	SourceApp struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"source_app"`
+}
+
+type IngressRuleCreateOpts struct {
+	Port      int    `json:"port"`
+	SourceApp string `json:"source_app"`
+}
+
+type Machine struct {
+	Allocated Resources `json:"allocated"`
+	Dynos     int       `json:"dynos"`
+	Fits      *int      `json:"fits,omitempty"`
+	Host      Host      `json:"host"`
+	Lost      bool      `json:"lost"`
+	Total     Resources `json:"total"`
+	Zone      string    `json:"zone"`
+}
+
+type Namespace struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Team      string    `json:"team"`
+}
+
+type NamespaceCreateOpts struct {
+	Name string `json:"name"`
+	Team string `json:"team"`
+}
+
+type OperatorConfig struct {
+	FreezeWindows int      `json:"freeze_windows"`
+	Quotas        int      `json:"quotas"`
+	Sizes         []string `json:"sizes"`
+}
+
+type PatchFormationForm struct {
+	Updates []struct {
+		Args     []string `json:"args"`
+		Change   *string  `json:"change"`
+		Process  string   `json:"process"`
+		Quantity int      `json:"quantity"`
+		Size     *string  `json:"size"`
+	} `json:"updates"`
+}
+
+type PostAppsForm struct {
+	Name         string `json:"name"`
+	Organization string `json:"organization"`
+	Region       string `json:"region"`
+}
+
+type PostBatchesForm struct {
+	Commands    []string `json:"commands"`
+	Parallelism int      `json:"parallelism"`
+	Size        *string  `json:"size"`
+}
+
+type PostBulkForm struct {
+	Confirm   string `json:"confirm"`
+	Operation string `json:"operation"`
+	Selector  string `json:"selector"`
+	Updates   []struct {
+		Args     []string `json:"args"`
+		Change   *string  `json:"change"`
+		Process  string   `json:"process"`
+		Quantity int      `json:"quantity"`
+		Size     *string  `json:"size"`
+	} `json:"updates"`
+	Vars map[string]*string `json:"vars"`
+}
+
+type PostDeployForm struct {
+	Image      string      `json:"Image"`
+	Provenance *Provenance `json:"Provenance"`
+	Simulate   bool        `json:"Simulate"`
+	Stream     bool        `json:"Stream"`
+}
+
+type PostDomainsForm struct {
+	Hostname string `json:"hostname"`
+}
+
+type PostLogsForm struct {
+	Duration int64 `json:"Duration"`
+}
+
+type PostProcessForm struct {
+	Attach  bool              `json:"attach"`
+	Capture bool              `json:"capture"`
+	Command string            `json:"command"`
+	CPU     string            `json:"cpu"`
+	Env     map[string]string `json:"env"`
+	Memory  string            `json:"memory"`
+	Size    *string           `json:"size"`
+	Timeout int               `json:"timeout"`
+}
+
+type PostReleasesForm struct {
+	Release string `json:"release"`
+}
+
+type ProcessSpec struct {
+	Quantity int    `json:"quantity"`
+	Size     string `json:"size,omitempty"`
+}
+
+type Provenance struct {
+	BuildURL string `json:"build_url,omitempty"`
+	Builder  string `json:"builder,omitempty"`
+	Commit   string `json:"commit,omitempty"`
+	Repo     string `json:"repo,omitempty"`
+}
+
+type Quota struct {
+	App *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	ID           string    `json:"id"`
+	MaxInstances int       `json:"max_instances"`
+	MaxMemory    int64     `json:"max_memory"`
+	MaxRunMemory int64     `json:"max_run_memory"`
+	MaxRuns      int       `json:"max_runs"`
+	Namespace    string    `json:"namespace,omitempty"`
+	Team         string    `json:"team,omitempty"`
+}
+
+type QuotaCreateOpts struct {
+	App          *string `json:"app,omitempty"`
+	MaxInstances *int    `json:"max_instances,omitempty"`
+	MaxMemory    *string `json:"max_memory,omitempty"`
+	MaxRunMemory *string `json:"max_run_memory,omitempty"`
+	MaxRuns      *int    `json:"max_runs,omitempty"`
+	Namespace    *string `json:"namespace,omitempty"`
+	Team         *string `json:"team,omitempty"`
+}
+
+type RegistryCredential struct {
+	App *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
+	CredentialsParameter string    `json:"credentials_parameter,omitempty"`
+	ID                   string    `json:"id"`
+	Registry             string    `json:"registry"`
+	Scope                string    `json:"scope"`
+	Team                 string    `json:"team,omitempty"`
+	Username             string    `json:"username,omitempty"`
+}
+
+type RegistryCredentialCreateOpts struct {
+	App                  *string `json:"app,omitempty"`
+	CredentialsParameter *string `json:"credentials_parameter,omitempty"`
+	Password             *string `json:"password,omitempty"`
+	Registry             string  `json:"registry"`
+	Team                 *string `json:"team,omitempty"`
+	Username             *string `json:"username,omitempty"`
+}
+
+type Release struct {
+	Changes     *ReleaseChanges `json:"changes,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Description string          `json:"description"`
+	ID          string          `json:"id"`
+	Message     string          `json:"message"`
+	Provenance  *Provenance     `json:"provenance,omitempty"`
+	Slug        *struct {
+		ID string `json:"id"`
+	} `json:"slug"`
+	Source    string    `json:"source"`
+	UpdatedAt time.Time `json:"updated_at"`
+	User      struct {
+		Email string `json:"email"`
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+	} `json:"user"`
+	Version int `json:"version"`
+}
+
+type ReleaseChanges struct {
+	Formation []ReleaseProcessChange `json:"formation,omitempty"`
+	Image     *struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	} `json:"image,omitempty"`
+	VarsAdded   []string `json:"vars_added,omitempty"`
+	VarsChanged []string `json:"vars_changed,omitempty"`
+	VarsRemoved []string `json:"vars_removed,omitempty"`
+}
+
+type ReleaseExport struct {
+	CreatedAt   time.Time        `json:"created_at"`
+	Description string           `json:"description"`
+	Provenance  EmpireProvenance `json:"provenance,omitempty"`
+	Version     int              `json:"version"`
+}
+
+type ReleaseProcessChange struct {
+	Current  *ReleaseProcessScale `json:"current"`
+	Previous *ReleaseProcessScale `json:"previous"`
+	Type     string               `json:"type"`
+}
+
+type ReleaseProcessScale struct {
+	Quantity int    `json:"quantity"`
+	Size     string `json:"size"`
+}
+
+type Resources struct {
+	CPU    int   `json:"cpu"`
+	GPU    int   `json:"gpu"`
+	Memory int64 `json:"memory"`
+}
+
+type RouterSettings struct {
+	AllowedCIDRs    []string   `json:"AllowedCIDRs,omitempty"`
+	BasicAuth       *BasicAuth `json:"BasicAuth,omitempty"`
+	DrainTimeout    *int64     `json:"DrainTimeout,omitempty"`
+	IdleTimeout     int64      `json:"IdleTimeout,omitempty"`
+	ProtocolVersion string     `json:"ProtocolVersion,omitempty"`
+	StickySessions  bool       `json:"StickySessions,omitempty"`
+	WebSockets      bool       `json:"WebSockets,omitempty"`
+}
+
+type RoutingRule struct {
+	App struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app"`
+	CreatedAt time.Time `json:"created_at"`
+	Host      string    `json:"host"`
+	ID        string    `json:"id"`
+	Path      string    `json:"path"`
+	Process   string    `json:"process"`
+}
+
+type RoutingRuleCreateOpts struct {
+	Host    string `json:"host,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Process string `json:"process"`
+}
+
+type TeamMember struct {
+	CreatedAt time.Time `json:"created_at"`
+	Team      string    `json:"team"`
+	Username  string    `json:"username"`
+}
+
+type TeamMemberCreateOpts struct {
+	Username string `json:"username"`
+}
+
+type Transition struct {
+	Host       string    `json:"host"`
+	ID         string    `json:"id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Pid        string    `json:"pid"`
+	Process    string    `json:"process"`
+	Reason     string    `json:"reason"`
+	Release    int       `json:"release"`
+	State      string    `json:"state"`
+}
+
+type TwoFactorSecret struct {
+	Secret string `json:"secret"`
+	URL    string `json:"url"`
+}
+
+type Usage struct {
+	App           string    `json:"app,omitempty"`
+	InstanceHours float64   `json:"instance_hours"`
+	MemoryHours   float64   `json:"memory_hours"`
+	Month         time.Time `json:"month"`
+	Team          string    `json:"team"`
+}
+
+// DeleteAPIToken sends a DELETE request to /api-tokens/{id}.
+func (c *Client) DeleteAPIToken(ctx context.Context, id string) error {
+	return c.do(ctx, "DELETE", "/api-tokens/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// DeleteApp sends a DELETE request to /apps/{app}.
+func (c *Client) DeleteApp(ctx context.Context, app string) error {
+	return c.do(ctx, "DELETE", "/apps/"+url.PathEscape(app), nil, nil, nil)
+}
+
+// DeleteDomain sends a DELETE request to /apps/{app}/domains/{hostname}.
+func (c *Client) DeleteDomain(ctx context.Context, app string, hostname string) error {
+	return c.do(ctx, "DELETE", "/apps/"+url.PathEscape(app)+"/domains/"+url.PathEscape(hostname), nil, nil, nil)
+}
+
+// DeleteFreezeWindow sends a DELETE request to /freeze-windows/{id}.
+func (c *Client) DeleteFreezeWindow(ctx context.Context, id string) error {
+	return c.do(ctx, "DELETE", "/freeze-windows/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// DeleteGrant sends a DELETE request to /grants/{id}.
+func (c *Client) DeleteGrant(ctx context.Context, id string) error {
+	return c.do(ctx, "DELETE", "/grants/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// DeleteHostCordon sends a DELETE request to /hosts/{host}/cordon.
+func (c *Client) DeleteHostCordon(ctx context.Context, host string) error {
+	return c.do(ctx, "DELETE", "/hosts/"+url.PathEscape(host)+"/cordon", nil, nil, nil)
+}
+
+// DeleteIngressRule sends a DELETE request to /apps/{app}/ingress-rules/{id}.
+func (c *Client) DeleteIngressRule(ctx context.Context, app string, id string) error {
+	return c.do(ctx, "DELETE", "/apps/"+url.PathEscape(app)+"/ingress-rules/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// DeleteNamespace sends a DELETE request to /namespaces/{namespace}.
+func (c *Client) DeleteNamespace(ctx context.Context, namespace string) error {
+	return c.do(ctx, "DELETE", "/namespaces/"+url.PathEscape(namespace), nil, nil, nil)
+}
+
+// DeleteProcess sends a DELETE request to /apps/{app}/dynos/{pid}.
+func (c *Client) DeleteProcess(ctx context.Context, app string, pid string) error {
+	return c.do(ctx, "DELETE", "/apps/"+url.PathEscape(app)+"/dynos/"+url.PathEscape(pid), nil, nil, nil)
+}
+
+// DeleteProcesses sends a DELETE request to /apps/{app}/dynos.
+func (c *Client) DeleteProcesses(ctx context.Context, app string) error {
+	return c.do(ctx, "DELETE", "/apps/"+url.PathEscape(app)+"/dynos", nil, nil, nil)
+}
+
+// DeleteQuota sends a DELETE request to /quotas/{id}.
+func (c *Client) DeleteQuota(ctx context.Context, id string) error {
+	return c.do(ctx, "DELETE", "/quotas/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// DeleteRegistryCredential sends a DELETE request to /registry-credentials/{id}.
+func (c *Client) DeleteRegistryCredential(ctx context.Context, id string) error {
+	return c.do(ctx, "DELETE", "/registry-credentials/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// DeleteRoutingRule sends a DELETE request to /apps/{app}/routing-rules/{id}.
+func (c *Client) DeleteRoutingRule(ctx context.Context, app string, id string) error {
+	return c.do(ctx, "DELETE", "/apps/"+url.PathEscape(app)+"/routing-rules/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// DeleteTeamMember sends a DELETE request to /teams/{team}/members/{username}.
+func (c *Client) DeleteTeamMember(ctx context.Context, team string, username string) error {
+	return c.do(ctx, "DELETE", "/teams/"+url.PathEscape(team)+"/members/"+url.PathEscape(username), nil, nil, nil)
+}
+
+// DeleteTwoFactor sends a DELETE request to /two-factor/{username}.
+func (c *Client) DeleteTwoFactor(ctx context.Context, username string) error {
+	return c.do(ctx, "DELETE", "/two-factor/"+url.PathEscape(username), nil, nil, nil)
+}
+
+// DeployApp sends a POST request to /apps/{app}/deploys.
+func (c *Client) DeployApp(ctx context.Context, app string, body *PostDeployForm) (io.ReadCloser, error) {
+	return c.stream(ctx, "POST", "/apps/"+url.PathEscape(app)+"/deploys", nil, body)
+}
+
+// GetAPITokens sends a GET request to /api-tokens.
+func (c *Client) GetAPITokens(ctx context.Context) ([]APIToken, error) {
+	var v []APIToken
+	err := c.do(ctx, "GET", "/api-tokens", nil, nil, &v)
+	return v, err
+}
+
+// GetActivity sends a GET request to /apps/{app}/activity.
+func (c *Client) GetActivity(ctx context.Context, app string) ([]Activity, error) {
+	var v []Activity
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app)+"/activity", nil, nil, &v)
+	return v, err
+}
+
+// GetAppExport sends a GET request to /apps/{app}/export.
+func (c *Client) GetAppExport(ctx context.Context, app string) (*AppExport, error) {
+	var v *AppExport
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app)+"/export", nil, nil, &v)
+	return v, err
+}
+
+// GetAppInfo sends a GET request to /apps/{app}.
+func (c *Client) GetAppInfo(ctx context.Context, app string) (*App, error) {
+	var v *App
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app), nil, nil, &v)
+	return v, err
+}
+
+// GetAppOverview sends a GET request to /apps/{app}/overview.
+func (c *Client) GetAppOverview(ctx context.Context, app string) (*AppOverview, error) {
+	var v *AppOverview
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app)+"/overview", nil, nil, &v)
+	return v, err
+}
+
+// GetAppsParams are the query parameters of GetApps.
+type GetAppsParams struct {
+	Labels string `query:"labels"`
+}
+
+// GetApps sends a GET request to /apps.
+func (c *Client) GetApps(ctx context.Context, params *GetAppsParams) ([]App, error) {
+	var v []App
+	err := c.do(ctx, "GET", "/apps", params, nil, &v)
+	return v, err
+}
+
+// GetBatch sends a GET request to /apps/{app}/batches/{id}.
+func (c *Client) GetBatch(ctx context.Context, app string, id string) (*Batch, error) {
+	var v *Batch
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app)+"/batches/"+url.PathEscape(id), nil, nil, &v)
+	return v, err
+}
+
+// GetBatches sends a GET request to /apps/{app}/batches.
+func (c *Client) GetBatches(ctx context.Context, app string) ([]Batch, error) {
+	var v []Batch
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app)+"/batches", nil, nil, &v)
+	return v, err
+}
+
+// GetCapacityParams are the query parameters of GetCapacity.
+type GetCapacityParams struct {
+	Size string `query:"size"`
+}
+
+// GetCapacity sends a GET request to /capacity.
+func (c *Client) GetCapacity(ctx context.Context, params *GetCapacityParams) ([]Capacity, error) {
+	var v []Capacity
+	err := c.do(ctx, "GET", "/capacity", params, nil, &v)
+	return v, err
+}
+
+// GetChaosKills sends a GET request to /apps/{app}/chaos-kills.
+func (c *Client) GetChaosKills(ctx context.Context, app string) ([]ChaosKill, error) {
+	var v []ChaosKill
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app)+"/chaos-kills", nil, nil, &v)
+	return v, err
+}
+
+// GetClusterProcessesParams are the query parameters of GetClusterProcesses.
+type GetClusterProcessesParams struct {
+	Type    string `query:"type"`
+	Version string `query:"version"`
+	State   string `query:"state"`
+	Host    string `query:"host"`
+	Image   string `query:"image"`
+	Labels  string `query:"labels"`
+}
+
+// GetClusterProcesses sends a GET request to /dynos.
+func (c *Client) GetClusterProcesses(ctx context.Context, params *GetClusterProcessesParams) ([]Dyno, error) {
+	var v []Dyno
+	err := c.do(ctx, "GET", "/dynos", params, nil, &v)
+	return v, err
+}
+
+// GetConfigs sends a GET request to /apps/{app}/config-vars.
+func (c *Client) GetConfigs(ctx context.Context, app string) (map[string]*string, error) {
+	var v map[string]*string
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app)+"/config-vars", nil, nil, &v)
+	return v, err
+}
+
+// GetConfigsByRelease sends a GET request to /apps/{app}/config-vars/{version}.
+func (c *Client) GetConfigsByRelease(ctx context.Context, app string, version string) (map[string]*string, error) {
+	var v map[string]*string
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app)+"/config-vars/"+url.PathEscape(version), nil, nil, &v)
+	return v, err
+}
+
+// GetCronRuns sends a GET request to /apps/{app}/crons/{process}/runs.
+func (c *Client) GetCronRuns(ctx context.Context, app string, process string) ([]CronRun, error) {
+	var v []CronRun
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app)+"/crons/"+url.PathEscape(process)+"/runs", nil, nil, &v)
+	return v, err
+}
+
+// GetDomains sends a GET request to /apps/{app}/domains.
+func (c *Client) GetDomains(ctx context.Context, app string) ([]Domain, error) {
+	var v []Domain
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app)+"/domains", nil, nil, &v)
+	return v, err
+}
+
+// GetFormation sends a GET request to /apps/{app}/formation.
+func (c *Client) GetFormation(ctx context.Context, app string) ([]Formation, error) {
+	var v []Formation
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app)+"/formation", nil, nil, &v)
+	return v, err
+}
+
+// GetFreezeWindows sends a GET request to /freeze-windows.
+func (c *Client) GetFreezeWindows(ctx context.Context) ([]FreezeWindow, error) {
+	var v []FreezeWindow
+	err := c.do(ctx, "GET", "/freeze-windows", nil, nil, &v)
+	return v, err
+}
+
+// GetGrants sends a GET request to /grants.
+func (c *Client) GetGrants(ctx context.Context) ([]Grant, error) {
+	var v []Grant
+	err := c.do(ctx, "GET", "/grants", nil, nil, &v)
+	return v, err
+}
+
+// GetImageUsageParams are the query parameters of GetImageUsage.
+type GetImageUsageParams struct {
+	Image string `query:"image"`
+	Layer string `query:"layer"`
+}
+
+// GetImageUsage sends a GET request to /images/usage.
+func (c *Client) GetImageUsage(ctx context.Context, params *GetImageUsageParams) ([]ImageUsage, error) {
+	var v []ImageUsage
+	err := c.do(ctx, "GET", "/images/usage", params, nil, &v)
+	return v, err
+}
+
+// GetIngressRules sends a GET request to /apps/{app}/ingress-rules.
+func (c *Client) GetIngressRules(ctx context.Context, app string) ([]IngressRule, error) {
+	var v []IngressRule
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app)+"/ingress-rules", nil, nil, &v)
+	return v, err
+}
+
+// GetJobOutput sends a GET request to /apps/{app}/jobs/{id}/output.
+func (c *Client) GetJobOutput(ctx context.Context, app string, id string) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/apps/"+url.PathEscape(app)+"/jobs/"+url.PathEscape(id)+"/output", nil, nil)
+}
+
+// GetNamespaceApps sends a GET request to /namespaces/{namespace}/apps.
+func (c *Client) GetNamespaceApps(ctx context.Context, namespace string) ([]App, error) {
+	var v []App
+	err := c.do(ctx, "GET", "/namespaces/"+url.PathEscape(namespace)+"/apps", nil, nil, &v)
+	return v, err
+}
+
+// GetNamespaces sends a GET request to /namespaces.
+func (c *Client) GetNamespaces(ctx context.Context) ([]Namespace, error) {
+	var v []Namespace
+	err := c.do(ctx, "GET", "/namespaces", nil, nil, &v)
+	return v, err
+}
+
+// GetProcessFilesParams are the query parameters of GetProcessFiles.
+type GetProcessFilesParams struct {
+	Path string `query:"path"`
+}
+
+// GetProcessFiles sends a GET request to /apps/{app}/dynos/{pid}/files.
+func (c *Client) GetProcessFiles(ctx context.Context, app string, pid string, params *GetProcessFilesParams) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/apps/"+url.PathEscape(app)+"/dynos/"+url.PathEscape(pid)+"/files", params, nil)
+}
+
+// GetProcessesParams are the query parameters of GetProcesses.
+type GetProcessesParams struct {
+	Type    string `query:"type"`
+	Version string `query:"version"`
+	State   string `query:"state"`
+	Host    string `query:"host"`
+	Image   string `query:"image"`
+	Labels  string `query:"labels"`
+}
+
+// GetProcesses sends a GET request to /apps/{app}/dynos.
+func (c *Client) GetProcesses(ctx context.Context, app string, params *GetProcessesParams) ([]Dyno, error) {
+	var v []Dyno
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app)+"/dynos", params, nil, &v)
+	return v, err
+}
+
+// GetQuotas sends a GET request to /quotas.
+func (c *Client) GetQuotas(ctx context.Context) ([]Quota, error) {
+	var v []Quota
+	err := c.do(ctx, "GET", "/quotas", nil, nil, &v)
+	return v, err
+}
+
+// GetRegistryCredentials sends a GET request to /registry-credentials.
+func (c *Client) GetRegistryCredentials(ctx context.Context) ([]RegistryCredential, error) {
+	var v []RegistryCredential
+	err := c.do(ctx, "GET", "/registry-credentials", nil, nil, &v)
+	return v, err
+}
+
+// GetRelease sends a GET request to /apps/{app}/releases/{version}.
+func (c *Client) GetRelease(ctx context.Context, app string, version string) (*Release, error) {
+	var v *Release
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app)+"/releases/"+url.PathEscape(version), nil, nil, &v)
+	return v, err
+}
+
+// GetReleaseDeployLog sends a GET request to /apps/{app}/releases/{version}/deploy-log.
+func (c *Client) GetReleaseDeployLog(ctx context.Context, app string, version string) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/apps/"+url.PathEscape(app)+"/releases/"+url.PathEscape(version)+"/deploy-log", nil, nil)
+}
+
+// GetReleases sends a GET request to /apps/{app}/releases.
+func (c *Client) GetReleases(ctx context.Context, app string) ([]Release, error) {
+	var v []Release
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app)+"/releases", nil, nil, &v)
+	return v, err
+}
+
+// GetRoutingRules sends a GET request to /apps/{app}/routing-rules.
+func (c *Client) GetRoutingRules(ctx context.Context, app string) ([]RoutingRule, error) {
+	var v []RoutingRule
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app)+"/routing-rules", nil, nil, &v)
+	return v, err
+}
+
+// GetTeamMembers sends a GET request to /teams/{team}/members.
+func (c *Client) GetTeamMembers(ctx context.Context, team string) ([]TeamMember, error) {
+	var v []TeamMember
+	err := c.do(ctx, "GET", "/teams/"+url.PathEscape(team)+"/members", nil, nil, &v)
+	return v, err
+}
+
+// GetTimeline sends a GET request to /apps/{app}/timeline.
+func (c *Client) GetTimeline(ctx context.Context, app string) ([]Transition, error) {
+	var v []Transition
+	err := c.do(ctx, "GET", "/apps/"+url.PathEscape(app)+"/timeline", nil, nil, &v)
+	return v, err
+}
+
+// GetUsageParams are the query parameters of GetUsage.
+type GetUsageParams struct {
+	Month  string `query:"month"`
+	Labels string `query:"labels"`
+	By     string `query:"by"`
+}
+
+// GetUsage sends a GET request to /usage.
+func (c *Client) GetUsage(ctx context.Context, params *GetUsageParams) ([]Usage, error) {
+	var v []Usage
+	err := c.do(ctx, "GET", "/usage", params, nil, &v)
+	return v, err
+}
+
+// PatchApp sends a PATCH request to /apps/{app}.
+func (c *Client) PatchApp(ctx context.Context, app string, body *AppUpdateOpts) (*App, error) {
+	var v *App
+	err := c.do(ctx, "PATCH", "/apps/"+url.PathEscape(app), nil, body, &v)
+	return v, err
+}
+
+// PatchConfigs sends a PATCH request to /apps/{app}/config-vars.
+func (c *Client) PatchConfigs(ctx context.Context, app string, body map[string]*string) (map[string]*string, error) {
+	var v map[string]*string
+	err := c.do(ctx, "PATCH", "/apps/"+url.PathEscape(app)+"/config-vars", nil, body, &v)
+	return v, err
+}
+
+// PatchFormation sends a PATCH request to /apps/{app}/formation.
+func (c *Client) PatchFormation(ctx context.Context, app string, body *PatchFormationForm) ([]Formation, error) {
+	var v []Formation
+	err := c.do(ctx, "PATCH", "/apps/"+url.PathEscape(app)+"/formation", nil, body, &v)
+	return v, err
+}
+
+// PostAPITokens sends a POST request to /api-tokens.
+func (c *Client) PostAPITokens(ctx context.Context, body *APITokenCreateOpts) (*APIToken, error) {
+	var v *APIToken
+	err := c.do(ctx, "POST", "/api-tokens", nil, body, &v)
+	return v, err
+}
+
+// PostApply sends a POST request to /apply.
+func (c *Client) PostApply(ctx context.Context, body *AppSpecApplyOpts) (*AppSpecApplyResult, error) {
+	var v *AppSpecApplyResult
+	err := c.do(ctx, "POST", "/apply", nil, body, &v)
+	return v, err
+}
+
+// PostApps sends a POST request to /apps.
+func (c *Client) PostApps(ctx context.Context, body *PostAppsForm) (*App, error) {
+	var v *App
+	err := c.do(ctx, "POST", "/apps", nil, body, &v)
+	return v, err
+}
+
+// PostAuthorizations sends a POST request to /oauth/authorizations.
+func (c *Client) PostAuthorizations(ctx context.Context) (*Authorization, error) {
+	var v *Authorization
+	err := c.do(ctx, "POST", "/oauth/authorizations", nil, nil, &v)
+	return v, err
+}
+
+// PostBatches sends a POST request to /apps/{app}/batches.
+func (c *Client) PostBatches(ctx context.Context, app string, body *PostBatchesForm) (*Batch, error) {
+	var v *Batch
+	err := c.do(ctx, "POST", "/apps/"+url.PathEscape(app)+"/batches", nil, body, &v)
+	return v, err
+}
+
+// PostBulk sends a POST request to /bulk.
+func (c *Client) PostBulk(ctx context.Context, body *PostBulkForm) (*BulkPlan, error) {
+	var v *BulkPlan
+	err := c.do(ctx, "POST", "/bulk", nil, body, &v)
+	return v, err
+}
+
+// PostCerts sends a POST request to /apps/{app}/certs.
+func (c *Client) PostCerts(ctx context.Context, app string, body *CertsAttachOpts) error {
+	return c.do(ctx, "POST", "/apps/"+url.PathEscape(app)+"/certs", nil, body, nil)
+}
+
+// PostCronRuns sends a POST request to /apps/{app}/crons/{process}/runs.
+func (c *Client) PostCronRuns(ctx context.Context, app string, process string) (*CronRun, error) {
+	var v *CronRun
+	err := c.do(ctx, "POST", "/apps/"+url.PathEscape(app)+"/crons/"+url.PathEscape(process)+"/runs", nil, nil, &v)
+	return v, err
+}
+
+// PostDeploys sends a POST request to /deploys.
+func (c *Client) PostDeploys(ctx context.Context, body *PostDeployForm) (io.ReadCloser, error) {
+	return c.stream(ctx, "POST", "/deploys", nil, body)
+}
+
+// PostDomains sends a POST request to /apps/{app}/domains.
+func (c *Client) PostDomains(ctx context.Context, app string, body *PostDomainsForm) (*Domain, error) {
+	var v *Domain
+	err := c.do(ctx, "POST", "/apps/"+url.PathEscape(app)+"/domains", nil, body, &v)
+	return v, err
+}
+
+// PostFreezeWindows sends a POST request to /freeze-windows.
+func (c *Client) PostFreezeWindows(ctx context.Context, body *FreezeWindowCreateOpts) (*FreezeWindow, error) {
+	var v *FreezeWindow
+	err := c.do(ctx, "POST", "/freeze-windows", nil, body, &v)
+	return v, err
+}
+
+// PostGrants sends a POST request to /grants.
+func (c *Client) PostGrants(ctx context.Context, body *GrantCreateOpts) (*Grant, error) {
+	var v *Grant
+	err := c.do(ctx, "POST", "/grants", nil, body, &v)
+	return v, err
+}
+
+// PostHostCordon sends a POST request to /hosts/{host}/cordon.
+func (c *Client) PostHostCordon(ctx context.Context, host string) error {
+	return c.do(ctx, "POST", "/hosts/"+url.PathEscape(host)+"/cordon", nil, nil, nil)
+}
+
+// PostHostDrain sends a POST request to /hosts/{host}/drain.
+func (c *Client) PostHostDrain(ctx context.Context, host string) ([]Dyno, error) {
+	var v []Dyno
+	err := c.do(ctx, "POST", "/hosts/"+url.PathEscape(host)+"/drain", nil, nil, &v)
+	return v, err
+}
+
+// PostImageRedeploy sends a POST request to /images/redeploy.
+func (c *Client) PostImageRedeploy(ctx context.Context, body *ImageRedeployOpts) (io.ReadCloser, error) {
+	return c.stream(ctx, "POST", "/images/redeploy", nil, body)
+}
+
+// PostImport sends a POST request to /import.
+func (c *Client) PostImport(ctx context.Context, body *AppImportOpts) (*AppImportResult, error) {
+	var v *AppImportResult
+	err := c.do(ctx, "POST", "/import", nil, body, &v)
+	return v, err
+}
+
+// PostIngressRules sends a POST request to /apps/{app}/ingress-rules.
+func (c *Client) PostIngressRules(ctx context.Context, app string, body *IngressRuleCreateOpts) (*IngressRule, error) {
+	var v *IngressRule
+	err := c.do(ctx, "POST", "/apps/"+url.PathEscape(app)+"/ingress-rules", nil, body, &v)
+	return v, err
+}
+
+// PostLogs sends a POST request to /apps/{app}/log-sessions.
+func (c *Client) PostLogs(ctx context.Context, app string, body *PostLogsForm) (io.ReadCloser, error) {
+	return c.stream(ctx, "POST", "/apps/"+url.PathEscape(app)+"/log-sessions", nil, body)
+}
+
+// PostNamespaces sends a POST request to /namespaces.
+func (c *Client) PostNamespaces(ctx context.Context, body *NamespaceCreateOpts) (*Namespace, error) {
+	var v *Namespace
+	err := c.do(ctx, "POST", "/namespaces", nil, body, &v)
+	return v, err
+}
+
+// PostOperatorConfigReload sends a POST request to /operator-config/reload.
+func (c *Client) PostOperatorConfigReload(ctx context.Context) (*OperatorConfig, error) {
+	var v *OperatorConfig
+	err := c.do(ctx, "POST", "/operator-config/reload", nil, nil, &v)
+	return v, err
+}
+
+// PostProcess sends a POST request to /apps/{app}/dynos.
+func (c *Client) PostProcess(ctx context.Context, app string, body *PostProcessForm) (*Dyno, error) {
+	var v *Dyno
+	err := c.do(ctx, "POST", "/apps/"+url.PathEscape(app)+"/dynos", nil, body, &v)
+	return v, err
+}
+
+// PostQuotas sends a POST request to /quotas.
+func (c *Client) PostQuotas(ctx context.Context, body *QuotaCreateOpts) (*Quota, error) {
+	var v *Quota
+	err := c.do(ctx, "POST", "/quotas", nil, body, &v)
+	return v, err
+}
+
+// PostRegistryCredentials sends a POST request to /registry-credentials.
+func (c *Client) PostRegistryCredentials(ctx context.Context, body *RegistryCredentialCreateOpts) (*RegistryCredential, error) {
+	var v *RegistryCredential
+	err := c.do(ctx, "POST", "/registry-credentials", nil, body, &v)
+	return v, err
+}
+
+// PostReleases sends a POST request to /apps/{app}/releases.
+func (c *Client) PostReleases(ctx context.Context, app string, body *PostReleasesForm) (*Release, error) {
+	var v *Release
+	err := c.do(ctx, "POST", "/apps/"+url.PathEscape(app)+"/releases", nil, body, &v)
+	return v, err
+}
+
+// PostRoutingRules sends a POST request to /apps/{app}/routing-rules.
+func (c *Client) PostRoutingRules(ctx context.Context, app string, body *RoutingRuleCreateOpts) (*RoutingRule, error) {
+	var v *RoutingRule
+	err := c.do(ctx, "POST", "/apps/"+url.PathEscape(app)+"/routing-rules", nil, body, &v)
+	return v, err
+}
+
+// PostTeamMembers sends a POST request to /teams/{team}/members.
+func (c *Client) PostTeamMembers(ctx context.Context, team string, body *TeamMemberCreateOpts) (*TeamMember, error) {
+	var v *TeamMember
+	err := c.do(ctx, "POST", "/teams/"+url.PathEscape(team)+"/members", nil, body, &v)
+	return v, err
+}
+
+// PostTwoFactor sends a POST request to /two-factor.
+func (c *Client) PostTwoFactor(ctx context.Context) (*TwoFactorSecret, error) {
+	var v *TwoFactorSecret
+	err := c.do(ctx, "POST", "/two-factor", nil, nil, &v)
+	return v, err
+}
+
+// PostUserTwoFactor sends a POST request to /two-factor/{username}.
+func (c *Client) PostUserTwoFactor(ctx context.Context, username string) (*TwoFactorSecret, error) {
+	var v *TwoFactorSecret
+	err := c.do(ctx, "POST", "/two-factor/"+url.PathEscape(username), nil, nil, &v)
+	return v, err
+}
+
+// PutProcessFilesParams are the query parameters of PutProcessFiles.
+type PutProcessFilesParams struct {
+	Path string `query:"path"`
+}
+
+// PutProcessFiles sends a PUT request to /apps/{app}/dynos/{pid}/files.
+func (c *Client) PutProcessFiles(ctx context.Context, app string, pid string, params *PutProcessFilesParams, body io.Reader) error {
+	return c.do(ctx, "PUT", "/apps/"+url.PathEscape(app)+"/dynos/"+url.PathEscape(pid)+"/files", params, &upload{"application/x-tar", body}, nil)
+}
@@ -0,0 +1,187 @@
+// Package empireapi is a client for the Empire API. The types and methods in
+// api.go are generated from the OpenAPI document of the API, in
+// docs/openapi.json, so they stay in sync with the server.
+package empireapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+const (
+	// DefaultURL is the URL of the API when a Client doesn't have one.
+	DefaultURL = "http://localhost:8080"
+
+	// AcceptHeader is sent with each request, so that Empire routes it to
+	// the API.
+	AcceptHeader = "application/vnd.heroku+json; version=3"
+
+	// DefaultUserAgent is sent with requests when a Client doesn't have a
+	// UserAgent.
+	DefaultUserAgent = "empireapi"
+)
+
+// Client is a client for the Empire API. Its methods are generated in api.go.
+type Client struct {
+	// The http.Client that's used to send requests. The zero value is
+	// http.DefaultClient.
+	HTTP *http.Client
+
+	// The URL of the Empire API. The zero value is DefaultURL.
+	URL string
+
+	// The credentials that requests are authenticated with. Username is
+	// the name of the user, and Password is an access token or API token.
+	Username, Password string
+
+	// Sent in the User-Agent header. The zero value is DefaultUserAgent.
+	UserAgent string
+
+	// Extra headers to send with each request (e.g. Commit-Message or
+	// Idempotency-Key).
+	Header http.Header
+}
+
+// Error is returned when the API responds with an error.
+type Error struct {
+	// The status code of the response.
+	StatusCode int
+
+	ErrorResource
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// upload is a request body that's streamed, instead of JSON encoded.
+type upload struct {
+	contentType string
+	r           io.Reader
+}
+
+// do sends a request, and decodes the JSON response body into v, if it's not
+// nil.
+func (c *Client) do(ctx context.Context, method, path string, query, body, v interface{}) error {
+	resp, err := c.request(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if v == nil || resp.StatusCode == http.StatusNoContent {
+		_, err := io.Copy(ioutil.Discard, resp.Body)
+		return err
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// stream sends a request, and returns the response body, which the caller must
+// close.
+func (c *Client) stream(ctx context.Context, method, path string, query, body interface{}) (io.ReadCloser, error) {
+	resp, err := c.request(ctx, method, path, query, body)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// request sends a request, and returns an error if the response isn't
+// successful.
+func (c *Client) request(ctx context.Context, method, path string, query, body interface{}) (*http.Response, error) {
+	u := c.URL
+	if u == "" {
+		u = DefaultURL
+	}
+	u = strings.TrimSuffix(u, "/") + path
+	if q := encodeQuery(query); len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+
+	var (
+		r           io.Reader
+		contentType string
+	)
+	switch body := body.(type) {
+	case nil:
+	case *upload:
+		r, contentType = body.r, body.contentType
+	default:
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r, contentType = bytes.NewReader(raw), "application/json"
+	}
+
+	req, err := http.NewRequest(method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	for k, v := range c.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", AcceptHeader)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		e := &Error{StatusCode: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(&e.ErrorResource); err != nil || e.Message == "" {
+			e.Message = fmt.Sprintf("unexpected response: %s", resp.Status)
+		}
+		return nil, e
+	}
+
+	return resp, nil
+}
+
+// encodeQuery encodes the fields of a pointer to a struct, with `query` tags,
+// as query parameters. Empty fields are left out.
+func encodeQuery(params interface{}) url.Values {
+	v := reflect.ValueOf(params)
+	if params == nil || v.IsNil() {
+		return nil
+	}
+	v = v.Elem()
+
+	q := url.Values{}
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Tag.Get("query")
+		if s := fmt.Sprint(v.Field(i).Interface()); name != "" && s != "" {
+			q.Set(name, s)
+		}
+	}
+	return q
+}
@@ -0,0 +1,94 @@
+package empireapi
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/remind101/empire/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	openAPIFilename = "../../docs/openapi.json"
+	apiFilename     = "api.go"
+)
+
+func TestGenerateClient(t *testing.T) {
+	raw, err := ioutil.ReadFile(openAPIFilename)
+	assert.NoError(t, err)
+
+	var doc openapi.Document
+	assert.NoError(t, json.Unmarshal(raw, &doc))
+
+	src, err := openapi.GenerateClient(&doc, "empireapi")
+	assert.NoError(t, err)
+
+	expected, err := ioutil.ReadFile(apiFilename)
+	assert.NoError(t, err)
+
+	if got, want := string(src), string(expected); got != want {
+		ioutil.WriteFile(apiFilename, src, 0660)
+		t.Errorf("expected generated client to match existing %s. Wrote to %s", apiFilename, apiFilename)
+	}
+}
+
+func TestClient(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		assert.Equal(t, "ejholmes", username)
+		assert.Equal(t, "token", password)
+		assert.Equal(t, AcceptHeader, r.Header.Get("Accept"))
+		assert.Equal(t, "Scale web", r.Header.Get("Commit-Message"))
+
+		switch r.URL.Path {
+		case "/apps/acme-inc/formation":
+			var form PatchFormationForm
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&form))
+			assert.Equal(t, "web", form.Updates[0].Process)
+			w.Write([]byte(`[{"type":"web","quantity":2}]`))
+		case "/capacity":
+			assert.Equal(t, "2X", r.URL.Query().Get("size"))
+			w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"id":"not_found","message":"Request failed, the specified resource does not exist"}`))
+		}
+	}))
+	defer s.Close()
+
+	c := &Client{
+		URL:      s.URL,
+		Username: "ejholmes",
+		Password: "token",
+		Header:   http.Header{"Commit-Message": []string{"Scale web"}},
+	}
+	ctx := context.Background()
+
+	form := &PatchFormationForm{}
+	form.Updates = append(form.Updates, struct {
+		Args     []string `json:"args"`
+		Change   *string  `json:"change"`
+		Process  string   `json:"process"`
+		Quantity int      `json:"quantity"`
+		Size     *string  `json:"size"`
+	}{Process: "web", Quantity: 2})
+	formation, err := c.PatchFormation(ctx, "acme-inc", form)
+	assert.NoError(t, err)
+	assert.Equal(t, []Formation{{Type: "web", Quantity: 2}}, formation)
+
+	_, err = c.GetCapacity(ctx, &GetCapacityParams{Size: "2X"})
+	assert.NoError(t, err)
+
+	_, err = c.GetAppInfo(ctx, "api")
+	assert.Equal(t, &Error{
+		StatusCode: 404,
+		ErrorResource: ErrorResource{
+			ID:      "not_found",
+			Message: "Request failed, the specified resource does not exist",
+		},
+	}, err)
+}
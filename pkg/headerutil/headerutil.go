@@ -14,6 +14,11 @@ type Range struct {
 
 	// The order the results are returned in.
 	Order *string
+
+	// If provided, the value of the sort field to start from (e.g. `name
+	// v1.web.1..`). When prefixed with "]", the start value is exclusive,
+	// which allows clients to request the next page of results.
+	Start *string
 }
 
 func ParseRange(header string) (*Range, error) {
@@ -25,8 +30,11 @@ func ParseRange(header string) (*Range, error) {
 			if len(parts) == 1 {
 				if rangeHeader.Sort == nil {
 					if len(parts[0]) > 0 && parts[0] != " " {
-						sort := strings.TrimSpace(strings.TrimRight(parts[0], " .."))
+						sort, start := parseRangeField(parts[0])
 						rangeHeader.Sort = &sort
+						if start != "" {
+							rangeHeader.Start = &start
+						}
 					}
 				}
 			} else {
@@ -68,5 +76,26 @@ func (r *Range) WithDefaults(d Range) Range {
 		c.Order = d.Order
 	}
 
+	if c.Start == nil {
+		c.Start = d.Start
+	}
+
 	return c
 }
+
+// parseRangeField parses the `<field> [<start>]..[<end>]` portion of a range
+// header, returning the field and start value.
+func parseRangeField(s string) (field, start string) {
+	s = strings.TrimSpace(s)
+
+	i := strings.Index(s, " ")
+	if i < 0 {
+		return strings.TrimRight(s, " .."), ""
+	}
+
+	field, rest := s[:i], strings.TrimSpace(s[i+1:])
+	if j := strings.Index(rest, ".."); j >= 0 {
+		rest = rest[:j]
+	}
+	return strings.TrimRight(field, " .."), rest
+}
@@ -63,3 +63,38 @@ func TestParseRange(t *testing.T) {
 		}
 	}
 }
+
+func TestParseRange_Start(t *testing.T) {
+	tests := []struct {
+		in    string
+		sort  string
+		start *string
+	}{
+		{"name ..", "name", nil},
+		{"name v1.web.1..; max=20", "name", strPtr("v1.web.1")},
+		{"name ]v1.web.1..; max=20", "name", strPtr("]v1.web.1")},
+		{"name v1.web.1..v1.web.9", "name", strPtr("v1.web.1")},
+	}
+
+	for i, tt := range tests {
+		r, err := ParseRange(tt.in)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+
+		if got, want := *r.Sort, tt.sort; got != want {
+			t.Fatalf("#%d: Range.Sort => %s; want %s", i, got, want)
+		}
+
+		switch {
+		case tt.start == nil && r.Start != nil:
+			t.Fatalf("#%d: Range.Start => %s; want nil", i, *r.Start)
+		case tt.start != nil && (r.Start == nil || *r.Start != *tt.start):
+			t.Fatalf("#%d: Range.Start => %v; want %s", i, r.Start, *tt.start)
+		}
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
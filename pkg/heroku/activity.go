@@ -0,0 +1,68 @@
+package heroku
+
+import (
+	"net/url"
+	"time"
+)
+
+// Something that happened to an app: a release, a scale, a restart, or a
+// crash.
+type Activity struct {
+	// what happened: deploy, rollback, config, release, scale, restart or
+	// crash
+	Type string `json:"type"`
+
+	// the user that did it, if it was done by a user
+	User string `json:"user"`
+
+	// a description of what happened
+	Description string `json:"description"`
+
+	// the release that was created, scaled, or that the crashed dyno was
+	// running
+	Release int `json:"release"`
+
+	// when it happened
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ActivityListOpts holds the optional parameters for ActivityList
+type ActivityListOpts struct {
+	// only list activity after this time
+	Since *time.Time
+	// only list activity before this time
+	Until *time.Time
+}
+
+// List what happened to an app, most recent first.
+//
+// appIdentity is the unique identifier of the App. options is the struct of
+// optional filters. lr is an optional ListRange that sets the Range options
+// for the paginated list of results.
+func (c *Client) ActivityList(appIdentity string, options *ActivityListOpts, lr *ListRange) ([]Activity, error) {
+	path := "/apps/" + appIdentity + "/activity"
+	if options != nil {
+		params := url.Values{}
+		if options.Since != nil {
+			params.Set("since", options.Since.Format(time.RFC3339))
+		}
+		if options.Until != nil {
+			params.Set("until", options.Until.Format(time.RFC3339))
+		}
+		if len(params) > 0 {
+			path += "?" + params.Encode()
+		}
+	}
+
+	req, err := c.NewRequest("GET", path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var activitiesRes []Activity
+	return activitiesRes, c.DoReq(req, &activitiesRes)
+}
@@ -0,0 +1,66 @@
+package heroku
+
+import "time"
+
+// APIToken is a long lived token for a service account. The token itself is
+// only returned when it's created.
+type APIToken struct {
+	// unique identifier of the token
+	Id string `json:"id"`
+
+	// the name of the service account
+	Name string `json:"name"`
+
+	// the token, only present when the token is created
+	Token string `json:"token,omitempty"`
+
+	// the apps that the token is restricted to
+	Apps []string `json:"apps,omitempty"`
+
+	// the actions that the token is restricted to
+	Actions []string `json:"actions,omitempty"`
+
+	// the user that created the token
+	CreatedBy string `json:"created_by"`
+
+	// when the token was created
+	CreatedAt time.Time `json:"created_at"`
+
+	// when the token was last used
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type APITokenCreateOpts struct {
+	// the name of the service account
+	Name string `json:"name"`
+	// if provided, restricts the token to these apps
+	Apps []string `json:"apps,omitempty"`
+	// if provided, restricts the token to these actions
+	Actions []string `json:"actions,omitempty"`
+}
+
+// Create a new API token.
+func (c *Client) APITokenCreate(options *APITokenCreateOpts) (*APIToken, error) {
+	var tokenRes APIToken
+	return &tokenRes, c.Post(&tokenRes, "/api-tokens", options)
+}
+
+// Revoke an API token.
+func (c *Client) APITokenDelete(tokenIdentity string) error {
+	return c.Delete("/api-tokens/" + tokenIdentity)
+}
+
+// List API tokens.
+func (c *Client) APITokenList(lr *ListRange) ([]APIToken, error) {
+	req, err := c.NewRequest("GET", "/api-tokens", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var tokensRes []APIToken
+	return tokensRes, c.DoReq(req, &tokensRes)
+}
@@ -5,6 +5,7 @@
 package heroku
 
 import (
+	"net/url"
 	"time"
 )
 
@@ -70,6 +71,68 @@ type App struct {
 
 	// maps a process name to a certificate to use for it.
 	Certs map[string]string `json:"certs,omitempty"`
+
+	// the team that owns the app
+	Team string `json:"team,omitempty"`
+
+	// the namespace that the app is in
+	Namespace string `json:"namespace,omitempty"`
+
+	// key/value pairs that describe the app
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// whether destructive operations on the app require a two factor code
+	Protected bool `json:"protected"`
+
+	// percentage of traffic sent to the previous release of the app
+	PreviousReleaseWeight int `json:"previous_release_weight"`
+
+	// settings for the load balancers of the app
+	Router AppRouter `json:"router"`
+
+	// seconds that a deploy waits for new dynos to become healthy before
+	// it's rolled back, 0 to wait indefinitely
+	DeployTimeout int `json:"deploy_timeout"`
+
+	// time zone that the schedules of scheduled processes are evaluated
+	// in, empty for UTC
+	CronTimezone string `json:"cron_timezone"`
+
+	// key that incidents about the app are routed with, empty for the
+	// default key
+	AlertRoutingKey string `json:"alert_routing_key"`
+
+	// fraction of the dynos of each process that chaos testing kills each
+	// time it runs, 0 when the app hasn't opted in
+	ChaosFraction float64 `json:"chaos_fraction"`
+}
+
+// AppRouter holds the settings for the load balancers of an app.
+type AppRouter struct {
+	// seconds that a connection can be idle before it's closed, 0 for the
+	// default
+	IdleTimeout int `json:"idle_timeout"`
+
+	// seconds that in flight requests to old dynos are given to finish
+	// when a release is deployed, or nil for the default
+	DrainTimeout *int `json:"drain_timeout"`
+
+	// whether connections can be upgraded to websockets
+	WebSockets bool `json:"websockets"`
+
+	// whether requests from the same client are sent to the same dyno
+	StickySessions bool `json:"sticky_sessions"`
+
+	// version of HTTP that requests are sent to dynos with: http1, http2 or
+	// grpc
+	ProtocolVersion string `json:"protocol_version"`
+
+	// CIDR blocks that connections are accepted from, if not empty
+	AllowedCIDRs []string `json:"allowed_cidrs"`
+
+	// username that requests must authenticate as, if basic auth is
+	// enabled
+	BasicAuthUsername string `json:"basic_auth_username"`
 }
 
 // Create a new app.
@@ -124,6 +187,24 @@ func (c *Client) AppList(lr *ListRange) ([]App, error) {
 	return appsRes, c.DoReq(req, &appsRes)
 }
 
+// List apps with labels matching a label selector (e.g. "tier=web").
+//
+// lr is an optional ListRange that sets the Range options for the paginated
+// list of results.
+func (c *Client) AppListByLabels(selector string, lr *ListRange) ([]App, error) {
+	req, err := c.NewRequest("GET", "/apps?"+url.Values{"labels": {selector}}.Encode(), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var appsRes []App
+	return appsRes, c.DoReq(req, &appsRes)
+}
+
 // Update an existing app.
 //
 // appIdentity is the unique identifier of the App. options is the struct of
@@ -138,8 +219,51 @@ func (c *Client) AppUpdate(appIdentity string, options *AppUpdateOpts, message s
 type AppUpdateOpts struct {
 	// maintenance status of app
 	Maintenance *bool `json:"maintenance,omitempty"`
+	// whether destructive operations on the app require a two factor code
+	Protected *bool `json:"protected,omitempty"`
+	// percentage of traffic to send to the previous release of the app
+	PreviousReleaseWeight *int `json:"previous_release_weight,omitempty"`
+	// settings to change for the load balancers of the app
+	Router *AppRouterUpdateOpts `json:"router,omitempty"`
+	// seconds that a deploy waits for new dynos to become healthy before
+	// it's rolled back, 0 to wait indefinitely
+	DeployTimeout *int `json:"deploy_timeout,omitempty"`
+	// time zone that the schedules of scheduled processes are evaluated
+	// in, empty for UTC
+	CronTimezone *string `json:"cron_timezone,omitempty"`
+	// key that incidents about the app are routed with, empty for the
+	// default key
+	AlertRoutingKey *string `json:"alert_routing_key,omitempty"`
+	// fraction of the dynos of each process that chaos testing kills each
+	// time it runs, 0 to opt out
+	ChaosFraction *float64 `json:"chaos_fraction,omitempty"`
+	// labels to set, or null to remove a label
+	Labels map[string]*string `json:"labels,omitempty"`
 	// unique name of app
 	Name *string `json:"name,omitempty"`
 	// DEPRECATED:
 	Cert *string `json:"cert,omitempty"`
 }
+
+// AppRouterUpdateOpts holds the router settings to change in AppUpdate.
+type AppRouterUpdateOpts struct {
+	// seconds that a connection can be idle before it's closed, 0 for the
+	// default
+	IdleTimeout *int `json:"idle_timeout,omitempty"`
+	// seconds that in flight requests to old dynos are given to finish
+	// when a release is deployed, or -1 for the default
+	DrainTimeout *int `json:"drain_timeout,omitempty"`
+	// whether connections can be upgraded to websockets
+	WebSockets *bool `json:"websockets,omitempty"`
+	// whether requests from the same client are sent to the same dyno
+	StickySessions *bool `json:"sticky_sessions,omitempty"`
+	// version of HTTP that requests are sent to dynos with: http1, http2 or
+	// grpc
+	ProtocolVersion *string `json:"protocol_version,omitempty"`
+	// CIDR blocks that connections are accepted from, or an empty list to
+	// accept connections from anywhere
+	AllowedCIDRs *[]string `json:"allowed_cidrs,omitempty"`
+	// basic auth credentials, as "username:password", or an empty string to
+	// disable basic auth
+	BasicAuth *string `json:"basic_auth,omitempty"`
+}
@@ -0,0 +1,45 @@
+package heroku
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Export an app, with its config, formation, domains, settings and current
+// release, to a portable JSON document, which is written to w. The document
+// includes the values of config vars.
+//
+// appIdentity is the unique identifier of the App.
+func (c *Client) AppExport(appIdentity string, w io.Writer) error {
+	return c.Get(w, "/apps/"+appIdentity+"/export")
+}
+
+// AppImportOpts are the options for importing an app.
+type AppImportOpts struct {
+	// the document returned by AppExport
+	Export json.RawMessage `json:"export"`
+
+	// if provided, the cluster that the app is scheduled to, when it's
+	// created
+	Cluster string `json:"cluster,omitempty"`
+}
+
+// AppImportResult is the result of importing an app.
+type AppImportResult struct {
+	// the name of the app
+	App string `json:"app"`
+
+	// a description of each change to the app
+	Changes []string `json:"changes"`
+}
+
+// Import an app that was exported with AppExport, which creates the app if it
+// doesn't exist, and then converges it to the export.
+//
+// options is the struct of the export to import. message is the commit message
+// for the changes.
+func (c *Client) AppImport(options *AppImportOpts, message string) (*AppImportResult, error) {
+	rh := RequestHeaders{CommitMessage: message}
+	var result AppImportResult
+	return &result, c.PostWithHeaders(&result, "/import", options, rh.Headers())
+}
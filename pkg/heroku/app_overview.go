@@ -0,0 +1,48 @@
+package heroku
+
+// An overview of an app, with everything that's usually looked up about an
+// app, so that it can be fetched in a single request.
+type AppOverview struct {
+	// the app
+	App App `json:"app"`
+
+	// the current release of the app, if it has been deployed
+	Release *Release `json:"release"`
+
+	// the id of the current config, which changes whenever config vars
+	// change
+	ConfigId string `json:"config_id"`
+
+	// the processes of the app, and the states of their dynos
+	Processes []AppOverviewProcess `json:"processes"`
+
+	// the domains that are routed to the app
+	Domains []Domain `json:"domains"`
+
+	// the most recent releases of the app, newest first
+	Releases []Release `json:"releases"`
+}
+
+// A process of an app, with its scale and the number of dynos in each state.
+type AppOverviewProcess struct {
+	// type of process
+	Type string `json:"type"`
+
+	// number of processes to maintain
+	Quantity int `json:"quantity"`
+
+	// dyno size
+	Size string `json:"size"`
+
+	// the number of dynos in each state (e.g. RUNNING)
+	States map[string]int `json:"states"`
+}
+
+// Info for an app, along with its current release, processes, domains and
+// recent releases.
+//
+// appIdentity is the unique identifier of the App.
+func (c *Client) AppOverviewInfo(appIdentity string) (*AppOverview, error) {
+	var overview AppOverview
+	return &overview, c.Get(&overview, "/apps/"+appIdentity+"/overview")
+}
@@ -0,0 +1,30 @@
+package heroku
+
+// AppSpecApplyOpts are the options for applying an app spec.
+type AppSpecApplyOpts struct {
+	// the app spec, as YAML or JSON
+	Spec string `json:"spec"`
+
+	// if true, the changes are returned without being made
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// AppSpecApplyResult is the result of applying an app spec.
+type AppSpecApplyResult struct {
+	// the name of the app
+	App string `json:"app"`
+
+	// a description of each change to the app
+	Changes []string `json:"changes"`
+}
+
+// Apply an app spec, which creates the app if it doesn't exist, and changes
+// whatever differs from the spec.
+//
+// options is the struct of the spec to apply. message is the commit message
+// for the changes.
+func (c *Client) AppSpecApply(options *AppSpecApplyOpts, message string) (*AppSpecApplyResult, error) {
+	rh := RequestHeaders{CommitMessage: message}
+	var result AppSpecApplyResult
+	return &result, c.PostWithHeaders(&result, "/apply", options, rh.Headers())
+}
@@ -0,0 +1,99 @@
+package heroku
+
+import "time"
+
+// A batch is a group of one-off jobs for an app that are run up to a maximum
+// number at a time.
+type Batch struct {
+	// unique identifier of the batch
+	Id string `json:"id"`
+
+	// the app that the batch runs in
+	App struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app"`
+
+	// the maximum number of jobs that can run at once
+	Parallelism int `json:"parallelism"`
+
+	// the number of jobs in each state
+	Status map[string]int `json:"status"`
+
+	// the jobs in the batch, in the order that they were submitted
+	Jobs []BatchJob `json:"jobs"`
+
+	// the user that submitted the batch
+	CreatedBy string `json:"created_by"`
+
+	// when the batch was submitted
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// A job in a batch.
+type BatchJob struct {
+	// unique identifier of the job
+	Id string `json:"id"`
+
+	// the command that the job runs
+	Command string `json:"command"`
+
+	// the state of the job: queued, running, succeeded, failed or lost
+	State string `json:"state"`
+
+	// the exit code of the job, once it has finished
+	ExitCode *int `json:"exit_code"`
+
+	// when the job started running
+	StartedAt *time.Time `json:"started_at"`
+
+	// when the job finished
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+type BatchCreateOpts struct {
+	// the commands to run, one job for each
+	Commands []string `json:"commands"`
+	// the maximum number of jobs that can run at once
+	Parallelism int `json:"parallelism"`
+	// the size to run each job with
+	Size *string `json:"size,omitempty"`
+	// commit message
+	Message string `json:"-"`
+}
+
+// Submit a batch of one-off jobs.
+//
+// appIdentity is the unique identifier of the Batch's App.
+func (c *Client) BatchCreate(appIdentity string, options *BatchCreateOpts) (*Batch, error) {
+	rh := RequestHeaders{CommitMessage: options.Message}
+	var batchRes Batch
+	return &batchRes, c.PostWithHeaders(&batchRes, "/apps/"+appIdentity+"/batches", options, rh.Headers())
+}
+
+// Info for an existing batch.
+//
+// appIdentity is the unique identifier of the Batch's App. batchIdentity is
+// the unique identifier of the Batch.
+func (c *Client) BatchInfo(appIdentity string, batchIdentity string) (*Batch, error) {
+	var batch Batch
+	return &batch, c.Get(&batch, "/apps/"+appIdentity+"/batches/"+batchIdentity)
+}
+
+// List the batches of an app, most recent first.
+//
+// appIdentity is the unique identifier of the Batch's App. lr is an optional
+// ListRange that sets the Range options for the paginated list of results.
+func (c *Client) BatchList(appIdentity string, lr *ListRange) ([]Batch, error) {
+	req, err := c.NewRequest("GET", "/apps/"+appIdentity+"/batches", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var batchesRes []Batch
+	return batchesRes, c.DoReq(req, &batchesRes)
+}
@@ -0,0 +1,67 @@
+package heroku
+
+// BulkOpts are the options for restarting, scaling, or setting config vars on,
+// every app with labels matching a selector.
+type BulkOpts struct {
+	// a label selector for the apps to apply the operation to (e.g.
+	// "tier=web")
+	Selector string `json:"selector"`
+
+	// the operation to apply, one of "restart", "scale" or "set"
+	Operation string `json:"operation"`
+
+	// the processes to scale, for "scale"
+	Updates []FormationBatchUpdateOpts `json:"updates,omitempty"`
+
+	// the config vars to change, for "set", where a null value unsets the
+	// config var
+	Vars map[string]*string `json:"vars,omitempty"`
+
+	// the id of the plan to apply, or empty to only plan the operation
+	Confirm string `json:"confirm,omitempty"`
+}
+
+// BulkPlan is the set of apps that a bulk operation applies to.
+type BulkPlan struct {
+	// identifies the operation, and the apps that it applies to, and has
+	// to be provided to apply it
+	Id string `json:"id"`
+
+	// the operation
+	Operation string `json:"operation"`
+
+	// the apps that the operation applies to
+	Apps []BulkApp `json:"apps"`
+
+	// the result for each app, once the operation was applied
+	Results []BulkResult `json:"results,omitempty"`
+}
+
+// BulkApp is an app that a bulk operation applies to.
+type BulkApp struct {
+	// unique identifier of the app
+	Id string `json:"id"`
+
+	// the name of the app
+	Name string `json:"name"`
+}
+
+// BulkResult is the result of a bulk operation on a single app.
+type BulkResult struct {
+	// the app
+	App BulkApp `json:"app"`
+
+	// the error that the operation failed with, if it failed
+	Error string `json:"error,omitempty"`
+}
+
+// Plan, or apply, an operation on every app with labels matching a selector.
+// The operation is only applied when options.Confirm is the id of the plan.
+//
+// options is the struct of the operation. message is the commit message for
+// the operation.
+func (c *Client) Bulk(options *BulkOpts, message string) (*BulkPlan, error) {
+	rh := RequestHeaders{CommitMessage: message}
+	var plan BulkPlan
+	return &plan, c.PostWithHeaders(&plan, "/bulk", options, rh.Headers())
+}
@@ -0,0 +1,73 @@
+package heroku
+
+import "net/url"
+
+// Resources are an amount of CPU, memory and GPUs.
+type Resources struct {
+	// CPU units, where 1024 units is a full core
+	CPU int `json:"cpu"`
+
+	// memory in bytes
+	Memory int64 `json:"memory"`
+
+	// number of GPUs
+	GPU int `json:"gpu"`
+}
+
+// A Machine is a host in a cluster that dynos are placed on.
+type Machine struct {
+	// the host
+	Host Host `json:"host"`
+
+	// true if the scheduler has lost contact with the host
+	Lost bool `json:"lost"`
+
+	// the availability zone that the host is in, if known
+	Zone string `json:"zone"`
+
+	// resources that the host makes available to dynos
+	Total Resources `json:"total"`
+
+	// resources reserved by dynos running on the host
+	Allocated Resources `json:"allocated"`
+
+	// number of dynos running on the host
+	Dynos int `json:"dynos"`
+
+	// if a size was given, how many more dynos of that size can be placed
+	// on the host
+	Fits *int `json:"fits,omitempty"`
+}
+
+// Capacity is the capacity of a cluster.
+type Capacity struct {
+	// name of the cluster, empty for the default cluster
+	Name string `json:"name"`
+
+	// resources of all hosts in the cluster
+	Total Resources `json:"total"`
+
+	// resources reserved by dynos in the cluster
+	Allocated Resources `json:"allocated"`
+
+	// hosts in the cluster
+	Machines []Machine `json:"machines"`
+
+	// if a size was given, how many more dynos of that size can be placed
+	// in the cluster
+	Fits *int `json:"fits,omitempty"`
+}
+
+// List the capacity of each cluster.
+//
+// size is an optional dyno size (e.g. "1X" or "512:1GB"). When provided, the
+// result includes how many more dynos of that size will fit.
+func (c *Client) CapacityList(size string) ([]Capacity, error) {
+	path := "/capacity"
+	if size != "" {
+		path += "?" + url.Values{"size": {size}}.Encode()
+	}
+
+	var capacity []Capacity
+	return capacity, c.Get(&capacity, path)
+}
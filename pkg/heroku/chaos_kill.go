@@ -0,0 +1,46 @@
+package heroku
+
+import "time"
+
+// A chaos kill is a dyno that was killed by chaos testing.
+type ChaosKill struct {
+	// unique identifier of the kill
+	Id string `json:"id"`
+
+	// the process that the dyno was an instance of
+	Process string `json:"process"`
+
+	// the name of the dyno that was killed
+	Pid string `json:"pid"`
+
+	// the release that the dyno was running
+	Release int `json:"release"`
+
+	// when the dyno was killed
+	KilledAt time.Time `json:"killed_at"`
+
+	// when the process was running as many dynos as it's scaled to again
+	RecoveredAt *time.Time `json:"recovered_at"`
+
+	// seconds that the process took to recover, once it has recovered
+	RecoveryTime *int `json:"recovery_time"`
+}
+
+// List the dynos of an app that were killed by chaos testing, most recent
+// first.
+//
+// appIdentity is the unique identifier of the App. lr is an optional
+// ListRange that sets the Range options for the paginated list of results.
+func (c *Client) ChaosKillList(appIdentity string, lr *ListRange) ([]ChaosKill, error) {
+	req, err := c.NewRequest("GET", "/apps/"+appIdentity+"/chaos-kills", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var killsRes []ChaosKill
+	return killsRes, c.DoReq(req, &killsRes)
+}
@@ -33,3 +33,16 @@ func (c *Client) ConfigVarUpdate(appIdentity string, options map[string]*string,
 	var configVarRes map[string]string
 	return configVarRes, c.PatchWithHeaders(&configVarRes, "/apps/"+appIdentity+"/config-vars", options, rh.Headers())
 }
+
+// Update config-vars for app, and mark the config-vars that are set as
+// sensitive, so that their values are redacted when they're read.
+//
+// appIdentity is the unique identifier of the ConfigVar's App. options is the
+// hash of config changes – update values or delete by seting it to nil.
+func (c *Client) ConfigVarUpdateSensitive(appIdentity string, options map[string]*string, message string) (map[string]string, error) {
+	rh := RequestHeaders{CommitMessage: message}
+	headers := rh.Headers()
+	headers.Set(SensitiveVarsHeader, "true")
+	var configVarRes map[string]string
+	return configVarRes, c.PatchWithHeaders(&configVarRes, "/apps/"+appIdentity+"/config-vars", options, headers)
+}
@@ -0,0 +1,73 @@
+package heroku
+
+import "time"
+
+// A cron run is a single invocation of a scheduled process.
+type CronRun struct {
+	// unique identifier of the invocation
+	Id string `json:"id"`
+
+	// the scheduled process that was invoked
+	Process string `json:"process"`
+
+	// what triggered the invocation: schedule or manual
+	Trigger string `json:"trigger"`
+
+	// the state of the invocation: queued, running, succeeded, failed,
+	// lost or skipped
+	State string `json:"state"`
+
+	// the instance that the invocation ran as, once it's known
+	TaskId string `json:"task_id"`
+
+	// the exit code of the process, once it has finished
+	ExitCode *int `json:"exit_code"`
+
+	// where the output of the process can be found, when it's known
+	Output string `json:"output"`
+
+	// the user that triggered the invocation, for manual invocations
+	CreatedBy string `json:"created_by"`
+
+	// when the invocation was recorded
+	CreatedAt time.Time `json:"created_at"`
+
+	// when the process started running
+	StartedAt *time.Time `json:"started_at"`
+
+	// when the process finished
+	FinishedAt *time.Time `json:"finished_at"`
+
+	// when the invocation was due, for processes that are scheduled in a
+	// time zone
+	ScheduledAt *time.Time `json:"scheduled_at"`
+}
+
+// Trigger a scheduled process right away.
+//
+// appIdentity is the unique identifier of the process's App. process is the
+// name of the scheduled process. message is the commit message.
+func (c *Client) CronTrigger(appIdentity, process, message string) (*CronRun, error) {
+	rh := RequestHeaders{CommitMessage: message}
+	var runRes CronRun
+	return &runRes, c.PostWithHeaders(&runRes, "/apps/"+appIdentity+"/crons/"+process+"/runs", nil, rh.Headers())
+}
+
+// List the invocations of a scheduled process, most recent first.
+//
+// appIdentity is the unique identifier of the process's App. process is the
+// name of the scheduled process. lr is an optional ListRange that sets the
+// Range options for the paginated list of results.
+func (c *Client) CronRunList(appIdentity, process string, lr *ListRange) ([]CronRun, error) {
+	req, err := c.NewRequest("GET", "/apps/"+appIdentity+"/crons/"+process+"/runs", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var runsRes []CronRun
+	return runsRes, c.DoReq(req, &runsRes)
+}
@@ -5,6 +5,8 @@
 package heroku
 
 import (
+	"io"
+	"net/url"
 	"time"
 )
 
@@ -16,12 +18,20 @@ type Host struct {
 
 // Dynos encapsulate running processes of an app on Heroku.
 type Dyno struct {
+	// app the dyno belongs to
+	App struct {
+		Name string `json:"name"`
+	} `json:"app"`
+
 	// a URL to stream output from for attached processes or null for non-attached processes
 	AttachURL *string `json:"attach_url"`
 
 	// command used to start this process
 	Command string `json:"command"`
 
+	// docker image the dyno is running
+	Image string `json:"image,omitempty"`
+
 	// when dyno was created
 	CreatedAt time.Time `json:"created_at"`
 
@@ -63,6 +73,10 @@ func (c *Client) DynoCreate(appIdentity string, command string, options *DynoCre
 		Attach  *bool              `json:"attach,omitempty"`
 		Env     *map[string]string `json:"env,omitempty"`
 		Size    *string            `json:"size,omitempty"`
+		Timeout *int               `json:"timeout,omitempty"`
+		CPU     *string            `json:"cpu,omitempty"`
+		Memory  *string            `json:"memory,omitempty"`
+		Capture *bool              `json:"capture,omitempty"`
 	}{
 		Command: command,
 	}
@@ -70,6 +84,10 @@ func (c *Client) DynoCreate(appIdentity string, command string, options *DynoCre
 		params.Attach = options.Attach
 		params.Env = options.Env
 		params.Size = options.Size
+		params.Timeout = options.Timeout
+		params.CPU = options.CPU
+		params.Memory = options.Memory
+		params.Capture = options.Capture
 	}
 
 	rh := RequestHeaders{CommitMessage: options.Message}
@@ -85,10 +103,28 @@ type DynoCreateOpts struct {
 	Env *map[string]string `json:"env,omitempty"`
 	// dyno size (default: "1X")
 	Size *string `json:"size,omitempty"`
+	// maximum number of seconds that the dyno can run for
+	Timeout *int `json:"timeout,omitempty"`
+	// cpu shares for the dyno, overriding its size (e.g. "1024")
+	CPU *string `json:"cpu,omitempty"`
+	// memory for the dyno, overriding its size (e.g. "16GB")
+	Memory *string `json:"memory,omitempty"`
+	// whether to capture the output of a detached dyno, so that it can be
+	// retrieved later with JobOutput
+	Capture *bool `json:"capture,omitempty"`
 	// commit message
 	Message string
 }
 
+// Output of a detached dyno that was created with Capture.
+//
+// appIdentity is the unique identifier of the Dyno's App. jobIdentity is the
+// id of the Dyno that was returned when it was created. The captured output is
+// copied to w.
+func (c *Client) JobOutput(appIdentity, jobIdentity string, w io.Writer) error {
+	return c.Get(w, "/apps/"+appIdentity+"/jobs/"+jobIdentity+"/output")
+}
+
 // Restart dyno.
 //
 // appIdentity is the unique identifier of the Dyno's App. dynoIdentity is the
@@ -120,7 +156,65 @@ func (c *Client) DynoInfo(appIdentity string, dynoIdentity string) (*Dyno, error
 // appIdentity is the unique identifier of the Dyno's App. lr is an optional
 // ListRange that sets the Range options for the paginated list of results.
 func (c *Client) DynoList(appIdentity string, lr *ListRange) ([]Dyno, error) {
-	req, err := c.NewRequest("GET", "/apps/"+appIdentity+"/dynos", nil, nil)
+	return c.DynoListWithOpts(appIdentity, nil, lr)
+}
+
+// DynoListOpts holds the optional parameters for DynoListWithOpts and
+// DynoListAll
+type DynoListOpts struct {
+	// only list dynos of this process type
+	Type string
+	// only list dynos running this release version
+	Version string
+	// only list dynos in this state
+	State string
+	// only list dynos running on this host
+	Host string
+	// only list dynos running this docker image
+	Image string
+	// only list dynos of apps with labels matching this selector, when
+	// listing dynos for all apps
+	Labels string
+}
+
+// List existing dynos, filtered by the given options.
+//
+// appIdentity is the unique identifier of the Dyno's App. options is the struct
+// of optional filters. lr is an optional ListRange that sets the Range options
+// for the paginated list of results.
+func (c *Client) DynoListWithOpts(appIdentity string, options *DynoListOpts, lr *ListRange) ([]Dyno, error) {
+	return c.dynoList("/apps/"+appIdentity+"/dynos", options, lr)
+}
+
+// List existing dynos for all apps, filtered by the given options.
+//
+// options is the struct of optional filters. lr is an optional ListRange that
+// sets the Range options for the paginated list of results.
+func (c *Client) DynoListAll(options *DynoListOpts, lr *ListRange) ([]Dyno, error) {
+	return c.dynoList("/dynos", options, lr)
+}
+
+func (c *Client) dynoList(path string, options *DynoListOpts, lr *ListRange) ([]Dyno, error) {
+	if options != nil {
+		params := url.Values{}
+		for k, v := range map[string]string{
+			"type":    options.Type,
+			"version": options.Version,
+			"state":   options.State,
+			"host":    options.Host,
+			"image":   options.Image,
+			"labels":  options.Labels,
+		} {
+			if v != "" {
+				params.Set(k, v)
+			}
+		}
+		if len(params) > 0 {
+			path += "?" + params.Encode()
+		}
+	}
+
+	req, err := c.NewRequest("GET", path, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -132,3 +226,38 @@ func (c *Client) DynoList(appIdentity string, lr *ListRange) ([]Dyno, error) {
 	var dynosRes []Dyno
 	return dynosRes, c.DoReq(req, &dynosRes)
 }
+
+// A change to a dyno that's being watched.
+type DynoChange struct {
+	// what happened to the dyno (added, updated or removed)
+	Type string `json:"type"`
+
+	// the dyno, or the dyno as it was last seen if it was removed
+	Dyno Dyno `json:"dyno"`
+}
+
+// Watch the dynos of an app. The response is a stream of JSON arrays of
+// DynoChange, which is copied into w until the connection is closed. The first
+// array has every dyno as an added dyno, and later arrays have what changed
+// since the previous one.
+//
+// appIdentity is the unique identifier of the Dyno's App. options is the struct
+// of optional filters.
+func (c *Client) DynoWatch(appIdentity string, options *DynoListOpts, w io.Writer) error {
+	path := "/apps/" + appIdentity + "/dynos"
+	params := url.Values{"watch": []string{"true"}}
+	if options != nil {
+		for k, v := range map[string]string{
+			"type":    options.Type,
+			"version": options.Version,
+			"state":   options.State,
+			"host":    options.Host,
+		} {
+			if v != "" {
+				params.Set(k, v)
+			}
+		}
+	}
+
+	return c.APIReq(w, "GET", path+"?"+params.Encode(), nil, nil)
+}
@@ -0,0 +1,30 @@
+package heroku
+
+import (
+	"io"
+	"net/url"
+)
+
+// Copy files to a running dyno.
+//
+// appIdentity is the unique identifier of the Dyno's App. dynoIdentity is the
+// unique identifier of the Dyno. archive is a tar archive that will be
+// extracted into the directory at path inside of the dyno.
+func (c *Client) DynoCopyTo(appIdentity string, dynoIdentity string, path string, archive io.Reader, message string) error {
+	rh := RequestHeaders{CommitMessage: message}
+	return c.PutWithHeaders(nil, dynoFilesPath(appIdentity, dynoIdentity, path), archive, rh.Headers())
+}
+
+// Copy files from a running dyno.
+//
+// appIdentity is the unique identifier of the Dyno's App. dynoIdentity is the
+// unique identifier of the Dyno. A tar archive of the file or directory at path
+// inside of the dyno is written to w.
+func (c *Client) DynoCopyFrom(appIdentity string, dynoIdentity string, path string, w io.Writer, message string) error {
+	rh := RequestHeaders{CommitMessage: message}
+	return c.GetWithHeaders(w, dynoFilesPath(appIdentity, dynoIdentity, path), rh.Headers())
+}
+
+func dynoFilesPath(appIdentity, dynoIdentity, path string) string {
+	return "/apps/" + appIdentity + "/dynos/" + dynoIdentity + "/files?" + url.Values{"path": []string{path}}.Encode()
+}
@@ -16,6 +16,9 @@ type Formation struct {
 	// command to use to launch this process
 	Command string `json:"command"`
 
+	// arguments appended to the command of this process
+	Args []string `json:"args,omitempty"`
+
 	// when process type was created
 	CreatedAt time.Time `json:"created_at"`
 
@@ -87,8 +90,16 @@ type FormationBatchUpdateOpts struct {
 	// number of processes to maintain
 	Quantity *int `json:"quantity,omitempty"`
 
+	// change to the number of processes, relative to the current number
+	// (e.g. "+5", "-2" or "+50%")
+	Change *string `json:"change,omitempty"`
+
 	// dyno size (default: "1X")
 	Size *string `json:"size,omitempty"`
+
+	// arguments appended to the command of the process, replacing the
+	// current arguments. An empty list removes them.
+	Args *[]string `json:"args,omitempty"`
 }
 
 // Update process type
@@ -0,0 +1,72 @@
+package heroku
+
+import "time"
+
+// FreezeWindow represents a period of time where new releases are rejected.
+type FreezeWindow struct {
+	// unique identifier of the freeze window
+	Id string `json:"id"`
+
+	// when the freeze starts
+	StartsAt time.Time `json:"starts_at"`
+
+	// when the freeze ends
+	EndsAt time.Time `json:"ends_at"`
+
+	// the reason for the freeze
+	Reason string `json:"reason"`
+
+	// the team that the freeze is scoped to
+	Team string `json:"team,omitempty"`
+
+	// the app that the freeze is scoped to
+	App *struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app,omitempty"`
+
+	// the user that created the freeze
+	CreatedBy string `json:"created_by"`
+
+	// when the freeze window was created
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FreezeWindowCreateOpts struct {
+	// when the freeze starts
+	StartsAt time.Time `json:"starts_at"`
+	// when the freeze ends
+	EndsAt time.Time `json:"ends_at"`
+	// the reason for the freeze
+	Reason *string `json:"reason,omitempty"`
+	// if provided, scopes the freeze to this team
+	Team *string `json:"team,omitempty"`
+	// if provided, scopes the freeze to this app
+	App *string `json:"app,omitempty"`
+}
+
+// Declare a new freeze window.
+func (c *Client) FreezeWindowCreate(options *FreezeWindowCreateOpts) (*FreezeWindow, error) {
+	var windowRes FreezeWindow
+	return &windowRes, c.Post(&windowRes, "/freeze-windows", options)
+}
+
+// Remove a freeze window.
+func (c *Client) FreezeWindowDelete(windowIdentity string) error {
+	return c.Delete("/freeze-windows/" + windowIdentity)
+}
+
+// List current and upcoming freeze windows.
+func (c *Client) FreezeWindowList(lr *ListRange) ([]FreezeWindow, error) {
+	req, err := c.NewRequest("GET", "/freeze-windows", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var windowsRes []FreezeWindow
+	return windowsRes, c.DoReq(req, &windowsRes)
+}
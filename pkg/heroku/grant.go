@@ -0,0 +1,113 @@
+package heroku
+
+import "time"
+
+// Grant gives a role to a user or a team, on a single app, on the apps in a
+// namespace, or on all apps.
+type Grant struct {
+	// unique identifier of the grant
+	Id string `json:"id"`
+
+	// the user that the role is granted to
+	Username string `json:"username,omitempty"`
+
+	// the team that the role is granted to
+	Team string `json:"team,omitempty"`
+
+	// viewer, deployer or admin
+	Role string `json:"role"`
+
+	// the app that the role is granted on, or nil for all apps
+	App *struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app,omitempty"`
+
+	// the namespace that the role is granted on
+	Namespace string `json:"namespace,omitempty"`
+
+	// when the grant was created
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GrantCreateOpts struct {
+	// viewer, deployer or admin
+	Role string `json:"role"`
+	// the user to grant the role to
+	Username *string `json:"username,omitempty"`
+	// the team to grant the role to
+	Team *string `json:"team,omitempty"`
+	// if provided, the app to grant the role on
+	App *string `json:"app,omitempty"`
+	// if provided, the namespace to grant the role on
+	Namespace *string `json:"namespace,omitempty"`
+}
+
+// Grant a role to a user or team.
+func (c *Client) GrantCreate(options *GrantCreateOpts) (*Grant, error) {
+	var grantRes Grant
+	return &grantRes, c.Post(&grantRes, "/grants", options)
+}
+
+// Revoke a grant.
+func (c *Client) GrantDelete(grantIdentity string) error {
+	return c.Delete("/grants/" + grantIdentity)
+}
+
+// List grants.
+func (c *Client) GrantList(lr *ListRange) ([]Grant, error) {
+	req, err := c.NewRequest("GET", "/grants", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var grantsRes []Grant
+	return grantsRes, c.DoReq(req, &grantsRes)
+}
+
+// TeamMember represents a user's membership in a team.
+type TeamMember struct {
+	// the name of the team
+	Team string `json:"team"`
+
+	// the user that is a member of the team
+	Username string `json:"username"`
+
+	// when the user was added to the team
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TeamMemberCreateOpts struct {
+	// the user to add to the team
+	Username string `json:"username"`
+}
+
+// Add a user to a team.
+func (c *Client) TeamMemberCreate(teamIdentity string, options *TeamMemberCreateOpts) (*TeamMember, error) {
+	var memberRes TeamMember
+	return &memberRes, c.Post(&memberRes, "/teams/"+teamIdentity+"/members", options)
+}
+
+// Remove a user from a team.
+func (c *Client) TeamMemberDelete(teamIdentity, username string) error {
+	return c.Delete("/teams/" + teamIdentity + "/members/" + username)
+}
+
+// List the members of a team.
+func (c *Client) TeamMemberList(teamIdentity string, lr *ListRange) ([]TeamMember, error) {
+	req, err := c.NewRequest("GET", "/teams/"+teamIdentity+"/members", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var membersRes []TeamMember
+	return membersRes, c.DoReq(req, &membersRes)
+}
@@ -22,10 +22,14 @@ import (
 )
 
 const (
-	Version             = "0.10.2"
-	DefaultAPIURL       = "https://api.heroku.com"
-	DefaultUserAgent    = "heroku-go/" + Version + " (" + runtime.GOOS + "; " + runtime.GOARCH + ")"
-	CommitMessageHeader = "Commit-Message"
+	Version              = "0.10.2"
+	DefaultAPIURL        = "https://api.heroku.com"
+	DefaultUserAgent     = "heroku-go/" + Version + " (" + runtime.GOOS + "; " + runtime.GOARCH + ")"
+	CommitMessageHeader  = "Commit-Message"
+	FreezeOverrideHeader = "Freeze-Override"
+	TwoFactorCodeHeader  = "Heroku-Two-Factor-Code"
+	IdempotencyKeyHeader = "Idempotency-Key"
+	SensitiveVarsHeader  = "Sensitive-Vars"
 )
 
 // A Client is a Heroku API client. Its zero value is a usable client that uses
@@ -242,6 +246,10 @@ type Error struct {
 	error
 	Id  string
 	URL string
+
+	// The id of the request that failed, which the server includes in
+	// its logs and error reports.
+	RequestID string
 }
 
 type errorResp struct {
@@ -257,7 +265,7 @@ func CheckResp(res *http.Response) error {
 		if err != nil {
 			return errors.New("Unexpected error: " + res.Status)
 		}
-		ret := Error{error: errors.New(e.Message), Id: e.Id, URL: e.URL}
+		ret := Error{error: errors.New(e.Message), Id: e.Id, URL: e.URL, RequestID: res.Header.Get("Request-Id")}
 		if e.Id == "message_required" {
 			panic(ret)
 		}
@@ -299,7 +307,10 @@ func (lr *ListRange) SetHeader(req *http.Request) {
 }
 
 type RequestHeaders struct {
-	CommitMessage string
+	CommitMessage  string
+	FreezeOverride string
+	TwoFactorCode  string
+	IdempotencyKey string
 }
 
 func (r *RequestHeaders) Headers() http.Header {
@@ -307,5 +318,14 @@ func (r *RequestHeaders) Headers() http.Header {
 	if r.CommitMessage != "" {
 		headers.Set(CommitMessageHeader, r.CommitMessage)
 	}
+	if r.FreezeOverride != "" {
+		headers.Set(FreezeOverrideHeader, r.FreezeOverride)
+	}
+	if r.TwoFactorCode != "" {
+		headers.Set(TwoFactorCodeHeader, r.TwoFactorCode)
+	}
+	if r.IdempotencyKey != "" {
+		headers.Set(IdempotencyKeyHeader, r.IdempotencyKey)
+	}
 	return headers
 }
@@ -49,6 +49,22 @@ func TestRequestId(t *testing.T) {
 	}
 }
 
+func TestCheckResp_RequestId(t *testing.T) {
+	res := &http.Response{
+		StatusCode: http.StatusInternalServerError,
+		Header:     http.Header{"Request-Id": []string{"1234"}},
+		Body:       ioutil.NopCloser(bytes.NewBufferString(`{"message": "boom"}`)),
+	}
+
+	err, ok := CheckResp(res).(Error)
+	if !ok {
+		t.Fatalf("expected an Error, got %#v", err)
+	}
+	if err.RequestID != "1234" {
+		t.Errorf("RequestID expected %q, got %q", "1234", err.RequestID)
+	}
+}
+
 func TestUserAgent(t *testing.T) {
 	c := &Client{}
 	req, err := c.NewRequest("GET", "/", nil, nil)
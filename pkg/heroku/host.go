@@ -0,0 +1,27 @@
+package heroku
+
+// Cordon a host, so that no new dynos are placed on it.
+//
+// hostIdentity is the unique identifier of the Host (e.g. the EC2 instance id).
+func (c *Client) HostCordon(hostIdentity string, message string) error {
+	rh := RequestHeaders{CommitMessage: message}
+	return c.PostWithHeaders(nil, "/hosts/"+hostIdentity+"/cordon", nil, rh.Headers())
+}
+
+// Uncordon a cordoned or drained host, so that dynos can be placed on it again.
+//
+// hostIdentity is the unique identifier of the Host.
+func (c *Client) HostUncordon(hostIdentity string, message string) error {
+	rh := RequestHeaders{CommitMessage: message}
+	return c.DeleteWithHeaders("/hosts/"+hostIdentity+"/cordon", rh.Headers())
+}
+
+// Drain a host, moving the dynos running on it to other hosts. Returns the
+// dynos that were running on the host when it started draining.
+//
+// hostIdentity is the unique identifier of the Host.
+func (c *Client) HostDrain(hostIdentity string, message string) ([]Dyno, error) {
+	var dynos []Dyno
+	rh := RequestHeaders{CommitMessage: message}
+	return dynos, c.PostWithHeaders(&dynos, "/hosts/"+hostIdentity+"/drain", nil, rh.Headers())
+}
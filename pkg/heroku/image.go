@@ -0,0 +1,75 @@
+package heroku
+
+import (
+	"io"
+	"net/url"
+)
+
+// ImageUsage is an app whose current release runs an image.
+type ImageUsage struct {
+	// the app
+	App struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app"`
+
+	// the current release of the app
+	Release struct {
+		Id      string `json:"id"`
+		Version int    `json:"version"`
+	} `json:"release"`
+
+	// the image that the release runs
+	Image string `json:"image"`
+
+	// the digests of the layers of the image, base layer first, if they're
+	// known
+	Layers []string `json:"layers"`
+}
+
+// List the apps whose current release runs an image, or an image with a layer.
+//
+// img is an image (e.g. "remind101/acme-inc"), which matches every tag and
+// digest of the repository unless one is given. layer is the digest of a
+// layer. Either can be empty, but not both.
+func (c *Client) ImageUsageList(img, layer string) ([]ImageUsage, error) {
+	v := url.Values{}
+	if img != "" {
+		v.Set("image", img)
+	}
+	if layer != "" {
+		v.Set("layer", layer)
+	}
+
+	var usageRes []ImageUsage
+	return usageRes, c.Get(&usageRes, "/images/usage?"+v.Encode())
+}
+
+// ImageRedeployOpts are the options for redeploying the apps that run an image.
+type ImageRedeployOpts struct {
+	// the image whose apps are redeployed
+	Image string `json:"image,omitempty"`
+
+	// the digest of a layer whose apps are redeployed
+	Layer string `json:"layer,omitempty"`
+
+	// if provided, each app is deployed with this tag of its repository,
+	// instead of its current image
+	Tag string `json:"tag,omitempty"`
+
+	// the number of apps that are deployed at once
+	Parallelism int `json:"parallelism,omitempty"`
+
+	// seconds to wait between each wave of deploys
+	Interval int `json:"interval,omitempty"`
+}
+
+// Redeploy the apps that run an image, in waves. The progress is written to w
+// as a stream of jsonmessages.
+//
+// options is the struct of the image and waves. message is the commit message
+// for the deploys.
+func (c *Client) ImageRedeploy(options *ImageRedeployOpts, message string, w io.Writer) error {
+	rh := RequestHeaders{CommitMessage: message}
+	return c.PostWithHeaders(w, "/images/redeploy", options, rh.Headers())
+}
@@ -0,0 +1,70 @@
+package heroku
+
+import "time"
+
+// An ingress rule allows the dynos of another app to connect to a port of an
+// app.
+type IngressRule struct {
+	// unique identifier of the ingress rule
+	Id string `json:"id"`
+
+	// the app that connections are allowed to
+	App struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app"`
+
+	// the app that's allowed to connect
+	SourceApp struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"source_app"`
+
+	// the port that the source app can connect to
+	Port int `json:"port"`
+
+	// when the ingress rule was created
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type IngressRuleCreateOpts struct {
+	// name of the app that's allowed to connect
+	SourceApp string `json:"source_app"`
+	// the port that the source app can connect to
+	Port int `json:"port"`
+}
+
+// Allow another app to connect to a port of an app.
+//
+// appIdentity is the unique identifier of the IngressRule's App.
+func (c *Client) IngressRuleCreate(appIdentity string, options *IngressRuleCreateOpts) (*IngressRule, error) {
+	var ruleRes IngressRule
+	return &ruleRes, c.Post(&ruleRes, "/apps/"+appIdentity+"/ingress-rules", options)
+}
+
+// Remove an ingress rule.
+//
+// appIdentity is the unique identifier of the IngressRule's App.
+// ruleIdentity is the unique identifier of the IngressRule.
+func (c *Client) IngressRuleDelete(appIdentity string, ruleIdentity string) error {
+	return c.Delete("/apps/" + appIdentity + "/ingress-rules/" + ruleIdentity)
+}
+
+// List the ingress rules of an app.
+//
+// appIdentity is the unique identifier of the IngressRule's App. lr is an
+// optional ListRange that sets the Range options for the paginated list of
+// results.
+func (c *Client) IngressRuleList(appIdentity string, lr *ListRange) ([]IngressRule, error) {
+	req, err := c.NewRequest("GET", "/apps/"+appIdentity+"/ingress-rules", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var rulesRes []IngressRule
+	return rulesRes, c.DoReq(req, &rulesRes)
+}
@@ -0,0 +1,66 @@
+package heroku
+
+import "time"
+
+// Namespace gives a team ownership of a prefix of app names.
+type Namespace struct {
+	// unique identifier of the namespace
+	Id string `json:"id"`
+
+	// the name of the namespace, which apps in it are prefixed with
+	Name string `json:"name"`
+
+	// the team that owns the namespace
+	Team string `json:"team"`
+
+	// when the namespace was claimed
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type NamespaceCreateOpts struct {
+	// the name of the namespace
+	Name string `json:"name"`
+	// the team that owns the namespace
+	Team string `json:"team"`
+}
+
+// Claim a namespace for a team.
+func (c *Client) NamespaceCreate(options *NamespaceCreateOpts) (*Namespace, error) {
+	var namespaceRes Namespace
+	return &namespaceRes, c.Post(&namespaceRes, "/namespaces", options)
+}
+
+// Release a namespace.
+func (c *Client) NamespaceDelete(namespaceIdentity string) error {
+	return c.Delete("/namespaces/" + namespaceIdentity)
+}
+
+// List namespaces.
+func (c *Client) NamespaceList(lr *ListRange) ([]Namespace, error) {
+	req, err := c.NewRequest("GET", "/namespaces", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var namespacesRes []Namespace
+	return namespacesRes, c.DoReq(req, &namespacesRes)
+}
+
+// List the apps in a namespace.
+func (c *Client) NamespaceAppList(namespaceIdentity string, lr *ListRange) ([]App, error) {
+	req, err := c.NewRequest("GET", "/namespaces/"+namespaceIdentity+"/apps", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var appsRes []App
+	return appsRes, c.DoReq(req, &appsRes)
+}
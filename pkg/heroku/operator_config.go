@@ -0,0 +1,20 @@
+package heroku
+
+// OperatorConfig summarizes the operator configuration that's in effect.
+type OperatorConfig struct {
+	// names of the process sizes added by the operator configuration
+	Sizes []string `json:"sizes"`
+
+	// number of quotas in the operator configuration
+	Quotas int `json:"quotas"`
+
+	// number of freeze windows in the operator configuration
+	FreezeWindows int `json:"freeze_windows"`
+}
+
+// Reload the operator configuration, without restarting Empire. Requires
+// admin access.
+func (c *Client) OperatorConfigReload() (*OperatorConfig, error) {
+	var config OperatorConfig
+	return &config, c.Post(&config, "/operator-config/reload", nil)
+}
@@ -0,0 +1,82 @@
+package heroku
+
+import "time"
+
+// Quota limits the resources that an app, all of the apps owned by a team, or
+// all of the apps in a namespace, can use.
+type Quota struct {
+	// unique identifier of the quota
+	Id string `json:"id"`
+
+	// the team that the quota is scoped to
+	Team string `json:"team,omitempty"`
+
+	// the namespace that the quota is scoped to
+	Namespace string `json:"namespace,omitempty"`
+
+	// the app that the quota is scoped to
+	App *struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app,omitempty"`
+
+	// maximum number of dynos, 0 if unlimited
+	MaxInstances int `json:"max_instances"`
+
+	// maximum memory reserved by all dynos in bytes, 0 if unlimited
+	MaxMemory int64 `json:"max_memory"`
+
+	// maximum number of one-off dynos running at the same time, 0 if
+	// unlimited
+	MaxRuns int `json:"max_runs"`
+
+	// maximum memory reserved by a single one-off dyno in bytes, 0 if
+	// unlimited
+	MaxRunMemory int64 `json:"max_run_memory"`
+
+	// when the quota was created
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type QuotaCreateOpts struct {
+	// if provided, scopes the quota to this team
+	Team *string `json:"team,omitempty"`
+	// if provided, scopes the quota to this namespace
+	Namespace *string `json:"namespace,omitempty"`
+	// if provided, scopes the quota to this app
+	App *string `json:"app,omitempty"`
+	// maximum number of dynos
+	MaxInstances *int `json:"max_instances,omitempty"`
+	// maximum memory reserved by all dynos (e.g. "16GB")
+	MaxMemory *string `json:"max_memory,omitempty"`
+	// maximum number of one-off dynos running at the same time
+	MaxRuns *int `json:"max_runs,omitempty"`
+	// maximum memory reserved by a single one-off dyno (e.g. "8GB")
+	MaxRunMemory *string `json:"max_run_memory,omitempty"`
+}
+
+// Set a quota for a team, a namespace or an app.
+func (c *Client) QuotaCreate(options *QuotaCreateOpts) (*Quota, error) {
+	var quotaRes Quota
+	return &quotaRes, c.Post(&quotaRes, "/quotas", options)
+}
+
+// Remove a quota.
+func (c *Client) QuotaDelete(quotaIdentity string) error {
+	return c.Delete("/quotas/" + quotaIdentity)
+}
+
+// List quotas.
+func (c *Client) QuotaList(lr *ListRange) ([]Quota, error) {
+	req, err := c.NewRequest("GET", "/quotas", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var quotasRes []Quota
+	return quotasRes, c.DoReq(req, &quotasRes)
+}
@@ -0,0 +1,75 @@
+package heroku
+
+import "time"
+
+// RegistryCredential represents credentials for a private Docker registry.
+// The password is never returned by the API.
+type RegistryCredential struct {
+	// unique identifier of the credential
+	Id string `json:"id"`
+
+	// the registry that these credentials are for
+	Registry string `json:"registry"`
+
+	// the username used to authenticate with the registry
+	Username string `json:"username,omitempty"`
+
+	// reference to the credentials in a scheduler specific secret store
+	CredentialsParameter string `json:"credentials_parameter,omitempty"`
+
+	// global, team or app
+	Scope string `json:"scope"`
+
+	// the team that the credentials are scoped to
+	Team string `json:"team,omitempty"`
+
+	// the app that the credentials are scoped to
+	App *struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app,omitempty"`
+
+	// when the credential was created
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RegistryCredentialCreateOpts struct {
+	// the registry that these credentials are for
+	Registry string `json:"registry"`
+	// the username used to authenticate with the registry
+	Username *string `json:"username,omitempty"`
+	// the password used to authenticate with the registry
+	Password *string `json:"password,omitempty"`
+	// reference to the credentials in a scheduler specific secret store
+	CredentialsParameter *string `json:"credentials_parameter,omitempty"`
+	// if provided, scopes the credentials to this team
+	Team *string `json:"team,omitempty"`
+	// if provided, scopes the credentials to this app
+	App *string `json:"app,omitempty"`
+}
+
+// Add credentials for a private registry.
+func (c *Client) RegistryCredentialCreate(options *RegistryCredentialCreateOpts) (*RegistryCredential, error) {
+	var credRes RegistryCredential
+	return &credRes, c.Post(&credRes, "/registry-credentials", options)
+}
+
+// Remove credentials for a private registry.
+func (c *Client) RegistryCredentialDelete(credIdentity string) error {
+	return c.Delete("/registry-credentials/" + credIdentity)
+}
+
+// List credentials for private registries.
+func (c *Client) RegistryCredentialList(lr *ListRange) ([]RegistryCredential, error) {
+	req, err := c.NewRequest("GET", "/registry-credentials", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var credsRes []RegistryCredential
+	return credsRes, c.DoReq(req, &credsRes)
+}
@@ -5,6 +5,7 @@
 package heroku
 
 import (
+	"io"
 	"time"
 )
 
@@ -32,10 +33,69 @@ type Release struct {
 	User struct {
 		Id    string `json:"id"`
 		Email string `json:"email"`
+		Name  string `json:"name"`
 	} `json:"user"`
 
 	// unique version assigned to the release
 	Version int `json:"version"`
+
+	// what created the release (deploy, rollback or config)
+	Source string `json:"source"`
+
+	// message provided by the user that created the release
+	Message string `json:"message"`
+
+	// what changed since the previous release
+	Changes *ReleaseChanges `json:"changes,omitempty"`
+
+	// where the image of the release was built from, if it's known
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// Where the image of a release was built from.
+type Provenance struct {
+	// source repository (e.g. "github.com/remind101/acme-inc")
+	Repo string `json:"repo,omitempty"`
+
+	// git SHA of the commit that the image was built from
+	Commit string `json:"commit,omitempty"`
+
+	// link to the CI build that built the image
+	BuildURL string `json:"build_url,omitempty"`
+
+	// what built the image, like the CI system, or a user
+	Builder string `json:"builder,omitempty"`
+}
+
+// What changed between a release and the release before it.
+type ReleaseChanges struct {
+	// image of the previous release and of this release, if it changed
+	Image *struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	} `json:"image,omitempty"`
+
+	// names of config vars that were added, changed or removed
+	VarsAdded   []string `json:"vars_added,omitempty"`
+	VarsChanged []string `json:"vars_changed,omitempty"`
+	VarsRemoved []string `json:"vars_removed,omitempty"`
+
+	// processes whose scale changed
+	Formation []ReleaseProcessChange `json:"formation,omitempty"`
+}
+
+// A change to the scale of a process. Previous is nil if the process was
+// added, and Current is nil if it was removed.
+type ReleaseProcessChange struct {
+	Type     string               `json:"type"`
+	Previous *ReleaseProcessScale `json:"previous"`
+	Current  *ReleaseProcessScale `json:"current"`
+}
+
+// The quantity and size of a process.
+type ReleaseProcessScale struct {
+	Quantity int    `json:"quantity"`
+	Size     string `json:"size"`
 }
 
 // Info for existing release.
@@ -47,6 +107,15 @@ func (c *Client) ReleaseInfo(appIdentity string, releaseIdentity string) (*Relea
 	return &release, c.Get(&release, "/apps/"+appIdentity+"/releases/"+releaseIdentity)
 }
 
+// Write the output of the deploy that created a release to w, in jsonmessage
+// format.
+//
+// appIdentity is the unique identifier of the Release's App. releaseIdentity is
+// the unique identifier of the Release.
+func (c *Client) ReleaseDeployLog(appIdentity string, releaseIdentity string, w io.Writer) error {
+	return c.Get(w, "/apps/"+appIdentity+"/releases/"+releaseIdentity+"/deploy-log")
+}
+
 // List existing releases.
 //
 // appIdentity is the unique identifier of the Release's App. lr is an optional
@@ -0,0 +1,71 @@
+package heroku
+
+import "time"
+
+// A routing rule routes requests for a host and/or path to a process of an app.
+type RoutingRule struct {
+	// unique identifier of the routing rule
+	Id string `json:"id"`
+
+	// the app that the rule belongs to
+	App struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app"`
+
+	// the host that requests must be for, if any
+	Host string `json:"host"`
+
+	// the path that requests must be for, if any
+	Path string `json:"path"`
+
+	// the process that matching requests are routed to
+	Process string `json:"process"`
+
+	// when the routing rule was created
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RoutingRuleCreateOpts struct {
+	// the host that requests must be for
+	Host string `json:"host,omitempty"`
+	// the path that requests must be for
+	Path string `json:"path,omitempty"`
+	// the process that matching requests are routed to
+	Process string `json:"process"`
+}
+
+// Route requests for a host and/or path to a process of an app.
+//
+// appIdentity is the unique identifier of the RoutingRule's App.
+func (c *Client) RoutingRuleCreate(appIdentity string, options *RoutingRuleCreateOpts) (*RoutingRule, error) {
+	var ruleRes RoutingRule
+	return &ruleRes, c.Post(&ruleRes, "/apps/"+appIdentity+"/routing-rules", options)
+}
+
+// Remove a routing rule.
+//
+// appIdentity is the unique identifier of the RoutingRule's App.
+// ruleIdentity is the unique identifier of the RoutingRule.
+func (c *Client) RoutingRuleDelete(appIdentity string, ruleIdentity string) error {
+	return c.Delete("/apps/" + appIdentity + "/routing-rules/" + ruleIdentity)
+}
+
+// List the routing rules of an app, in the order that they're evaluated.
+//
+// appIdentity is the unique identifier of the RoutingRule's App. lr is an
+// optional ListRange that sets the Range options for the paginated list of
+// results.
+func (c *Client) RoutingRuleList(appIdentity string, lr *ListRange) ([]RoutingRule, error) {
+	req, err := c.NewRequest("GET", "/apps/"+appIdentity+"/routing-rules", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var rulesRes []RoutingRule
+	return rulesRes, c.DoReq(req, &rulesRes)
+}
@@ -0,0 +1,85 @@
+package heroku
+
+import (
+	"net/url"
+	"time"
+)
+
+// A transition in the lifecycle of a dyno of a long running process.
+type Transition struct {
+	// unique identifier of the transition
+	Id string `json:"id"`
+
+	// the process that the dyno is an instance of
+	Process string `json:"process"`
+
+	// the name of the dyno
+	Pid string `json:"pid"`
+
+	// the release that the dyno is running
+	Release int `json:"release"`
+
+	// the host that the dyno was running on, if it was known
+	Host string `json:"host"`
+
+	// the transition: scheduled, started, health_passed, crashed or
+	// unscheduled
+	State string `json:"state"`
+
+	// why the dyno was unscheduled, if it was
+	Reason string `json:"reason"`
+
+	// when the transition was seen
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// TransitionListOpts holds the optional parameters for TransitionList
+type TransitionListOpts struct {
+	// only list transitions of dynos of this process type
+	Process string
+	// only list transitions of the dyno with this name or id
+	Pid string
+	// only list transitions after this time
+	Since *time.Time
+	// only list transitions before this time
+	Until *time.Time
+}
+
+// List the transitions of the dynos of an app, most recent first.
+//
+// appIdentity is the unique identifier of the App. options is the struct of
+// optional filters. lr is an optional ListRange that sets the Range options
+// for the paginated list of results.
+func (c *Client) TransitionList(appIdentity string, options *TransitionListOpts, lr *ListRange) ([]Transition, error) {
+	path := "/apps/" + appIdentity + "/timeline"
+	if options != nil {
+		params := url.Values{}
+		if options.Process != "" {
+			params.Set("process", options.Process)
+		}
+		if options.Pid != "" {
+			params.Set("pid", options.Pid)
+		}
+		if options.Since != nil {
+			params.Set("since", options.Since.Format(time.RFC3339))
+		}
+		if options.Until != nil {
+			params.Set("until", options.Until.Format(time.RFC3339))
+		}
+		if len(params) > 0 {
+			path += "?" + params.Encode()
+		}
+	}
+
+	req, err := c.NewRequest("GET", path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var transitionsRes []Transition
+	return transitionsRes, c.DoReq(req, &transitionsRes)
+}
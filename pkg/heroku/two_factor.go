@@ -0,0 +1,31 @@
+package heroku
+
+// TwoFactorSecret is the TOTP secret that a user enrolled with. The secret is
+// only returned when enrolling.
+type TwoFactorSecret struct {
+	// the base32 encoded secret
+	Secret string `json:"secret"`
+
+	// an otpauth:// url that can be scanned by authenticator apps
+	URL string `json:"url"`
+}
+
+// Enroll in two factor authentication. If already enrolled, a code for the
+// current secret must be provided to replace it.
+func (c *Client) TwoFactorEnroll(code string) (*TwoFactorSecret, error) {
+	rh := RequestHeaders{TwoFactorCode: code}
+	var secretRes TwoFactorSecret
+	return &secretRes, c.PostWithHeaders(&secretRes, "/two-factor", nil, rh.Headers())
+}
+
+// Enroll another user in two factor authentication. Only admins can enroll
+// other users, and the secret needs to be given to the user.
+func (c *Client) TwoFactorEnrollUser(username string) (*TwoFactorSecret, error) {
+	var secretRes TwoFactorSecret
+	return &secretRes, c.Post(&secretRes, "/two-factor/"+username, nil)
+}
+
+// Remove a user's two factor secret, so that they can enroll again.
+func (c *Client) TwoFactorReset(username string) error {
+	return c.Delete("/two-factor/" + username)
+}
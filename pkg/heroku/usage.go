@@ -0,0 +1,58 @@
+package heroku
+
+import (
+	"net/url"
+	"time"
+)
+
+// Usage is the resources that an app, or a team, reserved in a month.
+type Usage struct {
+	// name of the app, empty when usage is reported by team
+	App string `json:"app,omitempty"`
+
+	// team that owned the app
+	Team string `json:"team"`
+
+	// first day of the month
+	Month time.Time `json:"month"`
+
+	// number of dynos reserved, multiplied by the hours they were reserved
+	InstanceHours float64 `json:"instance_hours"`
+
+	// memory reserved in GB, multiplied by the hours it was reserved
+	MemoryHours float64 `json:"memory_hours"`
+}
+
+// UsageListOpts holds the optional parameters for UsageList
+type UsageListOpts struct {
+	// month to report usage for, formatted as YYYY-MM. Defaults to the
+	// current month.
+	Month string
+	// sum usage by team, rather than by app
+	ByTeam bool
+	// only report usage of apps with labels matching this selector
+	Labels string
+}
+
+// List the resources that each app, or team, reserved in a month.
+func (c *Client) UsageList(options *UsageListOpts) ([]Usage, error) {
+	path := "/usage"
+	if options != nil {
+		params := url.Values{}
+		if options.Month != "" {
+			params.Set("month", options.Month)
+		}
+		if options.ByTeam {
+			params.Set("by", "team")
+		}
+		if options.Labels != "" {
+			params.Set("labels", options.Labels)
+		}
+		if len(params) > 0 {
+			path += "?" + params.Encode()
+		}
+	}
+
+	var usage []Usage
+	return usage, c.Get(&usage, path)
+}
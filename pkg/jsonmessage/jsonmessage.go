@@ -29,7 +29,9 @@ func (w *Stream) Encode(m JSONMessage) error {
 }
 
 type JSONMessage struct {
+	ID           string     `json:"id,omitempty"`
 	Status       string     `json:"status,omitempty"`
+	Phase        string     `json:"phase,omitempty"`
 	Error        *JSONError `json:"errorDetail,omitempty"`
 	ErrorMessage string     `json:"error,omitempty"` //deprecated
 }
@@ -0,0 +1,303 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// GenerateClient generates the Go source of a client for the operations in the
+// document. The generated source declares a type for each schema in the
+// Components of the document, and a method on Client for each operation.
+//
+// Client, and the helpers that the methods use, aren't generated. They have to
+// be provided by the package that the source is written to:
+//
+//	func (c *Client) do(ctx context.Context, method, path string, query, body, v interface{}) error
+//	func (c *Client) stream(ctx context.Context, method, path string, query, body interface{}) (io.ReadCloser, error)
+//	type upload struct { contentType string; r io.Reader }
+//
+// JSON request bodies are passed to do and stream as values, and streamed
+// request bodies as an *upload. Query parameters are passed as a pointer to a
+// struct with `query` tags.
+func GenerateClient(doc *Document, pkg string) ([]byte, error) {
+	g := &generator{doc: doc}
+
+	g.printf("// Code generated by openapi.GenerateClient. DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", pkg)
+	g.printf("import (\n%%IMPORTS%%)\n\n")
+
+	var names []string
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		g.printf("type %s %s\n\n", name, g.structType(doc.Components.Schemas[name]))
+	}
+
+	ops, err := g.operations()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range ops {
+		g.operation(op)
+	}
+
+	src := g.buf.String()
+	var imports string
+	for _, imp := range []string{"context", "io", "net/url", "time"} {
+		name := imp[strings.LastIndex(imp, "/")+1:]
+		if strings.Contains(src, name+".") {
+			imports += fmt.Sprintf("\t%q\n", imp)
+		}
+	}
+	src = strings.Replace(src, "%IMPORTS%", imports, 1)
+
+	return format.Source([]byte(src))
+}
+
+// operation is an Operation, with the method and path that it's for.
+type operation struct {
+	*Operation
+	method, path string
+}
+
+type generator struct {
+	doc *Document
+	buf bytes.Buffer
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// operations returns the operations in the document, sorted by their operation
+// id.
+func (g *generator) operations() ([]operation, error) {
+	var ops []operation
+	ids := make(map[string]bool)
+	for path, item := range g.doc.Paths {
+		for method, op := range item {
+			if ids[op.OperationID] {
+				return nil, fmt.Errorf("openapi: operation id %s is used by more than one operation", op.OperationID)
+			}
+			ids[op.OperationID] = true
+			ops = append(ops, operation{op, strings.ToUpper(method), path})
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].OperationID < ops[j].OperationID })
+	return ops, nil
+}
+
+func (g *generator) operation(op operation) {
+	args := []string{"ctx context.Context"}
+
+	// Build the path from the path parameters.
+	path := `"` + op.path + `"`
+	var query []*Parameter
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			arg := goIdent(p.Name)
+			args = append(args, arg+" string")
+			path = strings.Replace(path, "{"+p.Name+"}", `" + url.PathEscape(`+arg+`) + "`, 1)
+		case "query":
+			query = append(query, p)
+		}
+	}
+	path = strings.Replace(path, ` + ""`, "", -1)
+
+	queryArg := "nil"
+	if len(query) > 0 {
+		params := op.OperationID + "Params"
+		g.printf("// %s are the query parameters of %s.\n", params, op.OperationID)
+		g.printf("type %s struct {\n", params)
+		for _, p := range query {
+			g.printf("%s %s `query:%q`\n", goName(p.Name), g.goType(p.Schema), p.Name)
+		}
+		g.printf("}\n\n")
+		args = append(args, "params *"+params)
+		queryArg = "params"
+	}
+
+	bodyArg := "nil"
+	if body := op.RequestBody; body != nil {
+		if media := body.Content["application/json"]; media != nil && media.Schema != nil {
+			args = append(args, "body "+g.valueType(media.Schema))
+			bodyArg = "body"
+		} else {
+			for contentType := range body.Content {
+				args = append(args, "body io.Reader")
+				bodyArg = fmt.Sprintf("&upload{%q, body}", contentType)
+			}
+		}
+	}
+
+	var result string
+	var stream bool
+	for status, resp := range op.Responses {
+		if status == "default" {
+			continue
+		}
+		if media := resp.Content["application/json"]; media != nil && media.Schema != nil {
+			result = g.valueType(media.Schema)
+		} else if len(resp.Content) > 0 {
+			stream = true
+		}
+	}
+
+	g.printf("// %s sends a %s request to %s.\n", op.OperationID, op.method, op.path)
+	signature := fmt.Sprintf("func (c *Client) %s(%s)", op.OperationID, strings.Join(args, ", "))
+	switch {
+	case stream:
+		g.printf("%s (io.ReadCloser, error) {\n", signature)
+		g.printf("return c.stream(ctx, %q, %s, %s, %s)\n", op.method, path, queryArg, bodyArg)
+	case result != "":
+		g.printf("%s (%s, error) {\n", signature, result)
+		g.printf("var v %s\n", result)
+		g.printf("err := c.do(ctx, %q, %s, %s, %s, &v)\n", op.method, path, queryArg, bodyArg)
+		g.printf("return v, err\n")
+	default:
+		g.printf("%s error {\n", signature)
+		g.printf("return c.do(ctx, %q, %s, %s, %s, nil)\n", op.method, path, queryArg, bodyArg)
+	}
+	g.printf("}\n\n")
+}
+
+// valueType returns the Go type that requests and responses with the schema
+// are encoded from, and decoded into. Objects are passed by reference.
+func (g *generator) valueType(s *Schema) string {
+	if s.Ref != "" {
+		return "*" + s.RefName()
+	}
+	return g.goType(s)
+}
+
+// goType returns the Go type for values of the schema.
+func (g *generator) goType(s *Schema) string {
+	if s.Ref != "" {
+		return s.RefName()
+	}
+	if len(s.AllOf) == 1 {
+		t := g.goType(s.AllOf[0])
+		if s.Nullable {
+			t = "*" + t
+		}
+		return t
+	}
+
+	var t string
+	switch s.Type {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			t = "time.Time"
+		case "byte":
+			return "[]byte"
+		default:
+			t = "string"
+		}
+	case "integer":
+		t = "int"
+		if s.Format == "int64" {
+			t = "int64"
+		}
+	case "number":
+		t = "float64"
+	case "boolean":
+		t = "bool"
+	case "array":
+		return "[]" + g.goType(s.Items)
+	case "object":
+		if s.AdditionalProperties != nil {
+			return "map[string]" + g.goType(s.AdditionalProperties)
+		}
+		t = g.structType(s)
+	default:
+		return "interface{}"
+	}
+
+	if s.Nullable {
+		t = "*" + t
+	}
+	return t
+}
+
+// structType returns a struct type with a field for each property of the
+// schema.
+func (g *generator) structType(s *Schema) string {
+	if s.Type != "object" || s.AdditionalProperties != nil {
+		return g.goType(s)
+	}
+
+	required := make(map[string]bool)
+	for _, name := range s.Required {
+		required[name] = true
+	}
+
+	var names []string
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	b.WriteString("struct {\n")
+	for _, name := range names {
+		tag := name
+		if !required[name] {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&b, "%s %s `json:%q`\n", goName(name), g.goType(s.Properties[name]), tag)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// initialisms are the words that are capitalized in Go names.
+var initialisms = map[string]bool{
+	"api": true, "cpu": true, "dns": true, "gpu": true, "http": true,
+	"id": true, "ip": true, "json": true, "ssl": true, "ttl": true,
+	"uri": true, "url": true, "uuid": true,
+}
+
+// goName returns an exported Go name for a JSON property name (e.g. created_at
+// becomes CreatedAt).
+func goName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '-' || r == '.' || r == ' '
+	})
+
+	var s string
+	for _, w := range words {
+		if initialisms[strings.ToLower(w)] {
+			s += strings.ToUpper(w)
+		} else {
+			s += strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return s
+}
+
+// goIdent returns an unexported Go identifier for a parameter name.
+func goIdent(name string) string {
+	s := goName(name)
+	if initialisms[strings.ToLower(s)] {
+		s = strings.ToLower(s)
+	} else {
+		s = strings.ToLower(s[:1]) + s[1:]
+	}
+	switch s {
+	case "body", "ctx", "err", "params", "v":
+		s += "Param"
+	}
+	if token.Lookup(s).IsKeyword() {
+		s += "_"
+	}
+	return s
+}
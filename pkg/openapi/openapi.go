@@ -0,0 +1,332 @@
+// Package openapi describes HTTP APIs with OpenAPI 3 documents
+// (https://spec.openapis.org/oas/v3.0.3), and generates Go clients from them.
+//
+// Schemas are built from Go types by reflection, following the rules that
+// encoding/json uses to encode them, so that a document stays in sync with the
+// types that a server actually encodes and decodes.
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Version is the version of the OpenAPI specification that documents conform
+// to.
+const Version = "3.0.3"
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []map[string][]string `json:"security,omitempty"`
+
+	// Maps the Go types that have been added to Components to the name of
+	// their schema.
+	types map[reflect.Type]string
+}
+
+// New returns a new empty Document.
+func New(title, version string) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Version: version},
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+		},
+	}
+}
+
+// Info provides metadata about the API.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem maps the lowercase HTTP methods that a path supports to their
+// Operation.
+type PathItem map[string]*Operation
+
+// Operation describes an endpoint.
+type Operation struct {
+	// A unique name for the operation. Generated clients name methods
+	// after it.
+	OperationID string `json:"operationId"`
+
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter describes a path or query parameter of an operation.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes a response to an operation.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType describes the body of a request or response, for a content type.
+// A MediaType without a Schema is a stream of bytes.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Components holds the schemas that are referenced from other schemas.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how requests are authenticated.
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+}
+
+// Schema describes a JSON value.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// RefName returns the name of the component that the schema refers to, or an
+// empty string if it's not a reference.
+func (s *Schema) RefName() string {
+	return strings.TrimPrefix(s.Ref, "#/components/schemas/")
+}
+
+// AddOperation adds an operation for the method to the path.
+func (d *Document) AddOperation(method, path string, op *Operation) {
+	item, ok := d.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = op
+}
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	durationType    = reflect.TypeOf(time.Duration(0))
+	rawMessageType  = reflect.TypeOf(json.RawMessage{})
+	marshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textType        = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// SchemaOf returns the schema of the JSON encoding of v. Named struct types are
+// added to the Components of the document, and referenced.
+func (d *Document) SchemaOf(v interface{}) *Schema {
+	return d.schema(reflect.TypeOf(v))
+}
+
+func (d *Document) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	if t.Kind() == reflect.Ptr {
+		s := d.schema(t.Elem())
+		if s.Ref != "" {
+			return &Schema{AllOf: []*Schema{s}, Nullable: true}
+		}
+		s.Nullable = true
+		return s
+	}
+
+	// Types that encode themselves are described as strings, which is how
+	// they're all encoded in Empire.
+	if implements(t, marshalerType) || implements(t, unmarshalerType) || implements(t, textType) {
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.element(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.object(t)
+		}
+		return d.component(t)
+	}
+
+	// Interfaces can hold any value.
+	return &Schema{}
+}
+
+// element returns the schema of the elements of a slice. Pointers to structs
+// are dereferenced, since JSON arrays of objects don't contain nulls.
+func (d *Document) element(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		return d.schema(t.Elem())
+	}
+	return d.schema(t)
+}
+
+// component adds the schema of a named struct type to Components, and returns
+// a reference to it. Types with the same name and schema, like a type that's
+// defined from another type, share a component. When the schemas are
+// different, the name of the second type is prefixed with its package name.
+func (d *Document) component(t reflect.Type) *Schema {
+	if d.types == nil {
+		d.types = make(map[reflect.Type]string)
+	}
+
+	name, ok := d.types[t]
+	if !ok {
+		name = d.componentName(t)
+		d.types[t] = name
+
+		// Reserve the name before building the schema, so that types
+		// that refer to themselves refer to it.
+		s := &Schema{}
+		d.Components.Schemas[name] = s
+		*s = *d.object(t)
+
+		if existing := t.Name(); name != existing && equal(s, d.Components.Schemas[existing]) {
+			delete(d.Components.Schemas, name)
+			name = existing
+			d.types[t] = name
+		}
+	}
+
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// componentName returns a name for the schema of t that isn't used by another
+// component.
+func (d *Document) componentName(t reflect.Type) string {
+	name := t.Name()
+	if d.Components.Schemas[name] == nil {
+		return name
+	}
+
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	prefixed := strings.Title(pkg) + t.Name()
+	name = prefixed
+	for i := 2; d.Components.Schemas[name] != nil; i++ {
+		name = fmt.Sprintf("%s%d", prefixed, i)
+	}
+	return name
+}
+
+// object returns the schema of a struct type, inlined.
+func (d *Document) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	d.fields(s, t)
+	return s
+}
+
+// fields adds the exported fields of t to s, including the fields of embedded
+// structs.
+func (d *Document) fields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseTag(tag)
+
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				d.fields(s, ft)
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			// Unexported.
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		s.Properties[name] = d.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func parseTag(tag string) (name, opts string) {
+	if i := strings.Index(tag, ","); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PtrTo(t).Implements(iface)
+}
+
+// equal returns true if the schemas have the same JSON encoding.
+func equal(a, b *Schema) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	ra, _ := json.Marshal(a)
+	rb, _ := json.Marshal(b)
+	return string(ra) == string(rb)
+}
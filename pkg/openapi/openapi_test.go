@@ -0,0 +1,98 @@
+package openapi
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type base struct {
+	ID string `json:"id"`
+}
+
+type Widget struct {
+	base
+	Name      string            `json:"name"`
+	Color     *string           `json:"color,omitempty"`
+	Labels    map[string]string `json:"labels"`
+	Parts     []*Widget         `json:"parts"`
+	CreatedAt time.Time         `json:"created_at"`
+	Owner     *Owner            `json:"owner"`
+	Ignored   string            `json:"-"`
+	Untagged  bool
+	secret    string
+}
+
+type Owner struct {
+	Email string `json:"email"`
+}
+
+func TestDocument_SchemaOf(t *testing.T) {
+	doc := New("Widgets", "1")
+
+	s := doc.SchemaOf([]*Widget{})
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/Widget"}}, s)
+
+	assert.Equal(t, &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":         {Type: "string"},
+			"name":       {Type: "string"},
+			"color":      {Type: "string", Nullable: true},
+			"labels":     {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+			"parts":      {Type: "array", Items: &Schema{Ref: "#/components/schemas/Widget"}},
+			"created_at": {Type: "string", Format: "date-time"},
+			"owner":      {AllOf: []*Schema{{Ref: "#/components/schemas/Owner"}}, Nullable: true},
+			"Untagged":   {Type: "boolean"},
+		},
+		Required: []string{"id", "name", "labels", "parts", "created_at", "owner", "Untagged"},
+	}, doc.Components.Schemas["Widget"])
+}
+
+func TestDocument_SchemaOf_SameName(t *testing.T) {
+	doc := New("Widgets", "1")
+	doc.SchemaOf(Widget{})
+
+	type Owner struct {
+		Email string `json:"email"`
+	}
+	type Widget struct {
+		Name string `json:"name"`
+	}
+
+	// A type with the same schema shares the component.
+	assert.Equal(t, "#/components/schemas/Owner", doc.SchemaOf(Owner{}).Ref)
+
+	// A type with a different schema is prefixed with its package name.
+	assert.Equal(t, "#/components/schemas/OpenapiWidget", doc.SchemaOf(Widget{}).Ref)
+}
+
+func TestGenerateClient(t *testing.T) {
+	doc := New("Widgets", "1")
+	doc.AddOperation("GET", "/widgets/{id}", &Operation{
+		OperationID: "GetWidget",
+		Parameters: []*Parameter{
+			{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+			{Name: "fields", In: "query", Schema: &Schema{Type: "string"}},
+		},
+		Responses: map[string]*Response{
+			"200": {Description: "OK", Content: map[string]*MediaType{
+				"application/json": {Schema: doc.SchemaOf(&Owner{})},
+			}},
+		},
+	})
+
+	src, err := GenerateClient(doc, "widgets")
+	assert.NoError(t, err)
+
+	for _, s := range []string{
+		"type Owner struct {\n\tEmail string `json:\"email\"`\n}",
+		"type GetWidgetParams struct {\n\tFields string `query:\"fields\"`\n}",
+		"func (c *Client) GetWidget(ctx context.Context, id string, params *GetWidgetParams) (*Owner, error) {",
+		`err := c.do(ctx, "GET", "/widgets/"+url.PathEscape(id), params, nil, &v)`,
+	} {
+		assert.True(t, strings.Contains(string(src), s), "expected generated source to contain %q\n%s", s, src)
+	}
+}
@@ -0,0 +1,109 @@
+// Package totp implements Time-Based One-Time Passwords, as described in RFC
+// 6238, compatible with authenticator apps like Google Authenticator.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Period is the number of seconds that a code is valid for.
+	Period = 30
+
+	// Digits is the number of digits in a code.
+	Digits = 6
+
+	// Skew is the number of periods before and after the current period
+	// that are also accepted, to allow for clock drift.
+	Skew = 1
+)
+
+// encoding is the base32 encoding used for secrets.
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random base32 encoded secret.
+func GenerateSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// Code returns the code for the secret at the given time.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", fmt.Errorf("totp: invalid secret: %v", err)
+	}
+	return code(key, uint64(t.Unix())/Period), nil
+}
+
+// Validate returns true if the code is valid for the secret at the given time.
+func Validate(secret, passcode string, t time.Time) bool {
+	_, ok := Verify(secret, passcode, t)
+	return ok
+}
+
+// Verify returns the time step that the code was generated for, and true if
+// the code is valid for the secret at the given time. Callers can store the
+// step of the last accepted code, and reject codes for the same, or an earlier,
+// step, so that a code can't be replayed while it's still valid.
+func Verify(secret, passcode string, t time.Time) (uint64, bool) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return 0, false
+	}
+
+	passcode = strings.TrimSpace(passcode)
+	if len(passcode) != Digits {
+		return 0, false
+	}
+
+	counter := uint64(t.Unix()) / Period
+	for i := -Skew; i <= Skew; i++ {
+		step := uint64(int64(counter) + int64(i))
+		c := code(key, step)
+		if subtle.ConstantTimeCompare([]byte(c), []byte(passcode)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// URL returns an otpauth:// url for the secret, which can be encoded as a QR
+// code and scanned by authenticator apps.
+func URL(issuer, account, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("period", fmt.Sprintf("%d", Period))
+	v.Set("digits", fmt.Sprintf("%d", Digits))
+	label := url.PathEscape(issuer + ":" + account)
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, v.Encode())
+}
+
+// code implements the HOTP algorithm from RFC 4226.
+func code(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	h := hmac.New(sha1.New, key)
+	h.Write(msg[:])
+	sum := h.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0xf
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", Digits, value%1000000)
+}
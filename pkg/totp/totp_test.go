@@ -0,0 +1,71 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vectors from RFC 6238, Appendix B, truncated to 6 digits.
+func TestCode(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		time int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := Code(secret, time.Unix(tt.time, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.code, code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+
+	now := time.Unix(1111111111, 0)
+	code, err := Code(secret, now)
+	assert.NoError(t, err)
+
+	assert.True(t, Validate(secret, code, now))
+	assert.True(t, Validate(secret, code, now.Add(Period*time.Second)))
+	assert.False(t, Validate(secret, code, now.Add(3*Period*time.Second)))
+	assert.False(t, Validate(secret, "", now))
+	assert.False(t, Validate("not base32!", code, now))
+}
+
+func TestVerify(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+
+	now := time.Unix(1111111111, 0)
+	code, err := Code(secret, now)
+	assert.NoError(t, err)
+
+	step, ok := Verify(secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1111111111/Period), step)
+
+	// The step is the one the code was generated for, not the current one.
+	step, ok = Verify(secret, code, now.Add(Period*time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1111111111/Period), step)
+
+	_, ok = Verify(secret, code, now.Add(3*Period*time.Second))
+	assert.False(t, ok)
+}
+
+func TestURL(t *testing.T) {
+	assert.Equal(t, "otpauth://totp/Empire:ejholmes?digits=6&issuer=Empire&period=30&secret=ABC", URL("Empire", "ejholmes", "ABC"))
+}
@@ -0,0 +1,230 @@
+package empire
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/remind101/empire/twelvefactor"
+	"golang.org/x/net/context"
+)
+
+// Priority classes that a process can have. Processes without a priority class
+// have a normal priority.
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+// Priorities are the valid priority classes.
+var Priorities = []string{PriorityHigh, PriorityNormal, PriorityLow}
+
+// priorityLabel is the label that holds the priority class of a process.
+const priorityLabel = "empire.priority"
+
+// place reserves room for n processes with the given constraints on the
+// machines, and returns true if they all fit. Each process is placed on the
+// first machine that it fits on.
+func place(ms []*Machine, c Constraints, n int) bool {
+	for i := 0; i < n; i++ {
+		placed := false
+		for _, m := range ms {
+			if m.Fits(c) > 0 {
+				m.Allocated.CPU += c.CPUShare
+				m.Allocated.Memory += c.Memory
+				m.Allocated.GPU += c.GPU
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			return false
+		}
+	}
+	return true
+}
+
+// placement is a number of new instances of a process that need room in the
+// cluster.
+type placement struct {
+	Constraints Constraints
+	Count       int
+}
+
+// placeAll returns true if all of the placements fit on the machines, without
+// changing the machines.
+func placeAll(ms []*Machine, placements []placement) bool {
+	var copies []*Machine
+	for _, m := range ms {
+		c := *m
+		copies = append(copies, &c)
+	}
+
+	for _, p := range placements {
+		if !place(copies, p.Constraints, p.Count) {
+			return false
+		}
+	}
+	return true
+}
+
+// preemptible is a low priority one-off process that can be stopped to make
+// room for a high priority process.
+type preemptible struct {
+	App  *App
+	Task *twelvefactor.Task
+}
+
+// preempt makes room in the cluster for the new instances of the high priority
+// processes that were scaled up, when there isn't enough room for them. Low
+// priority one-off processes in the cluster are stopped, most recently started
+// first, until there's enough room, and queued as a Job to run again once
+// there's room for them.
+func (s *appsService) preempt(ctx context.Context, app *App, previous, f Formation, types []string) error {
+	var placements []placement
+	for _, t := range types {
+		p := f[t]
+		if p.Priority != PriorityHigh {
+			continue
+		}
+		if n := p.Quantity - previous[t].Quantity; n > 0 {
+			placements = append(placements, placement{Constraints: p.Constraints(), Count: n})
+		}
+	}
+	if len(placements) == 0 {
+		return nil
+	}
+
+	scheduler, err := s.scheduler(app)
+	if err != nil {
+		return err
+	}
+
+	ms, err := machines(ctx, scheduler)
+	if err != nil {
+		return err
+	}
+	if len(ms) == 0 || placeAll(ms, placements) {
+		return nil
+	}
+
+	candidates, err := s.preemptibles(ctx, app.Cluster)
+	if err != nil {
+		return err
+	}
+
+	hosts := make(map[string]*Machine)
+	for _, m := range ms {
+		hosts[m.Host.ID] = m
+	}
+
+	for _, c := range candidates {
+		reason := fmt.Sprintf("to make room for %s %s", app.Name, strings.Join(types, ", "))
+		if err := s.preemptTask(ctx, c, reason); err != nil {
+			return err
+		}
+
+		// Give back the resources that the process reserved.
+		if m, ok := hosts[c.Task.Host.ID]; ok {
+			m.Allocated.CPU -= constraints.CPUShare(c.Task.Process.CPUShares)
+			m.Allocated.Memory -= constraints.Memory(c.Task.Process.Memory)
+		}
+
+		if placeAll(ms, placements) {
+			break
+		}
+	}
+
+	return nil
+}
+
+// preemptibles returns the running low priority one-off processes of the apps
+// in the cluster, most recently started first.
+func (s *appsService) preemptibles(ctx context.Context, cluster string) ([]*preemptible, error) {
+	apps, err := apps(s.db, AppsQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*preemptible
+	for _, app := range apps {
+		if app.Cluster != cluster {
+			continue
+		}
+
+		scheduler, err := s.scheduler(app)
+		if err != nil {
+			return nil, err
+		}
+
+		tasks, err := scheduler.Tasks(ctx, app.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range tasks {
+			labels := t.Process.Labels
+			if labels[userLabel] == "" || labels[priorityLabel] != PriorityLow || t.State == "STOPPED" {
+				continue
+			}
+			candidates = append(candidates, &preemptible{App: app, Task: t})
+		}
+	}
+
+	sort.Stable(byMostRecentlyStarted(candidates))
+
+	return candidates, nil
+}
+
+// byMostRecentlyStarted sorts preemptible processes so that the ones that have
+// been running for the least amount of time, and so have done the least work,
+// come first.
+type byMostRecentlyStarted []*preemptible
+
+func (s byMostRecentlyStarted) Len() int      { return len(s) }
+func (s byMostRecentlyStarted) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byMostRecentlyStarted) Less(i, j int) bool {
+	return s[i].Task.UpdatedAt.After(s[j].Task.UpdatedAt)
+}
+
+// preemptTask queues the one-off process to run again, and stops it.
+func (s *appsService) preemptTask(ctx context.Context, c *preemptible, reason string) error {
+	scheduler, err := s.scheduler(c.App)
+	if err != nil {
+		return err
+	}
+
+	p := c.Task.Process
+	procName := p.Labels["empire.app.process"]
+	if procName == "" {
+		procName = GenericProcessName
+	}
+	job, err := jobsCreate(s.db, &Job{
+		AppID:     c.App.ID,
+		Type:      procName,
+		Command:   Command(p.Command),
+		CPUShare:  constraints.CPUShare(p.CPUShares),
+		Memory:    constraints.Memory(p.Memory),
+		Nproc:     constraints.Nproc(p.Nproc),
+		CreatedBy: p.Labels[userLabel],
+		Reason:    reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := scheduler.Stop(ctx, c.Task.ID); err != nil {
+		jobsDestroy(s.db, job)
+		return err
+	}
+
+	return s.publishEvent(ctx, PreemptEvent{
+		App:    c.App.Name,
+		PID:    taskFromInstance(c.Task).Name,
+		Job:    job.ID,
+		Reason: reason,
+		app:    c.App,
+	})
+}
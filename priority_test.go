@@ -0,0 +1,26 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlace(t *testing.T) {
+	c := Constraints{CPUShare: 512, Memory: constraints.Memory(512 * bytesize.MB)}
+	ms := []*Machine{
+		{Host: Host{ID: "i-1"}, Total: Resources{CPU: 1024, Memory: constraints.Memory(1024 * bytesize.MB)}},
+		{Host: Host{ID: "i-2", Lost: true}, Total: Resources{CPU: 1024, Memory: constraints.Memory(1024 * bytesize.MB)}},
+	}
+
+	assert.True(t, placeAll(ms, []placement{{Constraints: c, Count: 2}}))
+	assert.False(t, placeAll(ms, []placement{{Constraints: c, Count: 3}}))
+	assert.Equal(t, constraints.CPUShare(0), ms[0].Allocated.CPU, "placeAll shouldn't change the machines")
+
+	assert.True(t, place(ms, c, 1))
+	assert.Equal(t, constraints.CPUShare(512), ms[0].Allocated.CPU)
+	assert.True(t, place(ms, c, 1))
+	assert.False(t, place(ms, c, 1))
+}
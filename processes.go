@@ -5,11 +5,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/remind101/empire/internal/shellwords"
+	. "github.com/remind101/empire/pkg/bytesize"
 	"github.com/remind101/empire/pkg/constraints"
 	"github.com/remind101/empire/procfile"
+	"github.com/remind101/empire/twelvefactor"
 )
 
 // DefaultQuantities maps a process type to the default number of instances to
@@ -18,6 +23,10 @@ var DefaultQuantities = map[string]int{
 	"web": 1,
 }
 
+// DefaultSidecarMemory is the amount of memory given to a sidecar when it
+// isn't provided in the Procfile.
+var DefaultSidecarMemory = constraints.Memory(128 * MB)
+
 // Command represents a command and it's arguments. For example:
 type Command []string
 
@@ -72,6 +81,11 @@ type Process struct {
 	// Command is the command to run.
 	Command Command `json:"Command,omitempty"`
 
+	// Arguments that are appended to Command (e.g. "--threads=8"). They're
+	// changed when scaling, rather than by deploying, and carry over to
+	// new releases.
+	Args Command `json:"Args,omitempty"`
+
 	// Signifies that this is a named one off command and not a long lived
 	// service.
 	NoService bool `json:"Run,omitempty"`
@@ -88,6 +102,9 @@ type Process struct {
 	// The allow number of unix processes within the container.
 	Nproc constraints.Nproc `json:"Nproc,omitempty"`
 
+	// The number of GPUs to reserve.
+	GPU constraints.GPU `json:"GPU,omitempty"`
+
 	// A cron expression. If provided, the process will be run as a
 	// scheduled task.
 	Cron *string `json:"cron,omitempty"`
@@ -100,6 +117,158 @@ type Process struct {
 
 	// ECS specific parameters.
 	ECS *procfile.ECS `json:"ECS,omitempty"`
+
+	// Containers to run alongside each instance of this process.
+	Sidecars []*Sidecar `json:"Sidecars,omitempty"`
+
+	// Storage to mount into the container.
+	Volumes []*Volume `json:"Volumes,omitempty"`
+
+	// Security settings for the container.
+	Security *procfile.Security `json:"Security,omitempty"`
+
+	// If true, an Envoy sidecar is injected to join the service mesh.
+	Mesh bool `json:"Mesh,omitempty"`
+
+	// If not nil, overrides the drain timeout in the router settings of
+	// the app for this process.
+	DrainTimeout *time.Duration `json:"DrainTimeout,omitempty"`
+
+	// If not nil, how the instances of this process are replaced when
+	// it's deployed.
+	Rollout *Rollout `json:"Rollout,omitempty"`
+
+	// If not nil, instances only receive requests from the router while
+	// they pass this check.
+	Readiness *HealthCheck `json:"Readiness,omitempty"`
+
+	// If not nil, instances are restarted when they fail this check.
+	Liveness *HealthCheck `json:"Liveness,omitempty"`
+
+	// If not nil, overrides where the output of the process is sent.
+	Logging *Logging `json:"Logging,omitempty"`
+
+	// If true, one instance of the process runs on every machine, and the
+	// process can't be scaled.
+	Daemon bool `json:"Daemon,omitempty"`
+
+	// If true, at most one instance of the process runs at a time. When
+	// it's deployed, the old instance is stopped before the new one is
+	// started.
+	Singleton bool `json:"Singleton,omitempty"`
+
+	// The priority class of the process. One-off instances of low priority
+	// processes can be preempted to make room for high priority processes.
+	Priority string `json:"Priority,omitempty"`
+
+	// The overlap policy of a scheduled process, which decides what
+	// happens when it's triggered while a previous invocation is still
+	// running.
+	Overlap string `json:"Overlap,omitempty"`
+
+	// The capacity pool that instances of the process are placed on. Spot
+	// processes fall back to on-demand capacity while no spot capacity is
+	// available.
+	Capacity string `json:"Capacity,omitempty"`
+
+	// How instances of the process are placed on machines, instead of the
+	// default strategy of the cluster.
+	Placement string `json:"Placement,omitempty"`
+
+	// The bounds that Quantity must be within. A MaxQuantity of 0 means
+	// there's no upper bound.
+	MinQuantity int `json:"MinQuantity,omitempty"`
+	MaxQuantity int `json:"MaxQuantity,omitempty"`
+}
+
+// Rollout bounds the number of instances of a process during a deploy, as
+// percentages of the desired number of instances.
+type Rollout struct {
+	// How many instances can be started above the desired number, while
+	// the old instances are still running. The default is 100.
+	MaxSurge int `json:"MaxSurge"`
+
+	// How many instances can be stopped below the desired number, before
+	// the new instances are healthy. The default is 0.
+	MaxUnavailable int `json:"MaxUnavailable"`
+}
+
+// Logging holds configuration for where the output of a process is sent.
+type Logging struct {
+	// The Docker log driver (e.g. "json-file").
+	Driver string `json:"Driver"`
+
+	// Options for the log driver.
+	Options map[string]string `json:"Options,omitempty"`
+}
+
+// HealthCheck holds configuration for checking the instances of a process.
+// Zero values use the defaults of the scheduler.
+type HealthCheck struct {
+	// For readiness checks, the HTTP path that's requested. An empty path
+	// checks that a TCP connection can be made.
+	Path string `json:"Path,omitempty"`
+
+	// For liveness checks, the command that's run inside the container. An
+	// exit status of 0 is healthy.
+	Command Command `json:"Command,omitempty"`
+
+	Interval time.Duration `json:"Interval,omitempty"`
+	Timeout  time.Duration `json:"Timeout,omitempty"`
+
+	// The number of consecutive checks that need to pass, or fail, before
+	// an instance is considered healthy, or unhealthy.
+	HealthyThreshold   int `json:"HealthyThreshold,omitempty"`
+	UnhealthyThreshold int `json:"UnhealthyThreshold,omitempty"`
+
+	// For liveness checks, how long failures are ignored for after an
+	// instance starts.
+	StartPeriod time.Duration `json:"StartPeriod,omitempty"`
+}
+
+// Volume holds configuration for storage that's mounted into the container of
+// a Process.
+type Volume struct {
+	// A name for the volume, unique within the process.
+	Name string `json:"Name"`
+
+	// The type of volume (e.g. twelvefactor.VolumeHost).
+	Type string `json:"Type"`
+
+	// For host volumes, the path on the host.
+	Source string `json:"Source,omitempty"`
+
+	// The path to mount the volume at within the container.
+	Path string `json:"Path"`
+
+	// For tmpfs and ebs volumes, the size of the volume in bytes.
+	Size constraints.Memory `json:"Size,omitempty"`
+
+	ReadOnly bool `json:"ReadOnly,omitempty"`
+}
+
+// Sidecar holds configuration for a container that runs alongside each
+// instance of a Process, like a log shipper or a proxy. Sidecars are started
+// and stopped with the process, and inherit its environment.
+type Sidecar struct {
+	// A name for the container, unique within the process.
+	Name string `json:"Name"`
+
+	// The docker image to run.
+	Image string `json:"Image"`
+
+	// If provided, overrides the command of the image.
+	Command Command `json:"Command,omitempty"`
+
+	// Environment variables to set, in addition to the environment
+	// variables of the process.
+	Environment map[string]string `json:"Environment,omitempty"`
+
+	// The memory limit, in bytes.
+	Memory constraints.Memory `json:"Memory,omitempty"`
+
+	// If true, the process is stopped when this container exits.
+	Essential bool `json:"Essential"`
 }
 
 type Port struct {
@@ -117,24 +286,104 @@ func (p *Process) IsValid() error {
 		}
 	}
 
+	// The number of instances of daemon processes follows the number of
+	// machines.
+	if p.Daemon {
+		if p.Quantity != 0 {
+			return errors.New("daemon processes run on every machine, and cannot be scaled")
+		}
+	}
+
+	if p.MaxQuantity > 0 && p.MinQuantity > p.MaxQuantity {
+		return fmt.Errorf("minimum of %d instances is more than the maximum of %d", p.MinQuantity, p.MaxQuantity)
+	}
+
+	// Guards against accidentally scaling critical processes down (e.g. to
+	// zero), or too far up.
+	if p.MinQuantity > 0 && p.Quantity < p.MinQuantity {
+		return fmt.Errorf("cannot be scaled below %d instances", p.MinQuantity)
+	}
+	if p.MaxQuantity > 0 && p.Quantity > p.MaxQuantity {
+		return fmt.Errorf("cannot be scaled above %d instances", p.MaxQuantity)
+	}
+
+	// EBS volumes can only be attached to one instance at a time.
+	if p.Quantity > 1 && p.hasVolume(twelvefactor.VolumeEBS) {
+		return errors.New("processes with ebs volumes cannot be scaled above 1")
+	}
+
+	if p.Quantity > 1 && p.Singleton {
+		return errors.New("singleton processes cannot be scaled above 1")
+	}
+
+	volumes := make(map[string]bool)
+	for _, v := range p.Volumes {
+		if volumes[v.Name] {
+			return fmt.Errorf("volume %s is defined more than once", v.Name)
+		}
+		volumes[v.Name] = true
+	}
+
+	names := make(map[string]bool)
+	for _, s := range p.Sidecars {
+		if s.Name == "" {
+			return errors.New("sidecars must have a name")
+		}
+		if names[s.Name] {
+			return fmt.Errorf("sidecar %s is defined more than once", s.Name)
+		}
+		names[s.Name] = true
+	}
+
 	return nil
 }
 
+// hasVolume returns true if the process has a volume of the given type.
+func (p *Process) hasVolume(volumeType string) bool {
+	for _, v := range p.Volumes {
+		if v.Type == volumeType {
+			return true
+		}
+	}
+	return false
+}
+
+// sidecarMemory returns the memory reserved by the sidecars of each instance
+// of this process.
+func (p *Process) sidecarMemory() constraints.Memory {
+	var m constraints.Memory
+	for _, s := range p.Sidecars {
+		m += s.Memory
+	}
+	return m
+}
+
 // Constraints returns a constraints.Constraints from this Process definition.
 func (p *Process) Constraints() Constraints {
 	return Constraints{
 		Memory:   p.Memory,
 		CPUShare: p.CPUShare,
 		Nproc:    p.Nproc,
+		GPU:      p.GPU,
 	}
 }
 
-// SetConstraints sets the memory/cpu/nproc for this Process to the given
+// SetConstraints sets the memory/cpu/nproc/gpu for this Process to the given
 // constraints.
 func (p *Process) SetConstraints(c Constraints) {
 	p.Memory = c.Memory
 	p.CPUShare = c.CPUShare
 	p.Nproc = c.Nproc
+	p.GPU = c.GPU
+}
+
+// CommandWithArgs returns the command that instances of the process run,
+// which is Command followed by Args.
+func (p *Process) CommandWithArgs() Command {
+	if len(p.Args) == 0 {
+		return p.Command
+	}
+	return append(append(Command{}, p.Command...), p.Args...)
 }
 
 // Formation represents a collection of named processes and their configuration.
@@ -151,6 +400,17 @@ func (f Formation) IsValid() error {
 	return nil
 }
 
+// Types returns the names of the processes in the Formation, sorted
+// alphabetically.
+func (f Formation) Types() []string {
+	var types []string
+	for n := range f {
+		types = append(types, n)
+	}
+	sort.Strings(types)
+	return types
+}
+
 // Scan implements the sql.Scanner interface.
 func (f *Formation) Scan(src interface{}) error {
 	bytes, ok := src.([]byte)
@@ -187,19 +447,144 @@ func (f Formation) Merge(other Formation) Formation {
 	new := make(Formation)
 
 	for name, p := range f {
+		// GPUs declared in the Procfile take precedence, since the
+		// process likely won't work without them.
+		gpu := p.GPU
+
 		if existing, found := other[name]; found {
 			// If the existing Formation already had a process
 			// configuration for this process type, copy over the
 			// instance count.
 			p.Quantity = existing.Quantity
 			p.SetConstraints(existing.Constraints())
+			p.Args = existing.Args
 		} else {
 			p.Quantity = DefaultQuantities[name]
 			p.SetConstraints(DefaultConstraints)
 		}
 
+		if gpu != 0 {
+			p.GPU = gpu
+		}
+
+		// Daemon processes aren't scaled, including processes that
+		// were scaled before they became daemons.
+		if p.Daemon {
+			p.Quantity = 0
+		}
+		if p.Singleton && p.Quantity > 1 {
+			p.Quantity = 1
+		}
+
+		// Bring the quantity within the bounds from the Procfile, in
+		// case they've changed.
+		if p.MinQuantity > 0 && p.Quantity < p.MinQuantity {
+			p.Quantity = p.MinQuantity
+		}
+		if p.MaxQuantity > 0 && p.Quantity > p.MaxQuantity {
+			p.Quantity = p.MaxQuantity
+		}
+
 		new[name] = p
 	}
 
 	return new
 }
+
+// QuantityChange changes the quantity of a process relative to its current
+// quantity, either by a number of instances (e.g. "+5" or "-2"), or by a
+// percentage (e.g. "+50%").
+type QuantityChange struct {
+	// The number of instances, or the percentage, to add. Negative values
+	// remove instances.
+	Delta int
+
+	// If true, Delta is a percentage of the current quantity.
+	Percent bool
+}
+
+// ParseQuantityChange parses a quantity change, like "+5", "-2" or "+50%".
+// Changes of zero (e.g. "+0" or "-0%") are rejected, since they don't change
+// anything.
+func ParseQuantityChange(s string) (*QuantityChange, error) {
+	if len(s) < 2 || (s[0] != '+' && s[0] != '-') {
+		return nil, fmt.Errorf("invalid quantity change %q: must start with + or -", s)
+	}
+
+	c := new(QuantityChange)
+	digits := s[1:]
+	if strings.HasSuffix(digits, "%") {
+		c.Percent = true
+		digits = digits[:len(digits)-1]
+	}
+
+	n, err := strconv.ParseUint(digits, 10, 31)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quantity change %q", s)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("invalid quantity change %q: must not be zero", s)
+	}
+
+	c.Delta = int(n)
+	if s[0] == '-' {
+		c.Delta = -c.Delta
+	}
+
+	return c, nil
+}
+
+// Apply returns the new quantity for a process that currently has quantity q.
+// Percentages are rounded away from zero, so that a percentage of a non-zero
+// quantity adds or removes at least one instance. The result is never less
+// than 0.
+func (c QuantityChange) Apply(q int) int {
+	delta := c.Delta
+	if c.Percent {
+		n := q * delta
+		delta = n / 100
+		if n%100 != 0 {
+			if n > 0 {
+				delta++
+			} else {
+				delta--
+			}
+		}
+	}
+
+	if q+delta < 0 {
+		return 0
+	}
+	return q + delta
+}
+
+func (c QuantityChange) String() string {
+	s := strconv.Itoa(c.Delta)
+	if c.Delta >= 0 {
+		s = "+" + s
+	}
+	if c.Percent {
+		s += "%"
+	}
+	return s
+}
+
+func (c *QuantityChange) UnmarshalJSON(b []byte) error {
+	var s string
+
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	cc, err := ParseQuantityChange(s)
+	if err != nil {
+		return err
+	}
+
+	*c = *cc
+	return nil
+}
+
+func (c QuantityChange) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
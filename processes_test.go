@@ -1,6 +1,7 @@
 package empire
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -98,6 +99,94 @@ func TestNewFormation(t *testing.T) {
 				},
 			},
 		},
+
+		// Check that GPUs declared in the Procfile are kept.
+		{
+			f: Formation{
+				"inference": Process{
+					Command: Command{"./bin/inference"},
+					GPU:     1,
+				},
+			},
+			other: Formation{
+				"inference": Process{
+					Command:  Command{"./bin/inference"},
+					Quantity: 2,
+					Memory:   NamedConstraints["PX"].Memory,
+					CPUShare: NamedConstraints["PX"].CPUShare,
+					Nproc:    NamedConstraints["PX"].Nproc,
+				},
+			},
+			expected: Formation{
+				"inference": Process{
+					Quantity: 2,
+					Command:  Command{"./bin/inference"},
+					Memory:   NamedConstraints["PX"].Memory,
+					CPUShare: NamedConstraints["PX"].CPUShare,
+					Nproc:    NamedConstraints["PX"].Nproc,
+					GPU:      1,
+				},
+			},
+		},
+
+		// Check that quantities are brought within the bounds from the
+		// Procfile.
+		{
+			f: Formation{
+				"web": Process{
+					Command:     Command{"./bin/web"},
+					MinQuantity: 2,
+				},
+				"worker": Process{
+					Command:     Command{"sidekiq"},
+					MaxQuantity: 3,
+				},
+			},
+			other: Formation{
+				"worker": Process{
+					Command:  Command{"sidekiq"},
+					Quantity: 5,
+				},
+			},
+			expected: Formation{
+				"web": Process{
+					Quantity:    2,
+					Command:     Command{"./bin/web"},
+					Memory:      NamedConstraints["1X"].Memory,
+					CPUShare:    NamedConstraints["1X"].CPUShare,
+					Nproc:       NamedConstraints["1X"].Nproc,
+					MinQuantity: 2,
+				},
+				"worker": Process{
+					Quantity:    3,
+					Command:     Command{"sidekiq"},
+					MaxQuantity: 3,
+				},
+			},
+		},
+
+		// Check that arguments set when scaling are kept.
+		{
+			f: Formation{
+				"worker": Process{
+					Command: Command{"sidekiq"},
+				},
+			},
+			other: Formation{
+				"worker": Process{
+					Command:  Command{"sidekiq", "-c", "5"},
+					Quantity: 2,
+					Args:     Command{"--threads=8"},
+				},
+			},
+			expected: Formation{
+				"worker": Process{
+					Quantity: 2,
+					Command:  Command{"sidekiq"},
+					Args:     Command{"--threads=8"},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -106,6 +195,42 @@ func TestNewFormation(t *testing.T) {
 	}
 }
 
+func TestProcess_CommandWithArgs(t *testing.T) {
+	p := Process{Command: Command{"sidekiq"}}
+	assert.Equal(t, Command{"sidekiq"}, p.CommandWithArgs())
+
+	p.Args = Command{"--threads=8"}
+	assert.Equal(t, Command{"sidekiq", "--threads=8"}, p.CommandWithArgs())
+	assert.Equal(t, Command{"sidekiq"}, p.Command)
+}
+
+func TestProcess_IsValid(t *testing.T) {
+	tests := []struct {
+		process Process
+		err     error
+	}{
+		{Process{Quantity: 1}, nil},
+		{Process{NoService: true, Quantity: 1}, errors.New("non-service processes cannot be scaled up")},
+		{Process{Quantity: 1, Volumes: []*Volume{{Name: "data", Type: "ebs"}}}, nil},
+		{Process{Quantity: 2, Volumes: []*Volume{{Name: "data", Type: "ebs"}}}, errors.New("processes with ebs volumes cannot be scaled above 1")},
+		{Process{Quantity: 2, Volumes: []*Volume{{Name: "scratch", Type: "tmpfs"}}}, nil},
+		{Process{Volumes: []*Volume{{Name: "data"}, {Name: "data"}}}, errors.New("volume data is defined more than once")},
+		{Process{Sidecars: []*Sidecar{{Name: "envoy"}, {Name: "envoy"}}}, errors.New("sidecar envoy is defined more than once")},
+		{Process{Quantity: 2, MinQuantity: 2, MaxQuantity: 4}, nil},
+		{Process{Quantity: 1, MinQuantity: 2}, errors.New("cannot be scaled below 2 instances")},
+		{Process{Quantity: 5, MaxQuantity: 4}, errors.New("cannot be scaled above 4 instances")},
+		{Process{Quantity: 2, MinQuantity: 4, MaxQuantity: 2}, errors.New("minimum of 4 instances is more than the maximum of 2")},
+		{Process{Daemon: true}, nil},
+		{Process{Daemon: true, Quantity: 1}, errors.New("daemon processes run on every machine, and cannot be scaled")},
+		{Process{Singleton: true, Quantity: 1}, nil},
+		{Process{Singleton: true, Quantity: 2}, errors.New("singleton processes cannot be scaled above 1")},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.err, tt.process.IsValid())
+	}
+}
+
 func ExampleCommand() {
 	cmd := Command{"/bin/ls", "-h"}
 	fmt.Println(cmd)
@@ -121,3 +246,58 @@ func ExampleParseCommand() {
 	// empire.Command{"/bin/echo", "hello world"}
 
 }
+
+func TestParseQuantityChange(t *testing.T) {
+	tests := []struct {
+		in  string
+		out *QuantityChange
+		err bool
+	}{
+		{"+5", &QuantityChange{Delta: 5}, false},
+		{"-2", &QuantityChange{Delta: -2}, false},
+		{"+50%", &QuantityChange{Delta: 50, Percent: true}, false},
+		{"-25%", &QuantityChange{Delta: -25, Percent: true}, false},
+		{"5", nil, true},
+		{"+", nil, true},
+		{"++5", nil, true},
+		{"+%", nil, true},
+		{"+5x", nil, true},
+		{"+0", nil, true},
+		{"-0", nil, true},
+		{"+0%", nil, true},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseQuantityChange(tt.in)
+		if tt.err {
+			assert.Error(t, err, tt.in)
+			continue
+		}
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, tt.out, c, tt.in)
+		assert.Equal(t, tt.in, c.String())
+	}
+}
+
+func TestQuantityChange_Apply(t *testing.T) {
+	tests := []struct {
+		change QuantityChange
+		in     int
+		out    int
+	}{
+		{QuantityChange{Delta: 5}, 2, 7},
+		{QuantityChange{Delta: -2}, 5, 3},
+		{QuantityChange{Delta: -2}, 1, 0},
+		{QuantityChange{Delta: 50, Percent: true}, 4, 6},
+		{QuantityChange{Delta: 50, Percent: true}, 3, 5},
+		{QuantityChange{Delta: 50, Percent: true}, 1, 2},
+		{QuantityChange{Delta: 50, Percent: true}, 0, 0},
+		{QuantityChange{Delta: -50, Percent: true}, 3, 1},
+		{QuantityChange{Delta: -200, Percent: true}, 3, 0},
+		{QuantityChange{Delta: -2}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.out, tt.change.Apply(tt.in), "%s of %d", tt.change, tt.in)
+	}
+}
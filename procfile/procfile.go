@@ -10,7 +10,7 @@ import (
 	"strings"
 
 	"github.com/aws/aws-sdk-go/service/ecs"
-	"github.com/remind101/empire/procfile/internal/yaml"
+	"github.com/remind101/empire/internal/yaml"
 )
 
 // Procfile is a Go representation of process configuration.
@@ -32,6 +32,155 @@ type Process struct {
 	Ports       []Port            `yaml:"ports,omitempty"`
 	Environment map[string]string `yaml:"environment,omitempty"`
 	ECS         *ECS              `yaml:"ecs,omitempty"`
+	Sidecars    []*Sidecar        `yaml:"sidecars,omitempty"`
+	Volumes     []*Volume         `yaml:"volumes,omitempty"`
+	GPUs        uint              `yaml:"gpus,omitempty"`
+	Security    *Security         `yaml:"security,omitempty"`
+	Mesh        bool              `yaml:"mesh,omitempty"`
+	Scale       *Scale            `yaml:"scale,omitempty"`
+
+	// If true, one instance of the process runs on every machine in the
+	// cluster (e.g. log shippers), instead of being scaled.
+	Daemon bool `yaml:"daemon,omitempty"`
+
+	// If true, at most one instance of the process runs at a time, even
+	// while it's being deployed.
+	Singleton bool `yaml:"singleton,omitempty"`
+
+	// The priority class of the process: "high", "normal" (the default)
+	// or "low".
+	Priority string `yaml:"priority,omitempty"`
+
+	// What happens when a scheduled process is triggered while a previous
+	// invocation is still running: "allow" (the default), "skip", "queue"
+	// or "replace".
+	Overlap string `yaml:"overlap,omitempty"`
+
+	// The capacity pool that instances of the process are placed on:
+	// "spot" or "on-demand". By default, instances can be placed on any
+	// machine.
+	Capacity string `yaml:"capacity,omitempty"`
+
+	// How instances of the process are placed on machines: "binpack" or
+	// "spread". Overrides the default strategy of the cluster.
+	Placement string `yaml:"placement,omitempty"`
+
+	// How long in flight requests to old instances of the process are
+	// given to finish when it's deployed (e.g. "0s", "2m").
+	DrainTimeout *string `yaml:"drain_timeout,omitempty"`
+
+	Deploy *Deploy `yaml:"deploy,omitempty"`
+
+	// Readiness decides when an instance can receive requests, and
+	// liveness decides when it needs to be restarted.
+	Readiness *HealthCheck `yaml:"readiness,omitempty"`
+	Liveness  *HealthCheck `yaml:"liveness,omitempty"`
+
+	Logging *Logging `yaml:"logging,omitempty"`
+}
+
+// Logging configures where the output of a process is sent.
+type Logging struct {
+	// The Docker log driver (e.g. "json-file", "journald" or "syslog").
+	Driver string `yaml:"driver"`
+
+	// Options for the log driver (e.g. max-size and max-file for
+	// json-file, or syslog-address for syslog).
+	Options map[string]string `yaml:"options,omitempty"`
+}
+
+// HealthCheck checks the instances of a process. Durations are strings like
+// "10s".
+type HealthCheck struct {
+	// For readiness checks, the HTTP path that's requested (e.g.
+	// "/health"). Without one, a TCP connection is made.
+	Path string `yaml:"path,omitempty"`
+
+	// For liveness checks, the command to run inside the container.
+	Command interface{} `yaml:"command,omitempty"`
+
+	Interval string `yaml:"interval,omitempty"`
+	Timeout  string `yaml:"timeout,omitempty"`
+
+	// The number of consecutive checks that need to pass, or fail, before
+	// the instance is considered healthy, or unhealthy.
+	HealthyThreshold   int `yaml:"healthy_threshold,omitempty"`
+	UnhealthyThreshold int `yaml:"unhealthy_threshold,omitempty"`
+
+	// For liveness checks, how long failures are ignored for after the
+	// instance starts.
+	StartPeriod string `yaml:"start_period,omitempty"`
+}
+
+// Deploy controls how the instances of a process are replaced when it's
+// deployed, as percentages of the desired number of instances (e.g. "25%").
+type Deploy struct {
+	// How many instances can be started above the desired number.
+	MaxSurge *string `yaml:"max_surge,omitempty"`
+
+	// How many instances can be stopped below the desired number.
+	MaxUnavailable *string `yaml:"max_unavailable,omitempty"`
+}
+
+// Scale bounds the number of instances that a process can be scaled to.
+type Scale struct {
+	// The minimum number of instances.
+	Min int `yaml:"min,omitempty"`
+
+	// The maximum number of instances. 0 means there's no maximum.
+	Max int `yaml:"max,omitempty"`
+}
+
+// Security controls the privileges of the container of a process.
+type Security struct {
+	// When true, the container is given extended privileges on the host.
+	Privileged bool `yaml:"privileged,omitempty"`
+
+	// Linux capabilities to add to, or drop from, the default set (e.g.
+	// "NET_ADMIN").
+	CapAdd  []string `yaml:"cap_add,omitempty"`
+	CapDrop []string `yaml:"cap_drop,omitempty"`
+
+	// When true, the root filesystem of the container is mounted
+	// read-only.
+	ReadOnlyRootFilesystem bool `yaml:"read_only_root_filesystem,omitempty"`
+
+	// The seccomp profile to apply, either "default", "unconfined", or a
+	// JSON seccomp profile. Defaults to the Docker default profile.
+	SeccompProfile string `yaml:"seccomp_profile,omitempty"`
+}
+
+// Volume represents storage that's mounted into the container of a process.
+type Volume struct {
+	Name string `yaml:"name"`
+
+	// One of "host" (the default), "tmpfs" or "ebs".
+	Type string `yaml:"type,omitempty"`
+
+	// For host volumes, the path on the host to mount.
+	Source string `yaml:"source,omitempty"`
+
+	// The path to mount the volume at within the container.
+	Path string `yaml:"path"`
+
+	// For tmpfs and ebs volumes, the size of the volume (e.g. "64mb").
+	Size string `yaml:"size,omitempty"`
+
+	ReadOnly bool `yaml:"read_only,omitempty"`
+}
+
+// Sidecar represents an additional container (e.g. a log shipper or a proxy)
+// that runs alongside each instance of a process.
+type Sidecar struct {
+	Name        string            `yaml:"name"`
+	Image       string            `yaml:"image"`
+	Command     interface{}       `yaml:"command,omitempty"`
+	Environment map[string]string `yaml:"environment,omitempty"`
+	Memory      string            `yaml:"memory,omitempty"`
+
+	// When false, the process will keep running if the sidecar exits.
+	// Defaults to true.
+	Essential *bool `yaml:"essential,omitempty"`
 }
 
 // ECS specific options.
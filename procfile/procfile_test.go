@@ -152,6 +152,141 @@ web:
 			},
 		},
 	},
+
+	// Sidecars
+	{
+		strings.NewReader(`---
+web:
+  command: nginx
+  sidecars:
+    - name: envoy
+      image: envoyproxy/envoy:v1.8.0
+      memory: 128mb
+      environment:
+        ENVOY_LOG_LEVEL: info
+    - name: metrics
+      image: prom/statsd-exporter
+      command: ["--statsd.listen-udp=:8125"]
+      essential: false`),
+		ExtendedProcfile{
+			"web": Process{
+				Command: "nginx",
+				Sidecars: []*Sidecar{
+					{
+						Name:   "envoy",
+						Image:  "envoyproxy/envoy:v1.8.0",
+						Memory: "128mb",
+						Environment: map[string]string{
+							"ENVOY_LOG_LEVEL": "info",
+						},
+					},
+					{
+						Name:      "metrics",
+						Image:     "prom/statsd-exporter",
+						Command:   []interface{}{"--statsd.listen-udp=:8125"},
+						Essential: aws.Bool(false),
+					},
+				},
+			},
+		},
+	},
+
+	// Volumes
+	{
+		strings.NewReader(`---
+db:
+  command: postgres
+  volumes:
+    - name: config
+      source: /etc/postgresql
+      path: /etc/postgresql
+      read_only: true
+    - name: scratch
+      type: tmpfs
+      path: /tmp
+      size: 64mb
+    - name: data
+      type: ebs
+      path: /var/lib/postgresql/data
+      size: 100gb`),
+		ExtendedProcfile{
+			"db": Process{
+				Command: "postgres",
+				Volumes: []*Volume{
+					{Name: "config", Source: "/etc/postgresql", Path: "/etc/postgresql", ReadOnly: true},
+					{Name: "scratch", Type: "tmpfs", Path: "/tmp", Size: "64mb"},
+					{Name: "data", Type: "ebs", Path: "/var/lib/postgresql/data", Size: "100gb"},
+				},
+			},
+		},
+	},
+
+	// GPUs
+	{
+		strings.NewReader(`---
+inference:
+  command: ./bin/inference
+  gpus: 2`),
+		ExtendedProcfile{
+			"inference": Process{
+				Command: "./bin/inference",
+				GPUs:    2,
+			},
+		},
+	},
+
+	// Security
+	{
+		strings.NewReader(`---
+web:
+  command: ./bin/web
+  security:
+    cap_drop:
+      - ALL
+    cap_add:
+      - NET_BIND_SERVICE
+    read_only_root_filesystem: true
+    seccomp_profile: unconfined`),
+		ExtendedProcfile{
+			"web": Process{
+				Command: "./bin/web",
+				Security: &Security{
+					CapDrop:                []string{"ALL"},
+					CapAdd:                 []string{"NET_BIND_SERVICE"},
+					ReadOnlyRootFilesystem: true,
+					SeccompProfile:         "unconfined",
+				},
+			},
+		},
+	},
+
+	{
+		strings.NewReader(`---
+api:
+  command: ./bin/api
+  mesh: true`),
+		ExtendedProcfile{
+			"api": Process{
+				Command: "./bin/api",
+				Mesh:    true,
+			},
+		},
+	},
+
+	{
+		strings.NewReader(`---
+web:
+  command: ./bin/web
+  scale:
+    min: 2
+    max: 10`),
+		ExtendedProcfile{
+			"web": Process{
+				Command: "./bin/web",
+				Scale:   &Scale{Min: 2, Max: 10},
+			},
+		},
+	},
 }
 
 func TestParse(t *testing.T) {
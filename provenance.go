@@ -0,0 +1,90 @@
+package empire
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"regexp"
+)
+
+// CommitPattern is a regex pattern that git commit SHAs must conform to.
+var CommitPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// ErrInvalidCommit is returned when the commit of a Provenance isn't a git SHA.
+var ErrInvalidCommit = &ValidationError{
+	errors.New("The commit must be a git SHA, with 7 to 40 lowercase hex characters."),
+}
+
+// Provenance describes where the image of a slug came from, so that every
+// process that's running can be traced back to the source that it was built
+// from. It's provided when deploying, usually by the CI system that built the
+// image.
+type Provenance struct {
+	// The source repository (e.g. "github.com/remind101/acme-inc").
+	Repo string `json:",omitempty"`
+
+	// The git SHA of the commit that the image was built from.
+	Commit string `json:",omitempty"`
+
+	// A link to the CI build that built the image.
+	BuildURL string `json:",omitempty"`
+
+	// Identifies what built the image, like the CI system, or a user.
+	Builder string `json:",omitempty"`
+}
+
+// IsValid returns an error if the provenance isn't valid.
+func (p Provenance) IsValid() error {
+	if p.Commit != "" && !CommitPattern.MatchString(p.Commit) {
+		return ErrInvalidCommit
+	}
+	return nil
+}
+
+// IsZero returns true if nothing is known about where the image came from.
+func (p Provenance) IsZero() bool {
+	return p == Provenance{}
+}
+
+// ShortCommit returns the abbreviated commit SHA.
+func (p Provenance) ShortCommit() string {
+	if len(p.Commit) > 7 {
+		return p.Commit[:7]
+	}
+	return p.Commit
+}
+
+// Scan implements the sql.Scanner interface.
+func (p *Provenance) Scan(src interface{}) error {
+	if src == nil {
+		*p = Provenance{}
+		return nil
+	}
+
+	bytes, ok := src.([]byte)
+	if !ok {
+		return error(errors.New("Scan source was not []bytes"))
+	}
+
+	var provenance Provenance
+	if err := json.Unmarshal(bytes, &provenance); err != nil {
+		return err
+	}
+	*p = provenance
+
+	return nil
+}
+
+// Value implements the driver.Value interface.
+func (p Provenance) Value() (driver.Value, error) {
+	if p.IsZero() {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.Value(raw), nil
+}
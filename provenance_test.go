@@ -0,0 +1,45 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvenance_IsValid(t *testing.T) {
+	tests := []struct {
+		provenance Provenance
+		err        error
+	}{
+		{Provenance{}, nil},
+		{Provenance{Commit: "c6f77d2"}, nil},
+		{Provenance{Commit: "c6f77d2098bc7e7a5c5d7c6e8e1f2a3b4c5d6e7f"}, nil},
+		{Provenance{Commit: "c6f77d"}, ErrInvalidCommit},
+		{Provenance{Commit: "master"}, ErrInvalidCommit},
+		{Provenance{Commit: "C6F77D2"}, ErrInvalidCommit},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.err, tt.provenance.IsValid())
+	}
+}
+
+func TestProvenance_ShortCommit(t *testing.T) {
+	assert.Equal(t, "", Provenance{}.ShortCommit())
+	assert.Equal(t, "c6f77d2", Provenance{Commit: "c6f77d2"}.ShortCommit())
+	assert.Equal(t, "c6f77d2", Provenance{Commit: "c6f77d2098bc7e7a5c5d7c6e8e1f2a3b4c5d6e7f"}.ShortCommit())
+}
+
+func TestProvenance_Value(t *testing.T) {
+	v, err := Provenance{}.Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	v, err = Provenance{Commit: "c6f77d2", Builder: "github"}.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Commit":"c6f77d2","Builder":"github"}`, string(v.([]byte)))
+
+	var p Provenance
+	assert.NoError(t, p.Scan(v))
+	assert.Equal(t, Provenance{Commit: "c6f77d2", Builder: "github"}, p)
+}
@@ -0,0 +1,414 @@
+package empire
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/remind101/empire/pkg/timex"
+	"golang.org/x/net/context"
+)
+
+// Resources that a Quota can limit.
+const (
+	QuotaInstances = "instances"
+	QuotaMemory    = "memory"
+	QuotaRuns      = "runs"
+	QuotaRunMemory = "run memory"
+)
+
+// ErrQuotaScope is returned when a Quota isn't scoped to exactly one of a team,
+// a namespace or an app.
+var ErrQuotaScope = &ValidationError{
+	errors.New("A quota must be scoped to either a team, a namespace or an app."),
+}
+
+// Quota limits the resources that an app, all of the apps owned by a team, or
+// all of the apps in a namespace, can reserve in the cluster. This prevents one team from consuming the whole
+// cluster.
+//
+// A limit of 0 means that the resource is not limited.
+type Quota struct {
+	// A unique uuid that identifies the quota.
+	ID string
+
+	// If provided, the team that this quota is scoped to.
+	Team string
+
+	// If provided, the namespace that this quota is scoped to.
+	Namespace string
+
+	// If provided, the id of the app that this quota is scoped to.
+	AppID *string
+
+	// The maximum number of instances of all processes.
+	MaxInstances int
+
+	// The maximum amount of memory reserved by all instances of all
+	// processes.
+	MaxMemory constraints.Memory
+
+	// The maximum number of one-off processes that can be running at the
+	// same time.
+	MaxRuns int
+
+	// The maximum amount of memory that a single one-off process can
+	// reserve.
+	MaxRunMemory constraints.Memory
+
+	// The time that the quota was created.
+	CreatedAt *time.Time
+}
+
+// IsValid returns an error if the quota isn't valid.
+func (q *Quota) IsValid() error {
+	scopes := 0
+	for _, scoped := range []bool{q.AppID != nil, q.Team != "", q.Namespace != ""} {
+		if scoped {
+			scopes++
+		}
+	}
+	if scopes != 1 {
+		return ErrQuotaScope
+	}
+
+	return nil
+}
+
+// BeforeCreate sets created_at before inserting.
+func (q *Quota) BeforeCreate() error {
+	t := timex.Now()
+	q.CreatedAt = &t
+	return q.IsValid()
+}
+
+// appliesTo returns true if the quota applies to the given app.
+func (q *Quota) appliesTo(app *App) bool {
+	if q.AppID != nil {
+		return *q.AppID == app.ID
+	}
+
+	if q.Namespace != "" {
+		return q.Namespace == app.Namespace
+	}
+
+	return q.Team == app.Team
+}
+
+// scopedApps returns the apps that a quota on a team, or a namespace, applies
+// to.
+func (q *Quota) scopedApps(db *gorm.DB) ([]*App, error) {
+	if q.Namespace != "" {
+		return apps(db, AppsQuery{Namespace: &q.Namespace})
+	}
+
+	return apps(db, AppsQuery{Team: &q.Team})
+}
+
+// QuotaExceededError is returned when an operation would cause an app, a team,
+// or a namespace, to use more of a resource than its quota allows.
+type QuotaExceededError struct {
+	Quota *Quota
+
+	// The resource that would exceed the quota (e.g. QuotaInstances).
+	Resource string
+
+	// The limit for the resource, and how much would be used.
+	Limit, Requested uint
+}
+
+func (e *QuotaExceededError) Error() string {
+	scope := "app"
+	if e.Quota.Namespace != "" {
+		scope = fmt.Sprintf("namespace %s", e.Quota.Namespace)
+	} else if e.Quota.AppID == nil {
+		scope = fmt.Sprintf("team %s", e.Quota.Team)
+	}
+
+	limit, requested := fmt.Sprint(e.Limit), fmt.Sprint(e.Requested)
+	if e.Resource == QuotaMemory || e.Resource == QuotaRunMemory {
+		limit, requested = constraints.Memory(e.Limit).String(), constraints.Memory(e.Requested).String()
+	}
+
+	return fmt.Sprintf("%s quota exceeded for %s: %s requested, limit is %s", e.Resource, scope, requested, limit)
+}
+
+// QuotasQuery is a scope implementation for common things to filter quotas by.
+type QuotasQuery struct {
+	// If provided, finds the quota with the given id.
+	ID *string
+
+	// If provided, finds quotas scoped to the given team.
+	Team *string
+
+	// If provided, finds quotas scoped to the given app.
+	App *App
+
+	// If provided, finds quotas scoped to the given namespace.
+	Namespace *string
+}
+
+// scope implements the scope interface.
+func (q QuotasQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.ID != nil {
+		scope = append(scope, idEquals(*q.ID))
+	}
+
+	if q.Team != nil {
+		scope = append(scope, fieldEquals("team", *q.Team))
+	}
+
+	if q.App != nil {
+		scope = append(scope, forApp(q.App))
+	}
+
+	if q.Namespace != nil {
+		scope = append(scope, fieldEquals("namespace", *q.Namespace))
+	}
+
+	scope = append(scope, order("created_at desc"))
+
+	return scope.scope(db)
+}
+
+// quotasFind returns the first matching quota.
+func quotasFind(db *gorm.DB, scope scope) (*Quota, error) {
+	var quota Quota
+	return &quota, first(db, scope, &quota)
+}
+
+// quotas returns all quotas matching the scope.
+func quotas(db *gorm.DB, scope scope) ([]*Quota, error) {
+	var quotas []*Quota
+	return quotas, find(db, scope, &quotas)
+}
+
+func quotasCreate(db *gorm.DB, quota *Quota) (*Quota, error) {
+	return quota, db.Create(quota).Error
+}
+
+func quotasDestroy(db *gorm.DB, quota *Quota) error {
+	return db.Delete(quota).Error
+}
+
+// usage represents the resources reserved by the services of one or more
+// apps.
+type usage struct {
+	Instances uint
+	Memory    uint
+}
+
+func (u usage) add(other usage) usage {
+	return usage{
+		Instances: u.Instances + other.Instances,
+		Memory:    u.Memory + other.Memory,
+	}
+}
+
+// formationUsage returns the resources reserved by the services in the
+// formation.
+func formationUsage(f Formation) usage {
+	var u usage
+	for _, p := range f {
+		if p.NoService {
+			continue
+		}
+		u.Instances += uint(p.Quantity)
+		u.Memory += uint(p.Quantity) * uint(p.Memory+p.sidecarMemory())
+	}
+	return u
+}
+
+// appUsage returns the resources reserved by the current release of the app.
+func appUsage(db *gorm.DB, app *App) (usage, error) {
+	release, err := releasesFind(db, ReleasesQuery{App: app})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return usage{}, nil
+		}
+		return usage{}, err
+	}
+	return formationUsage(release.Formation), nil
+}
+
+// usageWith returns the resources that will be reserved within the scope of
+// the quota, if the app is released with the given usage.
+func usageWith(db *gorm.DB, q *Quota, app *App, u usage) (usage, error) {
+	if q.AppID != nil {
+		return u, nil
+	}
+
+	scoped, err := q.scopedApps(db)
+	if err != nil {
+		return u, err
+	}
+
+	for _, a := range scoped {
+		if a.ID == app.ID {
+			continue
+		}
+
+		au, err := appUsage(db, a)
+		if err != nil {
+			return u, err
+		}
+		u = u.add(au)
+	}
+
+	return u, nil
+}
+
+// allQuotas returns the quotas that were created through the API, and the
+// quotas in the operator configuration.
+func (e *Empire) allQuotas() ([]*Quota, error) {
+	qs, err := quotas(e.db, QuotasQuery{})
+	if err != nil {
+		return nil, err
+	}
+	return append(qs, e.OperatorConfig().Quotas...), nil
+}
+
+// checkQuota returns a QuotaExceededError if the release would use more
+// instances, or memory, than a quota for the app, its team or its namespace
+// allows. Releases
+// that don't increase usage are always allowed, so that apps that are already
+// over a quota can still be deployed, and scaled down.
+func (e *Empire) checkQuota(ctx context.Context, req *AdmissionRequest) error {
+	qs, err := e.allQuotas()
+	if err != nil {
+		return err
+	}
+
+	var applicable []*Quota
+	for _, q := range qs {
+		if q.appliesTo(req.App) && (q.MaxInstances > 0 || q.MaxMemory > 0) {
+			applicable = append(applicable, q)
+		}
+	}
+
+	if len(applicable) == 0 {
+		return nil
+	}
+
+	current, err := appUsage(e.db, req.App)
+	if err != nil {
+		return err
+	}
+	requested := formationUsage(req.Release.Formation)
+
+	for _, q := range applicable {
+		u, err := usageWith(e.db, q, req.App, requested)
+		if err != nil {
+			return err
+		}
+
+		if max := uint(q.MaxInstances); max > 0 && u.Instances > max && requested.Instances > current.Instances {
+			return &QuotaExceededError{Quota: q, Resource: QuotaInstances, Limit: max, Requested: u.Instances}
+		}
+
+		if max := uint(q.MaxMemory); max > 0 && u.Memory > max && requested.Memory > current.Memory {
+			return &QuotaExceededError{Quota: q, Resource: QuotaMemory, Limit: max, Requested: u.Memory}
+		}
+	}
+
+	return nil
+}
+
+// checkRunQuota returns a QuotaExceededError if starting another one-off
+// process for the app, with the given constraints, would exceed a quota for the
+// app, its team or its namespace.
+func (e *Empire) checkRunQuota(ctx context.Context, app *App, c Constraints) error {
+	qs, err := e.allQuotas()
+	if err != nil {
+		return err
+	}
+
+	for _, q := range qs {
+		if !q.appliesTo(app) {
+			continue
+		}
+
+		if max := uint(q.MaxRunMemory); max > 0 && uint(c.Memory) > max {
+			return &QuotaExceededError{Quota: q, Resource: QuotaRunMemory, Limit: max, Requested: uint(c.Memory)}
+		}
+
+		if q.MaxRuns == 0 {
+			continue
+		}
+
+		scoped := []*App{app}
+		if q.AppID == nil {
+			scoped, err = q.scopedApps(e.db)
+			if err != nil {
+				return err
+			}
+		}
+
+		var runs uint
+		for _, a := range scoped {
+			n, err := e.runs(ctx, a)
+			if err != nil {
+				return err
+			}
+			runs += n
+		}
+
+		if max := uint(q.MaxRuns); runs+1 > max {
+			return &QuotaExceededError{Quota: q, Resource: QuotaRuns, Limit: max, Requested: runs + 1}
+		}
+	}
+
+	return nil
+}
+
+// runs returns the number of one-off processes that are running for the app.
+// A task is a one-off process when its process type isn't a service in the
+// app's current release.
+func (e *Empire) runs(ctx context.Context, app *App) (uint, error) {
+	release, err := releasesFind(e.db, ReleasesQuery{App: app})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	tasks, err := e.tasks.appTasks(ctx, app)
+	if err != nil {
+		return 0, err
+	}
+
+	var n uint
+	for _, t := range tasks {
+		if t.State == "STOPPED" {
+			continue
+		}
+		if p, ok := release.Formation[t.Type]; ok && !p.NoService {
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
+
+// QuotasCreateOpts are options provided when creating a quota.
+type QuotasCreateOpts struct {
+	// User performing the action.
+	User *User
+
+	// The quota to create.
+	Quota *Quota
+}
+
+// QuotasDestroyOpts are options provided when removing a quota.
+type QuotasDestroyOpts struct {
+	// User performing the action.
+	User *User
+
+	// The quota to remove.
+	Quota *Quota
+}
@@ -0,0 +1,98 @@
+package empire
+
+import (
+	"testing"
+
+	. "github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotasQuery(t *testing.T) {
+	var (
+		id   = "1234"
+		team = "platform"
+		app  = &App{ID: "4321"}
+	)
+
+	tests := scopeTests{
+		{QuotasQuery{}, "ORDER BY created_at desc", []interface{}{}},
+		{QuotasQuery{ID: &id}, "WHERE (id = $1) ORDER BY created_at desc", []interface{}{"1234"}},
+		{QuotasQuery{Team: &team}, "WHERE (team = $1) ORDER BY created_at desc", []interface{}{"platform"}},
+		{QuotasQuery{App: app}, "WHERE (app_id = $1) ORDER BY created_at desc", []interface{}{"4321"}},
+	}
+
+	tests.Run(t)
+}
+
+func TestQuota_IsValid(t *testing.T) {
+	appID := "4321"
+
+	tests := []struct {
+		quota Quota
+		err   error
+	}{
+		{Quota{Team: "platform"}, nil},
+		{Quota{AppID: &appID}, nil},
+		{Quota{}, ErrQuotaScope},
+		{Quota{Namespace: "payments"}, nil},
+		{Quota{}, ErrQuotaScope},
+		{Quota{Team: "platform", AppID: &appID}, ErrQuotaScope},
+		{Quota{Namespace: "payments", Team: "platform"}, ErrQuotaScope},
+		{Quota{Namespace: "payments", AppID: &appID}, ErrQuotaScope},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.err, tt.quota.IsValid())
+	}
+}
+
+func TestFormationUsage(t *testing.T) {
+	f := Formation{
+		"web":    Process{Quantity: 2, Memory: constraints.Memory(512 * MB)},
+		"worker": Process{Quantity: 1, Memory: constraints.Memory(1 * GB)},
+		"psql":   Process{NoService: true, Memory: constraints.Memory(6 * GB)},
+	}
+
+	assert.Equal(t, usage{Instances: 3, Memory: 2 * GB}, formationUsage(f))
+
+	// Sidecars reserve memory for each instance of the process.
+	f["web"] = Process{
+		Quantity: 2,
+		Memory:   constraints.Memory(512 * MB),
+		Sidecars: []*Sidecar{
+			{Name: "envoy", Memory: constraints.Memory(256 * MB)},
+		},
+	}
+	assert.Equal(t, usage{Instances: 3, Memory: 2*GB + 512*MB}, formationUsage(f))
+}
+
+func TestQuotaExceededError(t *testing.T) {
+	appID := "4321"
+
+	tests := []struct {
+		err *QuotaExceededError
+		out string
+	}{
+		{
+			&QuotaExceededError{Quota: &Quota{AppID: &appID}, Resource: QuotaInstances, Limit: 10, Requested: 12},
+			"instances quota exceeded for app: 12 requested, limit is 10",
+		},
+		{
+			&QuotaExceededError{Quota: &Quota{Team: "platform"}, Resource: QuotaMemory, Limit: 8 * GB, Requested: 9 * GB},
+			"memory quota exceeded for team platform: 9.00gb requested, limit is 8.00gb",
+		},
+		{
+			&QuotaExceededError{Quota: &Quota{Namespace: "payments"}, Resource: QuotaInstances, Limit: 10, Requested: 12},
+			"instances quota exceeded for namespace payments: 12 requested, limit is 10",
+		},
+		{
+			&QuotaExceededError{Quota: &Quota{AppID: &appID}, Resource: QuotaRunMemory, Limit: 8 * GB, Requested: 16 * GB},
+			"run memory quota exceeded for app: 16.00gb requested, limit is 8.00gb",
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.out, tt.err.Error())
+	}
+}
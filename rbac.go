@@ -0,0 +1,568 @@
+package empire
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/timex"
+)
+
+// Roles that can be granted to users and teams. Each role includes the
+// permissions of the roles before it:
+//
+//	viewer:   read apps, releases, config, processes and logs.
+//	deployer: deploy, rollback, change config, scale, restart and run.
+//	admin:    create and destroy apps, manage domains, certificates and grants.
+const (
+	RoleViewer   = "viewer"
+	RoleDeployer = "deployer"
+	RoleAdmin    = "admin"
+)
+
+// roleLevels maps a role to its position in the role hierarchy.
+var roleLevels = map[string]int{
+	RoleViewer:   1,
+	RoleDeployer: 2,
+	RoleAdmin:    3,
+}
+
+var (
+	// ErrGrantRole is returned when a Grant has an unknown role.
+	ErrGrantRole = &ValidationError{
+		errors.New("Role must be one of viewer, deployer or admin."),
+	}
+
+	// ErrGrantSubject is returned when a Grant is not given to exactly one
+	// user or team.
+	ErrGrantSubject = &ValidationError{
+		errors.New("A grant must be given to a user or a team, but not both."),
+	}
+
+	// ErrGrantScope is returned when a Grant is scoped to both an app and a
+	// namespace.
+	ErrGrantScope = &ValidationError{
+		errors.New("A grant can be scoped to an app or a namespace, but not both."),
+	}
+
+	// ErrTeamMember is returned when a TeamMember is missing the team or
+	// user.
+	ErrTeamMember = &ValidationError{
+		errors.New("A team and username are required."),
+	}
+)
+
+// ForbiddenError is returned when a user does not have the role required to
+// perform an action.
+type ForbiddenError struct {
+	// The user that attempted the action.
+	User string
+
+	// The role that was required.
+	Role string
+
+	// If provided, the action that the user's scope does not allow.
+	Action string
+
+	// The app that the action was performed against. Nil for actions that
+	// are not scoped to an app.
+	App *App
+}
+
+func (e *ForbiddenError) Error() string {
+	scope := "all apps"
+	if e.App != nil && e.App.Name != "" {
+		scope = e.App.Name
+	} else if e.App != nil {
+		scope = "this app"
+	}
+	user := e.User
+	if user == "" {
+		user = "anonymous"
+	}
+	if e.Action != "" {
+		return fmt.Sprintf("%s is not allowed to %s on %s", user, e.Action, scope)
+	}
+	return fmt.Sprintf("%s does not have the %s role on %s", user, e.Role, scope)
+}
+
+// TeamForbiddenError is returned when a user assigns an app to a team that
+// they aren't a member of.
+type TeamForbiddenError struct {
+	// The user that attempted the action.
+	User string
+
+	// The team that the app would have been assigned to.
+	Team string
+}
+
+func (e *TeamForbiddenError) Error() string {
+	user := e.User
+	if user == "" {
+		user = "anonymous"
+	}
+	return fmt.Sprintf("%s is not a member of the %s team", user, e.Team)
+}
+
+// Grant gives a role to a user, or to every member of a team, either on a
+// single app, on the apps in a namespace, or on all apps.
+type Grant struct {
+	// A unique uuid that identifies the grant.
+	ID string
+
+	// The user that this grant is given to.
+	Username string
+
+	// The team that this grant is given to.
+	Team string
+
+	// If provided, the id of the app that this grant applies to. A nil
+	// AppID applies to all apps.
+	AppID *string
+
+	// If provided, the name of the namespace that this grant applies to.
+	Namespace string
+
+	// The role that is granted.
+	Role string
+
+	// The time that the grant was created.
+	CreatedAt *time.Time
+}
+
+// IsValid returns an error if the grant isn't valid.
+func (g *Grant) IsValid() error {
+	if _, ok := roleLevels[g.Role]; !ok {
+		return ErrGrantRole
+	}
+
+	if (g.Username == "") == (g.Team == "") {
+		return ErrGrantSubject
+	}
+
+	if g.AppID != nil && g.Namespace != "" {
+		return ErrGrantScope
+	}
+
+	return nil
+}
+
+// BeforeCreate sets created_at before inserting.
+func (g *Grant) BeforeCreate() error {
+	t := timex.Now()
+	g.CreatedAt = &t
+	return g.IsValid()
+}
+
+// appliesTo returns true if the grant covers the app. A nil app refers to
+// actions that aren't scoped to an app, which are only covered by grants on all
+// apps.
+func (g *Grant) appliesTo(app *App) bool {
+	if g.AppID != nil {
+		return app != nil && *g.AppID == app.ID
+	}
+
+	if g.Namespace != "" {
+		return app != nil && g.Namespace == app.Namespace
+	}
+
+	return true
+}
+
+// GrantsQuery is a scope implementation for common things to filter grants by.
+type GrantsQuery struct {
+	// If provided, finds the grant with the given id.
+	ID *string
+
+	// If provided, finds grants given to this user.
+	Username *string
+
+	// If provided, finds grants given to this team.
+	Team *string
+
+	// If provided, finds grants on the given app.
+	App *App
+
+	// If provided, finds grants on the given namespace.
+	Namespace *string
+}
+
+// scope implements the scope interface.
+func (q GrantsQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.ID != nil {
+		scope = append(scope, idEquals(*q.ID))
+	}
+
+	if q.Username != nil {
+		scope = append(scope, fieldEquals("username", *q.Username))
+	}
+
+	if q.Team != nil {
+		scope = append(scope, fieldEquals("team", *q.Team))
+	}
+
+	if q.App != nil {
+		scope = append(scope, forApp(q.App))
+	}
+
+	if q.Namespace != nil {
+		scope = append(scope, fieldEquals("namespace", *q.Namespace))
+	}
+
+	scope = append(scope, order("created_at desc"))
+
+	return scope.scope(db)
+}
+
+// grantsFind returns the first matching grant.
+func grantsFind(db *gorm.DB, scope scope) (*Grant, error) {
+	var grant Grant
+	return &grant, first(db, scope, &grant)
+}
+
+// grants returns all grants matching the scope.
+func grants(db *gorm.DB, scope scope) ([]*Grant, error) {
+	var grants []*Grant
+	return grants, find(db, scope, &grants)
+}
+
+func grantsCreate(db *gorm.DB, grant *Grant) (*Grant, error) {
+	return grant, db.Create(grant).Error
+}
+
+func grantsDestroy(db *gorm.DB, grant *Grant) error {
+	return db.Delete(grant).Error
+}
+
+// TeamMember adds a user to a team.
+type TeamMember struct {
+	// A unique uuid that identifies the membership.
+	ID string
+
+	// The name of the team.
+	Team string
+
+	// The user that is a member of the team.
+	Username string
+
+	// The time that the user was added to the team.
+	CreatedAt *time.Time
+}
+
+// IsValid returns an error if the team member isn't valid.
+func (m *TeamMember) IsValid() error {
+	if m.Team == "" || m.Username == "" {
+		return ErrTeamMember
+	}
+
+	return nil
+}
+
+// BeforeCreate sets created_at before inserting.
+func (m *TeamMember) BeforeCreate() error {
+	t := timex.Now()
+	m.CreatedAt = &t
+	return m.IsValid()
+}
+
+// TeamMembersQuery is a scope implementation for common things to filter team
+// members by.
+type TeamMembersQuery struct {
+	// If provided, finds members of the given team.
+	Team *string
+
+	// If provided, finds the teams that the user is a member of.
+	Username *string
+}
+
+// scope implements the scope interface.
+func (q TeamMembersQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.Team != nil {
+		scope = append(scope, fieldEquals("team", *q.Team))
+	}
+
+	if q.Username != nil {
+		scope = append(scope, fieldEquals("username", *q.Username))
+	}
+
+	scope = append(scope, order("username asc"))
+
+	return scope.scope(db)
+}
+
+// teamMembersFind returns the first matching team member.
+func teamMembersFind(db *gorm.DB, scope scope) (*TeamMember, error) {
+	var member TeamMember
+	return &member, first(db, scope, &member)
+}
+
+// teamMembers returns all team members matching the scope.
+func teamMembers(db *gorm.DB, scope scope) ([]*TeamMember, error) {
+	var members []*TeamMember
+	return members, find(db, scope, &members)
+}
+
+func teamMembersCreate(db *gorm.DB, member *TeamMember) (*TeamMember, error) {
+	return member, db.Create(member).Error
+}
+
+func teamMembersDestroy(db *gorm.DB, member *TeamMember) error {
+	return db.Delete(member).Error
+}
+
+// Permissions represents the set of grants that apply to a user.
+type Permissions struct {
+	// When true, every role is permitted on every app.
+	all bool
+
+	grants []*Grant
+
+	// If provided, restricts the apps that the user can access, regardless
+	// of grants.
+	scope *Scope
+}
+
+// Role returns the highest role that the user has on the app, or an empty
+// string if the user has no access. If app is nil, only grants on all apps are
+// considered.
+func (p *Permissions) Role(app *App) string {
+	if p.scope != nil && !p.scope.AllowsApp(app) {
+		return ""
+	}
+
+	if p.all {
+		return RoleAdmin
+	}
+
+	var role string
+	for _, g := range p.grants {
+		if !g.appliesTo(app) {
+			continue
+		}
+
+		if roleLevels[g.Role] > roleLevels[role] {
+			role = g.Role
+		}
+	}
+
+	return role
+}
+
+// Allowed returns true if the user has at least the given role on the app.
+func (p *Permissions) Allowed(app *App, role string) bool {
+	return roleLevels[p.Role(app)] >= roleLevels[role]
+}
+
+// permissionsFor returns the Permissions for a user that is a member of the
+// given teams.
+func permissionsFor(grants []*Grant, user *User, teams []string) *Permissions {
+	isMember := make(map[string]bool)
+	for _, t := range teams {
+		isMember[t] = true
+	}
+
+	p := &Permissions{}
+	for _, g := range grants {
+		if (g.Username != "" && g.Username == user.Name) || (g.Team != "" && isMember[g.Team]) {
+			p.grants = append(p.grants, g)
+		}
+	}
+
+	return p
+}
+
+// Permissions returns the Permissions for the given user. When RBAC is
+// disabled, every user is permitted to do anything, within the user's Scope.
+func (e *Empire) Permissions(user *User) (*Permissions, error) {
+	p, err := e.permissions(user)
+	if err != nil {
+		return p, err
+	}
+
+	if user != nil {
+		p.scope = user.Scope
+	}
+
+	return p, nil
+}
+
+func (e *Empire) permissions(user *User) (*Permissions, error) {
+	if !e.RBAC {
+		return &Permissions{all: true}, nil
+	}
+
+	if user == nil {
+		return &Permissions{}, nil
+	}
+
+	for _, admin := range e.Admins {
+		if admin == user.Name {
+			return &Permissions{all: true}, nil
+		}
+	}
+
+	members, err := teamMembers(e.db, TeamMembersQuery{Username: &user.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	teams := append([]string{}, user.Teams...)
+	for _, m := range members {
+		teams = append(teams, m.Team)
+	}
+
+	gs, err := grants(e.db, GrantsQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	return permissionsFor(gs, user, teams), nil
+}
+
+// Authorize returns a ForbiddenError if the user does not have at least the
+// given role on the app. If app is nil, the user must have the role on all
+// apps.
+func (e *Empire) Authorize(user *User, app *App, role string) error {
+	p, err := e.Permissions(user)
+	if err != nil {
+		return err
+	}
+
+	if !p.Allowed(app, role) {
+		err := &ForbiddenError{Role: role, App: app}
+		if user != nil {
+			err.User = user.Name
+		}
+		return err
+	}
+
+	return nil
+}
+
+// authorize checks that the user has the role required to perform the action
+// on the app, and that the action is within the user's Scope.
+func (e *Empire) authorize(user *User, app *App, action string) error {
+	if err := e.Authorize(user, app, actionRoles[action]); err != nil {
+		return err
+	}
+
+	if user != nil && user.Scope != nil && !user.Scope.AllowsAction(action) {
+		return &ForbiddenError{User: user.Name, Action: action, App: app}
+	}
+
+	return nil
+}
+
+// authorizeAppID is authorize for the app with the given id. The app is loaded
+// first, so that grants scoped to its namespace apply.
+func (e *Empire) authorizeAppID(user *User, appID string, action string) error {
+	app, err := appsFind(e.db, AppsQuery{ID: &appID})
+	if err != nil {
+		return err
+	}
+	return e.authorize(user, app, action)
+}
+
+// authorizeTeam returns a TeamForbiddenError if the user isn't a member of the
+// team, so that an app can't be assigned to another team, and use what's scoped
+// to that team, like its registry credentials. Admins can assign apps to any
+// team.
+func (e *Empire) authorizeTeam(user *User, team string) error {
+	if team == "" {
+		return nil
+	}
+
+	err := &TeamForbiddenError{Team: team}
+	if user == nil {
+		return err
+	}
+	err.User = user.Name
+
+	for _, admin := range e.Admins {
+		if admin == user.Name {
+			return nil
+		}
+	}
+	if e.RBAC && e.Authorize(user, nil, RoleAdmin) == nil {
+		return nil
+	}
+
+	for _, t := range user.Teams {
+		if t == team {
+			return nil
+		}
+	}
+	members, merr := teamMembers(e.db, TeamMembersQuery{Team: &team, Username: &user.Name})
+	if merr != nil {
+		return merr
+	}
+	if len(members) > 0 {
+		return nil
+	}
+
+	return err
+}
+
+// grantApp returns the app that a grant is scoped to, for authorization. The
+// app is loaded, so that admins of its namespace can manage grants on it. Grants
+// on a namespace are authorized like an app in the namespace.
+func (e *Empire) grantApp(g *Grant) (*App, error) {
+	if g.AppID != nil {
+		return appsFind(e.db, AppsQuery{ID: g.AppID})
+	}
+	if g.Namespace != "" {
+		return &App{Namespace: g.Namespace}, nil
+	}
+	return nil, nil
+}
+
+// authorizeGrant checks that the user can manage the grant.
+func (e *Empire) authorizeGrant(user *User, g *Grant) error {
+	app, err := e.grantApp(g)
+	if err != nil {
+		return err
+	}
+	return e.authorize(user, app, ActionAdmin)
+}
+
+// GrantsCreateOpts are options provided when creating a grant.
+type GrantsCreateOpts struct {
+	// User performing the action.
+	User *User
+
+	// The grant to create.
+	Grant *Grant
+}
+
+// GrantsDestroyOpts are options provided when removing a grant.
+type GrantsDestroyOpts struct {
+	// User performing the action.
+	User *User
+
+	// The grant to remove.
+	Grant *Grant
+}
+
+// TeamMembersCreateOpts are options provided when adding a user to a team.
+type TeamMembersCreateOpts struct {
+	// User performing the action.
+	User *User
+
+	// The membership to create.
+	Member *TeamMember
+}
+
+// TeamMembersDestroyOpts are options provided when removing a user from a
+// team.
+type TeamMembersDestroyOpts struct {
+	// User performing the action.
+	User *User
+
+	// The membership to remove.
+	Member *TeamMember
+}
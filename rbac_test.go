@@ -0,0 +1,144 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrantsQuery(t *testing.T) {
+	var (
+		id       = "1234"
+		username = "ejholmes"
+		team     = "platform"
+		app      = &App{ID: "4321"}
+	)
+
+	tests := scopeTests{
+		{GrantsQuery{}, "ORDER BY created_at desc", []interface{}{}},
+		{GrantsQuery{ID: &id}, "WHERE (id = $1) ORDER BY created_at desc", []interface{}{"1234"}},
+		{GrantsQuery{Username: &username}, "WHERE (username = $1) ORDER BY created_at desc", []interface{}{"ejholmes"}},
+		{GrantsQuery{Team: &team}, "WHERE (team = $1) ORDER BY created_at desc", []interface{}{"platform"}},
+		{GrantsQuery{App: app}, "WHERE (app_id = $1) ORDER BY created_at desc", []interface{}{"4321"}},
+	}
+
+	tests.Run(t)
+}
+
+func TestTeamMembersQuery(t *testing.T) {
+	var (
+		username = "ejholmes"
+		team     = "platform"
+	)
+
+	tests := scopeTests{
+		{TeamMembersQuery{}, "ORDER BY username asc", []interface{}{}},
+		{TeamMembersQuery{Team: &team}, "WHERE (team = $1) ORDER BY username asc", []interface{}{"platform"}},
+		{TeamMembersQuery{Team: &team, Username: &username}, "WHERE (team = $1) AND (username = $2) ORDER BY username asc", []interface{}{"platform", "ejholmes"}},
+	}
+
+	tests.Run(t)
+}
+
+func TestGrant_IsValid(t *testing.T) {
+	appID := "4321"
+
+	tests := []struct {
+		grant Grant
+		err   error
+	}{
+		{Grant{Username: "ejholmes", Role: RoleViewer}, nil},
+		{Grant{Team: "platform", Role: RoleAdmin}, nil},
+		{Grant{Username: "ejholmes", Role: "owner"}, ErrGrantRole},
+		{Grant{Role: RoleDeployer}, ErrGrantSubject},
+		{Grant{Username: "ejholmes", Team: "platform", Role: RoleDeployer}, ErrGrantSubject},
+		{Grant{Team: "payments", Namespace: "payments", Role: RoleAdmin}, nil},
+		{Grant{Team: "payments", Namespace: "payments", AppID: &appID, Role: RoleAdmin}, ErrGrantScope},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.err, tt.grant.IsValid())
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	appID, otherAppID := "4321", "1234"
+	app := &App{ID: appID}
+	otherApp := &App{ID: otherAppID}
+	user := &User{Name: "ejholmes"}
+
+	grants := []*Grant{
+		{Team: "platform", Role: RoleViewer},
+		{Username: "ejholmes", Role: RoleDeployer, AppID: &appID},
+		{Team: "data", Role: RoleAdmin},
+		{Username: "mwildehahn", Role: RoleAdmin, AppID: &otherAppID},
+	}
+
+	p := permissionsFor(grants, user, []string{"platform"})
+
+	assert.Equal(t, RoleDeployer, p.Role(app))
+	assert.Equal(t, RoleViewer, p.Role(otherApp))
+	assert.Equal(t, RoleViewer, p.Role(nil))
+
+	assert.True(t, p.Allowed(app, RoleViewer))
+	assert.True(t, p.Allowed(app, RoleDeployer))
+	assert.False(t, p.Allowed(app, RoleAdmin))
+	assert.False(t, p.Allowed(otherApp, RoleDeployer))
+	assert.False(t, p.Allowed(nil, RoleDeployer))
+
+	p = permissionsFor(grants, user, nil)
+	assert.Equal(t, "", p.Role(otherApp))
+	assert.False(t, p.Allowed(otherApp, RoleViewer))
+}
+
+func TestPermissions_Namespace(t *testing.T) {
+	user := &User{Name: "ejholmes"}
+	app := &App{ID: "4321", Name: "payments-api", Namespace: "payments"}
+	otherApp := &App{ID: "1234", Name: "acme-inc"}
+
+	grants := []*Grant{
+		{Team: "payments", Role: RoleAdmin, Namespace: "payments"},
+	}
+
+	p := permissionsFor(grants, user, []string{"payments"})
+
+	assert.Equal(t, RoleAdmin, p.Role(app))
+	assert.Equal(t, RoleAdmin, p.Role(&App{Name: "payments-worker", Namespace: "payments"}))
+	assert.Equal(t, "", p.Role(otherApp))
+	assert.Equal(t, "", p.Role(nil))
+}
+
+func TestEmpire_Authorize(t *testing.T) {
+	e := &Empire{}
+	user := &User{Name: "ejholmes"}
+
+	// RBAC disabled.
+	assert.NoError(t, e.Authorize(user, nil, RoleAdmin))
+
+	// Bootstrapped admins.
+	e.RBAC = true
+	e.Admins = []string{"ejholmes"}
+	assert.NoError(t, e.Authorize(user, &App{ID: "4321"}, RoleAdmin))
+
+	err := e.Authorize(nil, &App{Name: "acme-inc"}, RoleDeployer)
+	assert.EqualError(t, err, "anonymous does not have the deployer role on acme-inc")
+}
+
+func TestForbiddenError(t *testing.T) {
+	err := &ForbiddenError{User: "ejholmes", Role: RoleAdmin}
+	assert.EqualError(t, err, "ejholmes does not have the admin role on all apps")
+
+	err = &ForbiddenError{User: "ejholmes", Role: RoleViewer, App: &App{Name: "acme-inc"}}
+	assert.EqualError(t, err, "ejholmes does not have the viewer role on acme-inc")
+}
+
+func TestEmpire_authorizeTeam(t *testing.T) {
+	e := &Empire{Admins: []string{"admin"}}
+
+	assert.NoError(t, e.authorizeTeam(&User{Name: "ejholmes"}, ""))
+	assert.NoError(t, e.authorizeTeam(&User{Name: "ejholmes", Teams: []string{"payments"}}, "payments"))
+	assert.NoError(t, e.authorizeTeam(&User{Name: "admin"}, "payments"))
+
+	err := e.authorizeTeam(nil, "payments")
+	assert.EqualError(t, err, "anonymous is not a member of the payments team")
+}
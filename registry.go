@@ -1,14 +1,22 @@
 package empire
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"golang.org/x/net/context"
 
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/remind101/empire/pkg/cron"
 	"github.com/remind101/empire/pkg/image"
 	"github.com/remind101/empire/pkg/jsonmessage"
 	"github.com/remind101/empire/procfile"
+	"github.com/remind101/empire/twelvefactor"
 )
 
 // Example instance: Procfile doesn't exist
@@ -38,6 +46,34 @@ func (fn ProcfileExtractorFunc) ExtractProcfile(ctx context.Context, image image
 	return fn(ctx, image, w)
 }
 
+// LayerExtractor can optionally be implemented by an ImageRegistry, to record
+// the layers of each image that's deployed, so that apps can be found by a base
+// layer that they share (e.g. one with a CVE) with ImageUsage.
+type LayerExtractor interface {
+	// ExtractLayers should return the digests of the layers of an image,
+	// base layer first.
+	ExtractLayers(context.Context, image.Image) ([]string, error)
+}
+
+// ImageVerifier verifies the signature of an image, before a release that runs
+// it is submitted to the scheduler.
+type ImageVerifier interface {
+	// VerifyImage should return an error if the image isn't signed by a
+	// trusted key, or if its signature doesn't match its contents.
+	VerifyImage(context.Context, image.Image) error
+}
+
+// ImageVerificationError is returned when the image of a release can't be
+// verified by the ImageVerifier.
+type ImageVerificationError struct {
+	Image image.Image
+	Err   error
+}
+
+func (e *ImageVerificationError) Error() string {
+	return fmt.Sprintf("image %s could not be verified: %v", e.Image, e.Err)
+}
+
 // ImageRegistry represents something that can interact with container images.
 type ImageRegistry interface {
 	ProcfileExtractor
@@ -81,21 +117,24 @@ func formationFromExtendedProcfile(p procfile.ExtendedProcfile) (Formation, erro
 	f := make(Formation)
 
 	for name, process := range p {
-		var cmd Command
-		var err error
+		cmd, err := commandFromProcfile(process.Command)
+		if err != nil {
+			return nil, err
+		}
 
-		switch command := process.Command.(type) {
-		case string:
-			cmd, err = ParseCommand(command)
-			if err != nil {
-				return nil, err
-			}
-		case []interface{}:
-			for _, v := range command {
-				cmd = append(cmd, v.(string))
-			}
-		default:
-			return nil, errors.New("unknown command format")
+		sidecars, err := sidecarsFromProcfile(process.Sidecars)
+		if err != nil {
+			return nil, err
+		}
+
+		volumes, err := volumesFromProcfile(process.Volumes)
+		if err != nil {
+			return nil, err
+		}
+
+		security, err := securityFromProcfile(process.Security)
+		if err != nil {
+			return nil, err
 		}
 
 		var ports []Port
@@ -113,19 +152,158 @@ func formationFromExtendedProcfile(p procfile.ExtendedProcfile) (Formation, erro
 			})
 		}
 
+		drainTimeout, err := drainTimeoutFromProcfile(process.DrainTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		rollout, err := rolloutFromProcfile(process.Deploy)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		readiness, err := readinessFromProcfile(process.Readiness)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid readiness check: %v", name, err)
+		}
+
+		liveness, err := livenessFromProcfile(process.Liveness)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid liveness check: %v", name, err)
+		}
+
+		logging, err := loggingFromProcfile(process.Logging)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		priority, err := priorityFromProcfile(process.Priority)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		overlap, err := overlapFromProcfile(process)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		capacity, err := capacityFromProcfile(process.Capacity)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		placement, err := placementFromProcfile(process)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		if err := cronFromProcfile(process.Cron); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		var min, max int
+		if process.Scale != nil {
+			min, max = process.Scale.Min, process.Scale.Max
+		}
+
 		f[name] = Process{
-			Command:     cmd,
-			Cron:        process.Cron,
-			NoService:   process.NoService,
-			Ports:       ports,
-			Environment: process.Environment,
-			ECS:         process.ECS,
+			Command:      cmd,
+			Cron:         process.Cron,
+			NoService:    process.NoService,
+			Ports:        ports,
+			Environment:  process.Environment,
+			ECS:          process.ECS,
+			Sidecars:     sidecars,
+			Volumes:      volumes,
+			GPU:          constraints.GPU(process.GPUs),
+			Security:     security,
+			Mesh:         process.Mesh,
+			MinQuantity:  min,
+			MaxQuantity:  max,
+			DrainTimeout: drainTimeout,
+			Rollout:      rollout,
+			Readiness:    readiness,
+			Liveness:     liveness,
+			Logging:      logging,
+			Daemon:       process.Daemon,
+			Singleton:    process.Singleton,
+			Priority:     priority,
+			Overlap:      overlap,
+			Capacity:     capacity,
+			Placement:    placement,
+		}
+		if err := validateDaemon(f[name]); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		if err := validateSingleton(f[name]); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
 		}
 	}
 
 	return f, nil
 }
 
+// commandFromProcfile converts a command in an extended Procfile, which can
+// either be a string or a list of arguments, to a Command.
+func commandFromProcfile(command interface{}) (Command, error) {
+	switch command := command.(type) {
+	case string:
+		return ParseCommand(command)
+	case []interface{}:
+		var cmd Command
+		for _, v := range command {
+			cmd = append(cmd, v.(string))
+		}
+		return cmd, nil
+	default:
+		return nil, errors.New("unknown command format")
+	}
+}
+
+func sidecarsFromProcfile(sidecars []*procfile.Sidecar) ([]*Sidecar, error) {
+	var s []*Sidecar
+
+	for _, sidecar := range sidecars {
+		if _, err := image.Decode(sidecar.Image); err != nil {
+			return nil, fmt.Errorf("invalid image for sidecar %s: %v", sidecar.Name, err)
+		}
+
+		var cmd Command
+		if sidecar.Command != nil {
+			var err error
+			cmd, err = commandFromProcfile(sidecar.Command)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		memory := DefaultSidecarMemory
+		if sidecar.Memory != "" {
+			var err error
+			memory, err = constraints.ParseMemory(sidecar.Memory)
+			if err != nil {
+				return nil, fmt.Errorf("invalid memory for sidecar %s: %v", sidecar.Name, err)
+			}
+		}
+
+		essential := true
+		if sidecar.Essential != nil {
+			essential = *sidecar.Essential
+		}
+
+		s = append(s, &Sidecar{
+			Name:        sidecar.Name,
+			Image:       sidecar.Image,
+			Command:     cmd,
+			Environment: sidecar.Environment,
+			Memory:      memory,
+			Essential:   essential,
+		})
+	}
+
+	return s, nil
+}
+
 // protocolFromPort attempts to automatically determine what protocol a port
 // should use. For example, port 80 is well known to be http, so we can assume
 // that http should be used. Defaults to "tcp" if unknown.
@@ -139,3 +317,369 @@ func protocolFromPort(port int) string {
 		return "tcp"
 	}
 }
+
+// drainTimeoutFromProcfile parses the drain timeout of a process in an
+// extended Procfile.
+func drainTimeoutFromProcfile(v *string) (*time.Duration, error) {
+	if v == nil {
+		return nil, nil
+	}
+	d, err := time.ParseDuration(*v)
+	if err != nil || !validDrainTimeout(d) {
+		return nil, fmt.Errorf("drain timeout must be a duration between 0s and 1h, got %q", *v)
+	}
+	return &d, nil
+}
+
+// rolloutFromProcfile parses the deploy settings of a process in an extended
+// Procfile.
+func rolloutFromProcfile(d *procfile.Deploy) (*Rollout, error) {
+	if d == nil {
+		return nil, nil
+	}
+
+	r := &Rollout{MaxSurge: 100}
+	if d.MaxSurge != nil {
+		p, err := parsePercent(*d.MaxSurge)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_surge: %v", err)
+		}
+		r.MaxSurge = p
+	}
+	if d.MaxUnavailable != nil {
+		p, err := parsePercent(*d.MaxUnavailable)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_unavailable: %v", err)
+		}
+		r.MaxUnavailable = p
+	}
+	if r.MaxSurge == 0 && r.MaxUnavailable == 0 {
+		return nil, errors.New("max_surge and max_unavailable can't both be 0%")
+	}
+	return r, nil
+}
+
+// readinessFromProcfile parses the readiness check of a process in an extended
+// Procfile. Readiness is checked by the router, so it can only make HTTP
+// requests, or TCP connections.
+func readinessFromProcfile(c *procfile.HealthCheck) (*HealthCheck, error) {
+	if c == nil {
+		return nil, nil
+	}
+	if c.Command != nil {
+		return nil, errors.New("readiness checks can't run a command, use a path instead")
+	}
+	if c.StartPeriod != "" {
+		return nil, errors.New("start_period only applies to liveness checks")
+	}
+	if c.Path != "" && !strings.HasPrefix(c.Path, "/") {
+		return nil, fmt.Errorf("path must start with a /, got %q", c.Path)
+	}
+	return healthCheckFromProcfile(c, nil)
+}
+
+// livenessFromProcfile parses the liveness check of a process in an extended
+// Procfile. Liveness is checked by running a command inside the container.
+func livenessFromProcfile(c *procfile.HealthCheck) (*HealthCheck, error) {
+	if c == nil {
+		return nil, nil
+	}
+	if c.Path != "" {
+		return nil, errors.New("liveness checks can't request a path, use a command instead")
+	}
+	if c.Command == nil {
+		return nil, errors.New("a command is required")
+	}
+	cmd, err := commandFromProcfile(c.Command)
+	if err != nil {
+		return nil, err
+	}
+	return healthCheckFromProcfile(c, cmd)
+}
+
+// healthCheckFromProcfile parses the settings that readiness and liveness
+// checks share.
+func healthCheckFromProcfile(c *procfile.HealthCheck, cmd Command) (*HealthCheck, error) {
+	h := &HealthCheck{
+		Path:               c.Path,
+		Command:            cmd,
+		HealthyThreshold:   c.HealthyThreshold,
+		UnhealthyThreshold: c.UnhealthyThreshold,
+	}
+	durations := []struct {
+		name  string
+		value string
+		d     *time.Duration
+	}{
+		{"interval", c.Interval, &h.Interval},
+		{"timeout", c.Timeout, &h.Timeout},
+		{"start_period", c.StartPeriod, &h.StartPeriod},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("%s must be a duration, got %q", d.name, d.value)
+		}
+		*d.d = v
+	}
+	if h.Interval != 0 && h.Timeout >= h.Interval {
+		return nil, errors.New("timeout must be shorter than interval")
+	}
+	if h.HealthyThreshold < 0 || h.UnhealthyThreshold < 0 {
+		return nil, errors.New("thresholds can't be negative")
+	}
+	return h, nil
+}
+
+// validateDaemon ensures that a daemon process doesn't use settings that
+// conflict with running one instance on every machine.
+func validateDaemon(p Process) error {
+	if !p.Daemon {
+		return nil
+	}
+	if p.Cron != nil || p.NoService {
+		return errors.New("daemon processes must be long running")
+	}
+	if p.MinQuantity != 0 || p.MaxQuantity != 0 {
+		return errors.New("daemon processes run on every machine, so they can't have scale bounds")
+	}
+	if p.Rollout != nil {
+		return errors.New("daemon processes are replaced on every machine at once, so they can't have deploy settings")
+	}
+	if p.hasVolume(twelvefactor.VolumeEBS) {
+		return errors.New("daemon processes can't mount ebs volumes")
+	}
+	return nil
+}
+
+// validateSingleton ensures that a singleton process can't be configured to run
+// more than one instance.
+func validateSingleton(p Process) error {
+	if !p.Singleton {
+		return nil
+	}
+	if p.Daemon {
+		return errors.New("singleton processes can't be daemons")
+	}
+	if p.Cron != nil || p.NoService {
+		return errors.New("singleton processes must be long running")
+	}
+	if p.MinQuantity > 1 || p.MaxQuantity > 1 {
+		return errors.New("singleton processes can't be scaled above 1")
+	}
+	if p.Rollout != nil {
+		return errors.New("singleton processes are stopped before they're replaced, so they can't have deploy settings")
+	}
+	return nil
+}
+
+// priorityFromProcfile parses the priority class of a process in an extended
+// Procfile.
+func priorityFromProcfile(priority string) (string, error) {
+	if priority == "" {
+		return "", nil
+	}
+	for _, p := range Priorities {
+		if priority == p {
+			return priority, nil
+		}
+	}
+	return "", fmt.Errorf("unknown priority %q, must be one of: %s", priority, strings.Join(Priorities, ", "))
+}
+
+// capacityFromProcfile validates the capacity pool of a process in an extended
+// Procfile.
+func capacityFromProcfile(capacity string) (string, error) {
+	if capacity == "" {
+		return "", nil
+	}
+	for _, c := range Capacities {
+		if capacity == c {
+			return capacity, nil
+		}
+	}
+	return "", fmt.Errorf("unknown capacity %q, must be one of: %s", capacity, strings.Join(Capacities, ", "))
+}
+
+// placementFromProcfile validates the placement strategy of a process in an
+// extended Procfile.
+func placementFromProcfile(p procfile.Process) (string, error) {
+	if p.Placement == "" {
+		return "", nil
+	}
+	if p.ECS != nil && len(p.ECS.PlacementStrategy) > 0 {
+		return "", errors.New("placement can't be used with ecs placement_strategy")
+	}
+	for _, s := range Placements {
+		if p.Placement == s {
+			return p.Placement, nil
+		}
+	}
+	return "", fmt.Errorf("unknown placement %q, must be one of: %s", p.Placement, strings.Join(Placements, ", "))
+}
+
+// overlapFromProcfile parses the overlap policy of a scheduled process in an
+// extended Procfile.
+func overlapFromProcfile(p procfile.Process) (string, error) {
+	if p.Overlap == "" {
+		return "", nil
+	}
+	if p.Cron == nil {
+		return "", errors.New("overlap can only be set for scheduled processes")
+	}
+	for _, o := range OverlapPolicies {
+		if p.Overlap == o {
+			return p.Overlap, nil
+		}
+	}
+	return "", fmt.Errorf("unknown overlap policy %q, must be one of: %s", p.Overlap, strings.Join(OverlapPolicies, ", "))
+}
+
+// cronFromProcfile validates the schedule of a process in an extended Procfile
+// that's evaluated in a time zone. Other schedules are validated by CloudWatch
+// Events, which supports more wildcards than Empire does.
+func cronFromProcfile(expr *string) error {
+	if expr == nil {
+		return nil
+	}
+	if tz, _ := cron.SplitTimezone(*expr); tz == "" {
+		return nil
+	}
+	if _, err := cron.Parse(*expr, nil); err != nil {
+		return fmt.Errorf("invalid cron expression: %v", err)
+	}
+	return nil
+}
+
+// LogDrivers are the Docker log drivers that processes can use.
+var LogDrivers = []string{
+	"json-file",
+	"journald",
+	"syslog",
+	"gelf",
+	"fluentd",
+	"awslogs",
+	"splunk",
+	"none",
+}
+
+// loggingFromProcfile parses the logging configuration of a process in an
+// extended Procfile.
+func loggingFromProcfile(l *procfile.Logging) (*Logging, error) {
+	if l == nil {
+		return nil, nil
+	}
+	for _, driver := range LogDrivers {
+		if l.Driver == driver {
+			return &Logging{
+				Driver:  l.Driver,
+				Options: l.Options,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported log driver %q, must be one of: %s", l.Driver, strings.Join(LogDrivers, ", "))
+}
+
+// parsePercent parses a percentage between 0% and 100%.
+func parsePercent(v string) (int, error) {
+	if !strings.HasSuffix(v, "%") {
+		return 0, fmt.Errorf("%q is not a percentage", v)
+	}
+	p, err := strconv.Atoi(strings.TrimSuffix(v, "%"))
+	if err != nil || p < 0 || p > 100 {
+		return 0, fmt.Errorf("%q must be between 0%% and 100%%", v)
+	}
+	return p, nil
+}
+
+func volumesFromProcfile(volumes []*procfile.Volume) ([]*Volume, error) {
+	var vs []*Volume
+
+	for _, volume := range volumes {
+		v := &Volume{
+			Name:     volume.Name,
+			Type:     volume.Type,
+			Source:   volume.Source,
+			Path:     volume.Path,
+			ReadOnly: volume.ReadOnly,
+		}
+		if v.Type == "" {
+			v.Type = twelvefactor.VolumeHost
+		}
+
+		if v.Name == "" {
+			return nil, errors.New("volumes must have a name")
+		}
+		if !path.IsAbs(v.Path) {
+			return nil, fmt.Errorf("path for volume %s must be absolute", v.Name)
+		}
+
+		switch v.Type {
+		case twelvefactor.VolumeHost:
+			if !path.IsAbs(v.Source) {
+				return nil, fmt.Errorf("source for volume %s must be an absolute path on the host", v.Name)
+			}
+		case twelvefactor.VolumeTmpfs, twelvefactor.VolumeEBS:
+			if volume.Size == "" {
+				return nil, fmt.Errorf("%s volume %s must have a size", v.Type, v.Name)
+			}
+			size, err := constraints.ParseMemory(volume.Size)
+			if err != nil {
+				return nil, fmt.Errorf("invalid size for volume %s: %v", v.Name, err)
+			}
+			v.Size = size
+		default:
+			return nil, fmt.Errorf("unknown type for volume %s: %s", v.Name, v.Type)
+		}
+
+		vs = append(vs, v)
+	}
+
+	return vs, nil
+}
+
+// securityFromProcfile validates the security settings of a process, and
+// normalizes capability names to the form used by Docker (e.g. "NET_ADMIN").
+func securityFromProcfile(security *procfile.Security) (*procfile.Security, error) {
+	if security == nil {
+		return nil, nil
+	}
+
+	s := *security
+
+	var err error
+	if s.CapAdd, err = capabilitiesFromProcfile(security.CapAdd); err != nil {
+		return nil, err
+	}
+	if s.CapDrop, err = capabilitiesFromProcfile(security.CapDrop); err != nil {
+		return nil, err
+	}
+
+	switch s.SeccompProfile {
+	case "", SeccompUnconfined:
+	case "default":
+		s.SeccompProfile = ""
+	default:
+		var profile map[string]interface{}
+		if err := json.Unmarshal([]byte(s.SeccompProfile), &profile); err != nil {
+			return nil, fmt.Errorf("invalid seccomp profile: %v", err)
+		}
+	}
+
+	return &s, nil
+}
+
+func capabilitiesFromProcfile(capabilities []string) ([]string, error) {
+	var caps []string
+	for _, c := range capabilities {
+		c = strings.TrimPrefix(strings.ToUpper(c), "CAP_")
+		if !capabilityRegexp.MatchString(c) {
+			return nil, fmt.Errorf("invalid capability: %s", c)
+		}
+		caps = append(caps, c)
+	}
+	return caps, nil
+}
@@ -132,6 +132,22 @@ func (r *DockerDaemonRegistry) Resolve(ctx context.Context, img image.Image, w *
 	return image.Decode(digest)
 }
 
+// ExtractLayers implements the empire.LayerExtractor interface, by inspecting
+// the image, which has already been pulled by Resolve.
+func (r *DockerDaemonRegistry) ExtractLayers(ctx context.Context, img image.Image) ([]string, error) {
+	i, err := r.docker.InspectImage(img.String())
+	if err != nil {
+		return nil, err
+	}
+
+	// Docker < 1.10 doesn't include the layers of an image.
+	if i.RootFS == nil {
+		return nil, nil
+	}
+
+	return i.RootFS.Layers, nil
+}
+
 // cmdExtractor is an Extractor implementation that returns a Procfile based
 // on the CMD directive in the Dockerfile. It makes the assumption that the cmd
 // is a "web" process.
@@ -55,6 +55,35 @@ func TestResolve(t *testing.T) {
 	}
 }
 
+func TestExtractLayers(t *testing.T) {
+	api := httpmock.NewServeReplay(t).Add(httpmock.PathHandler(t,
+		"GET /version",
+		200, `{ "ApiVersion": "1.20" }`,
+	)).Add(httpmock.PathHandler(t,
+		"GET /images/remind101:acme-inc/json",
+		200, `{ "RootFS": { "Type": "layers", "Layers": [ "sha256:base", "sha256:app" ] } }`,
+	))
+
+	c, s := newTestDockerClient(t, api)
+	defer s.Close()
+
+	d := DockerDaemonRegistry{
+		docker: c,
+	}
+
+	layers, err := d.ExtractLayers(nil, image.Image{
+		Tag:        "acme-inc",
+		Repository: "remind101",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := layers, []string{"sha256:base", "sha256:app"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ExtractLayers() => %v; want %v", got, want)
+	}
+}
+
 func TestResolve_NoDigest_WithDigestsPrefer(t *testing.T) {
 	api := httpmock.NewServeReplay(t).Add(httpmock.PathHandler(t,
 		"GET /version",
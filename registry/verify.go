@@ -0,0 +1,132 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/remind101/empire/pkg/image"
+)
+
+// ErrNoKeys is returned by a CosignVerifier that doesn't have any keys.
+var ErrNoKeys = errors.New("no keys are configured to verify signatures with")
+
+// CosignVerifier is an implementation of the empire.ImageVerifier interface that
+// verifies the signatures of images with cosign
+// (https://github.com/sigstore/cosign).
+type CosignVerifier struct {
+	// The public keys that images can be signed with. Each can be a path
+	// to a key, or a KMS URI (e.g. awskms:///alias/empire). An image only
+	// needs to be signed by one of them.
+	Keys []string
+
+	// Runs a command. The zero value runs the binary.
+	run func(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// VerifyImage runs `cosign verify` with each key, until one of them verifies the
+// signature of the image.
+func (v *CosignVerifier) VerifyImage(ctx context.Context, img image.Image) error {
+	if len(v.Keys) == 0 {
+		return ErrNoKeys
+	}
+
+	run := v.run
+	if run == nil {
+		run = runCommand
+	}
+
+	var err error
+	for _, key := range v.Keys {
+		if _, err = run(ctx, "cosign", "verify", "--key", key, img.String()); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// NotaryVerifier is an implementation of the empire.ImageVerifier interface that
+// verifies images against the trust data in a Notary server, which is what
+// Docker Content Trust uses. The root keys of each repository are pinned in
+// TrustDir the first time that they're seen, or can be provisioned there
+// ahead of time.
+type NotaryVerifier struct {
+	// The url of the Notary server (e.g. https://notary.docker.io).
+	Server string
+
+	// The directory that trusted keys, and trust data, are stored in.
+	TrustDir string
+
+	// Runs a command. The zero value runs the binary.
+	run func(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// VerifyImage looks up the signed targets of the repository of the image, and
+// checks that one of them matches the digest, and tag, of the image.
+func (v *NotaryVerifier) VerifyImage(ctx context.Context, img image.Image) error {
+	if img.Digest == "" {
+		return fmt.Errorf("image %s has no digest to verify", img)
+	}
+
+	run := v.run
+	if run == nil {
+		run = runCommand
+	}
+
+	args := []string{"list", notaryGUN(img)}
+	if v.Server != "" {
+		args = append([]string{"-s", v.Server}, args...)
+	}
+	if v.TrustDir != "" {
+		args = append([]string{"-d", v.TrustDir}, args...)
+	}
+
+	out, err := run(ctx, "notary", args...)
+	if err != nil {
+		return err
+	}
+
+	digest := strings.TrimPrefix(img.Digest, "sha256:")
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		// Each target is listed as NAME DIGEST SIZE ROLE.
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[1] != digest {
+			continue
+		}
+		if img.Tag == "" || fields[0] == img.Tag {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("no signed target in %s matches %s", notaryGUN(img), img.Digest)
+}
+
+// notaryGUN returns the globally unique name of the repository of an image in
+// Notary, which includes the registry.
+func notaryGUN(img image.Image) string {
+	if img.Registry != "" {
+		return img.Registry + "/" + img.Repository
+	}
+	repo := img.Repository
+	if !strings.Contains(repo, "/") {
+		repo = "library/" + repo
+	}
+	return "docker.io/" + repo
+}
+
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
@@ -0,0 +1,93 @@
+package registry
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/remind101/empire/pkg/image"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCosignVerifier(t *testing.T) {
+	var calls [][]string
+	v := &CosignVerifier{
+		Keys: []string{"/etc/empire/old.pub", "/etc/empire/new.pub"},
+		run: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			calls = append(calls, append([]string{name}, args...))
+			if args[2] == "/etc/empire/new.pub" {
+				return nil, nil
+			}
+			return nil, errors.New("no matching signatures")
+		},
+	}
+
+	img := image.Image{Repository: "remind101/acme-inc", Digest: "sha256:c6f77d2098bc0e32aef3102e71b51831a9083dd9356a0ccadca860596a1e9007"}
+	assert.NoError(t, v.VerifyImage(context.Background(), img))
+	assert.Equal(t, [][]string{
+		{"cosign", "verify", "--key", "/etc/empire/old.pub", img.String()},
+		{"cosign", "verify", "--key", "/etc/empire/new.pub", img.String()},
+	}, calls)
+
+	v.Keys = []string{"/etc/empire/old.pub"}
+	assert.EqualError(t, v.VerifyImage(context.Background(), img), "no matching signatures")
+
+	v.Keys = nil
+	assert.Equal(t, ErrNoKeys, v.VerifyImage(context.Background(), img))
+}
+
+func TestNotaryVerifier(t *testing.T) {
+	const list = `   NAME                                 DIGEST                                SIZE (BYTES)    ROLE
+------------------------------------------------------------------------------------------------------
+  latest   c6f77d2098bc0e32aef3102e71b51831a9083dd9356a0ccadca860596a1e9007   1234           targets
+  v1       1d2a3f0c4b5e6d7f8091a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f7   1234           targets
+`
+
+	var args []string
+	v := &NotaryVerifier{
+		Server:   "https://notary.docker.io",
+		TrustDir: "/var/lib/empire/trust",
+		run: func(ctx context.Context, name string, a ...string) ([]byte, error) {
+			args = append([]string{name}, a...)
+			return []byte(list), nil
+		},
+	}
+
+	tests := []struct {
+		img image.Image
+		err string
+	}{
+		{image.Image{Repository: "remind101/acme-inc", Digest: "sha256:c6f77d2098bc0e32aef3102e71b51831a9083dd9356a0ccadca860596a1e9007"}, ""},
+		{image.Image{Repository: "remind101/acme-inc", Tag: "latest", Digest: "sha256:c6f77d2098bc0e32aef3102e71b51831a9083dd9356a0ccadca860596a1e9007"}, ""},
+		{image.Image{Repository: "remind101/acme-inc", Tag: "v1", Digest: "sha256:c6f77d2098bc0e32aef3102e71b51831a9083dd9356a0ccadca860596a1e9007"}, "no signed target in docker.io/remind101/acme-inc matches sha256:c6f77d2098bc0e32aef3102e71b51831a9083dd9356a0ccadca860596a1e9007"},
+		{image.Image{Repository: "remind101/acme-inc", Digest: "sha256:0000000000000000000000000000000000000000000000000000000000000000"}, "no signed target in docker.io/remind101/acme-inc matches sha256:0000000000000000000000000000000000000000000000000000000000000000"},
+		{image.Image{Repository: "remind101/acme-inc", Tag: "latest"}, "image remind101/acme-inc:latest has no digest to verify"},
+	}
+
+	for _, tt := range tests {
+		err := v.VerifyImage(context.Background(), tt.img)
+		if tt.err == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, tt.err)
+		}
+	}
+
+	assert.Equal(t, []string{"notary", "-d", "/var/lib/empire/trust", "-s", "https://notary.docker.io", "list", "docker.io/remind101/acme-inc"}, args)
+}
+
+func TestNotaryGUN(t *testing.T) {
+	tests := []struct {
+		img image.Image
+		gun string
+	}{
+		{image.Image{Repository: "ubuntu"}, "docker.io/library/ubuntu"},
+		{image.Image{Repository: "remind101/acme-inc"}, "docker.io/remind101/acme-inc"},
+		{image.Image{Registry: "quay.io", Repository: "remind101/acme-inc"}, "quay.io/remind101/acme-inc"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.gun, notaryGUN(tt.img))
+	}
+}
@@ -0,0 +1,241 @@
+package empire
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/remind101/empire/twelvefactor"
+)
+
+// Scopes that a RegistryCredential can apply to.
+const (
+	RegistryCredentialScopeGlobal = "global"
+	RegistryCredentialScopeTeam   = "team"
+	RegistryCredentialScopeApp    = "app"
+)
+
+var (
+	// ErrRegistryCredentialScope is returned when a RegistryCredential is
+	// scoped to both a team and an app.
+	ErrRegistryCredentialScope = &ValidationError{
+		errors.New("Registry credentials can be scoped to a team or an app, but not both."),
+	}
+
+	// ErrRegistryCredentialMissing is returned when a RegistryCredential
+	// does not contain any credentials.
+	ErrRegistryCredentialMissing = &ValidationError{
+		errors.New("Registry credentials require a username and password, or a credentials parameter."),
+	}
+)
+
+// RegistryCredential holds credentials for pulling images from a private Docker
+// registry. Credentials are passed to the scheduler as pull secrets whenever an
+// app is released, so hosts don't need to be pre-configured with them.
+//
+// A RegistryCredential can be global (no team or app), scoped to a team, or
+// scoped to a single app. When more than one credential matches the registry of
+// an image, the most specific one wins.
+type RegistryCredential struct {
+	// A unique uuid that identifies the credential.
+	ID string
+
+	// The registry that these credentials are for (e.g. "quay.io"). An
+	// empty string represents the Docker Hub.
+	Registry string
+
+	// The username and password used to authenticate with the registry.
+	Username string
+	Password string
+
+	// If provided, a reference to the credentials in a scheduler specific
+	// secret store (e.g. the ARN of a Secrets Manager secret for ECS).
+	CredentialsParameter string
+
+	// If provided, the team that these credentials are scoped to.
+	Team string
+
+	// If provided, the id of the app that these credentials are scoped to.
+	AppID *string
+
+	// The time that these credentials were created.
+	CreatedAt *time.Time
+}
+
+// Scope returns the scope that this credential applies to.
+func (c *RegistryCredential) Scope() string {
+	if c.AppID != nil {
+		return RegistryCredentialScopeApp
+	}
+
+	if c.Team != "" {
+		return RegistryCredentialScopeTeam
+	}
+
+	return RegistryCredentialScopeGlobal
+}
+
+// IsValid returns an error if the credential isn't valid.
+func (c *RegistryCredential) IsValid() error {
+	if c.AppID != nil && c.Team != "" {
+		return ErrRegistryCredentialScope
+	}
+
+	if c.CredentialsParameter == "" && (c.Username == "" || c.Password == "") {
+		return ErrRegistryCredentialMissing
+	}
+
+	return nil
+}
+
+// BeforeCreate sets created_at before inserting.
+func (c *RegistryCredential) BeforeCreate() error {
+	t := timex.Now()
+	c.CreatedAt = &t
+	return c.IsValid()
+}
+
+// appliesTo returns true if this credential can be used by the given app.
+func (c *RegistryCredential) appliesTo(app *App) bool {
+	switch c.Scope() {
+	case RegistryCredentialScopeApp:
+		return *c.AppID == app.ID
+	case RegistryCredentialScopeTeam:
+		return c.Team == app.Team
+	default:
+		return true
+	}
+}
+
+// RegistryCredentialsQuery is a scope implementation for common things to filter
+// registry credentials by.
+type RegistryCredentialsQuery struct {
+	// If provided, finds the credential with the given id.
+	ID *string
+
+	// If provided, finds credentials for the given registry.
+	Registry *string
+
+	// If provided, finds credentials scoped to the given team.
+	Team *string
+
+	// If provided, finds credentials scoped to the given app.
+	App *App
+}
+
+// scope implements the scope interface.
+func (q RegistryCredentialsQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.ID != nil {
+		scope = append(scope, idEquals(*q.ID))
+	}
+
+	if q.Registry != nil {
+		scope = append(scope, fieldEquals("registry", *q.Registry))
+	}
+
+	if q.Team != nil {
+		scope = append(scope, fieldEquals("team", *q.Team))
+	}
+
+	if q.App != nil {
+		scope = append(scope, forApp(q.App))
+	}
+
+	scope = append(scope, order("created_at desc"))
+
+	return scope.scope(db)
+}
+
+// appPullSecrets returns the pull secrets that should be provided to the
+// scheduler for the given app.
+func appPullSecrets(db *gorm.DB, app *App) ([]*twelvefactor.PullSecret, error) {
+	creds, err := registryCredentials(db, RegistryCredentialsQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	return pullSecrets(creds, app), nil
+}
+
+// pullSecrets resolves the set of credentials that apply to the app, choosing
+// the most specific credential for each registry.
+func pullSecrets(creds []*RegistryCredential, app *App) []*twelvefactor.PullSecret {
+	precedence := map[string]int{
+		RegistryCredentialScopeGlobal: 0,
+		RegistryCredentialScopeTeam:   1,
+		RegistryCredentialScopeApp:    2,
+	}
+
+	var registries []string
+	chosen := make(map[string]*RegistryCredential)
+	for _, c := range creds {
+		if !c.appliesTo(app) {
+			continue
+		}
+
+		existing, ok := chosen[c.Registry]
+		if !ok {
+			registries = append(registries, c.Registry)
+		}
+
+		if !ok || precedence[c.Scope()] > precedence[existing.Scope()] {
+			chosen[c.Registry] = c
+		}
+	}
+
+	var secrets []*twelvefactor.PullSecret
+	for _, registry := range registries {
+		c := chosen[registry]
+		secrets = append(secrets, &twelvefactor.PullSecret{
+			Registry:             c.Registry,
+			Username:             c.Username,
+			Password:             c.Password,
+			CredentialsParameter: c.CredentialsParameter,
+		})
+	}
+
+	return secrets
+}
+
+// registryCredentialsFind returns the first matching registry credential.
+func registryCredentialsFind(db *gorm.DB, scope scope) (*RegistryCredential, error) {
+	var cred RegistryCredential
+	return &cred, first(db, scope, &cred)
+}
+
+// registryCredentials returns all registry credentials matching the scope.
+func registryCredentials(db *gorm.DB, scope scope) ([]*RegistryCredential, error) {
+	var creds []*RegistryCredential
+	return creds, find(db, scope, &creds)
+}
+
+func registryCredentialsCreate(db *gorm.DB, cred *RegistryCredential) (*RegistryCredential, error) {
+	return cred, db.Create(cred).Error
+}
+
+func registryCredentialsDestroy(db *gorm.DB, cred *RegistryCredential) error {
+	return db.Delete(cred).Error
+}
+
+// RegistryCredentialsCreateOpts are options provided when adding registry
+// credentials.
+type RegistryCredentialsCreateOpts struct {
+	// User performing the action.
+	User *User
+
+	// The credentials to add.
+	Credential *RegistryCredential
+}
+
+// RegistryCredentialsDestroyOpts are options provided when removing registry
+// credentials.
+type RegistryCredentialsDestroyOpts struct {
+	// User performing the action.
+	User *User
+
+	// The credentials to remove.
+	Credential *RegistryCredential
+}
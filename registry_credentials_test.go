@@ -0,0 +1,66 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/twelvefactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryCredentialsQuery(t *testing.T) {
+	var (
+		id       = "1234"
+		registry = "quay.io"
+		team     = "platform"
+		app      = &App{ID: "4321"}
+	)
+
+	tests := scopeTests{
+		{RegistryCredentialsQuery{}, "ORDER BY created_at desc", []interface{}{}},
+		{RegistryCredentialsQuery{ID: &id}, "WHERE (id = $1) ORDER BY created_at desc", []interface{}{"1234"}},
+		{RegistryCredentialsQuery{Registry: &registry}, "WHERE (registry = $1) ORDER BY created_at desc", []interface{}{"quay.io"}},
+		{RegistryCredentialsQuery{Team: &team}, "WHERE (team = $1) ORDER BY created_at desc", []interface{}{"platform"}},
+		{RegistryCredentialsQuery{App: app}, "WHERE (app_id = $1) ORDER BY created_at desc", []interface{}{"4321"}},
+	}
+
+	tests.Run(t)
+}
+
+func TestRegistryCredential_IsValid(t *testing.T) {
+	appID := "4321"
+
+	tests := []struct {
+		cred RegistryCredential
+		err  error
+	}{
+		{RegistryCredential{Registry: "quay.io", Username: "u", Password: "p"}, nil},
+		{RegistryCredential{Registry: "quay.io", CredentialsParameter: "arn:aws:secretsmanager:us-east-1:012345678901:secret:quay"}, nil},
+		{RegistryCredential{Registry: "quay.io", Username: "u"}, ErrRegistryCredentialMissing},
+		{RegistryCredential{Registry: "quay.io", Username: "u", Password: "p", Team: "platform", AppID: &appID}, ErrRegistryCredentialScope},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.err, tt.cred.IsValid())
+	}
+}
+
+func TestPullSecrets(t *testing.T) {
+	appID, otherAppID := "4321", "1234"
+	app := &App{ID: appID, Team: "platform"}
+
+	creds := []*RegistryCredential{
+		{Registry: "quay.io", Username: "global", Password: "p"},
+		{Registry: "quay.io", Username: "team", Password: "p", Team: "platform"},
+		{Registry: "quay.io", Username: "app", Password: "p", AppID: &appID},
+		{Registry: "quay.io", Username: "other-app", Password: "p", AppID: &otherAppID},
+		{Registry: "", Username: "other-team", Password: "p", Team: "data"},
+		{Registry: "", Username: "hub", Password: "p"},
+		{Registry: "gcr.io", Username: "team", Password: "p", Team: "platform"},
+	}
+
+	assert.Equal(t, []*twelvefactor.PullSecret{
+		{Registry: "quay.io", Username: "app", Password: "p"},
+		{Registry: "", Username: "hub", Password: "p"},
+		{Registry: "gcr.io", Username: "team", Password: "p"},
+	}, pullSecrets(creds, app))
+}
@@ -0,0 +1,422 @@
+package empire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	. "github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/remind101/empire/procfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormationFromProcfile_Sidecars(t *testing.T) {
+	essential := false
+	f, err := formationFromProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{
+			Command: "./bin/web",
+			Sidecars: []*procfile.Sidecar{
+				{
+					Name:   "envoy",
+					Image:  "envoyproxy/envoy:v1.8.0",
+					Memory: "256mb",
+				},
+				{
+					Name:      "statsd",
+					Image:     "prom/statsd-exporter",
+					Command:   []interface{}{"--statsd.listen-udp=:8125"},
+					Essential: &essential,
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*Sidecar{
+		{
+			Name:      "envoy",
+			Image:     "envoyproxy/envoy:v1.8.0",
+			Memory:    constraints.Memory(256 * MB),
+			Essential: true,
+		},
+		{
+			Name:    "statsd",
+			Image:   "prom/statsd-exporter",
+			Command: Command{"--statsd.listen-udp=:8125"},
+			Memory:  DefaultSidecarMemory,
+		},
+	}, f["web"].Sidecars)
+
+	_, err = formationFromProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{
+			Command: "./bin/web",
+			Sidecars: []*procfile.Sidecar{
+				{Name: "envoy", Image: "envoyproxy/envoy", Memory: "lots"},
+			},
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestFormationFromProcfile_Volumes(t *testing.T) {
+	f, err := formationFromProcfile(procfile.ExtendedProcfile{
+		"db": procfile.Process{
+			Command: "postgres",
+			Volumes: []*procfile.Volume{
+				{Name: "config", Source: "/etc/postgresql", Path: "/etc/postgresql", ReadOnly: true},
+				{Name: "data", Type: "ebs", Path: "/var/lib/postgresql/data", Size: "100gb"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*Volume{
+		{Name: "config", Type: "host", Source: "/etc/postgresql", Path: "/etc/postgresql", ReadOnly: true},
+		{Name: "data", Type: "ebs", Path: "/var/lib/postgresql/data", Size: constraints.Memory(100 * GB)},
+	}, f["db"].Volumes)
+
+	tests := []struct {
+		volume *procfile.Volume
+		err    string
+	}{
+		{&procfile.Volume{Path: "/tmp", Type: "tmpfs", Size: "64mb"}, "volumes must have a name"},
+		{&procfile.Volume{Name: "scratch", Path: "tmp", Type: "tmpfs", Size: "64mb"}, "path for volume scratch must be absolute"},
+		{&procfile.Volume{Name: "scratch", Path: "/tmp", Type: "tmpfs"}, "tmpfs volume scratch must have a size"},
+		{&procfile.Volume{Name: "config", Path: "/etc/postgresql"}, "source for volume config must be an absolute path on the host"},
+		{&procfile.Volume{Name: "data", Path: "/data", Type: "nfs"}, "unknown type for volume data: nfs"},
+	}
+
+	for _, tt := range tests {
+		_, err := formationFromProcfile(procfile.ExtendedProcfile{
+			"db": procfile.Process{
+				Command: "postgres",
+				Volumes: []*procfile.Volume{tt.volume},
+			},
+		})
+		assert.EqualError(t, err, tt.err)
+	}
+}
+
+func TestFormationFromProcfile_Security(t *testing.T) {
+	f, err := formationFromProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{
+			Command: "./bin/web",
+			Security: &procfile.Security{
+				CapAdd:         []string{"cap_net_admin"},
+				CapDrop:        []string{"ALL"},
+				SeccompProfile: "default",
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &procfile.Security{
+		CapAdd:  []string{"NET_ADMIN"},
+		CapDrop: []string{"ALL"},
+	}, f["web"].Security)
+
+	tests := []struct {
+		security *procfile.Security
+		err      string
+	}{
+		{&procfile.Security{CapAdd: []string{"NET ADMIN"}}, "invalid capability: NET ADMIN"},
+		{&procfile.Security{SeccompProfile: "/etc/seccomp.json"}, "invalid seccomp profile: invalid character '/' looking for beginning of value"},
+	}
+
+	for _, tt := range tests {
+		_, err := formationFromProcfile(procfile.ExtendedProcfile{
+			"web": procfile.Process{
+				Command:  "./bin/web",
+				Security: tt.security,
+			},
+		})
+		assert.EqualError(t, err, tt.err)
+	}
+}
+
+func TestFormationFromProcfile_DrainTimeout(t *testing.T) {
+	immediate, slow, invalid := "0s", "5m", "2h"
+	f, err := formationFromProcfile(procfile.ExtendedProcfile{
+		"web":       procfile.Process{Command: "./bin/web"},
+		"api":       procfile.Process{Command: "./bin/api", DrainTimeout: &immediate},
+		"downloads": procfile.Process{Command: "./bin/downloads", DrainTimeout: &slow},
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, f["web"].DrainTimeout)
+	assert.Equal(t, time.Duration(0), *f["api"].DrainTimeout)
+	assert.Equal(t, 5*time.Minute, *f["downloads"].DrainTimeout)
+
+	_, err = formationFromProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{Command: "./bin/web", DrainTimeout: &invalid},
+	})
+	assert.EqualError(t, err, `web: drain timeout must be a duration between 0s and 1h, got "2h"`)
+}
+
+func TestFormationFromProcfile_Deploy(t *testing.T) {
+	surge, unavailable, zero, invalid := "25%", "50%", "0%", "1"
+	f, err := formationFromProcfile(procfile.ExtendedProcfile{
+		"web":    procfile.Process{Command: "./bin/web"},
+		"api":    procfile.Process{Command: "./bin/api", Deploy: &procfile.Deploy{MaxSurge: &surge}},
+		"worker": procfile.Process{Command: "./bin/worker", Deploy: &procfile.Deploy{MaxSurge: &zero, MaxUnavailable: &unavailable}},
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, f["web"].Rollout)
+	assert.Equal(t, &Rollout{MaxSurge: 25}, f["api"].Rollout)
+	assert.Equal(t, &Rollout{MaxSurge: 0, MaxUnavailable: 50}, f["worker"].Rollout)
+
+	tests := []struct {
+		deploy *procfile.Deploy
+		err    string
+	}{
+		{&procfile.Deploy{MaxSurge: &invalid}, `web: invalid max_surge: "1" is not a percentage`},
+		{&procfile.Deploy{MaxSurge: &zero}, "web: max_surge and max_unavailable can't both be 0%"},
+	}
+
+	for _, tt := range tests {
+		_, err := formationFromProcfile(procfile.ExtendedProcfile{
+			"web": procfile.Process{Command: "./bin/web", Deploy: tt.deploy},
+		})
+		assert.EqualError(t, err, tt.err)
+	}
+}
+
+func TestFormationFromProcfile_HealthChecks(t *testing.T) {
+	f, err := formationFromProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{
+			Command:   "./bin/web",
+			Readiness: &procfile.HealthCheck{Path: "/ready", Interval: "10s", Timeout: "2s"},
+			Liveness:  &procfile.HealthCheck{Command: "./bin/alive", StartPeriod: "1m", UnhealthyThreshold: 3},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &HealthCheck{
+		Path:     "/ready",
+		Interval: 10 * time.Second,
+		Timeout:  2 * time.Second,
+	}, f["web"].Readiness)
+	assert.Equal(t, &HealthCheck{
+		Command:            Command{"./bin/alive"},
+		UnhealthyThreshold: 3,
+		StartPeriod:        time.Minute,
+	}, f["web"].Liveness)
+
+	tests := []struct {
+		process procfile.Process
+		err     string
+	}{
+		{procfile.Process{Command: "./bin/web", Readiness: &procfile.HealthCheck{Command: "./bin/ready"}}, "web: invalid readiness check: readiness checks can't run a command, use a path instead"},
+		{procfile.Process{Command: "./bin/web", Readiness: &procfile.HealthCheck{Path: "health"}}, `web: invalid readiness check: path must start with a /, got "health"`},
+		{procfile.Process{Command: "./bin/web", Readiness: &procfile.HealthCheck{Interval: "5s", Timeout: "5s"}}, "web: invalid readiness check: timeout must be shorter than interval"},
+		{procfile.Process{Command: "./bin/web", Liveness: &procfile.HealthCheck{Path: "/health"}}, "web: invalid liveness check: liveness checks can't request a path, use a command instead"},
+		{procfile.Process{Command: "./bin/web", Liveness: &procfile.HealthCheck{}}, "web: invalid liveness check: a command is required"},
+		{procfile.Process{Command: "./bin/web", Liveness: &procfile.HealthCheck{Command: "./bin/alive", Interval: "often"}}, `web: invalid liveness check: interval must be a duration, got "often"`},
+	}
+
+	for _, tt := range tests {
+		_, err := formationFromProcfile(procfile.ExtendedProcfile{"web": tt.process})
+		assert.EqualError(t, err, tt.err)
+	}
+}
+
+func TestFormationFromProcfile_Logging(t *testing.T) {
+	f, err := formationFromProcfile(procfile.ExtendedProcfile{
+		"worker": procfile.Process{
+			Command: "./bin/worker",
+			Logging: &procfile.Logging{
+				Driver:  "json-file",
+				Options: map[string]string{"max-size": "10m"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &Logging{
+		Driver:  "json-file",
+		Options: map[string]string{"max-size": "10m"},
+	}, f["worker"].Logging)
+
+	_, err = formationFromProcfile(procfile.ExtendedProcfile{
+		"worker": procfile.Process{
+			Command: "./bin/worker",
+			Logging: &procfile.Logging{Driver: "files"},
+		},
+	})
+	assert.EqualError(t, err, `worker: unsupported log driver "files", must be one of: json-file, journald, syslog, gelf, fluentd, awslogs, splunk, none`)
+}
+
+func TestFormationFromProcfile_Daemon(t *testing.T) {
+	f, err := formationFromProcfile(procfile.ExtendedProcfile{
+		"logs": procfile.Process{
+			Command: "./bin/ship-logs",
+			Daemon:  true,
+		},
+	})
+	assert.NoError(t, err)
+	assert.True(t, f["logs"].Daemon)
+
+	cron := "* * * * *"
+	tests := []struct {
+		process procfile.Process
+		err     string
+	}{
+		{procfile.Process{Command: "./bin/ship-logs", Daemon: true, Cron: &cron}, "logs: daemon processes must be long running"},
+		{procfile.Process{Command: "./bin/ship-logs", Daemon: true, NoService: true}, "logs: daemon processes must be long running"},
+		{procfile.Process{Command: "./bin/ship-logs", Daemon: true, Scale: &procfile.Scale{Min: 1}}, "logs: daemon processes run on every machine, so they can't have scale bounds"},
+		{procfile.Process{Command: "./bin/ship-logs", Daemon: true, Deploy: &procfile.Deploy{}}, "logs: daemon processes are replaced on every machine at once, so they can't have deploy settings"},
+		{procfile.Process{Command: "./bin/ship-logs", Daemon: true, Volumes: []*procfile.Volume{{Name: "data", Type: "ebs", Path: "/data", Size: "10gb"}}}, "logs: daemon processes can't mount ebs volumes"},
+	}
+
+	for _, tt := range tests {
+		_, err := formationFromProcfile(procfile.ExtendedProcfile{"logs": tt.process})
+		assert.EqualError(t, err, tt.err)
+	}
+}
+
+func TestFormationFromProcfile_Singleton(t *testing.T) {
+	f, err := formationFromProcfile(procfile.ExtendedProcfile{
+		"scheduler": procfile.Process{
+			Command:   "./bin/scheduler",
+			Singleton: true,
+		},
+	})
+	assert.NoError(t, err)
+	assert.True(t, f["scheduler"].Singleton)
+
+	tests := []struct {
+		process procfile.Process
+		err     string
+	}{
+		{procfile.Process{Command: "./bin/scheduler", Singleton: true, Daemon: true}, "scheduler: singleton processes can't be daemons"},
+		{procfile.Process{Command: "./bin/scheduler", Singleton: true, NoService: true}, "scheduler: singleton processes must be long running"},
+		{procfile.Process{Command: "./bin/scheduler", Singleton: true, Scale: &procfile.Scale{Max: 2}}, "scheduler: singleton processes can't be scaled above 1"},
+		{procfile.Process{Command: "./bin/scheduler", Singleton: true, Deploy: &procfile.Deploy{}}, "scheduler: singleton processes are stopped before they're replaced, so they can't have deploy settings"},
+	}
+
+	for _, tt := range tests {
+		_, err := formationFromProcfile(procfile.ExtendedProcfile{"scheduler": tt.process})
+		assert.EqualError(t, err, tt.err)
+	}
+}
+
+func TestFormationFromProcfile_Priority(t *testing.T) {
+	f, err := formationFromProcfile(procfile.ExtendedProcfile{
+		"report": procfile.Process{
+			Command:   "./bin/report",
+			NoService: true,
+			Priority:  "low",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, PriorityLow, f["report"].Priority)
+
+	_, err = formationFromProcfile(procfile.ExtendedProcfile{
+		"report": procfile.Process{
+			Command:  "./bin/report",
+			Priority: "urgent",
+		},
+	})
+	assert.EqualError(t, err, `report: unknown priority "urgent", must be one of: high, normal, low`)
+}
+
+func TestFormationFromProcfile_Capacity(t *testing.T) {
+	f, err := formationFromProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{
+			Command:  "./bin/web",
+			Capacity: "on-demand",
+		},
+		"worker": procfile.Process{
+			Command:  "./bin/worker",
+			Capacity: "spot",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, CapacityOnDemand, f["web"].Capacity)
+	assert.Equal(t, CapacitySpot, f["worker"].Capacity)
+
+	_, err = formationFromProcfile(procfile.ExtendedProcfile{
+		"worker": procfile.Process{
+			Command:  "./bin/worker",
+			Capacity: "preemptible",
+		},
+	})
+	assert.EqualError(t, err, `worker: unknown capacity "preemptible", must be one of: spot, on-demand`)
+}
+
+func TestFormationFromProcfile_Placement(t *testing.T) {
+	f, err := formationFromProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{
+			Command:   "./bin/web",
+			Placement: "spread",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, PlacementSpread, f["web"].Placement)
+
+	tests := []struct {
+		process procfile.Process
+		err     string
+	}{
+		{procfile.Process{Command: "./bin/web", Placement: "random"}, `web: unknown placement "random", must be one of: binpack, spread`},
+		{procfile.Process{
+			Command:   "./bin/web",
+			Placement: "spread",
+			ECS: &procfile.ECS{
+				PlacementStrategy: []*ecs.PlacementStrategy{{Type: aws.String("random")}},
+			},
+		}, "web: placement can't be used with ecs placement_strategy"},
+	}
+
+	for _, tt := range tests {
+		_, err := formationFromProcfile(procfile.ExtendedProcfile{"web": tt.process})
+		assert.EqualError(t, err, tt.err)
+	}
+}
+
+func TestFormationFromProcfile_Overlap(t *testing.T) {
+	cron := "0 * * * ? *"
+	f, err := formationFromProcfile(procfile.ExtendedProcfile{
+		"vacuum": procfile.Process{
+			Command: "./bin/vacuum",
+			Cron:    &cron,
+			Overlap: "skip",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, OverlapSkip, f["vacuum"].Overlap)
+
+	_, err = formationFromProcfile(procfile.ExtendedProcfile{
+		"vacuum": procfile.Process{
+			Command: "./bin/vacuum",
+			Cron:    &cron,
+			Overlap: "wait",
+		},
+	})
+	assert.EqualError(t, err, `vacuum: unknown overlap policy "wait", must be one of: allow, skip, queue, replace`)
+
+	_, err = formationFromProcfile(procfile.ExtendedProcfile{
+		"worker": procfile.Process{
+			Command: "./bin/worker",
+			Overlap: "skip",
+		},
+	})
+	assert.EqualError(t, err, `worker: overlap can only be set for scheduled processes`)
+}
+
+func TestFormationFromProcfile_CronTimezone(t *testing.T) {
+	cron := "CRON_TZ=America/New_York 0 9 ? * MON-FRI *"
+	f, err := formationFromProcfile(procfile.ExtendedProcfile{
+		"report": procfile.Process{
+			Command: "./bin/report",
+			Cron:    &cron,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, cron, *f["report"].Cron)
+
+	cron = "CRON_TZ=America/Gotham 0 9 ? * MON-FRI *"
+	_, err = formationFromProcfile(procfile.ExtendedProcfile{
+		"report": procfile.Process{
+			Command: "./bin/report",
+			Cron:    &cron,
+		},
+	})
+	assert.EqualError(t, err, `report: invalid cron expression: unknown time zone "America/Gotham"`)
+}
@@ -0,0 +1,179 @@
+package empire
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ReleaseChanges describes what changed between a release and the release
+// before it.
+type ReleaseChanges struct {
+	// If the image changed, the image of the previous release and the
+	// image of this release.
+	Image *ImageChange
+
+	// The names of config vars that were added, changed or removed. Values
+	// aren't included, since they're often secrets.
+	VarsAdded   []string
+	VarsChanged []string
+	VarsRemoved []string
+
+	// The processes that were added, removed, or had their quantity or
+	// constraints changed.
+	Formation []*ProcessChange
+}
+
+// ImageChange is a change to the image that a release runs.
+type ImageChange struct {
+	// The previous image. Empty for the first release.
+	From string
+
+	// The new image.
+	To string
+}
+
+// ProcessChange is a change to a process in the formation.
+type ProcessChange struct {
+	// The name of the process.
+	Process string
+
+	// The scale of the process before and after. Previous is nil if the
+	// process was added, and Current is nil if it was removed.
+	Previous *ProcessScale
+	Current  *ProcessScale
+}
+
+// ProcessScale is the number of instances of a process, and their
+// constraints.
+type ProcessScale struct {
+	Quantity    int
+	Constraints Constraints
+}
+
+// String returns the quantity and constraints, like 2:1X, or none if the
+// process doesn't exist.
+func (s *ProcessScale) String() string {
+	if s == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%d:%s", s.Quantity, s.Constraints)
+}
+
+// diffReleases returns what changed from prev to r. prev is nil if r is the
+// first release.
+func diffReleases(prev, r *Release) *ReleaseChanges {
+	c := new(ReleaseChanges)
+
+	var prevImage string
+	var prevVars Vars
+	var prevFormation Formation
+	if prev != nil {
+		prevImage = prev.Slug.Image.String()
+		prevVars = prev.Config.Vars
+		prevFormation = prev.Formation
+	}
+
+	if image := r.Slug.Image.String(); image != prevImage {
+		c.Image = &ImageChange{From: prevImage, To: image}
+	}
+
+	vars := r.Config.Vars
+	for k, v := range vars {
+		old, ok := prevVars[k]
+		switch {
+		case !ok:
+			c.VarsAdded = append(c.VarsAdded, string(k))
+		case !stringPtrsEqual(old, v):
+			c.VarsChanged = append(c.VarsChanged, string(k))
+		}
+	}
+	for k := range prevVars {
+		if _, ok := vars[k]; !ok {
+			c.VarsRemoved = append(c.VarsRemoved, string(k))
+		}
+	}
+	sort.Strings(c.VarsAdded)
+	sort.Strings(c.VarsChanged)
+	sort.Strings(c.VarsRemoved)
+
+	for _, name := range formationNames(prevFormation, r.Formation) {
+		previous := processScale(prevFormation, name)
+		current := processScale(r.Formation, name)
+		if previous != nil && current != nil && *previous == *current {
+			continue
+		}
+		c.Formation = append(c.Formation, &ProcessChange{
+			Process:  name,
+			Previous: previous,
+			Current:  current,
+		})
+	}
+
+	return c
+}
+
+// releasesChanges returns what changed in each of the releases. The previous
+// release is taken from rs when it's there, and looked up otherwise.
+func releasesChanges(db *gorm.DB, rs []*Release) ([]*ReleaseChanges, error) {
+	byVersion := make(map[int]*Release)
+	for _, r := range rs {
+		byVersion[r.Version] = r
+	}
+
+	changes := make([]*ReleaseChanges, len(rs))
+	for i, r := range rs {
+		prev, ok := byVersion[r.Version-1]
+		if !ok && r.Version > 1 {
+			version := r.Version - 1
+			var err error
+			prev, err = releasesFind(db, ReleasesQuery{App: r.App, Version: &version})
+			if err == gorm.RecordNotFound {
+				prev, err = nil, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		changes[i] = diffReleases(prev, r)
+	}
+
+	return changes, nil
+}
+
+func processScale(f Formation, name string) *ProcessScale {
+	p, ok := f[name]
+	if !ok {
+		return nil
+	}
+	return &ProcessScale{
+		Quantity:    p.Quantity,
+		Constraints: p.Constraints(),
+	}
+}
+
+// formationNames returns the sorted names of the processes in either
+// formation.
+func formationNames(a, b Formation) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, f := range []Formation{a, b} {
+		for name := range f {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func stringPtrsEqual(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,52 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/pkg/image"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffReleases(t *testing.T) {
+	web1X := Process{Quantity: 1, Memory: Constraints1X.Memory, CPUShare: Constraints1X.CPUShare, Nproc: Constraints1X.Nproc}
+	web2X := Process{Quantity: 2, Memory: Constraints2X.Memory, CPUShare: Constraints2X.CPUShare, Nproc: Constraints2X.Nproc}
+	worker := Process{Quantity: 0, Memory: Constraints1X.Memory, CPUShare: Constraints1X.CPUShare, Nproc: Constraints1X.Nproc}
+
+	foo, bar, baz, qux := "foo", "bar", "baz", "qux"
+
+	v1 := &Release{
+		Version:   1,
+		Slug:      &Slug{Image: image.Image{Repository: "remind101/acme-inc", Tag: "v1"}},
+		Config:    &Config{Vars: Vars{"FOO": &foo, "BAR": &bar}},
+		Formation: Formation{"web": web1X, "worker": worker},
+	}
+	v2 := &Release{
+		Version:   2,
+		Slug:      &Slug{Image: image.Image{Repository: "remind101/acme-inc", Tag: "v2"}},
+		Config:    &Config{Vars: Vars{"FOO": &foo, "BAR": &baz, "QUX": &qux}},
+		Formation: Formation{"web": web2X, "scheduler": worker},
+	}
+
+	assert.Equal(t, &ReleaseChanges{
+		Image:     &ImageChange{From: "", To: "remind101/acme-inc:v1"},
+		VarsAdded: []string{"BAR", "FOO"},
+		Formation: []*ProcessChange{
+			{Process: "web", Current: &ProcessScale{1, Constraints1X}},
+			{Process: "worker", Current: &ProcessScale{0, Constraints1X}},
+		},
+	}, diffReleases(nil, v1))
+
+	assert.Equal(t, &ReleaseChanges{
+		Image:       &ImageChange{From: "remind101/acme-inc:v1", To: "remind101/acme-inc:v2"},
+		VarsAdded:   []string{"QUX"},
+		VarsChanged: []string{"BAR"},
+		Formation: []*ProcessChange{
+			{Process: "scheduler", Current: &ProcessScale{0, Constraints1X}},
+			{Process: "web", Previous: &ProcessScale{1, Constraints1X}, Current: &ProcessScale{2, Constraints2X}},
+			{Process: "worker", Previous: &ProcessScale{0, Constraints1X}},
+		},
+	}, diffReleases(v1, v2))
+
+	// A rollback to the same config and slug doesn't change anything.
+	assert.Equal(t, &ReleaseChanges{}, diffReleases(v1, v1))
+}
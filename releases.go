@@ -5,9 +5,12 @@ import (
 	"time"
 
 	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/cron"
 	"github.com/remind101/empire/pkg/headerutil"
+	"github.com/remind101/empire/pkg/image"
 	"github.com/remind101/empire/pkg/timex"
 	"github.com/remind101/empire/procfile"
+	"github.com/remind101/empire/trace"
 	"github.com/remind101/empire/twelvefactor"
 	"golang.org/x/net/context"
 )
@@ -32,7 +35,10 @@ type Release struct {
 	// The id of the config that this release uses.
 	ConfigID string
 
-	// The config that this release uses.
+	// The config that this release uses. Configs are never updated after
+	// they're created (changing config vars creates a new Config, and a new
+	// release), so this is a snapshot of the environment at the time the
+	// release was created, which is used whenever the release is scheduled.
 	Config *Config
 
 	// The id of the slug that this release uses.
@@ -44,14 +50,36 @@ type Release struct {
 	// The process formation to use.
 	Formation Formation
 
+	// The version of the formation, which is incremented each time that
+	// it's scaled. It's used to detect concurrent changes to the
+	// formation.
+	FormationVersion int
+
 	// A description for the release. Usually contains the reason for why
 	// the release was created (e.g. deployment, config changes, etc).
 	Description string
 
+	// The name of the user that created the release.
+	CreatedBy string
+
+	// What created the release. One of the ReleaseSource constants.
+	Source string
+
+	// The message that the user provided when creating the release, if
+	// any.
+	Message string
+
 	// The time that this release was created.
 	CreatedAt *time.Time
 }
 
+// The sources that a release can be created from.
+const (
+	ReleaseSourceDeploy   = "deploy"
+	ReleaseSourceRollback = "rollback"
+	ReleaseSourceConfig   = "config"
+)
+
 // Procfile returns the Procfile that generated this Release.
 func (r *Release) Procfile() (procfile.Procfile, error) {
 	return r.Slug.ParsedProcfile()
@@ -109,21 +137,21 @@ type releasesService struct {
 	*Empire
 }
 
-// CreateAndRelease creates a new release then submits it to the scheduler.
-func (s *releasesService) CreateAndRelease(ctx context.Context, db *gorm.DB, r *Release, ss twelvefactor.StatusStream) (*Release, error) {
-	r, err := s.Create(ctx, db, r)
-	if err != nil {
-		return r, err
-	}
-	// Schedule the new release onto the cluster.
-	return r, s.Release(ctx, r, ss)
-}
-
 // Create creates a new release.
 func (s *releasesService) Create(ctx context.Context, db *gorm.DB, r *Release) (*Release, error) {
+	ctx, span := startSpan(ctx, "release.create", r.App)
+	r, err := s.create(ctx, db, r)
+	span.Finish(err)
+	return r, err
+}
+
+func (s *releasesService) create(ctx context.Context, db *gorm.DB, r *Release) (*Release, error) {
 	// Lock all releases for the given application to ensure that the
 	// release version is updated automically.
-	if err := db.Exec(`select 1 from releases where app_id = ? for update`, r.App.ID).Error; err != nil {
+	_, span := startSpan(ctx, "db.releases.lock", r.App)
+	err := db.Exec(`select 1 from releases where app_id = ? for update`, r.App.ID).Error
+	span.Finish(err)
+	if err != nil {
 		return r, err
 	}
 
@@ -132,12 +160,31 @@ func (s *releasesService) Create(ctx context.Context, db *gorm.DB, r *Release) (
 	// merging the formation from the extracted Procfile, and the Formation
 	// from the existing release.
 	if r.Formation == nil {
-		if err := buildFormation(db, r); err != nil {
+		_, span := startSpan(ctx, "db.formation.build", r.App)
+		err := buildFormation(db, r)
+		span.Finish(err)
+		if err != nil {
 			return r, err
 		}
 	}
 
-	return releasesCreate(db, r)
+	// Fail before the release is created, rather than when it's
+	// scheduled, if its config vars don't satisfy the rules of the app.
+	if err := checkConfigRules(r); err != nil {
+		return r, err
+	}
+
+	_, span = startSpan(ctx, "db.formation.update", r.App)
+	err = appsUpdateFormation(db, r.App, r.Formation)
+	span.Finish(err)
+	if err != nil {
+		return r, err
+	}
+
+	_, span = startSpan(ctx, "db.releases.create", r.App)
+	r, err = releasesCreate(db, r)
+	span.Finish(err)
+	return r, err
 }
 
 // Rolls back to a specific release version.
@@ -148,24 +195,157 @@ func (s *releasesService) Rollback(ctx context.Context, db *gorm.DB, opts Rollba
 		return nil, err
 	}
 
+	// Only the process types and commands are taken from the release
+	// that's being rolled back to. The processes keep their current
+	// scale, so a rollback doesn't undo scale changes made since.
+	scale, err := currentScale(db, app)
+	if err != nil {
+		return nil, err
+	}
+	for name, p := range r.Formation {
+		if _, ok := scale[name]; !ok {
+			scale[name] = p
+		}
+	}
+
 	desc := fmt.Sprintf("Rollback to v%d", version)
 	desc = appendMessageToDescription(desc, opts.User, opts.Message)
-	return s.CreateAndRelease(ctx, db, &Release{
+	r, err = s.Create(ctx, db, &Release{
 		App:         app,
 		Config:      r.Config,
 		Slug:        r.Slug,
-		Formation:   r.Formation,
+		Formation:   r.Formation.Merge(scale),
 		Description: desc,
-	}, nil)
+		CreatedBy:   opts.User.Name,
+		Source:      ReleaseSourceRollback,
+		Message:     opts.Message,
+	})
+	if err != nil {
+		return r, err
+	}
+
+	if err := s.admit(ctx, &AdmissionRequest{
+		Operation:      AdmissionRollback,
+		User:           opts.User,
+		Release:        r,
+		FreezeOverride: opts.FreezeOverride,
+	}); err != nil {
+		return r, err
+	}
+
+	return r, s.Release(ctx, r, nil)
 }
 
 // Release submits a release to the scheduler.
 func (s *releasesService) Release(ctx context.Context, release *Release, ss twelvefactor.StatusStream) error {
-	a, err := newSchedulerApp(release)
+	ctx, span := startSpan(ctx, "release", release.App)
+	err := s.release(ctx, release, ss)
+	span.Finish(err)
+	return err
+}
+
+func (s *releasesService) release(ctx context.Context, release *Release, ss twelvefactor.StatusStream) error {
+	scheduler, err := s.scheduler(release.App)
+	if err != nil {
+		return err
+	}
+	pctx, span := startSpan(ctx, "release.prepare", release.App)
+	a, err := s.manifest(pctx, scheduler, release)
+	span.Finish(err)
+	if err != nil {
+		return err
+	}
+
+	sctx, span := startSpan(ctx, "release.submit", release.App)
+	err = scheduler.Submit(sctx, a, ss)
+	span.Finish(err)
 	if err != nil {
 		return err
 	}
-	return s.Scheduler.Submit(ctx, a, ss)
+
+	_, span = startSpan(ctx, "db.usage.record", release.App)
+	err = recordUsage(s.db, release.App, formationUsage(release.Formation))
+	span.Finish(err)
+	return err
+}
+
+// manifest returns the manifest that's submitted to the scheduler for the
+// release. The scheduler is used to expand daemons, and place processes on
+// the capacity that's available, but nothing is submitted to it.
+func (s *releasesService) manifest(ctx context.Context, scheduler Scheduler, release *Release) (*twelvefactor.Manifest, error) {
+	a, err := s.schedulerApp(ctx, release)
+	if err != nil {
+		return nil, err
+	}
+	if w := release.App.PreviousReleaseWeight; w > 0 {
+		a.Previous, err = s.previousSchedulerApp(ctx, release)
+		if err != nil {
+			return nil, err
+		}
+		a.PreviousWeight = w
+	}
+	if err := expandDaemons(ctx, scheduler, a); err != nil {
+		return nil, err
+	}
+	if err := placeCapacity(ctx, scheduler, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// verifyImage verifies the signature of the image of the release, when an
+// ImageVerifier is configured.
+func (s *releasesService) verifyImage(ctx context.Context, release *Release) error {
+	if s.ImageVerifier == nil {
+		return nil
+	}
+	img := release.Slug.Image
+	if err := s.ImageVerifier.VerifyImage(ctx, img); err != nil {
+		return &ImageVerificationError{Image: img, Err: err}
+	}
+	return nil
+}
+
+// schedulerApp returns the manifest for a single release, after verifying its
+// image.
+func (s *releasesService) schedulerApp(ctx context.Context, release *Release) (*twelvefactor.Manifest, error) {
+	if err := s.verifyImage(ctx, release); err != nil {
+		return nil, err
+	}
+	a, err := newSchedulerApp(release)
+	if err != nil {
+		return nil, err
+	}
+	a.PullSecrets, err = appPullSecrets(s.db, release.App)
+	if err != nil {
+		return nil, err
+	}
+	a.Ingress, err = appIngress(s.db, release.App)
+	if err != nil {
+		return nil, err
+	}
+	a.Routes, err = appRoutes(s.db, release.App)
+	if err != nil {
+		return nil, err
+	}
+	injectMesh(s.Mesh, a, release.Formation)
+	injectMetadata(s.Metadata, release.App, a)
+	if err := injectIdentity(s.Identity, release.App, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// previousSchedulerApp returns the manifest for the release before the given
+// release, which receives a share of the traffic to the app. It's built the
+// same way as the manifest of the release itself, so the previous release
+// keeps its ingress and routes, and its image is verified too.
+func (s *releasesService) previousSchedulerApp(ctx context.Context, release *Release) (*twelvefactor.Manifest, error) {
+	previous, err := previousRelease(s.db, release)
+	if err != nil {
+		return nil, err
+	}
+	return s.schedulerApp(ctx, previous)
 }
 
 func (s *releasesService) ReleaseApp(ctx context.Context, db *gorm.DB, app *App, ss twelvefactor.StatusStream) error {
@@ -188,7 +368,11 @@ func (s *releasesService) ReleaseApp(ctx context.Context, db *gorm.DB, app *App,
 // Restart will find the last release for an app and submit it to the scheduler
 // to restart the app.
 func (s *releasesService) Restart(ctx context.Context, db *gorm.DB, app *App) error {
-	return s.Scheduler.Restart(ctx, app.ID, nil)
+	scheduler, err := s.scheduler(app)
+	if err != nil {
+		return err
+	}
+	return scheduler.Restart(ctx, app.ID, nil)
 }
 
 // These associations are always available on a Release.
@@ -213,30 +397,115 @@ func releases(db *gorm.DB, scope scope) ([]*Release, error) {
 	return releases, find(db, scope, &releases)
 }
 
-func releasesUpdate(db *gorm.DB, release *Release) error {
-	return db.Save(release).Error
+// FormationConflictError is returned when scaling a formation that was changed
+// by something else since it was read.
+type FormationConflictError struct {
+	App *App
+
+	// The version of the formation that was read.
+	Version int
+}
+
+func (e *FormationConflictError) Error() string {
+	return fmt.Sprintf("the formation of %s was changed since version %d was read; get the current formation, and try again", e.App.Name, e.Version)
+}
+
+// releasesUpdateFormation saves the formation of a release, only if it hasn't
+// been changed since the release was read, and increments its version.
+// Concurrent scales can't overwrite each other's changes; all but one of them
+// fails with a FormationConflictError.
+func releasesUpdateFormation(db *gorm.DB, release *Release) error {
+	result := db.Exec(`update releases set formation = ?, formation_version = formation_version + 1 where id = ? and formation_version = ?`, release.Formation, release.ID, release.FormationVersion)
+	if err := result.Error; err != nil {
+		return err
+	}
+
+	if result.RowsAffected == 0 {
+		return &FormationConflictError{App: release.App, Version: release.FormationVersion}
+	}
+
+	release.FormationVersion++
+	return nil
+}
+
+// releasesUpdateDescription saves the description of a release.
+func releasesUpdateDescription(db *gorm.DB, release *Release) error {
+	return db.Exec(`update releases set description = ? where id = ?`, release.Description, release.ID).Error
 }
 
 func buildFormation(db *gorm.DB, release *Release) error {
-	var existing Formation
+	existing, err := currentScale(db, release.App)
+	if err != nil {
+		return err
+	}
 
-	// Get the old release, so we can copy the Formation.
-	last, err := releasesFind(db, ReleasesQuery{App: release.App})
+	f, err := release.Slug.Formation()
+	if err != nil {
+		return err
+	}
+	release.Formation = f.Merge(existing)
+
+	return nil
+}
+
+// currentScale returns the current scale of each process of the app.
+func currentScale(db *gorm.DB, app *App) (Formation, error) {
+	// The scale that's persisted on the app takes precedence, since it
+	// also includes processes that aren't in the last release.
+	existing, err := appsFormation(db, app)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the old release, so we can copy the Formation of any processes
+	// that the app doesn't have a scale for yet.
+	last, err := releasesFind(db, ReleasesQuery{App: app})
 	if err != nil {
 		if err != gorm.RecordNotFound {
-			return err
+			return nil, err
 		}
 	} else {
-		existing = last.Formation
+		for name, p := range last.Formation {
+			if _, ok := existing[name]; !ok {
+				existing[name] = p
+			}
+		}
 	}
 
-	f, err := release.Slug.Formation()
+	return existing, nil
+}
+
+// appsFormation returns the scale that's persisted for each process of the
+// app.
+func appsFormation(db *gorm.DB, app *App) (Formation, error) {
+	var raw []byte
+	if err := db.Raw(`select formation from apps where id = ?`, app.ID).Row().Scan(&raw); err != nil {
+		return nil, err
+	}
+
+	f := make(Formation)
+	if raw == nil {
+		return f, nil
+	}
+	return f, f.Scan(raw)
+}
+
+// appsUpdateFormation persists the quantity, constraints and args of the
+// processes in the given Formation on the app. The scale of processes that aren't in
+// the Formation is kept, so it can be restored if they're added back.
+func appsUpdateFormation(db *gorm.DB, app *App, f Formation) error {
+	existing, err := appsFormation(db, app)
 	if err != nil {
 		return err
 	}
-	release.Formation = f.Merge(existing)
 
-	return nil
+	for name, p := range f {
+		scale := Process{Quantity: p.Quantity, Args: p.Args}
+		scale.SetConstraints(p.Constraints())
+		existing[name] = scale
+	}
+
+	return db.Exec(`update apps set formation = ? where id = ?`, existing, app.ID).Error
 }
 
 // currentFormations gets the current formations for an app
@@ -278,6 +547,7 @@ func releasesCreate(db *gorm.DB, release *Release) (*Release, error) {
 
 	// Increment the release version.
 	release.Version = v + 1
+	release.FormationVersion = 1
 
 	if err := db.Create(release).Error; err != nil {
 		return release, err
@@ -302,6 +572,14 @@ func newSchedulerApp(release *Release) (*twelvefactor.Manifest, error) {
 			continue
 		}
 
+		// Processes that are scheduled in a time zone are triggered by
+		// the CronMonitor, instead of the backend.
+		if s, err := cronSchedule(release.App, p); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		} else if s != nil {
+			continue
+		}
+
 		process, err := newSchedulerProcess(release, name, p)
 		if err != nil {
 			return nil, err
@@ -309,16 +587,22 @@ func newSchedulerApp(release *Release) (*twelvefactor.Manifest, error) {
 		processes = append(processes, process)
 	}
 
-	env := environment(release.Config.Vars)
-	env["EMPIRE_APPID"] = release.App.ID
-	env["EMPIRE_APPNAME"] = release.App.Name
-	env["EMPIRE_RELEASE"] = fmt.Sprintf("v%d", release.Version)
+	env, err := environment(release.Config.Vars, releaseAttributes(release))
+	if err != nil {
+		return nil, err
+	}
 
 	labels := map[string]string{
 		"empire.app.id":      release.App.ID,
 		"empire.app.name":    release.App.Name,
 		"empire.app.release": fmt.Sprintf("v%d", release.Version),
 	}
+	if release.App.Team != "" {
+		labels["empire.app.team"] = release.App.Team
+	}
+	for k, v := range release.App.Labels {
+		labels[appLabelPrefix+k] = v
+	}
 
 	return &twelvefactor.Manifest{
 		AppID:     release.App.ID,
@@ -343,6 +627,9 @@ func newSchedulerProcess(release *Release, name string, p Process) (*twelvefacto
 	labels := map[string]string{
 		"empire.app.process": name,
 	}
+	if p.Priority != "" {
+		labels[priorityLabel] = p.Priority
+	}
 
 	var (
 		exposure *twelvefactor.Exposure
@@ -359,6 +646,9 @@ func newSchedulerProcess(release *Release, name string, p Process) (*twelvefacto
 		if err != nil {
 			return nil, err
 		}
+		if exposure != nil && p.DrainTimeout != nil {
+			exposure.DrainTimeout = p.DrainTimeout
+		}
 	}
 
 	quantity := p.Quantity
@@ -366,31 +656,72 @@ func newSchedulerProcess(release *Release, name string, p Process) (*twelvefacto
 		quantity = 0
 	}
 
+	var sidecars []*twelvefactor.Sidecar
+	for _, s := range p.Sidecars {
+		img, err := image.Decode(s.Image)
+		if err != nil {
+			return nil, err
+		}
+
+		sidecars = append(sidecars, &twelvefactor.Sidecar{
+			Name:      s.Name,
+			Image:     img,
+			Command:   []string(s.Command),
+			Env:       s.Environment,
+			Memory:    uint(s.Memory),
+			Essential: s.Essential,
+		})
+	}
+
+	var volumes []*twelvefactor.Volume
+	for _, v := range p.Volumes {
+		volumes = append(volumes, &twelvefactor.Volume{
+			Name:     v.Name,
+			Type:     v.Type,
+			Source:   v.Source,
+			Path:     v.Path,
+			Size:     uint(v.Size),
+			ReadOnly: v.ReadOnly,
+		})
+	}
+
 	return &twelvefactor.Process{
 		Type:      name,
 		Env:       env,
 		Labels:    labels,
-		Command:   []string(p.Command),
+		Command:   []string(p.CommandWithArgs()),
 		Image:     release.Slug.Image,
 		Quantity:  quantity,
 		Memory:    uint(p.Memory),
 		CPUShares: uint(p.CPUShare),
 		Nproc:     uint(p.Nproc),
+		GPUs:      uint(p.GPU),
 		Exposure:  exposure,
 		Schedule:  processSchedule(name, p),
 		ECS:       p.ECS,
+		Sidecars:  sidecars,
+		Volumes:   volumes,
+		Security:  p.Security,
+		Rollout:   rollout(p.Rollout),
+		Readiness: healthCheck(p.Readiness),
+		Liveness:  healthCheck(p.Liveness),
+		Logging:   logging(p.Logging),
+		Daemon:    p.Daemon,
+		Singleton: p.Singleton,
+		Overlap:   p.Overlap,
+		Capacity:  p.Capacity,
+		Placement: p.Placement,
 	}, nil
 }
 
-// environment coerces a Vars into a map[string]string.
-func environment(vars Vars) map[string]string {
-	env := make(map[string]string)
-
-	for k, v := range vars {
-		env[string(k)] = string(*v)
+// releaseAttributes returns the attributes of the app and release that are
+// added to the environment, and can be referenced by config vars.
+func releaseAttributes(release *Release) map[string]string {
+	return map[string]string{
+		"EMPIRE_APPID":   release.App.ID,
+		"EMPIRE_APPNAME": release.App.Name,
+		"EMPIRE_RELEASE": fmt.Sprintf("v%d", release.Version),
 	}
-
-	return env
 }
 
 // standardWebExposure generates a scheduler.Exposure for a web process in the
@@ -415,10 +746,7 @@ func standardWebExposure(app *App) *twelvefactor.Exposure {
 		})
 	}
 
-	return &twelvefactor.Exposure{
-		External: app.Exposure == exposePublic,
-		Ports:    ports,
-	}
+	return newExposure(app, ports)
 }
 
 func processExposure(app *App, name string, process Process) (*twelvefactor.Exposure, error) {
@@ -458,15 +786,78 @@ func processExposure(app *App, name string, process Process) (*twelvefactor.Expo
 			Protocol:  protocol,
 		})
 	}
+	return newExposure(app, ports), nil
+}
+
+// newExposure returns a scheduler.Exposure for the ports, with the router
+// settings of the app.
+func newExposure(app *App, ports []twelvefactor.Port) *twelvefactor.Exposure {
 	return &twelvefactor.Exposure{
-		External: app.Exposure == exposePublic,
-		Ports:    ports,
-	}, nil
+		External:        app.Exposure == exposePublic,
+		Ports:           ports,
+		IdleTimeout:     app.RouterSettings.IdleTimeout,
+		DrainTimeout:    app.RouterSettings.DrainTimeout,
+		WebSockets:      app.RouterSettings.WebSockets,
+		StickySessions:  app.RouterSettings.StickySessions,
+		ProtocolVersion: app.RouterSettings.ProtocolVersion,
+		AllowedCIDRs:    app.RouterSettings.AllowedCIDRs,
+		BasicAuth:       basicAuth(app.RouterSettings.BasicAuth),
+	}
+}
+
+// rollout converts a Rollout to a twelvefactor.Rollout.
+func rollout(r *Rollout) *twelvefactor.Rollout {
+	if r == nil {
+		return nil
+	}
+	return &twelvefactor.Rollout{
+		MaxSurge:       r.MaxSurge,
+		MaxUnavailable: r.MaxUnavailable,
+	}
+}
+
+// healthCheck converts a HealthCheck to a twelvefactor.HealthCheck.
+func healthCheck(c *HealthCheck) *twelvefactor.HealthCheck {
+	if c == nil {
+		return nil
+	}
+	return &twelvefactor.HealthCheck{
+		Path:               c.Path,
+		Command:            []string(c.Command),
+		Interval:           c.Interval,
+		Timeout:            c.Timeout,
+		HealthyThreshold:   c.HealthyThreshold,
+		UnhealthyThreshold: c.UnhealthyThreshold,
+		StartPeriod:        c.StartPeriod,
+	}
+}
+
+// logging converts a Logging to a twelvefactor.Logging.
+func logging(l *Logging) *twelvefactor.Logging {
+	if l == nil {
+		return nil
+	}
+	return &twelvefactor.Logging{
+		Driver:  l.Driver,
+		Options: l.Options,
+	}
+}
+
+// basicAuth converts a BasicAuth to a twelvefactor.BasicAuth.
+func basicAuth(a *BasicAuth) *twelvefactor.BasicAuth {
+	if a == nil {
+		return nil
+	}
+	return &twelvefactor.BasicAuth{
+		Username: a.Username,
+		Password: a.Password,
+	}
 }
 
 func processSchedule(name string, p Process) twelvefactor.Schedule {
 	if p.Cron != nil {
-		return twelvefactor.CRONSchedule(*p.Cron)
+		_, expr := cron.SplitTimezone(*p.Cron)
+		return twelvefactor.CRONSchedule(expr)
 	}
 
 	return nil
@@ -479,3 +870,18 @@ func appendMessageToDescription(main string, user *User, message string) string
 	}
 	return fmt.Sprintf("%s (%s%s)", main, user.Name, formatted)
 }
+
+// startSpan starts a span for a step of a deploy or release of an app, so that
+// slow deploys can be attributed to the database, the registry, or the
+// scheduler.
+func startSpan(ctx context.Context, name string, app *App) (context.Context, *trace.Span) {
+	return trace.Start(ctx, name, appTags(app))
+}
+
+// appTags returns the tags that the spans of an app are tagged with.
+func appTags(app *App) []string {
+	if app == nil {
+		return nil
+	}
+	return []string{fmt.Sprintf("app:%s", app.Name)}
+}
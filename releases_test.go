@@ -1,9 +1,11 @@
 package empire
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/remind101/empire/pkg/headerutil"
+	"github.com/remind101/empire/twelvefactor"
 )
 
 func TestReleasesQuery(t *testing.T) {
@@ -31,3 +33,72 @@ func TestReleasesQuery(t *testing.T) {
 
 	tests.Run(t)
 }
+
+func TestNewSchedulerApp_Labels(t *testing.T) {
+	release := &Release{
+		Version: 2,
+		App:     &App{ID: "1234", Name: "acme-inc", Team: "platform", Labels: Labels{"tier": "web"}},
+		Config:  &Config{Vars: Vars{}},
+		Slug:    &Slug{},
+		Formation: Formation{
+			"web": Process{Quantity: 1},
+		},
+	}
+
+	a, err := newSchedulerApp(release)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"empire.app.id":         "1234",
+		"empire.app.name":       "acme-inc",
+		"empire.app.release":    "v2",
+		"empire.app.team":       "platform",
+		"empire.app.label.tier": "web",
+	}
+	if !reflect.DeepEqual(a.Labels, expected) {
+		t.Fatalf("Labels => %v; want %v", a.Labels, expected)
+	}
+
+	if got, want := a.Processes[0].Labels["empire.app.process"], "web"; got != want {
+		t.Fatalf("process label => %q; want %q", got, want)
+	}
+}
+
+func TestNewSchedulerApp_CronTimezone(t *testing.T) {
+	utc := "0 9 ? * MON-FRI *"
+	newYork := "CRON_TZ=America/New_York 0 9 ? * MON-FRI *"
+	release := &Release{
+		Version: 2,
+		App:     &App{ID: "1234", Name: "acme-inc"},
+		Config:  &Config{Vars: Vars{}},
+		Slug:    &Slug{},
+		Formation: Formation{
+			"report": Process{Quantity: 1, Cron: &utc},
+			"digest": Process{Quantity: 1, Cron: &newYork},
+		},
+	}
+
+	a, err := newSchedulerApp(release)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Processes that are scheduled in a time zone are triggered by Empire.
+	if got, want := len(a.Processes), 1; got != want {
+		t.Fatalf("len(Processes) => %d; want %d", got, want)
+	}
+	if got, want := a.Processes[0].Schedule, twelvefactor.CRONSchedule(utc); got != want {
+		t.Fatalf("Schedule => %v; want %v", got, want)
+	}
+
+	release.App.CronTimezone = "America/New_York"
+	a, err = newSchedulerApp(release)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(a.Processes), 0; got != want {
+		t.Fatalf("len(Processes) => %d; want %d", got, want)
+	}
+}
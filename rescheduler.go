@@ -0,0 +1,144 @@
+package empire
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/remind101/pkg/reporter"
+	"golang.org/x/net/context"
+)
+
+// Rescheduler periodically looks for processes that are running on hosts that
+// the scheduler has lost contact with, and stops them so that the scheduler
+// replaces them on healthy hosts. Without this, an app can stay
+// under-provisioned until its next deploy. When Empire has a MaxZoneSkew, it
+// also rebalances processes whose instances are skewed towards an
+// availability zone. It also records when processes have recovered from
+// instances killed by the ChaosMonkey.
+type Rescheduler struct {
+	*Empire
+
+	// How often to look for processes on lost hosts.
+	Interval time.Duration
+}
+
+// Start starts looking for processes on lost hosts, until the context is
+// canceled. Errors, and panics, are reported to the reporter in the context.
+func (r *Rescheduler) Start(ctx context.Context) {
+	defer reporter.Monitor(ctx)
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Reschedule(ctx); err != nil {
+				reporter.Report(ctx, err)
+			}
+		}
+	}
+}
+
+type rescheduleService struct {
+	*Empire
+}
+
+// Reschedule stops all of the processes that are running on lost hosts, and
+// returns the processes that were stopped. A RescheduleEvent is published for
+// each one, and a ReconcileFailedEvent for each one that couldn't be stopped.
+// When MaxZoneSkew is set, processes stopped to rebalance availability zones
+// are also returned.
+func (s *rescheduleService) Reschedule(ctx context.Context) ([]*Task, error) {
+	apps, err := apps(s.db, AppsQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		rescheduled []*Task
+		errors      []error
+
+		// The zone weights of each cluster, keyed by cluster name.
+		weights = make(map[string]map[string]float64)
+	)
+	for _, app := range apps {
+		scheduler, err := s.scheduler(app)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		tasks, err := s.tasks.appTasks(ctx, app)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		for _, t := range tasks {
+			if !t.Host.Lost || t.State == "STOPPED" {
+				continue
+			}
+
+			if err := unscheduleTask(s.db, app, t, fmt.Sprintf("host %s was lost", t.Host.ID)); err != nil {
+				errors = append(errors, err)
+			}
+
+			if err := scheduler.Stop(ctx, t.ID); err != nil {
+				errors = append(errors, err)
+				if err := s.PublishEvent(ReconcileFailedEvent{
+					App:    app.Name,
+					Reason: fmt.Sprintf("stopping %s on lost host %s: %v", t.Name, t.Host.ID, err),
+					app:    app,
+				}); err != nil {
+					errors = append(errors, err)
+				}
+				continue
+			}
+
+			rescheduled = append(rescheduled, t)
+
+			if err := s.PublishEvent(RescheduleEvent{
+				App:  app.Name,
+				PID:  t.Name,
+				Host: t.Host.ID,
+				app:  app,
+			}); err != nil {
+				errors = append(errors, err)
+			}
+		}
+
+		if err := s.recordChaosRecoveries(ctx, app, tasks); err != nil {
+			errors = append(errors, err)
+		}
+
+		if s.MaxZoneSkew <= 0 {
+			continue
+		}
+
+		w, ok := weights[app.Cluster]
+		if !ok {
+			ms, err := machines(ctx, scheduler)
+			if err != nil {
+				errors = append(errors, err)
+				continue
+			}
+			w = zoneWeights(ms)
+			weights[app.Cluster] = w
+		}
+
+		rebalanced, err := s.rebalanceZones(ctx, scheduler, app, tasks, w)
+		rescheduled = append(rescheduled, rebalanced...)
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return rescheduled, &multiError{Errors: errors}
+	}
+
+	return rescheduled, nil
+}
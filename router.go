@@ -0,0 +1,192 @@
+package empire
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/remind101/empire/twelvefactor"
+	"golang.org/x/net/context"
+)
+
+// MaxIdleTimeout is the longest idle timeout that can be set on the router.
+const MaxIdleTimeout = time.Hour
+
+// MaxDrainTimeout is the longest drain timeout that can be set on the router,
+// which is also the longest that the load balancers will wait for in flight
+// requests to finish.
+const MaxDrainTimeout = time.Hour
+
+// ErrIdleTimeout is returned when the idle timeout of the router isn't valid.
+var ErrIdleTimeout = &ValidationError{
+	errors.New("The idle timeout must be between 1 second and 1 hour."),
+}
+
+// ErrDrainTimeout is returned when the drain timeout of the router isn't
+// valid.
+var ErrDrainTimeout = &ValidationError{
+	errors.New("The drain timeout must be between 0 seconds and 1 hour."),
+}
+
+// ErrProtocolVersion is returned when the protocol version of the router isn't
+// valid.
+var ErrProtocolVersion = &ValidationError{
+	errors.New("The protocol version must be one of http1, http2 or grpc."),
+}
+
+// ErrAllowedCIDR is returned when an entry in the IP allowlist of the router
+// isn't a valid CIDR block.
+var ErrAllowedCIDR = &ValidationError{
+	errors.New("The allowed IP ranges must be CIDR blocks (e.g. 203.0.113.0/24)."),
+}
+
+// ErrBasicAuth is returned when the basic auth credentials of the router
+// aren't valid.
+var ErrBasicAuth = &ValidationError{
+	errors.New("Basic auth needs a username without a colon, and a password."),
+}
+
+// RouterSettings controls how the router (the load balancers of an app)
+// handles connections to the exposed processes of an app. The zero value
+// uses the defaults of the router.
+type RouterSettings struct {
+	// How long a connection can be idle before the router closes it. The
+	// zero value uses the default of the router (60 seconds with ECS).
+	IdleTimeout time.Duration `json:"IdleTimeout,omitempty"`
+
+	// How long the router keeps sending in flight requests to the old
+	// instances of a process, after they're replaced by a new release,
+	// before it stops them. Nil uses the default of the router (30
+	// seconds with ECS), 0 stops them immediately, and MaxDrainTimeout
+	// waits for as long as the router can for connections to drain. Can
+	// be overridden for a process in the Procfile.
+	DrainTimeout *time.Duration `json:"DrainTimeout,omitempty"`
+
+	// When true, connections can be upgraded to WebSockets.
+	WebSockets bool `json:"WebSockets,omitempty"`
+
+	// When true, the router sets a cookie to send requests from the same
+	// client to the same instance of a process.
+	StickySessions bool `json:"StickySessions,omitempty"`
+
+	// The version of HTTP that the router uses to send requests to the
+	// process. "http2" sends requests with h2c (HTTP/2 without TLS), and
+	// "grpc" does the same, but also health checks the process with the
+	// gRPC health checking protocol. The zero value is "http1".
+	ProtocolVersion string `json:"ProtocolVersion,omitempty"`
+
+	// If not empty, the router only accepts connections from these CIDR
+	// blocks.
+	AllowedCIDRs []string `json:"AllowedCIDRs,omitempty"`
+
+	// If not nil, the router rejects requests that don't have these
+	// credentials.
+	BasicAuth *BasicAuth `json:"BasicAuth,omitempty"`
+}
+
+// BasicAuth is a set of HTTP basic authentication credentials.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// IsValid returns an error if the settings aren't valid.
+func (s *RouterSettings) IsValid() error {
+	if s.IdleTimeout != 0 && (s.IdleTimeout < time.Second || s.IdleTimeout > MaxIdleTimeout) {
+		return ErrIdleTimeout
+	}
+	if d := s.DrainTimeout; d != nil && !validDrainTimeout(*d) {
+		return ErrDrainTimeout
+	}
+	switch s.ProtocolVersion {
+	case "", twelvefactor.ProtocolVersionHTTP1, twelvefactor.ProtocolVersionHTTP2, twelvefactor.ProtocolVersionGRPC:
+	default:
+		return ErrProtocolVersion
+	}
+	for _, cidr := range s.AllowedCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return ErrAllowedCIDR
+		}
+	}
+	if a := s.BasicAuth; a != nil {
+		if a.Username == "" || strings.Contains(a.Username, ":") || a.Password == "" {
+			return ErrBasicAuth
+		}
+	}
+	return nil
+}
+
+// validDrainTimeout returns true if d can be used as a drain timeout.
+func validDrainTimeout(d time.Duration) bool {
+	return d >= 0 && d <= MaxDrainTimeout
+}
+
+// Scan implements the sql.Scanner interface.
+func (s *RouterSettings) Scan(src interface{}) error {
+	if src == nil {
+		return nil
+	}
+
+	bytes, ok := src.([]byte)
+	if !ok {
+		return error(errors.New("Scan source was not []bytes"))
+	}
+
+	return json.Unmarshal(bytes, s)
+}
+
+// Value implements the driver.Value interface.
+func (s RouterSettings) Value() (driver.Value, error) {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.Value(raw), nil
+}
+
+// SetRouterSettingsOpts are options provided when changing the router settings
+// of an app.
+type SetRouterSettingsOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// The new settings.
+	Settings RouterSettings
+}
+
+// SetRouterSettings changes the router settings of an app, and releases it so
+// that the settings are applied to its load balancers.
+func (e *Empire) SetRouterSettings(ctx context.Context, opts SetRouterSettingsOpts) error {
+	if err := e.authorize(opts.User, opts.App, ActionAdmin); err != nil {
+		return err
+	}
+
+	if err := opts.Settings.IsValid(); err != nil {
+		return err
+	}
+
+	tx := e.db.Begin()
+
+	app := opts.App
+
+	app.RouterSettings = opts.Settings
+
+	if err := appsUpdate(tx, app); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := e.releases.ReleaseApp(ctx, tx, app, nil); err != nil && err != ErrNoReleases {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
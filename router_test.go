@@ -0,0 +1,46 @@
+package empire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterSettings_IsValid(t *testing.T) {
+	zero, drain, tooLong := time.Duration(0), MaxDrainTimeout, 2*time.Hour
+	tests := []struct {
+		settings RouterSettings
+		err      error
+	}{
+		{RouterSettings{}, nil},
+		{RouterSettings{IdleTimeout: time.Second}, nil},
+		{RouterSettings{IdleTimeout: time.Hour, WebSockets: true, StickySessions: true}, nil},
+		{RouterSettings{IdleTimeout: 500 * time.Millisecond}, ErrIdleTimeout},
+		{RouterSettings{IdleTimeout: -time.Second}, ErrIdleTimeout},
+		{RouterSettings{IdleTimeout: 2 * time.Hour}, ErrIdleTimeout},
+		{RouterSettings{DrainTimeout: &zero}, nil},
+		{RouterSettings{DrainTimeout: &drain}, nil},
+		{RouterSettings{DrainTimeout: &tooLong}, ErrDrainTimeout},
+		{RouterSettings{ProtocolVersion: "grpc"}, nil},
+		{RouterSettings{ProtocolVersion: "http3"}, ErrProtocolVersion},
+		{RouterSettings{AllowedCIDRs: []string{"203.0.113.0/24", "2001:db8::/32"}}, nil},
+		{RouterSettings{AllowedCIDRs: []string{"203.0.113.1"}}, ErrAllowedCIDR},
+		{RouterSettings{BasicAuth: &BasicAuth{Username: "staging", Password: "secret"}}, nil},
+		{RouterSettings{BasicAuth: &BasicAuth{Username: "staging"}}, ErrBasicAuth},
+		{RouterSettings{BasicAuth: &BasicAuth{Username: "a:b", Password: "secret"}}, ErrBasicAuth},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.err, tt.settings.IsValid())
+	}
+}
+
+func TestRouterSettings_Scan(t *testing.T) {
+	var s RouterSettings
+	assert.NoError(t, s.Scan(nil))
+	assert.Equal(t, RouterSettings{}, s)
+
+	assert.NoError(t, s.Scan([]byte(`{"IdleTimeout":300000000000,"WebSockets":true}`)))
+	assert.Equal(t, RouterSettings{IdleTimeout: 5 * time.Minute, WebSockets: true}, s)
+}
@@ -0,0 +1,180 @@
+package empire
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/remind101/empire/twelvefactor"
+)
+
+var (
+	// ErrRoutingRuleProcess is returned when a RoutingRule doesn't have a
+	// process to route to.
+	ErrRoutingRuleProcess = &ValidationError{
+		errors.New("A routing rule needs a process to route requests to."),
+	}
+
+	// ErrRoutingRuleCondition is returned when a RoutingRule doesn't match
+	// anything.
+	ErrRoutingRuleCondition = &ValidationError{
+		errors.New("A routing rule needs a host, a path, or both."),
+	}
+
+	// ErrRoutingRulePath is returned when a RoutingRule has an invalid
+	// path.
+	ErrRoutingRulePath = &ValidationError{
+		errors.New("Path must start with / and be at most 128 characters."),
+	}
+
+	// ErrRoutingRuleHost is returned when a RoutingRule has an invalid host.
+	ErrRoutingRuleHost = &ValidationError{
+		errors.New("Host must be at most 128 characters."),
+	}
+)
+
+// RoutingRule routes the requests that the web process of an app receives to
+// another process of the app, based on the host and path of the request (e.g.
+// "/api/*" to the api process).
+//
+// Requests that don't match a rule go to the web process. When multiple rules
+// match a request, the most specific one wins: rules with both a host and a
+// path, then rules with longer paths. Rules are provided to the scheduler the
+// next time the app is released.
+type RoutingRule struct {
+	// A unique uuid that identifies the rule.
+	ID string
+
+	// The id of the app that the rule belongs to.
+	AppID string
+
+	// If provided, the rule only matches requests for this host. "*" and
+	// "?" can be used as wildcards.
+	Host string
+
+	// If provided, the rule only matches requests for this path. "*" and
+	// "?" can be used as wildcards.
+	Path string
+
+	// The process that matching requests are routed to.
+	Process string
+
+	// The time that the rule was created.
+	CreatedAt *time.Time
+}
+
+// IsValid returns an error if the rule isn't valid.
+func (r *RoutingRule) IsValid() error {
+	if r.Process == "" {
+		return ErrRoutingRuleProcess
+	}
+
+	if r.Host == "" && r.Path == "" {
+		return ErrRoutingRuleCondition
+	}
+
+	if r.Path != "" && (!strings.HasPrefix(r.Path, "/") || len(r.Path) > 128) {
+		return ErrRoutingRulePath
+	}
+
+	if len(r.Host) > 128 {
+		return ErrRoutingRuleHost
+	}
+
+	return nil
+}
+
+// BeforeCreate sets created_at before inserting.
+func (r *RoutingRule) BeforeCreate() error {
+	t := timex.Now()
+	r.CreatedAt = &t
+	return r.IsValid()
+}
+
+// RoutingRulesQuery is a scope implementation for common things to filter
+// routing rules by.
+type RoutingRulesQuery struct {
+	// If provided, finds the rule with the given id.
+	ID *string
+
+	// If provided, finds rules that belong to the given app.
+	App *App
+}
+
+// scope implements the scope interface.
+func (q RoutingRulesQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.ID != nil {
+		scope = append(scope, idEquals(*q.ID))
+	}
+
+	if q.App != nil {
+		scope = append(scope, forApp(q.App))
+	}
+
+	// Most specific first, which is the order that they're evaluated in.
+	scope = append(scope, order("(host = '') asc, length(path) desc, created_at asc"))
+
+	return scope.scope(db)
+}
+
+// routingRulesFind returns the first matching rule.
+func routingRulesFind(db *gorm.DB, scope scope) (*RoutingRule, error) {
+	var rule RoutingRule
+	return &rule, first(db, scope, &rule)
+}
+
+// routingRules returns all rules matching the scope.
+func routingRules(db *gorm.DB, scope scope) ([]*RoutingRule, error) {
+	var rules []*RoutingRule
+	return rules, find(db, scope, &rules)
+}
+
+func routingRulesCreate(db *gorm.DB, rule *RoutingRule) (*RoutingRule, error) {
+	return rule, db.Create(rule).Error
+}
+
+func routingRulesDestroy(db *gorm.DB, rule *RoutingRule) error {
+	return db.Delete(rule).Error
+}
+
+// appRoutes returns the routes that should be provided to the scheduler for
+// the given app, in the order that they should be evaluated.
+func appRoutes(db *gorm.DB, app *App) ([]*twelvefactor.Route, error) {
+	rules, err := routingRules(db, RoutingRulesQuery{App: app})
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []*twelvefactor.Route
+	for _, r := range rules {
+		routes = append(routes, &twelvefactor.Route{
+			Host:    r.Host,
+			Path:    r.Path,
+			Process: r.Process,
+		})
+	}
+
+	return routes, nil
+}
+
+// RoutingRulesCreateOpts are options provided when adding a routing rule.
+type RoutingRulesCreateOpts struct {
+	// User performing the action.
+	User *User
+
+	// The rule to create.
+	Rule *RoutingRule
+}
+
+// RoutingRulesDestroyOpts are options provided when removing a routing rule.
+type RoutingRulesDestroyOpts struct {
+	// User performing the action.
+	User *User
+
+	// The rule to remove.
+	Rule *RoutingRule
+}
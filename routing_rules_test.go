@@ -0,0 +1,43 @@
+package empire
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutingRulesQuery(t *testing.T) {
+	var (
+		id  = "1234"
+		app = &App{ID: "4321"}
+	)
+
+	tests := scopeTests{
+		{RoutingRulesQuery{}, "ORDER BY (host = '') asc, length(path) desc, created_at asc", []interface{}{}},
+		{RoutingRulesQuery{ID: &id}, "WHERE (id = $1) ORDER BY (host = '') asc, length(path) desc, created_at asc", []interface{}{"1234"}},
+		{RoutingRulesQuery{App: app}, "WHERE (app_id = $1) ORDER BY (host = '') asc, length(path) desc, created_at asc", []interface{}{"4321"}},
+	}
+
+	tests.Run(t)
+}
+
+func TestRoutingRule_IsValid(t *testing.T) {
+	tests := []struct {
+		rule RoutingRule
+		err  error
+	}{
+		{RoutingRule{Path: "/api/*", Process: "api"}, nil},
+		{RoutingRule{Host: "admin.acme.com", Process: "admin"}, nil},
+		{RoutingRule{Host: "*.acme.com", Path: "/v2/*", Process: "api"}, nil},
+		{RoutingRule{Path: "/api/*"}, ErrRoutingRuleProcess},
+		{RoutingRule{Process: "api"}, ErrRoutingRuleCondition},
+		{RoutingRule{Path: "api/*", Process: "api"}, ErrRoutingRulePath},
+		{RoutingRule{Path: "/" + strings.Repeat("a", 128), Process: "api"}, ErrRoutingRulePath},
+		{RoutingRule{Host: strings.Repeat("a", 129), Process: "api"}, ErrRoutingRuleHost},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.err, tt.rule.IsValid())
+	}
+}
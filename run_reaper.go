@@ -0,0 +1,192 @@
+package empire
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/remind101/empire/twelvefactor"
+	"github.com/remind101/pkg/reporter"
+	"golang.org/x/net/context"
+)
+
+// timeoutLabel is the label that holds the maximum runtime of a one-off
+// process (e.g. "1h0m0s"), when it was run with one.
+const timeoutLabel = "empire.timeout"
+
+// finishedBatchRetention is how long a batch is kept around once all of its
+// jobs have finished.
+const finishedBatchRetention = 7 * 24 * time.Hour
+
+// RunReaper periodically kills one-off processes that have been running for
+// longer than their timeout, archives the captured output of detached one-off
+// processes that have finished, removes what the scheduler kept around for
+// one-off processes that have finished, and removes batches whose jobs
+// finished long ago.
+type RunReaper struct {
+	*Empire
+
+	// How often to look for one-off processes to reap.
+	Interval time.Duration
+}
+
+// Start starts reaping one-off processes, until the context is canceled.
+// Errors, and panics, are reported to the reporter in the context.
+func (r *RunReaper) Start(ctx context.Context) {
+	defer reporter.Monitor(ctx)
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.ReapRuns(ctx); err != nil {
+				reporter.Report(ctx, err)
+			}
+		}
+	}
+}
+
+// ReapRuns stops the one-off processes that have run for longer than their
+// timeout, and returns the processes that were stopped. A RunTimeoutEvent is
+// published for each one.
+func (r *RunReaper) ReapRuns(ctx context.Context) ([]*Task, error) {
+	apps, err := apps(r.db, AppsQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		killed []*Task
+		errors []error
+	)
+	for _, app := range apps {
+		scheduler, err := r.scheduler(app)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		tasks, err := scheduler.Tasks(ctx, app.ID)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		for _, t := range tasks {
+			timeout, ok := timedOut(t, timex.Now())
+			if !ok {
+				continue
+			}
+
+			if err := scheduler.Stop(ctx, t.ID); err != nil {
+				errors = append(errors, err)
+				continue
+			}
+
+			task := taskFromInstance(t)
+			killed = append(killed, task)
+
+			if err := r.PublishEvent(RunTimeoutEvent{
+				App:     app.Name,
+				PID:     task.Name,
+				Command: task.Command,
+				Timeout: timeout,
+				app:     app,
+			}); err != nil {
+				errors = append(errors, err)
+			}
+		}
+
+		// The output of finished processes needs to be archived before
+		// the scheduler forgets about them.
+		if r.Outputs != nil && r.Blobs != nil {
+			if err := r.archiveOutputs(ctx, scheduler, app); err != nil {
+				errors = append(errors, err)
+			}
+		}
+
+		if err := scheduler.Cleanup(ctx, app.ID); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if err := reapBatches(r.db, timex.Now().Add(-finishedBatchRetention)); err != nil {
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return killed, &multiError{Errors: errors}
+	}
+
+	return killed, nil
+}
+
+// archiveOutputs archives the captured output of the detached one-off processes
+// of the app that have stopped, and haven't been archived yet.
+func (r *RunReaper) archiveOutputs(ctx context.Context, scheduler Scheduler, app *App) error {
+	stopped, err := scheduler.StoppedTasks(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range stopped {
+		id := t.Process.Labels[outputLabel]
+		if id == "" {
+			continue
+		}
+
+		archived, err := r.Blobs.List(ctx, outputKey(app.ID, id))
+		if err != nil {
+			return err
+		}
+		if len(archived) > 0 {
+			continue
+		}
+
+		if err := r.archiveOutput(ctx, app, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// timedOut returns the timeout of the one-off process, and true if it's been
+// running for longer than that.
+func timedOut(t *twelvefactor.Task, now time.Time) (time.Duration, bool) {
+	v := t.Process.Labels[timeoutLabel]
+	if v == "" || t.Process.Labels[userLabel] == "" || t.State != "RUNNING" {
+		return 0, false
+	}
+
+	timeout, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, false
+	}
+
+	return timeout, now.Sub(t.UpdatedAt) > timeout
+}
+
+// reapBatches removes the batches whose jobs all finished before the given
+// time.
+func reapBatches(db *gorm.DB, before time.Time) error {
+	bs, err := batches(db, BatchesQuery{})
+	if err != nil {
+		return err
+	}
+
+	for _, b := range bs {
+		if !b.Finished() || b.FinishedAt().After(before) {
+			continue
+		}
+		if err := batchesDestroy(db, b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package empire
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/remind101/empire/twelvefactor"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestTimedOut(t *testing.T) {
+	now := time.Date(2016, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		labels  map[string]string
+		state   string
+		started time.Time
+		timeout time.Duration
+		out     bool
+	}{
+		{map[string]string{userLabel: "ejholmes", timeoutLabel: "1h0m0s"}, "RUNNING", now.Add(-2 * time.Hour), time.Hour, true},
+		{map[string]string{userLabel: "ejholmes", timeoutLabel: "1h0m0s"}, "RUNNING", now.Add(-30 * time.Minute), time.Hour, false},
+
+		// Processes that haven't started yet.
+		{map[string]string{userLabel: "ejholmes", timeoutLabel: "1h0m0s"}, "PENDING", now.Add(-2 * time.Hour), 0, false},
+
+		// Processes without a timeout.
+		{map[string]string{userLabel: "ejholmes"}, "RUNNING", now.Add(-2 * time.Hour), 0, false},
+
+		// Processes that are part of the formation.
+		{map[string]string{timeoutLabel: "1h0m0s"}, "RUNNING", now.Add(-2 * time.Hour), 0, false},
+
+		// Invalid timeouts are ignored.
+		{map[string]string{userLabel: "ejholmes", timeoutLabel: "forever"}, "RUNNING", now.Add(-2 * time.Hour), 0, false},
+	}
+
+	for _, tt := range tests {
+		timeout, out := timedOut(&twelvefactor.Task{
+			Process:   &twelvefactor.Process{Labels: tt.labels},
+			State:     tt.state,
+			UpdatedAt: tt.started,
+		}, now)
+		assert.Equal(t, tt.out, out)
+		assert.Equal(t, tt.timeout, timeout)
+	}
+}
+
+func TestBatch_FinishedAt(t *testing.T) {
+	created := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	first, last := created.Add(time.Minute), created.Add(time.Hour)
+
+	b := &Batch{
+		CreatedAt: &created,
+		Jobs: []*Job{
+			{State: JobStateSucceeded, FinishedAt: &last},
+			{State: JobStateFailed, FinishedAt: &first},
+		},
+	}
+	assert.Equal(t, last, b.FinishedAt())
+}
+
+func TestRunReaper_archiveOutputs(t *testing.T) {
+	blobs := newMemBlobStore()
+	r := &RunReaper{Empire: &Empire{
+		Blobs:   blobs,
+		Outputs: memOutputStore{"a": "done\n", "b": "also done\n"},
+	}}
+	app := &App{ID: "appid"}
+	scheduler := &stoppedScheduler{tasks: []*twelvefactor.Task{
+		{Process: &twelvefactor.Process{Labels: map[string]string{outputLabel: "a"}}, State: "STOPPED"},
+		{Process: &twelvefactor.Process{Labels: map[string]string{outputLabel: "b"}}, State: "STOPPED"},
+		{Process: &twelvefactor.Process{Labels: map[string]string{}}, State: "STOPPED"},
+	}}
+
+	// Outputs that were already archived aren't archived again.
+	blobs.Put(context.Background(), outputKey(app.ID, "b"), []byte("archived\n"))
+
+	assert.NoError(t, r.archiveOutputs(context.Background(), scheduler, app))
+	assert.Equal(t, []string{"outputs/appid/a.log", "outputs/appid/b.log"}, blobs.keys())
+	assert.Equal(t, []byte("done\n"), blobs.data["outputs/appid/a.log"])
+	assert.Equal(t, []byte("archived\n"), blobs.data["outputs/appid/b.log"])
+}
+
+// memOutputStore is an OutputStore with the captured output by id.
+type memOutputStore map[string]string
+
+func (s memOutputStore) Logging(app *App, id string) *Logging {
+	return nil
+}
+
+func (s memOutputStore) Output(ctx context.Context, app *App, id string, w io.Writer) error {
+	output, ok := s[id]
+	if !ok {
+		return ErrNoOutput
+	}
+	_, err := io.WriteString(w, output)
+	return err
+}
+
+// stoppedScheduler is a Scheduler with the given stopped instances.
+type stoppedScheduler struct {
+	Scheduler
+	tasks []*twelvefactor.Task
+}
+
+func (s *stoppedScheduler) StoppedTasks(ctx context.Context, app string) ([]*twelvefactor.Task, error) {
+	return s.tasks, nil
+}
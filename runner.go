@@ -11,6 +11,16 @@ const (
 	GenericProcessName = "run"
 )
 
+// userLabel is the label that one-off processes are labeled with, which holds
+// the name of the user that ran them. Processes that are part of the formation
+// don't have it.
+const userLabel = "empire.user"
+
+// outputLabel is the label that detached one-off processes are labeled with,
+// which holds the id that their output is captured under, so that it can be
+// archived once they've finished.
+const outputLabel = "empire.output"
+
 // RunRecorder is a function that returns an io.Writer that will be written to
 // to record Stdout and Stdin of interactive runs.
 type RunRecorder func() (io.Writer, error)
@@ -42,6 +52,9 @@ func (r *runnerService) Run(ctx context.Context, opts RunOpts) error {
 	if cmd, ok := release.Formation[procName]; ok {
 		proc = cmd
 		proc.Command = append(cmd.Command, opts.Command[1:]...)
+		// The arguments given to `emp run` are used instead of the
+		// arguments that the process was scaled with.
+		proc.Args = nil
 		proc.NoService = false
 	} else {
 		// If we've set the flag to only allow `emp run` on commands
@@ -59,21 +72,58 @@ func (r *runnerService) Run(ctx context.Context, opts RunOpts) error {
 
 	proc.Quantity = 1
 
+	// Send the output to the store, so that it can be retrieved later.
+	var labels map[string]string
+	if opts.OutputID != "" {
+		proc.Logging = r.Outputs.Logging(opts.App, opts.OutputID)
+		labels = map[string]string{outputLabel: opts.OutputID}
+	}
+
 	// Set the size of the process.
 	if opts.Constraints != nil {
 		proc.SetConstraints(*opts.Constraints)
 	}
 
+	// Override individual constraints, leaving the others as they are.
+	c := proc.Constraints()
+	if opts.CPUShare != nil {
+		c.CPUShare = *opts.CPUShare
+	}
+	if opts.Memory != nil {
+		c.Memory = *opts.Memory
+	}
+	proc.SetConstraints(c)
+
+	return r.run(ctx, release, procName, proc, opts, labels)
+}
+
+// run runs a single instance of the process, with the config and image from
+// the release. The process is labeled with any extra labels that are given.
+func (r *runnerService) run(ctx context.Context, release *Release, procName string, proc Process, opts RunOpts, labels map[string]string) error {
 	release.Formation = Formation{procName: proc}
 	a, err := newSchedulerApp(release)
 	if err != nil {
 		return err
 	}
+	a.PullSecrets, err = appPullSecrets(r.db, release.App)
+	if err != nil {
+		return err
+	}
+	injectMetadata(r.Metadata, release.App, a)
+	if err := injectIdentity(r.Identity, release.App, a); err != nil {
+		return err
+	}
 	for _, p := range a.Processes {
 		p.Stdin = opts.Stdin
 		p.Stdout = opts.Stdout
 		p.Stderr = opts.Stderr
-		p.Labels["empire.user"] = opts.User.Name
+		p.Labels[userLabel] = opts.User.Name
+		if opts.Timeout > 0 {
+			p.Labels[timeoutLabel] = opts.Timeout.String()
+		}
+		for k, v := range labels {
+			p.Labels[k] = v
+		}
 
 		// Add additional environment variables to the process.
 		for k, v := range opts.Env {
@@ -81,5 +131,18 @@ func (r *runnerService) Run(ctx context.Context, opts RunOpts) error {
 		}
 	}
 
-	return r.Scheduler.Run(ctx, a)
+	scheduler, err := r.scheduler(opts.App)
+	if err != nil {
+		return err
+	}
+
+	if err := r.checkRunQuota(ctx, opts.App, proc.Constraints()); err != nil {
+		return err
+	}
+
+	if err := placeCapacity(ctx, scheduler, a); err != nil {
+		return err
+	}
+
+	return scheduler.Run(ctx, a)
 }
@@ -1,7 +1,13 @@
 package empire
 
 import (
+	"archive/tar"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/remind101/empire/pkg/timex"
@@ -39,19 +45,27 @@ func (m *FakeScheduler) Remove(ctx context.Context, appID string) error {
 }
 
 func (m *FakeScheduler) Tasks(ctx context.Context, appID string) ([]*twelvefactor.Task, error) {
+	return m.QueryTasks(ctx, twelvefactor.TasksQuery{App: appID})
+}
+
+func (m *FakeScheduler) QueryTasks(ctx context.Context, q twelvefactor.TasksQuery) ([]*twelvefactor.Task, error) {
 	var instances []*twelvefactor.Task
-	if a, ok := m.apps[appID]; ok {
+	for _, a := range m.apps {
 		for _, p := range a.Processes {
 			pp := *p
 			pp.Env = twelvefactor.Env(a, p)
 			for i := 1; i <= p.Quantity; i++ {
-				instances = append(instances, &twelvefactor.Task{
+				t := &twelvefactor.Task{
+					App:       a.AppID,
 					ID:        fmt.Sprintf("%d", i),
 					Host:      twelvefactor.Host{ID: "i-aa111aa1"},
 					State:     "running",
 					Process:   &pp,
 					UpdatedAt: timex.Now(),
-				})
+				}
+				if q.Matches(t) {
+					instances = append(instances, t)
+				}
 			}
 		}
 	}
@@ -74,3 +88,72 @@ func (m *FakeScheduler) Run(ctx context.Context, app *twelvefactor.Manifest) err
 	}
 	return nil
 }
+
+func (m *FakeScheduler) Exec(ctx context.Context, appID string, instanceID string, opts twelvefactor.ExecOpts) error {
+	if opts.Stdout != nil {
+		fmt.Fprintf(opts.Stdout, "Fake output for `%s` in %s\n", strings.Join(opts.Command, " "), instanceID)
+	}
+	return nil
+}
+
+func (m *FakeScheduler) Dial(ctx context.Context, appID string, instanceID string, port int) (net.Conn, error) {
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		io.Copy(server, server)
+	}()
+	return client, nil
+}
+
+func (m *FakeScheduler) CopyTo(ctx context.Context, appID string, instanceID string, path string, archive io.Reader) error {
+	_, err := io.Copy(ioutil.Discard, archive)
+	return err
+}
+
+func (m *FakeScheduler) CopyFrom(ctx context.Context, appID string, instanceID string, path string, w io.Writer) error {
+	content := fmt.Sprintf("Fake contents of %s in %s\n", path, instanceID)
+
+	tw := tar.NewWriter(w)
+	if err := tw.WriteHeader(&tar.Header{
+		Name: filepath.Base(path),
+		Mode: 0644,
+		Size: int64(len(content)),
+	}); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(tw, content); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func (m *FakeScheduler) StoppedTasks(ctx context.Context, appID string) ([]*twelvefactor.Task, error) {
+	return nil, nil
+}
+
+func (m *FakeScheduler) Cleanup(ctx context.Context, appID string) error {
+	return nil
+}
+
+func (m *FakeScheduler) Cordon(ctx context.Context, hostID string) error {
+	return nil
+}
+
+func (m *FakeScheduler) Uncordon(ctx context.Context, hostID string) error {
+	return nil
+}
+
+func (m *FakeScheduler) Drain(ctx context.Context, hostID string) error {
+	return nil
+}
+
+func (m *FakeScheduler) Machines(ctx context.Context) ([]*twelvefactor.Machine, error) {
+	return []*twelvefactor.Machine{
+		{
+			Host:      twelvefactor.Host{ID: "i-aa111aa1"},
+			Total:     twelvefactor.Resources{CPU: 2048, Memory: 8 * 1024 * 1024 * 1024},
+			Allocated: twelvefactor.Resources{CPU: 256, Memory: 512 * 1024 * 1024},
+			Tasks:     1,
+		},
+	}, nil
+}
@@ -11,6 +11,7 @@ import (
 	"hash/crc32"
 	"html/template"
 	"io"
+	"net"
 	"strconv"
 	"strings"
 	"time"
@@ -25,10 +26,12 @@ import (
 	docker "github.com/fsouza/go-dockerclient"
 	"github.com/remind101/empire/pkg/arn"
 	"github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/pkg/image"
 	pglock "github.com/remind101/empire/pkg/pg/lock"
 	"github.com/remind101/empire/stats"
 	"github.com/remind101/empire/twelvefactor"
 	"github.com/remind101/pkg/logger"
+	"github.com/remind101/pkg/reporter"
 	"golang.org/x/net/context"
 )
 
@@ -87,6 +90,97 @@ var DefaultStackNameTemplate = template.Must(template.New("stack_name").Parse("{
 // app.
 var errNoStack = errors.New("no stack for app found")
 
+// errTaskNotFound is returned when a task could not be found for the app.
+var errTaskNotFound = errors.New("no task with that id was found for the app")
+
+// cordonAttribute is the custom container instance attribute that's set when a
+// host is cordoned. Tasks started by Empire have a placement constraint that
+// excludes container instances with this attribute.
+const cordonAttribute = "empire.cordoned"
+
+// capacityAttribute is the custom container instance attribute that has the
+// capacity pool of the instance (e.g. "spot"). Container instances without it
+// are on-demand. It's usually set with ECS_INSTANCE_ATTRIBUTES when the
+// instance registers.
+const capacityAttribute = "empire.capacity"
+
+// zoneAttribute is the attribute that ECS sets on container instances with
+// the availability zone that the instance is in.
+const zoneAttribute = "ecs.availability-zone"
+
+// appIDLabel is the Docker label that has the id of the app that a task
+// belongs to, which is used to find the app of tasks listed for every app.
+const appIDLabel = "empire.app.id"
+
+// cordonConstraint is the placement constraint expression that excludes
+// cordoned container instances.
+var cordonConstraint = fmt.Sprintf("attribute:%s !exists", cordonAttribute)
+
+// capacityConstraint returns the placement constraint expression that places
+// instances of the process on its capacity pool, or an empty string if it can
+// be placed anywhere.
+func capacityConstraint(p *twelvefactor.Process) string {
+	switch p.Capacity {
+	case twelvefactor.CapacitySpot:
+		return fmt.Sprintf("attribute:%s == %s", capacityAttribute, twelvefactor.CapacitySpot)
+	case twelvefactor.CapacityOnDemand:
+		return fmt.Sprintf("attribute:%s != %s", capacityAttribute, twelvefactor.CapacitySpot)
+	default:
+		return ""
+	}
+}
+
+// placementStrategies are the ECS placement strategies for each
+// twelvefactor placement strategy.
+var placementStrategies = map[string][]*ecs.PlacementStrategy{
+	// Fill the machine with the least memory available first.
+	twelvefactor.PlacementBinpack: {
+		{Type: aws.String(ecs.PlacementStrategyTypeBinpack), Field: aws.String("memory")},
+	},
+
+	// Spread across availability zones first, then machines within each
+	// zone.
+	twelvefactor.PlacementSpread: {
+		{Type: aws.String(ecs.PlacementStrategyTypeSpread), Field: aws.String("attribute:" + zoneAttribute)},
+		{Type: aws.String(ecs.PlacementStrategyTypeSpread), Field: aws.String("instanceId")},
+	},
+}
+
+// placementStrategy returns the ECS placement strategy for the process. The
+// strategy of the process, either its placement or an ECS specific strategy in
+// the Procfile, takes precedence over the strategy of the cluster. When neither
+// is set, nil is returned, so that ECS's default placement is used.
+func placementStrategy(p *twelvefactor.Process, clusterStrategy string) []*ecs.PlacementStrategy {
+	if v := p.ECS; v != nil && len(v.PlacementStrategy) > 0 {
+		return v.PlacementStrategy
+	}
+	if p.Placement != "" {
+		return placementStrategies[p.Placement]
+	}
+	return placementStrategies[clusterStrategy]
+}
+
+// newHost returns the twelvefactor.Host for a container instance.
+func newHost(ci *ecs.ContainerInstance) twelvefactor.Host {
+	h := twelvefactor.Host{
+		ID:       aws.StringValue(ci.Ec2InstanceId),
+		Lost:     aws.StringValue(ci.Status) == "INACTIVE" || !aws.BoolValue(ci.AgentConnected),
+		Draining: aws.StringValue(ci.Status) == ecs.ContainerInstanceStatusDraining,
+		Capacity: twelvefactor.CapacityOnDemand,
+	}
+	for _, a := range ci.Attributes {
+		switch aws.StringValue(a.Name) {
+		case capacityAttribute:
+			if v := aws.StringValue(a.Value); v != "" {
+				h.Capacity = v
+			}
+		case zoneAttribute:
+			h.Zone = aws.StringValue(a.Value)
+		}
+	}
+	return h
+}
+
 // cloudformationClient duck types the cloudformation.CloudFormation interface
 // that we use.
 type cloudformationClient interface {
@@ -108,10 +202,17 @@ type ecsClient interface {
 	DescribeTasks(*ecs.DescribeTasksInput) (*ecs.DescribeTasksOutput, error)
 	RunTask(*ecs.RunTaskInput) (*ecs.RunTaskOutput, error)
 	RegisterTaskDefinition(*ecs.RegisterTaskDefinitionInput) (*ecs.RegisterTaskDefinitionOutput, error)
+	DeregisterTaskDefinition(*ecs.DeregisterTaskDefinitionInput) (*ecs.DeregisterTaskDefinitionOutput, error)
+	ListTaskDefinitionsPages(*ecs.ListTaskDefinitionsInput, func(*ecs.ListTaskDefinitionsOutput, bool) bool) error
 	StopTask(*ecs.StopTaskInput) (*ecs.StopTaskOutput, error)
 	UpdateService(*ecs.UpdateServiceInput) (*ecs.UpdateServiceOutput, error)
 	DescribeServices(*ecs.DescribeServicesInput) (*ecs.DescribeServicesOutput, error)
 	DescribeContainerInstances(*ecs.DescribeContainerInstancesInput) (*ecs.DescribeContainerInstancesOutput, error)
+	ListContainerInstances(*ecs.ListContainerInstancesInput) (*ecs.ListContainerInstancesOutput, error)
+	ListContainerInstancesPages(*ecs.ListContainerInstancesInput, func(*ecs.ListContainerInstancesOutput, bool) bool) error
+	UpdateContainerInstancesState(*ecs.UpdateContainerInstancesStateInput) (*ecs.UpdateContainerInstancesStateOutput, error)
+	PutAttributes(*ecs.PutAttributesInput) (*ecs.PutAttributesOutput, error)
+	DeleteAttributes(*ecs.DeleteAttributesInput) (*ecs.DeleteAttributesOutput, error)
 	WaitUntilTasksNotPending(*ecs.DescribeTasksInput) error
 }
 
@@ -130,6 +231,10 @@ type ec2Client interface {
 type DockerClient interface {
 	ListContainers(docker.ListContainersOptions) ([]docker.APIContainers, error)
 	AttachToContainer(docker.AttachToContainerOptions) error
+	CreateExec(docker.CreateExecOptions) (*docker.Exec, error)
+	StartExec(string, docker.StartExecOptions) error
+	UploadToContainer(string, docker.UploadToContainerOptions) error
+	DownloadFromContainer(string, docker.DownloadFromContainerOptions) error
 }
 
 // Data handed to template generators.
@@ -155,6 +260,11 @@ type Scheduler struct {
 	// The ECS cluster to run tasks in.
 	Cluster string
 
+	// The placement strategy of one-off processes that don't have their
+	// own. This should be the same as the PlacementStrategy of the
+	// EmpireTemplate.
+	PlacementStrategy string
+
 	// The name of the bucket to store templates in.
 	Bucket string
 
@@ -183,6 +293,9 @@ type Scheduler struct {
 
 	db *sql.DB
 
+	// Stack operations that are running in the background.
+	operations stackOperations
+
 	after func(time.Duration) <-chan time.Time
 }
 
@@ -243,13 +356,16 @@ func (s *Scheduler) Restart(ctx context.Context, appID string, ss twelvefactor.S
 		return err
 	}
 
-	if ss != nil {
-		o := <-output
-		if o.err != nil || o.stack == nil {
-			return o.err
-		}
-		// TODO: Wait for services to stabilize?
+	if ss == nil {
+		go reportStackOperation(ctx, stackName, output)
+		return nil
+	}
+
+	o := <-output
+	if o.err != nil || o.stack == nil {
+		return o.err
 	}
+	// TODO: Wait for services to stabilize?
 
 	return nil
 }
@@ -328,18 +444,35 @@ func (s *Scheduler) submit(ctx context.Context, tx *sql.Tx, app *twelvefactor.Ma
 		return fmt.Errorf("error describing stack: %v", err)
 	}
 
-	if ss != nil {
-		o := <-output
-		if o.err != nil || o.stack == nil {
-			return o.err
-		}
-		if err := s.waitUntilStable(ctx, o.stack, ss); err != nil {
-			logger.Warn(ctx, fmt.Sprintf("error waiting for submit to stabilize: %v", err))
+	if ss == nil {
+		go reportStackOperation(ctx, stackName, output)
+		return nil
+	}
+
+	o := <-output
+	if o.err != nil || o.stack == nil {
+		return o.err
+	}
+	if err := s.waitUntilStable(ctx, o.stack, ss); err != nil {
+		// The caller gave up waiting (e.g. the deploy timed out), so
+		// it needs to know that the new version isn't stable.
+		if err == ctx.Err() {
+			return err
 		}
+		logger.Warn(ctx, fmt.Sprintf("error waiting for submit to stabilize: %v", err))
 	}
 	return nil
 }
 
+// reportStackOperation waits for a stack operation that continues in the
+// background, and reports it if it fails, since there's nothing to return the
+// error to.
+func reportStackOperation(ctx context.Context, stackName string, output chan stackOperationOutput) {
+	if o := <-output; o.err != nil {
+		reporter.Report(ctx, fmt.Errorf("error updating stack %s: %v", stackName, o.err))
+	}
+}
+
 func (s *Scheduler) waitUntilStable(ctx context.Context, stack *cloudformation.Stack, ss twelvefactor.StatusStream) error {
 	deployments, err := deploymentsToWatch(stack)
 	if err != nil {
@@ -347,12 +480,46 @@ func (s *Scheduler) waitUntilStable(ctx context.Context, stack *cloudformation.S
 	}
 	deploymentStatuses := s.waitForDeploymentsToStabilize(ctx, deployments)
 	for status := range deploymentStatuses {
-		publish(ctx, ss, fmt.Sprintf("Service %s became %s", status.deployment.process, status))
+		process := status.deployment.process
+		switch status.status {
+		case deploymentPending:
+			publishStatus(ctx, ss, twelvefactor.Status{
+				Message: fmt.Sprintf("Service %s is waiting for new tasks to pass health checks", process),
+				Phase:   twelvefactor.PhaseHealthPending,
+				Process: process,
+			})
+		case deploymentStable:
+			// A service only has a single deployment once the new
+			// tasks are healthy, and the old tasks have been
+			// drained.
+			publishStatus(ctx, ss, twelvefactor.Status{
+				Message: fmt.Sprintf("Service %s became %s", process, status),
+				Phase:   twelvefactor.PhaseHealthPassed,
+				Process: process,
+			})
+			publishStatus(ctx, ss, twelvefactor.Status{
+				Message: fmt.Sprintf("Service %s removed tasks from the previous release", process),
+				Phase:   twelvefactor.PhaseRemoved,
+				Process: process,
+			})
+		default:
+			publishStatus(ctx, ss, twelvefactor.Status{
+				Message: fmt.Sprintf("Service %s became %s", process, status),
+				Process: process,
+			})
+		}
 	}
 	// TODO publish notification to empire
-	return nil
+	return ctx.Err()
 }
 
+// Statuses that an ECS deployment can be in while waiting for it to stabilize.
+const (
+	deploymentPending  = "pending"
+	deploymentStable   = "stable"
+	deploymentInactive = "inactive"
+)
+
 type deploymentStatus struct {
 	deployment *ecsDeployment
 	status     string
@@ -365,6 +532,9 @@ func (d *deploymentStatus) String() string {
 func (s *Scheduler) waitForDeploymentsToStabilize(ctx context.Context, deployments map[string]*ecsDeployment) <-chan *deploymentStatus {
 	ch := make(chan *deploymentStatus)
 
+	// The deployments that have already been reported as pending.
+	pending := make(map[string]bool)
+
 	wait := func(deployments map[string]*ecsDeployment) (bool, error) {
 		arns := make([]*string, 0, len(deployments))
 		for arn := range deployments {
@@ -390,12 +560,15 @@ func (s *Scheduler) waitForDeploymentsToStabilize(ctx context.Context, deploymen
 			}
 
 			if primary && stable {
-				ch <- &deploymentStatus{d, "stable"}
+				ch <- &deploymentStatus{d, deploymentStable}
 				delete(deployments, *service.ServiceArn)
 			} else if primary {
-				// do nothing
+				if !pending[d.ID] {
+					pending[d.ID] = true
+					ch <- &deploymentStatus{d, deploymentPending}
+				}
 			} else {
-				ch <- &deploymentStatus{d, "inactive"}
+				ch <- &deploymentStatus{d, deploymentInactive}
 				return false, nil
 			}
 		}
@@ -412,7 +585,11 @@ func (s *Scheduler) waitForDeploymentsToStabilize(ctx context.Context, deploymen
 				break
 			}
 			if keepWaiting {
-				<-s.after(pollServicesWait)
+				select {
+				case <-s.after(pollServicesWait):
+				case <-ctx.Done():
+					keepWaiting = false
+				}
 			}
 		}
 		close(ch)
@@ -495,7 +672,9 @@ func (s *Scheduler) createStack(ctx context.Context, input *createStackInput, ou
 		submitted <- err
 		return err
 	}
+	s.operations.add(*input.StackName)
 	go func() {
+		defer s.operations.done(*input.StackName)
 		stack, err := s.performStackOperation(ctx, *input.StackName, fn, waiter, ss)
 		output <- stackOperationOutput{stack, err}
 	}()
@@ -524,13 +703,18 @@ func (s *Scheduler) updateStack(ctx context.Context, input *updateStackInput, ou
 		close(locked)
 		err := s.executeStackUpdate(input)
 		if err == nil {
-			publish(ctx, ss, "Stack update submitted")
+			publishStatus(ctx, ss, twelvefactor.Status{
+				Message: "Stack update submitted",
+				Phase:   twelvefactor.PhaseScheduled,
+			})
 		}
 		submitted <- err
 		return err
 	}
 
+	s.operations.add(*input.StackName)
 	go func() {
+		defer s.operations.done(*input.StackName)
 		stack, err := s.performStackOperation(ctx, *input.StackName, fn, waiter, ss)
 		output <- stackOperationOutput{stack, err}
 	}()
@@ -539,9 +723,9 @@ func (s *Scheduler) updateStack(ctx context.Context, input *updateStackInput, ou
 	select {
 	case <-s.after(lockWait):
 		publish(ctx, ss, "Waiting for existing stack operation to complete")
-		// FIXME: At this point, we don't want to affect UX by waiting
-		// around, so we return. But, if the stack update times out, or
-		// there's an error, that information is essentially silenced.
+		// At this point, we don't want to affect UX by waiting
+		// around, so we return. If the stack update times out, or
+		// there's an error, the caller either waits for it, or reports it.
 		return nil
 	case <-locked:
 		// if a lock is obtained within the time frame, we might as well
@@ -727,13 +911,58 @@ func (s *Scheduler) remove(_ context.Context, tx *sql.Tx, appID string) error {
 
 // Tasks returns all of the running tasks for this application.
 func (s *Scheduler) Tasks(ctx context.Context, app string) ([]*twelvefactor.Task, error) {
-	var instances []*twelvefactor.Task
+	return s.QueryTasks(ctx, twelvefactor.TasksQuery{App: app})
+}
 
-	tasks, err := s.tasks(app)
+// QueryTasks returns the running tasks matching the query. Tasks are listed
+// with the ECS filters for the service of the process, and the container
+// instance of the host, so that only the matching tasks are described. When the
+// query isn't scoped to an app, every task in the cluster is listed at once.
+func (s *Scheduler) QueryTasks(ctx context.Context, q twelvefactor.TasksQuery) ([]*twelvefactor.Task, error) {
+	var containerInstance *string
+	if q.Host != "" {
+		resp, err := s.ecs.ListContainerInstances(&ecs.ListContainerInstancesInput{
+			Cluster: aws.String(s.Cluster),
+			Filter:  aws.String(fmt.Sprintf("ec2InstanceId == %s", q.Host)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing container instances: %v", err)
+		}
+
+		// The host isn't in this cluster.
+		if len(resp.ContainerInstanceArns) == 0 {
+			return nil, nil
+		}
+		containerInstance = resp.ContainerInstanceArns[0]
+	}
+
+	tasks, err := s.queryTasks(q, containerInstance)
 	if err != nil {
 		return nil, err
 	}
 
+	instances, err := s.instances(tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*twelvefactor.Task
+	for _, i := range instances {
+		if q.App != "" {
+			i.App = q.App
+		}
+		if q.Matches(i) {
+			matched = append(matched, i)
+		}
+	}
+
+	return matched, nil
+}
+
+// instances converts ECS tasks to twelvefactor Tasks.
+func (s *Scheduler) instances(tasks []*ecs.Task) ([]*twelvefactor.Task, error) {
+	var instances []*twelvefactor.Task
+
 	taskDefinitions := make(map[string]*ecs.TaskDefinition)
 	for _, t := range tasks {
 		k := *t.TaskDefinitionArn
@@ -749,34 +978,9 @@ func (s *Scheduler) Tasks(ctx context.Context, app string) ([]*twelvefactor.Task
 		}
 	}
 
-	// Map from clusterARN to containerInstanceARN pointers to batch tasks from the same cluster
-	clusterMap := make(map[string][]*string)
-
-	for _, t := range tasks {
-		k := *t.ClusterArn
-		clusterMap[k] = append(clusterMap[k], t.ContainerInstanceArn)
-	}
-
-	// Map from containerInstanceARN to ec2-instance-id
-	hostMap := make(map[string]string)
-
-	for clusterArn, containerArnPtrs := range clusterMap {
-		for _, chunk := range chunkStrings(containerArnPtrs, MaxDescribeContainerInstances) {
-			resp, err := s.ecs.DescribeContainerInstances(&ecs.DescribeContainerInstancesInput{
-				Cluster:            aws.String(clusterArn),
-				ContainerInstances: chunk,
-			})
-			if err != nil {
-				return nil, err
-			}
-			for _, f := range resp.Failures {
-				return nil, fmt.Errorf("error describing container instance %s: %s", aws.StringValue(f.Arn), aws.StringValue(f.Reason))
-			}
-
-			for _, ci := range resp.ContainerInstances {
-				hostMap[aws.StringValue(ci.ContainerInstanceArn)] = aws.StringValue(ci.Ec2InstanceId)
-			}
-		}
+	hostMap, err := s.hosts(tasks)
+	if err != nil {
+		return nil, err
 	}
 
 	for _, t := range tasks {
@@ -787,7 +991,7 @@ func (s *Scheduler) Tasks(ctx context.Context, app string) ([]*twelvefactor.Task
 			return instances, err
 		}
 
-		hostId := hostMap[*t.ContainerInstanceArn]
+		host := hostMap[*t.ContainerInstanceArn]
 
 		p, err := taskDefinitionToProcess(taskDefinition)
 		if err != nil {
@@ -806,10 +1010,11 @@ func (s *Scheduler) Tasks(ctx context.Context, app string) ([]*twelvefactor.Task
 		}
 
 		instances = append(instances, &twelvefactor.Task{
+			App:       p.Labels[appIDLabel],
 			Process:   p,
 			State:     state,
 			ID:        id,
-			Host:      twelvefactor.Host{ID: hostId},
+			Host:      host,
 			UpdatedAt: updatedAt,
 		})
 	}
@@ -817,6 +1022,178 @@ func (s *Scheduler) Tasks(ctx context.Context, app string) ([]*twelvefactor.Task
 	return instances, nil
 }
 
+// StoppedTasks returns the one-off tasks for this application that have recently
+// stopped, with the exit code of their process. ECS only keeps stopped tasks
+// for about an hour.
+func (s *Scheduler) StoppedTasks(ctx context.Context, app string) ([]*twelvefactor.Task, error) {
+	tasks, err := s.stoppedTasks(app)
+	if err != nil {
+		return nil, err
+	}
+
+	taskDefinitions := make(map[string]*ecs.TaskDefinition)
+	var instances []*twelvefactor.Task
+	for _, t := range tasks {
+		k := *t.TaskDefinitionArn
+		if _, ok := taskDefinitions[k]; !ok {
+			resp, err := s.ecs.DescribeTaskDefinition(&ecs.DescribeTaskDefinitionInput{
+				TaskDefinition: t.TaskDefinitionArn,
+			})
+			if err != nil {
+				return nil, err
+			}
+			taskDefinitions[k] = resp.TaskDefinition
+		}
+
+		id, err := arn.ResourceID(*t.TaskArn)
+		if err != nil {
+			return instances, err
+		}
+
+		p, err := taskDefinitionToProcess(taskDefinitions[k])
+		if err != nil {
+			return instances, err
+		}
+
+		var exitCode *int
+		for _, c := range t.Containers {
+			if aws.StringValue(c.Name) == p.Type && c.ExitCode != nil {
+				code := int(*c.ExitCode)
+				exitCode = &code
+			}
+		}
+
+		instances = append(instances, &twelvefactor.Task{
+			Process:   p,
+			State:     aws.StringValue(t.LastStatus),
+			ID:        id,
+			UpdatedAt: aws.TimeValue(t.StoppedAt),
+			ExitCode:  exitCode,
+		})
+	}
+
+	return instances, nil
+}
+
+// Cleanup deregisters the task definitions that Run registered for the one-off
+// tasks of this application that have stopped, so that they don't pile up.
+func (s *Scheduler) Cleanup(ctx context.Context, app string) error {
+	tasks, err := s.tasks(app)
+	if err != nil {
+		return err
+	}
+
+	stopped, err := s.stoppedTasks(app)
+	if err != nil {
+		return err
+	}
+
+	inUse := make(map[string]bool)
+	for _, t := range tasks {
+		inUse[aws.StringValue(t.TaskDefinitionArn)] = true
+	}
+
+	// Task definitions used by stopped tasks, grouped by family.
+	finished := make(map[string]map[string]bool)
+	for _, t := range stopped {
+		k := aws.StringValue(t.TaskDefinitionArn)
+		if inUse[k] {
+			continue
+		}
+		family, err := taskDefinitionFamily(k)
+		if err != nil {
+			return err
+		}
+		// Only task definitions registered by Run, not by the stack.
+		if !strings.HasPrefix(family, app+"--") {
+			continue
+		}
+		if finished[family] == nil {
+			finished[family] = make(map[string]bool)
+		}
+		finished[family][k] = true
+	}
+
+	for family, arns := range finished {
+		var active []*string
+		if err := s.ecs.ListTaskDefinitionsPages(&ecs.ListTaskDefinitionsInput{
+			FamilyPrefix: aws.String(family),
+			Status:       aws.String(ecs.TaskDefinitionStatusActive),
+		}, func(resp *ecs.ListTaskDefinitionsOutput, lastPage bool) bool {
+			active = append(active, resp.TaskDefinitionArns...)
+			return true
+		}); err != nil {
+			return fmt.Errorf("error listing task definitions for %s: %v", family, err)
+		}
+
+		for _, k := range active {
+			if !arns[aws.StringValue(k)] {
+				continue
+			}
+			if _, err := s.ecs.DeregisterTaskDefinition(&ecs.DeregisterTaskDefinitionInput{
+				TaskDefinition: k,
+			}); err != nil {
+				return fmt.Errorf("error deregistering %s: %v", aws.StringValue(k), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// taskDefinitionFamily returns the family of a task definition from its ARN
+// (e.g. "arn:aws:ecs:us-east-1:012345678910:task-definition/family:1").
+func taskDefinitionFamily(taskDefinitionArn string) (string, error) {
+	id, err := arn.ResourceID(taskDefinitionArn)
+	if err != nil {
+		return "", err
+	}
+	return strings.SplitN(id, ":", 2)[0], nil
+}
+
+// hosts returns a map from container instance ARN to the host for the
+// container instances that the tasks are running on.
+func (s *Scheduler) hosts(tasks []*ecs.Task) (map[string]twelvefactor.Host, error) {
+	// Map from clusterARN to containerInstanceARN pointers to batch tasks from the same cluster
+	clusterMap := make(map[string][]*string)
+
+	for _, t := range tasks {
+		k := *t.ClusterArn
+		clusterMap[k] = append(clusterMap[k], t.ContainerInstanceArn)
+	}
+
+	// Map from containerInstanceARN to the host
+	hosts := make(map[string]twelvefactor.Host)
+
+	for clusterArn, containerArnPtrs := range clusterMap {
+		for _, chunk := range chunkStrings(containerArnPtrs, MaxDescribeContainerInstances) {
+			resp, err := s.ecs.DescribeContainerInstances(&ecs.DescribeContainerInstancesInput{
+				Cluster:            aws.String(clusterArn),
+				ContainerInstances: chunk,
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range resp.Failures {
+				// The container instance was deregistered, usually
+				// because the EC2 instance was terminated, but ECS
+				// still thinks the task is running.
+				if aws.StringValue(f.Reason) == "MISSING" {
+					hosts[aws.StringValue(f.Arn)] = twelvefactor.Host{Lost: true}
+					continue
+				}
+				return nil, fmt.Errorf("error describing container instance %s: %s", aws.StringValue(f.Arn), aws.StringValue(f.Reason))
+			}
+
+			for _, ci := range resp.ContainerInstances {
+				hosts[aws.StringValue(ci.ContainerInstanceArn)] = newHost(ci)
+			}
+		}
+	}
+
+	return hosts, nil
+}
+
 func (s *Scheduler) services(arns []*string) ([]*ecs.Service, error) {
 	var services []*ecs.Service
 	for _, chunk := range chunkStrings(arns, MaxDescribeServices) {
@@ -834,7 +1211,25 @@ func (s *Scheduler) services(arns []*string) ([]*ecs.Service, error) {
 
 // tasks returns all of the ECS tasks for this app.
 func (s *Scheduler) tasks(app string) ([]*ecs.Task, error) {
-	services, err := s.Services(app)
+	return s.queryTasks(twelvefactor.TasksQuery{App: app}, nil)
+}
+
+// queryTasks returns the ECS tasks for the app, and process, in the query, that
+// are running on the container instance, if one is provided. When the query
+// isn't scoped to an app, the tasks of every app are returned.
+func (s *Scheduler) queryTasks(q twelvefactor.TasksQuery, containerInstance *string) ([]*ecs.Task, error) {
+	if q.App == "" {
+		arns, err := s.listTasks(&ecs.ListTasksInput{
+			Cluster:           aws.String(s.Cluster),
+			ContainerInstance: containerInstance,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing tasks: %v", err)
+		}
+		return s.describeTasks(arns)
+	}
+
+	services, err := s.Services(q.App)
 	if err != nil {
 		return nil, err
 	}
@@ -843,33 +1238,58 @@ func (s *Scheduler) tasks(app string) ([]*ecs.Task, error) {
 
 	// Find all of the tasks started by the ECS services.
 	for process, serviceArn := range services {
+		if q.Process != "" && process != q.Process {
+			continue
+		}
+
 		id, err := arn.ResourceID(serviceArn)
 		if err != nil {
 			return nil, err
 		}
 
-		var taskArns []*string
-		if err := s.ecs.ListTasksPages(&ecs.ListTasksInput{
-			Cluster:     aws.String(s.Cluster),
-			ServiceName: aws.String(id),
-		}, func(resp *ecs.ListTasksOutput, lastPage bool) bool {
-			taskArns = append(taskArns, resp.TaskArns...)
-			return true
-		}); err != nil {
+		taskArns, err := s.listTasks(&ecs.ListTasksInput{
+			Cluster:           aws.String(s.Cluster),
+			ServiceName:       aws.String(id),
+			ContainerInstance: containerInstance,
+		})
+		if err != nil {
 			return nil, fmt.Errorf("error listing tasks for %s: %v", process, err)
 		}
 
-		if len(taskArns) == 0 {
-			continue
-		}
-
 		arns = append(arns, taskArns...)
 	}
 
 	// Find all of the tasks started by Run.
+	taskArns, err := s.listTasks(&ecs.ListTasksInput{
+		Cluster:           aws.String(s.Cluster),
+		StartedBy:         aws.String(q.App),
+		ContainerInstance: containerInstance,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing tasks started by %s: %v", q.App, err)
+	}
+	arns = append(arns, taskArns...)
+
+	return s.describeTasks(arns)
+}
+
+// listTasks returns the ARNs of every page of tasks matching the input.
+func (s *Scheduler) listTasks(input *ecs.ListTasksInput) ([]*string, error) {
+	var arns []*string
+	err := s.ecs.ListTasksPages(input, func(resp *ecs.ListTasksOutput, lastPage bool) bool {
+		arns = append(arns, resp.TaskArns...)
+		return true
+	})
+	return arns, err
+}
+
+// stoppedTasks returns the one-off tasks started by the app that have stopped.
+func (s *Scheduler) stoppedTasks(app string) ([]*ecs.Task, error) {
+	var arns []*string
 	if err := s.ecs.ListTasksPages(&ecs.ListTasksInput{
-		Cluster:   aws.String(s.Cluster),
-		StartedBy: aws.String(app),
+		Cluster:       aws.String(s.Cluster),
+		StartedBy:     aws.String(app),
+		DesiredStatus: aws.String(ecs.DesiredStatusStopped),
 	}, func(resp *ecs.ListTasksOutput, lastPage bool) bool {
 		arns = append(arns, resp.TaskArns...)
 		return true
@@ -877,6 +1297,11 @@ func (s *Scheduler) tasks(app string) ([]*ecs.Task, error) {
 		return nil, fmt.Errorf("error listing tasks started by %s: %v", app, err)
 	}
 
+	return s.describeTasks(arns)
+}
+
+// describeTasks describes the tasks with the given ARNs.
+func (s *Scheduler) describeTasks(arns []*string) ([]*ecs.Task, error) {
 	var tasks []*ecs.Task
 	for _, chunk := range chunkStrings(arns, MaxDescribeTasks) {
 		resp, err := s.ecs.DescribeTasks(&ecs.DescribeTasksInput{
@@ -928,6 +1353,318 @@ func (s *Scheduler) Stop(ctx context.Context, taskID string) error {
 	return err
 }
 
+// Exec runs a command inside of the container for the given task, using the
+// Docker daemon on the container instance where the task is running.
+func (s *Scheduler) Exec(ctx context.Context, app string, taskID string, opts twelvefactor.ExecOpts) error {
+	task, err := s.task(app, taskID)
+	if err != nil {
+		return err
+	}
+
+	d, containerID, err := s.container(task)
+	if err != nil {
+		return err
+	}
+
+	exec, err := d.CreateExec(docker.CreateExecOptions{
+		Container:    containerID,
+		Cmd:          opts.Command,
+		Tty:          opts.Tty,
+		AttachStdin:  opts.Stdin != nil,
+		AttachStdout: opts.Stdout != nil,
+		AttachStderr: opts.Stderr != nil,
+	})
+	if err != nil {
+		return fmt.Errorf("error creating exec in container (%s): %v", containerID, err)
+	}
+
+	if err := d.StartExec(exec.ID, docker.StartExecOptions{
+		Tty:          opts.Tty,
+		InputStream:  opts.Stdin,
+		OutputStream: opts.Stdout,
+		ErrorStream:  opts.Stderr,
+		RawTerminal:  opts.Tty,
+	}); err != nil {
+		return fmt.Errorf("error starting exec in container (%s): %v", containerID, err)
+	}
+
+	return nil
+}
+
+// CopyTo extracts the tar archive into the directory at path inside of the
+// container for the given task.
+func (s *Scheduler) CopyTo(ctx context.Context, app string, taskID string, path string, archive io.Reader) error {
+	task, err := s.task(app, taskID)
+	if err != nil {
+		return err
+	}
+
+	d, containerID, err := s.container(task)
+	if err != nil {
+		return err
+	}
+
+	if err := d.UploadToContainer(containerID, docker.UploadToContainerOptions{
+		InputStream: archive,
+		Path:        path,
+	}); err != nil {
+		return fmt.Errorf("error copying to container (%s): %v", containerID, err)
+	}
+
+	return nil
+}
+
+// CopyFrom writes a tar archive of the path inside of the container for the
+// given task to w.
+func (s *Scheduler) CopyFrom(ctx context.Context, app string, taskID string, path string, w io.Writer) error {
+	task, err := s.task(app, taskID)
+	if err != nil {
+		return err
+	}
+
+	d, containerID, err := s.container(task)
+	if err != nil {
+		return err
+	}
+
+	if err := d.DownloadFromContainer(containerID, docker.DownloadFromContainerOptions{
+		OutputStream: w,
+		Path:         path,
+	}); err != nil {
+		return fmt.Errorf("error copying from container (%s): %v", containerID, err)
+	}
+
+	return nil
+}
+
+// Dial connects to the host port that the given container port is mapped to, on
+// the container instance where the task is running.
+func (s *Scheduler) Dial(ctx context.Context, app string, taskID string, port int) (net.Conn, error) {
+	task, err := s.task(app, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	var hostPort int64
+	for _, c := range task.Containers {
+		for _, b := range c.NetworkBindings {
+			if aws.Int64Value(b.ContainerPort) == int64(port) {
+				hostPort = aws.Int64Value(b.HostPort)
+			}
+		}
+	}
+	if hostPort == 0 {
+		return nil, fmt.Errorf("port %d is not mapped to a host port for %s", port, taskID)
+	}
+
+	ec2Instance, err := s.instance(task)
+	if err != nil {
+		return nil, err
+	}
+
+	host := aws.StringValue(ec2Instance.PrivateIpAddress)
+	if host == "" {
+		return nil, fmt.Errorf("instance %s does not have a private ip address", aws.StringValue(ec2Instance.InstanceId))
+	}
+
+	return net.Dial("tcp", net.JoinHostPort(host, strconv.FormatInt(hostPort, 10)))
+}
+
+// Cordon sets the cordon attribute on the container instance for the EC2
+// instance, so that new tasks are no longer placed on it.
+func (s *Scheduler) Cordon(ctx context.Context, hostID string) error {
+	containerInstance, err := s.containerInstance(hostID)
+	if err != nil {
+		return err
+	}
+
+	return s.cordon(containerInstance)
+}
+
+// cordon sets the cordon attribute on the container instance.
+func (s *Scheduler) cordon(containerInstance *string) error {
+	_, err := s.ecs.PutAttributes(&ecs.PutAttributesInput{
+		Cluster: aws.String(s.Cluster),
+		Attributes: []*ecs.Attribute{
+			{
+				Name:       aws.String(cordonAttribute),
+				Value:      aws.String("true"),
+				TargetId:   containerInstance,
+				TargetType: aws.String(ecs.TargetTypeContainerInstance),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error cordoning container instance: %v", err)
+	}
+	return nil
+}
+
+// Uncordon removes the cordon attribute from the container instance, and
+// returns it to the ACTIVE state if it was draining.
+func (s *Scheduler) Uncordon(ctx context.Context, hostID string) error {
+	containerInstance, err := s.containerInstance(hostID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.updateContainerInstanceState(containerInstance, ecs.ContainerInstanceStatusActive); err != nil {
+		return err
+	}
+
+	_, err = s.ecs.DeleteAttributes(&ecs.DeleteAttributesInput{
+		Cluster: aws.String(s.Cluster),
+		Attributes: []*ecs.Attribute{
+			{
+				Name:       aws.String(cordonAttribute),
+				TargetId:   containerInstance,
+				TargetType: aws.String(ecs.TargetTypeContainerInstance),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error uncordoning container instance: %v", err)
+	}
+	return nil
+}
+
+// Drain cordons the container instance, then sets it to the DRAINING state.
+// ECS will replace the service tasks on the instance with tasks on other
+// instances, without letting the number of running tasks for a service drop
+// below its minimum healthy percent. One-off tasks are left running until they
+// exit.
+func (s *Scheduler) Drain(ctx context.Context, hostID string) error {
+	containerInstance, err := s.containerInstance(hostID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.cordon(containerInstance); err != nil {
+		return err
+	}
+
+	return s.updateContainerInstanceState(containerInstance, ecs.ContainerInstanceStatusDraining)
+}
+
+// Machines returns the container instances in the cluster, with the CPU and
+// memory that they've registered with ECS, and how much of it is reserved by
+// running tasks.
+func (s *Scheduler) Machines(ctx context.Context) ([]*twelvefactor.Machine, error) {
+	var arns []*string
+	if err := s.ecs.ListContainerInstancesPages(&ecs.ListContainerInstancesInput{
+		Cluster: aws.String(s.Cluster),
+	}, func(resp *ecs.ListContainerInstancesOutput, lastPage bool) bool {
+		arns = append(arns, resp.ContainerInstanceArns...)
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("error listing container instances: %v", err)
+	}
+
+	var machines []*twelvefactor.Machine
+	for _, chunk := range chunkStrings(arns, MaxDescribeContainerInstances) {
+		resp, err := s.ecs.DescribeContainerInstances(&ecs.DescribeContainerInstancesInput{
+			Cluster:            aws.String(s.Cluster),
+			ContainerInstances: chunk,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ci := range resp.ContainerInstances {
+			total := resources(ci.RegisteredResources)
+			remaining := resources(ci.RemainingResources)
+			machines = append(machines, &twelvefactor.Machine{
+				Host:  newHost(ci),
+				Total: total,
+				Allocated: twelvefactor.Resources{
+					CPU:    total.CPU - remaining.CPU,
+					Memory: total.Memory - remaining.Memory,
+					GPU:    total.GPU - remaining.GPU,
+				},
+				Tasks: int(aws.Int64Value(ci.RunningTasksCount)),
+			})
+		}
+	}
+
+	return machines, nil
+}
+
+// resources converts the CPU and MEMORY resources of a container instance.
+// ECS reports memory in MiB.
+func resources(rs []*ecs.Resource) twelvefactor.Resources {
+	var r twelvefactor.Resources
+	for _, v := range rs {
+		switch aws.StringValue(v.Name) {
+		case "CPU":
+			r.CPU = uint(aws.Int64Value(v.IntegerValue))
+		case "MEMORY":
+			r.Memory = uint(aws.Int64Value(v.IntegerValue)) * bytesize.MB
+		case "GPU":
+			// GPUs are reported as the set of available GPU ids.
+			r.GPU = uint(len(v.StringSetValue))
+		}
+	}
+	return r
+}
+
+// containerInstance returns the ARN of the container instance in the cluster
+// for the given EC2 instance id.
+func (s *Scheduler) containerInstance(hostID string) (*string, error) {
+	resp, err := s.ecs.ListContainerInstances(&ecs.ListContainerInstancesInput{
+		Cluster: aws.String(s.Cluster),
+		Filter:  aws.String(fmt.Sprintf("ec2InstanceId == %s", hostID)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing container instances: %v", err)
+	}
+
+	if len(resp.ContainerInstanceArns) == 0 {
+		return nil, fmt.Errorf("no container instance found for %s", hostID)
+	}
+
+	return resp.ContainerInstanceArns[0], nil
+}
+
+// updateContainerInstanceState sets the status of the container instance.
+func (s *Scheduler) updateContainerInstanceState(containerInstance *string, status string) error {
+	resp, err := s.ecs.UpdateContainerInstancesState(&ecs.UpdateContainerInstancesStateInput{
+		Cluster:            aws.String(s.Cluster),
+		ContainerInstances: []*string{containerInstance},
+		Status:             aws.String(status),
+	})
+	if err != nil {
+		return fmt.Errorf("error updating container instance state: %v", err)
+	}
+
+	for _, f := range resp.Failures {
+		return fmt.Errorf("error updating container instance %s: %s", aws.StringValue(f.Arn), aws.StringValue(f.Reason))
+	}
+
+	return nil
+}
+
+// task returns the ECS task with the given id, ensuring that it belongs to the
+// app.
+func (s *Scheduler) task(app string, taskID string) (*ecs.Task, error) {
+	tasks, err := s.tasks(app)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tasks {
+		id, err := arn.ResourceID(aws.StringValue(t.TaskArn))
+		if err != nil {
+			return nil, err
+		}
+
+		if id == taskID {
+			return t, nil
+		}
+	}
+
+	return nil, errTaskNotFound
+}
+
 // Run registers a TaskDefinition for the process, and calls RunTask.
 func (m *Scheduler) Run(ctx context.Context, app *twelvefactor.Manifest) error {
 	for _, process := range app.Processes {
@@ -956,12 +1693,39 @@ func (m *Scheduler) Run(ctx context.Context, app *twelvefactor.Manifest) error {
 			containerDefinition.DockerLabels["docker.config.OpenStdin"] = aws.String("true")
 		}
 
+		if process.GPUs > 0 {
+			return errors.New("GPUs are not supported for one-off processes")
+		}
+
+		if hasCapabilities(process) {
+			return errors.New("capabilities are not supported for one-off processes")
+		}
+
+		var volumes []*ecs.Volume
+		for _, v := range process.Volumes {
+			if v.Type != twelvefactor.VolumeHost {
+				return fmt.Errorf("%s volumes are not supported for one-off processes", v.Type)
+			}
+			volumes = append(volumes, &ecs.Volume{
+				Name: aws.String(v.Name),
+				Host: &ecs.HostVolumeProperties{SourcePath: aws.String(v.Source)},
+			})
+		}
+
+		containerDefinitions := []*ecs.ContainerDefinition{
+			containerDefinition,
+		}
+		if t, ok := m.Template.(interface {
+			SidecarDefinitions(*twelvefactor.Manifest, *twelvefactor.Process) []*ecs.ContainerDefinition
+		}); ok {
+			containerDefinitions = append(containerDefinitions, t.SidecarDefinitions(app, process)...)
+		}
+
 		resp, err := m.ecs.RegisterTaskDefinition(&ecs.RegisterTaskDefinitionInput{
-			Family:      aws.String(fmt.Sprintf("%s--%s", app.AppID, process.Type)),
-			TaskRoleArn: taskRoleArn(app),
-			ContainerDefinitions: []*ecs.ContainerDefinition{
-				containerDefinition,
-			},
+			Family:               aws.String(fmt.Sprintf("%s--%s", app.AppID, process.Type)),
+			TaskRoleArn:          taskRoleArn(app),
+			ContainerDefinitions: containerDefinitions,
+			Volumes:              volumes,
 		})
 		if err != nil {
 			return fmt.Errorf("error registering TaskDefinition: %v", err)
@@ -974,10 +1738,24 @@ func (m *Scheduler) Run(ctx context.Context, app *twelvefactor.Manifest) error {
 			StartedBy:      aws.String(app.AppID),
 		}
 
+		input.PlacementConstraints = []*ecs.PlacementConstraint{
+			{
+				Type:       aws.String(ecs.PlacementConstraintTypeMemberOf),
+				Expression: aws.String(cordonConstraint),
+			},
+		}
+
+		if c := capacityConstraint(process); c != "" {
+			input.PlacementConstraints = append(input.PlacementConstraints, &ecs.PlacementConstraint{
+				Type:       aws.String(ecs.PlacementConstraintTypeMemberOf),
+				Expression: aws.String(c),
+			})
+		}
+
 		if v := process.ECS; v != nil {
-			input.PlacementConstraints = v.PlacementConstraints
-			input.PlacementStrategy = v.PlacementStrategy
+			input.PlacementConstraints = append(input.PlacementConstraints, v.PlacementConstraints...)
 		}
+		input.PlacementStrategy = placementStrategy(process, m.PlacementStrategy)
 
 		runResp, err := m.ecs.RunTask(input)
 		if err != nil {
@@ -1014,24 +1792,6 @@ func (m *Scheduler) attach(ctx context.Context, task *ecs.Task, stdin io.Reader,
 		fmt.Fprintf(stderr, "Attaching to %s...\r\n", a.Resource)
 	}
 
-	descContainerInstanceResp, err := m.ecs.DescribeContainerInstances(&ecs.DescribeContainerInstancesInput{
-		Cluster:            task.ClusterArn,
-		ContainerInstances: []*string{task.ContainerInstanceArn},
-	})
-	if err != nil {
-		return fmt.Errorf("error describing container instance (%s): %v", aws.StringValue(task.ContainerInstanceArn), err)
-	}
-
-	containerInstance := descContainerInstanceResp.ContainerInstances[0]
-	descInstanceResp, err := m.ec2.DescribeInstances(&ec2.DescribeInstancesInput{
-		InstanceIds: []*string{containerInstance.Ec2InstanceId},
-	})
-	if err != nil {
-		return fmt.Errorf("error describing ec2 instance (%s): %v", aws.StringValue(containerInstance.Ec2InstanceId), err)
-	}
-
-	ec2Instance := descInstanceResp.Reservations[0].Instances[0]
-
 	// Wait for the task to start running. It will stay in the
 	// PENDING state while the container is being pulled.
 	if err := m.ecs.WaitUntilTasksNotPending(&ecs.DescribeTasksInput{
@@ -1041,11 +1801,42 @@ func (m *Scheduler) attach(ctx context.Context, task *ecs.Task, stdin io.Reader,
 		return fmt.Errorf("error waiting for %s to transition from PENDING state: %s", aws.StringValue(task.TaskArn), err)
 	}
 
+	d, containerID, err := m.container(task)
+	if err != nil {
+		return err
+	}
+
+	if err := d.AttachToContainer(docker.AttachToContainerOptions{
+		Container:    containerID,
+		InputStream:  stdin,
+		OutputStream: stdout,
+		ErrorStream:  stderr,
+		Logs:         true,
+		Stream:       true,
+		Stdin:        true,
+		Stdout:       true,
+		Stderr:       true,
+		RawTerminal:  true,
+	}); err != nil {
+		return fmt.Errorf("error attaching to container (%s): %v", containerID, err)
+	}
+
+	return nil
+}
+
+// container opens a new connection to the Docker daemon on the EC2 instance
+// where the task is running, and returns the id of the task's container.
+func (m *Scheduler) container(task *ecs.Task) (DockerClient, string, error) {
+	ec2Instance, err := m.instance(task)
+	if err != nil {
+		return nil, "", err
+	}
+
 	// Open a new connection to the Docker daemon on the EC2
 	// instance where the task is running.
 	d, err := m.NewDockerClient(ec2Instance)
 	if err != nil {
-		return fmt.Errorf("error connecting to docker daemon on %s: %v", aws.StringValue(ec2Instance.InstanceId), err)
+		return nil, "", fmt.Errorf("error connecting to docker daemon on %s: %v", aws.StringValue(ec2Instance.InstanceId), err)
 	}
 
 	// Find the container id for the ECS task.
@@ -1059,31 +1850,43 @@ func (m *Scheduler) attach(ctx context.Context, task *ecs.Task, stdin io.Reader,
 		},
 	})
 	if err != nil {
-		return fmt.Errorf("error listing containers for task: %v", err)
+		return nil, "", fmt.Errorf("error listing containers for task: %v", err)
 	}
 
-	if len(containers) != 1 {
-		return fmt.Errorf("unable to find container for %s running on %s", aws.StringValue(task.TaskArn), aws.StringValue(ec2Instance.InstanceId))
+	// Ignore any sidecars that are running with the process.
+	var ids []string
+	for _, c := range containers {
+		if _, ok := c.Labels[sidecarLabel]; !ok {
+			ids = append(ids, c.ID)
+		}
 	}
 
-	containerID := containers[0].ID
+	if len(ids) != 1 {
+		return nil, "", fmt.Errorf("unable to find container for %s running on %s", aws.StringValue(task.TaskArn), aws.StringValue(ec2Instance.InstanceId))
+	}
 
-	if err := d.AttachToContainer(docker.AttachToContainerOptions{
-		Container:    containerID,
-		InputStream:  stdin,
-		OutputStream: stdout,
-		ErrorStream:  stderr,
-		Logs:         true,
-		Stream:       true,
-		Stdin:        true,
-		Stdout:       true,
-		Stderr:       true,
-		RawTerminal:  true,
-	}); err != nil {
-		return fmt.Errorf("error attaching to container (%s): %v", containerID, err)
+	return d, ids[0], nil
+}
+
+// instance returns the EC2 instance where the task is running.
+func (m *Scheduler) instance(task *ecs.Task) (*ec2.Instance, error) {
+	descContainerInstanceResp, err := m.ecs.DescribeContainerInstances(&ecs.DescribeContainerInstancesInput{
+		Cluster:            task.ClusterArn,
+		ContainerInstances: []*string{task.ContainerInstanceArn},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error describing container instance (%s): %v", aws.StringValue(task.ContainerInstanceArn), err)
 	}
 
-	return nil
+	containerInstance := descContainerInstanceResp.ContainerInstances[0]
+	descInstanceResp, err := m.ec2.DescribeInstances(&ec2.DescribeInstancesInput{
+		InstanceIds: []*string{containerInstance.Ec2InstanceId},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error describing ec2 instance (%s): %v", aws.StringValue(containerInstance.Ec2InstanceId), err)
+	}
+
+	return descInstanceResp.Reservations[0].Instances[0], nil
 }
 
 // stackName returns the name of the CloudFormation stack for the app id.
@@ -1188,10 +1991,21 @@ func taskDefinitionToProcess(td *ecs.TaskDefinition) (*twelvefactor.Process, err
 		}
 	}
 
+	labels := make(map[string]string)
+	for k, v := range container.DockerLabels {
+		labels[k] = aws.StringValue(v)
+	}
+
+	// The image is informational, so an image that can't be decoded is
+	// ignored.
+	img, _ := image.Decode(aws.StringValue(container.Image))
+
 	return &twelvefactor.Process{
 		Type:      aws.StringValue(container.Name),
+		Image:     img,
 		Command:   command,
 		Env:       env,
+		Labels:    labels,
 		CPUShares: uint(*container.Cpu),
 		Memory:    uint(*container.Memory) * bytesize.MB,
 		Nproc:     uint(softLimit(container.Ulimits, "nproc")),
@@ -1364,8 +2178,12 @@ func deploymentsToWatch(stack *cloudformation.Stack) (map[string]*ecsDeployment,
 }
 
 func publish(ctx context.Context, stream twelvefactor.StatusStream, msg string) {
+	publishStatus(ctx, stream, twelvefactor.Status{Message: msg})
+}
+
+func publishStatus(ctx context.Context, stream twelvefactor.StatusStream, status twelvefactor.Status) {
 	if stream != nil {
-		if err := stream.Publish(twelvefactor.Status{Message: msg}); err != nil {
+		if err := stream.Publish(status); err != nil {
 			logger.Warn(ctx, fmt.Sprintf("error publishing to stream: %v", err))
 		}
 	}
@@ -25,6 +25,7 @@ import (
 	"github.com/remind101/empire/dbtest"
 	"github.com/remind101/empire/internal/uuid"
 	"github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/procfile"
 	"github.com/remind101/empire/twelvefactor"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -1202,6 +1203,7 @@ func TestScheduler_Instances(t *testing.T) {
 			{
 				Ec2InstanceId:        aws.String("ec2-instance-id-1"),
 				ContainerInstanceArn: aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-1"),
+				AgentConnected:       aws.Bool(true),
 			},
 		},
 	}, nil)
@@ -1214,6 +1216,7 @@ func TestScheduler_Instances(t *testing.T) {
 			{
 				Ec2InstanceId:        aws.String("ec2-instance-id-2"),
 				ContainerInstanceArn: aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-2"),
+				AgentConnected:       aws.Bool(true),
 			},
 		},
 	}, nil)
@@ -1221,6 +1224,7 @@ func TestScheduler_Instances(t *testing.T) {
 	instances, err := s.Tasks(context.Background(), "c9366591-ab68-4d49-a333-95ce5a23df68")
 	assert.NoError(t, err)
 	assert.Equal(t, &twelvefactor.Task{
+		App:       "c9366591-ab68-4d49-a333-95ce5a23df68",
 		ID:        "0b69d5c0-d655-4695-98cd-5d2d526d9d5a",
 		Host:      twelvefactor.Host{ID: "ec2-instance-id-1"},
 		UpdatedAt: dt,
@@ -1233,6 +1237,7 @@ func TestScheduler_Instances(t *testing.T) {
 		},
 	}, instances[0])
 	assert.Equal(t, &twelvefactor.Task{
+		App:       "c9366591-ab68-4d49-a333-95ce5a23df68",
 		ID:        "c09f0188-7f87-4b0f-bfc3-16296622b6fe",
 		Host:      twelvefactor.Host{ID: "ec2-instance-id-2"},
 		UpdatedAt: dt,
@@ -1250,6 +1255,164 @@ func TestScheduler_Instances(t *testing.T) {
 	e.AssertExpectations(t)
 }
 
+func TestScheduler_hosts(t *testing.T) {
+	e := new(mockECSClient)
+	s := &Scheduler{
+		ecs: e,
+	}
+
+	tasks := []*ecs.Task{
+		{
+			ClusterArn:           aws.String("arn:aws:ecs:us-east-1:012345678910:cluster/cluster"),
+			ContainerInstanceArn: aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-1"),
+		},
+		{
+			ClusterArn:           aws.String("arn:aws:ecs:us-east-1:012345678910:cluster/cluster"),
+			ContainerInstanceArn: aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-2"),
+		},
+		{
+			ClusterArn:           aws.String("arn:aws:ecs:us-east-1:012345678910:cluster/cluster"),
+			ContainerInstanceArn: aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-3"),
+		},
+	}
+
+	e.On("DescribeContainerInstances", &ecs.DescribeContainerInstancesInput{
+		Cluster: aws.String("arn:aws:ecs:us-east-1:012345678910:cluster/cluster"),
+		ContainerInstances: []*string{
+			aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-1"),
+			aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-2"),
+			aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-3"),
+		},
+	}).Return(&ecs.DescribeContainerInstancesOutput{
+		ContainerInstances: []*ecs.ContainerInstance{
+			{
+				Ec2InstanceId:        aws.String("ec2-instance-id-1"),
+				ContainerInstanceArn: aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-1"),
+				AgentConnected:       aws.Bool(true),
+				Status:               aws.String("ACTIVE"),
+			},
+			{
+				Ec2InstanceId:        aws.String("ec2-instance-id-2"),
+				ContainerInstanceArn: aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-2"),
+				AgentConnected:       aws.Bool(false),
+				Status:               aws.String("DRAINING"),
+				Attributes: []*ecs.Attribute{
+					{Name: aws.String("empire.capacity"), Value: aws.String("spot")},
+				},
+			},
+		},
+		Failures: []*ecs.Failure{
+			{
+				Arn:    aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-3"),
+				Reason: aws.String("MISSING"),
+			},
+		},
+	}, nil)
+
+	hosts, err := s.hosts(tasks)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]twelvefactor.Host{
+		"arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-1": {ID: "ec2-instance-id-1", Capacity: "on-demand"},
+		"arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-2": {ID: "ec2-instance-id-2", Lost: true, Draining: true, Capacity: "spot"},
+		"arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-3": {Lost: true},
+	}, hosts)
+
+	e.AssertExpectations(t)
+}
+
+func TestPlacementStrategy(t *testing.T) {
+	custom := []*ecs.PlacementStrategy{
+		{Type: aws.String("random")},
+	}
+
+	tests := []struct {
+		process         *twelvefactor.Process
+		clusterStrategy string
+		strategy        []*ecs.PlacementStrategy
+	}{
+		// Without a strategy for the process or the cluster, ECS's
+		// default placement is used.
+		{&twelvefactor.Process{}, "", nil},
+		{&twelvefactor.Process{ECS: &procfile.ECS{}}, "", nil},
+
+		// The strategy of the cluster.
+		{&twelvefactor.Process{}, "binpack", placementStrategies["binpack"]},
+		{&twelvefactor.Process{}, "spread", placementStrategies["spread"]},
+		{&twelvefactor.Process{ECS: &procfile.ECS{PlacementConstraints: []*ecs.PlacementConstraint{{Type: aws.String("distinctInstance")}}}}, "spread", placementStrategies["spread"]},
+
+		// The placement of the process overrides the cluster.
+		{&twelvefactor.Process{Placement: "spread"}, "", placementStrategies["spread"]},
+		{&twelvefactor.Process{Placement: "spread"}, "binpack", placementStrategies["spread"]},
+		{&twelvefactor.Process{Placement: "binpack"}, "spread", placementStrategies["binpack"]},
+
+		// An ECS placement strategy in the Procfile overrides the
+		// cluster.
+		{&twelvefactor.Process{ECS: &procfile.ECS{PlacementStrategy: custom}}, "binpack", custom},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.strategy, placementStrategy(tt.process, tt.clusterStrategy))
+	}
+}
+
+func TestScheduler_Machines(t *testing.T) {
+	e := new(mockECSClient)
+	s := &Scheduler{
+		Cluster: "cluster",
+		ecs:     e,
+	}
+
+	e.On("ListContainerInstancesPages", &ecs.ListContainerInstancesInput{
+		Cluster: aws.String("cluster"),
+	}).Return(&ecs.ListContainerInstancesOutput{
+		ContainerInstanceArns: []*string{
+			aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-1"),
+		},
+	}, nil)
+
+	e.On("DescribeContainerInstances", &ecs.DescribeContainerInstancesInput{
+		Cluster: aws.String("cluster"),
+		ContainerInstances: []*string{
+			aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-1"),
+		},
+	}).Return(&ecs.DescribeContainerInstancesOutput{
+		ContainerInstances: []*ecs.ContainerInstance{
+			{
+				Ec2InstanceId:        aws.String("ec2-instance-id-1"),
+				ContainerInstanceArn: aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-1"),
+				AgentConnected:       aws.Bool(true),
+				Status:               aws.String("ACTIVE"),
+				RunningTasksCount:    aws.Int64(3),
+				RegisteredResources: []*ecs.Resource{
+					{Name: aws.String("CPU"), IntegerValue: aws.Int64(2048)},
+					{Name: aws.String("MEMORY"), IntegerValue: aws.Int64(7680)},
+					{Name: aws.String("PORTS"), StringSetValue: []*string{aws.String("22")}},
+				},
+				RemainingResources: []*ecs.Resource{
+					{Name: aws.String("CPU"), IntegerValue: aws.Int64(1280)},
+					{Name: aws.String("MEMORY"), IntegerValue: aws.Int64(6144)},
+				},
+				Attributes: []*ecs.Attribute{
+					{Name: aws.String("ecs.availability-zone"), Value: aws.String("us-east-1a")},
+				},
+			},
+		},
+	}, nil)
+
+	machines, err := s.Machines(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []*twelvefactor.Machine{
+		{
+			Host:      twelvefactor.Host{ID: "ec2-instance-id-1", Capacity: "on-demand", Zone: "us-east-1a"},
+			Total:     twelvefactor.Resources{CPU: 2048, Memory: 7680 * bytesize.MB},
+			Allocated: twelvefactor.Resources{CPU: 768, Memory: 1536 * bytesize.MB},
+			Tasks:     3,
+		},
+	}, machines)
+
+	e.AssertExpectations(t)
+}
+
 func TestScheduler_Instances_ManyTasks(t *testing.T) {
 	db := newDB(t)
 	defer db.Close()
@@ -1336,6 +1499,138 @@ func TestScheduler_Instances_ManyTasks(t *testing.T) {
 	e.AssertExpectations(t)
 }
 
+func TestScheduler_QueryTasks_Host(t *testing.T) {
+	e := new(mockECSClient)
+	s := &Scheduler{
+		Cluster: "cluster",
+		ecs:     e,
+	}
+
+	e.On("ListContainerInstances", &ecs.ListContainerInstancesInput{
+		Cluster: aws.String("cluster"),
+		Filter:  aws.String("ec2InstanceId == ec2-instance-id-1"),
+	}).Return(&ecs.ListContainerInstancesOutput{
+		ContainerInstanceArns: []*string{aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-1")},
+	}, nil)
+
+	e.On("ListTasksPages", &ecs.ListTasksInput{
+		Cluster:           aws.String("cluster"),
+		ContainerInstance: aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-1"),
+	}).Return(&ecs.ListTasksOutput{
+		TaskArns: []*string{
+			aws.String("arn:aws:ecs:us-east-1:012345678910:task/0b69d5c0-d655-4695-98cd-5d2d526d9d5a"),
+			aws.String("arn:aws:ecs:us-east-1:012345678910:task/c09f0188-7f87-4b0f-bfc3-16296622b6fe"),
+		},
+	}, nil)
+
+	dt := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	e.On("DescribeTasks", &ecs.DescribeTasksInput{
+		Cluster: aws.String("cluster"),
+		Tasks: []*string{
+			aws.String("arn:aws:ecs:us-east-1:012345678910:task/0b69d5c0-d655-4695-98cd-5d2d526d9d5a"),
+			aws.String("arn:aws:ecs:us-east-1:012345678910:task/c09f0188-7f87-4b0f-bfc3-16296622b6fe"),
+		},
+	}).Return(&ecs.DescribeTasksOutput{
+		Tasks: []*ecs.Task{
+			{
+				TaskArn:              aws.String("arn:aws:ecs:us-east-1:012345678910:task/0b69d5c0-d655-4695-98cd-5d2d526d9d5a"),
+				TaskDefinitionArn:    aws.String("arn:aws:ecs:us-east-1:012345678910:task-definition/acme-inc-web:0"),
+				ContainerInstanceArn: aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-1"),
+				ClusterArn:           aws.String("arn:aws:ecs:us-east-1:012345678910:cluster/cluster"),
+				LastStatus:           aws.String("RUNNING"),
+				StartedAt:            &dt,
+			},
+			{
+				TaskArn:              aws.String("arn:aws:ecs:us-east-1:012345678910:task/c09f0188-7f87-4b0f-bfc3-16296622b6fe"),
+				TaskDefinitionArn:    aws.String("arn:aws:ecs:us-east-1:012345678910:task-definition/blog-worker:0"),
+				ContainerInstanceArn: aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-1"),
+				ClusterArn:           aws.String("arn:aws:ecs:us-east-1:012345678910:cluster/cluster"),
+				LastStatus:           aws.String("RUNNING"),
+				StartedAt:            &dt,
+			},
+		},
+	}, nil)
+
+	e.On("DescribeTaskDefinition", &ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: aws.String("arn:aws:ecs:us-east-1:012345678910:task-definition/acme-inc-web:0"),
+	}).Return(&ecs.DescribeTaskDefinitionOutput{
+		TaskDefinition: &ecs.TaskDefinition{
+			ContainerDefinitions: []*ecs.ContainerDefinition{
+				{
+					Name:         aws.String("web"),
+					Cpu:          aws.Int64(256),
+					Memory:       aws.Int64(int64(256)),
+					DockerLabels: map[string]*string{"empire.app.id": aws.String("c9366591-ab68-4d49-a333-95ce5a23df68")},
+				},
+			},
+		},
+	}, nil)
+
+	e.On("DescribeTaskDefinition", &ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: aws.String("arn:aws:ecs:us-east-1:012345678910:task-definition/blog-worker:0"),
+	}).Return(&ecs.DescribeTaskDefinitionOutput{
+		TaskDefinition: &ecs.TaskDefinition{
+			ContainerDefinitions: []*ecs.ContainerDefinition{
+				{
+					Name:         aws.String("worker"),
+					Cpu:          aws.Int64(256),
+					Memory:       aws.Int64(int64(256)),
+					DockerLabels: map[string]*string{"empire.app.id": aws.String("f7c16d2e-58a5-4c52-a6c0-8a2e1bd1d1f4")},
+				},
+			},
+		},
+	}, nil)
+
+	e.On("DescribeContainerInstances", &ecs.DescribeContainerInstancesInput{
+		Cluster: aws.String("arn:aws:ecs:us-east-1:012345678910:cluster/cluster"),
+		ContainerInstances: []*string{
+			aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-1"),
+			aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-1"),
+		},
+	}).Return(&ecs.DescribeContainerInstancesOutput{
+		ContainerInstances: []*ecs.ContainerInstance{
+			{
+				Ec2InstanceId:        aws.String("ec2-instance-id-1"),
+				ContainerInstanceArn: aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/container-instance-id-1"),
+				AgentConnected:       aws.Bool(true),
+			},
+		},
+	}, nil)
+
+	// Tasks of every app on the host, that are running the web process.
+	instances, err := s.QueryTasks(context.Background(), twelvefactor.TasksQuery{
+		Host:    "ec2-instance-id-1",
+		Process: "web",
+	})
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(instances)) {
+		assert.Equal(t, "c9366591-ab68-4d49-a333-95ce5a23df68", instances[0].App)
+		assert.Equal(t, "0b69d5c0-d655-4695-98cd-5d2d526d9d5a", instances[0].ID)
+		assert.Equal(t, "ec2-instance-id-1", instances[0].Host.ID)
+	}
+
+	e.AssertExpectations(t)
+}
+
+func TestScheduler_QueryTasks_HostNotInCluster(t *testing.T) {
+	e := new(mockECSClient)
+	s := &Scheduler{
+		Cluster: "cluster",
+		ecs:     e,
+	}
+
+	e.On("ListContainerInstances", &ecs.ListContainerInstancesInput{
+		Cluster: aws.String("cluster"),
+		Filter:  aws.String("ec2InstanceId == i-042f39dc"),
+	}).Return(&ecs.ListContainerInstancesOutput{}, nil)
+
+	instances, err := s.QueryTasks(context.Background(), twelvefactor.TasksQuery{Host: "i-042f39dc"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(instances))
+
+	e.AssertExpectations(t)
+}
+
 func TextExtractProcessData(t *testing.T) {
 	output := "statuses=arn:aws:ecs:us-east-1:897883143566:service/stage-app-statuses-16NM105QFD6UO,statuses_retry=arn:aws:ecs:us-east-1:897883143566:service/stage-app-statusesretry-DKG2XMH75H5N"
 	services := extractProcessData(output)
@@ -1347,6 +1642,15 @@ func TextExtractProcessData(t *testing.T) {
 	assert.Equal(t, expected, services)
 }
 
+func TestTaskDefinitionFamily(t *testing.T) {
+	family, err := taskDefinitionFamily("arn:aws:ecs:us-east-1:012345678910:task-definition/c9366591-ab68-4d49-a333-95ce5a23df68--run:12")
+	assert.NoError(t, err)
+	assert.Equal(t, "c9366591-ab68-4d49-a333-95ce5a23df68--run", family)
+
+	_, err = taskDefinitionFamily("c9366591-ab68-4d49-a333-95ce5a23df68--run:12")
+	assert.Error(t, err)
+}
+
 func TestChunkStrings(t *testing.T) {
 	tests := []struct {
 		in  []*string
@@ -1487,6 +1791,103 @@ func TestScheduler_Restart(t *testing.T) {
 	x.AssertExpectations(t)
 }
 
+func TestScheduler_Drain(t *testing.T) {
+	e := new(mockECSClient)
+	s := &Scheduler{
+		Cluster: "cluster",
+		ecs:     e,
+	}
+
+	containerInstance := aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/4c543eed-f83f-47da-b1d8-3d23f1da4c64")
+
+	e.On("ListContainerInstances", &ecs.ListContainerInstancesInput{
+		Cluster: aws.String("cluster"),
+		Filter:  aws.String("ec2InstanceId == i-042f39dc"),
+	}).Return(&ecs.ListContainerInstancesOutput{
+		ContainerInstanceArns: []*string{containerInstance},
+	}, nil)
+
+	e.On("PutAttributes", &ecs.PutAttributesInput{
+		Cluster: aws.String("cluster"),
+		Attributes: []*ecs.Attribute{
+			{
+				Name:       aws.String("empire.cordoned"),
+				Value:      aws.String("true"),
+				TargetId:   containerInstance,
+				TargetType: aws.String("container-instance"),
+			},
+		},
+	}).Return(&ecs.PutAttributesOutput{}, nil)
+
+	e.On("UpdateContainerInstancesState", &ecs.UpdateContainerInstancesStateInput{
+		Cluster:            aws.String("cluster"),
+		ContainerInstances: []*string{containerInstance},
+		Status:             aws.String("DRAINING"),
+	}).Return(&ecs.UpdateContainerInstancesStateOutput{}, nil)
+
+	err := s.Drain(context.Background(), "i-042f39dc")
+	assert.NoError(t, err)
+
+	e.AssertExpectations(t)
+}
+
+func TestScheduler_Uncordon(t *testing.T) {
+	e := new(mockECSClient)
+	s := &Scheduler{
+		Cluster: "cluster",
+		ecs:     e,
+	}
+
+	containerInstance := aws.String("arn:aws:ecs:us-east-1:012345678910:container-instance/4c543eed-f83f-47da-b1d8-3d23f1da4c64")
+
+	e.On("ListContainerInstances", &ecs.ListContainerInstancesInput{
+		Cluster: aws.String("cluster"),
+		Filter:  aws.String("ec2InstanceId == i-042f39dc"),
+	}).Return(&ecs.ListContainerInstancesOutput{
+		ContainerInstanceArns: []*string{containerInstance},
+	}, nil)
+
+	e.On("UpdateContainerInstancesState", &ecs.UpdateContainerInstancesStateInput{
+		Cluster:            aws.String("cluster"),
+		ContainerInstances: []*string{containerInstance},
+		Status:             aws.String("ACTIVE"),
+	}).Return(&ecs.UpdateContainerInstancesStateOutput{}, nil)
+
+	e.On("DeleteAttributes", &ecs.DeleteAttributesInput{
+		Cluster: aws.String("cluster"),
+		Attributes: []*ecs.Attribute{
+			{
+				Name:       aws.String("empire.cordoned"),
+				TargetId:   containerInstance,
+				TargetType: aws.String("container-instance"),
+			},
+		},
+	}).Return(&ecs.DeleteAttributesOutput{}, nil)
+
+	err := s.Uncordon(context.Background(), "i-042f39dc")
+	assert.NoError(t, err)
+
+	e.AssertExpectations(t)
+}
+
+func TestScheduler_Drain_NoContainerInstance(t *testing.T) {
+	e := new(mockECSClient)
+	s := &Scheduler{
+		Cluster: "cluster",
+		ecs:     e,
+	}
+
+	e.On("ListContainerInstances", &ecs.ListContainerInstancesInput{
+		Cluster: aws.String("cluster"),
+		Filter:  aws.String("ec2InstanceId == i-042f39dc"),
+	}).Return(&ecs.ListContainerInstancesOutput{}, nil)
+
+	err := s.Drain(context.Background(), "i-042f39dc")
+	assert.EqualError(t, err, "no container instance found for i-042f39dc")
+
+	e.AssertExpectations(t)
+}
+
 func TestScheduler_Run_Detached(t *testing.T) {
 	db := newDB(t)
 	defer db.Close()
@@ -1521,6 +1922,9 @@ func TestScheduler_Run_Detached(t *testing.T) {
 		Cluster:        aws.String(""),
 		Count:          aws.Int64(1),
 		StartedBy:      aws.String("c9366591-ab68-4d49-a333-95ce5a23df68"),
+		PlacementConstraints: []*ecs.PlacementConstraint{
+			{Type: aws.String("memberOf"), Expression: aws.String("attribute:empire.cordoned !exists")},
+		},
 	}).Return(&ecs.RunTaskOutput{
 		Tasks: []*ecs.Task{
 			&ecs.Task{
@@ -1599,6 +2003,9 @@ func TestScheduler_Run_Attached(t *testing.T) {
 		Cluster:        aws.String(""),
 		Count:          aws.Int64(1),
 		StartedBy:      aws.String("c9366591-ab68-4d49-a333-95ce5a23df68"),
+		PlacementConstraints: []*ecs.PlacementConstraint{
+			{Type: aws.String("memberOf"), Expression: aws.String("attribute:empire.cordoned !exists")},
+		},
 	}).Return(&ecs.RunTaskOutput{
 		Tasks: []*ecs.Task{
 			&ecs.Task{
@@ -1852,6 +2259,32 @@ func (m *mockECSClient) StopTask(input *ecs.StopTaskInput) (*ecs.StopTaskOutput,
 	return args.Get(0).(*ecs.StopTaskOutput), args.Error(1)
 }
 
+func (m *mockECSClient) ListContainerInstances(input *ecs.ListContainerInstancesInput) (*ecs.ListContainerInstancesOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*ecs.ListContainerInstancesOutput), args.Error(1)
+}
+
+func (m *mockECSClient) ListContainerInstancesPages(input *ecs.ListContainerInstancesInput, fn func(p *ecs.ListContainerInstancesOutput, lastPage bool) (shouldContinue bool)) error {
+	args := m.Called(input)
+	fn(args.Get(0).(*ecs.ListContainerInstancesOutput), true)
+	return args.Error(1)
+}
+
+func (m *mockECSClient) UpdateContainerInstancesState(input *ecs.UpdateContainerInstancesStateInput) (*ecs.UpdateContainerInstancesStateOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*ecs.UpdateContainerInstancesStateOutput), args.Error(1)
+}
+
+func (m *mockECSClient) PutAttributes(input *ecs.PutAttributesInput) (*ecs.PutAttributesOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*ecs.PutAttributesOutput), args.Error(1)
+}
+
+func (m *mockECSClient) DeleteAttributes(input *ecs.DeleteAttributesInput) (*ecs.DeleteAttributesOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*ecs.DeleteAttributesOutput), args.Error(1)
+}
+
 func (m *mockECSClient) WaitUntilTasksNotPending(input *ecs.DescribeTasksInput) error {
 	args := m.Called(input)
 	return args.Error(0)
@@ -1880,6 +2313,26 @@ func (m *mockDockerClient) AttachToContainer(options docker.AttachToContainerOpt
 	return args.Error(0)
 }
 
+func (m *mockDockerClient) CreateExec(options docker.CreateExecOptions) (*docker.Exec, error) {
+	args := m.Called(options)
+	return args.Get(0).(*docker.Exec), args.Error(1)
+}
+
+func (m *mockDockerClient) StartExec(id string, options docker.StartExecOptions) error {
+	args := m.Called(id, options)
+	return args.Error(0)
+}
+
+func (m *mockDockerClient) UploadToContainer(id string, options docker.UploadToContainerOptions) error {
+	args := m.Called(id, options)
+	return args.Error(0)
+}
+
+func (m *mockDockerClient) DownloadFromContainer(id string, options docker.DownloadFromContainerOptions) error {
+	args := m.Called(id, options)
+	return args.Error(0)
+}
+
 // fakeAfter is a helper function that will resolve immediately
 // except in cases where a lockWait is specified.
 func fakeAfter(d time.Duration) <-chan time.Time {
@@ -0,0 +1,74 @@
+package cloudformation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// stackOperations tracks the stack operations that are running in the
+// background, by stack name. Stack updates return as soon as they're
+// submitted, or after lockWait when an earlier update of the stack is still in
+// progress, and the rest of the operation happens in the background.
+type stackOperations struct {
+	sync.Mutex
+	wg     sync.WaitGroup
+	stacks map[string]int
+}
+
+func (o *stackOperations) add(stackName string) {
+	o.Lock()
+	defer o.Unlock()
+
+	if o.stacks == nil {
+		o.stacks = make(map[string]int)
+	}
+	o.stacks[stackName]++
+	o.wg.Add(1)
+}
+
+func (o *stackOperations) done(stackName string) {
+	o.Lock()
+	defer o.Unlock()
+
+	o.stacks[stackName]--
+	if o.stacks[stackName] == 0 {
+		delete(o.stacks, stackName)
+	}
+	o.wg.Done()
+}
+
+// pending returns the names of the stacks that have operations in progress.
+func (o *stackOperations) pending() []string {
+	o.Lock()
+	defer o.Unlock()
+
+	var names []string
+	for name := range o.stacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Wait waits for the stack operations that are running in the background to
+// complete. If ctx is done first, the error names the stacks that still have
+// operations in progress. Updates that were still waiting for an earlier
+// update of the same stack are never submitted.
+func (s *Scheduler) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.operations.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("stack operations still in progress on %s: %v", strings.Join(s.operations.pending(), ", "), ctx.Err())
+	}
+}
@@ -0,0 +1,30 @@
+package cloudformation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestScheduler_Wait(t *testing.T) {
+	s := &Scheduler{}
+
+	// Nothing in progress.
+	assert.NoError(t, s.Wait(context.Background()))
+
+	s.operations.add("acme-inc")
+	s.operations.add("api")
+	s.operations.add("api")
+	s.operations.done("api")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.EqualError(t, s.Wait(ctx), "stack operations still in progress on acme-inc, api: context deadline exceeded")
+
+	s.operations.done("acme-inc")
+	s.operations.done("api")
+	assert.NoError(t, s.Wait(context.Background()))
+	assert.Nil(t, s.operations.pending())
+}
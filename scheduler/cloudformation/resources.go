@@ -1,5 +1,7 @@
 package cloudformation
 
+import "github.com/aws/aws-sdk-go/service/ecs"
+
 type PortMappingProperties struct {
 	ContainerPort interface{} `json:",omitempty"`
 	HostPort      interface{} `json:",omitempty"`
@@ -22,6 +24,22 @@ type ContainerDefinitionProperties struct {
 	PortMappings     []*PortMappingProperties `json:",omitempty"`
 	Ulimits          interface{}              `json:",omitempty"`
 	LogConfiguration interface{}              `json:",omitempty"`
+	Links            []*string                `json:",omitempty"`
+	MountPoints      []*ecs.MountPoint        `json:",omitempty"`
+	LinuxParameters  interface{}              `json:",omitempty"`
+
+	RepositoryCredentials *RepositoryCredentialsProperties `json:",omitempty"`
+	ResourceRequirements  interface{}                      `json:",omitempty"`
+
+	HealthCheck interface{} `json:",omitempty"`
+
+	Privileged             interface{} `json:",omitempty"`
+	ReadonlyRootFilesystem interface{} `json:",omitempty"`
+	DockerSecurityOptions  interface{} `json:",omitempty"`
+}
+
+type RepositoryCredentialsProperties struct {
+	CredentialsParameter interface{}
 }
 
 type TaskDefinitionProperties struct {
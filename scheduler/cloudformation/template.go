@@ -2,6 +2,7 @@ package cloudformation
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -30,6 +31,10 @@ var (
 	Join   = troposphere.Join
 )
 
+// sidecarLabel is the docker label that's set on sidecar containers, with the
+// name of the sidecar as the value.
+const sidecarLabel = "empire.sidecar"
+
 // Load balancer types
 const (
 	classicLoadBalancer     = "elb"
@@ -96,11 +101,26 @@ const (
 	defaultConnectionDrainingTimeout int64 = 30
 	defaultCNAMETTL                        = 60
 
+	// The name of the ELB policy that enables sticky sessions.
+	stickySessionsPolicy = "StickySessions"
+
+	// The service and method that target groups use to health check gRPC
+	// processes. See
+	// https://github.com/grpc/grpc/blob/master/doc/health-checking.md
+	grpcHealthCheckPath = "/grpc.health.v1.Health/Check"
+
+	// The priority of the listener rules that forward authenticated
+	// requests. It's the lowest priority that a rule can have, so that
+	// routing rules are evaluated first.
+	basicAuthRulePriority = 50000
+
 	runTaskFunction = "RunTaskFunction"
 
 	appEnvironment = "AppEnvironment"
 
 	restartLabel = "cloudformation.restart-key"
+
+	defaultVolumeDriver = "rexray/ebs"
 )
 
 // This implements the Template interface to create a suitable CloudFormation
@@ -142,6 +162,27 @@ type EmpireTemplate struct {
 
 	LogConfiguration *ecs.LogConfiguration
 
+	// The Docker volume driver used to provision ebs volumes. The default
+	// is "rexray/ebs".
+	VolumeDriver string
+
+	// If provided, a placement constraint expression that's added to
+	// processes that require GPUs, to place them on hosts with GPUs (e.g.
+	// "attribute:empire.gpu exists").
+	GPUConstraint string
+
+	// The placement strategy of processes that don't have their own:
+	// twelvefactor.PlacementBinpack, twelvefactor.PlacementSpread, or empty
+	// for the ECS default.
+	PlacementStrategy string
+
+	// The security groups of the container instances in each cluster,
+	// keyed by the name of the cluster (an empty string for the default
+	// cluster). The internal load balancers of isolated apps only accept
+	// connections from the clusters that the apps in their ingress rules
+	// run in.
+	ClusterSecurityGroups map[string]string
+
 	// Any extra outputs to attach to the template.
 	ExtraOutputs map[string]troposphere.Output
 }
@@ -241,11 +282,34 @@ func (t *EmpireTemplate) Build(data *TemplateData) (*troposphere.Template, error
 		}
 	}
 
+	routes, err := t.addRoutes(tmpl, app, data.StackTags)
+	if err != nil {
+		return tmpl, err
+	}
+
 	for _, p := range app.Processes {
 		if p.Env == nil {
 			p.Env = make(map[string]string)
 		}
 
+		if taskDefinitionResourceType(app) == "Custom::ECSTaskDefinition" {
+			if p.GPUs > 0 {
+				return tmpl, errors.New("GPUs are not supported with custom task definitions")
+			}
+			for _, v := range p.Volumes {
+				if v.Type != twelvefactor.VolumeHost {
+					return tmpl, fmt.Errorf("%s volumes are not supported with custom task definitions", v.Type)
+				}
+			}
+			if hasCapabilities(p) {
+				return tmpl, errors.New("capabilities are not supported with custom task definitions")
+			}
+		}
+
+		if previousProcess(app, p) != nil && (taskDefinitionResourceType(app) == "Custom::ECSTaskDefinition" || taskDefinitionResourceType(app.Previous) == "Custom::ECSTaskDefinition") {
+			return tmpl, errors.New("traffic can't be split between releases with custom task definitions")
+		}
+
 		tmpl.Parameters[scaleParameter(p.Type)] = troposphere.Parameter{
 			Type: "String",
 		}
@@ -255,7 +319,7 @@ func (t *EmpireTemplate) Build(data *TemplateData) (*troposphere.Template, error
 			taskDefinition := t.addScheduledTask(tmpl, app, p)
 			scheduledProcesses[p.Type] = taskDefinition.Name
 		default:
-			service, err := t.addService(tmpl, app, p, data.StackTags)
+			service, err := t.addService(tmpl, app, p, data.StackTags, routes[p.Type])
 			if err != nil {
 				return tmpl, err
 			}
@@ -275,8 +339,9 @@ func (t *EmpireTemplate) Build(data *TemplateData) (*troposphere.Template, error
 	return tmpl, nil
 }
 
-func (t *EmpireTemplate) addTaskDefinition(tmpl *troposphere.Template, app *twelvefactor.Manifest, p *twelvefactor.Process) (troposphere.NamedResource, *ContainerDefinitionProperties) {
-	key := processResourceName(p.Type)
+// addTaskDefinition adds the task definition for the process, using key as the
+// prefix for the names of the resources.
+func (t *EmpireTemplate) addTaskDefinition(tmpl *troposphere.Template, app *twelvefactor.Manifest, p *twelvefactor.Process, key string) (troposphere.NamedResource, *ContainerDefinitionProperties) {
 	// The task definition that will be used to run the ECS task.
 	taskDefinition := troposphere.NamedResource{
 		Name: fmt.Sprintf("%sTaskDefinition", key),
@@ -285,11 +350,37 @@ func (t *EmpireTemplate) addTaskDefinition(tmpl *troposphere.Template, app *twel
 	cd := t.ContainerDefinition(app, p)
 	containerDefinition := cloudformationContainerDefinition(cd)
 
+	// ECS can only pull from private registries using credentials stored
+	// in Secrets Manager.
+	if secret := twelvefactor.ImagePullSecret(app, p); secret != nil && secret.CredentialsParameter != "" {
+		containerDefinition.RepositoryCredentials = &RepositoryCredentialsProperties{
+			CredentialsParameter: secret.CredentialsParameter,
+		}
+	}
+
 	// If provided in the app environment, this role will be used when
 	// running tasks.
 	taskRole := toInterface(taskRoleArn(app))
 
-	var placementConstraints []*PlacementConstraint
+	// Never place tasks on cordoned hosts.
+	placementConstraints := []*PlacementConstraint{
+		{
+			Type:       "memberOf",
+			Expression: cordonConstraint,
+		},
+	}
+	if c := capacityConstraint(p); c != "" {
+		placementConstraints = append(placementConstraints, &PlacementConstraint{
+			Type:       "memberOf",
+			Expression: c,
+		})
+	}
+	if p.GPUs > 0 && t.GPUConstraint != "" {
+		placementConstraints = append(placementConstraints, &PlacementConstraint{
+			Type:       "memberOf",
+			Expression: t.GPUConstraint,
+		})
+	}
 	if v := p.ECS; v != nil {
 		if len(v.PlacementConstraints) > 0 {
 			for _, c := range v.PlacementConstraints {
@@ -301,6 +392,69 @@ func (t *EmpireTemplate) addTaskDefinition(tmpl *troposphere.Template, app *twel
 		}
 	}
 
+	// tmpfs mounts and capabilities are only supported by newer versions of
+	// the ECS API, so they're added here, rather than in
+	// ContainerDefinition.
+	var tmpfs []interface{}
+	for _, v := range p.Volumes {
+		if v.Type == twelvefactor.VolumeTmpfs {
+			mount := map[string]interface{}{
+				"ContainerPath": v.Path,
+				"Size":          v.Size / bytesize.MB,
+			}
+			if v.ReadOnly {
+				mount["MountOptions"] = []string{"ro"}
+			}
+			tmpfs = append(tmpfs, mount)
+		}
+	}
+	linuxParameters := make(map[string]interface{})
+	if len(tmpfs) > 0 {
+		linuxParameters["Tmpfs"] = tmpfs
+	}
+	if hasCapabilities(p) {
+		capabilities := make(map[string]interface{})
+		if len(p.Security.CapAdd) > 0 {
+			capabilities["Add"] = p.Security.CapAdd
+		}
+		if len(p.Security.CapDrop) > 0 {
+			capabilities["Drop"] = p.Security.CapDrop
+		}
+		linuxParameters["Capabilities"] = capabilities
+	}
+	if len(linuxParameters) > 0 {
+		containerDefinition.LinuxParameters = linuxParameters
+	}
+
+	// Container health checks are also only supported by newer versions of
+	// the ECS API.
+	if p.Liveness != nil {
+		containerDefinition.HealthCheck = containerHealthCheck(p.Liveness)
+	}
+
+	// Like tmpfs mounts, GPUs are only supported by newer versions of the
+	// ECS API.
+	if p.GPUs > 0 {
+		containerDefinition.ResourceRequirements = []interface{}{
+			map[string]interface{}{
+				"Type":  "GPU",
+				"Value": fmt.Sprintf("%d", p.GPUs),
+			},
+		}
+	}
+
+	var sidecars []*ContainerDefinitionProperties
+	for i, sd := range t.SidecarDefinitions(app, p) {
+		sidecar := cloudformationContainerDefinition(sd)
+		pullSecret := twelvefactor.ImagePullSecret(app, &twelvefactor.Process{Image: p.Sidecars[i].Image})
+		if pullSecret != nil && pullSecret.CredentialsParameter != "" {
+			sidecar.RepositoryCredentials = &RepositoryCredentialsProperties{
+				CredentialsParameter: pullSecret.CredentialsParameter,
+			}
+		}
+		sidecars = append(sidecars, sidecar)
+	}
+
 	var taskDefinitionProperties interface{}
 	taskDefinitionType := taskDefinitionResourceType(app)
 	if taskDefinitionType == "Custom::ECSTaskDefinition" {
@@ -319,23 +473,37 @@ func (t *EmpireTemplate) addTaskDefinition(tmpl *troposphere.Template, app *twel
 			Ref(appEnvironment),
 			Ref(processEnvironment),
 		}
+		for i, sidecar := range sidecars {
+			environment := []interface{}{
+				Ref(appEnvironment),
+				Ref(processEnvironment),
+			}
+			if env := p.Sidecars[i].Env; len(env) > 0 {
+				sidecarEnvironment := fmt.Sprintf("%s%sEnvironment", key, processResourceName(p.Sidecars[i].Name))
+				tmpl.Resources[sidecarEnvironment] = troposphere.Resource{
+					Type: "Custom::ECSEnvironment",
+					Properties: map[string]interface{}{
+						"ServiceToken": t.CustomResourcesTopic,
+						"Environment":  sortedEnvironment(env),
+					},
+				}
+				environment = append(environment, Ref(sidecarEnvironment))
+			}
+			sidecar.Environment = environment
+		}
 		taskDefinitionProperties = &CustomTaskDefinitionProperties{
-			Volumes:      []interface{}{},
-			ServiceToken: t.CustomResourcesTopic,
-			Family:       fmt.Sprintf("%s-%s", app.Name, p.Type),
-			ContainerDefinitions: []*ContainerDefinitionProperties{
-				containerDefinition,
-			},
+			Volumes:              t.volumes(app, p),
+			ServiceToken:         t.CustomResourcesTopic,
+			Family:               fmt.Sprintf("%s-%s", app.Name, p.Type),
+			ContainerDefinitions: append([]*ContainerDefinitionProperties{containerDefinition}, sidecars...),
 			TaskRoleArn:          taskRole,
 			PlacementConstraints: placementConstraints,
 		}
 	} else {
 		containerDefinition.Environment = cd.Environment
 		taskDefinitionProperties = &TaskDefinitionProperties{
-			Volumes: []interface{}{},
-			ContainerDefinitions: []*ContainerDefinitionProperties{
-				containerDefinition,
-			},
+			Volumes:              t.volumes(app, p),
+			ContainerDefinitions: append([]*ContainerDefinitionProperties{containerDefinition}, sidecars...),
 			TaskRoleArn:          taskRole,
 			PlacementConstraints: placementConstraints,
 		}
@@ -353,12 +521,20 @@ func (t *EmpireTemplate) addTaskDefinition(tmpl *troposphere.Template, app *twel
 func (t *EmpireTemplate) addScheduledTask(tmpl *troposphere.Template, app *twelvefactor.Manifest, p *twelvefactor.Process) troposphere.NamedResource {
 	key := processResourceName(p.Type)
 
-	taskDefinition, _ := t.addTaskDefinition(tmpl, app, p)
+	taskDefinition, _ := t.addTaskDefinition(tmpl, app, p, key)
 
 	state := "DISABLED"
 	if p.Quantity > 0 {
 		state = "ENABLED"
 	}
+	// The RunTask function decides what to do when a previous invocation
+	// of the process is still running, based on its overlap policy.
+	input := []interface{}{`{"taskDefinition":"`, Ref(taskDefinition), `","count":`, Ref(scaleParameter(p.Type)), `,"cluster":"`, t.Cluster, `","startedBy": "`, app.AppID}
+	if p.Overlap != "" && p.Overlap != "allow" {
+		input = append(input, `","process":"`+p.Type+`","overlap":"`+p.Overlap)
+	}
+	input = append(input, `"}`)
+
 	schedule := fmt.Sprintf("%sTrigger", key)
 	tmpl.Resources[schedule] = troposphere.Resource{
 		Type: "AWS::Events::Rule",
@@ -371,7 +547,7 @@ func (t *EmpireTemplate) addScheduledTask(tmpl *troposphere.Template, app *twelv
 				map[string]interface{}{
 					"Arn":   GetAtt(runTaskFunction, "Arn"),
 					"Id":    "f",
-					"Input": Join("", `{"taskDefinition":"`, Ref(taskDefinition), `","count":`, Ref(scaleParameter(p.Type)), `,"cluster":"`, t.Cluster, `","startedBy": "`, app.AppID, `"}`),
+					"Input": Join("", input...),
 				},
 			},
 		},
@@ -392,7 +568,7 @@ func (t *EmpireTemplate) addScheduledTask(tmpl *troposphere.Template, app *twelv
 	return taskDefinition
 }
 
-func (t *EmpireTemplate) addService(tmpl *troposphere.Template, app *twelvefactor.Manifest, p *twelvefactor.Process, stackTags []*cloudformation.Tag) (serviceName string, err error) {
+func (t *EmpireTemplate) addService(tmpl *troposphere.Template, app *twelvefactor.Manifest, p *twelvefactor.Process, stackTags []*cloudformation.Tag, routes *processRoutes) (serviceName string, err error) {
 	key := processResourceName(p.Type)
 
 	// Process specific tags to apply to resources.
@@ -411,11 +587,15 @@ func (t *EmpireTemplate) addService(tmpl *troposphere.Template, app *twelvefacto
 
 	var portMappings []*PortMappingProperties
 
-	var serviceDependencies []string
+	var (
+		serviceDependencies []string
+		previous            *twelvefactor.Process
+		previousTargetGroup string
+	)
 	loadBalancers := []map[string]interface{}{}
 	if p.Exposure != nil {
 		scheme := schemeInternal
-		sg := t.InternalSecurityGroupID
+		var sg interface{} = t.InternalSecurityGroupID
 		subnets := t.InternalSubnetIDs
 
 		if p.Exposure.External {
@@ -424,8 +604,23 @@ func (t *EmpireTemplate) addService(tmpl *troposphere.Template, app *twelvefacto
 			subnets = t.ExternalSubnetIDs
 		}
 
+		if (!p.Exposure.External && app.Ingress != nil) || len(p.Exposure.AllowedCIDRs) > 0 {
+			var securityGroup troposphere.NamedResource
+			securityGroup, err = t.addLoadBalancerSecurityGroup(tmpl, app, p)
+			if err != nil {
+				return
+			}
+			sg = Ref(securityGroup)
+		}
+
 		loadBalancerType := loadBalancerType(app, p)
 
+		previous = previousProcess(app, p)
+		if previous != nil && loadBalancerType != applicationLoadBalancer {
+			err = fmt.Errorf("traffic to %s can't be split between releases, since it doesn't use an Application Load Balancer", p.Type)
+			return
+		}
+
 		var (
 			loadBalancer          troposphere.NamedResource
 			canonicalHostedZoneId interface{}
@@ -433,16 +628,27 @@ func (t *EmpireTemplate) addService(tmpl *troposphere.Template, app *twelvefacto
 
 		switch loadBalancerType {
 		case applicationLoadBalancer:
+			loadBalancerProperties := map[string]interface{}{
+				"Scheme":         scheme,
+				"SecurityGroups": []interface{}{sg},
+				"Subnets":        subnets,
+				"Tags":           append(stackTags, tags...),
+			}
+			// ALB supports WebSockets without any changes.
+			if d := p.Exposure.IdleTimeout; d > 0 {
+				loadBalancerProperties["LoadBalancerAttributes"] = []interface{}{
+					map[string]interface{}{
+						"Key":   "idle_timeout.timeout_seconds",
+						"Value": fmt.Sprintf("%d", int(d.Seconds())),
+					},
+				}
+			}
+
 			loadBalancer = troposphere.NamedResource{
 				Name: fmt.Sprintf("%sApplicationLoadBalancer", key),
 				Resource: troposphere.Resource{
-					Type: "AWS::ElasticLoadBalancingV2::LoadBalancer",
-					Properties: map[string]interface{}{
-						"Scheme":         scheme,
-						"SecurityGroups": []string{sg},
-						"Subnets":        subnets,
-						"Tags":           append(stackTags, tags...),
-					},
+					Type:       "AWS::ElasticLoadBalancingV2::LoadBalancer",
+					Properties: loadBalancerProperties,
 				},
 			}
 			canonicalHostedZoneId = GetAtt(loadBalancer, "CanonicalHostedZoneID")
@@ -451,13 +657,8 @@ func (t *EmpireTemplate) addService(tmpl *troposphere.Template, app *twelvefacto
 
 			targetGroup := fmt.Sprintf("%sTargetGroup", key)
 			tmpl.Resources[targetGroup] = troposphere.Resource{
-				Type: "AWS::ElasticLoadBalancingV2::TargetGroup",
-				Properties: map[string]interface{}{
-					"Port":     65535, // Not used. ECS sets a port override when registering targets.
-					"Protocol": "HTTP",
-					"VpcId":    t.VpcId,
-					"Tags":     append(stackTags, tags...),
-				},
+				Type:       "AWS::ElasticLoadBalancingV2::TargetGroup",
+				Properties: t.targetGroupProperties(p, append(stackTags, tags...)),
 			}
 
 			// Add a port mapping for each unique container port.
@@ -487,26 +688,106 @@ func (t *EmpireTemplate) addService(tmpl *troposphere.Template, app *twelvefacto
 				return
 			}
 
+			defaultActions := []interface{}{
+				map[string]interface{}{
+					"TargetGroupArn": Ref(targetGroup),
+					"Type":           "forward",
+				},
+			}
+
+			// ALB only sends HTTP/2 and gRPC requests to targets
+			// when the client connected over HTTPS, so HTTP
+			// listeners redirect to the HTTPS port.
+			var redirectPort int
+			if usesHTTP2(p.Exposure) {
+				for _, port := range p.Exposure.Ports {
+					if _, ok := port.Protocol.(*twelvefactor.HTTPS); ok {
+						redirectPort = port.Host
+						break
+					}
+				}
+				if redirectPort == 0 {
+					err = fmt.Errorf("%s needs an https port to use %s, since Application Load Balancers only support it over HTTPS", p.Type, p.Exposure.ProtocolVersion)
+					return
+				}
+			}
+
+			// If the previous release of the process is running,
+			// split the traffic between it and this release.
+			if previous != nil {
+				previousTargetGroup = fmt.Sprintf("%sPreviousTargetGroup", key)
+				tmpl.Resources[previousTargetGroup] = troposphere.Resource{
+					Type:       "AWS::ElasticLoadBalancingV2::TargetGroup",
+					Properties: t.targetGroupProperties(p, append(stackTags, tags...)),
+				}
+
+				defaultActions = []interface{}{
+					map[string]interface{}{
+						"Type": "forward",
+						"ForwardConfig": map[string]interface{}{
+							"TargetGroups": []interface{}{
+								map[string]interface{}{
+									"TargetGroupArn": Ref(targetGroup),
+									"Weight":         100 - app.PreviousWeight,
+								},
+								map[string]interface{}{
+									"TargetGroupArn": Ref(previousTargetGroup),
+									"Weight":         app.PreviousWeight,
+								},
+							},
+						},
+					},
+				}
+			}
+
+			// With basic auth, listeners reject requests by default,
+			// and a rule forwards the requests that have the
+			// credentials.
+			listenerActions := defaultActions
+			if p.Exposure.BasicAuth != nil {
+				listenerActions = []interface{}{
+					map[string]interface{}{
+						"Type": "fixed-response",
+						"FixedResponseConfig": map[string]interface{}{
+							"StatusCode":  "401",
+							"ContentType": "text/plain",
+							"MessageBody": "Unauthorized",
+						},
+					},
+				}
+			}
+
 			// Add a listener for each port.
 			for _, port := range p.Exposure.Ports {
 				listener := troposphere.NamedResource{
 					Name: fmt.Sprintf("%sPort%dListener", loadBalancer.Name, port.Host),
 				}
+				actions := listenerActions
+				redirected := false
 
 				switch e := port.Protocol.(type) {
 				case *twelvefactor.HTTP:
+					if redirectPort != 0 {
+						redirected = true
+						actions = []interface{}{
+							map[string]interface{}{
+								"Type": "redirect",
+								"RedirectConfig": map[string]interface{}{
+									"Protocol":   "HTTPS",
+									"Port":       fmt.Sprintf("%d", redirectPort),
+									"StatusCode": "HTTP_301",
+								},
+							},
+						}
+					}
+
 					listener.Resource = troposphere.Resource{
 						Type: "AWS::ElasticLoadBalancingV2::Listener",
 						Properties: map[string]interface{}{
 							"LoadBalancerArn": Ref(loadBalancer),
 							"Port":            port.Host,
 							"Protocol":        "HTTP",
-							"DefaultActions": []interface{}{
-								map[string]interface{}{
-									"TargetGroupArn": Ref(targetGroup),
-									"Type":           "forward",
-								},
-							},
+							"DefaultActions":  actions,
 						},
 					}
 				case *twelvefactor.HTTPS:
@@ -528,12 +809,7 @@ func (t *EmpireTemplate) addService(tmpl *troposphere.Template, app *twelvefacto
 							"LoadBalancerArn": Ref(loadBalancer),
 							"Port":            port.Host,
 							"Protocol":        "HTTPS",
-							"DefaultActions": []interface{}{
-								map[string]interface{}{
-									"TargetGroupArn": Ref(targetGroup),
-									"Type":           "forward",
-								},
-							},
+							"DefaultActions":  actions,
 						},
 					}
 				default:
@@ -542,6 +818,28 @@ func (t *EmpireTemplate) addService(tmpl *troposphere.Template, app *twelvefacto
 				}
 				tmpl.AddResource(listener)
 				serviceDependencies = append(serviceDependencies, listener.Name)
+
+				// The target group is only attached to the
+				// load balancer through this rule, so the
+				// service has to wait for it.
+				if p.Exposure.BasicAuth != nil && !redirected {
+					rule := troposphere.NamedResource{
+						Name: fmt.Sprintf("%sBasicAuthRule", listener.Name),
+						Resource: troposphere.Resource{
+							Type: "AWS::ElasticLoadBalancingV2::ListenerRule",
+							Properties: map[string]interface{}{
+								"ListenerArn": Ref(listener),
+								"Priority":    basicAuthRulePriority,
+								"Conditions": []interface{}{
+									basicAuthCondition(p.Exposure.BasicAuth),
+								},
+								"Actions": defaultActions,
+							},
+						},
+					}
+					tmpl.AddResource(rule)
+					serviceDependencies = append(serviceDependencies, rule.Name)
+				}
 			}
 
 			loadBalancers = append(loadBalancers, map[string]interface{}{
@@ -555,7 +853,36 @@ func (t *EmpireTemplate) addService(tmpl *troposphere.Template, app *twelvefacto
 			}
 			canonicalHostedZoneId = GetAtt(loadBalancer, "CanonicalHostedZoneNameID")
 
+			// ELB only supports sticky sessions with HTTP listeners,
+			// but WebSockets require TCP listeners.
+			if p.Exposure.StickySessions && p.Exposure.WebSockets {
+				err = fmt.Errorf("sticky sessions can't be used with WebSockets on %s, since it doesn't use an Application Load Balancer", p.Type)
+				return
+			}
+
+			if p.Exposure.BasicAuth != nil {
+				err = fmt.Errorf("basic auth can't be used on %s, since it doesn't use an Application Load Balancer", p.Type)
+				return
+			}
+
+			// ELB doesn't speak HTTP/2, but h2c can be passed
+			// through TCP listeners. It can't be passed through SSL
+			// listeners, because ELB doesn't negotiate h2 with ALPN.
+			if usesHTTP2(p.Exposure) {
+				if p.Exposure.StickySessions {
+					err = fmt.Errorf("sticky sessions can't be used with %s on %s, since it doesn't use an Application Load Balancer", p.Exposure.ProtocolVersion, p.Type)
+					return
+				}
+				for _, port := range p.Exposure.Ports {
+					if _, ok := port.Protocol.(*twelvefactor.HTTPS); ok {
+						err = fmt.Errorf("%s can't be used over HTTPS on %s, since it doesn't use an Application Load Balancer", p.Exposure.ProtocolVersion, p.Type)
+						return
+					}
+				}
+			}
+
 			listeners := []map[string]interface{}{}
+			var loadBalancerHealthCheck map[string]interface{}
 
 			// Add a port mapping for each unique container port.
 			instancePorts := make(map[int]troposphere.NamedResource)
@@ -607,12 +934,22 @@ func (t *EmpireTemplate) addService(tmpl *troposphere.Template, app *twelvefacto
 						"InstanceProtocol": "tcp",
 					})
 				case *twelvefactor.HTTP:
-					listeners = append(listeners, map[string]interface{}{
+					listener := map[string]interface{}{
 						"LoadBalancerPort": port.Host,
 						"Protocol":         "http",
 						"InstancePort":     GetAtt(instancePort, "InstancePort"),
 						"InstanceProtocol": "http",
-					})
+					}
+					// ELB can only proxy WebSockets and HTTP/2
+					// with TCP listeners.
+					if p.Exposure.WebSockets || usesHTTP2(p.Exposure) {
+						listener["Protocol"] = "tcp"
+						listener["InstanceProtocol"] = "tcp"
+					}
+					if p.Exposure.StickySessions {
+						listener["PolicyNames"] = []string{stickySessionsPolicy}
+					}
+					listeners = append(listeners, listener)
 				case *twelvefactor.HTTPS:
 					var cert interface{}
 					if _, err := arn.Parse(e.Cert); err == nil {
@@ -621,30 +958,62 @@ func (t *EmpireTemplate) addService(tmpl *troposphere.Template, app *twelvefacto
 						cert = Join("", "arn:aws:iam::", Ref("AWS::AccountId"), ":server-certificate/", e.Cert)
 					}
 
-					listeners = append(listeners, map[string]interface{}{
+					listener := map[string]interface{}{
 						"LoadBalancerPort": port.Host,
 						"Protocol":         "https",
 						"InstancePort":     GetAtt(instancePort, "InstancePort"),
 						"SSLCertificateId": cert,
 						"InstanceProtocol": "http",
-					})
+					}
+					if p.Exposure.WebSockets {
+						listener["Protocol"] = "ssl"
+						listener["InstanceProtocol"] = "tcp"
+					}
+					if p.Exposure.StickySessions {
+						listener["PolicyNames"] = []string{stickySessionsPolicy}
+					}
+					listeners = append(listeners, listener)
 				}
 			}
 
-			loadBalancer.Resource = troposphere.Resource{
-				Type: "AWS::ElasticLoadBalancing::LoadBalancer",
-				Properties: map[string]interface{}{
-					"Scheme":         scheme,
-					"SecurityGroups": []string{sg},
-					"Subnets":        subnets,
-					"Listeners":      listeners,
-					"CrossZone":      true,
-					"Tags":           tags,
-					"ConnectionDrainingPolicy": map[string]interface{}{
-						"Enabled": true,
-						"Timeout": defaultConnectionDrainingTimeout,
+			if c := p.Readiness; c != nil {
+				instancePort := instancePorts[p.Exposure.Ports[0].Container]
+				loadBalancerHealthCheck = elbHealthCheck(c, GetAtt(instancePort, "InstancePort"))
+			}
+
+			if c := p.Readiness; c != nil {
+				instancePort := instancePorts[p.Exposure.Ports[0].Container]
+				loadBalancerHealthCheck = elbHealthCheck(c, GetAtt(instancePort, "InstancePort"))
+			}
+
+			loadBalancerProperties := map[string]interface{}{
+				"Scheme":         scheme,
+				"SecurityGroups": []interface{}{sg},
+				"Subnets":        subnets,
+				"Listeners":      listeners,
+				"CrossZone":      true,
+				"Tags":           tags,
+				"ConnectionDrainingPolicy": connectionDrainingPolicy(p.Exposure),
+			}
+			if loadBalancerHealthCheck != nil {
+				loadBalancerProperties["HealthCheck"] = loadBalancerHealthCheck
+			}
+			if d := p.Exposure.IdleTimeout; d > 0 {
+				loadBalancerProperties["ConnectionSettings"] = map[string]interface{}{
+					"IdleTimeout": int(d.Seconds()),
+				}
+			}
+			if p.Exposure.StickySessions {
+				loadBalancerProperties["LBCookieStickinessPolicy"] = []interface{}{
+					map[string]interface{}{
+						"PolicyName": stickySessionsPolicy,
 					},
-				},
+				}
+			}
+
+			loadBalancer.Resource = troposphere.Resource{
+				Type:       "AWS::ElasticLoadBalancing::LoadBalancer",
+				Properties: loadBalancerProperties,
 			}
 			tmpl.AddResource(loadBalancer)
 
@@ -691,7 +1060,18 @@ func (t *EmpireTemplate) addService(tmpl *troposphere.Template, app *twelvefacto
 		}
 	}
 
-	taskDefinition, containerDefinition := t.addTaskDefinition(tmpl, app, p)
+	// Register with the target group that the load balancer of the web
+	// process routes requests to.
+	if routes != nil {
+		loadBalancers = append(loadBalancers, map[string]interface{}{
+			"ContainerName":  p.Type,
+			"ContainerPort":  p.Exposure.Ports[0].Container,
+			"TargetGroupArn": Ref(routes.TargetGroup),
+		})
+		serviceDependencies = append(serviceDependencies, routes.Rules...)
+	}
+
+	taskDefinition, containerDefinition := t.addTaskDefinition(tmpl, app, p, key)
 
 	containerDefinition.DockerLabels[restartLabel] = Ref(restartParameter)
 	containerDefinition.PortMappings = portMappings
@@ -704,17 +1084,49 @@ func (t *EmpireTemplate) addService(tmpl *troposphere.Template, app *twelvefacto
 		"ServiceName":    fmt.Sprintf("%s-%s", app.Name, p.Type),
 		"ServiceToken":   t.CustomResourcesTopic,
 	}
-	if v := p.ECS; v != nil {
-		if len(v.PlacementStrategy) > 0 {
-			var placementStrategy []interface{}
-			for _, c := range v.PlacementStrategy {
-				placementStrategy = append(placementStrategy, map[string]interface{}{
-					"Type":  c.Type,
-					"Field": c.Field,
-				})
-			}
-			serviceProperties["PlacementStrategy"] = placementStrategy
+	// Daemons run one instance on each machine, so there's no room to start
+	// a new instance before the old one is stopped.
+	if p.Daemon {
+		serviceProperties["PlacementConstraints"] = []interface{}{
+			map[string]interface{}{
+				"Type": "distinctInstance",
+			},
 		}
+		serviceProperties["DeploymentConfiguration"] = map[string]interface{}{
+			"MinimumHealthyPercent": 0,
+			"MaximumPercent":        100,
+		}
+	}
+	if r := p.Rollout; r != nil {
+		serviceProperties["DeploymentConfiguration"] = map[string]interface{}{
+			"MinimumHealthyPercent": 100 - r.MaxUnavailable,
+			"MaximumPercent":        100 + r.MaxSurge,
+		}
+	}
+	// An ebs volume can only be attached to one instance of the process, and
+	// only one instance of a singleton can run at a time, so the old
+	// instance needs to be stopped before a new one is started.
+	stopFirst := p.Singleton
+	for _, v := range p.Volumes {
+		if v.Type == twelvefactor.VolumeEBS {
+			stopFirst = true
+		}
+	}
+	if stopFirst {
+		serviceProperties["DeploymentConfiguration"] = map[string]interface{}{
+			"MinimumHealthyPercent": 0,
+			"MaximumPercent":        100,
+		}
+	}
+	if strategy := placementStrategy(p, t.PlacementStrategy); len(strategy) > 0 {
+		var placementStrategy []interface{}
+		for _, c := range strategy {
+			placementStrategy = append(placementStrategy, map[string]interface{}{
+				"Type":  c.Type,
+				"Field": c.Field,
+			})
+		}
+		serviceProperties["PlacementStrategy"] = placementStrategy
 	}
 	if len(loadBalancers) > 0 {
 		serviceProperties["Role"] = t.ServiceRole
@@ -730,9 +1142,463 @@ func (t *EmpireTemplate) addService(tmpl *troposphere.Template, app *twelvefacto
 		service.Resource.DependsOn = serviceDependencies
 	}
 	tmpl.AddResource(service)
+
+	if previous != nil {
+		t.addPreviousService(tmpl, app, previous, previousTargetGroup, serviceDependencies)
+	}
+
 	return service.Name, nil
 }
 
+// processRoutes are the resources that route requests from the load balancer of
+// the web process to another process.
+type processRoutes struct {
+	// The target group that the process registers with.
+	TargetGroup string
+
+	// The listener rules that forward requests to the target group.
+	Rules []string
+}
+
+// addRoutes adds a listener rule to each listener of the load balancer of the
+// web process, for each route of the app. It returns the routes to each
+// process, other than the web process, keyed by the process type.
+func (t *EmpireTemplate) addRoutes(tmpl *troposphere.Template, app *twelvefactor.Manifest, stackTags []*cloudformation.Tag) (map[string]*processRoutes, error) {
+	if len(app.Routes) == 0 {
+		return nil, nil
+	}
+
+	processes := make(map[string]*twelvefactor.Process)
+	for _, p := range app.Processes {
+		processes[p.Type] = p
+	}
+
+	web, ok := processes["web"]
+	if !ok || web.Exposure == nil || loadBalancerType(app, web) != applicationLoadBalancer {
+		return nil, errors.New("routing rules require the web process to be exposed with an Application Load Balancer")
+	}
+	webKey := processResourceName(web.Type)
+	loadBalancer := fmt.Sprintf("%sApplicationLoadBalancer", webKey)
+
+	routes := make(map[string]*processRoutes)
+	for i, route := range app.Routes {
+		p, ok := processes[route.Process]
+		if !ok {
+			return nil, fmt.Errorf("unable to route requests to %s: no such process", route.Process)
+		}
+		if p.Exposure == nil || p.Schedule != nil {
+			return nil, fmt.Errorf("unable to route requests to %s: the process isn't exposed", p.Type)
+		}
+		// An ECS service can't register with both a classic ELB and a
+		// target group.
+		if loadBalancerType(app, p) != applicationLoadBalancer {
+			return nil, fmt.Errorf("unable to route requests to %s: the process doesn't use an Application Load Balancer", p.Type)
+		}
+
+		var r *processRoutes
+		targetGroup := fmt.Sprintf("%sTargetGroup", webKey)
+		if p != web {
+			r, ok = routes[p.Type]
+			if !ok {
+				r = &processRoutes{
+					TargetGroup: fmt.Sprintf("%sRouteTargetGroup", processResourceName(p.Type)),
+				}
+				tmpl.Resources[r.TargetGroup] = troposphere.Resource{
+					Type:       "AWS::ElasticLoadBalancingV2::TargetGroup",
+					Properties: t.targetGroupProperties(p, append(stackTags, tagsFromLabels(p.Labels)...)),
+				}
+				routes[p.Type] = r
+			}
+			targetGroup = r.TargetGroup
+		}
+
+		conditions := []interface{}{}
+		if route.Host != "" {
+			conditions = append(conditions, map[string]interface{}{
+				"Field":  "host-header",
+				"Values": []string{route.Host},
+			})
+		}
+		if route.Path != "" {
+			conditions = append(conditions, map[string]interface{}{
+				"Field":  "path-pattern",
+				"Values": []string{route.Path},
+			})
+		}
+		if web.Exposure.BasicAuth != nil {
+			conditions = append(conditions, basicAuthCondition(web.Exposure.BasicAuth))
+		}
+
+		for _, port := range web.Exposure.Ports {
+			// These listeners redirect every request to HTTPS.
+			if _, ok := port.Protocol.(*twelvefactor.HTTP); ok && usesHTTP2(web.Exposure) {
+				continue
+			}
+
+			rule := troposphere.NamedResource{
+				Name: fmt.Sprintf("%sPort%dRoute%dRule", loadBalancer, port.Host, i+1),
+				Resource: troposphere.Resource{
+					Type: "AWS::ElasticLoadBalancingV2::ListenerRule",
+					Properties: map[string]interface{}{
+						"ListenerArn": Ref(fmt.Sprintf("%sPort%dListener", loadBalancer, port.Host)),
+						"Priority":    i + 1,
+						"Conditions":  conditions,
+						"Actions": []interface{}{
+							map[string]interface{}{
+								"TargetGroupArn": Ref(targetGroup),
+								"Type":           "forward",
+							},
+						},
+					},
+				},
+			}
+			tmpl.AddResource(rule)
+
+			if r != nil {
+				r.Rules = append(r.Rules, rule.Name)
+			}
+		}
+	}
+
+	return routes, nil
+}
+
+// targetGroupProperties returns the properties of a target group for the
+// process.
+func (t *EmpireTemplate) targetGroupProperties(p *twelvefactor.Process, tags []*cloudformation.Tag) map[string]interface{} {
+	properties := map[string]interface{}{
+		"Port":     65535, // Not used. ECS sets a port override when registering targets.
+		"Protocol": "HTTP",
+		"VpcId":    t.VpcId,
+		"Tags":     tags,
+	}
+	var attributes []interface{}
+	if p.Exposure.StickySessions {
+		attributes = append(attributes,
+			map[string]interface{}{
+				"Key":   "stickiness.enabled",
+				"Value": "true",
+			},
+			map[string]interface{}{
+				"Key":   "stickiness.type",
+				"Value": "lb_cookie",
+			},
+		)
+	}
+	if d := p.Exposure.DrainTimeout; d != nil {
+		attributes = append(attributes, map[string]interface{}{
+			"Key":   "deregistration_delay.timeout_seconds",
+			"Value": fmt.Sprintf("%d", int(d.Seconds())),
+		})
+	}
+	if len(attributes) > 0 {
+		properties["TargetGroupAttributes"] = attributes
+	}
+	if usesHTTP2(p.Exposure) {
+		properties["ProtocolVersion"] = strings.ToUpper(p.Exposure.ProtocolVersion)
+	}
+	if p.Exposure.ProtocolVersion == twelvefactor.ProtocolVersionGRPC {
+		properties["HealthCheckPath"] = grpcHealthCheckPath
+		properties["Matcher"] = map[string]interface{}{
+			"GrpcCode": "0",
+		}
+	}
+	// Target groups of an ALB only support HTTP health checks, so a
+	// readiness check without a path uses the default path.
+	if c := p.Readiness; c != nil {
+		if c.Path != "" {
+			properties["HealthCheckPath"] = c.Path
+		}
+		if c.Interval != 0 {
+			properties["HealthCheckIntervalSeconds"] = int(c.Interval.Seconds())
+		}
+		if c.Timeout != 0 {
+			properties["HealthCheckTimeoutSeconds"] = int(c.Timeout.Seconds())
+		}
+		if c.HealthyThreshold != 0 {
+			properties["HealthyThresholdCount"] = c.HealthyThreshold
+		}
+		if c.UnhealthyThreshold != 0 {
+			properties["UnhealthyThresholdCount"] = c.UnhealthyThreshold
+		}
+	}
+	return properties
+}
+
+// Defaults for the health check of a classic ELB, which has to be provided in
+// full.
+const (
+	defaultELBHealthCheckInterval           = 30
+	defaultELBHealthCheckTimeout            = 5
+	defaultELBHealthCheckHealthyThreshold   = 10
+	defaultELBHealthCheckUnhealthyThreshold = 2
+)
+
+// elbHealthCheck returns the HealthCheck of a classic ELB for a readiness
+// check, which is made against the instance port of the process.
+func elbHealthCheck(c *twelvefactor.HealthCheck, instancePort interface{}) map[string]interface{} {
+	target := Join("", "TCP:", instancePort)
+	if c.Path != "" {
+		target = Join("", "HTTP:", instancePort, c.Path)
+	}
+
+	healthCheck := map[string]interface{}{
+		"Target":             target,
+		"Interval":           fmt.Sprintf("%d", defaultELBHealthCheckInterval),
+		"Timeout":            fmt.Sprintf("%d", defaultELBHealthCheckTimeout),
+		"HealthyThreshold":   fmt.Sprintf("%d", defaultELBHealthCheckHealthyThreshold),
+		"UnhealthyThreshold": fmt.Sprintf("%d", defaultELBHealthCheckUnhealthyThreshold),
+	}
+	if c.Interval != 0 {
+		healthCheck["Interval"] = fmt.Sprintf("%d", int(c.Interval.Seconds()))
+	}
+	if c.Timeout != 0 {
+		healthCheck["Timeout"] = fmt.Sprintf("%d", int(c.Timeout.Seconds()))
+	}
+	if c.HealthyThreshold != 0 {
+		healthCheck["HealthyThreshold"] = fmt.Sprintf("%d", c.HealthyThreshold)
+	}
+	if c.UnhealthyThreshold != 0 {
+		healthCheck["UnhealthyThreshold"] = fmt.Sprintf("%d", c.UnhealthyThreshold)
+	}
+	return healthCheck
+}
+
+// containerHealthCheck returns the HealthCheck of an ECS container definition
+// for a liveness check. ECS restarts the task when the container becomes
+// unhealthy.
+func containerHealthCheck(c *twelvefactor.HealthCheck) map[string]interface{} {
+	healthCheck := map[string]interface{}{
+		"Command": append([]string{"CMD"}, c.Command...),
+	}
+	if c.Interval != 0 {
+		healthCheck["Interval"] = int(c.Interval.Seconds())
+	}
+	if c.Timeout != 0 {
+		healthCheck["Timeout"] = int(c.Timeout.Seconds())
+	}
+	if c.UnhealthyThreshold != 0 {
+		healthCheck["Retries"] = c.UnhealthyThreshold
+	}
+	if c.StartPeriod != 0 {
+		healthCheck["StartPeriod"] = int(c.StartPeriod.Seconds())
+	}
+	return healthCheck
+}
+
+// logConfiguration returns the LogConfiguration for the containers of the
+// process. The logging configuration of the process takes precedence over the
+// default LogConfiguration.
+func (t *EmpireTemplate) logConfiguration(p *twelvefactor.Process) *ecs.LogConfiguration {
+	if p.Logging == nil {
+		return t.LogConfiguration
+	}
+	c := &ecs.LogConfiguration{
+		LogDriver: aws.String(p.Logging.Driver),
+	}
+	if len(p.Logging.Options) > 0 {
+		c.Options = make(map[string]*string)
+		for k, v := range p.Logging.Options {
+			c.Options[k] = aws.String(v)
+		}
+	}
+	return c
+}
+
+// connectionDrainingPolicy returns the ConnectionDrainingPolicy of a classic
+// ELB for the exposure. A drain timeout of 0 disables connection draining, so
+// that instances are deregistered immediately.
+func connectionDrainingPolicy(e *twelvefactor.Exposure) map[string]interface{} {
+	timeout := defaultConnectionDrainingTimeout
+	if e.DrainTimeout != nil {
+		timeout = int64(e.DrainTimeout.Seconds())
+	}
+	if timeout == 0 {
+		return map[string]interface{}{
+			"Enabled": false,
+		}
+	}
+	return map[string]interface{}{
+		"Enabled": true,
+		"Timeout": timeout,
+	}
+}
+
+// basicAuthCondition returns a listener rule condition that matches requests
+// with the credentials.
+func basicAuthCondition(a *twelvefactor.BasicAuth) map[string]interface{} {
+	credentials := base64.StdEncoding.EncodeToString([]byte(a.Username + ":" + a.Password))
+	return map[string]interface{}{
+		"Field": "http-header",
+		"HttpHeaderConfig": map[string]interface{}{
+			"HttpHeaderName": "Authorization",
+			"Values":         []string{"Basic " + credentials},
+		},
+	}
+}
+
+// usesHTTP2 returns true if requests are sent to the exposed process with
+// HTTP/2, which includes gRPC.
+func usesHTTP2(e *twelvefactor.Exposure) bool {
+	switch e.ProtocolVersion {
+	case twelvefactor.ProtocolVersionHTTP2, twelvefactor.ProtocolVersionGRPC:
+		return true
+	default:
+		return false
+	}
+}
+
+// previousProcess returns the process in the previous release of the app that
+// should receive a share of the traffic to p, or nil if traffic isn't split.
+func previousProcess(app *twelvefactor.Manifest, p *twelvefactor.Process) *twelvefactor.Process {
+	if app.Previous == nil || app.PreviousWeight <= 0 {
+		return nil
+	}
+
+	// Running the previous release alongside the new one would run two
+	// instances of a singleton.
+	if p.Singleton {
+		return nil
+	}
+
+	for _, previous := range app.Previous.Processes {
+		if previous.Type == p.Type && previous.Exposure != nil && len(previous.Exposure.Ports) > 0 {
+			return previous
+		}
+	}
+
+	return nil
+}
+
+// addPreviousService adds an ECS service that runs the process from the
+// previous release of the app, registered with the given target group. It uses
+// the same scale as the process in the current release, so that either one can
+// handle all of the traffic.
+func (t *EmpireTemplate) addPreviousService(tmpl *troposphere.Template, app *twelvefactor.Manifest, p *twelvefactor.Process, targetGroup string, dependencies []string) {
+	key := fmt.Sprintf("%sPrevious", processResourceName(p.Type))
+
+	if p.Env == nil {
+		p.Env = make(map[string]string)
+	}
+
+	taskDefinition, containerDefinition := t.addTaskDefinition(tmpl, app.Previous, p, key)
+
+	containerDefinition.DockerLabels[restartLabel] = Ref(restartParameter)
+	containerDefinition.PortMappings = []*PortMappingProperties{
+		{
+			ContainerPort: p.Exposure.Ports[0].Container,
+			HostPort:      0,
+		},
+	}
+
+	service := troposphere.NamedResource{
+		Name: fmt.Sprintf("%sService", key),
+		Resource: troposphere.Resource{
+			Type: "Custom::ECSService",
+			Properties: map[string]interface{}{
+				"Cluster":      t.Cluster,
+				"DesiredCount": Ref(scaleParameter(p.Type)),
+				"LoadBalancers": []map[string]interface{}{
+					{
+						"ContainerName":  p.Type,
+						"ContainerPort":  p.Exposure.Ports[0].Container,
+						"TargetGroupArn": Ref(targetGroup),
+					},
+				},
+				"Role":           t.ServiceRole,
+				"TaskDefinition": Ref(taskDefinition),
+				"ServiceName":    fmt.Sprintf("%s-%s-previous", app.Name, p.Type),
+				"ServiceToken":   t.CustomResourcesTopic,
+			},
+			DependsOn: dependencies,
+		},
+	}
+	tmpl.AddResource(service)
+}
+
+// addLoadBalancerSecurityGroup adds a security group for the load balancer of a
+// process that only accepts some connections. If the load balancer is internal,
+// and the app is isolated, it allows connections to the ports in the ingress
+// rules of the app, from the clusters that the source apps run in. It also
+// allows connections to any of the ports from the allowed CIDR blocks.
+func (t *EmpireTemplate) addLoadBalancerSecurityGroup(tmpl *troposphere.Template, app *twelvefactor.Manifest, p *twelvefactor.Process) (troposphere.NamedResource, error) {
+	key := processResourceName(p.Type)
+
+	ports := make(map[int]bool)
+	for _, port := range p.Exposure.Ports {
+		ports[port.Host] = true
+	}
+
+	seen := make(map[string]bool)
+	ingress := []interface{}{}
+	for _, i := range app.Ingress {
+		if p.Exposure.External || !ports[i.Port] {
+			continue
+		}
+
+		sg, ok := t.ClusterSecurityGroups[i.Cluster]
+		if !ok {
+			cluster := i.Cluster
+			if cluster == "" {
+				cluster = "default"
+			}
+			return troposphere.NamedResource{}, fmt.Errorf("unable to allow connections from %s: no security group is configured for the %s cluster", i.App, cluster)
+		}
+
+		// Security groups reject duplicate rules, which happens when
+		// source apps run in the same cluster.
+		k := fmt.Sprintf("%s:%d", sg, i.Port)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		ingress = append(ingress, map[string]interface{}{
+			"IpProtocol":            "tcp",
+			"FromPort":              i.Port,
+			"ToPort":                i.Port,
+			"SourceSecurityGroupId": sg,
+		})
+	}
+
+	for _, cidr := range p.Exposure.AllowedCIDRs {
+		source := "CidrIp"
+		if strings.Contains(cidr, ":") {
+			source = "CidrIpv6"
+		}
+
+		for _, port := range p.Exposure.Ports {
+			k := fmt.Sprintf("%s:%d", cidr, port.Host)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+
+			ingress = append(ingress, map[string]interface{}{
+				"IpProtocol": "tcp",
+				"FromPort":   port.Host,
+				"ToPort":     port.Host,
+				source:       cidr,
+			})
+		}
+	}
+
+	securityGroup := troposphere.NamedResource{
+		Name: fmt.Sprintf("%sLoadBalancerSecurityGroup", key),
+		Resource: troposphere.Resource{
+			Type: "AWS::EC2::SecurityGroup",
+			Properties: map[string]interface{}{
+				"GroupDescription":     fmt.Sprintf("Ingress rules for %s.%s", p.Type, app.Name),
+				"VpcId":                t.VpcId,
+				"SecurityGroupIngress": ingress,
+			},
+		},
+	}
+	tmpl.AddResource(securityGroup)
+
+	return securityGroup, nil
+}
+
 // If the ServiceRole option is not an ARN, it will return a CloudFormation
 // expression that expands the ServiceRole to an ARN.
 func (t *EmpireTemplate) serviceRoleArn() interface{} {
@@ -774,7 +1640,26 @@ func (t *EmpireTemplate) ContainerDefinition(app *twelvefactor.Manifest, p *twel
 		}
 	}
 
-	return &ecs.ContainerDefinition{
+	var mountPoints []*ecs.MountPoint
+	for _, v := range p.Volumes {
+		if v.Type == twelvefactor.VolumeTmpfs {
+			continue
+		}
+		mountPoints = append(mountPoints, &ecs.MountPoint{
+			SourceVolume:  aws.String(volumeName(app, p, v)),
+			ContainerPath: aws.String(v.Path),
+			ReadOnly:      aws.Bool(v.ReadOnly),
+		})
+	}
+
+	// Link to each sidecar, so that they can be reached using the name of
+	// the sidecar as the hostname.
+	var links []*string
+	for _, sidecar := range p.Sidecars {
+		links = append(links, aws.String(sidecar.Name))
+	}
+
+	containerDefinition := &ecs.ContainerDefinition{
 		Name:             aws.String(p.Type),
 		Cpu:              aws.Int64(int64(p.CPUShares)),
 		Command:          command,
@@ -782,10 +1667,113 @@ func (t *EmpireTemplate) ContainerDefinition(app *twelvefactor.Manifest, p *twel
 		Essential:        aws.Bool(true),
 		Memory:           aws.Int64(int64(p.Memory / bytesize.MB)),
 		Environment:      sortedEnvironment(twelvefactor.Env(app, p)),
-		LogConfiguration: t.LogConfiguration,
+		LogConfiguration: t.logConfiguration(p),
 		DockerLabels:     labels,
 		Ulimits:          ulimits,
+		Links:            links,
+		MountPoints:      mountPoints,
 	}
+	if s := p.Security; s != nil {
+		if s.Privileged {
+			containerDefinition.Privileged = aws.Bool(true)
+		}
+		if s.ReadOnlyRootFilesystem {
+			containerDefinition.ReadonlyRootFilesystem = aws.Bool(true)
+		}
+		if opts := twelvefactor.SecurityOpts(p); len(opts) > 0 {
+			containerDefinition.DockerSecurityOptions = aws.StringSlice(opts)
+		}
+	}
+
+	return containerDefinition
+}
+
+// hasCapabilities returns true if the process adds or drops Linux
+// capabilities.
+func hasCapabilities(p *twelvefactor.Process) bool {
+	return p.Security != nil && (len(p.Security.CapAdd) > 0 || len(p.Security.CapDrop) > 0)
+}
+
+// volumes returns the volumes for the task definition of a process.
+func (t *EmpireTemplate) volumes(app *twelvefactor.Manifest, p *twelvefactor.Process) []interface{} {
+	volumes := []interface{}{}
+	for _, v := range p.Volumes {
+		switch v.Type {
+		case twelvefactor.VolumeHost:
+			volumes = append(volumes, map[string]interface{}{
+				"Name": volumeName(app, p, v),
+				"Host": map[string]interface{}{
+					"SourcePath": v.Source,
+				},
+			})
+		case twelvefactor.VolumeEBS:
+			driver := t.VolumeDriver
+			if driver == "" {
+				driver = defaultVolumeDriver
+			}
+			size := v.Size / bytesize.GB
+			if size == 0 {
+				size = 1
+			}
+			volumes = append(volumes, map[string]interface{}{
+				"Name": volumeName(app, p, v),
+				"DockerVolumeConfiguration": map[string]interface{}{
+					"Scope":         "shared",
+					"Autoprovision": true,
+					"Driver":        driver,
+					"DriverOpts": map[string]string{
+						"volumetype": "gp2",
+						"size":       fmt.Sprintf("%d", size),
+					},
+				},
+			})
+		}
+	}
+	return volumes
+}
+
+// volumeName returns the name of the task definition volume for a process
+// volume. Shared Docker volumes are named after the task definition volume,
+// so ebs volumes are prefixed with the app and process to make them unique
+// within the cluster.
+func volumeName(app *twelvefactor.Manifest, p *twelvefactor.Process, v *twelvefactor.Volume) string {
+	if v.Type == twelvefactor.VolumeEBS {
+		return fmt.Sprintf("%s-%s-%s", app.Name, p.Type, v.Name)
+	}
+	return v.Name
+}
+
+// SidecarDefinitions generates an ECS ContainerDefinition for each sidecar of a
+// process. These are added to the same task definition as the process, so
+// that they're started and stopped with it.
+func (t *EmpireTemplate) SidecarDefinitions(app *twelvefactor.Manifest, p *twelvefactor.Process) []*ecs.ContainerDefinition {
+	var definitions []*ecs.ContainerDefinition
+	for _, sidecar := range p.Sidecars {
+		command := []*string{}
+		for _, s := range sidecar.Command {
+			command = append(command, aws.String(s))
+		}
+
+		labels := make(map[string]*string)
+		for k, v := range twelvefactor.Labels(app, p) {
+			labels[k] = aws.String(v)
+		}
+		labels[sidecarLabel] = aws.String(sidecar.Name)
+
+		definitions = append(definitions, &ecs.ContainerDefinition{
+			Name:             aws.String(sidecar.Name),
+			Cpu:              aws.Int64(0),
+			Command:          command,
+			Image:            aws.String(sidecar.Image.String()),
+			Essential:        aws.Bool(sidecar.Essential),
+			Memory:           aws.Int64(int64(sidecar.Memory / bytesize.MB)),
+			Environment:      sortedEnvironment(twelvefactor.SidecarEnv(app, p, sidecar)),
+			LogConfiguration: t.logConfiguration(p),
+			DockerLabels:     labels,
+			Ulimits:          []*ecs.Ulimit{},
+		})
+	}
+	return definitions
 }
 
 // HostedZone returns the HostedZone for the ZoneID.
@@ -843,10 +1831,21 @@ func cloudformationContainerDefinition(cd *ecs.ContainerDefinition) *ContainerDe
 		Environment:  cd.Environment,
 		DockerLabels: labels,
 		Ulimits:      cd.Ulimits,
+		Links:        cd.Links,
+		MountPoints:  cd.MountPoints,
 	}
 	if cd.LogConfiguration != nil {
 		c.LogConfiguration = cd.LogConfiguration
 	}
+	if cd.Privileged != nil {
+		c.Privileged = *cd.Privileged
+	}
+	if cd.ReadonlyRootFilesystem != nil {
+		c.ReadonlyRootFilesystem = *cd.ReadonlyRootFilesystem
+	}
+	if len(cd.DockerSecurityOptions) > 0 {
+		c.DockerSecurityOptions = cd.DockerSecurityOptions
+	}
 	return c
 }
 
@@ -890,6 +1889,7 @@ func runTaskResource(role interface{}) troposphere.Resource {
 			"Handler":     "index.handler",
 			"Role":        role,
 			"Runtime":     "python2.7",
+			"Timeout":     300,
 			"Code": map[string]interface{}{
 				"ZipFile": runTaskCode,
 			},
@@ -942,20 +1942,52 @@ func toInterface(v interface{}) interface{} {
 	return v
 }
 
-// A simple lambda function that can be used to trigger an ecs.RunTask.
+// A simple lambda function that can be used to trigger an ecs.RunTask. When the
+// event includes an overlap policy, running instances of the process are
+// either left alone, by skipping this invocation, stopped, or waited on until
+// they stop. If they're still running when the function is about to time out,
+// it fails, so that Lambda retries the invocation later.
 const runTaskCode = `
 import boto3
 import logging
+import time
 
 logger = logging.getLogger()
 logger.setLevel(logging.INFO)
 
 ecs = boto3.client('ecs')
 
+def running_tasks(event):
+  arns = []
+  for page in ecs.get_paginator('list_tasks').paginate(cluster=event['cluster'], startedBy=event['startedBy']):
+    arns.extend(page['taskArns'])
+
+  tasks = []
+  for i in range(0, len(arns), 100):
+    tasks.extend(ecs.describe_tasks(cluster=event['cluster'], tasks=arns[i:i+100])['tasks'])
+
+  return [t for t in tasks if t['lastStatus'] != 'STOPPED' and any(c['name'] == event['process'] for c in t['containers'])]
+
 def handler(event, context):
   logger.info('Request Received')
   logger.info(event)
 
+  overlap = event.get('overlap', 'allow')
+  if overlap != 'allow':
+    tasks = running_tasks(event)
+    if tasks and overlap == 'skip':
+      logger.info('Skipping, %d tasks are still running' % len(tasks))
+      return []
+    if overlap == 'replace':
+      for t in tasks:
+        ecs.stop_task(cluster=event['cluster'], task=t['taskArn'], reason='Replaced by a newer invocation')
+    if overlap == 'queue':
+      while tasks:
+        if context.get_remaining_time_in_millis() < 15000:
+          raise Exception('Timed out waiting for %d tasks to stop' % len(tasks))
+        time.sleep(5)
+        tasks = running_tasks(event)
+
   resp = ecs.run_task(
     cluster=event['cluster'],
     taskDefinition=event['taskDefinition'],
@@ -389,6 +389,7 @@ func TestEmpireTemplate(t *testing.T) {
 						Image:    image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
 						Command:  []string{"./bin/vacuum"},
 						Schedule: twelvefactor.CRONSchedule("* * * * *"),
+						Overlap:  "skip",
 						Quantity: 0,
 						Labels: map[string]string{
 							"empire.app.process": "vacuum",
@@ -458,6 +459,521 @@ func TestEmpireTemplate(t *testing.T) {
 				},
 			},
 		},
+
+		{
+			"sidecars.json",
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Env: map[string]string{
+					"EMPIRE_X_TASK_DEFINITION_TYPE": "custom",
+				},
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/web"},
+						Env: map[string]string{
+							"PORT": "8080",
+						},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
+								{
+									Host:      80,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTP{},
+								},
+							},
+						},
+						Labels: map[string]string{
+							"empire.app.process": "web",
+						},
+						Memory:    128 * bytesize.MB,
+						CPUShares: 256,
+						Quantity:  1,
+						Nproc:     256,
+						Sidecars: []*twelvefactor.Sidecar{
+							{
+								Name:  "envoy",
+								Image: image.Image{Repository: "envoyproxy/envoy", Tag: "v1.8.0"},
+								Env: map[string]string{
+									"ENVOY_LOG_LEVEL": "info",
+								},
+								Memory:    128 * bytesize.MB,
+								Essential: true,
+							},
+							{
+								Name:    "statsd",
+								Image:   image.Image{Repository: "prom/statsd-exporter", Tag: "latest"},
+								Command: []string{"--statsd.listen-udp=:8125"},
+								Memory:  64 * bytesize.MB,
+							},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			"volumes.json",
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "db",
+						Image:   image.Image{Repository: "postgres", Tag: "10"},
+						Command: []string{"postgres"},
+						Labels: map[string]string{
+							"empire.app.process": "db",
+						},
+						Memory:    1 * bytesize.GB,
+						CPUShares: 512,
+						Quantity:  1,
+						Volumes: []*twelvefactor.Volume{
+							{Name: "config", Type: twelvefactor.VolumeHost, Source: "/etc/postgresql", Path: "/etc/postgresql", ReadOnly: true},
+							{Name: "scratch", Type: twelvefactor.VolumeTmpfs, Path: "/tmp", Size: 64 * bytesize.MB},
+							{Name: "data", Type: twelvefactor.VolumeEBS, Path: "/var/lib/postgresql/data", Size: 100 * bytesize.GB},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			"gpu.json",
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "inference",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/inference"},
+						Labels: map[string]string{
+							"empire.app.process": "inference",
+						},
+						Memory:    6 * bytesize.GB,
+						CPUShares: 1024,
+						GPUs:      1,
+						Quantity:  2,
+					},
+				},
+			},
+		},
+
+		{
+			"capacity.json",
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/web"},
+						Labels: map[string]string{
+							"empire.app.process": "web",
+						},
+						Memory:    128 * bytesize.MB,
+						CPUShares: 256,
+						Quantity:  2,
+						Capacity:  twelvefactor.CapacityOnDemand,
+					},
+					{
+						Type:    "worker",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/worker"},
+						Labels: map[string]string{
+							"empire.app.process": "worker",
+						},
+						Memory:    128 * bytesize.MB,
+						CPUShares: 256,
+						Quantity:  4,
+						Capacity:  twelvefactor.CapacitySpot,
+					},
+				},
+			},
+		},
+
+		{
+			"security.json",
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "vpn",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/vpn"},
+						Labels: map[string]string{
+							"empire.app.process": "vpn",
+						},
+						Memory:    128 * bytesize.MB,
+						CPUShares: 256,
+						Quantity:  1,
+						Security: &procfile.Security{
+							Privileged:             true,
+							CapAdd:                 []string{"NET_ADMIN"},
+							CapDrop:                []string{"MKNOD"},
+							ReadOnlyRootFilesystem: true,
+							SeccompProfile:         "unconfined",
+						},
+					},
+				},
+			},
+		},
+
+		{
+			"ingress.json",
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Ingress: []*twelvefactor.Ingress{
+					{App: "api", Port: 50051},
+					{App: "billing", Port: 50051},
+					{App: "reports", Cluster: "batch", Port: 50051},
+					{App: "admin", Port: 8080},
+				},
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "grpc",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/grpc"},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
+								{
+									Host:      50051,
+									Container: 8080,
+									Protocol:  &twelvefactor.TCP{},
+								},
+							},
+						},
+						Labels: map[string]string{
+							"empire.app.process": "grpc",
+						},
+						Env: map[string]string{
+							"PORT": "8080",
+						},
+						Memory:    128 * bytesize.MB,
+						CPUShares: 256,
+						Quantity:  1,
+					},
+				},
+			},
+		},
+
+		{
+			"traffic.json",
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v2",
+				Name:    "acme-inc",
+				Env: map[string]string{
+					"LOAD_BALANCER_TYPE": "alb",
+				},
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "v2"},
+						Command: []string{"./bin/web"},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
+								{
+									Host:      80,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTP{},
+								},
+							},
+						},
+						Labels: map[string]string{
+							"empire.app.process": "web",
+						},
+						Memory:    128 * bytesize.MB,
+						CPUShares: 256,
+						Quantity:  2,
+					},
+				},
+				Previous: &twelvefactor.Manifest{
+					AppID:   "1234",
+					Release: "v1",
+					Name:    "acme-inc",
+					Env: map[string]string{
+						"LOAD_BALANCER_TYPE": "alb",
+					},
+					Processes: []*twelvefactor.Process{
+						{
+							Type:    "web",
+							Image:   image.Image{Repository: "remind101/acme-inc", Tag: "v1"},
+							Command: []string{"./bin/web"},
+							Exposure: &twelvefactor.Exposure{
+								Ports: []twelvefactor.Port{
+									{
+										Host:      80,
+										Container: 8080,
+										Protocol:  &twelvefactor.HTTP{},
+									},
+								},
+							},
+							Labels: map[string]string{
+								"empire.app.process": "web",
+							},
+							Memory:    128 * bytesize.MB,
+							CPUShares: 256,
+							Quantity:  2,
+						},
+					},
+				},
+				PreviousWeight: 10,
+			},
+		},
+
+		{
+			"grpc.json",
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Env: map[string]string{
+					"LOAD_BALANCER_TYPE": "alb",
+				},
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/web"},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
+								{
+									Host:      80,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTP{},
+								},
+								{
+									Host:      443,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTPS{Cert: "arn:aws:acm:us-east-1:012345678901:certificate/4a4d8b5e-3b1f-4a5e-9e1b-6b2f3c1d8e7a"},
+								},
+							},
+							ProtocolVersion: twelvefactor.ProtocolVersionGRPC,
+						},
+						Labels: map[string]string{
+							"empire.app.process": "web",
+						},
+						Memory:    128 * bytesize.MB,
+						CPUShares: 256,
+						Quantity:  1,
+					},
+				},
+			},
+		},
+
+		{
+			"http2-elb.json",
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/web"},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
+								{
+									Host:      80,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTP{},
+								},
+							},
+							ProtocolVersion: twelvefactor.ProtocolVersionHTTP2,
+						},
+						Labels: map[string]string{
+							"empire.app.process": "web",
+						},
+						Memory:    128 * bytesize.MB,
+						CPUShares: 256,
+						Quantity:  1,
+					},
+				},
+			},
+		},
+
+		{
+			"routes.json",
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Env: map[string]string{
+					"LOAD_BALANCER_TYPE": "alb",
+				},
+				Routes: []*twelvefactor.Route{
+					{Host: "admin.acme.com", Path: "/*", Process: "web"},
+					{Path: "/api/*", Process: "api"},
+				},
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/web"},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
+								{
+									Host:      80,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTP{},
+								},
+							},
+						},
+						Labels: map[string]string{
+							"empire.app.process": "web",
+						},
+						Memory:    128 * bytesize.MB,
+						CPUShares: 256,
+						Quantity:  1,
+					},
+					{
+						Type:    "api",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/api"},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
+								{
+									Host:      80,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTP{},
+								},
+							},
+						},
+						Labels: map[string]string{
+							"empire.app.process": "api",
+						},
+						Memory:    128 * bytesize.MB,
+						CPUShares: 256,
+						Quantity:  1,
+					},
+				},
+			},
+		},
+
+		{
+			"router-protected.json",
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Env: map[string]string{
+					"LOAD_BALANCER_TYPE": "alb",
+				},
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/web"},
+						Exposure: &twelvefactor.Exposure{
+							External: true,
+							Ports: []twelvefactor.Port{
+								{
+									Host:      80,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTP{},
+								},
+							},
+							AllowedCIDRs: []string{"203.0.113.0/24", "2001:db8::/32"},
+							BasicAuth:    &twelvefactor.BasicAuth{Username: "staging", Password: "secret"},
+						},
+						Labels: map[string]string{
+							"empire.app.process": "web",
+						},
+						Memory:    128 * bytesize.MB,
+						CPUShares: 256,
+						Quantity:  1,
+					},
+				},
+			},
+		},
+
+		{
+			"router-alb.json",
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Env: map[string]string{
+					"LOAD_BALANCER_TYPE": "alb",
+				},
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/web"},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
+								{
+									Host:      80,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTP{},
+								},
+							},
+							IdleTimeout:    5 * time.Minute,
+							WebSockets:     true,
+							StickySessions: true,
+						},
+						Labels: map[string]string{
+							"empire.app.process": "web",
+						},
+						Memory:    128 * bytesize.MB,
+						CPUShares: 256,
+						Quantity:  1,
+					},
+				},
+			},
+		},
+
+		{
+			"router-elb.json",
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Env: map[string]string{
+					"LOAD_BALANCER_TYPE": "elb",
+				},
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/web"},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
+								{
+									Host:      80,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTP{},
+								},
+							},
+							IdleTimeout:    5 * time.Minute,
+							WebSockets:     true,
+							StickySessions: false,
+						},
+						Labels: map[string]string{
+							"empire.app.process": "web",
+						},
+						Memory:    128 * bytesize.MB,
+						CPUShares: 256,
+						Quantity:  1,
+					},
+				},
+			},
+		},
 	}
 
 	stackTags := []*cloudformation.Tag{
@@ -470,31 +986,233 @@ func TestEmpireTemplate(t *testing.T) {
 			tmpl.NoCompress = true
 			buf := new(bytes.Buffer)
 
-			filename := fmt.Sprintf("templates/%s", tt.file)
-			data := &TemplateData{tt.app, stackTags}
-			err := tmpl.Execute(buf, data)
-			if assert.NoError(t, err) {
-				expected, err := ioutil.ReadFile(filename)
-				assert.NoError(t, err)
+			filename := fmt.Sprintf("templates/%s", tt.file)
+			data := &TemplateData{tt.app, stackTags}
+			err := tmpl.Execute(buf, data)
+			if assert.NoError(t, err) {
+				expected, err := ioutil.ReadFile(filename)
+				assert.NoError(t, err)
+
+				if got, want := buf.String(), string(expected); got != want {
+					ioutil.WriteFile(filename, buf.Bytes(), 0660)
+					t.Errorf("expected generated template to match existing %s. Wrote to %s", tt.file, filename)
+				}
+			}
+		})
+	}
+}
+
+func TestEmpireTemplate_Errors(t *testing.T) {
+	tests := []struct {
+		err error
+		app *twelvefactor.Manifest
+	}{
+		{
+			// When using an ALB, the container ports must all
+			// match.
+			errors.New("AWS Application Load Balancers can only map listeners to a single container port. 2 unique container ports were defined: [80 => 80, 8080 => 8080]"),
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Env: map[string]string{
+					"LOAD_BALANCER_TYPE": "alb",
+				},
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/web"},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
+								{
+									Host:      80,
+									Container: 80,
+									Protocol:  &twelvefactor.HTTP{},
+								},
+								{
+									Host:      8080,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTP{},
+								},
+							},
+						},
+						Labels: map[string]string{
+							"empire.app.process": "web",
+						},
+						Env: map[string]string{
+							"PORT": "8080",
+						},
+						Memory:    128 * bytesize.MB,
+						CPUShares: 256,
+						Quantity:  1,
+						Nproc:     256,
+					},
+				},
+			},
+		},
+
+		{
+			// When using an ALB, SSL and TCP listeners are not
+			// supported.
+			errors.New("tcp listeners are not supported with AWS Application Load Balancing"),
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Env: map[string]string{
+					"LOAD_BALANCER_TYPE": "alb",
+				},
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/web"},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
+								{
+									Host:      80,
+									Container: 80,
+									Protocol:  &twelvefactor.TCP{},
+								},
+							},
+						},
+						Labels: map[string]string{
+							"empire.app.process": "web",
+						},
+						Env: map[string]string{
+							"PORT": "8080",
+						},
+						Memory:    128 * bytesize.MB,
+						CPUShares: 256,
+						Quantity:  1,
+						Nproc:     256,
+					},
+				},
+			},
+		},
+
+		{
+			errors.New("tmpfs volumes are not supported with custom task definitions"),
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Env: map[string]string{
+					"EMPIRE_X_TASK_DEFINITION_TYPE": "custom",
+				},
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "worker",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/worker"},
+						Volumes: []*twelvefactor.Volume{
+							{Name: "scratch", Type: twelvefactor.VolumeTmpfs, Path: "/tmp", Size: 64 * bytesize.MB},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			errors.New("GPUs are not supported with custom task definitions"),
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Env: map[string]string{
+					"EMPIRE_X_TASK_DEFINITION_TYPE": "custom",
+				},
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "inference",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/inference"},
+						GPUs:    1,
+					},
+				},
+			},
+		},
+
+		{
+			errors.New("capabilities are not supported with custom task definitions"),
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Env: map[string]string{
+					"EMPIRE_X_TASK_DEFINITION_TYPE": "custom",
+				},
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "vpn",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/vpn"},
+						Security: &procfile.Security{
+							CapAdd: []string{"NET_ADMIN"},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			errors.New("unable to allow connections from reports: no security group is configured for the reporting cluster"),
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Ingress: []*twelvefactor.Ingress{
+					{App: "reports", Cluster: "reporting", Port: 80},
+				},
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/web"},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
+								{
+									Host:      80,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTP{},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 
-				if got, want := buf.String(), string(expected); got != want {
-					ioutil.WriteFile(filename, buf.Bytes(), 0660)
-					t.Errorf("expected generated template to match existing %s. Wrote to %s", tt.file, filename)
-				}
-			}
-		})
-	}
-}
+		{
+			errors.New("sticky sessions can't be used with WebSockets on web, since it doesn't use an Application Load Balancer"),
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/web"},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
+								{
+									Host:      80,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTP{},
+								},
+							},
+							WebSockets:     true,
+							StickySessions: true,
+						},
+					},
+				},
+			},
+		},
 
-func TestEmpireTemplate_Errors(t *testing.T) {
-	tests := []struct {
-		err error
-		app *twelvefactor.Manifest
-	}{
 		{
-			// When using an ALB, the container ports must all
-			// match.
-			errors.New("AWS Application Load Balancers can only map listeners to a single container port. 2 unique container ports were defined: [80 => 80, 8080 => 8080]"),
+			errors.New("web needs an https port to use grpc, since Application Load Balancers only support it over HTTPS"),
 			&twelvefactor.Manifest{
 				AppID:   "1234",
 				Release: "v1",
@@ -511,35 +1229,92 @@ func TestEmpireTemplate_Errors(t *testing.T) {
 							Ports: []twelvefactor.Port{
 								{
 									Host:      80,
-									Container: 80,
+									Container: 8080,
 									Protocol:  &twelvefactor.HTTP{},
 								},
+							},
+							ProtocolVersion: twelvefactor.ProtocolVersionGRPC,
+						},
+					},
+				},
+			},
+		},
+
+		{
+			errors.New("http2 can't be used over HTTPS on web, since it doesn't use an Application Load Balancer"),
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/web"},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
 								{
-									Host:      8080,
+									Host:      80,
 									Container: 8080,
 									Protocol:  &twelvefactor.HTTP{},
 								},
+								{
+									Host:      443,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTPS{Cert: "arn:aws:acm:us-east-1:012345678901:certificate/4a4d8b5e-3b1f-4a5e-9e1b-6b2f3c1d8e7a"},
+								},
 							},
+							ProtocolVersion: twelvefactor.ProtocolVersionHTTP2,
 						},
-						Labels: map[string]string{
-							"empire.app.process": "web",
+					},
+				},
+			},
+		},
+
+		{
+			errors.New("routing rules require the web process to be exposed with an Application Load Balancer"),
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Routes: []*twelvefactor.Route{
+					{Path: "/api/*", Process: "api"},
+				},
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/web"},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
+								{
+									Host:      80,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTP{},
+								},
+							},
 						},
-						Env: map[string]string{
-							"PORT": "8080",
+					},
+					{
+						Type:    "api",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/api"},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
+								{
+									Host:      80,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTP{},
+								},
+							},
 						},
-						Memory:    128 * bytesize.MB,
-						CPUShares: 256,
-						Quantity:  1,
-						Nproc:     256,
 					},
 				},
 			},
 		},
 
 		{
-			// When using an ALB, SSL and TCP listeners are not
-			// supported.
-			errors.New("tcp listeners are not supported with AWS Application Load Balancing"),
+			errors.New("unable to route requests to worker: the process isn't exposed"),
 			&twelvefactor.Manifest{
 				AppID:   "1234",
 				Release: "v1",
@@ -547,6 +1322,9 @@ func TestEmpireTemplate_Errors(t *testing.T) {
 				Env: map[string]string{
 					"LOAD_BALANCER_TYPE": "alb",
 				},
+				Routes: []*twelvefactor.Route{
+					{Path: "/api/*", Process: "worker"},
+				},
 				Processes: []*twelvefactor.Process{
 					{
 						Type:    "web",
@@ -556,23 +1334,91 @@ func TestEmpireTemplate_Errors(t *testing.T) {
 							Ports: []twelvefactor.Port{
 								{
 									Host:      80,
-									Container: 80,
-									Protocol:  &twelvefactor.TCP{},
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTP{},
 								},
 							},
 						},
-						Labels: map[string]string{
-							"empire.app.process": "web",
+					},
+					{
+						Type:    "worker",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/worker"},
+					},
+				},
+			},
+		},
+
+		{
+			errors.New("basic auth can't be used on web, since it doesn't use an Application Load Balancer"),
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/web"},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
+								{
+									Host:      80,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTP{},
+								},
+							},
+							BasicAuth: &twelvefactor.BasicAuth{Username: "staging", Password: "secret"},
 						},
-						Env: map[string]string{
-							"PORT": "8080",
+					},
+				},
+			},
+		},
+
+		{
+			errors.New("traffic to web can't be split between releases, since it doesn't use an Application Load Balancer"),
+			&twelvefactor.Manifest{
+				AppID:   "1234",
+				Release: "v2",
+				Name:    "acme-inc",
+				Processes: []*twelvefactor.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "v2"},
+						Command: []string{"./bin/web"},
+						Exposure: &twelvefactor.Exposure{
+							Ports: []twelvefactor.Port{
+								{
+									Host:      80,
+									Container: 8080,
+									Protocol:  &twelvefactor.HTTP{},
+								},
+							},
+						},
+					},
+				},
+				Previous: &twelvefactor.Manifest{
+					AppID:   "1234",
+					Release: "v1",
+					Name:    "acme-inc",
+					Processes: []*twelvefactor.Process{
+						{
+							Type:    "web",
+							Image:   image.Image{Repository: "remind101/acme-inc", Tag: "v1"},
+							Command: []string{"./bin/web"},
+							Exposure: &twelvefactor.Exposure{
+								Ports: []twelvefactor.Port{
+									{
+										Host:      80,
+										Container: 8080,
+										Protocol:  &twelvefactor.HTTP{},
+									},
+								},
+							},
 						},
-						Memory:    128 * bytesize.MB,
-						CPUShares: 256,
-						Quantity:  1,
-						Nproc:     256,
 					},
 				},
+				PreviousWeight: 10,
 			},
 		},
 	}
@@ -638,6 +1484,134 @@ func TestScheduleExpression(t *testing.T) {
 	}
 }
 
+func TestConnectionDrainingPolicy(t *testing.T) {
+	immediate, slow := time.Duration(0), 5*time.Minute
+
+	assert.Equal(t, map[string]interface{}{
+		"Enabled": true,
+		"Timeout": int64(30),
+	}, connectionDrainingPolicy(&twelvefactor.Exposure{}))
+	assert.Equal(t, map[string]interface{}{
+		"Enabled": false,
+	}, connectionDrainingPolicy(&twelvefactor.Exposure{DrainTimeout: &immediate}))
+	assert.Equal(t, map[string]interface{}{
+		"Enabled": true,
+		"Timeout": int64(300),
+	}, connectionDrainingPolicy(&twelvefactor.Exposure{DrainTimeout: &slow}))
+}
+
+func TestELBHealthCheck(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"Target":             Join("", "TCP:", 8080),
+		"Interval":           "30",
+		"Timeout":            "5",
+		"HealthyThreshold":   "10",
+		"UnhealthyThreshold": "2",
+	}, elbHealthCheck(&twelvefactor.HealthCheck{}, 8080))
+	assert.Equal(t, map[string]interface{}{
+		"Target":             Join("", "HTTP:", 8080, "/health"),
+		"Interval":           "10",
+		"Timeout":            "2",
+		"HealthyThreshold":   "2",
+		"UnhealthyThreshold": "3",
+	}, elbHealthCheck(&twelvefactor.HealthCheck{
+		Path:               "/health",
+		Interval:           10 * time.Second,
+		Timeout:            2 * time.Second,
+		HealthyThreshold:   2,
+		UnhealthyThreshold: 3,
+	}, 8080))
+}
+
+func TestContainerHealthCheck(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"Command": []string{"CMD", "./bin/alive"},
+	}, containerHealthCheck(&twelvefactor.HealthCheck{Command: []string{"./bin/alive"}}))
+	assert.Equal(t, map[string]interface{}{
+		"Command":     []string{"CMD", "./bin/alive"},
+		"Interval":    10,
+		"Timeout":     2,
+		"Retries":     3,
+		"StartPeriod": 60,
+	}, containerHealthCheck(&twelvefactor.HealthCheck{
+		Command:            []string{"./bin/alive"},
+		Interval:           10 * time.Second,
+		Timeout:            2 * time.Second,
+		UnhealthyThreshold: 3,
+		StartPeriod:        time.Minute,
+	}))
+}
+
+func TestEmpireTemplate_LogConfiguration(t *testing.T) {
+	tmpl := &EmpireTemplate{
+		LogConfiguration: &ecs.LogConfiguration{
+			LogDriver: aws.String("syslog"),
+		},
+	}
+	assert.Equal(t, tmpl.LogConfiguration, tmpl.logConfiguration(&twelvefactor.Process{}))
+	assert.Equal(t, &ecs.LogConfiguration{
+		LogDriver: aws.String("json-file"),
+		Options: map[string]*string{
+			"max-size": aws.String("10m"),
+			"max-file": aws.String("3"),
+		},
+	}, tmpl.logConfiguration(&twelvefactor.Process{
+		Logging: &twelvefactor.Logging{
+			Driver: "json-file",
+			Options: map[string]string{
+				"max-size": "10m",
+				"max-file": "3",
+			},
+		},
+	}))
+	assert.Equal(t, &ecs.LogConfiguration{
+		LogDriver: aws.String("none"),
+	}, tmpl.logConfiguration(&twelvefactor.Process{
+		Logging: &twelvefactor.Logging{Driver: "none"},
+	}))
+}
+
+func TestEmpireTemplate_PlacementStrategy(t *testing.T) {
+	app := &twelvefactor.Manifest{
+		AppID:   "1234",
+		Release: "v1",
+		Name:    "acme-inc",
+		Processes: []*twelvefactor.Process{
+			{
+				Type:    "worker",
+				Command: []string{"./bin/worker"},
+			},
+		},
+	}
+
+	tests := []struct {
+		strategy string
+		expected interface{}
+	}{
+		// ECS's default placement.
+		{"", nil},
+
+		{"spread", []interface{}{
+			map[string]interface{}{"Type": aws.String("spread"), "Field": aws.String("attribute:ecs.availability-zone")},
+			map[string]interface{}{"Type": aws.String("spread"), "Field": aws.String("instanceId")},
+		}},
+		{"binpack", []interface{}{
+			map[string]interface{}{"Type": aws.String("binpack"), "Field": aws.String("memory")},
+		}},
+	}
+
+	for _, tt := range tests {
+		tmpl := newTemplate()
+		tmpl.PlacementStrategy = tt.strategy
+
+		out, err := tmpl.Build(&TemplateData{app, nil})
+		assert.NoError(t, err)
+
+		properties := out.Resources["workerService"].Properties.(map[string]interface{})
+		assert.Equal(t, tt.expected, properties["PlacementStrategy"])
+	}
+}
+
 func newTemplate() *EmpireTemplate {
 	return &EmpireTemplate{
 		Cluster:                 "cluster",
@@ -647,6 +1621,11 @@ func newTemplate() *EmpireTemplate {
 		InternalSubnetIDs:       []string{"subnet-bb01c4cd", "subnet-c85f4091"},
 		ExternalSubnetIDs:       []string{"subnet-ca96f4cd", "subnet-a13b909c"},
 		CustomResourcesTopic:    "sns topic arn",
+		GPUConstraint:           "attribute:empire.gpu exists",
+		ClusterSecurityGroups: map[string]string{
+			"":      "sg-5b7d1a3c",
+			"batch": "sg-9c2e4f61",
+		},
 		HostedZone: &route53.HostedZone{
 			Id:   aws.String("Z3DG6IL3SJCGPX"),
 			Name: aws.String("empire"),
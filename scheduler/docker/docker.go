@@ -6,11 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"strconv"
 	"strings"
 
 	"github.com/fsouza/go-dockerclient"
 	"github.com/remind101/empire/internal/uuid"
 	"github.com/remind101/empire/pkg/dockerutil"
+	"github.com/remind101/empire/pkg/image"
 	"github.com/remind101/empire/twelvefactor"
 	"golang.org/x/net/context"
 )
@@ -23,11 +26,16 @@ type dockerClient interface {
 	InspectContainer(string) (*docker.Container, error)
 	ListContainers(docker.ListContainersOptions) ([]docker.APIContainers, error)
 	PullImage(context.Context, docker.PullImageOptions) error
+	PullImageWithAuth(context.Context, docker.PullImageOptions, docker.AuthConfiguration) error
 	CreateContainer(context.Context, docker.CreateContainerOptions) (*docker.Container, error)
 	RemoveContainer(context.Context, docker.RemoveContainerOptions) error
 	StartContainer(context.Context, string, *docker.HostConfig) error
 	StopContainer(context.Context, string, uint) error
 	AttachToContainer(context.Context, docker.AttachToContainerOptions) error
+	CreateExec(docker.CreateExecOptions) (*docker.Exec, error)
+	StartExec(string, docker.StartExecOptions) error
+	UploadToContainer(string, docker.UploadToContainerOptions) error
+	DownloadFromContainer(string, docker.DownloadFromContainerOptions) error
 }
 
 const (
@@ -40,6 +48,10 @@ const (
 
 	// Label that determines what the name of the process is.
 	processLabel = "empire.app.process"
+
+	// Label that's set on sidecar containers, with the name of the
+	// sidecar.
+	sidecarLabel = "empire.sidecar"
 )
 
 // Values for `runLabel`.
@@ -119,6 +131,32 @@ func (s *AttachedScheduler) Tasks(ctx context.Context, app string) ([]*twelvefac
 	return append(instances, result.instances...), nil
 }
 
+// QueryTasks returns a combination of instances from the wrapped scheduler, as
+// well as instances from attached runs, that match the query.
+func (s *AttachedScheduler) QueryTasks(ctx context.Context, q twelvefactor.TasksQuery) ([]*twelvefactor.Task, error) {
+	if !s.ShowAttached {
+		return s.Scheduler.QueryTasks(ctx, q)
+	}
+
+	instances, err := s.Scheduler.QueryTasks(ctx, q)
+	if err != nil {
+		return instances, err
+	}
+
+	attachedInstances, err := s.dockerScheduler.InstancesFromAttachedRuns(ctx, q.App)
+	if err != nil {
+		return instances, err
+	}
+
+	for _, i := range attachedInstances {
+		if q.Matches(i) {
+			instances = append(instances, i)
+		}
+	}
+
+	return instances, nil
+}
+
 // Stop checks if there's an attached run matching the given id, and stops that
 // container if there is. Otherwise, it delegates to the wrapped Scheduler.
 func (s *AttachedScheduler) Stop(ctx context.Context, maybeContainerID string) error {
@@ -137,6 +175,82 @@ func (s *AttachedScheduler) Stop(ctx context.Context, maybeContainerID string) e
 	return err
 }
 
+// Exec checks if there's an attached run matching the given id, and execs into
+// that container if there is. Otherwise, it delegates to the wrapped Scheduler.
+func (s *AttachedScheduler) Exec(ctx context.Context, app string, maybeContainerID string, opts twelvefactor.ExecOpts) error {
+	if !s.ShowAttached {
+		return s.Scheduler.Exec(ctx, app, maybeContainerID, opts)
+	}
+
+	err := s.dockerScheduler.Exec(ctx, app, maybeContainerID, opts)
+
+	// If there's no container with this ID, delegate to the wrapped
+	// scheduler.
+	if _, ok := err.(*docker.NoSuchContainer); ok {
+		return s.Scheduler.Exec(ctx, app, maybeContainerID, opts)
+	}
+
+	return err
+}
+
+// Dial checks if there's an attached run matching the given id, and connects to
+// that container if there is. Otherwise, it delegates to the wrapped Scheduler.
+func (s *AttachedScheduler) Dial(ctx context.Context, app string, maybeContainerID string, port int) (net.Conn, error) {
+	if !s.ShowAttached {
+		return s.Scheduler.Dial(ctx, app, maybeContainerID, port)
+	}
+
+	conn, err := s.dockerScheduler.Dial(ctx, app, maybeContainerID, port)
+
+	// If there's no container with this ID, delegate to the wrapped
+	// scheduler.
+	if _, ok := err.(*docker.NoSuchContainer); ok {
+		return s.Scheduler.Dial(ctx, app, maybeContainerID, port)
+	}
+
+	return conn, err
+}
+
+// CopyTo checks if there's an attached run matching the given id, and copies to
+// that container if there is. Otherwise, it delegates to the wrapped Scheduler.
+func (s *AttachedScheduler) CopyTo(ctx context.Context, app string, maybeContainerID string, path string, archive io.Reader) error {
+	if !s.ShowAttached {
+		return s.Scheduler.CopyTo(ctx, app, maybeContainerID, path, archive)
+	}
+
+	// The archive can only be read once, so check for the container before
+	// deciding which scheduler to copy to.
+	if _, err := s.dockerScheduler.appContainer(app, maybeContainerID); err != nil {
+		// If there's no container with this ID, delegate to the
+		// wrapped scheduler.
+		if _, ok := err.(*docker.NoSuchContainer); ok {
+			return s.Scheduler.CopyTo(ctx, app, maybeContainerID, path, archive)
+		}
+		return err
+	}
+
+	return s.dockerScheduler.CopyTo(ctx, app, maybeContainerID, path, archive)
+}
+
+// CopyFrom checks if there's an attached run matching the given id, and copies
+// from that container if there is. Otherwise, it delegates to the wrapped
+// Scheduler.
+func (s *AttachedScheduler) CopyFrom(ctx context.Context, app string, maybeContainerID string, path string, w io.Writer) error {
+	if !s.ShowAttached {
+		return s.Scheduler.CopyFrom(ctx, app, maybeContainerID, path, w)
+	}
+
+	err := s.dockerScheduler.CopyFrom(ctx, app, maybeContainerID, path, w)
+
+	// If there's no container with this ID, delegate to the wrapped
+	// scheduler.
+	if _, ok := err.(*docker.NoSuchContainer); ok {
+		return s.Scheduler.CopyFrom(ctx, app, maybeContainerID, path, w)
+	}
+
+	return err
+}
+
 // Scheduler provides an implementation of the scheduler.Scheduler interface
 // backed by Docker.
 type Scheduler struct {
@@ -159,16 +273,60 @@ func (s *Scheduler) Run(ctx context.Context, app *twelvefactor.Manifest) error {
 			return errors.New("cannot run detached processes with Docker scheduler")
 		}
 
+		if p.GPUs > 0 {
+			return errors.New("cannot reserve GPUs with Docker scheduler")
+		}
+
 		labels := twelvefactor.Labels(app, p)
 		labels[runLabel] = Attached
 
+		hostConfig := &docker.HostConfig{
+			LogConfig: docker.LogConfig{
+				Type: "json-file",
+			},
+		}
+		if l := p.Logging; l != nil {
+			hostConfig.LogConfig = docker.LogConfig{
+				Type:   l.Driver,
+				Config: l.Options,
+			}
+		}
+		for _, v := range p.Volumes {
+			switch v.Type {
+			case twelvefactor.VolumeHost:
+				bind := fmt.Sprintf("%s:%s", v.Source, v.Path)
+				if v.ReadOnly {
+					bind += ":ro"
+				}
+				hostConfig.Binds = append(hostConfig.Binds, bind)
+			case twelvefactor.VolumeTmpfs:
+				if hostConfig.Tmpfs == nil {
+					hostConfig.Tmpfs = make(map[string]string)
+				}
+				opts := fmt.Sprintf("size=%d", v.Size)
+				if v.ReadOnly {
+					opts += ",ro"
+				}
+				hostConfig.Tmpfs[v.Path] = opts
+			default:
+				return fmt.Errorf("cannot mount %s volumes with Docker scheduler", v.Type)
+			}
+		}
+		if sec := p.Security; sec != nil {
+			hostConfig.Privileged = sec.Privileged
+			hostConfig.CapAdd = sec.CapAdd
+			hostConfig.CapDrop = sec.CapDrop
+			hostConfig.ReadonlyRootfs = sec.ReadOnlyRootFilesystem
+			hostConfig.SecurityOpt = twelvefactor.SecurityOpts(p)
+		}
+
 		pullOptions, err := dockerutil.PullImageOptions(p.Image)
 		if err != nil {
 			return err
 		}
 		pullOptions.OutputStream = replaceNL(p.Stderr)
 
-		if err := s.docker.PullImage(ctx, pullOptions); err != nil {
+		if err := s.pullImage(ctx, pullOptions, twelvefactor.ImagePullSecret(app, p)); err != nil {
 			return fmt.Errorf("error pulling image: %v", err)
 		}
 
@@ -187,11 +345,7 @@ func (s *Scheduler) Run(ctx context.Context, app *twelvefactor.Manifest) error {
 				Env:          envKeys(twelvefactor.Env(app, p)),
 				Labels:       labels,
 			},
-			HostConfig: &docker.HostConfig{
-				LogConfig: docker.LogConfig{
-					Type: "json-file",
-				},
-			},
+			HostConfig: hostConfig,
 		})
 		if err != nil {
 			return fmt.Errorf("error creating container: %v", err)
@@ -206,6 +360,20 @@ func (s *Scheduler) Run(ctx context.Context, app *twelvefactor.Manifest) error {
 			return fmt.Errorf("error starting container: %v", err)
 		}
 
+		for _, sidecar := range p.Sidecars {
+			id, err := s.startSidecar(ctx, app, p, sidecar, container.ID)
+			if id != "" {
+				defer s.docker.RemoveContainer(ctx, docker.RemoveContainerOptions{
+					ID:            id,
+					RemoveVolumes: true,
+					Force:         true,
+				})
+			}
+			if err != nil {
+				return fmt.Errorf("error starting sidecar %s: %v", sidecar.Name, err)
+			}
+		}
+
 		if err := s.docker.AttachToContainer(ctx, docker.AttachToContainerOptions{
 			Container:    container.ID,
 			InputStream:  p.Stdin,
@@ -225,6 +393,57 @@ func (s *Scheduler) Run(ctx context.Context, app *twelvefactor.Manifest) error {
 	return nil
 }
 
+// startSidecar starts a container for the sidecar, which shares the network of
+// the process container. The sidecar isn't labeled with the app, so it's not
+// returned as an instance.
+func (s *Scheduler) startSidecar(ctx context.Context, app *twelvefactor.Manifest, p *twelvefactor.Process, sidecar *twelvefactor.Sidecar, containerID string) (string, error) {
+	pullOptions, err := dockerutil.PullImageOptions(sidecar.Image)
+	if err != nil {
+		return "", err
+	}
+	pullOptions.OutputStream = replaceNL(p.Stderr)
+
+	secret := twelvefactor.ImagePullSecret(app, &twelvefactor.Process{Image: sidecar.Image})
+	if err := s.pullImage(ctx, pullOptions, secret); err != nil {
+		return "", fmt.Errorf("error pulling image: %v", err)
+	}
+
+	container, err := s.docker.CreateContainer(ctx, docker.CreateContainerOptions{
+		Name: uuid.New(),
+		Config: &docker.Config{
+			Memory: int64(sidecar.Memory),
+			Image:  sidecar.Image.String(),
+			Cmd:    sidecar.Command,
+			Env:    envKeys(twelvefactor.SidecarEnv(app, p, sidecar)),
+			Labels: map[string]string{
+				sidecarLabel: sidecar.Name,
+			},
+		},
+		HostConfig: &docker.HostConfig{
+			NetworkMode: fmt.Sprintf("container:%s", containerID),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return container.ID, s.docker.StartContainer(ctx, container.ID, nil)
+}
+
+// pullImage pulls the image, using the pull secret from the app when one is
+// present.
+func (s *Scheduler) pullImage(ctx context.Context, opts docker.PullImageOptions, secret *twelvefactor.PullSecret) error {
+	if secret == nil || secret.Username == "" {
+		return s.docker.PullImage(ctx, opts)
+	}
+
+	return s.docker.PullImageWithAuth(ctx, opts, docker.AuthConfiguration{
+		Username:      secret.Username,
+		Password:      secret.Password,
+		ServerAddress: secret.Registry,
+	})
+}
+
 func (s *Scheduler) Instances(ctx context.Context, app string) ([]*twelvefactor.Task, error) {
 	return s.InstancesFromAttachedRuns(ctx, app)
 }
@@ -242,11 +461,14 @@ func (s *Scheduler) InstancesFromAttachedRuns(ctx context.Context, app string) (
 func (s *Scheduler) instances(ctx context.Context, app string, labels ...string) ([]*twelvefactor.Task, error) {
 	var instances []*twelvefactor.Task
 
+	// Without an app, the instances of every app are returned.
+	if app != "" {
+		labels = append([]string{fmt.Sprintf("%s=%s", appLabel, app)}, labels...)
+	}
+
 	containers, err := s.docker.ListContainers(docker.ListContainersOptions{
 		Filters: map[string][]string{
-			"label": append([]string{
-				fmt.Sprintf("%s=%s", appLabel, app),
-			}, labels...),
+			"label": labels,
 		},
 	})
 	if err != nil {
@@ -260,13 +482,16 @@ func (s *Scheduler) instances(ctx context.Context, app string, labels ...string)
 		}
 
 		state := strings.ToUpper(container.State.StateString())
+		img, _ := image.Decode(container.Config.Image)
 
 		instances = append(instances, &twelvefactor.Task{
+			App:       container.Config.Labels[appLabel],
 			ID:        container.ID[0:12],
 			State:     state,
 			UpdatedAt: container.State.StartedAt,
 			Process: &twelvefactor.Process{
 				Type:      container.Config.Labels[processLabel],
+				Image:     img,
 				Command:   container.Config.Cmd,
 				Env:       parseEnv(container.Config.Env),
 				Memory:    uint(container.HostConfig.Memory),
@@ -301,6 +526,130 @@ func (s *Scheduler) Stop(ctx context.Context, containerID string) error {
 	return nil
 }
 
+// Exec runs a command inside of the given container.
+func (s *Scheduler) Exec(ctx context.Context, app string, containerID string, opts twelvefactor.ExecOpts) error {
+	container, err := s.appContainer(app, containerID)
+	if err != nil {
+		return err
+	}
+
+	exec, err := s.docker.CreateExec(docker.CreateExecOptions{
+		Container:    container.ID,
+		Cmd:          opts.Command,
+		Tty:          opts.Tty,
+		AttachStdin:  opts.Stdin != nil,
+		AttachStdout: opts.Stdout != nil,
+		AttachStderr: opts.Stderr != nil,
+	})
+	if err != nil {
+		return fmt.Errorf("error creating exec: %v", err)
+	}
+
+	if err := s.docker.StartExec(exec.ID, docker.StartExecOptions{
+		Tty:          opts.Tty,
+		InputStream:  opts.Stdin,
+		OutputStream: opts.Stdout,
+		ErrorStream:  opts.Stderr,
+		RawTerminal:  opts.Tty,
+	}); err != nil {
+		return fmt.Errorf("error starting exec: %v", err)
+	}
+
+	return nil
+}
+
+// Dial connects to the given port on the container's IP address.
+func (s *Scheduler) Dial(ctx context.Context, app string, containerID string, port int) (net.Conn, error) {
+	container, err := s.appContainer(app, containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if container.NetworkSettings == nil || container.NetworkSettings.IPAddress == "" {
+		return nil, fmt.Errorf("container %s does not have an ip address", containerID)
+	}
+
+	return net.Dial("tcp", net.JoinHostPort(container.NetworkSettings.IPAddress, strconv.Itoa(port)))
+}
+
+// CopyTo extracts the tar archive into the directory at path inside of the
+// container.
+func (s *Scheduler) CopyTo(ctx context.Context, app string, containerID string, path string, archive io.Reader) error {
+	container, err := s.appContainer(app, containerID)
+	if err != nil {
+		return err
+	}
+
+	return s.docker.UploadToContainer(container.ID, docker.UploadToContainerOptions{
+		InputStream: archive,
+		Path:        path,
+	})
+}
+
+// CopyFrom writes a tar archive of the path inside of the container to w.
+func (s *Scheduler) CopyFrom(ctx context.Context, app string, containerID string, path string, w io.Writer) error {
+	container, err := s.appContainer(app, containerID)
+	if err != nil {
+		return err
+	}
+
+	return s.docker.DownloadFromContainer(container.ID, docker.DownloadFromContainerOptions{
+		OutputStream: w,
+		Path:         path,
+	})
+}
+
+// errHostsNotSupported is returned when trying to cordon, drain or list hosts.
+// The Docker scheduler only knows about the single Docker daemon that it's
+// connected to, so there's nowhere to move processes to.
+var errHostsNotSupported = errors.New("cannot manage hosts with Docker scheduler")
+
+func (s *Scheduler) Cordon(ctx context.Context, hostID string) error {
+	return errHostsNotSupported
+}
+
+func (s *Scheduler) Uncordon(ctx context.Context, hostID string) error {
+	return errHostsNotSupported
+}
+
+func (s *Scheduler) Drain(ctx context.Context, hostID string) error {
+	return errHostsNotSupported
+}
+
+func (s *Scheduler) Machines(ctx context.Context) ([]*twelvefactor.Machine, error) {
+	return nil, errHostsNotSupported
+}
+
+// StoppedTasks returns nothing, since attached containers are removed once they
+// exit, and their exit code is returned to the client.
+func (s *Scheduler) StoppedTasks(ctx context.Context, app string) ([]*twelvefactor.Task, error) {
+	return nil, nil
+}
+
+// Cleanup does nothing, since containers are removed once they exit.
+func (s *Scheduler) Cleanup(ctx context.Context, app string) error {
+	return nil
+}
+
+// appContainer inspects the given container, and ensures that it was started
+// by Empire for the app. Like Stop, this protects against interacting with
+// containers that were started outside of Empire.
+func (s *Scheduler) appContainer(app string, containerID string) (*docker.Container, error) {
+	container, err := s.docker.InspectContainer(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := container.Config.Labels
+	if _, ok := labels[runLabel]; !ok || labels[appLabel] != app {
+		return nil, &docker.NoSuchContainer{
+			ID: containerID,
+		}
+	}
+
+	return container, nil
+}
+
 func parseEnv(env []string) map[string]string {
 	m := make(map[string]string)
 	for _, e := range env {
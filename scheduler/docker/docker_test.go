@@ -1,6 +1,7 @@
 package docker
 
 import (
+	"io/ioutil"
 	"testing"
 	"time"
 
@@ -57,6 +58,7 @@ func TestScheduler_InstancesFromAttachedRuns(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(instances))
 	assert.Equal(t, &twelvefactor.Task{
+		App: "2cdc4941-e36d-4855-a0ec-51525db4a500",
 		Process: &twelvefactor.Process{
 			Type:    "run",
 			Command: []string{"/bin/sh"},
@@ -143,6 +145,68 @@ func TestAttachedScheduler_Stop_ContainerNotFound(t *testing.T) {
 	w.AssertExpectations(t)
 }
 
+func TestScheduler_Exec(t *testing.T) {
+	d := new(mockDockerClient)
+	s := Scheduler{
+		docker: d,
+	}
+
+	d.On("InspectContainer", "container_id").Return(&docker.Container{
+		ID: "container_id",
+		Config: &docker.Config{
+			Labels: map[string]string{
+				"run":           "attached",
+				"empire.app.id": "app_id",
+			},
+		},
+	}, nil)
+
+	d.On("CreateExec", docker.CreateExecOptions{
+		Container:    "container_id",
+		Cmd:          []string{"ps", "aux"},
+		AttachStdout: true,
+		AttachStderr: true,
+	}).Return(&docker.Exec{ID: "exec_id"}, nil)
+
+	d.On("StartExec", "exec_id", docker.StartExecOptions{
+		OutputStream: ioutil.Discard,
+		ErrorStream:  ioutil.Discard,
+	}).Return(nil)
+
+	err := s.Exec(ctx, "app_id", "container_id", twelvefactor.ExecOpts{
+		Command: []string{"ps", "aux"},
+		Stdout:  ioutil.Discard,
+		Stderr:  ioutil.Discard,
+	})
+	assert.NoError(t, err)
+
+	d.AssertExpectations(t)
+}
+
+func TestScheduler_Exec_ContainerForOtherApp(t *testing.T) {
+	d := new(mockDockerClient)
+	s := Scheduler{
+		docker: d,
+	}
+
+	d.On("InspectContainer", "container_id").Return(&docker.Container{
+		ID: "container_id",
+		Config: &docker.Config{
+			Labels: map[string]string{
+				"run":           "attached",
+				"empire.app.id": "other_app_id",
+			},
+		},
+	}, nil)
+
+	err := s.Exec(ctx, "app_id", "container_id", twelvefactor.ExecOpts{
+		Command: []string{"ps", "aux"},
+	})
+	assert.IsType(t, &docker.NoSuchContainer{}, err)
+
+	d.AssertExpectations(t)
+}
+
 func TestParseEnv(t *testing.T) {
 	tests := []struct {
 		in  []string
@@ -181,6 +245,20 @@ func (m *mockDockerClient) StopContainer(ctx context.Context, id string, timeout
 	return args.Error(0)
 }
 
+func (m *mockDockerClient) CreateExec(opts docker.CreateExecOptions) (*docker.Exec, error) {
+	args := m.Called(opts)
+	var exec *docker.Exec
+	if v := args.Get(0); v != nil {
+		exec = v.(*docker.Exec)
+	}
+	return exec, args.Error(1)
+}
+
+func (m *mockDockerClient) StartExec(id string, opts docker.StartExecOptions) error {
+	args := m.Called(id, opts)
+	return args.Error(0)
+}
+
 type mockScheduler struct {
 	twelvefactor.Scheduler
 	mock.Mock
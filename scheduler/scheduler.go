@@ -0,0 +1,83 @@
+// Package scheduler is a registry of scheduler backends, which lets a custom
+// build of Empire compile in its own twelvefactor.Scheduler implementation,
+// and select it with the EMPIRE_SCHEDULER option, in the same way that
+// database/sql drivers are registered:
+//
+//	func init() {
+//		scheduler.Register("nomad", func(c scheduler.Config) (twelvefactor.Scheduler, error) {
+//			return nomad.NewScheduler(c.Cluster)
+//		})
+//	}
+package scheduler
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/remind101/empire/twelvefactor"
+)
+
+// Config is provided to a Factory when the scheduler is created.
+type Config struct {
+	// The database that Empire uses, for backends that need to store
+	// state.
+	DB *sql.DB
+
+	// The name of the cluster that the scheduler is for. Empire creates a
+	// scheduler for the default cluster, and one for each additional
+	// cluster.
+	Cluster string
+
+	// The default placement strategy of processes in the cluster:
+	// twelvefactor.PlacementBinpack, twelvefactor.PlacementSpread, or empty
+	// for the default of the backend. Backends that can't control
+	// placement can ignore it.
+	PlacementStrategy string
+}
+
+// Factory creates a Scheduler.
+type Factory func(Config) (twelvefactor.Scheduler, error)
+
+var (
+	mu        sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// Register makes a scheduler backend available by the given name. It panics
+// if the name is already registered, or the factory is nil.
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if factory == nil {
+		panic("scheduler: Register factory is nil")
+	}
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("scheduler: Register called twice for %s", name))
+	}
+	factories[name] = factory
+}
+
+// Lookup returns the factory registered with the given name.
+func Lookup(name string) (Factory, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	f, ok := factories[name]
+	return f, ok
+}
+
+// Names returns the sorted names of the registered backends.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var names []string
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,30 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/twelvefactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	var cluster string
+	Register("test", func(c Config) (twelvefactor.Scheduler, error) {
+		cluster = c.Cluster
+		return nil, nil
+	})
+
+	f, ok := Lookup("test")
+	assert.True(t, ok)
+	_, err := f(Config{Cluster: "gpu"})
+	assert.NoError(t, err)
+	assert.Equal(t, "gpu", cluster)
+	assert.Contains(t, Names(), "test")
+
+	_, ok = Lookup("unknown")
+	assert.False(t, ok)
+
+	assert.Panics(t, func() {
+		Register("test", func(c Config) (twelvefactor.Scheduler, error) { return nil, nil })
+	})
+}
@@ -62,6 +62,36 @@ SET default_tablespace = '';
 
 SET default_with_oids = false;
 
+--
+-- Name: activities; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE activities (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL,
+    app_id uuid NOT NULL,
+    type text NOT NULL,
+    created_by text DEFAULT ''::text NOT NULL,
+    description text DEFAULT ''::text NOT NULL,
+    release integer DEFAULT 0 NOT NULL,
+    created_at timestamp without time zone DEFAULT timezone('utc'::text, now())
+);
+
+
+--
+-- Name: api_tokens; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE api_tokens (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL,
+    name text NOT NULL,
+    token_hash text NOT NULL,
+    scope json NOT NULL,
+    created_by text DEFAULT ''::text NOT NULL,
+    created_at timestamp without time zone DEFAULT timezone('utc'::text, now()),
+    last_used_at timestamp without time zone
+);
+
+
 --
 -- Name: apps; Type: TABLE; Schema: public; Owner: -
 --
@@ -74,7 +104,34 @@ CREATE TABLE apps (
     exposure text DEFAULT 'private'::text NOT NULL,
     certs json,
     maintenance boolean DEFAULT false NOT NULL,
-    deleted_at timestamp without time zone
+    deleted_at timestamp without time zone,
+    team text DEFAULT ''::text NOT NULL,
+    protected boolean DEFAULT false NOT NULL,
+    cluster text DEFAULT ''::text NOT NULL,
+    previous_release_weight integer DEFAULT 0 NOT NULL,
+    router_settings json,
+    formation json,
+    applied_image text,
+    applied_slug_image text,
+    deploy_timeout bigint DEFAULT 0 NOT NULL,
+    cron_timezone text DEFAULT ''::text NOT NULL,
+    alert_routing_key text DEFAULT ''::text NOT NULL,
+    namespace text DEFAULT ''::text NOT NULL,
+    labels json,
+    chaos_fraction double precision DEFAULT 0 NOT NULL
+);
+
+
+--
+-- Name: batches; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE batches (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL,
+    app_id uuid NOT NULL,
+    parallelism integer DEFAULT 1 NOT NULL,
+    created_by text DEFAULT ''::text NOT NULL,
+    created_at timestamp without time zone DEFAULT timezone('utc'::text, now())
 );
 
 
@@ -93,9 +150,22 @@ CREATE TABLE certificates (
 
 
 --
--- Name: configs; Type: TABLE; Schema: public; Owner: -
+-- Name: chaos_kills; Type: TABLE; Schema: public; Owner: -
 --
 
+CREATE TABLE chaos_kills (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL,
+    app_id uuid NOT NULL,
+    process_type text NOT NULL,
+    task_id text NOT NULL,
+    pid text NOT NULL,
+    release integer NOT NULL,
+    killed_at timestamp without time zone DEFAULT timezone('utc'::text, now()),
+    recovered_at timestamp without time zone
+);
+
+
+
 CREATE TABLE configs (
     id uuid DEFAULT uuid_generate_v4() NOT NULL,
     app_id uuid NOT NULL,
@@ -104,6 +174,27 @@ CREATE TABLE configs (
 );
 
 
+--
+-- Name: cron_runs; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE cron_runs (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL,
+    app_id uuid NOT NULL,
+    process_type text NOT NULL,
+    trigger text NOT NULL,
+    state text NOT NULL,
+    task_id text DEFAULT ''::text NOT NULL,
+    exit_code integer,
+    output text DEFAULT ''::text NOT NULL,
+    created_by text DEFAULT ''::text NOT NULL,
+    created_at timestamp without time zone DEFAULT timezone('utc'::text, now()),
+    started_at timestamp without time zone,
+    finished_at timestamp without time zone,
+    scheduled_at timestamp without time zone
+);
+
+
 --
 -- Name: domains; Type: TABLE; Schema: public; Owner: -
 --
@@ -126,6 +217,98 @@ CREATE TABLE ecs_environment (
 );
 
 
+--
+-- Name: freeze_windows; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE freeze_windows (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL,
+    starts_at timestamp without time zone NOT NULL,
+    ends_at timestamp without time zone NOT NULL,
+    reason text DEFAULT ''::text NOT NULL,
+    team text DEFAULT ''::text NOT NULL,
+    app_id uuid,
+    created_by text DEFAULT ''::text NOT NULL,
+    created_at timestamp without time zone DEFAULT timezone('utc'::text, now())
+);
+
+
+--
+-- Name: grants; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE grants (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL,
+    username text DEFAULT ''::text NOT NULL,
+    team text DEFAULT ''::text NOT NULL,
+    app_id uuid,
+    role text NOT NULL,
+    created_at timestamp without time zone DEFAULT timezone('utc'::text, now()),
+    namespace text DEFAULT ''::text NOT NULL
+);
+
+
+--
+-- Name: idempotency_keys; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE idempotency_keys (
+    key text NOT NULL,
+    user_name text NOT NULL,
+    operation text NOT NULL,
+    result json,
+    created_at timestamp without time zone DEFAULT timezone('utc'::text, now())
+);
+
+
+--
+-- Name: ingress_rules; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE ingress_rules (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL,
+    app_id uuid NOT NULL,
+    source_app_id uuid NOT NULL,
+    port integer NOT NULL,
+    created_at timestamp without time zone DEFAULT timezone('utc'::text, now())
+);
+
+
+--
+-- Name: jobs; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE jobs (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL,
+    app_id uuid NOT NULL,
+    type text NOT NULL,
+    command json NOT NULL,
+    cpu_share integer DEFAULT 0 NOT NULL,
+    memory bigint DEFAULT 0 NOT NULL,
+    nproc bigint DEFAULT 0 NOT NULL,
+    created_by text DEFAULT ''::text NOT NULL,
+    reason text DEFAULT ''::text NOT NULL,
+    created_at timestamp without time zone DEFAULT timezone('utc'::text, now()),
+    batch_id uuid,
+    state text DEFAULT 'queued'::text NOT NULL,
+    exit_code integer,
+    started_at timestamp without time zone,
+    finished_at timestamp without time zone
+);
+
+
+--
+-- Name: namespaces; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE namespaces (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL,
+    name text NOT NULL,
+    team text NOT NULL,
+    created_at timestamp without time zone DEFAULT timezone('utc'::text, now())
+);
+
+
 --
 -- Name: ports; Type: TABLE; Schema: public; Owner: -
 --
@@ -138,6 +321,39 @@ CREATE TABLE ports (
 );
 
 
+--
+-- Name: quotas; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE quotas (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL,
+    team text DEFAULT ''::text NOT NULL,
+    app_id uuid,
+    max_instances integer DEFAULT 0 NOT NULL,
+    max_memory bigint DEFAULT 0 NOT NULL,
+    max_runs integer DEFAULT 0 NOT NULL,
+    created_at timestamp without time zone DEFAULT timezone('utc'::text, now()),
+    max_run_memory bigint DEFAULT 0 NOT NULL,
+    namespace text DEFAULT ''::text NOT NULL
+);
+
+
+--
+-- Name: registry_credentials; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE registry_credentials (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL,
+    registry text NOT NULL,
+    username text DEFAULT ''::text NOT NULL,
+    password text DEFAULT ''::text NOT NULL,
+    credentials_parameter text DEFAULT ''::text NOT NULL,
+    team text DEFAULT ''::text NOT NULL,
+    app_id uuid,
+    created_at timestamp without time zone DEFAULT timezone('utc'::text, now())
+);
+
+
 --
 -- Name: releases; Type: TABLE; Schema: public; Owner: -
 --
@@ -150,7 +366,25 @@ CREATE TABLE releases (
     version integer NOT NULL,
     description text,
     created_at timestamp without time zone DEFAULT timezone('utc'::text, now()),
-    formation json NOT NULL
+    formation json NOT NULL,
+    created_by text DEFAULT ''::text NOT NULL,
+    source text DEFAULT ''::text NOT NULL,
+    message text DEFAULT ''::text NOT NULL,
+    formation_version integer DEFAULT 1 NOT NULL
+);
+
+
+--
+-- Name: routing_rules; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE routing_rules (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL,
+    app_id uuid NOT NULL,
+    host text DEFAULT ''::text NOT NULL,
+    path text DEFAULT ''::text NOT NULL,
+    process text NOT NULL,
+    created_at timestamp without time zone DEFAULT timezone('utc'::text, now())
 );
 
 
@@ -180,7 +414,9 @@ CREATE TABLE schema_migrations (
 CREATE TABLE slugs (
     id uuid DEFAULT uuid_generate_v4() NOT NULL,
     image text NOT NULL,
-    procfile bytea NOT NULL
+    procfile bytea NOT NULL,
+    layers json,
+    provenance json
 );
 
 
@@ -194,6 +430,81 @@ CREATE TABLE stacks (
 );
 
 
+--
+-- Name: task_transitions; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE task_transitions (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL,
+    app_id uuid NOT NULL,
+    process_type text NOT NULL,
+    task_id text NOT NULL,
+    pid text NOT NULL,
+    release integer NOT NULL,
+    host text DEFAULT ''::text NOT NULL,
+    state text NOT NULL,
+    reason text DEFAULT ''::text NOT NULL,
+    occurred_at timestamp without time zone DEFAULT timezone('utc'::text, now())
+);
+
+
+--
+-- Name: team_members; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE team_members (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL,
+    team text NOT NULL,
+    username text NOT NULL,
+    created_at timestamp without time zone DEFAULT timezone('utc'::text, now())
+);
+
+
+--
+-- Name: two_factor_secrets; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE two_factor_secrets (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL,
+    username text NOT NULL,
+    secret text NOT NULL,
+    created_at timestamp without time zone DEFAULT timezone('utc'::text, now()),
+    last_step bigint DEFAULT 0 NOT NULL
+);
+
+
+--
+-- Name: usage_periods; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE usage_periods (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL,
+    app_id uuid NOT NULL,
+    app_name text NOT NULL,
+    team text DEFAULT ''::text NOT NULL,
+    instances integer NOT NULL,
+    memory bigint NOT NULL,
+    started_at timestamp without time zone NOT NULL,
+    ended_at timestamp without time zone
+);
+
+
+--
+-- Name: activities activities_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY activities
+    ADD CONSTRAINT activities_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: api_tokens api_tokens_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY api_tokens
+    ADD CONSTRAINT api_tokens_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: apps apps_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -202,6 +513,14 @@ ALTER TABLE ONLY apps
     ADD CONSTRAINT apps_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: batches batches_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY batches
+    ADD CONSTRAINT batches_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: certificates certificates_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -210,6 +529,14 @@ ALTER TABLE ONLY certificates
     ADD CONSTRAINT certificates_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: chaos_kills chaos_kills_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY chaos_kills
+    ADD CONSTRAINT chaos_kills_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: configs configs_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -218,6 +545,14 @@ ALTER TABLE ONLY configs
     ADD CONSTRAINT configs_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: cron_runs cron_runs_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY cron_runs
+    ADD CONSTRAINT cron_runs_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: domains domains_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -234,6 +569,46 @@ ALTER TABLE ONLY ecs_environment
     ADD CONSTRAINT ecs_environment_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: freeze_windows freeze_windows_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY freeze_windows
+    ADD CONSTRAINT freeze_windows_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: grants grants_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY grants
+    ADD CONSTRAINT grants_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: ingress_rules ingress_rules_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY ingress_rules
+    ADD CONSTRAINT ingress_rules_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: jobs jobs_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY jobs
+    ADD CONSTRAINT jobs_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: namespaces namespaces_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY namespaces
+    ADD CONSTRAINT namespaces_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: ports ports_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -242,6 +617,22 @@ ALTER TABLE ONLY ports
     ADD CONSTRAINT ports_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: quotas quotas_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY quotas
+    ADD CONSTRAINT quotas_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: registry_credentials registry_credentials_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY registry_credentials
+    ADD CONSTRAINT registry_credentials_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: releases releases_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -250,6 +641,14 @@ ALTER TABLE ONLY releases
     ADD CONSTRAINT releases_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: routing_rules routing_rules_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY routing_rules
+    ADD CONSTRAINT routing_rules_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: schema_migrations schema_migrations_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -266,6 +665,66 @@ ALTER TABLE ONLY slugs
     ADD CONSTRAINT slugs_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: task_transitions task_transitions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY task_transitions
+    ADD CONSTRAINT task_transitions_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: team_members team_members_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY team_members
+    ADD CONSTRAINT team_members_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: two_factor_secrets two_factor_secrets_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY two_factor_secrets
+    ADD CONSTRAINT two_factor_secrets_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: usage_periods usage_periods_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY usage_periods
+    ADD CONSTRAINT usage_periods_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: index_activities_on_app_id_and_created_at; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_activities_on_app_id_and_created_at ON activities USING btree (app_id, created_at);
+
+
+--
+-- Name: index_api_tokens_on_token_hash; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_api_tokens_on_token_hash ON api_tokens USING btree (token_hash);
+
+
+--
+-- Name: index_apps_on_namespace; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_apps_on_namespace ON apps USING btree (namespace);
+
+
+--
+-- Name: index_batches_on_app_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_batches_on_app_id ON batches USING btree (app_id);
+
+
 --
 -- Name: index_certificates_on_app_id; Type: INDEX; Schema: public; Owner: -
 --
@@ -273,6 +732,20 @@ ALTER TABLE ONLY slugs
 CREATE UNIQUE INDEX index_certificates_on_app_id ON certificates USING btree (app_id);
 
 
+--
+-- Name: index_chaos_kills_on_app_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_chaos_kills_on_app_id ON chaos_kills USING btree (app_id);
+
+
+--
+-- Name: index_chaos_kills_on_killed_at; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_chaos_kills_on_killed_at ON chaos_kills USING btree (killed_at);
+
+
 --
 -- Name: index_configs_on_created_at; Type: INDEX; Schema: public; Owner: -
 --
@@ -280,6 +753,27 @@ CREATE UNIQUE INDEX index_certificates_on_app_id ON certificates USING btree (ap
 CREATE INDEX index_configs_on_created_at ON configs USING btree (created_at);
 
 
+--
+-- Name: index_cron_runs_on_app_id_and_process_type; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_cron_runs_on_app_id_and_process_type ON cron_runs USING btree (app_id, process_type);
+
+
+--
+-- Name: index_cron_runs_on_app_id_and_process_type_and_scheduled_at; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_cron_runs_on_app_id_and_process_type_and_scheduled_at ON cron_runs USING btree (app_id, process_type, scheduled_at);
+
+
+--
+-- Name: index_cron_runs_on_task_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_cron_runs_on_task_id ON cron_runs USING btree (task_id);
+
+
 --
 -- Name: index_domains_on_app_id; Type: INDEX; Schema: public; Owner: -
 --
@@ -294,6 +788,62 @@ CREATE INDEX index_domains_on_app_id ON domains USING btree (app_id);
 CREATE UNIQUE INDEX index_domains_on_hostname ON domains USING btree (hostname);
 
 
+--
+-- Name: index_freeze_windows_on_ends_at; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_freeze_windows_on_ends_at ON freeze_windows USING btree (ends_at);
+
+
+--
+-- Name: index_idempotency_keys_on_user_name_and_key; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_idempotency_keys_on_user_name_and_key ON idempotency_keys USING btree (user_name, key);
+
+
+--
+-- Name: index_ingress_rules_on_app_id_and_source_app_id_and_port; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_ingress_rules_on_app_id_and_source_app_id_and_port ON ingress_rules USING btree (app_id, source_app_id, port);
+
+
+--
+-- Name: index_jobs_on_batch_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_jobs_on_batch_id ON jobs USING btree (batch_id);
+
+
+--
+-- Name: index_jobs_on_created_at; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_jobs_on_created_at ON jobs USING btree (created_at);
+
+
+--
+-- Name: index_namespaces_on_name; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_namespaces_on_name ON namespaces USING btree (name);
+
+
+--
+-- Name: index_quotas_on_scope; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_quotas_on_scope ON quotas USING btree (team, namespace, COALESCE(app_id, '00000000-0000-0000-0000-000000000000'::uuid));
+
+
+--
+-- Name: index_registry_credentials_on_scope; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_registry_credentials_on_scope ON registry_credentials USING btree (registry, team, COALESCE(app_id, '00000000-0000-0000-0000-000000000000'::uuid));
+
+
 --
 -- Name: index_releases_on_app_id_and_version; Type: INDEX; Schema: public; Owner: -
 --
@@ -301,6 +851,13 @@ CREATE UNIQUE INDEX index_domains_on_hostname ON domains USING btree (hostname);
 CREATE UNIQUE INDEX index_releases_on_app_id_and_version ON releases USING btree (app_id, version);
 
 
+--
+-- Name: index_routing_rules_on_app_id_and_host_and_path; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_routing_rules_on_app_id_and_host_and_path ON routing_rules USING btree (app_id, host, path);
+
+
 --
 -- Name: index_stacks_on_app_id; Type: INDEX; Schema: public; Owner: -
 --
@@ -315,6 +872,48 @@ CREATE UNIQUE INDEX index_stacks_on_app_id ON stacks USING btree (app_id);
 CREATE UNIQUE INDEX index_stacks_on_stack_name ON stacks USING btree (stack_name);
 
 
+--
+-- Name: index_task_transitions_on_app_id_and_occurred_at; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_task_transitions_on_app_id_and_occurred_at ON task_transitions USING btree (app_id, occurred_at);
+
+
+--
+-- Name: index_task_transitions_on_task_id_and_state; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_task_transitions_on_task_id_and_state ON task_transitions USING btree (task_id, state);
+
+
+--
+-- Name: index_team_members_on_team_and_username; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_team_members_on_team_and_username ON team_members USING btree (team, username);
+
+
+--
+-- Name: index_two_factor_secrets_on_username; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_two_factor_secrets_on_username ON two_factor_secrets USING btree (username);
+
+
+--
+-- Name: index_usage_periods_on_app_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_usage_periods_on_app_id ON usage_periods USING btree (app_id);
+
+
+--
+-- Name: index_usage_periods_on_started_at; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_usage_periods_on_started_at ON usage_periods USING btree (started_at);
+
+
 --
 -- Name: unique_app_name; Type: INDEX; Schema: public; Owner: -
 --
@@ -322,6 +921,22 @@ CREATE UNIQUE INDEX index_stacks_on_stack_name ON stacks USING btree (stack_name
 CREATE UNIQUE INDEX unique_app_name ON apps USING btree (name) WHERE (deleted_at IS NULL);
 
 
+--
+-- Name: activities activities_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY activities
+    ADD CONSTRAINT activities_app_id_fkey FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE;
+
+
+--
+-- Name: batches batches_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY batches
+    ADD CONSTRAINT batches_app_id_fkey FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE;
+
+
 --
 -- Name: certificates certificates_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
@@ -330,6 +945,14 @@ ALTER TABLE ONLY certificates
     ADD CONSTRAINT certificates_app_id_fkey FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE;
 
 
+--
+-- Name: chaos_kills chaos_kills_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY chaos_kills
+    ADD CONSTRAINT chaos_kills_app_id_fkey FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE;
+
+
 --
 -- Name: configs configs_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
@@ -338,6 +961,14 @@ ALTER TABLE ONLY configs
     ADD CONSTRAINT configs_app_id_fkey FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE;
 
 
+--
+-- Name: cron_runs cron_runs_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY cron_runs
+    ADD CONSTRAINT cron_runs_app_id_fkey FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE;
+
+
 --
 -- Name: domains domains_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
@@ -346,6 +977,54 @@ ALTER TABLE ONLY domains
     ADD CONSTRAINT domains_app_id_fkey FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE;
 
 
+--
+-- Name: freeze_windows freeze_windows_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY freeze_windows
+    ADD CONSTRAINT freeze_windows_app_id_fkey FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE;
+
+
+--
+-- Name: grants grants_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY grants
+    ADD CONSTRAINT grants_app_id_fkey FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE;
+
+
+--
+-- Name: ingress_rules ingress_rules_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY ingress_rules
+    ADD CONSTRAINT ingress_rules_app_id_fkey FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE;
+
+
+--
+-- Name: ingress_rules ingress_rules_source_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY ingress_rules
+    ADD CONSTRAINT ingress_rules_source_app_id_fkey FOREIGN KEY (source_app_id) REFERENCES apps(id) ON DELETE CASCADE;
+
+
+--
+-- Name: jobs jobs_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY jobs
+    ADD CONSTRAINT jobs_app_id_fkey FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE;
+
+
+--
+-- Name: jobs jobs_batch_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY jobs
+    ADD CONSTRAINT jobs_batch_id_fkey FOREIGN KEY (batch_id) REFERENCES batches(id) ON DELETE CASCADE;
+
+
 --
 -- Name: ports ports_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
@@ -354,6 +1033,22 @@ ALTER TABLE ONLY ports
     ADD CONSTRAINT ports_app_id_fkey FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE SET NULL;
 
 
+--
+-- Name: quotas quotas_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY quotas
+    ADD CONSTRAINT quotas_app_id_fkey FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE;
+
+
+--
+-- Name: registry_credentials registry_credentials_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY registry_credentials
+    ADD CONSTRAINT registry_credentials_app_id_fkey FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE;
+
+
 --
 -- Name: releases releases_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
@@ -378,7 +1073,31 @@ ALTER TABLE ONLY releases
     ADD CONSTRAINT releases_slug_id_fkey FOREIGN KEY (slug_id) REFERENCES slugs(id) ON DELETE CASCADE;
 
 
+--
+-- Name: routing_rules routing_rules_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY routing_rules
+    ADD CONSTRAINT routing_rules_app_id_fkey FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE;
+
+
 --
 -- PostgreSQL database dump complete
 --
 
+--
+-- Name: task_transitions task_transitions_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY task_transitions
+    ADD CONSTRAINT task_transitions_app_id_fkey FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE;
+
+
+--
+-- Name: usage_periods usage_periods_app_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY usage_periods
+    ADD CONSTRAINT usage_periods_app_id_fkey FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE;
+
+
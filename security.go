@@ -0,0 +1,171 @@
+package empire
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/procfile"
+	"golang.org/x/net/context"
+)
+
+// SeccompUnconfined can be used as the seccomp profile of a process to disable
+// seccomp filtering.
+const SeccompUnconfined = "unconfined"
+
+// capabilityRegexp matches a Linux capability name, without the CAP_ prefix.
+var capabilityRegexp = regexp.MustCompile(`^[A-Z_]+$`)
+
+// SecurityPolicy restricts the security settings that processes can relax,
+// and who can relax them. Dropping capabilities and a read-only root
+// filesystem are always allowed, since they only restrict the container
+// further.
+//
+// The zero value doesn't allow privileged containers, added capabilities or
+// unconfined seccomp profiles.
+type SecurityPolicy struct {
+	// When true, processes can run privileged containers.
+	AllowPrivileged bool
+
+	// Capabilities that processes can add. "ALL" allows any capability.
+	AllowedCapabilities []string
+
+	// When true, processes can disable seccomp filtering.
+	AllowUnconfined bool
+
+	// The role that a user must have on an app to relax the security
+	// settings of its processes. The zero value requires the admin role.
+	Role string
+}
+
+// role returns the role required to relax security settings.
+func (p *SecurityPolicy) role() string {
+	if p.Role == "" {
+		return RoleAdmin
+	}
+	return p.Role
+}
+
+// allowsCapability returns true if processes can add the capability.
+func (p *SecurityPolicy) allowsCapability(capability string) bool {
+	for _, c := range p.AllowedCapabilities {
+		c = strings.TrimPrefix(strings.ToUpper(c), "CAP_")
+		if c == "ALL" || c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// denied returns the reasons why the security settings of the named process
+// aren't allowed by the policy.
+func (p *SecurityPolicy) denied(name string, s *procfile.Security) []string {
+	var reasons []string
+	if s == nil {
+		return reasons
+	}
+
+	if s.Privileged && !p.AllowPrivileged {
+		reasons = append(reasons, fmt.Sprintf("%s can't run privileged", name))
+	}
+
+	for _, c := range s.CapAdd {
+		if !p.allowsCapability(c) {
+			reasons = append(reasons, fmt.Sprintf("%s can't add the %s capability", name, c))
+		}
+	}
+
+	if s.SeccompProfile == SeccompUnconfined && !p.AllowUnconfined {
+		reasons = append(reasons, fmt.Sprintf("%s can't run with an unconfined seccomp profile", name))
+	}
+
+	return reasons
+}
+
+// relaxations returns the security settings that relax the default privileges
+// of a container. Custom seccomp profiles are included, since they can allow
+// more than the default profile.
+func relaxations(s *procfile.Security) map[string]bool {
+	r := make(map[string]bool)
+	if s == nil {
+		return r
+	}
+
+	if s.Privileged {
+		r["privileged"] = true
+	}
+
+	for _, c := range s.CapAdd {
+		r["cap_add:"+c] = true
+	}
+
+	if s.SeccompProfile != "" {
+		r["seccomp:"+s.SeccompProfile] = true
+	}
+
+	return r
+}
+
+// relaxes returns true if the security settings in s relax any settings that
+// weren't already relaxed by current.
+func relaxes(s, current *procfile.Security) bool {
+	existing := relaxations(current)
+	for r := range relaxations(s) {
+		if !existing[r] {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSecurity returns an AdmissionDeniedError if the security settings of
+// a process aren't allowed by the SecurityPolicy, and a ForbiddenError if the
+// user doesn't have the role required to relax them. Settings that were
+// already relaxed in the current release can be kept by anyone who can
+// release the app.
+func (e *Empire) checkSecurity(ctx context.Context, req *AdmissionRequest) error {
+	policy := &e.SecurityPolicy
+
+	var reasons []string
+	for _, name := range req.Release.Formation.Types() {
+		reasons = append(reasons, policy.denied(name, req.Release.Formation[name].Security)...)
+	}
+	if len(reasons) > 0 {
+		return &AdmissionDeniedError{Reasons: reasons}
+	}
+
+	current := make(Formation)
+	release, err := releasesFind(e.db, ReleasesQuery{App: req.App})
+	if err != nil && err != gorm.RecordNotFound {
+		return err
+	}
+	if err == nil {
+		current = release.Formation
+	}
+
+	for _, name := range req.Release.Formation.Types() {
+		if !relaxes(req.Release.Formation[name].Security, current[name].Security) {
+			continue
+		}
+
+		p, err := e.Permissions(req.User)
+		if err != nil {
+			return err
+		}
+
+		if !p.Allowed(req.App, policy.role()) {
+			err := &ForbiddenError{
+				Role:   policy.role(),
+				Action: fmt.Sprintf("relax the security settings of %s", name),
+				App:    req.App,
+			}
+			if req.User != nil {
+				err.User = req.User.Name
+			}
+			return err
+		}
+	}
+
+	return nil
+}
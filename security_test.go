@@ -0,0 +1,69 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/procfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityPolicy_Denied(t *testing.T) {
+	security := &procfile.Security{
+		Privileged:     true,
+		CapAdd:         []string{"NET_ADMIN", "SYS_PTRACE"},
+		CapDrop:        []string{"ALL"},
+		SeccompProfile: SeccompUnconfined,
+	}
+
+	tests := []struct {
+		policy  SecurityPolicy
+		reasons []string
+	}{
+		{
+			SecurityPolicy{},
+			[]string{
+				"vpn can't run privileged",
+				"vpn can't add the NET_ADMIN capability",
+				"vpn can't add the SYS_PTRACE capability",
+				"vpn can't run with an unconfined seccomp profile",
+			},
+		},
+		{
+			SecurityPolicy{AllowPrivileged: true, AllowedCapabilities: []string{"cap_net_admin"}},
+			[]string{
+				"vpn can't add the SYS_PTRACE capability",
+				"vpn can't run with an unconfined seccomp profile",
+			},
+		},
+		{
+			SecurityPolicy{AllowPrivileged: true, AllowedCapabilities: []string{"ALL"}, AllowUnconfined: true},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.reasons, tt.policy.denied("vpn", security))
+	}
+
+	assert.Nil(t, (&SecurityPolicy{}).denied("web", &procfile.Security{CapDrop: []string{"ALL"}, ReadOnlyRootFilesystem: true}))
+}
+
+func TestRelaxes(t *testing.T) {
+	tests := []struct {
+		security, current *procfile.Security
+		relaxes           bool
+	}{
+		{nil, nil, false},
+		{&procfile.Security{CapDrop: []string{"ALL"}, ReadOnlyRootFilesystem: true}, nil, false},
+		{&procfile.Security{Privileged: true}, nil, true},
+		{&procfile.Security{Privileged: true}, &procfile.Security{Privileged: true}, false},
+		{&procfile.Security{CapAdd: []string{"NET_ADMIN"}}, &procfile.Security{CapAdd: []string{"NET_ADMIN", "SYS_PTRACE"}}, false},
+		{&procfile.Security{CapAdd: []string{"NET_ADMIN", "SYS_PTRACE"}}, &procfile.Security{CapAdd: []string{"NET_ADMIN"}}, true},
+		{&procfile.Security{SeccompProfile: `{"defaultAction": "SCMP_ACT_ALLOW"}`}, nil, true},
+		{nil, &procfile.Security{Privileged: true}, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.relaxes, relaxes(tt.security, tt.current))
+	}
+}
@@ -0,0 +1,94 @@
+package empire
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+// RedactedValue replaces the values of sensitive config vars wherever config
+// vars are read, like the API and app exports.
+const RedactedValue = "[REDACTED]"
+
+// SensitiveVars are the names of the config vars of an app whose values are
+// secrets (e.g. passwords and API keys). Their values can be set, and are
+// provided to processes as usual, but they're redacted when config vars are
+// read, so that they can't leak into dashboards, or chat notifications.
+type SensitiveVars []string
+
+// Contains returns true if the config var is sensitive.
+func (s SensitiveVars) Contains(name string) bool {
+	for _, n := range s {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of the vars, with the values of the sensitive vars
+// replaced with RedactedValue.
+func (s SensitiveVars) Redact(vars Vars) Vars {
+	redacted := make(Vars, len(vars))
+	for k, v := range vars {
+		if v != nil && s.Contains(string(k)) {
+			r := RedactedValue
+			v = &r
+		}
+		redacted[k] = v
+	}
+	return redacted
+}
+
+// update returns the sensitive vars after the vars are set. When sensitive is
+// true, the vars that are set become sensitive. Vars are never made readable
+// again, even after they're unset, since earlier releases are redacted with
+// the current sensitive vars of the app.
+func (s SensitiveVars) update(vars Vars, sensitive bool) SensitiveVars {
+	names := make(map[string]bool)
+	for _, n := range s {
+		names[n] = true
+	}
+	for k, v := range vars {
+		if v != nil && sensitive {
+			names[string(k)] = true
+		}
+	}
+
+	var updated SensitiveVars
+	for n := range names {
+		updated = append(updated, n)
+	}
+	sort.Strings(updated)
+	return updated
+}
+
+// Scan implements the sql.Scanner interface.
+func (s *SensitiveVars) Scan(src interface{}) error {
+	if src == nil {
+		*s = nil
+		return nil
+	}
+
+	bytes, ok := src.([]byte)
+	if !ok {
+		return error(errors.New("Scan source was not []bytes"))
+	}
+
+	return json.Unmarshal(bytes, s)
+}
+
+// Value implements the driver.Value interface.
+func (s SensitiveVars) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.Value(raw), nil
+}
@@ -0,0 +1,47 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSensitiveVars_Redact(t *testing.T) {
+	password, env := "hunter2", "production"
+	sensitive := SensitiveVars{"DATABASE_PASSWORD"}
+
+	vars := Vars{"DATABASE_PASSWORD": &password, "RAILS_ENV": &env}
+	redacted := sensitive.Redact(vars)
+
+	assert.Equal(t, RedactedValue, *redacted["DATABASE_PASSWORD"])
+	assert.Equal(t, "production", *redacted["RAILS_ENV"])
+
+	// The vars aren't modified.
+	assert.Equal(t, "hunter2", *vars["DATABASE_PASSWORD"])
+}
+
+func TestSensitiveVars_update(t *testing.T) {
+	v := "value"
+
+	tests := []struct {
+		sensitive SensitiveVars
+		vars      Vars
+		marked    bool
+		expected  SensitiveVars
+	}{
+		{nil, Vars{"A": &v}, false, nil},
+		{nil, Vars{"B": &v, "A": &v}, true, SensitiveVars{"A", "B"}},
+
+		// Setting a sensitive var again keeps it sensitive.
+		{SensitiveVars{"A"}, Vars{"A": &v}, false, SensitiveVars{"A"}},
+
+		// Unsetting a var keeps it sensitive, so that earlier
+		// releases stay redacted.
+		{SensitiveVars{"A", "B"}, Vars{"A": nil}, false, SensitiveVars{"A", "B"}},
+		{nil, Vars{"A": nil}, true, nil},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.sensitive.update(tt.vars, tt.marked))
+	}
+}
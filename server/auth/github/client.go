@@ -98,6 +98,14 @@ type TeamMembership struct {
 	State string `json:"state"`
 }
 
+// Team represents a GitHub team.
+type Team struct {
+	Slug         string `json:"slug"`
+	Organization struct {
+		Login string `json:"login"`
+	} `json:"organization"`
+}
+
 // CreateAuthorization creates a new GitHub authorization (or returns the
 // existing authorization if present) for the GitHub OAuth application. See
 // http://goo.gl/bs9I3o.
@@ -188,6 +196,34 @@ func (c *Client) IsOrganizationMember(organization, token string) (bool, error)
 	return true, nil
 }
 
+// ListUserTeams returns the teams that the authenticated user is a member of,
+// across all organizations.
+func (c *Client) ListUserTeams(token string) ([]*Team, error) {
+	var teams []*Team
+
+	for page := 1; ; page++ {
+		req, err := c.NewRequest("GET", fmt.Sprintf("/user/teams?per_page=100&page=%d", page), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		tokenAuth(req, token)
+
+		var t []*Team
+		if _, err := c.Do(req, &t); err != nil {
+			return nil, err
+		}
+
+		teams = append(teams, t...)
+
+		if len(t) < 100 {
+			break
+		}
+	}
+
+	return teams, nil
+}
+
 // IsTeamMember returns true if the given user is a member of the team.
 func (c *Client) IsTeamMember(teamID, token string) (bool, error) {
 	u, err := c.GetUser(token)
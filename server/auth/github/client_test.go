@@ -214,6 +214,33 @@ func TestClient_GetUser_Error(t *testing.T) {
 	h.AssertExpectations(t)
 }
 
+func TestClient_ListUserTeams(t *testing.T) {
+	h := new(mockHTTPClient)
+	c := &Client{
+		Config:  oauthConfig,
+		client:  h,
+		backoff: noBackoff,
+	}
+
+	req, _ := http.NewRequest("GET", "https://api.github.com/user/teams?per_page=100&page=1", nil)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.SetBasicAuth("access_token", "x-oauth-basic")
+
+	h.On("Do", req).Return(&http.Response{
+		Request:    req,
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(`[{"slug":"platform","organization":{"login":"remind101"}}]`)),
+	}, nil).Once()
+
+	teams, err := c.ListUserTeams("access_token")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(teams))
+	assert.Equal(t, "platform", teams[0].Slug)
+	assert.Equal(t, "remind101", teams[0].Organization.Login)
+
+	h.AssertExpectations(t)
+}
+
 func TestClient_IsOrganizationMember(t *testing.T) {
 	tests := []struct {
 		status int
@@ -4,6 +4,7 @@ package github
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/remind101/empire"
 	"github.com/remind101/empire/server/auth"
@@ -13,10 +14,20 @@ import (
 // GitHub's Non-Web Application Flow, which can be found at
 // http://goo.gl/onpQKM.
 type Authenticator struct {
+	// When true, the user's GitHub teams will be mapped to Empire teams,
+	// so that team grants apply to members of the GitHub team.
+	SyncTeams bool
+
+	// If provided, only teams within this organization will be mapped, and
+	// teams are named by their slug (e.g. "platform"). Otherwise, teams are
+	// named by organization and slug (e.g. "remind101/platform").
+	Organization string
+
 	// OAuth2 configuration (client id, secret, scopes, etc).
 	client interface {
 		CreateAuthorization(CreateAuthorizationOptions) (*Authorization, error)
 		GetUser(token string) (*User, error)
+		ListUserTeams(token string) ([]*Team, error)
 	}
 }
 
@@ -69,9 +80,33 @@ func (a *Authenticator) Authenticate(username, password, otp string) (*auth.Sess
 		GitHubToken: authorization.Token,
 	}
 
+	if a.SyncTeams {
+		teams, err := a.client.ListUserTeams(authorization.Token)
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch GitHub team memberships: %v", err)
+		}
+		user.Teams = a.teamNames(teams)
+	}
+
 	return auth.NewSession(user), nil
 }
 
+// teamNames maps GitHub teams to Empire team names.
+func (a *Authenticator) teamNames(teams []*Team) []string {
+	var names []string
+	for _, t := range teams {
+		if a.Organization == "" {
+			names = append(names, fmt.Sprintf("%s/%s", t.Organization.Login, t.Slug))
+			continue
+		}
+
+		if strings.EqualFold(t.Organization.Login, a.Organization) {
+			names = append(names, t.Slug)
+		}
+	}
+	return names
+}
+
 // OrganizationAuthorizer is an implementation of the auth.Authorizer interface
 // that checks that the user is a member of the given GitHub organization.
 type OrganizationAuthorizer struct {
@@ -31,6 +31,30 @@ func TestAuthenticator(t *testing.T) {
 	assert.Equal(t, "access_token", session.User.GitHubToken)
 }
 
+func TestAuthenticator_SyncTeams(t *testing.T) {
+	c := new(mockClient)
+	a := &Authenticator{SyncTeams: true, client: c}
+
+	c.On("GetUser", "access_token").Return(&User{
+		Login: "ejholmes",
+	}, nil)
+
+	c.On("ListUserTeams", "access_token").Return([]*Team{
+		newTeam("remind101", "platform"),
+		newTeam("remind101", "data"),
+		newTeam("acme", "platform"),
+	}, nil)
+
+	session, err := a.Authenticate("$token$", "access_token", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"remind101/platform", "remind101/data", "acme/platform"}, session.User.Teams)
+
+	a.Organization = "remind101"
+	session, err = a.Authenticate("$token$", "access_token", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"platform", "data"}, session.User.Teams)
+}
+
 func TestAuthenticator_FailFast(t *testing.T) {
 	a := &Authenticator{}
 
@@ -132,6 +156,12 @@ func TestTeamAuthorizer_Unauthorized(t *testing.T) {
 	assert.EqualError(t, err, `ejholmes is not a member of team 123.`)
 }
 
+func newTeam(org, slug string) *Team {
+	t := &Team{Slug: slug}
+	t.Organization.Login = org
+	return t
+}
+
 type mockClient struct {
 	mock.Mock
 }
@@ -154,6 +184,15 @@ func (m *mockClient) GetUser(token string) (*User, error) {
 	return nil, args.Error(1)
 }
 
+func (m *mockClient) ListUserTeams(token string) ([]*Team, error) {
+	args := m.Called(token)
+	teams := args.Get(0)
+	if teams != nil {
+		return teams.([]*Team), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func (m *mockClient) IsOrganizationMember(organization, token string) (bool, error) {
 	args := m.Called(organization, token)
 	return args.Bool(0), args.Error(1)
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultGroupsAttribute is the default name of the SAML attribute, or OIDC
+// claim, that contains the groups that a user is a member of.
+const DefaultGroupsAttribute = "groups"
+
+// GroupMapper maps the groups that an identity provider (like Okta or Azure AD)
+// asserts a user is a member of, to Empire teams.
+type GroupMapper struct {
+	// The name of the SAML attribute, or OIDC claim, that contains the
+	// user's groups. The zero value is DefaultGroupsAttribute.
+	Attribute string
+
+	// If provided, maps group names to team names. Groups without a
+	// mapping are ignored. When empty, group names are used as team names.
+	Teams map[string]string
+}
+
+// AttributeName returns the name of the attribute that contains the groups.
+func (m *GroupMapper) AttributeName() string {
+	if m.Attribute == "" {
+		return DefaultGroupsAttribute
+	}
+	return m.Attribute
+}
+
+// Map returns the Empire teams for the given groups.
+func (m *GroupMapper) Map(groups []string) []string {
+	var teams []string
+	seen := make(map[string]bool)
+	for _, g := range groups {
+		team := g
+		if len(m.Teams) > 0 {
+			var ok bool
+			if team, ok = m.Teams[g]; !ok {
+				continue
+			}
+		}
+
+		if team == "" || seen[team] {
+			continue
+		}
+		seen[team] = true
+		teams = append(teams, team)
+	}
+	return teams
+}
+
+// ParseGroupMapping parses a list of `group=team` pairs into a map that can be
+// used as GroupMapper.Teams.
+func ParseGroupMapping(pairs []string) (map[string]string, error) {
+	teams := make(map[string]string)
+	for _, pair := range pairs {
+		if pair == "" {
+			continue
+		}
+
+		i := strings.LastIndex(pair, "=")
+		if i <= 0 || i == len(pair)-1 {
+			return nil, fmt.Errorf("invalid group mapping %q, expected group=team", pair)
+		}
+		teams[pair[:i]] = pair[i+1:]
+	}
+	return teams, nil
+}
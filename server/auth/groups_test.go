@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupMapper_Map(t *testing.T) {
+	tests := []struct {
+		mapper *GroupMapper
+		groups []string
+		teams  []string
+	}{
+		{&GroupMapper{}, nil, nil},
+		{&GroupMapper{}, []string{"platform", "mobile", "platform"}, []string{"platform", "mobile"}},
+		{&GroupMapper{Teams: map[string]string{"Empire Admins": "platform"}}, []string{"Everyone", "Empire Admins"}, []string{"platform"}},
+		{&GroupMapper{Teams: map[string]string{"a": "platform", "b": "platform"}}, []string{"a", "b"}, []string{"platform"}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.teams, tt.mapper.Map(tt.groups))
+	}
+}
+
+func TestParseGroupMapping(t *testing.T) {
+	teams, err := ParseGroupMapping([]string{"Empire Admins=platform", "", "cn=mobile,ou=groups=mobile"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"Empire Admins":       "platform",
+		"cn=mobile,ou=groups": "mobile",
+	}, teams)
+
+	_, err = ParseGroupMapping([]string{"platform"})
+	assert.EqualError(t, err, `invalid group mapping "platform", expected group=team`)
+
+	_, err = ParseGroupMapping([]string{"platform="})
+	assert.Error(t, err)
+}
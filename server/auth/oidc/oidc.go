@@ -0,0 +1,385 @@
+// Package oidc provides an OpenID Connect relying party, which allows users to
+// login to Empire through an identity provider like Okta or Azure AD.
+package oidc
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/server/auth"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// DefaultUsernameClaim is the default claim in the ID token that is used as
+// the Empire username.
+const DefaultUsernameClaim = "email"
+
+// KeysRefreshInterval is the minimum amount of time between fetches of the
+// provider's key set. ID tokens signed with an unknown key are rejected
+// without refetching the key set until this interval has passed, so that
+// forged tokens can't be used to flood the identity provider.
+const KeysRefreshInterval = time.Minute
+
+var (
+	// ErrNoIDToken is returned when the token response from the identity
+	// provider does not include an id_token.
+	ErrNoIDToken = errors.New("oidc: token response did not include an id_token")
+
+	// ErrNoUsername is returned when the ID token does not include the
+	// username claim.
+	ErrNoUsername = errors.New("oidc: id_token is missing the username claim")
+
+	// ErrEmailNotVerified is returned when the email claim is used as the
+	// username, but the identity provider has not verified it.
+	ErrEmailNotVerified = errors.New("oidc: email in id_token is not verified")
+
+	// ErrReservedUsername is returned when the username claim collides with
+	// the names given to API tokens.
+	ErrReservedUsername = fmt.Errorf("oidc: usernames starting with %q are reserved for API tokens", empire.APITokenUserPrefix)
+)
+
+// Provider is an OpenID Connect identity provider.
+type Provider struct {
+	// The issuer url of the identity provider (e.g.
+	// https://example.okta.com). The provider configuration is discovered
+	// from /.well-known/openid-configuration relative to this url.
+	Issuer string
+
+	// The client id and secret of the application registered with the
+	// identity provider.
+	ClientID     string
+	ClientSecret string
+
+	// The url that the identity provider redirects back to after login.
+	RedirectURL string
+
+	// Additional scopes to request, in addition to "openid".
+	Scopes []string
+
+	// The claim that is used as the Empire username. The zero value is
+	// DefaultUsernameClaim.
+	UsernameClaim string
+
+	// If provided, the groups claim in the ID token is mapped to the user's
+	// teams.
+	Groups *auth.GroupMapper
+
+	client *http.Client
+
+	mu     sync.Mutex
+	config *configuration
+	keys   map[string]*rsa.PublicKey
+
+	// The last time that the key set was fetched.
+	keysFetchedAt time.Time
+}
+
+// NewProvider returns a new Provider for the given issuer.
+func NewProvider(issuer, clientID, clientSecret, redirectURL string) *Provider {
+	return &Provider{
+		Issuer:       strings.TrimSuffix(issuer, "/"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"email", "profile"},
+		client:       http.DefaultClient,
+	}
+}
+
+// AuthCodeURL returns the url to redirect the user to in order to login with
+// the identity provider.
+func (p *Provider) AuthCodeURL(state string) (string, error) {
+	c, err := p.oauth2Config()
+	if err != nil {
+		return "", err
+	}
+	return c.AuthCodeURL(state), nil
+}
+
+// Exchange exchanges the authorization code for an ID token, verifies it, and
+// returns an auth.Session for the user.
+func (p *Provider) Exchange(ctx context.Context, code string) (*auth.Session, error) {
+	c, err := p.oauth2Config()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := c.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: error exchanging code: %v", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, ErrNoIDToken
+	}
+
+	return p.Session(rawIDToken)
+}
+
+// Session verifies the raw ID token and returns an auth.Session for the user.
+func (p *Provider) Session(rawIDToken string) (*auth.Session, error) {
+	claims, err := p.Verify(rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	usernameClaim := p.UsernameClaim
+	if usernameClaim == "" {
+		usernameClaim = DefaultUsernameClaim
+	}
+
+	login, ok := claims[usernameClaim].(string)
+	if !ok || login == "" {
+		return nil, ErrNoUsername
+	}
+
+	if usernameClaim == "email" {
+		if verified, _ := claims["email_verified"].(bool); !verified {
+			return nil, ErrEmailNotVerified
+		}
+	}
+
+	if strings.HasPrefix(login, empire.APITokenUserPrefix) {
+		return nil, ErrReservedUsername
+	}
+
+	user := &empire.User{
+		Name: login,
+	}
+
+	if p.Groups != nil {
+		user.Teams = p.Groups.Map(stringsClaim(claims[p.Groups.AttributeName()]))
+	}
+
+	session := auth.NewSession(user)
+	if exp, ok := claims["exp"].(float64); ok {
+		t := time.Unix(int64(exp), 0)
+		session.ExpiresAt = &t
+	}
+
+	return session, nil
+}
+
+// Verify verifies the signature, issuer, audience and expiration of the ID
+// token, and returns its claims.
+func (p *Provider) Verify(rawIDToken string) (map[string]interface{}, error) {
+	token, err := jwt.Parse(rawIDToken, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+
+		kid, _ := t.Header["kid"].(string)
+		return p.key(kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("oidc: invalid id_token: %v", err)
+	}
+
+	c, err := p.configuration()
+	if err != nil {
+		return nil, err
+	}
+
+	if iss, _ := token.Claims["iss"].(string); iss != c.Issuer {
+		return nil, fmt.Errorf("oidc: id_token issued by %q, expected %q", iss, c.Issuer)
+	}
+
+	if !contains(stringsClaim(token.Claims["aud"]), p.ClientID) {
+		return nil, errors.New("oidc: id_token was not issued for this client")
+	}
+
+	if _, ok := token.Claims["exp"].(float64); !ok {
+		return nil, errors.New("oidc: id_token does not expire")
+	}
+
+	return token.Claims, nil
+}
+
+// configuration represents the OpenID Provider Metadata.
+type configuration struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// configuration returns the provider configuration, discovering it on first
+// use.
+func (p *Provider) configuration() (*configuration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.config != nil {
+		return p.config, nil
+	}
+
+	var c configuration
+	if err := p.getJSON(p.Issuer+"/.well-known/openid-configuration", &c); err != nil {
+		return nil, err
+	}
+
+	if c.Issuer != p.Issuer {
+		return nil, fmt.Errorf("oidc: issuer %q did not match the discovered issuer %q", p.Issuer, c.Issuer)
+	}
+
+	p.config = &c
+	return p.config, nil
+}
+
+func (p *Provider) oauth2Config() (*oauth2.Config, error) {
+	c, err := p.configuration()
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		RedirectURL:  p.RedirectURL,
+		Scopes:       append([]string{"openid"}, p.Scopes...),
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  c.AuthorizationEndpoint,
+			TokenURL: c.TokenEndpoint,
+		},
+	}, nil
+}
+
+// key returns the public key with the given id. The key set is refetched when
+// an unknown key is requested, so that key rotation is picked up, but at most
+// once every KeysRefreshInterval.
+func (p *Provider) key(kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	if !ok {
+		if time.Since(p.keysFetchedAt) < KeysRefreshInterval {
+			p.mu.Unlock()
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		p.keysFetchedAt = time.Now()
+	}
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	c, err := p.configuration()
+	if err != nil {
+		return nil, err
+	}
+
+	var set jwks
+	if err := p.getJSON(c.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+
+		pub, err := k.publicKey()
+		if err != nil {
+			return nil, err
+		}
+		keys[k.Kid] = pub
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (p *Provider) getJSON(url string, v interface{}) error {
+	resp, err := p.client.Get(url)
+	if err != nil {
+		return fmt.Errorf("oidc: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("oidc: unexpected response status %d from %s", resp.StatusCode, url)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("oidc: error decoding response from %s: %v", url, err)
+	}
+
+	return nil
+}
+
+// jwks represents a JSON Web Key Set.
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// jwk represents a JSON Web Key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (k *jwk) publicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: invalid modulus for key %q: %v", k.Kid, err)
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: invalid exponent for key %q: %v", k.Kid, err)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+// stringsClaim returns the claim as a slice of strings. Claims like "aud" and
+// "groups" can either be a single string, or an array of strings.
+func stringsClaim(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var s []string
+		for _, e := range v {
+			if e, ok := e.(string); ok {
+				s = append(s, e)
+			}
+		}
+		return s
+	default:
+		return nil
+	}
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/remind101/empire/server/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_Session(t *testing.T) {
+	key, s := newIssuer(t)
+	defer s.Close()
+
+	p := NewProvider(s.URL, "empire", "secret", "http://localhost/oidc/callback")
+	p.Groups = &auth.GroupMapper{
+		Teams: map[string]string{"Empire Admins": "platform"},
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+	token := signToken(t, key, map[string]interface{}{
+		"iss":            s.URL,
+		"aud":            "empire",
+		"exp":            exp,
+		"email":          "ejholmes@example.com",
+		"email_verified": true,
+		"groups":         []string{"Everyone", "Empire Admins"},
+	})
+
+	session, err := p.Session(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "ejholmes@example.com", session.User.Name)
+	assert.Equal(t, []string{"platform"}, session.User.Teams)
+	assert.Equal(t, exp, session.ExpiresAt.Unix())
+}
+
+func TestProvider_Session_Invalid(t *testing.T) {
+	key, s := newIssuer(t)
+	defer s.Close()
+
+	p := NewProvider(s.URL, "empire", "secret", "http://localhost/oidc/callback")
+
+	exp := time.Now().Add(time.Hour).Unix()
+	tests := []struct {
+		claims map[string]interface{}
+		err    string
+	}{
+		{map[string]interface{}{"iss": "https://evil.example.com", "aud": "empire", "exp": exp, "email": "a"}, `oidc: id_token issued by "https://evil.example.com", expected "` + s.URL + `"`},
+		{map[string]interface{}{"iss": s.URL, "aud": []string{"other"}, "exp": exp, "email": "a"}, "oidc: id_token was not issued for this client"},
+		{map[string]interface{}{"iss": s.URL, "aud": "empire", "email": "a"}, "oidc: id_token does not expire"},
+		{map[string]interface{}{"iss": s.URL, "aud": "empire", "exp": exp}, ErrNoUsername.Error()},
+		{map[string]interface{}{"iss": s.URL, "aud": "empire", "exp": exp, "email": "a"}, ErrEmailNotVerified.Error()},
+		{map[string]interface{}{"iss": s.URL, "aud": "empire", "exp": exp, "email": "a", "email_verified": false}, ErrEmailNotVerified.Error()},
+		{map[string]interface{}{"iss": s.URL, "aud": "empire", "exp": exp, "email": "a", "email_verified": "true"}, ErrEmailNotVerified.Error()},
+		{map[string]interface{}{"iss": s.URL, "aud": "empire", "exp": exp, "email": "token:ci", "email_verified": true}, ErrReservedUsername.Error()},
+	}
+
+	for _, tt := range tests {
+		_, err := p.Session(signToken(t, key, tt.claims))
+		assert.EqualError(t, err, tt.err)
+	}
+
+	// Tokens signed by another key are rejected.
+	other, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+	_, err = p.Session(signToken(t, other, map[string]interface{}{"iss": s.URL, "aud": "empire", "exp": exp, "email": "a"}))
+	assert.Error(t, err)
+}
+
+func TestProvider_Session_UsernameClaim(t *testing.T) {
+	key, s := newIssuer(t)
+	defer s.Close()
+
+	p := NewProvider(s.URL, "empire", "secret", "http://localhost/oidc/callback")
+	p.UsernameClaim = "preferred_username"
+
+	exp := time.Now().Add(time.Hour).Unix()
+
+	// email_verified only matters when the email is the username.
+	session, err := p.Session(signToken(t, key, map[string]interface{}{"iss": s.URL, "aud": "empire", "exp": exp, "preferred_username": "ejholmes"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "ejholmes", session.User.Name)
+
+	_, err = p.Session(signToken(t, key, map[string]interface{}{"iss": s.URL, "aud": "empire", "exp": exp, "preferred_username": "token:ci"}))
+	assert.Equal(t, ErrReservedUsername, err)
+}
+
+func TestProvider_Session_UnknownKey(t *testing.T) {
+	key, s := newIssuer(t)
+	defer s.Close()
+
+	var fetches int
+	p := NewProvider(s.URL, "empire", "secret", "http://localhost/oidc/callback")
+	p.client = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path == "/keys" {
+			fetches++
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	claims := map[string]interface{}{"iss": s.URL, "aud": "empire", "exp": time.Now().Add(time.Hour).Unix(), "email": "a", "email_verified": true}
+
+	_, err := p.Session(signToken(t, key, claims))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fetches)
+
+	// An unknown key doesn't refetch the key set within the refresh
+	// interval.
+	_, err = p.Session(signTokenWithKid(t, key, "2", claims))
+	assert.Error(t, err)
+	_, err = p.Session(signTokenWithKid(t, key, "2", claims))
+	assert.Error(t, err)
+	assert.Equal(t, 1, fetches)
+
+	// Once the interval has passed, the key set is refetched.
+	p.keysFetchedAt = time.Now().Add(-KeysRefreshInterval)
+	_, err = p.Session(signTokenWithKid(t, key, "2", claims))
+	assert.Error(t, err)
+	assert.Equal(t, 2, fetches)
+
+	// Known keys are still accepted.
+	_, err = p.Session(signToken(t, key, claims))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, fetches)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// newIssuer starts a fake OpenID Connect provider that signs tokens with the
+// returned key.
+func newIssuer(t testing.TB) (*rsa.PrivateKey, *httptest.Server) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var s *httptest.Server
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(configuration{
+				Issuer:                s.URL,
+				AuthorizationEndpoint: s.URL + "/authorize",
+				TokenEndpoint:         s.URL + "/token",
+				JWKSURI:               s.URL + "/keys",
+			})
+		case "/keys":
+			json.NewEncoder(w).Encode(jwks{Keys: []jwk{{
+				Kty: "RSA",
+				Kid: "1",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	return key, s
+}
+
+func signToken(t testing.TB, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	return signTokenWithKid(t, key, "1", claims)
+}
+
+func signTokenWithKid(t testing.TB, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	token := jwt.New(jwt.SigningMethodRS256)
+	token.Header["kid"] = kid
+	token.Claims = claims
+
+	raw := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	s, err := token.SignedString(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
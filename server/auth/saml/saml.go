@@ -7,14 +7,39 @@ import (
 )
 
 // SessionFromAssertion returns a new auth.Session generated from the SAML
-// assertion.
-func SessionFromAssertion(assertion *saml.Assertion) *auth.Session {
+// assertion. If groups is provided, the groups in the assertion are mapped to
+// the user's teams.
+func SessionFromAssertion(assertion *saml.Assertion, groups *auth.GroupMapper) *auth.Session {
 	login := assertion.Subject.NameID.Value
 	user := &empire.User{
 		Name: login,
 	}
 
+	if groups != nil {
+		user.Teams = groups.Map(attributeValues(assertion, groups.AttributeName()))
+	}
+
 	session := auth.NewSession(user)
 	session.ExpiresAt = &assertion.AuthnStatement.SessionNotOnOrAfter
 	return session
 }
+
+// attributeValues returns the values of the attribute with the given name (or
+// friendly name).
+func attributeValues(assertion *saml.Assertion, name string) []string {
+	if assertion.AttributeStatement == nil {
+		return nil
+	}
+
+	var values []string
+	for _, attr := range assertion.AttributeStatement.Attributes {
+		if attr.Name != name && attr.FriendlyName != name {
+			continue
+		}
+
+		for _, v := range attr.Values {
+			values = append(values, v.Value)
+		}
+	}
+	return values
+}
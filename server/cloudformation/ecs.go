@@ -61,6 +61,28 @@ type ECSServiceProperties struct {
 	PlacementConstraints []ECSPlacementConstraint
 	PlacementStrategy    []ECSPlacementStrategy
 	PropagateTags        *string
+
+	// The deployment configuration can be updated in place, so it doesn't
+	// require the service to be replaced.
+	DeploymentConfiguration *ECSDeploymentConfiguration `hash:"ignore"`
+}
+
+type ECSDeploymentConfiguration struct {
+	MinimumHealthyPercent *customresources.IntValue
+	MaximumPercent        *customresources.IntValue
+}
+
+// deploymentConfiguration returns the ecs.DeploymentConfiguration for the
+// service, or nil if the default should be used.
+func (p *ECSServiceProperties) deploymentConfiguration() *ecs.DeploymentConfiguration {
+	if p.DeploymentConfiguration == nil {
+		return nil
+	}
+
+	return &ecs.DeploymentConfiguration{
+		MinimumHealthyPercent: p.DeploymentConfiguration.MinimumHealthyPercent.Value(),
+		MaximumPercent:        p.DeploymentConfiguration.MaximumPercent.Value(),
+	}
 }
 
 func (p *ECSServiceProperties) ReplacementHash() (uint64, error) {
@@ -140,6 +162,8 @@ func (p *ECSServiceResource) Create(ctx context.Context, req customresources.Req
 		PlacementConstraints: placementConstraints,
 		PlacementStrategy:    placementStrategy,
 		PropagateTags:        properties.PropagateTags,
+
+		DeploymentConfiguration: properties.deploymentConfiguration(),
 	})
 	if err != nil {
 		return "", nil, fmt.Errorf("error creating service: %v", err)
@@ -188,10 +212,11 @@ func (p *ECSServiceResource) Update(ctx context.Context, req customresources.Req
 	}
 
 	resp, err := p.ecs.UpdateService(&ecs.UpdateServiceInput{
-		Service:        aws.String(req.PhysicalResourceId),
-		Cluster:        properties.Cluster,
-		DesiredCount:   desiredCount,
-		TaskDefinition: properties.TaskDefinition,
+		Service:                 aws.String(req.PhysicalResourceId),
+		Cluster:                 properties.Cluster,
+		DesiredCount:            desiredCount,
+		TaskDefinition:          properties.TaskDefinition,
+		DeploymentConfiguration: properties.deploymentConfiguration(),
 	})
 	if err != nil {
 		return nil, err
@@ -265,6 +290,53 @@ type ContainerDefinition struct {
 	Ulimits          []Ulimit
 	Environment      []string
 	LogConfiguration *ecs.LogConfiguration
+	Links            []*string
+	MountPoints      []MountPoint
+
+	Privileged             *string
+	ReadonlyRootFilesystem *string
+	DockerSecurityOptions  []*string
+}
+
+// HashInclude implements the hashstructure.Includable interface. Links,
+// MountPoints and security settings are only hashed when provided, so that
+// existing task definitions aren't replaced.
+func (c ContainerDefinition) HashInclude(field string, v interface{}) (bool, error) {
+	switch field {
+	case "Links":
+		return len(c.Links) > 0, nil
+	case "MountPoints":
+		return len(c.MountPoints) > 0, nil
+	case "Privileged":
+		return c.Privileged != nil, nil
+	case "ReadonlyRootFilesystem":
+		return c.ReadonlyRootFilesystem != nil, nil
+	case "DockerSecurityOptions":
+		return len(c.DockerSecurityOptions) > 0, nil
+	}
+	return true, nil
+}
+
+// boolValue converts a boolean, which CloudFormation passes to custom resources
+// as a string, to a *bool. It returns nil if the value wasn't provided.
+func boolValue(v *string) *bool {
+	if v == nil {
+		return nil
+	}
+	return aws.Bool(*v == "true")
+}
+
+type MountPoint struct {
+	SourceVolume  *string
+	ContainerPath *string
+	ReadOnly      *string
+}
+
+type Volume struct {
+	Name *string
+	Host *struct {
+		SourcePath *string
+	}
 }
 
 // TaskDefinitionProperties are properties passed to the
@@ -275,12 +347,23 @@ type ECSTaskDefinitionProperties struct {
 	ContainerDefinitions []ContainerDefinition
 	PlacementConstraints []ECSPlacementConstraint
 	Tags                 []*ecs.Tag
+	Volumes              []Volume
 }
 
 func (p *ECSTaskDefinitionProperties) ReplacementHash() (uint64, error) {
 	return hashstructure.Hash(p, nil)
 }
 
+// HashInclude implements the hashstructure.Includable interface. Volumes are
+// only hashed when provided, so that existing task definitions aren't
+// replaced.
+func (p ECSTaskDefinitionProperties) HashInclude(field string, v interface{}) (bool, error) {
+	if field == "Volumes" {
+		return len(p.Volumes) > 0, nil
+	}
+	return true, nil
+}
+
 // ECSTaskDefinitionResource is a custom resource that provisions ECS task
 // definitions.
 type ECSTaskDefinitionResource struct {
@@ -326,6 +409,7 @@ func (p *ECSTaskDefinitionResource) register(properties *ECSTaskDefinitionProper
 		var (
 			ulimits      []*ecs.Ulimit
 			portMappings []*ecs.PortMapping
+			mountPoints  []*ecs.MountPoint
 			essential    *bool
 		)
 
@@ -349,6 +433,14 @@ func (p *ECSTaskDefinitionResource) register(properties *ECSTaskDefinitionProper
 			})
 		}
 
+		for _, m := range c.MountPoints {
+			mountPoints = append(mountPoints, &ecs.MountPoint{
+				SourceVolume:  m.SourceVolume,
+				ContainerPath: m.ContainerPath,
+				ReadOnly:      aws.Bool(aws.StringValue(m.ReadOnly) == "true"),
+			})
+		}
+
 		if c.Essential != nil {
 			essential = aws.Bool(*c.Essential == "true")
 		}
@@ -365,6 +457,12 @@ func (p *ECSTaskDefinitionResource) register(properties *ECSTaskDefinitionProper
 			Ulimits:          ulimits,
 			LogConfiguration: c.LogConfiguration,
 			Environment:      env,
+			Links:            c.Links,
+			MountPoints:      mountPoints,
+
+			Privileged:             boolValue(c.Privileged),
+			ReadonlyRootFilesystem: boolValue(c.ReadonlyRootFilesystem),
+			DockerSecurityOptions:  c.DockerSecurityOptions,
 		})
 	}
 
@@ -381,12 +479,22 @@ func (p *ECSTaskDefinitionResource) register(properties *ECSTaskDefinitionProper
 		})
 	}
 
+	var volumes []*ecs.Volume
+	for _, v := range properties.Volumes {
+		volume := &ecs.Volume{Name: v.Name}
+		if v.Host != nil {
+			volume.Host = &ecs.HostVolumeProperties{SourcePath: v.Host.SourcePath}
+		}
+		volumes = append(volumes, volume)
+	}
+
 	resp, err := p.ecs.RegisterTaskDefinition(&ecs.RegisterTaskDefinitionInput{
 		Family:               family,
 		TaskRoleArn:          properties.TaskRoleArn,
 		ContainerDefinitions: containerDefinitions,
 		PlacementConstraints: placementConstraints,
 		Tags:                 properties.Tags,
+		Volumes:              volumes,
 	})
 	if err != nil {
 		return "", fmt.Errorf("error creating task definition: %v", err)
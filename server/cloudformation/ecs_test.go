@@ -184,6 +184,60 @@ func TestECSServiceResource_Update(t *testing.T) {
 	e.AssertExpectations(t)
 }
 
+func TestECSServiceResource_Update_DeploymentConfiguration(t *testing.T) {
+	e := new(mockECS)
+	p := newECSServiceProvisioner(&ECSServiceResource{
+		ecs: e,
+	})
+
+	e.On("UpdateService", &ecs.UpdateServiceInput{
+		Service:        aws.String("arn:aws:ecs:us-east-1:012345678901:service/acme-inc-db"),
+		Cluster:        aws.String("cluster"),
+		TaskDefinition: aws.String("arn:aws:ecs:us-east-1:012345678910:task-definition/acme-inc:2"),
+		DeploymentConfiguration: &ecs.DeploymentConfiguration{
+			MinimumHealthyPercent: aws.Int64(0),
+			MaximumPercent:        aws.Int64(100),
+		},
+	}).Return(
+		&ecs.UpdateServiceOutput{
+			Service: &ecs.Service{
+				ServiceName: aws.String("acme-inc-db"),
+				Deployments: []*ecs.Deployment{
+					&ecs.Deployment{Id: aws.String("New"), Status: aws.String("PRIMARY")},
+				},
+			},
+		},
+		nil,
+	)
+
+	id, _, err := p.Provision(ctx, customresources.Request{
+		StackId:            "arn:aws:cloudformation:us-east-1:012345678901:stack/acme-inc/bc66fd60-32be-11e6-902b-50d501eb4c17",
+		RequestId:          "411f3f38-565f-4216-a711-aeafd5ba635e",
+		RequestType:        customresources.Update,
+		PhysicalResourceId: "arn:aws:ecs:us-east-1:012345678901:service/acme-inc-db",
+		ResourceProperties: &ECSServiceProperties{
+			Cluster:        aws.String("cluster"),
+			ServiceName:    aws.String("acme-inc-db"),
+			DesiredCount:   customresources.Int(1),
+			TaskDefinition: aws.String("arn:aws:ecs:us-east-1:012345678910:task-definition/acme-inc:2"),
+			DeploymentConfiguration: &ECSDeploymentConfiguration{
+				MinimumHealthyPercent: customresources.Int(0),
+				MaximumPercent:        customresources.Int(100),
+			},
+		},
+		OldResourceProperties: &ECSServiceProperties{
+			Cluster:        aws.String("cluster"),
+			ServiceName:    aws.String("acme-inc-db"),
+			DesiredCount:   customresources.Int(1),
+			TaskDefinition: aws.String("arn:aws:ecs:us-east-1:012345678910:task-definition/acme-inc:1"),
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws:ecs:us-east-1:012345678901:service/acme-inc-db", id)
+
+	e.AssertExpectations(t)
+}
+
 func TestECSServiceResource_Update_SameDesiredCount(t *testing.T) {
 	e := new(mockECS)
 	p := newECSServiceProvisioner(&ECSServiceResource{
@@ -3,12 +3,15 @@ package github
 import (
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/ejholmes/hookshot/events"
+	githubapi "github.com/google/go-github/github"
 	"github.com/remind101/empire"
 	"github.com/remind101/empire/pkg/dockerutil"
 	streamhttp "github.com/remind101/empire/pkg/stream/http"
+	"github.com/remind101/pkg/httpx"
 	"github.com/remind101/tugboat"
 	"golang.org/x/net/context"
 )
@@ -68,6 +71,17 @@ func (d *EmpireDeployer) Deploy(ctx context.Context, event events.Deployment, w
 		User:    &empire.User{Name: event.Deployment.Creator.Login},
 		Stream:  true,
 		Message: message,
+		Provenance: empire.Provenance{
+			Repo:    "github.com/" + event.Repository.FullName,
+			Commit:  event.Deployment.Sha,
+			Builder: "github",
+		},
+
+		// GitHub redelivers webhooks that it thinks failed, so each
+		// GitHub deployment is only released once.
+		IdempotencyKey: fmt.Sprintf("github-deployment-%d", event.Deployment.ID),
+
+		RequestID: httpx.RequestID(ctx),
 	})
 	if err != nil {
 		return err
@@ -113,6 +127,75 @@ func (d *TugboatDeployer) Deploy(ctx context.Context, event events.Deployment, o
 	return err
 }
 
+// deploymentStatusesClient mocks the interface to the GitHub API that's used
+// to create deployment statuses.
+type deploymentStatusesClient interface {
+	CreateDeploymentStatus(owner, repo string, deployment int, request *githubapi.DeploymentStatusRequest) (*githubapi.DeploymentStatus, *githubapi.Response, error)
+}
+
+// maxStatusDescription is the longest description that GitHub accepts for a
+// deployment status.
+const maxStatusDescription = 140
+
+// StatusDeployer is an implementation of the Deployer interface that reports
+// the progress of the deployment back to GitHub, by creating deployment
+// statuses. Tugboat also creates deployment statuses, so this isn't needed
+// when Tugboat is used.
+type StatusDeployer struct {
+	deployer Deployer
+	client   deploymentStatusesClient
+}
+
+// NotifyGitHub wraps a Deployer to create a pending deployment status when the
+// deployment starts, and a success or failure status when it finishes.
+func NotifyGitHub(d Deployer, c *githubapi.Client) *StatusDeployer {
+	return &StatusDeployer{
+		deployer: d,
+		client:   c.Repositories,
+	}
+}
+
+func (d *StatusDeployer) Deploy(ctx context.Context, event events.Deployment, w io.Writer) error {
+	if err := d.createStatus(event, "pending", "Deploying"); err != nil {
+		return err
+	}
+
+	err := d.deployer.Deploy(ctx, event, w)
+	if _, ok := err.(*empire.IdempotencyKeyError); ok {
+		// An earlier delivery of the event is still deploying, and
+		// will report the status.
+		return err
+	}
+
+	state, description := "success", "Deployed"
+	if err != nil {
+		state, description = "failure", err.Error()
+	}
+
+	if serr := d.createStatus(event, state, description); serr != nil && err == nil {
+		err = serr
+	}
+
+	return err
+}
+
+func (d *StatusDeployer) createStatus(event events.Deployment, state, description string) error {
+	parts := strings.SplitN(event.Repository.FullName, "/", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid repository name: %q", event.Repository.FullName)
+	}
+
+	if len(description) > maxStatusDescription {
+		description = description[:maxStatusDescription-3] + "..."
+	}
+
+	_, _, err := d.client.CreateDeploymentStatus(parts[0], parts[1], int(event.Deployment.ID), &githubapi.DeploymentStatusRequest{
+		State:       &state,
+		Description: &description,
+	})
+	return err
+}
+
 // provider implements the tugboat.Provider interface.
 type provider func(context.Context, *tugboat.Deployment, io.Writer) error
 
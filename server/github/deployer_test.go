@@ -2,11 +2,16 @@ package github
 
 import (
 	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
 	"testing"
 
 	"golang.org/x/net/context"
 
 	"github.com/ejholmes/hookshot/events"
+	githubapi "github.com/google/go-github/github"
 	"github.com/remind101/empire"
 	"github.com/remind101/empire/pkg/dockerutil"
 	"github.com/remind101/empire/pkg/image"
@@ -37,6 +42,12 @@ func TestEmpireDeployer_Deploy(t *testing.T) {
 		},
 		Stream:  true,
 		Message: "GitHub deployment #53252 of remind101/acme-inc",
+		Provenance: empire.Provenance{
+			Repo:    "github.com/remind101/acme-inc",
+			Commit:  "abcd123",
+			Builder: "github",
+		},
+		IdempotencyKey: "github-deployment-53252",
 	}).Return(nil)
 
 	err := d.Deploy(context.Background(), event, b)
@@ -50,6 +61,81 @@ Status: Image is up to date for remind101/acme-inc:latest
 `, b.String())
 }
 
+func TestStatusDeployer_Deploy(t *testing.T) {
+	tests := []struct {
+		err         error
+		state       string
+		description string
+	}{
+		{nil, "success", "Deployed"},
+		{errors.New("image not found"), "failure", "image not found"},
+		{errors.New(strings.Repeat("a", 200)), "failure", strings.Repeat("a", 137) + "..."},
+	}
+
+	for _, tt := range tests {
+		c := new(mockDeploymentStatusesClient)
+		d := &StatusDeployer{
+			deployer: DeployerFunc(func(ctx context.Context, event events.Deployment, w io.Writer) error {
+				return tt.err
+			}),
+			client: c,
+		}
+
+		var event events.Deployment
+		event.Repository.FullName = "remind101/acme-inc"
+		event.Deployment.ID = 53252
+
+		c.On("CreateDeploymentStatus", "remind101", "acme-inc", 53252, &githubapi.DeploymentStatusRequest{
+			State:       githubapi.String("pending"),
+			Description: githubapi.String("Deploying"),
+		}).Return(nil).Once()
+		c.On("CreateDeploymentStatus", "remind101", "acme-inc", 53252, &githubapi.DeploymentStatusRequest{
+			State:       githubapi.String(tt.state),
+			Description: githubapi.String(tt.description),
+		}).Return(nil).Once()
+
+		err := d.Deploy(context.Background(), event, ioutil.Discard)
+		assert.Equal(t, tt.err, err)
+
+		c.AssertExpectations(t)
+	}
+}
+
+func TestStatusDeployer_Deploy_InProgress(t *testing.T) {
+	c := new(mockDeploymentStatusesClient)
+	d := &StatusDeployer{
+		deployer: DeployerFunc(func(ctx context.Context, event events.Deployment, w io.Writer) error {
+			return &empire.IdempotencyKeyError{Key: "github-deployment-53252", Reason: "is being used by a request that's still in progress"}
+		}),
+		client: c,
+	}
+
+	var event events.Deployment
+	event.Repository.FullName = "remind101/acme-inc"
+	event.Deployment.ID = 53252
+
+	// Only the pending status is created, since the delivery that's in
+	// progress reports the result.
+	c.On("CreateDeploymentStatus", "remind101", "acme-inc", 53252, &githubapi.DeploymentStatusRequest{
+		State:       githubapi.String("pending"),
+		Description: githubapi.String("Deploying"),
+	}).Return(nil).Once()
+
+	err := d.Deploy(context.Background(), event, ioutil.Discard)
+	assert.IsType(t, &empire.IdempotencyKeyError{}, err)
+
+	c.AssertExpectations(t)
+}
+
+type mockDeploymentStatusesClient struct {
+	mock.Mock
+}
+
+func (m *mockDeploymentStatusesClient) CreateDeploymentStatus(owner, repo string, deployment int, request *githubapi.DeploymentStatusRequest) (*githubapi.DeploymentStatus, *githubapi.Response, error) {
+	args := m.Called(owner, repo, deployment, request)
+	return nil, nil, args.Error(0)
+}
+
 type mockEmpire struct {
 	mock.Mock
 }
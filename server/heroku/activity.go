@@ -0,0 +1,63 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+type Activity heroku.Activity
+
+func newActivity(a *empire.Activity) *Activity {
+	activity := &Activity{
+		Type:        a.Type,
+		User:        a.CreatedBy,
+		Description: a.Description,
+		Release:     a.Release,
+	}
+	if a.CreatedAt != nil {
+		activity.CreatedAt = *a.CreatedAt
+	}
+	return activity
+}
+
+// GetActivity returns the releases, scales, restarts and crashes of an app,
+// most recent first. They can be filtered by ?since= and ?until=, which are
+// RFC3339 times.
+func (h *Server) GetActivity(w http.ResponseWriter, r *http.Request) error {
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	rangeHeader, err := RangeHeader(r)
+	if err != nil {
+		return err
+	}
+
+	q := empire.ActivityFeedQuery{App: a, Range: rangeHeader}
+
+	params := r.URL.Query()
+
+	if q.Since, err = timelineTime(params.Get("since")); err != nil {
+		return err
+	}
+
+	if q.Until, err = timelineTime(params.Get("until")); err != nil {
+		return err
+	}
+
+	activities, err := h.ActivityFeed(q)
+	if err != nil {
+		return err
+	}
+
+	resources := make([]*Activity, len(activities))
+	for i, a := range activities {
+		resources[i] = newActivity(a)
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, resources)
+}
@@ -0,0 +1,133 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server/auth"
+)
+
+type APIToken heroku.APIToken
+
+func newAPIToken(t *empire.APIToken, appsByID map[string]*empire.App) *APIToken {
+	r := &APIToken{
+		Id:         t.ID,
+		Name:       t.Name,
+		Token:      t.Token,
+		Actions:    t.Scope.Actions,
+		CreatedBy:  t.CreatedBy,
+		CreatedAt:  *t.CreatedAt,
+		LastUsedAt: t.LastUsedAt,
+	}
+
+	for _, id := range t.Scope.Apps {
+		name := id
+		if a, ok := appsByID[id]; ok {
+			name = a.Name
+		}
+		r.Apps = append(r.Apps, name)
+	}
+
+	return r
+}
+
+func (h *Server) appsByID() (map[string]*empire.App, error) {
+	apps, err := h.Apps(empire.AppsQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	appsByID := make(map[string]*empire.App)
+	for _, a := range apps {
+		appsByID[a.ID] = a
+	}
+
+	return appsByID, nil
+}
+
+func (h *Server) GetAPITokens(w http.ResponseWriter, r *http.Request) error {
+	if err := h.Authorize(auth.UserFromContext(r.Context()), nil, empire.RoleAdmin); err != nil {
+		return err
+	}
+
+	tokens, err := h.APITokens(empire.APITokensQuery{})
+	if err != nil {
+		return err
+	}
+
+	appsByID, err := h.appsByID()
+	if err != nil {
+		return err
+	}
+
+	resources := make([]*APIToken, len(tokens))
+	for i, t := range tokens {
+		resources[i] = newAPIToken(t, appsByID)
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, resources)
+}
+
+func (h *Server) PostAPITokens(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var form heroku.APITokenCreateOpts
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	scope := empire.Scope{Actions: form.Actions}
+	appsByID := make(map[string]*empire.App)
+	for _, name := range form.Apps {
+		a, err := h.AppsFind(empire.AppsQuery{Name: &name})
+		if err != nil {
+			return err
+		}
+		appsByID[a.ID] = a
+		scope.Apps = append(scope.Apps, a.ID)
+	}
+
+	t, err := h.APITokensCreate(ctx, empire.APITokensCreateOpts{
+		User:  auth.UserFromContext(ctx),
+		Name:  form.Name,
+		Scope: scope,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(201)
+	return Encode(w, newAPIToken(t, appsByID))
+}
+
+func (h *Server) DeleteAPIToken(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	vars := Vars(r)
+	id := vars["id"]
+
+	t, err := h.APITokensFind(empire.APITokensQuery{ID: &id})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return &ErrorResource{
+				Status:  http.StatusNotFound,
+				ID:      "not_found",
+				Message: "Couldn't find that API token.",
+			}
+		}
+		return err
+	}
+
+	if err := h.APITokensDestroy(ctx, empire.APITokensDestroyOpts{
+		User:  auth.UserFromContext(ctx),
+		Token: t,
+	}); err != nil {
+		return err
+	}
+
+	return NoContent(w)
+}
@@ -0,0 +1,73 @@
+package heroku
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server/auth"
+)
+
+type AppImportResult heroku.AppImportResult
+
+func (h *Server) GetAppExport(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	export, err := h.ExportApp(ctx, empire.ExportAppOpts{
+		User: auth.UserFromContext(ctx),
+		App:  a,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, export)
+}
+
+func (h *Server) PostImport(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var form heroku.AppImportOpts
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	var export empire.AppExport
+	if err := json.Unmarshal(form.Export, &export); err != nil {
+		return &empire.ValidationError{Err: err}
+	}
+
+	m, err := findMessage(r)
+	if err != nil {
+		return err
+	}
+
+	changes, err := h.ImportApp(ctx, empire.ImportAppOpts{
+		User:      auth.UserFromContext(ctx),
+		Export:    &export,
+		Cluster:   form.Cluster,
+		Message:   m,
+		TwoFactor: findTwoFactorCode(r),
+	})
+	if err != nil {
+		return err
+	}
+
+	if changes == nil {
+		changes = []string{}
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, &AppImportResult{
+		App:     export.Name,
+		Changes: changes,
+	})
+}
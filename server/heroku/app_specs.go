@@ -0,0 +1,52 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server/auth"
+)
+
+type AppSpecApplyResult heroku.AppSpecApplyResult
+
+func (h *Server) PostApply(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var form heroku.AppSpecApplyOpts
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	spec, err := empire.ParseAppSpec([]byte(form.Spec))
+	if err != nil {
+		return &empire.ValidationError{Err: err}
+	}
+
+	m, err := findMessage(r)
+	if err != nil {
+		return err
+	}
+
+	changes, err := h.Apply(ctx, empire.ApplyOpts{
+		User:      auth.UserFromContext(ctx),
+		Spec:      spec,
+		DryRun:    form.DryRun,
+		Message:   m,
+		TwoFactor: findTwoFactorCode(r),
+	})
+	if err != nil {
+		return err
+	}
+
+	if changes == nil {
+		changes = []string{}
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, &AppSpecApplyResult{
+		App:     spec.Name,
+		Changes: changes,
+	})
+}
@@ -2,6 +2,8 @@ package heroku
 
 import (
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/remind101/empire"
 	"github.com/remind101/empire/pkg/heroku"
@@ -12,14 +14,40 @@ import (
 type App heroku.App
 
 func newApp(a *empire.App) *App {
-	return &App{
+	app := &App{
 		Id:          a.ID,
 		Name:        a.Name,
 		Maintenance: a.Maintenance,
+		Protected:   a.Protected,
 		CreatedAt:   *a.CreatedAt,
 		Cert:        a.Certs["web"], // For backwards compatibility.
 		Certs:       a.Certs,
+		Team:        a.Team,
+		Namespace:   a.Namespace,
+		Labels:      a.Labels,
+
+		PreviousReleaseWeight: a.PreviousReleaseWeight,
+		DeployTimeout:         int(a.DeployTimeout.Seconds()),
+		CronTimezone:          a.CronTimezone,
+		AlertRoutingKey:       a.AlertRoutingKey,
+		ChaosFraction:         a.ChaosFraction,
 	}
+	app.Region.Name = a.Cluster
+	app.Router = heroku.AppRouter{
+		IdleTimeout:     int(a.RouterSettings.IdleTimeout.Seconds()),
+		WebSockets:      a.RouterSettings.WebSockets,
+		StickySessions:  a.RouterSettings.StickySessions,
+		ProtocolVersion: a.RouterSettings.ProtocolVersion,
+		AllowedCIDRs:    a.RouterSettings.AllowedCIDRs,
+	}
+	if d := a.RouterSettings.DrainTimeout; d != nil {
+		seconds := int(d.Seconds())
+		app.Router.DrainTimeout = &seconds
+	}
+	if a.RouterSettings.BasicAuth != nil {
+		app.Router.BasicAuthUsername = a.RouterSettings.BasicAuth.Username
+	}
+	return app
 }
 
 func newApps(as []*empire.App) []*App {
@@ -33,13 +61,30 @@ func newApps(as []*empire.App) []*App {
 }
 
 func (h *Server) GetApps(w http.ResponseWriter, r *http.Request) error {
-	apps, err := h.Apps(empire.AppsQuery{})
+	selector, err := empire.ParseLabelSelector(r.URL.Query().Get("labels"))
+	if err != nil {
+		return err
+	}
+
+	apps, err := h.Apps(empire.AppsQuery{Selector: selector})
 	if err != nil {
 		return err
 	}
 
+	p, err := h.Permissions(auth.UserFromContext(r.Context()))
+	if err != nil {
+		return err
+	}
+
+	var visible []*empire.App
+	for _, a := range apps {
+		if p.Allowed(a, empire.RoleViewer) {
+			visible = append(visible, a)
+		}
+	}
+
 	w.WriteHeader(200)
-	return Encode(w, newApps(apps))
+	return Encode(w, newApps(visible))
 }
 
 func (h *Server) GetAppInfo(w http.ResponseWriter, r *http.Request) error {
@@ -52,6 +97,70 @@ func (h *Server) GetAppInfo(w http.ResponseWriter, r *http.Request) error {
 	return Encode(w, newApp(a))
 }
 
+type AppOverview heroku.AppOverview
+
+func newAppOverview(info *empire.AppInfo) *AppOverview {
+	overview := &AppOverview{
+		App:      heroku.App(*newApp(info.App)),
+		ConfigId: info.ConfigID,
+	}
+
+	if info.Release != nil {
+		overview.Release = (*heroku.Release)(newRelease(info.Release, info.ReleaseChanges[0]))
+	}
+
+	// Processes in the formation, along with processes that only have
+	// dynos, like one off tasks.
+	states := make(map[string]map[string]int)
+	for _, p := range info.Processes {
+		states[p.Type] = p.States
+	}
+	for _, name := range info.Formation.Types() {
+		p := info.Formation[name]
+		overview.Processes = append(overview.Processes, heroku.AppOverviewProcess{
+			Type:     name,
+			Quantity: p.Quantity,
+			Size:     p.Constraints().String(),
+			States:   states[name],
+		})
+	}
+	for _, p := range info.Processes {
+		if _, ok := info.Formation[p.Type]; !ok {
+			overview.Processes = append(overview.Processes, heroku.AppOverviewProcess{
+				Type:   p.Type,
+				States: p.States,
+			})
+		}
+	}
+
+	for _, d := range info.Domains {
+		overview.Domains = append(overview.Domains, heroku.Domain(*newDomain(d)))
+	}
+
+	for i, r := range info.Releases {
+		overview.Releases = append(overview.Releases, heroku.Release(*newRelease(r, info.ReleaseChanges[i])))
+	}
+
+	return overview
+}
+
+// GetAppOverview returns the app, along with its current release, processes,
+// domains and recent releases.
+func (h *Server) GetAppOverview(w http.ResponseWriter, r *http.Request) error {
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	info, err := h.AppInfo(r.Context(), a)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newAppOverview(info))
+}
+
 func (h *Server) DeleteApp(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
@@ -66,9 +175,10 @@ func (h *Server) DeleteApp(w http.ResponseWriter, r *http.Request) error {
 	}
 
 	if err := h.Destroy(ctx, empire.DestroyOpts{
-		User:    auth.UserFromContext(ctx),
-		App:     a,
-		Message: m,
+		User:      auth.UserFromContext(ctx),
+		App:       a,
+		Message:   m,
+		TwoFactor: findTwoFactorCode(r),
 	}); err != nil {
 		return err
 	}
@@ -95,6 +205,14 @@ func (h *Server) DeployApp(w http.ResponseWriter, r *http.Request) error {
 
 type PostAppsForm struct {
 	Name string `json:"name"`
+
+	// The team that will own the app. Heroku clients send this when
+	// creating apps through /organizations/apps.
+	Organization string `json:"organization"`
+
+	// The cluster that the app will be scheduled to. Heroku clients send
+	// this as the region.
+	Region string `json:"region"`
 }
 
 func (h *Server) PostApps(w http.ResponseWriter, r *http.Request) error {
@@ -114,6 +232,8 @@ func (h *Server) PostApps(w http.ResponseWriter, r *http.Request) error {
 	a, err := h.Create(ctx, empire.CreateOpts{
 		User:    auth.UserFromContext(ctx),
 		Name:    form.Name,
+		Team:    form.Organization,
+		Cluster: form.Region,
 		Message: m,
 	})
 	if err != nil {
@@ -143,9 +263,14 @@ func (h *Server) PatchApp(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
+	// The same code confirms enabling maintenance mode and removing
+	// protection, when both are changed.
+	twoFactor := findTwoFactorCode(r)
+
 	// DEPRECATED: For backwards compatibility with older emp clients.
 	if form.Cert != nil {
 		if err := h.CertsAttach(ctx, empire.CertsAttachOpts{
+			User: auth.UserFromContext(ctx),
 			App:  a,
 			Cert: *form.Cert,
 		}); err != nil {
@@ -159,6 +284,122 @@ func (h *Server) PatchApp(w http.ResponseWriter, r *http.Request) error {
 			App:         a,
 			Maintenance: *form.Maintenance,
 			Message:     m,
+			TwoFactor:   twoFactor,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if form.PreviousReleaseWeight != nil {
+		if err := h.SetTraffic(ctx, empire.SetTrafficOpts{
+			User:                  auth.UserFromContext(ctx),
+			App:                   a,
+			PreviousReleaseWeight: *form.PreviousReleaseWeight,
+			Message:               m,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if form.Router != nil {
+		settings := a.RouterSettings
+		if form.Router.IdleTimeout != nil {
+			settings.IdleTimeout = time.Duration(*form.Router.IdleTimeout) * time.Second
+		}
+		if form.Router.DrainTimeout != nil {
+			settings.DrainTimeout = nil
+			if seconds := *form.Router.DrainTimeout; seconds >= 0 {
+				d := time.Duration(seconds) * time.Second
+				settings.DrainTimeout = &d
+			}
+		}
+		if form.Router.WebSockets != nil {
+			settings.WebSockets = *form.Router.WebSockets
+		}
+		if form.Router.StickySessions != nil {
+			settings.StickySessions = *form.Router.StickySessions
+		}
+		if form.Router.ProtocolVersion != nil {
+			settings.ProtocolVersion = *form.Router.ProtocolVersion
+		}
+		if form.Router.AllowedCIDRs != nil {
+			settings.AllowedCIDRs = *form.Router.AllowedCIDRs
+		}
+		if form.Router.BasicAuth != nil {
+			settings.BasicAuth = nil
+			if *form.Router.BasicAuth != "" {
+				parts := strings.SplitN(*form.Router.BasicAuth, ":", 2)
+				settings.BasicAuth = &empire.BasicAuth{Username: parts[0]}
+				if len(parts) == 2 {
+					settings.BasicAuth.Password = parts[1]
+				}
+			}
+		}
+		if err := h.SetRouterSettings(ctx, empire.SetRouterSettingsOpts{
+			User:     auth.UserFromContext(ctx),
+			App:      a,
+			Settings: settings,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if form.DeployTimeout != nil {
+		if err := h.SetDeployTimeout(ctx, empire.SetDeployTimeoutOpts{
+			User:    auth.UserFromContext(ctx),
+			App:     a,
+			Timeout: time.Duration(*form.DeployTimeout) * time.Second,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if form.CronTimezone != nil {
+		if err := h.SetCronTimezone(ctx, empire.SetCronTimezoneOpts{
+			User:     auth.UserFromContext(ctx),
+			App:      a,
+			Timezone: *form.CronTimezone,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if form.AlertRoutingKey != nil {
+		if err := h.SetAlertRoutingKey(ctx, empire.SetAlertRoutingKeyOpts{
+			User:       auth.UserFromContext(ctx),
+			App:        a,
+			RoutingKey: *form.AlertRoutingKey,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if form.ChaosFraction != nil {
+		if err := h.SetChaos(ctx, empire.SetChaosOpts{
+			User:     auth.UserFromContext(ctx),
+			App:      a,
+			Fraction: *form.ChaosFraction,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if form.Labels != nil {
+		if err := h.SetLabels(ctx, empire.SetLabelsOpts{
+			User:   auth.UserFromContext(ctx),
+			App:    a,
+			Labels: form.Labels,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if form.Protected != nil {
+		if err := h.SetProtected(ctx, empire.SetProtectedOpts{
+			User:      auth.UserFromContext(ctx),
+			App:       a,
+			Protected: *form.Protected,
+			TwoFactor: twoFactor,
 		}); err != nil {
 			return err
 		}
@@ -173,5 +414,11 @@ func (h *Server) findApp(r *http.Request) (*empire.App, error) {
 
 	a, err := h.AppsFind(empire.AppsQuery{Name: &name})
 	reporter.AddContext(r.Context(), "app", a.Name)
-	return a, err
+	if err != nil {
+		return a, err
+	}
+
+	// Every app scoped endpoint requires at least read access to the app.
+	// Mutations are further checked by Empire.
+	return a, h.Authorize(auth.UserFromContext(r.Context()), a, empire.RoleViewer)
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/jinzhu/gorm"
 	"github.com/remind101/empire"
 	"github.com/remind101/empire/internal/realip"
 	"github.com/remind101/empire/server/auth"
@@ -64,6 +65,14 @@ func (s *Server) Authenticate(r *http.Request, strategies ...string) (context.Co
 		return nil, unauthorized(nil)
 	}
 
+	// API tokens authenticate service accounts, which aren't known to the
+	// identity provider, so they bypass the configured strategies and
+	// authorizer. They can't be used on routes that require a specific
+	// strategy (e.g. creating access tokens).
+	if len(strategies) == 0 && empire.IsAPIToken(password) {
+		return s.authenticateAPIToken(r, username, password)
+	}
+
 	otp := r.Header.Get(HeaderTwoFactor)
 	ctx, err := auther.Authenticate(ctx, username, password, otp, strategies...)
 	if err != nil {
@@ -100,6 +109,39 @@ func (s *Server) Authenticate(r *http.Request, strategies ...string) (context.Co
 	return ctx, nil
 }
 
+// authenticateAPIToken authenticates the request as the service account for the
+// API token.
+func (s *Server) authenticateAPIToken(r *http.Request, username, token string) (context.Context, error) {
+	ctx := r.Context()
+
+	unauthorized := s.Unauthorized
+	if unauthorized == nil {
+		unauthorized = Unauthorized
+	}
+
+	t, err := s.APITokensAuthenticate(token)
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			instrumentAuthenticationError(r, username, auth.ErrForbidden)
+			return nil, unauthorized(nil)
+		}
+		return nil, err
+	}
+
+	user := t.User()
+	ctx = auth.WithSession(ctx, auth.NewSession(user))
+
+	logger.Info(ctx,
+		"authenticated",
+		"user", user.Name,
+		"api_token", t.ID,
+	)
+
+	reporter.AddContext(ctx, "user", user.Name)
+
+	return ctx, nil
+}
+
 // Called when a request fails authentication/authorization checks, which logs
 // and increments metrics. Can be useful for alerting on.
 func instrumentAuthenticationError(r *http.Request, username string, err error) {
@@ -219,9 +261,11 @@ func accessTokenToJwt(token *AccessToken) *jwt.Token {
 	t.Claims["User"] = struct {
 		Name        string
 		GitHubToken string
+		Teams       []string `json:",omitempty"`
 	}{
 		Name:        token.User.Name,
 		GitHubToken: token.User.GitHubToken,
+		Teams:       token.User.Teams,
 	}
 
 	return t
@@ -251,6 +295,16 @@ func jwtToAccessToken(t *jwt.Token) (*AccessToken, error) {
 			return &token, errors.New("missing github token")
 		}
 
+		// Teams are optional, and not present in tokens created
+		// before team sync was supported.
+		if teams, ok := u["Teams"].([]interface{}); ok {
+			for _, t := range teams {
+				if t, ok := t.(string); ok {
+					user.Teams = append(user.Teams, t)
+				}
+			}
+		}
+
 		token.User = &user
 	} else {
 		return &token, errors.New("missing user")
@@ -245,6 +245,19 @@ func TestJWTTokens(t *testing.T) {
 	}
 }
 
+func TestJWTTokens_Teams(t *testing.T) {
+	token := &AccessToken{
+		User: &empire.User{Name: "ejholmes", Teams: []string{"platform", "data"}},
+	}
+
+	jwt, err := signToken(testSecret, token)
+	assert.NoError(t, err)
+
+	parsed, err := parseToken(testSecret, jwt)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"platform", "data"}, parsed.User.Teams)
+}
+
 type mockAuthenticator struct {
 	mock.Mock
 }
@@ -0,0 +1,131 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server/auth"
+)
+
+type Batch heroku.Batch
+
+func newBatch(b *empire.Batch, app *empire.App) *Batch {
+	batch := &Batch{
+		Id:          b.ID,
+		Parallelism: b.Parallelism,
+		Status:      b.Status(),
+		CreatedBy:   b.CreatedBy,
+		CreatedAt:   *b.CreatedAt,
+	}
+	batch.App.Id = app.ID
+	batch.App.Name = app.Name
+	batch.Jobs = make([]heroku.BatchJob, len(b.Jobs))
+	for i, j := range b.Jobs {
+		batch.Jobs[i] = heroku.BatchJob{
+			Id:         j.ID,
+			Command:    j.Command.String(),
+			State:      j.State,
+			ExitCode:   j.ExitCode,
+			StartedAt:  j.StartedAt,
+			FinishedAt: j.FinishedAt,
+		}
+	}
+	return batch
+}
+
+func (h *Server) GetBatches(w http.ResponseWriter, r *http.Request) error {
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	batches, err := h.Batches(empire.BatchesQuery{App: a})
+	if err != nil {
+		return err
+	}
+
+	resources := make([]*Batch, len(batches))
+	for i, b := range batches {
+		resources[i] = newBatch(b, a)
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, resources)
+}
+
+func (h *Server) GetBatch(w http.ResponseWriter, r *http.Request) error {
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	vars := Vars(r)
+	id := vars["id"]
+
+	b, err := h.BatchesFind(empire.BatchesQuery{ID: &id, App: a})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return &ErrorResource{
+				Status:  http.StatusNotFound,
+				ID:      "not_found",
+				Message: "Couldn't find that batch.",
+			}
+		}
+		return err
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newBatch(b, a))
+}
+
+type PostBatchesForm struct {
+	Commands    []string            `json:"commands"`
+	Parallelism int                 `json:"parallelism"`
+	Size        *empire.Constraints `json:"size"`
+}
+
+func (h *Server) PostBatches(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	m, err := findMessage(r)
+	if err != nil {
+		return err
+	}
+
+	var form PostBatchesForm
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	commands := make([]empire.Command, len(form.Commands))
+	for i, c := range form.Commands {
+		command, err := empire.ParseCommand(c)
+		if err != nil {
+			return err
+		}
+		commands[i] = command
+	}
+
+	b, err := h.BatchesCreate(ctx, empire.BatchesCreateOpts{
+		User:        auth.UserFromContext(ctx),
+		App:         a,
+		Commands:    commands,
+		Parallelism: form.Parallelism,
+		Constraints: form.Size,
+		Message:     m,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(201)
+	return Encode(w, newBatch(b, a))
+}
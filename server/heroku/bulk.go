@@ -0,0 +1,90 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server/auth"
+)
+
+type BulkPlan heroku.BulkPlan
+
+func newBulkPlan(p *empire.BulkPlan) *BulkPlan {
+	r := &BulkPlan{
+		Id:        p.ID,
+		Operation: p.Operation,
+		Apps:      []heroku.BulkApp{},
+	}
+
+	for _, a := range p.Apps {
+		r.Apps = append(r.Apps, newBulkApp(a))
+	}
+
+	for _, result := range p.Results {
+		br := heroku.BulkResult{App: newBulkApp(result.App)}
+		if result.Err != nil {
+			br.Error = result.Err.Error()
+		}
+		r.Results = append(r.Results, br)
+	}
+
+	return r
+}
+
+func newBulkApp(a *empire.App) heroku.BulkApp {
+	return heroku.BulkApp{Id: a.ID, Name: a.Name}
+}
+
+type PostBulkForm struct {
+	Selector  string `json:"selector"`
+	Operation string `json:"operation"`
+	PatchFormationForm
+	Vars    empire.Vars `json:"vars"`
+	Confirm string      `json:"confirm"`
+}
+
+func (h *Server) PostBulk(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var form PostBulkForm
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	selector, err := empire.ParseLabelSelector(form.Selector)
+	if err != nil {
+		return err
+	}
+
+	m, err := findMessage(r)
+	if err != nil {
+		return err
+	}
+
+	opts := empire.BulkOpts{
+		User:      auth.UserFromContext(ctx),
+		Selector:  selector,
+		Operation: form.Operation,
+		Updates:   form.processUpdates(),
+		Vars:      form.Vars,
+		Message:   m,
+		TwoFactor: findTwoFactorCode(r),
+	}
+
+	plan, err := h.Bulk(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if form.Confirm != "" {
+		opts.Confirm = form.Confirm
+		if plan, err = h.Bulk(ctx, opts); err != nil {
+			return err
+		}
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newBulkPlan(plan))
+}
@@ -0,0 +1,74 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server/auth"
+)
+
+type Capacity heroku.Capacity
+
+func newResources(r empire.Resources) heroku.Resources {
+	return heroku.Resources{
+		CPU:    int(r.CPU),
+		Memory: int64(r.Memory),
+		GPU:    int(r.GPU),
+	}
+}
+
+// newCapacity returns the Capacity for the cluster. If size is not nil, it's
+// used to calculate how many more dynos of that size will fit.
+func newCapacity(c *empire.ClusterCapacity, size *empire.Constraints) *Capacity {
+	capacity := &Capacity{
+		Name:      c.Name,
+		Total:     newResources(c.Total()),
+		Allocated: newResources(c.Allocated()),
+		Machines:  []heroku.Machine{},
+	}
+	for _, m := range c.Machines {
+		machine := heroku.Machine{
+			Host:      heroku.Host{Id: m.Host.ID},
+			Lost:      m.Host.Lost,
+			Zone:      m.Host.Zone,
+			Total:     newResources(m.Total),
+			Allocated: newResources(m.Allocated),
+			Dynos:     m.Tasks,
+		}
+		if size != nil {
+			fits := m.Fits(*size)
+			machine.Fits = &fits
+		}
+		capacity.Machines = append(capacity.Machines, machine)
+	}
+	if size != nil {
+		fits := c.Fits(*size)
+		capacity.Fits = &fits
+	}
+	return capacity
+}
+
+func (h *Server) GetCapacity(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	size, err := empire.ParseConstraints(r.URL.Query().Get("size"))
+	if err != nil {
+		return &empire.ValidationError{Err: err}
+	}
+
+	cs, err := h.Capacity(ctx, empire.CapacityOpts{
+		User: auth.UserFromContext(ctx),
+	})
+	if err != nil {
+		return err
+	}
+
+	capacity := make([]*Capacity, len(cs))
+	for i, c := range cs {
+		capacity[i] = newCapacity(c, size)
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, capacity)
+}
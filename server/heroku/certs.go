@@ -5,6 +5,7 @@ import (
 
 	"github.com/remind101/empire"
 	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server/auth"
 )
 
 func (h *Server) PostCerts(w http.ResponseWriter, r *http.Request) error {
@@ -22,6 +23,7 @@ func (h *Server) PostCerts(w http.ResponseWriter, r *http.Request) error {
 	}
 
 	opts := empire.CertsAttachOpts{
+		User: auth.UserFromContext(ctx),
 		App:  a,
 		Cert: *form.Cert,
 	}
@@ -0,0 +1,53 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+type ChaosKill heroku.ChaosKill
+
+func newChaosKill(k *empire.ChaosKill) *ChaosKill {
+	kill := &ChaosKill{
+		Id:          k.ID,
+		Process:     k.ProcessType,
+		Pid:         k.PID,
+		Release:     k.Release,
+		RecoveredAt: k.RecoveredAt,
+	}
+	if k.KilledAt != nil {
+		kill.KilledAt = *k.KilledAt
+	}
+	if k.RecoveredAt != nil {
+		seconds := int(k.RecoveryTime().Seconds())
+		kill.RecoveryTime = &seconds
+	}
+	return kill
+}
+
+func (h *Server) GetChaosKills(w http.ResponseWriter, r *http.Request) error {
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	rangeHeader, err := RangeHeader(r)
+	if err != nil {
+		return err
+	}
+
+	kills, err := h.ChaosKills(empire.ChaosKillsQuery{App: a, Range: rangeHeader})
+	if err != nil {
+		return err
+	}
+
+	resources := make([]*ChaosKill, len(kills))
+	for i, k := range kills {
+		resources[i] = newChaosKill(k)
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, resources)
+}
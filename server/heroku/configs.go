@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
 	"github.com/remind101/empire/server/auth"
 )
 
@@ -20,7 +21,7 @@ func (h *Server) GetConfigs(w http.ResponseWriter, r *http.Request) error {
 	}
 
 	w.WriteHeader(200)
-	return Encode(w, c.Vars)
+	return Encode(w, a.SensitiveVars.Redact(c.Vars))
 }
 
 func (h *Server) GetConfigsByRelease(w http.ResponseWriter, r *http.Request) error {
@@ -41,7 +42,7 @@ func (h *Server) GetConfigsByRelease(w http.ResponseWriter, r *http.Request) err
 	}
 
 	w.WriteHeader(200)
-	return Encode(w, rel.Config.Vars)
+	return Encode(w, a.SensitiveVars.Redact(rel.Config.Vars))
 }
 
 func (h *Server) PatchConfigs(w http.ResponseWriter, r *http.Request) error {
@@ -65,15 +66,19 @@ func (h *Server) PatchConfigs(w http.ResponseWriter, r *http.Request) error {
 
 	// Update the config
 	c, err := h.Set(ctx, empire.SetOpts{
-		User:    auth.UserFromContext(ctx),
-		App:     a,
-		Vars:    configVars,
-		Message: m,
+		User:      auth.UserFromContext(ctx),
+		App:       a,
+		Vars:      configVars,
+		Sensitive: r.Header.Get(heroku.SensitiveVarsHeader) == "true",
+		Message:   m,
+		TwoFactor: findTwoFactorCode(r),
+
+		FreezeOverride: findFreezeOverride(r),
 	})
 	if err != nil {
 		return err
 	}
 
 	w.WriteHeader(200)
-	return Encode(w, c.Vars)
+	return Encode(w, a.SensitiveVars.Redact(c.Vars))
 }
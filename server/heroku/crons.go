@@ -0,0 +1,88 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server/auth"
+)
+
+type CronRun heroku.CronRun
+
+func newCronRun(r *empire.CronRun) *CronRun {
+	run := &CronRun{
+		Id:          r.ID,
+		Process:     r.ProcessType,
+		Trigger:     r.Trigger,
+		State:       r.State,
+		TaskId:      r.TaskID,
+		ExitCode:    r.ExitCode,
+		Output:      r.Output,
+		CreatedBy:   r.CreatedBy,
+		StartedAt:   r.StartedAt,
+		FinishedAt:  r.FinishedAt,
+		ScheduledAt: r.ScheduledAt,
+	}
+	if r.CreatedAt != nil {
+		run.CreatedAt = *r.CreatedAt
+	}
+	return run
+}
+
+func (h *Server) GetCronRuns(w http.ResponseWriter, r *http.Request) error {
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	rangeHeader, err := RangeHeader(r)
+	if err != nil {
+		return err
+	}
+
+	vars := Vars(r)
+	process := vars["process"]
+
+	runs, err := h.CronRuns(empire.CronRunsQuery{App: a, ProcessType: &process, Range: rangeHeader})
+	if err != nil {
+		return err
+	}
+
+	resources := make([]*CronRun, len(runs))
+	for i, run := range runs {
+		resources[i] = newCronRun(run)
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, resources)
+}
+
+func (h *Server) PostCronRuns(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	m, err := findMessage(r)
+	if err != nil {
+		return err
+	}
+
+	vars := Vars(r)
+
+	run, err := h.CronTrigger(ctx, empire.CronTriggerOpts{
+		User:    auth.UserFromContext(ctx),
+		App:     a,
+		Process: vars["process"],
+		Message: m,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(201)
+	return Encode(w, newCronRun(run))
+}
@@ -3,17 +3,21 @@ package heroku
 import (
 	"net/http"
 
+	"github.com/remind101/empire/pkg/heroku"
 	"github.com/remind101/empire/pkg/image"
 	streamhttp "github.com/remind101/empire/pkg/stream/http"
 	"github.com/remind101/empire/server/auth"
+	"github.com/remind101/pkg/httpx"
 
 	"github.com/remind101/empire"
 )
 
 // PostDeployForm is the form object that represents the POST body.
 type PostDeployForm struct {
-	Image  image.Image
-	Stream bool
+	Image      image.Image
+	Stream     bool
+	Simulate   bool
+	Provenance *heroku.Provenance
 }
 
 // ServeHTTPContext implements the Handler interface.
@@ -63,6 +67,19 @@ func newDeployOpts(w http.ResponseWriter, req *http.Request) (*empire.DeployOpts
 		Output:  empire.NewDeploymentStream(streamhttp.StreamingResponseWriter(w)),
 		Message: m,
 		Stream:  form.Stream,
+
+		Simulate:       form.Simulate,
+		FreezeOverride: findFreezeOverride(req),
+		RequestID:      httpx.RequestID(ctx),
+		IdempotencyKey: findIdempotencyKey(req),
+	}
+	if p := form.Provenance; p != nil {
+		opts.Provenance = empire.Provenance{
+			Repo:     p.Repo,
+			Commit:   p.Commit,
+			BuildURL: p.BuildURL,
+			Builder:  p.Builder,
+		}
 	}
 	return &opts, nil
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/jinzhu/gorm"
 	"github.com/remind101/empire"
 	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server/auth"
 )
 
 type Domain heroku.Domain
@@ -25,13 +26,18 @@ func (h *Server) GetDomains(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	d, err := h.Domains(empire.DomainsQuery{App: a})
+	ds, err := h.Domains(empire.DomainsQuery{App: a})
 	if err != nil {
 		return err
 	}
 
+	resources := make([]*Domain, len(ds))
+	for i := range ds {
+		resources[i] = newDomain(ds[i])
+	}
+
 	w.WriteHeader(200)
-	return Encode(w, d)
+	return Encode(w, resources)
 }
 
 type PostDomainsForm struct {
@@ -56,7 +62,10 @@ func (h *Server) PostDomains(w http.ResponseWriter, r *http.Request) error {
 		AppID:    a.ID,
 		Hostname: form.Hostname,
 	}
-	d, err := h.DomainsCreate(ctx, domain)
+	d, err := h.DomainsCreate(ctx, empire.DomainsCreateOpts{
+		User:   auth.UserFromContext(ctx),
+		Domain: domain,
+	})
 	if err != nil {
 		if err == empire.ErrDomainInUse {
 			return fmt.Errorf("%s is currently in use by another app.", domain.Hostname)
@@ -93,7 +102,10 @@ func (h *Server) DeleteDomain(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	if err = h.DomainsDestroy(ctx, d); err != nil {
+	if err = h.DomainsDestroy(ctx, empire.DomainsDestroyOpts{
+		User:   auth.UserFromContext(ctx),
+		Domain: d,
+	}); err != nil {
 		return err
 	}
 
@@ -60,8 +60,23 @@ type ErrorResource struct {
 }
 
 func newError(err error) *ErrorResource {
-	if err == gorm.RecordNotFound {
+	switch err {
+	case gorm.RecordNotFound:
 		return ErrNotFound
+	case empire.ErrTwoFactorRequired:
+		return ErrTwoFactor
+	case empire.ErrTwoFactorCode:
+		return &ErrorResource{
+			Status:  http.StatusUnauthorized,
+			ID:      "two_factor",
+			Message: err.Error(),
+		}
+	case empire.ErrTwoFactorNotEnrolled:
+		return &ErrorResource{
+			Status:  http.StatusForbidden,
+			ID:      "two_factor_enrollment",
+			Message: err.Error() + " Enroll with `emp two-factor-enroll`.",
+		}
 	}
 
 	switch err := err.(type) {
@@ -71,6 +86,60 @@ func newError(err error) *ErrorResource {
 		return ErrMessageRequired
 	case *empire.ValidationError:
 		return ErrBadRequest
+	case *empire.ForbiddenError:
+		return &ErrorResource{
+			Status:  http.StatusForbidden,
+			ID:      "forbidden",
+			Message: err.Error(),
+		}
+	case *empire.TeamForbiddenError:
+		return &ErrorResource{
+			Status:  http.StatusForbidden,
+			ID:      "forbidden",
+			Message: err.Error(),
+		}
+	case *empire.FreezeError:
+		return &ErrorResource{
+			Status:  http.StatusForbidden,
+			ID:      "release_freeze",
+			Message: err.Error(),
+		}
+	case *empire.QuotaExceededError:
+		return &ErrorResource{
+			Status:  http.StatusForbidden,
+			ID:      "quota_exceeded",
+			Message: err.Error(),
+		}
+	case *empire.AdmissionDeniedError:
+		return &ErrorResource{
+			Status:  http.StatusForbidden,
+			ID:      "admission_denied",
+			Message: err.Error(),
+		}
+	case *empire.ImageVerificationError:
+		return &ErrorResource{
+			Status:  http.StatusForbidden,
+			ID:      "image_unverified",
+			Message: err.Error(),
+		}
+	case *empire.ConfigRulesError:
+		return &ErrorResource{
+			Status:  http.StatusUnprocessableEntity,
+			ID:      "invalid_config",
+			Message: err.Error(),
+		}
+	case *empire.FormationConflictError:
+		return &ErrorResource{
+			Status:  http.StatusConflict,
+			ID:      "conflict",
+			Message: err.Error(),
+		}
+	case *empire.IdempotencyKeyError:
+		return &ErrorResource{
+			Status:  http.StatusConflict,
+			ID:      "idempotency_key",
+			Message: err.Error(),
+		}
 	default:
 		return &ErrorResource{
 			Message: err.Error(),
@@ -105,7 +174,7 @@ func Unauthorized(reason error) *ErrorResource {
 }
 
 // SAMLUnauthorized can be used in place of Unauthorized to return a link to
-// login via SAML.
+// login via SAML or OpenID Connect.
 func SAMLUnauthorized(loginURL string) func(error) *ErrorResource {
 	return func(reason error) *ErrorResource {
 		if reason == nil {
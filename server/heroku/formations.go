@@ -1,7 +1,10 @@
 package heroku
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/remind101/empire"
 	"github.com/remind101/empire/pkg/heroku"
@@ -12,12 +15,29 @@ type Formation heroku.Formation
 
 type PatchFormationForm struct {
 	Updates []struct {
-		Process  string              `json:"process"` // Refers to process type
-		Quantity int                 `json:"quantity"`
-		Size     *empire.Constraints `json:"size"`
+		Process  string                 `json:"process"` // Refers to process type
+		Quantity int                    `json:"quantity"`
+		Change   *empire.QuantityChange `json:"change"`
+		Size     *empire.Constraints    `json:"size"`
+		Args     *empire.Command        `json:"args"`
 	} `json:"updates"`
 }
 
+// processUpdates returns the updates to make to the formation.
+func (f *PatchFormationForm) processUpdates() []*empire.ProcessUpdate {
+	var updates []*empire.ProcessUpdate
+	for _, up := range f.Updates {
+		updates = append(updates, &empire.ProcessUpdate{
+			Process:     up.Process,
+			Quantity:    up.Quantity,
+			Change:      up.Change,
+			Constraints: up.Size,
+			Args:        up.Args,
+		})
+	}
+	return updates
+}
+
 func (h *Server) PatchFormation(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
@@ -37,19 +57,20 @@ func (h *Server) PatchFormation(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	var updates []*empire.ProcessUpdate
-	for _, up := range form.Updates {
-		updates = append(updates, &empire.ProcessUpdate{
-			Process:     up.Process,
-			Quantity:    up.Quantity,
-			Constraints: up.Size,
-		})
+	version, err := findFormationVersion(r)
+	if err != nil {
+		return err
 	}
+
+	updates := form.processUpdates()
 	ps, err := h.Scale(ctx, empire.ScaleOpts{
-		User:    auth.UserFromContext(ctx),
-		App:     app,
-		Updates: updates,
-		Message: m,
+		User:             auth.UserFromContext(ctx),
+		App:              app,
+		Updates:          updates,
+		FormationVersion: version,
+		IdempotencyKey:   findIdempotencyKey(r),
+		Message:          m,
+		TwoFactor:        findTwoFactorCode(r),
 	})
 	if err != nil {
 		return err
@@ -62,6 +83,7 @@ func (h *Server) PatchFormation(w http.ResponseWriter, r *http.Request) error {
 			Type:     up.Process,
 			Quantity: p.Quantity,
 			Size:     p.Constraints().String(),
+			Args:     p.Args,
 		})
 	}
 
@@ -71,27 +93,49 @@ func (h *Server) PatchFormation(w http.ResponseWriter, r *http.Request) error {
 
 // ServeHTTPContext handles the http response
 func (h *Server) GetFormation(w http.ResponseWriter, r *http.Request) error {
-	ctx := r.Context()
-
 	app, err := h.findApp(r)
 	if err != nil {
 		return err
 	}
 
-	formation, err := h.ListScale(ctx, app)
+	release, err := h.ReleasesFind(empire.ReleasesQuery{App: app})
 	if err != nil {
 		return err
 	}
 
 	var resp []*Formation
-	for name, proc := range formation {
+	for name, proc := range release.Formation {
 		resp = append(resp, &Formation{
 			Type:     name,
 			Quantity: proc.Quantity,
 			Size:     proc.Constraints().String(),
+			Args:     proc.Args,
 		})
 	}
 
+	w.Header().Set("ETag", formationETag(release.FormationVersion))
 	w.WriteHeader(200)
 	return Encode(w, resp)
 }
+
+// formationETag returns the ETag of a version of a formation. Clients can
+// provide it in the If-Match header when scaling, so that the formation is only
+// scaled if it hasn't changed since they read it.
+func formationETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// findFormationVersion returns the version of the formation in the If-Match
+// header, or 0 if there isn't one.
+func findFormationVersion(r *http.Request) (int, error) {
+	etag := r.Header.Get("If-Match")
+	if etag == "" || etag == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(etag, "W/"), `"`))
+	if err != nil || version <= 0 {
+		return 0, ErrBadRequest
+	}
+	return version, nil
+}
@@ -0,0 +1,139 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/remind101/empire/server/auth"
+)
+
+type FreezeWindow heroku.FreezeWindow
+
+func newFreezeWindow(w *empire.FreezeWindow, app *empire.App) *FreezeWindow {
+	f := &FreezeWindow{
+		Id:        w.ID,
+		StartsAt:  w.StartsAt,
+		EndsAt:    w.EndsAt,
+		Reason:    w.Reason,
+		Team:      w.Team,
+		CreatedBy: w.CreatedBy,
+		CreatedAt: *w.CreatedAt,
+	}
+
+	if app != nil {
+		f.App = &struct {
+			Id   string `json:"id"`
+			Name string `json:"name"`
+		}{
+			Id:   app.ID,
+			Name: app.Name,
+		}
+	}
+
+	return f
+}
+
+func (h *Server) GetFreezeWindows(w http.ResponseWriter, r *http.Request) error {
+	now := timex.Now()
+	windows, err := h.FreezeWindows(empire.FreezeWindowsQuery{EndsAfter: &now})
+	if err != nil {
+		return err
+	}
+
+	apps, err := h.Apps(empire.AppsQuery{})
+	if err != nil {
+		return err
+	}
+
+	appsByID := make(map[string]*empire.App)
+	for _, a := range apps {
+		appsByID[a.ID] = a
+	}
+
+	resources := make([]*FreezeWindow, len(windows))
+	for i, fw := range windows {
+		var app *empire.App
+		if fw.AppID != nil {
+			app = appsByID[*fw.AppID]
+		}
+		resources[i] = newFreezeWindow(fw, app)
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, resources)
+}
+
+func (h *Server) PostFreezeWindows(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var form heroku.FreezeWindowCreateOpts
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	window := &empire.FreezeWindow{
+		StartsAt: form.StartsAt,
+		EndsAt:   form.EndsAt,
+	}
+
+	if form.Reason != nil {
+		window.Reason = *form.Reason
+	}
+
+	if form.Team != nil {
+		window.Team = *form.Team
+	}
+
+	var app *empire.App
+	if form.App != nil {
+		a, err := h.AppsFind(empire.AppsQuery{Name: form.App})
+		if err != nil {
+			return err
+		}
+		app = a
+		window.AppID = &a.ID
+	}
+
+	fw, err := h.FreezeWindowsCreate(ctx, empire.FreezeWindowsCreateOpts{
+		User:   auth.UserFromContext(ctx),
+		Window: window,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(201)
+	return Encode(w, newFreezeWindow(fw, app))
+}
+
+func (h *Server) DeleteFreezeWindow(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	vars := Vars(r)
+	id := vars["id"]
+
+	fw, err := h.FreezeWindowsFind(empire.FreezeWindowsQuery{ID: &id})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return &ErrorResource{
+				Status:  http.StatusNotFound,
+				ID:      "not_found",
+				Message: "Couldn't find that freeze window.",
+			}
+		}
+		return err
+	}
+
+	if err := h.FreezeWindowsDestroy(ctx, empire.FreezeWindowsDestroyOpts{
+		User:   auth.UserFromContext(ctx),
+		Window: fw,
+	}); err != nil {
+		return err
+	}
+
+	return NoContent(w)
+}
@@ -0,0 +1,229 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server/auth"
+)
+
+type Grant heroku.Grant
+
+func newGrant(g *empire.Grant, app *empire.App) *Grant {
+	r := &Grant{
+		Id:        g.ID,
+		Username:  g.Username,
+		Team:      g.Team,
+		Namespace: g.Namespace,
+		Role:      g.Role,
+		CreatedAt: *g.CreatedAt,
+	}
+
+	if app != nil {
+		r.App = &struct {
+			Id   string `json:"id"`
+			Name string `json:"name"`
+		}{
+			Id:   app.ID,
+			Name: app.Name,
+		}
+	}
+
+	return r
+}
+
+type TeamMember heroku.TeamMember
+
+func newTeamMember(m *empire.TeamMember) *TeamMember {
+	return &TeamMember{
+		Team:      m.Team,
+		Username:  m.Username,
+		CreatedAt: *m.CreatedAt,
+	}
+}
+
+func (h *Server) GetGrants(w http.ResponseWriter, r *http.Request) error {
+	if err := h.Authorize(auth.UserFromContext(r.Context()), nil, empire.RoleAdmin); err != nil {
+		return err
+	}
+
+	grants, err := h.Grants(empire.GrantsQuery{})
+	if err != nil {
+		return err
+	}
+
+	apps, err := h.Apps(empire.AppsQuery{})
+	if err != nil {
+		return err
+	}
+
+	appsByID := make(map[string]*empire.App)
+	for _, a := range apps {
+		appsByID[a.ID] = a
+	}
+
+	resources := make([]*Grant, len(grants))
+	for i, g := range grants {
+		var app *empire.App
+		if g.AppID != nil {
+			app = appsByID[*g.AppID]
+		}
+		resources[i] = newGrant(g, app)
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, resources)
+}
+
+func (h *Server) PostGrants(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var form heroku.GrantCreateOpts
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	grant := &empire.Grant{
+		Role: form.Role,
+	}
+
+	if form.Username != nil {
+		grant.Username = *form.Username
+	}
+
+	if form.Team != nil {
+		grant.Team = *form.Team
+	}
+
+	if form.Namespace != nil {
+		grant.Namespace = *form.Namespace
+	}
+
+	var app *empire.App
+	if form.App != nil {
+		a, err := h.AppsFind(empire.AppsQuery{Name: form.App})
+		if err != nil {
+			return err
+		}
+		app = a
+		grant.AppID = &a.ID
+	}
+
+	g, err := h.GrantsCreate(ctx, empire.GrantsCreateOpts{
+		User:  auth.UserFromContext(ctx),
+		Grant: grant,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(201)
+	return Encode(w, newGrant(g, app))
+}
+
+func (h *Server) DeleteGrant(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	vars := Vars(r)
+	id := vars["id"]
+
+	g, err := h.GrantsFind(empire.GrantsQuery{ID: &id})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return &ErrorResource{
+				Status:  http.StatusNotFound,
+				ID:      "not_found",
+				Message: "Couldn't find that grant.",
+			}
+		}
+		return err
+	}
+
+	if err := h.GrantsDestroy(ctx, empire.GrantsDestroyOpts{
+		User:  auth.UserFromContext(ctx),
+		Grant: g,
+	}); err != nil {
+		return err
+	}
+
+	return NoContent(w)
+}
+
+func (h *Server) GetTeamMembers(w http.ResponseWriter, r *http.Request) error {
+	if err := h.Authorize(auth.UserFromContext(r.Context()), nil, empire.RoleAdmin); err != nil {
+		return err
+	}
+
+	vars := Vars(r)
+	team := vars["team"]
+
+	members, err := h.TeamMembers(empire.TeamMembersQuery{Team: &team})
+	if err != nil {
+		return err
+	}
+
+	resources := make([]*TeamMember, len(members))
+	for i, m := range members {
+		resources[i] = newTeamMember(m)
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, resources)
+}
+
+func (h *Server) PostTeamMembers(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var form heroku.TeamMemberCreateOpts
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	vars := Vars(r)
+
+	m, err := h.TeamMembersCreate(ctx, empire.TeamMembersCreateOpts{
+		User: auth.UserFromContext(ctx),
+		Member: &empire.TeamMember{
+			Team:     vars["team"],
+			Username: form.Username,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(201)
+	return Encode(w, newTeamMember(m))
+}
+
+func (h *Server) DeleteTeamMember(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	vars := Vars(r)
+	team, username := vars["team"], vars["username"]
+
+	m, err := h.TeamMembersFind(empire.TeamMembersQuery{Team: &team, Username: &username})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return &ErrorResource{
+				Status:  http.StatusNotFound,
+				ID:      "not_found",
+				Message: "Couldn't find that team member.",
+			}
+		}
+		return err
+	}
+
+	if err := h.TeamMembersDestroy(ctx, empire.TeamMembersDestroyOpts{
+		User:   auth.UserFromContext(ctx),
+		Member: m,
+	}); err != nil {
+		return err
+	}
+
+	return NoContent(w)
+}
@@ -45,7 +45,14 @@ type Server struct {
 	// (e.g. when SAML is enabled).
 	Unauthorized func(reason error) *ErrorResource
 
+	// If provided, limits the number of requests that change things.
+	RateLimit *RateLimit
+
 	mux *mux.Router
+
+	// The routes that have been added, in order, which are described by
+	// the OpenAPI document.
+	routes []*route
 }
 
 // New returns a new Server instance to serve the Heroku compatible API.
@@ -56,61 +63,295 @@ func New(e *empire.Empire) *Server {
 	}
 
 	// Apps
-	r.handle("GET", "/apps", r.GetApps)                  // hk apps
-	r.handle("GET", "/apps/{app}", r.GetAppInfo)         // hk info
-	r.handle("DELETE", "/apps/{app}", r.DeleteApp)       // hk destroy
-	r.handle("PATCH", "/apps/{app}", r.PatchApp)         // hk destroy
-	r.handle("POST", "/apps/{app}/deploys", r.DeployApp) // Deploy an image to an app
-	r.handle("POST", "/apps", r.PostApps)                // hk create
-	r.handle("POST", "/organizations/apps", r.PostApps)  // hk create
+	r.handle("GET", "/apps", r.GetApps).
+		Query("labels").
+		Returns(200, []*App{}) // hk apps
+	r.handle("GET", "/apps/{app}", r.GetAppInfo).
+		Returns(200, &App{}) // hk info
+	r.handle("GET", "/apps/{app}/overview", r.GetAppOverview).
+		Returns(200, &AppOverview{}) // emp info
+	r.handle("DELETE", "/apps/{app}", r.DeleteApp).
+		Returns(204, nil) // hk destroy
+	r.handle("PATCH", "/apps/{app}", r.PatchApp).
+		Accepts(heroku.AppUpdateOpts{}).
+		Returns(200, &App{}) // hk destroy
+	r.handle("POST", "/apps/{app}/deploys", r.DeployApp).
+		Accepts(PostDeployForm{}).
+		ReturnsStream(200, "application/json") // Deploy an image to an app
+	r.handle("POST", "/apps", r.PostApps).
+		Accepts(PostAppsForm{}).
+		Returns(201, &App{}) // hk create
+	// Heroku clients create apps that are owned by a team here, which
+	// works the same as /apps.
+	r.handle("POST", "/organizations/apps", r.PostApps).
+		Undocumented() // hk create
 
 	// Domains
-	r.handle("GET", "/apps/{app}/domains", r.GetDomains)                 // hk domains
-	r.handle("POST", "/apps/{app}/domains", r.PostDomains)               // hk domain-add
-	r.handle("DELETE", "/apps/{app}/domains/{hostname}", r.DeleteDomain) // hk domain-remove
+	r.handle("GET", "/apps/{app}/domains", r.GetDomains).
+		Returns(200, []*Domain{}) // hk domains
+	r.handle("POST", "/apps/{app}/domains", r.PostDomains).
+		Accepts(PostDomainsForm{}).
+		Returns(201, &Domain{}) // hk domain-add
+	r.handle("DELETE", "/apps/{app}/domains/{hostname}", r.DeleteDomain).
+		Returns(204, nil) // hk domain-remove
+
+	// Ingress Rules
+	r.handle("GET", "/apps/{app}/ingress-rules", r.GetIngressRules).
+		Returns(200, []*IngressRule{})
+	r.handle("POST", "/apps/{app}/ingress-rules", r.PostIngressRules).
+		Accepts(heroku.IngressRuleCreateOpts{}).
+		Returns(201, &IngressRule{})
+	r.handle("DELETE", "/apps/{app}/ingress-rules/{id}", r.DeleteIngressRule).
+		Returns(204, nil)
+
+	// Routing Rules
+	r.handle("GET", "/apps/{app}/routing-rules", r.GetRoutingRules).
+		Returns(200, []*RoutingRule{})
+	r.handle("POST", "/apps/{app}/routing-rules", r.PostRoutingRules).
+		Accepts(heroku.RoutingRuleCreateOpts{}).
+		Returns(201, &RoutingRule{})
+	r.handle("DELETE", "/apps/{app}/routing-rules/{id}", r.DeleteRoutingRule).
+		Returns(204, nil)
+
+	// Jobs
+	r.handle("GET", "/apps/{app}/jobs/{id}/output", r.GetJobOutput).
+		ReturnsStream(200, "text/plain")
+
+	// Scheduled processes
+	r.handle("GET", "/apps/{app}/crons/{process}/runs", r.GetCronRuns).
+		Returns(200, []*CronRun{})
+	r.handle("POST", "/apps/{app}/crons/{process}/runs", r.PostCronRuns).
+		Returns(201, &CronRun{})
+
+	// Chaos testing
+	r.handle("GET", "/apps/{app}/chaos-kills", r.GetChaosKills).
+		Returns(200, []*ChaosKill{}) // emp chaos-kills
+	r.handle("GET", "/apps/{app}/timeline", r.GetTimeline).
+		Returns(200, []*Transition{}) // emp timeline
+	r.handle("GET", "/apps/{app}/activity", r.GetActivity).
+		Returns(200, []*Activity{}) // emp activity
+
+	// Batches
+	r.handle("GET", "/apps/{app}/batches", r.GetBatches).
+		Returns(200, []*Batch{})
+	r.handle("POST", "/apps/{app}/batches", r.PostBatches).
+		Accepts(PostBatchesForm{}).
+		Returns(201, &Batch{})
+	r.handle("GET", "/apps/{app}/batches/{id}", r.GetBatch).
+		Returns(200, &Batch{})
 
 	// Deploys
-	r.handle("POST", "/deploys", r.PostDeploys) // Deploy an app
+	r.handle("POST", "/deploys", r.PostDeploys).
+		Accepts(PostDeployForm{}).
+		ReturnsStream(200, "application/json") // Deploy an app
+
+	// Images
+	r.handle("GET", "/images/usage", r.GetImageUsage).
+		Query("image", "layer").
+		Returns(200, []*ImageUsage{}) // emp image-usage
+	r.handle("POST", "/images/redeploy", r.PostImageRedeploy).
+		Accepts(heroku.ImageRedeployOpts{}).
+		ReturnsStream(200, "application/json") // emp image-redeploy
+
+	// App specs
+	r.handle("POST", "/apply", r.PostApply).
+		Accepts(heroku.AppSpecApplyOpts{}).
+		Returns(200, &AppSpecApplyResult{}) // emp apply
+
+	// Exports
+	r.handle("GET", "/apps/{app}/export", r.GetAppExport).
+		Returns(200, &empire.AppExport{}) // emp export
+	r.handle("POST", "/import", r.PostImport).
+		Accepts(heroku.AppImportOpts{}).
+		Returns(200, &AppImportResult{}) // emp import
 
 	// Releases
-	r.handle("GET", "/apps/{app}/releases", r.GetReleases)          // hk releases
-	r.handle("GET", "/apps/{app}/releases/{version}", r.GetRelease) // hk release-info
-	r.handle("POST", "/apps/{app}/releases", r.PostReleases)        // hk rollback
+	r.handle("GET", "/apps/{app}/releases", r.GetReleases).
+		Returns(200, []*Release{}) // hk releases
+	r.handle("GET", "/apps/{app}/releases/{version}", r.GetRelease).
+		Returns(200, &Release{}) // hk release-info
+	r.handle("GET", "/apps/{app}/releases/{version}/deploy-log", r.GetReleaseDeployLog).
+		ReturnsStream(200, "application/json") // emp deploy-log
+	r.handle("POST", "/apps/{app}/releases", r.PostReleases).
+		Accepts(PostReleasesForm{}).
+		Returns(200, &Release{}) // hk rollback
 
 	// Configs
-	r.handle("GET", "/apps/{app}/config-vars", r.GetConfigs)                    // hk env, hk get
-	r.handle("GET", "/apps/{app}/config-vars/{version}", r.GetConfigsByRelease) // hk env v1, hk get v1
-	r.handle("PATCH", "/apps/{app}/config-vars", r.PatchConfigs)                // hk set, hk unset
+	r.handle("GET", "/apps/{app}/config-vars", r.GetConfigs).
+		Returns(200, empire.Vars{}) // hk env, hk get
+	r.handle("GET", "/apps/{app}/config-vars/{version}", r.GetConfigsByRelease).
+		Returns(200, empire.Vars{}) // hk env v1, hk get v1
+	r.handle("PATCH", "/apps/{app}/config-vars", r.PatchConfigs).
+		Accepts(empire.Vars{}).
+		Returns(200, empire.Vars{}) // hk set, hk unset
 
 	// Processes
-	r.handle("GET", "/apps/{app}/dynos", r.GetProcesses)                     // hk dynos
-	r.handle("POST", "/apps/{app}/dynos", r.PostProcess)                     // hk run
-	r.handle("DELETE", "/apps/{app}/dynos", r.DeleteProcesses)               // hk restart
-	r.handle("DELETE", "/apps/{app}/dynos/{ptype}.{pid}", r.DeleteProcesses) // hk restart web.1
-	r.handle("DELETE", "/apps/{app}/dynos/{pid}", r.DeleteProcesses)         // hk restart web
+	r.handle("GET", "/dynos", r.GetClusterProcesses).
+		Query(tasksQueryParams...).
+		Returns(200, []*Dyno{}) // emp cluster-ps
+	r.handle("GET", "/apps/{app}/dynos", r.GetProcesses).
+		Query(tasksQueryParams...).
+		Returns(200, []*Dyno{}) // hk dynos
+	r.handle("POST", "/apps/{app}/dynos", r.PostProcess).
+		Accepts(PostProcessForm{}).
+		Returns(201, &Dyno{}) // hk run
+	r.handle("DELETE", "/apps/{app}/dynos", r.DeleteProcesses).
+		Returns(204, nil) // hk restart
+	// Works the same as /apps/{app}/dynos/{pid}, which also matches
+	// web.1.
+	r.handle("DELETE", "/apps/{app}/dynos/{ptype}.{pid}", r.DeleteProcesses).
+		Undocumented() // hk restart web.1
+	r.handle("DELETE", "/apps/{app}/dynos/{pid}", r.DeleteProcesses).
+		OperationID("DeleteProcess").
+		Returns(204, nil) // hk restart web
+	// Exec and port forwarding hijack the connection, which can't be
+	// described in the OpenAPI document.
+	r.handle("POST", "/apps/{app}/dynos/{pid}/exec", r.PostProcessExec).
+		Undocumented() // emp exec
+	r.handle("POST", "/apps/{app}/dynos/{pid}/port-forward", r.PostProcessPortForward).
+		Undocumented() // emp port-forward
+	r.handle("GET", "/apps/{app}/dynos/{pid}/files", r.GetProcessFiles).
+		Query("path").
+		ReturnsStream(200, "application/x-tar") // emp cp
+	r.handle("PUT", "/apps/{app}/dynos/{pid}/files", r.PutProcessFiles).
+		Query("path").
+		AcceptsStream("application/x-tar").
+		Returns(204, nil) // emp cp
 
 	// Formations
-	r.handle("GET", "/apps/{app}/formation", r.GetFormation)     // hk scale -l
-	r.handle("PATCH", "/apps/{app}/formation", r.PatchFormation) // hk scale
+	r.handle("GET", "/apps/{app}/formation", r.GetFormation).
+		Returns(200, []*Formation{}) // hk scale -l
+	r.handle("PATCH", "/apps/{app}/formation", r.PatchFormation).
+		Accepts(PatchFormationForm{}).
+		Returns(200, []*Formation{}) // hk scale
 
 	// OAuth
 	r.handle("POST", "/oauth/authorizations", r.PostAuthorizations).
+		Returns(200, &Authorization{}).
 		// Authentication for this endpoint is handled directly in the
 		// handler.
 		AuthWith(auth.StrategyUsernamePassword)
 
 	// Certs
-	r.handle("POST", "/apps/{app}/certs", r.PostCerts)
+	r.handle("POST", "/apps/{app}/certs", r.PostCerts).
+		Accepts(heroku.CertsAttachOpts{}).
+		Returns(200, nil)
 
-	// SSL
+	// SSL. These only return ErrSSLRemoved, so they're not documented.
 	sslRemoved := errHandler(ErrSSLRemoved)
-	r.handle("GET", "/apps/{app}/ssl-endpoints", sslRemoved)           // hk ssl
-	r.handle("POST", "/apps/{app}/ssl-endpoints", sslRemoved)          // hk ssl-cert-add
-	r.handle("PATCH", "/apps/{app}/ssl-endpoints/{cert}", sslRemoved)  // hk ssl-cert-add, hk ssl-cert-rollback
-	r.handle("DELETE", "/apps/{app}/ssl-endpoints/{cert}", sslRemoved) // hk ssl-destroy
+	r.handle("GET", "/apps/{app}/ssl-endpoints", sslRemoved).
+		Undocumented() // hk ssl
+	r.handle("POST", "/apps/{app}/ssl-endpoints", sslRemoved).
+		Undocumented() // hk ssl-cert-add
+	r.handle("PATCH", "/apps/{app}/ssl-endpoints/{cert}", sslRemoved).
+		Undocumented() // hk ssl-cert-add, hk ssl-cert-rollback
+	r.handle("DELETE", "/apps/{app}/ssl-endpoints/{cert}", sslRemoved).
+		Undocumented() // hk ssl-destroy
 
 	// Logs
-	r.handle("POST", "/apps/{app}/log-sessions", r.PostLogs) // hk log
+	r.handle("POST", "/apps/{app}/log-sessions", r.PostLogs).
+		Accepts(PostLogsForm{}).
+		ReturnsStream(200, "text/plain") // hk log
+
+	// Bulk operations
+	r.handle("POST", "/bulk", r.PostBulk).
+		Accepts(PostBulkForm{}).
+		Returns(200, &BulkPlan{}) // emp bulk
+
+	// Registry Credentials
+	r.handle("GET", "/registry-credentials", r.GetRegistryCredentials).
+		Returns(200, []*RegistryCredential{})
+	r.handle("POST", "/registry-credentials", r.PostRegistryCredentials).
+		Accepts(heroku.RegistryCredentialCreateOpts{}).
+		Returns(201, &RegistryCredential{})
+	r.handle("DELETE", "/registry-credentials/{id}", r.DeleteRegistryCredential).
+		Returns(204, nil)
+
+	// Freeze Windows
+	r.handle("GET", "/freeze-windows", r.GetFreezeWindows).
+		Returns(200, []*FreezeWindow{})
+	r.handle("POST", "/freeze-windows", r.PostFreezeWindows).
+		Accepts(heroku.FreezeWindowCreateOpts{}).
+		Returns(201, &FreezeWindow{})
+	r.handle("DELETE", "/freeze-windows/{id}", r.DeleteFreezeWindow).
+		Returns(204, nil)
+
+	// Namespaces
+	r.handle("GET", "/namespaces", r.GetNamespaces).
+		Returns(200, []*Namespace{})
+	r.handle("POST", "/namespaces", r.PostNamespaces).
+		Accepts(heroku.NamespaceCreateOpts{}).
+		Returns(201, &Namespace{})
+	r.handle("DELETE", "/namespaces/{namespace}", r.DeleteNamespace).
+		Returns(204, nil)
+	r.handle("GET", "/namespaces/{namespace}/apps", r.GetNamespaceApps).
+		Returns(200, []*App{}) // emp apps -n
+
+	// Quotas
+	r.handle("GET", "/quotas", r.GetQuotas).
+		Returns(200, []*Quota{})
+	r.handle("POST", "/quotas", r.PostQuotas).
+		Accepts(heroku.QuotaCreateOpts{}).
+		Returns(201, &Quota{})
+	r.handle("DELETE", "/quotas/{id}", r.DeleteQuota).
+		Returns(204, nil)
+
+	// Grants
+	r.handle("GET", "/grants", r.GetGrants).
+		Returns(200, []*Grant{})
+	r.handle("POST", "/grants", r.PostGrants).
+		Accepts(heroku.GrantCreateOpts{}).
+		Returns(201, &Grant{})
+	r.handle("DELETE", "/grants/{id}", r.DeleteGrant).
+		Returns(204, nil)
+
+	// Hosts
+	r.handle("POST", "/hosts/{host}/cordon", r.PostHostCordon).
+		Returns(204, nil) // emp cordon
+	r.handle("DELETE", "/hosts/{host}/cordon", r.DeleteHostCordon).
+		Returns(204, nil) // emp uncordon
+	r.handle("POST", "/hosts/{host}/drain", r.PostHostDrain).
+		Returns(202, []*Dyno{}) // emp drain
+
+	// Operator Config
+	r.handle("POST", "/operator-config/reload", r.PostOperatorConfigReload).
+		Returns(200, &OperatorConfig{}) // emp reload-config
+
+	// Capacity
+	r.handle("GET", "/capacity", r.GetCapacity).
+		Query("size").
+		Returns(200, []*Capacity{}) // emp capacity
+
+	// Usage
+	r.handle("GET", "/usage", r.GetUsage).
+		Query("month", "labels", "by").
+		Returns(200, []*Usage{}) // emp usage
+
+	// Teams
+	r.handle("GET", "/teams/{team}/members", r.GetTeamMembers).
+		Returns(200, []*TeamMember{})
+	r.handle("POST", "/teams/{team}/members", r.PostTeamMembers).
+		Accepts(heroku.TeamMemberCreateOpts{}).
+		Returns(201, &TeamMember{})
+	r.handle("DELETE", "/teams/{team}/members/{username}", r.DeleteTeamMember).
+		Returns(204, nil)
+
+	// API Tokens
+	r.handle("GET", "/api-tokens", r.GetAPITokens).
+		Returns(200, []*APIToken{})
+	r.handle("POST", "/api-tokens", r.PostAPITokens).
+		Accepts(heroku.APITokenCreateOpts{}).
+		Returns(201, &APIToken{})
+	r.handle("DELETE", "/api-tokens/{id}", r.DeleteAPIToken).
+		Returns(204, nil)
+
+	// Two factor
+	r.handle("POST", "/two-factor", r.PostTwoFactor).
+		Returns(201, &TwoFactorSecret{})
+	r.handle("POST", "/two-factor/{username}", r.PostUserTwoFactor).
+		Returns(201, &TwoFactorSecret{})
+	r.handle("DELETE", "/two-factor/{username}", r.DeleteTwoFactor).
+		Returns(204, nil)
 
 	return r
 }
@@ -129,6 +370,12 @@ type route struct {
 	// When true, disables the authentication check.
 	authStrategies []string
 
+	// The method and path that the route was added with.
+	method, path string
+
+	// Describes the route in the OpenAPI document. See openapi.go.
+	api routeAPI
+
 	s *Server
 }
 
@@ -152,11 +399,16 @@ func (r *route) handle(w http.ResponseWriter, req *http.Request) error {
 	if err != nil {
 		return err
 	}
+	req = req.WithContext(ctx)
+
+	if err := r.s.rateLimit(w, req); err != nil {
+		return err
+	}
 
 	// Track metrics for this endpoint.
 	m := withMetrics(r.Name, r.handler)
 
-	return m(w, req.WithContext(ctx))
+	return m(w, req)
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -166,7 +418,9 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // handle adds a new handler to the router, which also increments a counter.
 func (s *Server) handle(method, path string, h handlerFunc, authStrategy ...string) *route {
 	r := s.route(h)
+	r.method, r.path = method, path
 	s.mux.Handle(path, r).Methods(method)
+	s.routes = append(s.routes, r)
 	return r
 }
 
@@ -260,6 +514,24 @@ func findMessage(r *http.Request) (string, error) {
 	return h, nil
 }
 
+// findFreezeOverride returns the reason provided to release during a freeze
+// window.
+func findFreezeOverride(r *http.Request) string {
+	return r.Header.Get(heroku.FreezeOverrideHeader)
+}
+
+// findTwoFactorCode returns the two factor code that the client provided, to
+// confirm destructive operations on protected apps.
+func findTwoFactorCode(r *http.Request) *empire.TwoFactorCode {
+	return &empire.TwoFactorCode{Code: r.Header.Get(HeaderTwoFactor)}
+}
+
+// findIdempotencyKey returns the idempotency key that the client provided, so
+// that retries of the request aren't performed again.
+func findIdempotencyKey(r *http.Request) string {
+	return r.Header.Get(heroku.IdempotencyKeyHeader)
+}
+
 var nameRegexp = regexp.MustCompile(`^.*\.(.*)-fm$`)
 
 // handlerName returns the name of the handler, which can be used as a metrics
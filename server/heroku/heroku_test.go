@@ -54,6 +54,10 @@ func TestError(t *testing.T) {
 		{ErrNotFound, 400, `{"id":"not_found","message":"Request failed, the specified resource does not exist","url":""}` + "\n", 404},
 		{&ErrorResource{Message: "custom"}, 400, `{"id":"","message":"custom","url":""}` + "\n", 400},
 		{&empire.ValidationError{Err: errors.New("boom")}, 500, `{"id":"bad_request","message":"Request invalid, validate usage and try again","url":""}` + "\n", 400},
+		{empire.ErrTwoFactorRequired, 400, `{"id":"two_factor","message":"Two factor code is required.","url":""}` + "\n", 401},
+		{empire.ErrTwoFactorCode, 400, `{"id":"two_factor","message":"Two factor code is invalid.","url":""}` + "\n", 401},
+		{&empire.ConfigRulesError{Missing: []string{"DATABASE_URL"}, Invalid: []string{"LOG_LEVEL must be one of debug, info"}}, 400, `{"id":"invalid_config","message":"Config vars don't satisfy the config rules of the app: missing DATABASE_URL; LOG_LEVEL must be one of debug, info","url":""}` + "\n", 422},
+		{&empire.FormationConflictError{App: &empire.App{Name: "acme-inc"}, Version: 1}, 400, `{"id":"conflict","message":"the formation of acme-inc was changed since version 1 was read; get the current formation, and try again","url":""}` + "\n", 409},
 	}
 
 	for _, tt := range tests {
@@ -72,3 +76,35 @@ func TestError(t *testing.T) {
 		}
 	}
 }
+
+func TestFindFormationVersion(t *testing.T) {
+	tests := []struct {
+		etag    string
+		version int
+		err     error
+	}{
+		{"", 0, nil},
+		{"*", 0, nil},
+		{`"3"`, 3, nil},
+		{`W/"3"`, 3, nil},
+		{`"abc"`, 0, ErrBadRequest},
+		{`"0"`, 0, ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("PATCH", "/apps/acme-inc/formation", nil)
+		req.Header.Set("If-Match", tt.etag)
+
+		version, err := findFormationVersion(req)
+		assert.Equal(t, tt.err, err)
+		assert.Equal(t, tt.version, version)
+	}
+}
+
+func TestFindTwoFactorCode(t *testing.T) {
+	req, _ := http.NewRequest("DELETE", "/apps/acme-inc", nil)
+	assert.Equal(t, &empire.TwoFactorCode{}, findTwoFactorCode(req))
+
+	req.Header.Set(HeaderTwoFactor, "123456")
+	assert.Equal(t, &empire.TwoFactorCode{Code: "123456"}, findTwoFactorCode(req))
+}
@@ -0,0 +1,69 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/server/auth"
+)
+
+func (h *Server) PostHostCordon(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	opts, err := hostOpts(r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.Cordon(ctx, opts); err != nil {
+		return err
+	}
+
+	return NoContent(w)
+}
+
+func (h *Server) DeleteHostCordon(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	opts, err := hostOpts(r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.Uncordon(ctx, opts); err != nil {
+		return err
+	}
+
+	return NoContent(w)
+}
+
+func (h *Server) PostHostDrain(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	opts, err := hostOpts(r)
+	if err != nil {
+		return err
+	}
+
+	js, err := h.Drain(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(202)
+	return Encode(w, newDynos(js))
+}
+
+// hostOpts returns the empire.HostOpts for the host in the request path.
+func hostOpts(r *http.Request) (empire.HostOpts, error) {
+	m, err := findMessage(r)
+	if err != nil {
+		return empire.HostOpts{}, err
+	}
+
+	return empire.HostOpts{
+		User:    auth.UserFromContext(r.Context()),
+		Host:    Vars(r)["host"],
+		Message: m,
+	}, nil
+}
@@ -0,0 +1,111 @@
+package heroku
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/pkg/image"
+	streamhttp "github.com/remind101/empire/pkg/stream/http"
+	"github.com/remind101/empire/server/auth"
+)
+
+type ImageUsage heroku.ImageUsage
+
+func newImageUsage(u *empire.ImageUsage) *ImageUsage {
+	var r ImageUsage
+	r.App.Id = u.App.ID
+	r.App.Name = u.App.Name
+	r.Release.Id = u.Release.ID
+	r.Release.Version = u.Release.Version
+	r.Image = u.Release.Slug.Image.String()
+	r.Layers = u.Release.Slug.Layers
+	if r.Layers == nil {
+		r.Layers = []string{}
+	}
+	return &r
+}
+
+// newImageUsageQuery returns a query for the image, and layer.
+func newImageUsageQuery(img, layer string) (empire.ImageUsageQuery, error) {
+	q := empire.ImageUsageQuery{Layer: layer}
+	if img != "" {
+		i, err := image.Decode(img)
+		if err != nil {
+			return q, &empire.ValidationError{Err: err}
+		}
+		q.Image = &i
+	}
+	return q, nil
+}
+
+func (h *Server) GetImageUsage(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	q, err := newImageUsageQuery(r.URL.Query().Get("image"), r.URL.Query().Get("layer"))
+	if err != nil {
+		return err
+	}
+
+	usage, err := h.ImageUsage(ctx, empire.ImageUsageOpts{
+		User:  auth.UserFromContext(ctx),
+		Query: q,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp := make([]*ImageUsage, len(usage))
+	for i, u := range usage {
+		resp[i] = newImageUsage(u)
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, resp)
+}
+
+func (h *Server) PostImageRedeploy(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var form heroku.ImageRedeployOpts
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	q, err := newImageUsageQuery(form.Image, form.Layer)
+	if err != nil {
+		return err
+	}
+
+	m, err := findMessage(r)
+	if err != nil {
+		return err
+	}
+
+	if form.Parallelism == 0 {
+		form.Parallelism = 1
+	}
+
+	opts := empire.RedeployOpts{
+		User:        auth.UserFromContext(ctx),
+		Query:       q,
+		Tag:         form.Tag,
+		Parallelism: form.Parallelism,
+		Interval:    time.Duration(form.Interval) * time.Second,
+		Message:     m,
+	}
+
+	// Errors from validation are returned before anything is streamed.
+	if err := opts.Validate(h.Empire); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json; boundary=NL")
+	opts.Output = empire.NewDeploymentStream(streamhttp.StreamingResponseWriter(w))
+
+	// All other errors are written to the stream.
+	h.Redeploy(ctx, opts)
+	return nil
+}
@@ -0,0 +1,125 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server/auth"
+)
+
+type IngressRule heroku.IngressRule
+
+func newIngressRule(rule *empire.IngressRule, app, source *empire.App) *IngressRule {
+	r := &IngressRule{
+		Id:        rule.ID,
+		Port:      rule.Port,
+		CreatedAt: *rule.CreatedAt,
+	}
+	r.App.Id = app.ID
+	r.App.Name = app.Name
+	r.SourceApp.Id = rule.SourceAppID
+	if source != nil {
+		r.SourceApp.Name = source.Name
+	}
+	return r
+}
+
+func (h *Server) GetIngressRules(w http.ResponseWriter, r *http.Request) error {
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	rules, err := h.IngressRules(empire.IngressRulesQuery{App: a})
+	if err != nil {
+		return err
+	}
+
+	apps, err := h.Apps(empire.AppsQuery{})
+	if err != nil {
+		return err
+	}
+
+	appsByID := make(map[string]*empire.App)
+	for _, a := range apps {
+		appsByID[a.ID] = a
+	}
+
+	resources := make([]*IngressRule, len(rules))
+	for i, rule := range rules {
+		resources[i] = newIngressRule(rule, a, appsByID[rule.SourceAppID])
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, resources)
+}
+
+func (h *Server) PostIngressRules(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	var form heroku.IngressRuleCreateOpts
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	source, err := h.AppsFind(empire.AppsQuery{Name: &form.SourceApp})
+	if err != nil {
+		return err
+	}
+
+	rule, err := h.IngressRulesCreate(ctx, empire.IngressRulesCreateOpts{
+		User: auth.UserFromContext(ctx),
+		Rule: &empire.IngressRule{
+			AppID:       a.ID,
+			SourceAppID: source.ID,
+			Port:        form.Port,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(201)
+	return Encode(w, newIngressRule(rule, a, source))
+}
+
+func (h *Server) DeleteIngressRule(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	vars := Vars(r)
+	id := vars["id"]
+
+	rule, err := h.IngressRulesFind(empire.IngressRulesQuery{ID: &id, App: a})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return &ErrorResource{
+				Status:  http.StatusNotFound,
+				ID:      "not_found",
+				Message: "Couldn't find that ingress rule.",
+			}
+		}
+		return err
+	}
+
+	if err := h.IngressRulesDestroy(ctx, empire.IngressRulesDestroyOpts{
+		User: auth.UserFromContext(ctx),
+		Rule: rule,
+	}); err != nil {
+		return err
+	}
+
+	return NoContent(w)
+}
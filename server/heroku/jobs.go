@@ -0,0 +1,35 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/server/auth"
+)
+
+func (h *Server) GetJobOutput(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	vars := Vars(r)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	err = h.JobOutput(ctx, empire.JobOutputOpts{
+		User:   auth.UserFromContext(ctx),
+		App:    a,
+		ID:     vars["id"],
+		Output: w,
+	})
+	if err == empire.ErrNoOutput {
+		return &ErrorResource{
+			Status:  http.StatusNotFound,
+			ID:      "not_found",
+			Message: "No output was captured for that job. It may not have started yet.",
+		}
+	}
+	return err
+}
@@ -0,0 +1,125 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server/auth"
+)
+
+type Namespace heroku.Namespace
+
+func newNamespace(n *empire.Namespace) *Namespace {
+	return &Namespace{
+		Id:        n.ID,
+		Name:      n.Name,
+		Team:      n.Team,
+		CreatedAt: *n.CreatedAt,
+	}
+}
+
+func (h *Server) GetNamespaces(w http.ResponseWriter, r *http.Request) error {
+	namespaces, err := h.Namespaces(empire.NamespacesQuery{})
+	if err != nil {
+		return err
+	}
+
+	resources := make([]*Namespace, len(namespaces))
+	for i, n := range namespaces {
+		resources[i] = newNamespace(n)
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, resources)
+}
+
+func (h *Server) PostNamespaces(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var form heroku.NamespaceCreateOpts
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	n, err := h.NamespacesCreate(ctx, empire.NamespacesCreateOpts{
+		User: auth.UserFromContext(ctx),
+		Namespace: &empire.Namespace{
+			Name: form.Name,
+			Team: form.Team,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(201)
+	return Encode(w, newNamespace(n))
+}
+
+func (h *Server) DeleteNamespace(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	n, err := h.findNamespace(r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.NamespacesDestroy(ctx, empire.NamespacesDestroyOpts{
+		User:      auth.UserFromContext(ctx),
+		Namespace: n,
+	}); err != nil {
+		return err
+	}
+
+	return NoContent(w)
+}
+
+func (h *Server) GetNamespaceApps(w http.ResponseWriter, r *http.Request) error {
+	n, err := h.findNamespace(r)
+	if err != nil {
+		return err
+	}
+
+	apps, err := h.Apps(empire.AppsQuery{Namespace: &n.Name})
+	if err != nil {
+		return err
+	}
+
+	p, err := h.Permissions(auth.UserFromContext(r.Context()))
+	if err != nil {
+		return err
+	}
+
+	var visible []*empire.App
+	for _, a := range apps {
+		if p.Allowed(a, empire.RoleViewer) {
+			visible = append(visible, a)
+		}
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newApps(visible))
+}
+
+// findNamespace finds the namespace named in the request.
+func (h *Server) findNamespace(r *http.Request) (*empire.Namespace, error) {
+	vars := Vars(r)
+	name := vars["namespace"]
+
+	n, err := h.NamespacesFind(empire.NamespacesQuery{Name: &name})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return nil, &ErrorResource{
+				Status:  http.StatusNotFound,
+				ID:      "not_found",
+				Message: "Couldn't find that namespace.",
+			}
+		}
+		return nil, err
+	}
+
+	return n, nil
+}
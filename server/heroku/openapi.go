@@ -0,0 +1,176 @@
+package heroku
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/remind101/empire/pkg/openapi"
+)
+
+// routeAPI describes the request and response of a route, for the OpenAPI
+// document.
+type routeAPI struct {
+	// Overrides the name of the handler as the operation id, for handlers
+	// that serve more than one route.
+	operationID string
+
+	// Excludes the route from the document.
+	undocumented bool
+
+	// The names of the query parameters that the route accepts.
+	query []string
+
+	// The value that the request body is decoded into, or the content type
+	// of a request body that's streamed.
+	request       interface{}
+	requestStream string
+
+	// The status of a successful response, and the value that's encoded in
+	// the response body, or the content type of a response that's
+	// streamed.
+	status         int
+	response       interface{}
+	responseStream string
+}
+
+// OperationID sets the name that identifies the route in the OpenAPI document.
+// By default, routes are identified by the name of their handler.
+func (r *route) OperationID(id string) *route {
+	r.api.operationID = id
+	return r
+}
+
+// Undocumented excludes the route from the OpenAPI document.
+func (r *route) Undocumented() *route {
+	r.api.undocumented = true
+	return r
+}
+
+// Query documents the query parameters that the route accepts.
+func (r *route) Query(names ...string) *route {
+	r.api.query = names
+	return r
+}
+
+// Accepts documents that the request body is decoded into v.
+func (r *route) Accepts(v interface{}) *route {
+	r.api.request = v
+	return r
+}
+
+// AcceptsStream documents that the request body is read as a stream of the
+// content type.
+func (r *route) AcceptsStream(contentType string) *route {
+	r.api.requestStream = contentType
+	return r
+}
+
+// Returns documents the status of a successful response, and that v is
+// encoded in the response body. v is nil when there's no body.
+func (r *route) Returns(status int, v interface{}) *route {
+	r.api.status, r.api.response = status, v
+	return r
+}
+
+// ReturnsStream documents the status of a successful response, and that the
+// response body is streamed with the content type.
+func (r *route) ReturnsStream(status int, contentType string) *route {
+	r.api.status, r.api.responseStream = status, contentType
+	return r
+}
+
+// pathParamRegexp matches the variables in the path of a route.
+var pathParamRegexp = regexp.MustCompile(`{([^}]+)}`)
+
+// OpenAPI returns an OpenAPI document that describes the routes of the API, so
+// that clients can be generated from it. It panics if two routes have the same
+// operation id.
+func (s *Server) OpenAPI() *openapi.Document {
+	doc := openapi.New("Empire", "3")
+	doc.Components.SecuritySchemes = map[string]*openapi.SecurityScheme{
+		"basic": {Type: "http", Scheme: "basic"},
+	}
+	doc.Security = []map[string][]string{{"basic": {}}}
+
+	errorSchema := doc.SchemaOf(ErrorResource{})
+
+	ids := make(map[string]bool)
+	for _, r := range s.routes {
+		if r.api.undocumented {
+			continue
+		}
+
+		id := r.api.operationID
+		if id == "" {
+			id = r.Name
+		}
+		if ids[id] {
+			panic(fmt.Sprintf("openapi: operation id %s is used by more than one route", id))
+		}
+		ids[id] = true
+
+		op := &openapi.Operation{
+			OperationID: id,
+			Responses: map[string]*openapi.Response{
+				"default": {
+					Description: "Error",
+					Content:     jsonContent(errorSchema),
+				},
+			},
+		}
+
+		for _, m := range pathParamRegexp.FindAllStringSubmatch(r.path, -1) {
+			op.Parameters = append(op.Parameters, &openapi.Parameter{
+				Name:     m[1],
+				In:       "path",
+				Required: true,
+				Schema:   &openapi.Schema{Type: "string"},
+			})
+		}
+		for _, name := range r.api.query {
+			op.Parameters = append(op.Parameters, &openapi.Parameter{
+				Name:   name,
+				In:     "query",
+				Schema: &openapi.Schema{Type: "string"},
+			})
+		}
+
+		switch {
+		case r.api.request != nil:
+			op.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content:  jsonContent(doc.SchemaOf(r.api.request)),
+			}
+		case r.api.requestStream != "":
+			op.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content:  map[string]*openapi.MediaType{r.api.requestStream: {}},
+			}
+		}
+
+		status := r.api.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		resp := &openapi.Response{Description: http.StatusText(status)}
+		switch {
+		case r.api.response != nil:
+			resp.Content = jsonContent(doc.SchemaOf(r.api.response))
+		case r.api.responseStream != "":
+			resp.Content = map[string]*openapi.MediaType{r.api.responseStream: {}}
+		}
+		op.Responses[strconv.Itoa(status)] = resp
+
+		doc.AddOperation(r.method, r.path, op)
+	}
+
+	return doc
+}
+
+func jsonContent(schema *openapi.Schema) map[string]*openapi.MediaType {
+	return map[string]*openapi.MediaType{
+		"application/json": {Schema: schema},
+	}
+}
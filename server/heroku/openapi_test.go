@@ -0,0 +1,43 @@
+package heroku
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const openAPIFilename = "../../docs/openapi.json"
+
+func TestServer_OpenAPI(t *testing.T) {
+	s := New(nil)
+
+	raw, err := json.MarshalIndent(s.OpenAPI(), "", "  ")
+	assert.NoError(t, err)
+	raw = append(raw, '\n')
+
+	expected, err := ioutil.ReadFile(openAPIFilename)
+	assert.NoError(t, err)
+
+	if got, want := string(raw), string(expected); got != want {
+		ioutil.WriteFile(openAPIFilename, raw, 0660)
+		t.Errorf("expected OpenAPI document to match existing %s. Wrote to %s", openAPIFilename, openAPIFilename)
+	}
+}
+
+func TestServer_OpenAPI_Operations(t *testing.T) {
+	doc := New(nil).OpenAPI()
+
+	op := doc.Paths["/apps/{app}/formation"]["patch"]
+	if assert.NotNil(t, op) {
+		assert.Equal(t, "PatchFormation", op.OperationID)
+		assert.Equal(t, "app", op.Parameters[0].Name)
+		assert.Equal(t, "#/components/schemas/PatchFormationForm", op.RequestBody.Content["application/json"].Schema.Ref)
+		assert.Equal(t, "#/components/schemas/Formation", op.Responses["200"].Content["application/json"].Schema.Items.Ref)
+	}
+
+	// Undocumented routes aren't included.
+	assert.Nil(t, doc.Paths["/apps/{app}/ssl-endpoints"])
+	assert.Nil(t, doc.Paths["/apps/{app}/dynos/{pid}/exec"])
+}
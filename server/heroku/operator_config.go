@@ -0,0 +1,40 @@
+package heroku
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server/auth"
+)
+
+type OperatorConfig heroku.OperatorConfig
+
+func newOperatorConfig(c *empire.OperatorConfig) *OperatorConfig {
+	sizes := []string{}
+	for name := range c.Sizes {
+		sizes = append(sizes, name)
+	}
+	sort.Strings(sizes)
+
+	return &OperatorConfig{
+		Sizes:         sizes,
+		Quotas:        len(c.Quotas),
+		FreezeWindows: len(c.FreezeWindows),
+	}
+}
+
+func (h *Server) PostOperatorConfigReload(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	config, err := h.ReloadOperatorConfig(ctx, empire.ReloadOperatorConfigOpts{
+		User: auth.UserFromContext(ctx),
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newOperatorConfig(config))
+}
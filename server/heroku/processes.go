@@ -2,20 +2,27 @@ package heroku
 
 import (
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/remind101/empire"
+	"github.com/remind101/empire/internal/uuid"
+	"github.com/remind101/empire/pkg/constraints"
 	"github.com/remind101/empire/pkg/heroku"
 	"github.com/remind101/empire/pkg/hijack"
 	"github.com/remind101/empire/pkg/stdcopy"
 	"github.com/remind101/empire/pkg/timex"
 	"github.com/remind101/empire/server/auth"
+	"github.com/remind101/pkg/reporter"
 )
 
 type Dyno heroku.Dyno
 
 func newDyno(task *empire.Task) *Dyno {
-	return &Dyno{
+	d := &Dyno{
 		Command:   task.Command.String(),
 		Type:      task.Type,
 		Name:      task.Name,
@@ -24,6 +31,11 @@ func newDyno(task *empire.Task) *Dyno {
 		Size:      task.Constraints.String(),
 		UpdatedAt: task.UpdatedAt,
 	}
+	d.App.Name = task.App
+	if task.Image.Repository != "" {
+		d.Image = task.Image.String()
+	}
+	return d
 }
 
 func newDynos(tasks []*empire.Task) []*Dyno {
@@ -44,21 +56,166 @@ func (h *Server) GetProcesses(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
+	q, err := tasksQuery(r)
+	if err != nil {
+		return err
+	}
+	q.App = a
+
+	if r.URL.Query().Get("watch") == "true" {
+		return h.watchProcesses(w, r, q)
+	}
+
 	// Retrieve tasks
-	js, err := h.Tasks(ctx, a)
+	js, err := h.Tasks(ctx, q)
 	if err != nil {
 		return err
 	}
 
-	w.WriteHeader(200)
+	writeNextRange(w, q, js, func(t *empire.Task) string { return t.Name })
+	return Encode(w, newDynos(js))
+}
+
+// DynoChange is a change to a dyno that's being watched.
+type DynoChange heroku.DynoChange
+
+// watchProcesses streams the changes to the processes of an app, as JSON
+// arrays of DynoChange, until the client disconnects. Arrays are only written
+// when something changed, and a newline is written otherwise, to keep the
+// connection alive.
+func (h *Server) watchProcesses(w http.ResponseWriter, r *http.Request, q empire.TasksQuery) error {
+	ctx := r.Context()
+
+	streaming := false
+	err := h.WatchTasks(ctx, empire.WatchTasksOpts{
+		Query: q,
+		Changes: func(changes []*empire.TaskChange) error {
+			if !streaming {
+				streaming = true
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(200)
+			} else if len(changes) == 0 {
+				if _, err := io.WriteString(w, "\n"); err != nil {
+					return err
+				}
+				if f, ok := w.(http.Flusher); ok {
+					f.Flush()
+				}
+				return nil
+			}
+
+			resp := make([]*DynoChange, len(changes))
+			for i, c := range changes {
+				resp[i] = &DynoChange{Type: c.Type, Dyno: heroku.Dyno(*newDyno(c.Task))}
+			}
+			return Stream(w, resp)
+		},
+	})
+
+	// Once the response has started, errors can't be returned to the
+	// client, so the stream is just closed.
+	if err != nil && streaming {
+		reporter.Report(ctx, err)
+		return nil
+	}
+	return err
+}
+
+// GetClusterProcesses returns the processes for all apps.
+func (h *Server) GetClusterProcesses(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	q, err := tasksQuery(r)
+	if err != nil {
+		return err
+	}
+
+	js, err := h.ClusterTasks(ctx, empire.ClusterTasksOpts{
+		User:  auth.UserFromContext(ctx),
+		Query: q,
+	})
+	if err != nil {
+		return err
+	}
+
+	writeNextRange(w, q, js, func(t *empire.Task) string { return t.App + "/" + t.Name })
 	return Encode(w, newDynos(js))
 }
 
+// writeNextRange writes the status of the response. If the results were
+// limited, a Next-Range header lets the client know where the next page starts.
+func writeNextRange(w http.ResponseWriter, q empire.TasksQuery, tasks []*empire.Task, key func(*empire.Task) string) {
+	if max := q.Range.Max; max != nil && len(tasks) > 0 && len(tasks) == *max {
+		next := fmt.Sprintf("name ]%s..; max=%d", key(tasks[len(tasks)-1]), *max)
+		if order := q.Range.Order; order != nil {
+			next += ", order=" + *order
+		}
+		w.Header().Set("Next-Range", next)
+		w.WriteHeader(206)
+		return
+	}
+
+	w.WriteHeader(200)
+}
+
+// tasksQueryParams are the query parameters that tasksQuery reads.
+var tasksQueryParams = []string{"type", "version", "state", "host", "image", "labels"}
+
+// tasksQuery builds an empire.TasksQuery from the query parameters and Range
+// header in the request.
+func tasksQuery(r *http.Request) (empire.TasksQuery, error) {
+	var q empire.TasksQuery
+
+	rangeHeader, err := RangeHeader(r)
+	if err != nil {
+		return q, err
+	}
+	q.Range = rangeHeader
+
+	params := r.URL.Query()
+
+	if v := params.Get("type"); v != "" {
+		q.Type = &v
+	}
+
+	if v := params.Get("version"); v != "" {
+		version, err := strconv.Atoi(strings.TrimPrefix(v, "v"))
+		if err != nil {
+			return q, &empire.ValidationError{Err: fmt.Errorf("invalid release version %q", v)}
+		}
+		q.Version = &version
+	}
+
+	if v := params.Get("state"); v != "" {
+		q.State = &v
+	}
+
+	if v := params.Get("host"); v != "" {
+		q.Host = &v
+	}
+
+	if v := params.Get("image"); v != "" {
+		q.Image = &v
+	}
+
+	selector, err := empire.ParseLabelSelector(params.Get("labels"))
+	if err != nil {
+		return q, err
+	}
+	q.Selector = selector
+
+	return q, nil
+}
+
 type PostProcessForm struct {
 	Command string              `json:"command"`
 	Attach  bool                `json:"attach"`
 	Env     map[string]string   `json:"env"`
 	Size    *empire.Constraints `json:"size"`
+	Timeout int                 `json:"timeout"`
+	CPU     string              `json:"cpu"`
+	Memory  string              `json:"memory"`
+	Capture bool                `json:"capture"`
 }
 
 func (h *Server) PostProcess(w http.ResponseWriter, r *http.Request) error {
@@ -91,7 +248,35 @@ func (h *Server) PostProcess(w http.ResponseWriter, r *http.Request) error {
 		Command:     command,
 		Env:         form.Env,
 		Constraints: form.Size,
+		Timeout:     time.Duration(form.Timeout) * time.Second,
 		Message:     m,
+
+		IdempotencyKey: findIdempotencyKey(r),
+	}
+
+	if form.CPU != "" {
+		c, err := constraints.ParseCPUShare(form.CPU)
+		if err != nil {
+			return &empire.ValidationError{Err: err}
+		}
+		opts.CPUShare = &c
+	}
+
+	if form.Memory != "" {
+		m, err := constraints.ParseMemory(form.Memory)
+		if err != nil {
+			return &empire.ValidationError{Err: err}
+		}
+		opts.Memory = &m
+	}
+
+	if form.Capture {
+		opts.OutputID = uuid.New()
+		if opts.IdempotencyKey != "" {
+			// Retries return the id of the output of the first
+			// run, so it's derived from the key.
+			opts.OutputID = uuid.NewSHA1(uuid.NameSpace_OID, []byte(opts.User.Name+"/"+opts.IdempotencyKey)).String()
+		}
 	}
 
 	if form.Attach {
@@ -136,6 +321,7 @@ func (h *Server) PostProcess(w http.ResponseWriter, r *http.Request) error {
 		}
 
 		dyno := &heroku.Dyno{
+			Id:        opts.OutputID,
 			Name:      "run",
 			Command:   form.Command,
 			CreatedAt: timex.Now(),
@@ -148,6 +334,176 @@ func (h *Server) PostProcess(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+type PostProcessExecForm struct {
+	Command string `json:"command"`
+	Tty     bool   `json:"tty"`
+}
+
+func (h *Server) PostProcessExec(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var form PostProcessExecForm
+
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	m, err := findMessage(r)
+	if err != nil {
+		return err
+	}
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	command, err := empire.ParseCommand(form.Command)
+	if err != nil {
+		return err
+	}
+
+	stream := &hijack.HijackReadWriter{
+		Response: w,
+		Header: http.Header{
+			"Content-Type": []string{"application/vnd.empire.stdcopy-stream"},
+		},
+	}
+	defer stream.Close()
+
+	if err := h.Exec(ctx, empire.ExecOpts{
+		User:    auth.UserFromContext(ctx),
+		App:     a,
+		PID:     dynoPID(r),
+		Command: command,
+		Tty:     form.Tty,
+		Message: m,
+		Stdin:   stream,
+		Stdout:  stdcopy.NewStdWriter(stream, stdcopy.Stdout),
+		Stderr:  stdcopy.NewStdWriter(stream, stdcopy.Stderr),
+	}); err != nil {
+		if stream.Hijacked {
+			fmt.Fprintf(stream, "%v\r", err)
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+type PostProcessPortForwardForm struct {
+	Port int `json:"port"`
+}
+
+func (h *Server) PostProcessPortForward(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var form PostProcessPortForwardForm
+
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	m, err := findMessage(r)
+	if err != nil {
+		return err
+	}
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	stream := &hijack.HijackReadWriter{
+		Response: w,
+		Header: http.Header{
+			"Content-Type": []string{"application/vnd.empire.raw-stream"},
+		},
+	}
+	defer stream.Close()
+
+	if err := h.PortForward(ctx, empire.PortForwardOpts{
+		User:    auth.UserFromContext(ctx),
+		App:     a,
+		PID:     dynoPID(r),
+		Port:    form.Port,
+		Message: m,
+		Stream:  stream,
+	}); err != nil {
+		if stream.Hijacked {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (h *Server) GetProcessFiles(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	m, err := findMessage(r)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	if err := h.CopyFrom(ctx, empire.CopyOpts{
+		User:    auth.UserFromContext(ctx),
+		App:     a,
+		PID:     dynoPID(r),
+		Path:    r.URL.Query().Get("path"),
+		Message: m,
+	}, w); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		return err
+	}
+
+	return nil
+}
+
+func (h *Server) PutProcessFiles(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	m, err := findMessage(r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.CopyTo(ctx, empire.CopyOpts{
+		User:    auth.UserFromContext(ctx),
+		App:     a,
+		PID:     dynoPID(r),
+		Path:    r.URL.Query().Get("path"),
+		Message: m,
+	}, r.Body); err != nil {
+		return err
+	}
+
+	return NoContent(w)
+}
+
+// dynoPID returns the pid of the dyno from the request path. The full name of
+// the dyno, as displayed by `emp ps` (e.g. v1.web.<id>), is also allowed.
+func dynoPID(r *http.Request) string {
+	pid := Vars(r)["pid"]
+	if i := strings.LastIndex(pid, "."); i >= 0 {
+		pid = pid[i+1:]
+	}
+	return pid
+}
+
 func (h *Server) DeleteProcesses(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
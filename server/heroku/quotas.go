@@ -0,0 +1,160 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server/auth"
+)
+
+type Quota heroku.Quota
+
+func newQuota(q *empire.Quota, app *empire.App) *Quota {
+	r := &Quota{
+		Id:           q.ID,
+		Team:         q.Team,
+		Namespace:    q.Namespace,
+		MaxInstances: q.MaxInstances,
+		MaxMemory:    int64(q.MaxMemory),
+		MaxRuns:      q.MaxRuns,
+		MaxRunMemory: int64(q.MaxRunMemory),
+		CreatedAt:    *q.CreatedAt,
+	}
+
+	if app != nil {
+		r.App = &struct {
+			Id   string `json:"id"`
+			Name string `json:"name"`
+		}{
+			Id:   app.ID,
+			Name: app.Name,
+		}
+	}
+
+	return r
+}
+
+func (h *Server) GetQuotas(w http.ResponseWriter, r *http.Request) error {
+	quotas, err := h.Quotas(empire.QuotasQuery{})
+	if err != nil {
+		return err
+	}
+
+	apps, err := h.Apps(empire.AppsQuery{})
+	if err != nil {
+		return err
+	}
+
+	appsByID := make(map[string]*empire.App)
+	for _, a := range apps {
+		appsByID[a.ID] = a
+	}
+
+	resources := make([]*Quota, len(quotas))
+	for i, q := range quotas {
+		var app *empire.App
+		if q.AppID != nil {
+			app = appsByID[*q.AppID]
+		}
+		resources[i] = newQuota(q, app)
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, resources)
+}
+
+func (h *Server) PostQuotas(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var form heroku.QuotaCreateOpts
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	quota := &empire.Quota{}
+
+	if form.Team != nil {
+		quota.Team = *form.Team
+	}
+
+	if form.Namespace != nil {
+		quota.Namespace = *form.Namespace
+	}
+
+	if form.MaxInstances != nil {
+		quota.MaxInstances = *form.MaxInstances
+	}
+
+	if form.MaxMemory != nil {
+		m, err := constraints.ParseMemory(*form.MaxMemory)
+		if err != nil {
+			return &empire.ValidationError{Err: err}
+		}
+		quota.MaxMemory = m
+	}
+
+	if form.MaxRuns != nil {
+		quota.MaxRuns = *form.MaxRuns
+	}
+
+	if form.MaxRunMemory != nil {
+		m, err := constraints.ParseMemory(*form.MaxRunMemory)
+		if err != nil {
+			return &empire.ValidationError{Err: err}
+		}
+		quota.MaxRunMemory = m
+	}
+
+	var app *empire.App
+	if form.App != nil {
+		a, err := h.AppsFind(empire.AppsQuery{Name: form.App})
+		if err != nil {
+			return err
+		}
+		app = a
+		quota.AppID = &a.ID
+	}
+
+	q, err := h.QuotasCreate(ctx, empire.QuotasCreateOpts{
+		User:  auth.UserFromContext(ctx),
+		Quota: quota,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(201)
+	return Encode(w, newQuota(q, app))
+}
+
+func (h *Server) DeleteQuota(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	vars := Vars(r)
+	id := vars["id"]
+
+	q, err := h.QuotasFind(empire.QuotasQuery{ID: &id})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return &ErrorResource{
+				Status:  http.StatusNotFound,
+				ID:      "not_found",
+				Message: "Couldn't find that quota.",
+			}
+		}
+		return err
+	}
+
+	if err := h.QuotasDestroy(ctx, empire.QuotasDestroyOpts{
+		User:  auth.UserFromContext(ctx),
+		Quota: q,
+	}); err != nil {
+		return err
+	}
+
+	return NoContent(w)
+}
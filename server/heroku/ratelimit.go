@@ -0,0 +1,148 @@
+package heroku
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/remind101/empire/server/auth"
+)
+
+// DefaultRateLimitWindow is the window that requests are counted in when a
+// RateLimit doesn't have one.
+const DefaultRateLimitWindow = time.Minute
+
+// RateLimit limits the number of requests that change things, like deploys,
+// scales and config changes, so that a runaway script can't flood the scheduler
+// with releases. Requests that only read aren't limited.
+//
+// Requests are counted in fixed windows, in memory, so each Empire server
+// enforces its limits separately.
+type RateLimit struct {
+	// The length of each window. The zero value is DefaultRateLimitWindow.
+	Window time.Duration
+
+	// The number of requests that are allowed in each window, across all
+	// users.
+	Global int
+
+	// The number of requests that are allowed in each window, with each
+	// token. Tokens are identified by the user that they authenticate as,
+	// so API tokens are limited separately from the user that created them.
+	PerToken int
+
+	// The number of requests that are allowed in each window, to each app.
+	PerApp int
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[string]int
+}
+
+// rateLimitStatus describes the most restrictive limit that applies to a
+// request.
+type rateLimitStatus struct {
+	Limit, Remaining int
+	Reset            time.Duration
+}
+
+// Allow counts a request by the user, to the app, and returns whether it's
+// allowed. When it's not, the request isn't counted. The status of the limit
+// with the fewest remaining requests is also returned, or nil if none of the
+// limits apply.
+func (l *RateLimit) Allow(user, app string) (bool, *rateLimitStatus) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	window := l.Window
+	if window == 0 {
+		window = DefaultRateLimitWindow
+	}
+
+	now := timex.Now()
+	if start := now.Truncate(window); !start.Equal(l.start) || l.counts == nil {
+		l.start = start
+		l.counts = make(map[string]int)
+	}
+
+	type limit struct {
+		key   string
+		limit int
+	}
+	var limits []limit
+	if l.Global > 0 {
+		limits = append(limits, limit{"global", l.Global})
+	}
+	if l.PerToken > 0 && user != "" {
+		limits = append(limits, limit{"token:" + user, l.PerToken})
+	}
+	if l.PerApp > 0 && app != "" {
+		limits = append(limits, limit{"app:" + app, l.PerApp})
+	}
+
+	if len(limits) == 0 {
+		return true, nil
+	}
+
+	allowed := true
+	for _, lim := range limits {
+		if l.counts[lim.key] >= lim.limit {
+			allowed = false
+		}
+	}
+
+	status := &rateLimitStatus{Remaining: math.MaxInt32, Reset: l.start.Add(window).Sub(now)}
+	for _, lim := range limits {
+		if allowed {
+			l.counts[lim.key]++
+		}
+		if remaining := lim.limit - l.counts[lim.key]; remaining < status.Remaining {
+			status.Limit, status.Remaining = lim.limit, remaining
+		}
+	}
+	if status.Remaining < 0 {
+		status.Remaining = 0
+	}
+
+	return allowed, status
+}
+
+// rateLimit counts the request against the RateLimit of the server, if there is
+// one, and sets the RateLimit headers on the response. It returns an error if
+// the request isn't allowed.
+func (s *Server) rateLimit(w http.ResponseWriter, req *http.Request) error {
+	if s.RateLimit == nil {
+		return nil
+	}
+
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS":
+		return nil
+	}
+
+	user := auth.UserFromContext(req.Context())
+	allowed, status := s.RateLimit.Allow(user.Name, Vars(req)["app"])
+	if status == nil {
+		return nil
+	}
+
+	reset := strconv.Itoa(int(math.Ceil(status.Reset.Seconds())))
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(status.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(status.Remaining))
+	w.Header().Set("RateLimit-Reset", reset)
+
+	if !allowed {
+		w.Header().Set("Retry-After", reset)
+		return &ErrorResource{
+			Status:  http.StatusTooManyRequests,
+			ID:      "rate_limit",
+			Message: fmt.Sprintf("Too many requests. Try again in %s seconds.", reset),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package heroku
+
+import (
+	"testing"
+	"time"
+
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit_Allow(t *testing.T) {
+	now := timex.Now
+	defer func() { timex.Now = now }()
+
+	at := time.Date(2017, 1, 1, 0, 0, 15, 0, time.UTC)
+	timex.Now = func() time.Time { return at }
+
+	l := &RateLimit{PerToken: 2, PerApp: 3}
+
+	allowed, status := l.Allow("ejholmes", "acme-inc")
+	assert.True(t, allowed)
+	assert.Equal(t, &rateLimitStatus{Limit: 2, Remaining: 1, Reset: 45 * time.Second}, status)
+
+	allowed, _ = l.Allow("ejholmes", "acme-inc")
+	assert.True(t, allowed)
+
+	// The token has used its requests.
+	allowed, status = l.Allow("ejholmes", "api")
+	assert.False(t, allowed)
+	assert.Equal(t, 0, status.Remaining)
+
+	// But the app hasn't.
+	allowed, status = l.Allow("remind101", "acme-inc")
+	assert.True(t, allowed)
+	assert.Equal(t, &rateLimitStatus{Limit: 3, Remaining: 0, Reset: 45 * time.Second}, status)
+
+	allowed, _ = l.Allow("remind101", "acme-inc")
+	assert.False(t, allowed)
+
+	// Requests are counted again in the next window.
+	at = at.Add(time.Minute)
+	allowed, _ = l.Allow("ejholmes", "acme-inc")
+	assert.True(t, allowed)
+}
+
+func TestRateLimit_Allow_NoLimits(t *testing.T) {
+	l := &RateLimit{Global: 1}
+
+	allowed, status := l.Allow("", "")
+	assert.True(t, allowed)
+	assert.NotNil(t, status)
+
+	l = &RateLimit{PerApp: 1}
+	allowed, status = l.Allow("ejholmes", "")
+	assert.True(t, allowed)
+	assert.Nil(t, status)
+}
@@ -0,0 +1,148 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server/auth"
+)
+
+type RegistryCredential heroku.RegistryCredential
+
+func newRegistryCredential(c *empire.RegistryCredential, app *empire.App) *RegistryCredential {
+	r := &RegistryCredential{
+		Id:                   c.ID,
+		Registry:             c.Registry,
+		Username:             c.Username,
+		CredentialsParameter: c.CredentialsParameter,
+		Scope:                c.Scope(),
+		Team:                 c.Team,
+		CreatedAt:            *c.CreatedAt,
+	}
+
+	if app != nil {
+		r.App = &struct {
+			Id   string `json:"id"`
+			Name string `json:"name"`
+		}{
+			Id:   app.ID,
+			Name: app.Name,
+		}
+	}
+
+	return r
+}
+
+func (h *Server) GetRegistryCredentials(w http.ResponseWriter, r *http.Request) error {
+	if err := h.Authorize(auth.UserFromContext(r.Context()), nil, empire.RoleAdmin); err != nil {
+		return err
+	}
+
+	creds, err := h.RegistryCredentials(empire.RegistryCredentialsQuery{})
+	if err != nil {
+		return err
+	}
+
+	apps, err := h.Apps(empire.AppsQuery{})
+	if err != nil {
+		return err
+	}
+
+	appsByID := make(map[string]*empire.App)
+	for _, a := range apps {
+		appsByID[a.ID] = a
+	}
+
+	resources := make([]*RegistryCredential, len(creds))
+	for i, c := range creds {
+		var app *empire.App
+		if c.AppID != nil {
+			app = appsByID[*c.AppID]
+		}
+		resources[i] = newRegistryCredential(c, app)
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, resources)
+}
+
+func (h *Server) PostRegistryCredentials(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var form heroku.RegistryCredentialCreateOpts
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	cred := &empire.RegistryCredential{
+		Registry: form.Registry,
+	}
+
+	if form.Username != nil {
+		cred.Username = *form.Username
+	}
+
+	if form.Password != nil {
+		cred.Password = *form.Password
+	}
+
+	if form.CredentialsParameter != nil {
+		cred.CredentialsParameter = *form.CredentialsParameter
+	}
+
+	if form.Team != nil {
+		cred.Team = *form.Team
+	}
+
+	var app *empire.App
+	if form.App != nil {
+		a, err := h.AppsFind(empire.AppsQuery{Name: form.App})
+		if err != nil {
+			return err
+		}
+		app = a
+		cred.AppID = &a.ID
+	}
+
+	c, err := h.RegistryCredentialsCreate(ctx, empire.RegistryCredentialsCreateOpts{
+		User:       auth.UserFromContext(ctx),
+		Credential: cred,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(201)
+	return Encode(w, newRegistryCredential(c, app))
+}
+
+func (h *Server) DeleteRegistryCredential(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	vars := Vars(r)
+	id := vars["id"]
+
+	c, err := h.RegistryCredentialsFind(empire.RegistryCredentialsQuery{ID: &id})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return &ErrorResource{
+				Status:  http.StatusNotFound,
+				ID:      "not_found",
+				Message: "Couldn't find those registry credentials.",
+			}
+		}
+		return err
+	}
+
+	if err := h.RegistryCredentialsDestroy(ctx, empire.RegistryCredentialsDestroyOpts{
+		User:       auth.UserFromContext(ctx),
+		Credential: c,
+	}); err != nil {
+		return err
+	}
+
+	return NoContent(w)
+}
@@ -11,8 +11,8 @@ import (
 
 type Release heroku.Release
 
-func newRelease(r *empire.Release) *Release {
-	return &Release{
+func newRelease(r *empire.Release, c *empire.ReleaseChanges) *Release {
+	release := &Release{
 		Id:      r.ID,
 		Version: r.Version,
 		Slug: &struct {
@@ -21,20 +21,75 @@ func newRelease(r *empire.Release) *Release {
 			Id: r.SlugID,
 		},
 		Description: r.Description,
+		Source:      r.Source,
+		Message:     r.Message,
+		Changes:     newReleaseChanges(c),
 		CreatedAt:   *r.CreatedAt,
 	}
+	release.User.Name = r.CreatedBy
+	if r.Slug != nil && !r.Slug.Provenance.IsZero() {
+		release.Provenance = newProvenance(r.Slug.Provenance)
+	}
+	return release
+}
+
+func newProvenance(p empire.Provenance) *heroku.Provenance {
+	return &heroku.Provenance{
+		Repo:     p.Repo,
+		Commit:   p.Commit,
+		BuildURL: p.BuildURL,
+		Builder:  p.Builder,
+	}
 }
 
-func newReleases(rs []*empire.Release) []*Release {
+func newReleases(rs []*empire.Release, cs []*empire.ReleaseChanges) []*Release {
 	releases := make([]*Release, len(rs))
 
 	for i := 0; i < len(rs); i++ {
-		releases[i] = newRelease(rs[i])
+		releases[i] = newRelease(rs[i], cs[i])
 	}
 
 	return releases
 }
 
+func newReleaseChanges(c *empire.ReleaseChanges) *heroku.ReleaseChanges {
+	changes := &heroku.ReleaseChanges{
+		VarsAdded:   c.VarsAdded,
+		VarsChanged: c.VarsChanged,
+		VarsRemoved: c.VarsRemoved,
+	}
+
+	if c.Image != nil {
+		changes.Image = &struct {
+			From string `json:"from"`
+			To   string `json:"to"`
+		}{
+			From: c.Image.From,
+			To:   c.Image.To,
+		}
+	}
+
+	for _, p := range c.Formation {
+		changes.Formation = append(changes.Formation, heroku.ReleaseProcessChange{
+			Type:     p.Process,
+			Previous: newReleaseProcessScale(p.Previous),
+			Current:  newReleaseProcessScale(p.Current),
+		})
+	}
+
+	return changes
+}
+
+func newReleaseProcessScale(s *empire.ProcessScale) *heroku.ReleaseProcessScale {
+	if s == nil {
+		return nil
+	}
+	return &heroku.ReleaseProcessScale{
+		Quantity: s.Quantity,
+		Size:     s.Constraints.String(),
+	}
+}
+
 func (h *Server) GetRelease(w http.ResponseWriter, r *http.Request) error {
 	a, err := h.findApp(r)
 	if err != nil {
@@ -52,8 +107,43 @@ func (h *Server) GetRelease(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
+	changes, err := h.ReleasesChanges([]*empire.Release{rel})
+	if err != nil {
+		return err
+	}
+
 	w.WriteHeader(200)
-	return Encode(w, newRelease(rel))
+	return Encode(w, newRelease(rel, changes[0]))
+}
+
+func (h *Server) GetReleaseDeployLog(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	vars := Vars(r)
+	vers, err := strconv.Atoi(vars["version"])
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = h.DeployLog(ctx, empire.DeployLogOpts{
+		App:     a,
+		Version: vers,
+		Output:  w,
+	})
+	if err == empire.ErrNoDeployLog {
+		return &ErrorResource{
+			Status:  http.StatusNotFound,
+			ID:      "not_found",
+			Message: "No deploy log was stored for that release. It may not have been created by a deploy, or the log may have expired.",
+		}
+	}
+	return err
 }
 
 func (h *Server) GetReleases(w http.ResponseWriter, r *http.Request) error {
@@ -72,8 +162,13 @@ func (h *Server) GetReleases(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
+	changes, err := h.ReleasesChanges(rels)
+	if err != nil {
+		return err
+	}
+
 	w.WriteHeader(200)
-	return Encode(w, newReleases(rels))
+	return Encode(w, newReleases(rels, changes))
 }
 
 type PostReleasesForm struct {
@@ -118,11 +213,18 @@ func (h *Server) PostReleases(w http.ResponseWriter, r *http.Request) error {
 		App:     app,
 		Version: version,
 		Message: m,
+
+		FreezeOverride: findFreezeOverride(r),
 	})
 	if err != nil {
 		return err
 	}
 
+	changes, err := h.ReleasesChanges([]*empire.Release{release})
+	if err != nil {
+		return err
+	}
+
 	w.WriteHeader(200)
-	return Encode(w, newRelease(release))
+	return Encode(w, newRelease(release, changes[0]))
 }
@@ -0,0 +1,109 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server/auth"
+)
+
+type RoutingRule heroku.RoutingRule
+
+func newRoutingRule(rule *empire.RoutingRule, app *empire.App) *RoutingRule {
+	r := &RoutingRule{
+		Id:        rule.ID,
+		Host:      rule.Host,
+		Path:      rule.Path,
+		Process:   rule.Process,
+		CreatedAt: *rule.CreatedAt,
+	}
+	r.App.Id = app.ID
+	r.App.Name = app.Name
+	return r
+}
+
+func (h *Server) GetRoutingRules(w http.ResponseWriter, r *http.Request) error {
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	rules, err := h.RoutingRules(empire.RoutingRulesQuery{App: a})
+	if err != nil {
+		return err
+	}
+
+	resources := make([]*RoutingRule, len(rules))
+	for i, rule := range rules {
+		resources[i] = newRoutingRule(rule, a)
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, resources)
+}
+
+func (h *Server) PostRoutingRules(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	var form heroku.RoutingRuleCreateOpts
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	rule, err := h.RoutingRulesCreate(ctx, empire.RoutingRulesCreateOpts{
+		User: auth.UserFromContext(ctx),
+		Rule: &empire.RoutingRule{
+			AppID:   a.ID,
+			Host:    form.Host,
+			Path:    form.Path,
+			Process: form.Process,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(201)
+	return Encode(w, newRoutingRule(rule, a))
+}
+
+func (h *Server) DeleteRoutingRule(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	vars := Vars(r)
+	id := vars["id"]
+
+	rule, err := h.RoutingRulesFind(empire.RoutingRulesQuery{ID: &id, App: a})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return &ErrorResource{
+				Status:  http.StatusNotFound,
+				ID:      "not_found",
+				Message: "Couldn't find that routing rule.",
+			}
+		}
+		return err
+	}
+
+	if err := h.RoutingRulesDestroy(ctx, empire.RoutingRulesDestroyOpts{
+		User: auth.UserFromContext(ctx),
+		Rule: rule,
+	}); err != nil {
+		return err
+	}
+
+	return NoContent(w)
+}
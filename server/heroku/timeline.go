@@ -0,0 +1,88 @@
+package heroku
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+type Transition heroku.Transition
+
+func newTransition(t *empire.TaskTransition) *Transition {
+	transition := &Transition{
+		Id:      t.ID,
+		Process: t.ProcessType,
+		Pid:     t.PID,
+		Release: t.Release,
+		Host:    t.Host,
+		State:   t.State,
+		Reason:  t.Reason,
+	}
+	if t.OccurredAt != nil {
+		transition.OccurredAt = *t.OccurredAt
+	}
+	return transition
+}
+
+// GetTimeline returns the transitions of the dynos of an app, most recent
+// first. They can be filtered by ?process=, ?pid=, which matches the name or
+// id of a dyno, and ?since= and ?until=, which are RFC3339 times.
+func (h *Server) GetTimeline(w http.ResponseWriter, r *http.Request) error {
+	a, err := h.findApp(r)
+	if err != nil {
+		return err
+	}
+
+	rangeHeader, err := RangeHeader(r)
+	if err != nil {
+		return err
+	}
+
+	q := empire.TaskTransitionsQuery{App: a, Range: rangeHeader}
+
+	params := r.URL.Query()
+
+	if v := params.Get("process"); v != "" {
+		q.Process = &v
+	}
+
+	if v := params.Get("pid"); v != "" {
+		q.PID = &v
+	}
+
+	if q.Since, err = timelineTime(params.Get("since")); err != nil {
+		return err
+	}
+
+	if q.Until, err = timelineTime(params.Get("until")); err != nil {
+		return err
+	}
+
+	transitions, err := h.TaskTransitions(q)
+	if err != nil {
+		return err
+	}
+
+	resources := make([]*Transition, len(transitions))
+	for i, t := range transitions {
+		resources[i] = newTransition(t)
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, resources)
+}
+
+// timelineTime parses an RFC3339 time, and returns nil if v is empty.
+func timelineTime(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, &empire.ValidationError{Err: fmt.Errorf("invalid time %q, must be formatted as RFC3339", v)}
+	}
+	return &t, nil
+}
@@ -0,0 +1,52 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server/auth"
+)
+
+type TwoFactorSecret heroku.TwoFactorSecret
+
+func newTwoFactorSecret(s *empire.TwoFactorSecret) *TwoFactorSecret {
+	return &TwoFactorSecret{
+		Secret: s.Secret,
+		URL:    s.URL(),
+	}
+}
+
+func (h *Server) PostTwoFactor(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	s, err := h.TwoFactorEnroll(ctx, empire.TwoFactorEnrollOpts{
+		User:     auth.UserFromContext(ctx),
+		Username: Vars(r)["username"],
+		Code:     r.Header.Get(HeaderTwoFactor),
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(201)
+	return Encode(w, newTwoFactorSecret(s))
+}
+
+// PostUserTwoFactor enrolls another user, which only admins can do.
+func (h *Server) PostUserTwoFactor(w http.ResponseWriter, r *http.Request) error {
+	return h.PostTwoFactor(w, r)
+}
+
+func (h *Server) DeleteTwoFactor(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	if err := h.TwoFactorReset(ctx, empire.TwoFactorResetOpts{
+		User:     auth.UserFromContext(ctx),
+		Username: Vars(r)["username"],
+	}); err != nil {
+		return err
+	}
+
+	return NoContent(w)
+}
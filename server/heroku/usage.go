@@ -0,0 +1,98 @@
+package heroku
+
+import (
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/remind101/empire/server/auth"
+)
+
+// usageMonthFormat is the format of the month query parameter.
+const usageMonthFormat = "2006-01"
+
+var errUsageMonth = &empire.ValidationError{Err: errors.New("month must be formatted as YYYY-MM")}
+
+type Usage heroku.Usage
+
+func newUsage(u *empire.AppUsage) *Usage {
+	return &Usage{
+		App:           u.App,
+		Team:          u.Team,
+		Month:         u.Month,
+		InstanceHours: u.InstanceHours,
+		MemoryHours:   u.MemoryHours,
+	}
+}
+
+// GetUsage reports the resources that each app, or team, reserved in a month.
+// With ?labels=, only apps with labels matching the selector are reported.
+// With ?format=csv, the report is returned as CSV, so that it can be imported
+// into a spreadsheet.
+func (h *Server) GetUsage(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	month := timex.Now()
+	if v := q.Get("month"); v != "" {
+		t, err := time.Parse(usageMonthFormat, v)
+		if err != nil {
+			return errUsageMonth
+		}
+		month = t
+	}
+
+	selector, err := empire.ParseLabelSelector(q.Get("labels"))
+	if err != nil {
+		return err
+	}
+
+	us, err := h.Usage(ctx, empire.UsageOpts{
+		User:     auth.UserFromContext(ctx),
+		Month:    month,
+		ByTeam:   q.Get("by") == "team",
+		Selector: selector,
+	})
+	if err != nil {
+		return err
+	}
+
+	if q.Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.WriteHeader(200)
+		return writeUsageCSV(w, us)
+	}
+
+	usage := make([]*Usage, len(us))
+	for i, u := range us {
+		usage[i] = newUsage(u)
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, usage)
+}
+
+func writeUsageCSV(w http.ResponseWriter, us []*empire.AppUsage) error {
+	c := csv.NewWriter(w)
+	if err := c.Write([]string{"month", "team", "app", "instance_hours", "memory_hours"}); err != nil {
+		return err
+	}
+	for _, u := range us {
+		if err := c.Write([]string{
+			u.Month.Format(usageMonthFormat),
+			u.Team,
+			u.App,
+			strconv.FormatFloat(u.InstanceHours, 'f', 2, 64),
+			strconv.FormatFloat(u.MemoryHours, 'f', 2, 64),
+		}); err != nil {
+			return err
+		}
+	}
+	c.Flush()
+	return c.Error()
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire"
+	"github.com/remind101/pkg/reporter"
+)
+
+// dynoMetadata is the JSON representation of an empire.DynoMetadata.
+type dynoMetadata struct {
+	App struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app"`
+	Release struct {
+		ID      string `json:"id"`
+		Version int    `json:"version"`
+	} `json:"release"`
+	Process     string `json:"process"`
+	Quantity    int    `json:"quantity"`
+	Constraints struct {
+		CPUShare int `json:"cpu_share"`
+		Memory   int `json:"memory"`
+		Nproc    int `json:"nproc"`
+		GPU      int `json:"gpu"`
+	} `json:"constraints"`
+	Instance *dynoInstance `json:"instance,omitempty"`
+}
+
+type dynoInstance struct {
+	ID        string    `json:"id"`
+	Number    int       `json:"number"`
+	Host      string    `json:"host"`
+	State     string    `json:"state"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newDynoMetadata(m *empire.DynoMetadata) *dynoMetadata {
+	d := &dynoMetadata{
+		Process:  m.Process,
+		Quantity: m.Quantity,
+	}
+	d.App.ID = m.App.ID
+	d.App.Name = m.App.Name
+	d.Release.ID = m.Release.ID
+	d.Release.Version = m.Release.Version
+	d.Constraints.CPUShare = int(m.Constraints.CPUShare)
+	d.Constraints.Memory = int(m.Constraints.Memory)
+	d.Constraints.Nproc = int(m.Constraints.Nproc)
+	d.Constraints.GPU = int(m.Constraints.GPU)
+	if t := m.Task; t != nil {
+		d.Instance = &dynoInstance{
+			ID:        t.ID,
+			Number:    m.Instance,
+			Host:      t.Host.ID,
+			State:     t.State,
+			UpdatedAt: t.UpdatedAt,
+		}
+	}
+	return d
+}
+
+// MetadataHandler is an http.Handler that lets processes query their own
+// metadata, using the token in EMPIRE_METADATA_TOKEN:
+//
+//	curl -H "Authorization: Bearer $EMPIRE_METADATA_TOKEN" "$EMPIRE_METADATA_URL?task=<task arn>"
+type MetadataHandler struct {
+	*empire.Empire
+}
+
+func (h *MetadataHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	m, err := h.DynoMetadata(r.Context(), empire.DynoMetadataOpts{
+		Token: token,
+		Task:  r.URL.Query().Get("task"),
+	})
+	switch err {
+	case nil:
+	case empire.ErrInvalidMetadataToken:
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case empire.ErrMetadataTask, gorm.RecordNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	default:
+		reporter.Report(r.Context(), err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newDynoMetadata(m))
+}
@@ -15,6 +15,9 @@ import (
 // * Recover from panics.
 // * Add the request id to the context.
 func Common(h http.Handler, r *realip.Resolver) http.Handler {
+	// Report panics, instead of only logging them.
+	h = Recover(h)
+
 	// Log requests to the embedded logger.
 	h = LogRequests(h)
 
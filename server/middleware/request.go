@@ -1,17 +1,26 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 
+	"github.com/remind101/empire/internal/uuid"
 	"github.com/remind101/pkg/httpx"
 	"github.com/remind101/pkg/reporter"
 )
 
 // WithRequest adds information about the http.Request to reported errors.
+// Requests that don't have a Request-Id header are given one, and the id is
+// returned in the Request-Id response header, so that clients can refer to
+// the logs and errors of a request.
 func WithRequest(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
+		if r.Header.Get("X-Request-Id") == "" && r.Header.Get("Request-Id") == "" {
+			r.Header.Set("Request-Id", uuid.New())
+		}
+
 		ctx = httpx.WithRequest(ctx, r)
 
 		// Add the request to the context.
@@ -19,7 +28,27 @@ func WithRequest(h http.Handler) http.Handler {
 
 		// Add the request id
 		reporter.AddContext(ctx, "request_id", httpx.RequestID(ctx))
+		w.Header().Set("Request-Id", httpx.RequestID(ctx))
 
 		h.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// Recover recovers from panics in the handler, reports them with the
+// information about the request, and responds with a 500.
+func Recover(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if v := recover(); v != nil {
+				err, ok := v.(error)
+				if !ok {
+					err = fmt.Errorf("panic: %v", v)
+				}
+				reporter.Report(r.Context(), err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+
+		h.ServeHTTP(w, r)
+	})
+}
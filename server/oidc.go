@@ -0,0 +1,80 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/remind101/pkg/reporter"
+)
+
+// oidcStateCookie is the name of the cookie that stores the state parameter
+// between the login redirect and the callback.
+const oidcStateCookie = "empire_oidc_state"
+
+// OIDCLogin starts an OpenID Connect login. It generates a random state, stores
+// it in a cookie, then redirects the user to the identity provider.
+func (s *Server) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if s.OIDCProvider == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	state := hex.EncodeToString(b)
+
+	url, err := s.OIDCProvider.AuthCodeURL(state)
+	if err != nil {
+		reporter.Report(r.Context(), err)
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/oidc",
+		MaxAge:   300,
+		HttpOnly: true,
+		Secure:   s.URL != nil && s.URL.Scheme == "https",
+	})
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+// OIDCCallback handles the redirect from the identity provider. It will verify
+// the state, exchange the code for an ID token, generate an API token, then
+// present the token to the user.
+func (s *Server) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if s.OIDCProvider == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if msg := r.FormValue("error"); msg != "" {
+		if desc := r.FormValue("error_description"); desc != "" {
+			msg = desc
+		}
+		http.Error(w, msg, 403)
+		return
+	}
+
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || cookie.Value == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.FormValue("state"))) != 1 {
+		http.Error(w, "invalid state", 403)
+		return
+	}
+
+	session, err := s.OIDCProvider.Exchange(r.Context(), r.FormValue("code"))
+	if err != nil {
+		reporter.Report(r.Context(), err)
+		http.Error(w, err.Error(), 403)
+		return
+	}
+
+	s.presentAccessToken(w, r, session)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/remind101/empire"
 	"github.com/remind101/empire/internal/saml"
+	"github.com/remind101/empire/server/auth"
 	samlauth "github.com/remind101/empire/server/auth/saml"
 	"github.com/remind101/empire/server/heroku"
 	"github.com/remind101/pkg/reporter"
@@ -43,8 +44,13 @@ func (s *Server) SAMLACS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session := samlauth.SessionFromAssertion(assertion)
+	session := samlauth.SessionFromAssertion(assertion, s.Groups)
+	s.presentAccessToken(w, r, session)
+}
 
+// presentAccessToken generates an API token for the session, then presents the
+// token to the user.
+func (s *Server) presentAccessToken(w http.ResponseWriter, r *http.Request, session *auth.Session) {
 	// Create an Access Token for the API.
 	at, err := s.Heroku.AccessTokensCreate(&heroku.AccessToken{
 		ExpiresAt: session.ExpiresAt,
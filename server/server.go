@@ -1,19 +1,25 @@
 // Package server provides an http.Handler implementation that includes the
-// Heroku Platform API compatibility layer, GitHub Deployments integration and a
-// simple health check.
+// Heroku Platform API compatibility layer, GitHub Deployments integration and
+// health and readiness checks.
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 	"golang.org/x/oauth2"
-	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
+	githubapi "github.com/google/go-github/github"
 	"github.com/remind101/empire"
 	"github.com/remind101/empire/internal/saml"
+	"github.com/remind101/empire/server/auth"
+	"github.com/remind101/empire/server/auth/oidc"
 	"github.com/remind101/empire/server/github"
 	"github.com/remind101/empire/server/heroku"
+	"github.com/remind101/empire/server/slack"
 )
 
 var (
@@ -30,6 +36,14 @@ type Options struct {
 			Environments []string
 			ImageBuilder github.ImageBuilder
 			TugboatURL   string
+
+			// If provided, deployment statuses are created on
+			// GitHub with this token.
+			Token string
+
+			// The URL of the GitHub API. The default is
+			// https://api.github.com/.
+			APIURL string
 		}
 		OAuth struct {
 			ClientID string
@@ -38,10 +52,30 @@ type Options struct {
 			Scopes []string
 		}
 	}
+
+	Slack struct {
+		// If provided, enables Slack slash commands, verified with
+		// this signing secret.
+		SigningSecret string
+
+		// Maps Slack user ids to Empire users.
+		Users map[string]string
+	}
+
+	Health struct {
+		// How long each dependency has to respond to a health check.
+		// The default is empire.DefaultHealthCheckTimeout.
+		Timeout time.Duration
+
+		// The dependencies that need to be healthy for /readiness to
+		// pass (e.g. database and scheduler). The default is all of
+		// them. /health only checks the database.
+		Readiness []string
+	}
 }
 
 // Server composes the Heroku API compatibility layer, the GitHub Webhooks
-// handlers and a health check as a single http.Handler.
+// handlers and the health and readiness checks as a single http.Handler.
 type Server struct {
 	// Base host for the server.
 	URL *url.URL
@@ -51,11 +85,29 @@ type Server struct {
 
 	GitHubWebhooks http.Handler
 
+	// If provided, handles Slack slash commands.
+	Slack http.Handler
+
+	// Checks that Empire is alive, which only requires the database.
 	Health *HealthHandler
 
+	// Checks that Empire is ready to serve requests, which requires every
+	// dependency that it's configured to check.
+	Readiness *HealthHandler
+
+	// If provided, processes can query their own metadata.
+	Metadata http.Handler
+
 	// If provided, enables the SAML integration.
 	ServiceProvider *saml.ServiceProvider
 
+	// If provided, enables the OpenID Connect integration.
+	OIDCProvider *oidc.Provider
+
+	// If provided, maps the groups that the SAML or OpenID Connect identity
+	// provider asserts a user is a member of, to Empire teams.
+	Groups *auth.GroupMapper
+
 	AuthConfig *oauth2.Config
 }
 
@@ -71,6 +123,13 @@ func New(e *empire.Empire, options Options) *Server {
 		})
 	}
 
+	if options.Slack.SigningSecret != "" {
+		s.Slack = slack.New(e, slack.Options{
+			SigningSecret: options.Slack.SigningSecret,
+			Users:         options.Slack.Users,
+		})
+	}
+
 	if options.GitHub.OAuth.ClientID != "" {
 		s.AuthConfig = &oauth2.Config{
 			ClientID: options.GitHub.OAuth.ClientID,
@@ -85,7 +144,12 @@ func New(e *empire.Empire, options Options) *Server {
 	}
 
 	s.Heroku = heroku.New(e)
-	s.Health = NewHealthHandler(e)
+	s.Health = NewHealthHandler(e, options.Health.Timeout, []string{empire.DependencyDatabase})
+	s.Readiness = NewHealthHandler(e, options.Health.Timeout, options.Health.Readiness)
+
+	if e.Metadata != nil {
+		s.Metadata = &MetadataHandler{Empire: e}
+	}
 
 	return s
 }
@@ -155,8 +219,24 @@ func (s *Server) handler(r *http.Request) http.Handler {
 		return http.HandlerFunc(s.SAMLLogin)
 	case "/saml/acs":
 		return http.HandlerFunc(s.SAMLACS)
+	case "/oidc/login":
+		return http.HandlerFunc(s.OIDCLogin)
+	case "/oidc/callback":
+		return http.HandlerFunc(s.OIDCCallback)
 	case "/health":
 		return s.Health
+	case "/readiness":
+		return s.Readiness
+	case "/openapi.json":
+		return http.HandlerFunc(s.OpenAPI)
+	case "/metadata":
+		if s.Metadata != nil {
+			return s.Metadata
+		}
+	case "/slack/commands":
+		if s.Slack != nil {
+			return s.Slack
+		}
 
 	// These endpoints get hit by clients using the browser in order to do the web-flow
 	// version of authentication
@@ -174,29 +254,85 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.ServeHTTP(w, r)
 }
 
-// HealthHandler is an http.Handler that returns the health of empire.
+// OpenAPI responds with the OpenAPI document that describes the Heroku API, so
+// that clients can be generated from it.
+func (s *Server) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Heroku.OpenAPI())
+}
+
+// HealthHandler is an http.Handler that checks the dependencies of empire, and
+// responds with the status of each of them. It responds with a 503 if any of
+// them are unhealthy.
 type HealthHandler struct {
-	// A function that returns true if empire is healthy.
-	IsHealthy func() error
+	// The dependencies to check.
+	Checks []empire.DependencyCheck
+
+	// How long each dependency has to respond.
+	Timeout time.Duration
 }
 
-// NewHealthHandler returns a new HealthHandler using the IsHealthy method from
-// an Empire instance.
-func NewHealthHandler(e *empire.Empire) *HealthHandler {
+// NewHealthHandler returns a new HealthHandler that checks the named
+// dependencies of an Empire instance, or all of them if names is empty.
+func NewHealthHandler(e *empire.Empire, timeout time.Duration, names []string) *HealthHandler {
+	var checks []empire.DependencyCheck
+	for _, c := range e.DependencyChecks() {
+		if len(names) == 0 || dependencyCheckIsAny(c, names) {
+			checks = append(checks, c)
+		}
+	}
+
 	return &HealthHandler{
-		IsHealthy: e.IsHealthy,
+		Checks:  checks,
+		Timeout: timeout,
+	}
+}
+
+func dependencyCheckIsAny(c empire.DependencyCheck, names []string) bool {
+	for _, name := range names {
+		if c.Is(name) {
+			return true
+		}
 	}
+	return false
+}
+
+// healthResponse is the response body of a HealthHandler.
+type healthResponse struct {
+	Status       string             `json:"status"`
+	Dependencies []dependencyStatus `json:"dependencies"`
+}
+
+type dependencyStatus struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
 }
 
 func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	err := h.IsHealthy()
-	if err == nil {
-		w.WriteHeader(http.StatusOK)
-		return
+	resp := healthResponse{Status: "ok", Dependencies: []dependencyStatus{}}
+	for _, d := range empire.CheckHealth(r.Context(), h.Checks, h.Timeout) {
+		status := dependencyStatus{
+			Name:       d.Name,
+			Status:     "ok",
+			DurationMs: int64(d.Duration / time.Millisecond),
+		}
+		if !d.Healthy() {
+			status.Status = "unavailable"
+			status.Error = d.Err.Error()
+			resp.Status = "unavailable"
+		}
+		resp.Dependencies = append(resp.Dependencies, status)
 	}
 
-	w.WriteHeader(http.StatusServiceUnavailable)
-	io.WriteString(w, err.Error())
+	w.Header().Set("Content-Type", "application/json")
+	if resp.Status == "ok" {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(resp)
 }
 
 // newDeployer generates a new github.Deployer implementation for the given
@@ -213,9 +349,27 @@ func newDeployer(e *empire.Empire, options Options) github.Deployer {
 		d = github.NotifyTugboat(d, url)
 	}
 
+	// Reports the status of deployments back to GitHub.
+	if token := options.GitHub.Deployments.Token; token != "" {
+		d = github.NotifyGitHub(d, newGitHubClient(token, options.GitHub.Deployments.APIURL))
+	}
+
 	// Perform the deployment within a go routine so we don't timeout
 	// githubs webhook requests.
 	d = github.DeployAsync(d)
 
 	return d
 }
+
+// newGitHubClient returns a client for the GitHub API, that authenticates with
+// the token.
+func newGitHubClient(token, apiURL string) *githubapi.Client {
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	c := githubapi.NewClient(oauth2.NewClient(oauth2.NoContext, ts))
+	if apiURL != "" {
+		if u, err := url.Parse(strings.TrimSuffix(apiURL, "/") + "/"); err == nil {
+			c.BaseURL = u
+		}
+	}
+	return c
+}
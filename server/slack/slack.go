@@ -0,0 +1,359 @@
+// Package slack provides an http.Handler implementation that handles Slack
+// slash commands, so that routine operations, like deploying, scaling and
+// listing processes, can be performed from Slack:
+//
+//	/empire deploy acme-inc remind101/acme-inc:master
+//	/empire scale acme-inc web=5 worker=2
+//	/empire ps acme-inc
+//
+// Slack users are mapped to Empire users, and commands are authorized as the
+// Empire user, with the same RBAC as the API.
+package slack
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/image"
+	"github.com/remind101/pkg/reporter"
+	"golang.org/x/net/context"
+)
+
+const (
+	// HeaderSignature is the header that Slack signs requests with.
+	HeaderSignature = "X-Slack-Signature"
+
+	// HeaderTimestamp is the header that includes the time that Slack sent
+	// the request.
+	HeaderTimestamp = "X-Slack-Request-Timestamp"
+
+	// MaxRequestAge is how old a request can be before it's rejected, to
+	// prevent signed requests from being replayed.
+	MaxRequestAge = 5 * time.Minute
+)
+
+// Usage is returned for commands that aren't recognized.
+const Usage = "Usage:\n" +
+	"`/empire deploy <app> <image>` deploys a Docker image to an app\n" +
+	"`/empire scale <app> <process>=<quantity>...` scales the processes of an app\n" +
+	"`/empire ps <app>` lists the running processes of an app"
+
+type Options struct {
+	// The signing secret of the Slack app, to ensure that the request was
+	// sent from Slack.
+	SigningSecret string
+
+	// Maps Slack user ids to Empire users. Commands from Slack users that
+	// aren't mapped are rejected.
+	Users map[string]string
+}
+
+// empireClient mocks the Empire interface we use.
+type empireClient interface {
+	AppsFind(empire.AppsQuery) (*empire.App, error)
+	Authorize(*empire.User, *empire.App, string) error
+	Deploy(context.Context, empire.DeployOpts) (*empire.Release, error)
+	Scale(context.Context, empire.ScaleOpts) ([]*empire.Process, error)
+	Tasks(context.Context, empire.TasksQuery) ([]*empire.Task, error)
+}
+
+// CommandHandler is an http.Handler that handles Slack slash commands.
+type CommandHandler struct {
+	empire empireClient
+
+	secret string
+	users  map[string]string
+
+	// Used to post responses to commands that finish after Slack stops
+	// waiting for a response.
+	client *http.Client
+
+	// Returns the current time. The zero value is time.Now.
+	now func() time.Time
+}
+
+// New returns a new CommandHandler instance.
+func New(e *empire.Empire, opts Options) *CommandHandler {
+	return &CommandHandler{
+		empire: e,
+		secret: opts.SigningSecret,
+		users:  opts.Users,
+		client: http.DefaultClient,
+	}
+}
+
+// Response is a message that's sent back to Slack.
+type Response struct {
+	// "in_channel" shows the response to everyone in the channel, so
+	// that others can see what was done during an incident.
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+// command is a parsed slash command.
+type command struct {
+	user        *empire.User
+	text        string
+	args        []string
+	responseURL string
+}
+
+func (h *CommandHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.verify(r.Header, body); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var text string
+	name, ok := h.users[form.Get("user_id")]
+	if !ok {
+		text = fmt.Sprintf("Your Slack user (%s) isn't mapped to an Empire user.", form.Get("user_name"))
+	} else {
+		text = h.run(r.Context(), &command{
+			user:        &empire.User{Name: name},
+			text:        form.Get("text"),
+			args:        strings.Fields(form.Get("text")),
+			responseURL: form.Get("response_url"),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&Response{ResponseType: "in_channel", Text: text})
+}
+
+// verify returns an error if the request wasn't signed with the signing
+// secret, or was signed too long ago.
+//
+// See https://api.slack.com/authentication/verifying-requests-from-slack
+func (h *CommandHandler) verify(header http.Header, body []byte) error {
+	ts := header.Get(HeaderTimestamp)
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s header", HeaderTimestamp)
+	}
+
+	now := time.Now
+	if h.now != nil {
+		now = h.now
+	}
+	if age := now().Sub(time.Unix(sec, 0)); age > MaxRequestAge || age < -MaxRequestAge {
+		return fmt.Errorf("request is too old")
+	}
+
+	mac := hmac.New(sha256.New, []byte(h.secret))
+	fmt.Fprintf(mac, "v0:%s:%s", ts, body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get(HeaderSignature))) {
+		return fmt.Errorf("invalid %s header", HeaderSignature)
+	}
+
+	return nil
+}
+
+// run runs the command, and returns the text to respond with.
+func (h *CommandHandler) run(ctx context.Context, cmd *command) string {
+	if len(cmd.args) < 2 {
+		return Usage
+	}
+
+	var fn func(context.Context, *command, *empire.App) (string, error)
+	switch cmd.args[0] {
+	case "deploy":
+		fn = h.deploy
+	case "scale":
+		fn = h.scale
+	case "ps":
+		fn = h.ps
+	default:
+		return Usage
+	}
+
+	name := cmd.args[1]
+	app, err := h.empire.AppsFind(empire.AppsQuery{Name: &name})
+	if err == nil {
+		// Every command requires at least read access to the app.
+		// Mutations are further checked by Empire.
+		err = h.empire.Authorize(cmd.user, app, empire.RoleViewer)
+	}
+	if err == nil {
+		var text string
+		text, err = fn(ctx, cmd, app)
+		if err == nil {
+			return text
+		}
+	}
+
+	if err == gorm.RecordNotFound {
+		return fmt.Sprintf("App %s not found.", name)
+	}
+	return fmt.Sprintf("Error: %v", err)
+}
+
+// deploy deploys an image to the app. Slack only waits 3 seconds for a
+// response, so the deploy happens in the background, and the result is posted
+// to the response url when it finishes.
+func (h *CommandHandler) deploy(ctx context.Context, cmd *command, app *empire.App) (string, error) {
+	if len(cmd.args) != 3 {
+		return Usage, nil
+	}
+
+	img, err := image.Decode(cmd.args[2])
+	if err != nil {
+		return "", err
+	}
+
+	// The deploy outlives the request, so it keeps the values of the
+	// request context (like the request id and the error reporter), but
+	// isn't canceled when the request finishes.
+	ctx = withoutCancel(ctx)
+
+	go func() {
+		var text string
+		r, err := h.empire.Deploy(ctx, empire.DeployOpts{
+			User:    cmd.user,
+			App:     app,
+			Image:   img,
+			Output:  empire.NewDeploymentStream(ioutil.Discard),
+			Message: message(cmd),
+		})
+		if err != nil {
+			text = fmt.Sprintf("Deploy of %s to %s failed: %v", img, app.Name, err)
+		} else {
+			text = fmt.Sprintf("Deployed %s to %s as v%d", img, app.Name, r.Version)
+		}
+		if err := h.respond(cmd.responseURL, text); err != nil {
+			reporter.Report(ctx, fmt.Errorf("slack: error posting the result of the deploy of %s to %s: %v", img, app.Name, err))
+		}
+	}()
+
+	return fmt.Sprintf("Deploying %s to %s...", img, app.Name), nil
+}
+
+// scale scales the processes of the app.
+func (h *CommandHandler) scale(ctx context.Context, cmd *command, app *empire.App) (string, error) {
+	if len(cmd.args) < 3 {
+		return Usage, nil
+	}
+
+	var updates []*empire.ProcessUpdate
+	for _, arg := range cmd.args[2:] {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			return "", fmt.Errorf("invalid scale %q: must be <process>=<quantity>", arg)
+		}
+		quantity, err := strconv.Atoi(parts[1])
+		if err != nil || quantity < 0 {
+			return "", fmt.Errorf("invalid quantity %q for %s", parts[1], parts[0])
+		}
+
+		// Scaling a protected app down requires a two factor code,
+		// which can't be provided from Slack.
+		if quantity == 0 && app.Protected {
+			return "", fmt.Errorf("%s is protected, so scaling %s to 0 must be done with the CLI", app.Name, parts[0])
+		}
+
+		updates = append(updates, &empire.ProcessUpdate{Process: parts[0], Quantity: quantity})
+	}
+
+	ps, err := h.empire.Scale(ctx, empire.ScaleOpts{
+		User:    cmd.user,
+		App:     app,
+		Updates: updates,
+		Message: message(cmd),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var scaled []string
+	for i, p := range ps {
+		scaled = append(scaled, fmt.Sprintf("%s=%d", updates[i].Process, p.Quantity))
+	}
+	return fmt.Sprintf("Scaled %s to %s", app.Name, strings.Join(scaled, " ")), nil
+}
+
+// ps lists the running processes of the app.
+func (h *CommandHandler) ps(ctx context.Context, cmd *command, app *empire.App) (string, error) {
+	tasks, err := h.empire.Tasks(ctx, empire.TasksQuery{App: app})
+	if err != nil {
+		return "", err
+	}
+
+	if len(tasks) == 0 {
+		return fmt.Sprintf("%s has no running processes.", app.Name), nil
+	}
+
+	var b bytes.Buffer
+	tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	for _, t := range tasks {
+		fmt.Fprintf(tw, "v%d\t%s\t%s\t%s\n", t.Version, t.Name, t.State, t.UpdatedAt.Format(time.RFC3339))
+	}
+	tw.Flush()
+
+	return "```\n" + b.String() + "```", nil
+}
+
+// respond posts a response to the response url of a command.
+func (h *CommandHandler) respond(responseURL, text string) error {
+	raw, err := json.Marshal(&Response{ResponseType: "in_channel", Text: text})
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.client.Post(responseURL, "application/json", bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// withoutCancel returns a context.Context with the values of ctx, that's never
+// canceled and has no deadline.
+func withoutCancel(ctx context.Context) context.Context {
+	return valuesContext{ctx}
+}
+
+type valuesContext struct {
+	values context.Context
+}
+
+func (valuesContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (valuesContext) Done() <-chan struct{}               { return nil }
+func (valuesContext) Err() error                          { return nil }
+func (c valuesContext) Value(key interface{}) interface{} { return c.values.Value(key) }
+
+// message returns the commit message for changes made by a command.
+func message(cmd *command) string {
+	return fmt.Sprintf("Slack: /empire %s", cmd.text)
+}
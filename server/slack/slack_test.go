@@ -0,0 +1,308 @@
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/image"
+	"github.com/remind101/pkg/reporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/net/context"
+)
+
+const testSecret = "secret"
+
+var (
+	testNow  = time.Unix(1531420618, 0)
+	testApp  = &empire.App{ID: "1", Name: "acme-inc"}
+	ejholmes = &empire.User{Name: "ejholmes"}
+)
+
+func TestCommandHandler_Unsigned(t *testing.T) {
+	h := newTestHandler(new(mockEmpire))
+
+	tests := []struct {
+		timestamp time.Time
+		secret    string
+	}{
+		{testNow, "foo"},
+		{testNow.Add(-10 * time.Minute), testSecret},
+	}
+
+	for _, tt := range tests {
+		req := newRequest(url.Values{"user_id": {"U1"}, "text": {"ps acme-inc"}}, tt.timestamp, tt.secret)
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	}
+}
+
+func TestCommandHandler_UnmappedUser(t *testing.T) {
+	h := newTestHandler(new(mockEmpire))
+
+	text := serve(t, h, url.Values{"user_id": {"U2"}, "user_name": {"mallory"}, "text": {"ps acme-inc"}})
+	assert.Equal(t, "Your Slack user (mallory) isn't mapped to an Empire user.", text)
+}
+
+func TestCommandHandler_Usage(t *testing.T) {
+	h := newTestHandler(new(mockEmpire))
+
+	for _, cmd := range []string{"", "help", "ps", "restart acme-inc"} {
+		assert.Equal(t, Usage, serve(t, h, url.Values{"user_id": {"U1"}, "text": {cmd}}))
+	}
+}
+
+func TestCommandHandler_NotFound(t *testing.T) {
+	e := new(mockEmpire)
+	h := newTestHandler(e)
+
+	name := "acme-inc"
+	e.On("AppsFind", empire.AppsQuery{Name: &name}).Return(nil, gorm.RecordNotFound)
+
+	text := serve(t, h, url.Values{"user_id": {"U1"}, "text": {"ps acme-inc"}})
+	assert.Equal(t, "App acme-inc not found.", text)
+
+	e.AssertExpectations(t)
+}
+
+func TestCommandHandler_Forbidden(t *testing.T) {
+	e := new(mockEmpire)
+	h := newTestHandler(e)
+
+	name := "acme-inc"
+	e.On("AppsFind", empire.AppsQuery{Name: &name}).Return(testApp, nil)
+	e.On("Authorize", ejholmes, testApp, empire.RoleViewer).Return(&empire.ForbiddenError{User: "ejholmes", Role: empire.RoleViewer, App: testApp})
+
+	text := serve(t, h, url.Values{"user_id": {"U1"}, "text": {"ps acme-inc"}})
+	assert.True(t, strings.HasPrefix(text, "Error: "), text)
+
+	e.AssertExpectations(t)
+}
+
+func TestCommandHandler_Ps(t *testing.T) {
+	e := new(mockEmpire)
+	h := newTestHandler(e)
+
+	e.expectApp()
+	e.On("Tasks", empire.TasksQuery{App: testApp}).Return([]*empire.Task{
+		{Name: "web.1", Version: 2, State: "RUNNING", UpdatedAt: testNow.UTC()},
+		{Name: "worker.1", Version: 2, State: "PENDING", UpdatedAt: testNow.UTC()},
+	}, nil)
+
+	text := serve(t, h, url.Values{"user_id": {"U1"}, "text": {"ps acme-inc"}})
+	assert.Equal(t, "```\n"+
+		"v2  web.1     RUNNING  2018-07-12T18:36:58Z\n"+
+		"v2  worker.1  PENDING  2018-07-12T18:36:58Z\n"+
+		"```", text)
+
+	e.AssertExpectations(t)
+}
+
+func TestCommandHandler_Scale(t *testing.T) {
+	e := new(mockEmpire)
+	h := newTestHandler(e)
+
+	e.expectApp()
+	e.On("Scale", empire.ScaleOpts{
+		User: ejholmes,
+		App:  testApp,
+		Updates: []*empire.ProcessUpdate{
+			{Process: "web", Quantity: 5},
+			{Process: "worker", Quantity: 2},
+		},
+		Message: "Slack: /empire scale acme-inc web=5 worker=2",
+	}).Return([]*empire.Process{{Quantity: 5}, {Quantity: 2}}, nil)
+
+	text := serve(t, h, url.Values{"user_id": {"U1"}, "text": {"scale acme-inc web=5 worker=2"}})
+	assert.Equal(t, "Scaled acme-inc to web=5 worker=2", text)
+
+	e.AssertExpectations(t)
+}
+
+func TestCommandHandler_Scale_Invalid(t *testing.T) {
+	e := new(mockEmpire)
+	h := newTestHandler(e)
+
+	e.expectApp()
+
+	text := serve(t, h, url.Values{"user_id": {"U1"}, "text": {"scale acme-inc web=five"}})
+	assert.Equal(t, `Error: invalid quantity "five" for web`, text)
+
+	e.AssertExpectations(t)
+}
+
+func TestCommandHandler_Deploy(t *testing.T) {
+	responses := make(chan Response, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resp Response
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&resp))
+		responses <- resp
+	}))
+	defer s.Close()
+
+	e := new(mockEmpire)
+	h := newTestHandler(e)
+
+	e.expectApp()
+	e.On("Deploy", empire.DeployOpts{
+		User:    ejholmes,
+		App:     testApp,
+		Image:   image.Image{Repository: "remind101/acme-inc", Tag: "master"},
+		Message: "Slack: /empire deploy acme-inc remind101/acme-inc:master",
+	}).Return(&empire.Release{Version: 3}, nil)
+
+	text := serve(t, h, url.Values{"user_id": {"U1"}, "text": {"deploy acme-inc remind101/acme-inc:master"}, "response_url": {s.URL}})
+	assert.Equal(t, "Deploying remind101/acme-inc:master to acme-inc...", text)
+
+	select {
+	case resp := <-responses:
+		assert.Equal(t, Response{ResponseType: "in_channel", Text: "Deployed remind101/acme-inc:master to acme-inc as v3"}, resp)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the deploy to be reported")
+	}
+
+	e.AssertExpectations(t)
+}
+
+func TestCommandHandler_Deploy_RequestContext(t *testing.T) {
+	// Slack fails to accept the result of the deploy.
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer s.Close()
+
+	e := new(mockEmpire)
+	e.deployed = make(chan context.Context, 1)
+	h := newTestHandler(e)
+
+	e.expectApp()
+	e.On("Deploy", empire.DeployOpts{
+		User:    ejholmes,
+		App:     testApp,
+		Image:   image.Image{Repository: "remind101/acme-inc", Tag: "master"},
+		Message: "Slack: /empire deploy acme-inc remind101/acme-inc:master",
+	}).Return(&empire.Release{Version: 3}, nil)
+
+	reported := make(chan error, 1)
+	ctx, cancel := context.WithCancel(reporter.WithReporter(context.Background(), reporter.ReporterFunc(func(ctx context.Context, err error) error {
+		reported <- err
+		return nil
+	})))
+
+	req := newRequest(url.Values{"user_id": {"U1"}, "text": {"deploy acme-inc remind101/acme-inc:master"}, "response_url": {s.URL}}, testNow, testSecret)
+	h.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+	// The request finishing doesn't cancel the deploy.
+	cancel()
+
+	select {
+	case ctx := <-e.deployed:
+		assert.NoError(t, ctx.Err())
+		_, ok := reporter.FromContext(ctx)
+		assert.True(t, ok, "the deploy should have the values of the request context")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the deploy")
+	}
+
+	select {
+	case err := <-reported:
+		assert.EqualError(t, err, "slack: error posting the result of the deploy of remind101/acme-inc:master to acme-inc: unexpected response status 500")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the failed response to be reported")
+	}
+
+	e.AssertExpectations(t)
+}
+
+func newTestHandler(e *mockEmpire) *CommandHandler {
+	return &CommandHandler{
+		empire: e,
+		secret: testSecret,
+		users:  map[string]string{"U1": "ejholmes"},
+		client: http.DefaultClient,
+		now:    func() time.Time { return testNow },
+	}
+}
+
+// serve sends a signed slash command to the handler, and returns the text of
+// the response.
+func serve(t testing.TB, h http.Handler, form url.Values) string {
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, newRequest(form, testNow, testSecret))
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var r Response
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&r))
+	assert.Equal(t, "in_channel", r.ResponseType)
+	return r.Text
+}
+
+func newRequest(form url.Values, timestamp time.Time, secret string) *http.Request {
+	body := form.Encode()
+	ts := fmt.Sprintf("%d", timestamp.Unix())
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", ts, body)
+
+	req, _ := http.NewRequest("POST", "/slack/commands", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderSignature, "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+type mockEmpire struct {
+	mock.Mock
+
+	// If provided, the context of each deploy is sent here.
+	deployed chan context.Context
+}
+
+// expectApp expects acme-inc to be found, and ejholmes to be able to view it.
+func (m *mockEmpire) expectApp() {
+	name := testApp.Name
+	m.On("AppsFind", empire.AppsQuery{Name: &name}).Return(testApp, nil)
+	m.On("Authorize", ejholmes, testApp, empire.RoleViewer).Return(nil)
+}
+
+func (m *mockEmpire) AppsFind(q empire.AppsQuery) (*empire.App, error) {
+	args := m.Called(q)
+	app, _ := args.Get(0).(*empire.App)
+	return app, args.Error(1)
+}
+
+func (m *mockEmpire) Authorize(user *empire.User, app *empire.App, role string) error {
+	args := m.Called(user, app, role)
+	return args.Error(0)
+}
+
+func (m *mockEmpire) Deploy(ctx context.Context, opts empire.DeployOpts) (*empire.Release, error) {
+	if m.deployed != nil {
+		m.deployed <- ctx
+	}
+	opts.Output = nil
+	args := m.Called(opts)
+	return args.Get(0).(*empire.Release), args.Error(1)
+}
+
+func (m *mockEmpire) Scale(ctx context.Context, opts empire.ScaleOpts) ([]*empire.Process, error) {
+	args := m.Called(opts)
+	return args.Get(0).([]*empire.Process), args.Error(1)
+}
+
+func (m *mockEmpire) Tasks(ctx context.Context, q empire.TasksQuery) ([]*empire.Task, error) {
+	args := m.Called(q)
+	return args.Get(0).([]*empire.Task), args.Error(1)
+}
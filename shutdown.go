@@ -0,0 +1,86 @@
+package empire
+
+import (
+	"errors"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// ErrShuttingDown is returned when a deploy, or anything else that changes
+// what's scheduled, is started after Empire has started shutting down.
+var ErrShuttingDown = errors.New("Empire is shutting down, try again in a moment")
+
+// Waiter is implemented by things that finish work in the background, after
+// the call that started it has returned (e.g. a scheduler that waits for an
+// earlier stack update before submitting the next one). Empire waits for them
+// when it shuts down.
+type Waiter interface {
+	Wait(ctx context.Context) error
+}
+
+// operations tracks the operations that change what's scheduled, so that they
+// can finish before Empire exits.
+type operations struct {
+	sync.Mutex
+	wg      sync.WaitGroup
+	stopped bool
+}
+
+// start starts an operation, and returns a function that finishes it. Once the
+// operations have been stopped, ErrShuttingDown is returned instead.
+func (o *operations) start() (func(), error) {
+	o.Lock()
+	defer o.Unlock()
+
+	if o.stopped {
+		return nil, ErrShuttingDown
+	}
+
+	o.wg.Add(1)
+	return o.wg.Done, nil
+}
+
+// stop stops new operations from starting, and waits for the ones that already
+// started to finish, or for ctx to be done.
+func (o *operations) stop(ctx context.Context) error {
+	o.Lock()
+	o.stopped = true
+	o.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		o.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown shuts Empire down cleanly. New deploys, rollbacks, config changes,
+// scales, restarts and runs are refused with ErrShuttingDown, and the ones in
+// progress are waited for, followed by the Waiters. Then, the connections to
+// the database are closed. If ctx is done before everything finishes, the
+// connections are closed anyway, and an error is returned.
+func (e *Empire) Shutdown(ctx context.Context) error {
+	err := e.ops.stop(ctx)
+
+	if err == nil {
+		for _, w := range e.Waiters {
+			if err = w.Wait(ctx); err != nil {
+				break
+			}
+		}
+	}
+
+	if cerr := e.DB.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
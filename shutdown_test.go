@@ -0,0 +1,40 @@
+package empire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestOperations(t *testing.T) {
+	var o operations
+
+	done, err := o.start()
+	assert.NoError(t, err)
+
+	// Stopping waits for the operation in progress.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, o.stop(ctx))
+
+	// New operations are refused once stopped.
+	_, err = o.start()
+	assert.Equal(t, ErrShuttingDown, err)
+
+	done()
+	assert.NoError(t, o.stop(context.Background()))
+}
+
+func TestEmpire_Run_ShuttingDown(t *testing.T) {
+	e := &Empire{}
+	assert.NoError(t, e.ops.stop(context.Background()))
+
+	err := e.Run(context.Background(), RunOpts{
+		User:    &User{Name: "ejholmes"},
+		App:     &App{Name: "acme-inc"},
+		Command: Command{"bash"},
+	})
+	assert.Equal(t, ErrShuttingDown, err)
+}
@@ -1,6 +1,9 @@
 package empire
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/jinzhu/gorm"
@@ -19,6 +22,62 @@ type Slug struct {
 
 	// The raw Procfile that was extracted from the Docker image.
 	Procfile []byte
+
+	// The digests of the layers of the Docker image, base layer first. Nil
+	// if the ImageRegistry doesn't implement the LayerExtractor interface.
+	Layers Layers
+
+	// Where the image came from, if it was provided when deploying.
+	Provenance Provenance
+}
+
+// Layers are the digests of the layers of a Docker image.
+type Layers []string
+
+// Scan implements the sql.Scanner interface.
+func (l *Layers) Scan(src interface{}) error {
+	if src == nil {
+		*l = nil
+		return nil
+	}
+
+	bytes, ok := src.([]byte)
+	if !ok {
+		return error(errors.New("Scan source was not []bytes"))
+	}
+
+	var layers Layers
+	if err := json.Unmarshal(bytes, &layers); err != nil {
+		return err
+	}
+	*l = layers
+
+	return nil
+}
+
+// Value implements the driver.Value interface.
+func (l Layers) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.Value(raw), nil
+}
+
+// Contains returns true if the layer with the given digest is one of the
+// layers.
+func (l Layers) Contains(digest string) bool {
+	for _, d := range l {
+		if d == digest {
+			return true
+		}
+	}
+	return false
 }
 
 // ParsedProcfile returns the parsed Procfile.
@@ -42,8 +101,8 @@ type slugsService struct {
 }
 
 // SlugsCreateByImage creates a Slug for the given image.
-func (s *slugsService) Create(ctx context.Context, db *gorm.DB, img image.Image, w *DeploymentStream) (*Slug, error) {
-	return slugsCreateByImage(ctx, db, s.ImageRegistry, img, w)
+func (s *slugsService) Create(ctx context.Context, db *gorm.DB, img image.Image, provenance Provenance, w *DeploymentStream) (*Slug, error) {
+	return slugsCreateByImage(ctx, db, s.ImageRegistry, img, provenance, w)
 }
 
 // slugsCreate inserts a Slug into the database.
@@ -54,9 +113,9 @@ func slugsCreate(db *gorm.DB, slug *Slug) (*Slug, error) {
 // SlugsCreateByImage first attempts to find a matching slug for the image. If
 // it's not found, it will fallback to extracting the process types using the
 // provided extractor, then create a slug.
-func slugsCreateByImage(ctx context.Context, db *gorm.DB, r ImageRegistry, img image.Image, w *DeploymentStream) (*Slug, error) {
+func slugsCreateByImage(ctx context.Context, db *gorm.DB, r ImageRegistry, img image.Image, provenance Provenance, w *DeploymentStream) (*Slug, error) {
 	var (
-		slug Slug
+		slug = Slug{Provenance: provenance}
 		err  error
 	)
 
@@ -70,5 +129,12 @@ func slugsCreateByImage(ctx context.Context, db *gorm.DB, r ImageRegistry, img i
 		return nil, fmt.Errorf("extracting Procfile from %s: %v", slug.Image, err)
 	}
 
+	if e, ok := r.(LayerExtractor); ok {
+		slug.Layers, err = e.ExtractLayers(ctx, slug.Image)
+		if err != nil {
+			return nil, fmt.Errorf("extracting layers from %s: %v", slug.Image, err)
+		}
+	}
+
 	return slugsCreate(db, &slug)
 }
@@ -0,0 +1,241 @@
+package empire
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/twelvefactor"
+	"github.com/remind101/pkg/reporter"
+	"golang.org/x/net/context"
+)
+
+// Capacity pools that a process can be placed on.
+const (
+	CapacitySpot     = twelvefactor.CapacitySpot
+	CapacityOnDemand = twelvefactor.CapacityOnDemand
+)
+
+// Capacities are the valid capacity pools.
+var Capacities = []string{CapacitySpot, CapacityOnDemand}
+
+// placeCapacity moves the spot processes in the manifest, and its previous
+// manifest, to on-demand capacity when the scheduler has no spot machines that
+// instances can be placed on, so that they keep running while spot capacity
+// is reclaimed.
+func placeCapacity(ctx context.Context, s twelvefactor.Scheduler, a *twelvefactor.Manifest) error {
+	if !hasSpot(a) && (a.Previous == nil || !hasSpot(a.Previous)) {
+		return nil
+	}
+
+	available, err := spotAvailable(ctx, s)
+	if err != nil || available {
+		return err
+	}
+
+	for _, m := range []*twelvefactor.Manifest{a, a.Previous} {
+		if m == nil {
+			continue
+		}
+		for _, p := range m.Processes {
+			if p.Capacity == CapacitySpot {
+				p.Capacity = CapacityOnDemand
+			}
+		}
+	}
+	return nil
+}
+
+// spotAvailable returns true if the scheduler has a spot machine that isn't
+// lost, or being reclaimed.
+func spotAvailable(ctx context.Context, s twelvefactor.Scheduler) (bool, error) {
+	machines, err := s.Machines(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, m := range machines {
+		if m.Host.Capacity == CapacitySpot && !m.Host.Lost && !m.Host.Draining {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func hasSpot(a *twelvefactor.Manifest) bool {
+	for _, p := range a.Processes {
+		if p.Capacity == CapacitySpot {
+			return true
+		}
+	}
+	return false
+}
+
+// spotProcesses returns the names of the processes in the formation that are
+// placed on spot capacity, sorted by name.
+func spotProcesses(f Formation) []string {
+	var names []string
+	for name, p := range f {
+		if p.Capacity == CapacitySpot {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SpotRebalancer periodically checks the spot capacity in the cluster of each
+// app with spot processes. When spot capacity is reclaimed, it publishes a
+// SpotInterruptionEvent for each instance on a reclaimed host, and
+// re-releases the app so that its spot processes are rescheduled onto
+// on-demand capacity. When spot capacity is available again, the app is
+// re-released to move them back.
+type SpotRebalancer struct {
+	*Empire
+
+	// How often to check spot capacity.
+	Interval time.Duration
+
+	// Whether spot capacity was available when each app was last
+	// released, keyed by app id.
+	available map[string]bool
+
+	// The ids of the instances that interruptions have been published
+	// for.
+	interrupted map[string]bool
+}
+
+// Start starts checking spot capacity, until the context is canceled. Errors,
+// and panics, are reported to the reporter in the context.
+func (r *SpotRebalancer) Start(ctx context.Context) {
+	defer reporter.Monitor(ctx)
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Rebalance(ctx); err != nil {
+				reporter.Report(ctx, err)
+			}
+		}
+	}
+}
+
+// Rebalance publishes interruptions for the instances of spot processes on
+// reclaimed hosts, and re-releases each app with spot processes whose cluster
+// has lost, or regained, spot capacity since it was last checked. Apps are
+// re-released the first time that they're checked, since capacity may have
+// changed while nothing was checking.
+func (r *SpotRebalancer) Rebalance(ctx context.Context) error {
+	if r.available == nil {
+		r.available = make(map[string]bool)
+	}
+	interrupted := make(map[string]bool)
+
+	apps, err := apps(r.db, AppsQuery{})
+	if err != nil {
+		return err
+	}
+
+	var errors []error
+	for _, app := range apps {
+		release, err := releasesFind(r.db, ReleasesQuery{App: app})
+		if err == gorm.RecordNotFound {
+			continue
+		}
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		processes := spotProcesses(release.Formation)
+		if len(processes) == 0 {
+			delete(r.available, app.ID)
+			continue
+		}
+
+		if err := r.publishInterruptions(ctx, app, interrupted); err != nil {
+			errors = append(errors, err)
+		}
+
+		scheduler, err := r.scheduler(app)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		available, err := spotAvailable(ctx, scheduler)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		last, ok := r.available[app.ID]
+		if ok && last == available {
+			continue
+		}
+
+		if err := r.releases.Release(ctx, release, nil); err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		r.available[app.ID] = available
+
+		// Nothing moved if spot capacity was available when the app
+		// was first checked.
+		if !ok && available {
+			continue
+		}
+		if err := r.PublishEvent(CapacityFallbackEvent{
+			App:       app.Name,
+			Processes: processes,
+			Spot:      available,
+			app:       app,
+		}); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	r.interrupted = interrupted
+
+	if len(errors) > 0 {
+		return &multiError{Errors: errors}
+	}
+
+	return nil
+}
+
+// publishInterruptions publishes a SpotInterruptionEvent for each instance of
+// the app that's running on a spot host that's being reclaimed, unless one
+// was published by the last check. The ids of the instances are added to
+// interrupted.
+func (r *SpotRebalancer) publishInterruptions(ctx context.Context, app *App, interrupted map[string]bool) error {
+	tasks, err := r.tasks.appTasks(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tasks {
+		if t.Host.Capacity != CapacitySpot || !(t.Host.Draining || t.Host.Lost) || t.State == "STOPPED" {
+			continue
+		}
+
+		interrupted[t.ID] = true
+		if r.interrupted[t.ID] {
+			continue
+		}
+
+		if err := r.PublishEvent(SpotInterruptionEvent{
+			App:  app.Name,
+			PID:  t.Name,
+			Host: t.Host.ID,
+			app:  app,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
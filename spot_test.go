@@ -0,0 +1,69 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/twelvefactor"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestPlaceCapacity(t *testing.T) {
+	tests := []struct {
+		machines []*twelvefactor.Machine
+		capacity string
+	}{
+		// A spot machine is available.
+		{
+			[]*twelvefactor.Machine{
+				{Host: twelvefactor.Host{ID: "i-1", Capacity: CapacityOnDemand}},
+				{Host: twelvefactor.Host{ID: "i-2", Capacity: CapacitySpot}},
+			},
+			CapacitySpot,
+		},
+
+		// Every spot machine is being reclaimed, or was lost.
+		{
+			[]*twelvefactor.Machine{
+				{Host: twelvefactor.Host{ID: "i-1", Capacity: CapacityOnDemand}},
+				{Host: twelvefactor.Host{ID: "i-2", Capacity: CapacitySpot, Draining: true}},
+				{Host: twelvefactor.Host{ID: "i-3", Capacity: CapacitySpot, Lost: true}},
+			},
+			CapacityOnDemand,
+		},
+	}
+
+	for _, tt := range tests {
+		s := &machinesScheduler{FakeScheduler: NewFakeScheduler(), machines: tt.machines}
+
+		a := &twelvefactor.Manifest{
+			Processes: []*twelvefactor.Process{
+				{Type: "web", Capacity: CapacityOnDemand},
+				{Type: "worker", Capacity: CapacitySpot},
+			},
+			Previous: &twelvefactor.Manifest{
+				Processes: []*twelvefactor.Process{
+					{Type: "worker", Capacity: CapacitySpot},
+				},
+			},
+		}
+		err := placeCapacity(context.Background(), s, a)
+		assert.NoError(t, err)
+		assert.Equal(t, CapacityOnDemand, a.Processes[0].Capacity)
+		assert.Equal(t, tt.capacity, a.Processes[1].Capacity)
+		assert.Equal(t, tt.capacity, a.Previous.Processes[0].Capacity)
+	}
+}
+
+func TestPlaceCapacity_NoSpot(t *testing.T) {
+	s := &machinesScheduler{FakeScheduler: NewFakeScheduler()}
+
+	a := &twelvefactor.Manifest{
+		Processes: []*twelvefactor.Process{
+			{Type: "web", Capacity: CapacityOnDemand},
+		},
+	}
+	err := placeCapacity(context.Background(), s, a)
+	assert.NoError(t, err)
+	assert.False(t, s.called)
+}
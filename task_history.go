@@ -0,0 +1,591 @@
+package empire
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/headerutil"
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/remind101/empire/twelvefactor"
+	"github.com/remind101/pkg/reporter"
+	"golang.org/x/net/context"
+)
+
+// TaskHistoryRetention is how long the transitions of tasks that have stopped
+// are kept. The transitions of tasks that are still running are kept for as
+// long as they're running.
+const TaskHistoryRetention = 30 * 24 * time.Hour
+
+// The transitions in the lifecycle of a task, in the order that they happen.
+// A task ends with either TransitionCrashed, when it stopped on its own, or
+// TransitionUnscheduled, when it was stopped because of something that
+// happened outside of it (e.g. a deploy, scaling down, or a lost host).
+const (
+	TransitionScheduled    = "scheduled"
+	TransitionStarted      = "started"
+	TransitionHealthPassed = "health_passed"
+	TransitionCrashed      = "crashed"
+	TransitionUnscheduled  = "unscheduled"
+)
+
+// transitionOrder is the position of each transition in the lifecycle of a
+// task, so that the last transition of a task can be found.
+var transitionOrder = map[string]int{
+	TransitionScheduled:    1,
+	TransitionStarted:      2,
+	TransitionHealthPassed: 3,
+	TransitionCrashed:      4,
+	TransitionUnscheduled:  4,
+}
+
+// TaskTransition records a transition in the lifecycle of an instance of a
+// long running process.
+type TaskTransition struct {
+	// A unique uuid that identifies the transition.
+	ID string
+
+	// The id of the app that the task belongs to.
+	AppID string
+
+	// The process that the task is an instance of.
+	ProcessType string
+
+	// The id and name of the task.
+	TaskID string
+	PID    string
+
+	// The release that the task is running.
+	Release int
+
+	// The id of the host that the task was running on, if it was known.
+	Host string
+
+	// The transition (e.g. started).
+	State string
+
+	// Why the task was unscheduled, if it was.
+	Reason string
+
+	// The time that the transition was seen.
+	OccurredAt *time.Time
+}
+
+// BeforeCreate sets occurred_at before inserting.
+func (t *TaskTransition) BeforeCreate() error {
+	if t.OccurredAt == nil {
+		now := timex.Now()
+		t.OccurredAt = &now
+	}
+	return nil
+}
+
+// Final returns true if the task has stopped.
+func (t *TaskTransition) Final() bool {
+	return t.State == TransitionCrashed || t.State == TransitionUnscheduled
+}
+
+// next returns the transition of the same task to state.
+func (t *TaskTransition) next(state, reason string) *TaskTransition {
+	return &TaskTransition{
+		AppID:       t.AppID,
+		ProcessType: t.ProcessType,
+		TaskID:      t.TaskID,
+		PID:         t.PID,
+		Release:     t.Release,
+		Host:        t.Host,
+		State:       state,
+		Reason:      reason,
+	}
+}
+
+// TaskTransitionsQuery is a scope implementation for common things to filter
+// task transitions by.
+type TaskTransitionsQuery struct {
+	// If provided, finds transitions of the given app's tasks.
+	App *App
+
+	// If provided, finds transitions of instances of this process.
+	Process *string
+
+	// If provided, finds transitions of the task with this name (e.g.
+	// v5.web.1d2c7b0a) or id.
+	PID *string
+
+	// If provided, finds transitions of these tasks, by id.
+	TaskIDs []string
+
+	// If provided, finds transitions to this state (e.g. crashed).
+	State *string
+
+	// If true, only finds transitions of tasks that haven't stopped.
+	Open bool
+
+	// If provided, finds transitions after the given time.
+	Since *time.Time
+
+	// If provided, finds transitions before the given time.
+	Until *time.Time
+
+	// If provided, uses the limit and sorting parameters specified in the range.
+	Range headerutil.Range
+}
+
+// scope implements the scope interface.
+func (q TaskTransitionsQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.App != nil {
+		scope = append(scope, forApp(q.App))
+	}
+
+	if q.Process != nil {
+		scope = append(scope, fieldEquals("process_type", *q.Process))
+	}
+
+	if q.PID != nil {
+		pid := *q.PID
+		scope = append(scope, scopeFunc(func(db *gorm.DB) *gorm.DB {
+			return db.Where("pid = ? or task_id = ?", pid, pid)
+		}))
+	}
+
+	if len(q.TaskIDs) > 0 {
+		ids := q.TaskIDs
+		scope = append(scope, scopeFunc(func(db *gorm.DB) *gorm.DB {
+			return db.Where("task_id in (?)", ids)
+		}))
+	}
+
+	if q.State != nil {
+		scope = append(scope, fieldEquals("state", *q.State))
+	}
+
+	if q.Open {
+		scope = append(scope, scopeFunc(func(db *gorm.DB) *gorm.DB {
+			return db.Where("task_id not in (select task_id from task_transitions where state in (?, ?))", TransitionCrashed, TransitionUnscheduled)
+		}))
+	}
+
+	if q.Since != nil {
+		since := *q.Since
+		scope = append(scope, scopeFunc(func(db *gorm.DB) *gorm.DB {
+			return db.Where("occurred_at > ?", since)
+		}))
+	}
+
+	if q.Until != nil {
+		until := *q.Until
+		scope = append(scope, scopeFunc(func(db *gorm.DB) *gorm.DB {
+			return db.Where("occurred_at < ?", until)
+		}))
+	}
+
+	scope = append(scope, inRange(q.Range.WithDefaults(q.DefaultRange())))
+
+	return scope.scope(db)
+}
+
+// DefaultRange returns the default headerutil.Range used if values aren't
+// provided.
+func (q TaskTransitionsQuery) DefaultRange() headerutil.Range {
+	sort, order := "occurred_at", "desc"
+	return headerutil.Range{
+		Sort:  &sort,
+		Order: &order,
+	}
+}
+
+// taskTransitions returns all task transitions matching the scope.
+func taskTransitions(db *gorm.DB, scope scope) ([]*TaskTransition, error) {
+	var transitions []*TaskTransition
+	return transitions, find(db, scope, &transitions)
+}
+
+// taskTransitionsCreate inserts the transition. A task only goes through each
+// transition once, so a transition that was already recorded, by another
+// Empire process, isn't an error, and nil is returned.
+func taskTransitionsCreate(db *gorm.DB, t *TaskTransition) (*TaskTransition, error) {
+	if err := db.Create(t).Error; err != nil {
+		if isUniqueViolation(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+// taskTransitionsDestroyBefore removes the transitions of tasks that stopped
+// before the given time.
+func taskTransitionsDestroyBefore(db *gorm.DB, before time.Time) error {
+	return db.Where("task_id in (select task_id from task_transitions where state in (?, ?) and occurred_at < ?)", TransitionCrashed, TransitionUnscheduled, before).Delete(TaskTransition{}).Error
+}
+
+// lastTransitions returns the last transition of each task, by task id.
+func lastTransitions(transitions []*TaskTransition) map[string]*TaskTransition {
+	last := make(map[string]*TaskTransition)
+	for _, t := range transitions {
+		if l, ok := last[t.TaskID]; !ok || transitionOrder[t.State] > transitionOrder[l.State] {
+			last[t.TaskID] = t
+		}
+	}
+	return last
+}
+
+// advanceTask records the transitions that take the task from its last
+// transition, which is nil if it hasn't been seen before, to state. Tasks that
+// are seen for the first time after they've started are recorded as having
+// been scheduled and started at the same time.
+func advanceTask(db *gorm.DB, app *App, t *Task, last *TaskTransition, state string) ([]*TaskTransition, error) {
+	var from int
+	if last != nil {
+		from = transitionOrder[last.State]
+	}
+
+	var recorded []*TaskTransition
+	for _, s := range []string{TransitionScheduled, TransitionStarted, TransitionHealthPassed} {
+		if transitionOrder[s] <= from || transitionOrder[s] > transitionOrder[state] {
+			continue
+		}
+		tr, err := taskTransitionsCreate(db, newTaskTransition(app, t, s, ""))
+		if err != nil {
+			return recorded, err
+		}
+		if tr != nil {
+			recorded = append(recorded, tr)
+		}
+	}
+	return recorded, nil
+}
+
+// unscheduleTask records that Empire stopped the task, for the given reason.
+// It's recorded before the task has actually stopped, so that it isn't
+// mistaken for a crash when the TaskTracker sees that it's gone.
+func unscheduleTask(db *gorm.DB, app *App, t *Task, reason string) error {
+	_, err := taskTransitionsCreate(db, newTaskTransition(app, t, TransitionUnscheduled, reason))
+	return err
+}
+
+// unscheduleOpenTasks records that Empire stopped the tasks of the app that
+// haven't stopped, and that match the query, for the given reason.
+func unscheduleOpenTasks(db *gorm.DB, app *App, q TaskTransitionsQuery, reason string) error {
+	q.App, q.Open = app, true
+	transitions, err := taskTransitions(db, q)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range lastTransitions(transitions) {
+		if _, err := taskTransitionsCreate(db, l.next(TransitionUnscheduled, reason)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newTaskTransition(app *App, t *Task, state, reason string) *TaskTransition {
+	return &TaskTransition{
+		AppID:       app.ID,
+		ProcessType: t.Type,
+		TaskID:      t.ID,
+		PID:         t.Name,
+		Release:     t.Version,
+		Host:        t.Host.ID,
+		State:       state,
+		Reason:      reason,
+	}
+}
+
+// stoppedTransition returns the final transition of a task that stopped without
+// Empire stopping it, and the reason that it was unscheduled. Tasks on hosts
+// that were lost, or drained, were moved to another host, tasks that aren't
+// running the current release were replaced by a deploy, and tasks of a process
+// that has fewer instances than were running were scaled down, so they were
+// unscheduled. Anything else crashed. running is the number of tasks of each
+// process of the current release that had started, which is decremented for
+// each task that was unscheduled by scaling down.
+func stoppedTransition(last *TaskTransition, release *Release, host Host, running map[string]int) (string, string) {
+	if host.Lost {
+		return TransitionUnscheduled, fmt.Sprintf("host %s was lost", host.ID)
+	}
+
+	if host.Draining {
+		return TransitionUnscheduled, fmt.Sprintf("host %s was drained", host.ID)
+	}
+
+	if last.Release != release.Version {
+		return TransitionUnscheduled, fmt.Sprintf("replaced by v%d", release.Version)
+	}
+
+	p, ok := release.Formation[last.ProcessType]
+	if !ok {
+		return TransitionUnscheduled, "process was removed"
+	}
+
+	if last.State != TransitionScheduled && running[last.ProcessType] > p.Quantity {
+		running[last.ProcessType]--
+		return TransitionUnscheduled, fmt.Sprintf("scaled down to %d", p.Quantity)
+	}
+
+	return TransitionCrashed, ""
+}
+
+// TaskTracker periodically compares the tasks of the long running processes of
+// each app with the transitions that were recorded for them, and records the
+// transitions that they've gone through since: tasks that are new are
+// scheduled, tasks that are running have started, and tasks that have stopped,
+// or are gone, either crashed or were unscheduled. Tasks that passed their
+// health checks are recorded by deploys, when the scheduler reports it.
+type TaskTracker struct {
+	*Empire
+
+	// How often to look at the tasks of each app.
+	Interval time.Duration
+}
+
+// Start starts tracking tasks, until the context is canceled. Errors, and
+// panics, are reported to the reporter in the context.
+func (t *TaskTracker) Start(ctx context.Context) {
+	defer reporter.Monitor(ctx)
+
+	ticker := time.NewTicker(t.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := t.Track(ctx); err != nil {
+				reporter.Report(ctx, err)
+			}
+		}
+	}
+}
+
+// Track records the transitions of the tasks of every app since they were last
+// tracked, and removes the transitions of tasks that stopped longer than
+// TaskHistoryRetention ago. It returns the transitions that were recorded.
+func (t *TaskTracker) Track(ctx context.Context) ([]*TaskTransition, error) {
+	if err := taskTransitionsDestroyBefore(t.db, timex.Now().Add(-TaskHistoryRetention)); err != nil {
+		return nil, err
+	}
+
+	apps, err := apps(t.db, AppsQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		transitions []*TaskTransition
+		errors      []error
+	)
+	for _, app := range apps {
+		tr, err := t.trackApp(ctx, app)
+		transitions = append(transitions, tr...)
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return transitions, &multiError{Errors: errors}
+	}
+
+	return transitions, nil
+}
+
+// trackApp records the transitions of the tasks of the app.
+func (t *TaskTracker) trackApp(ctx context.Context, app *App) ([]*TaskTransition, error) {
+	release, err := releasesFind(t.db, ReleasesQuery{App: app})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	scheduler, err := t.scheduler(app)
+	if err != nil {
+		return nil, err
+	}
+
+	instances, err := scheduler.Tasks(ctx, app.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		tasks []*Task
+		ids   []string
+	)
+	for _, i := range instances {
+		if i.Process.Labels[userLabel] != "" || i.Process.Labels[cronLabel] != "" {
+			continue
+		}
+		tasks = append(tasks, taskFromInstance(i))
+		ids = append(ids, i.ID)
+	}
+
+	// The last transitions of the tasks that are there now, and of the
+	// tasks that haven't been seen to stop.
+	transitions, err := taskTransitions(t.db, TaskTransitionsQuery{App: app, Open: true})
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		current, err := taskTransitions(t.db, TaskTransitionsQuery{App: app, TaskIDs: ids})
+		if err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, current...)
+	}
+	last := lastTransitions(transitions)
+
+	running := make(map[string]int)
+	for _, l := range last {
+		if !l.Final() && l.State != TransitionScheduled && l.Release == release.Version {
+			running[l.ProcessType]++
+		}
+	}
+
+	var recorded []*TaskTransition
+	seen := make(map[string]bool)
+	for _, task := range tasks {
+		seen[task.ID] = true
+		l := last[task.ID]
+		if l != nil && l.Final() {
+			continue
+		}
+
+		if task.State == "STOPPED" {
+			if l == nil {
+				continue
+			}
+			state, reason := stoppedTransition(l, release, task.Host, running)
+			tr, err := taskTransitionsCreate(t.db, newTaskTransition(app, task, state, reason))
+			if err != nil {
+				return recorded, err
+			}
+			if tr != nil {
+				recorded = append(recorded, tr)
+			}
+			continue
+		}
+
+		state := TransitionScheduled
+		if task.State == "RUNNING" {
+			state = TransitionStarted
+		}
+		tr, err := advanceTask(t.db, app, task, l, state)
+		recorded = append(recorded, tr...)
+		if err != nil {
+			return recorded, err
+		}
+	}
+
+	// The hosts in the cluster, which are only needed when tasks are gone.
+	var hosts map[string]Host
+	for id, l := range last {
+		if seen[id] || l.Final() {
+			continue
+		}
+		if hosts == nil {
+			ms, err := machines(ctx, scheduler)
+			if err != nil {
+				return recorded, err
+			}
+			hosts = make(map[string]Host)
+			for _, m := range ms {
+				hosts[m.Host.ID] = m.Host
+			}
+		}
+		// Hosts that have left the cluster were lost.
+		host, ok := hosts[l.Host]
+		if !ok && l.Host != "" {
+			host = Host{ID: l.Host, Lost: true}
+		}
+		state, reason := stoppedTransition(l, release, host, running)
+		tr, err := taskTransitionsCreate(t.db, l.next(state, reason))
+		if err != nil {
+			return recorded, err
+		}
+		if tr != nil {
+			recorded = append(recorded, tr)
+		}
+	}
+
+	return recorded, nil
+}
+
+// taskHealthStream wraps the StatusStream of a release, and records that the
+// running tasks of a process of the release passed their health checks when
+// the scheduler reports that the process did.
+type taskHealthStream struct {
+	twelvefactor.StatusStream
+
+	ctx     context.Context
+	e       *Empire
+	release *Release
+}
+
+// recordTaskHealth wraps ss so that tasks that pass their health checks are
+// recorded. It returns nil if ss is nil, since the scheduler doesn't wait for
+// health checks to pass when there's nowhere to publish its status.
+func (e *Empire) recordTaskHealth(ctx context.Context, release *Release, ss twelvefactor.StatusStream) twelvefactor.StatusStream {
+	if ss == nil {
+		return nil
+	}
+	return &taskHealthStream{StatusStream: ss, ctx: ctx, e: e, release: release}
+}
+
+// Publish implements the twelvefactor.StatusStream interface. Errors recording
+// health checks are reported, rather than failing the deploy.
+func (s *taskHealthStream) Publish(status twelvefactor.Status) error {
+	if status.Phase == twelvefactor.PhaseHealthPassed && status.Process != "" {
+		if err := s.e.taskHealthPassed(s.ctx, s.release, status.Process); err != nil {
+			reporter.Report(s.ctx, err)
+		}
+	}
+	return s.StatusStream.Publish(status)
+}
+
+// taskHealthPassed records that the running tasks of the process of the
+// release passed their health checks.
+func (e *Empire) taskHealthPassed(ctx context.Context, release *Release, process string) error {
+	app := release.App
+	tasks, err := e.tasks.appTasks(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	var (
+		healthy []*Task
+		ids     []string
+	)
+	for _, t := range tasks {
+		if t.Type == process && t.Version == release.Version && t.State == "RUNNING" {
+			healthy = append(healthy, t)
+			ids = append(ids, t.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	transitions, err := taskTransitions(e.db, TaskTransitionsQuery{App: app, TaskIDs: ids})
+	if err != nil {
+		return err
+	}
+	last := lastTransitions(transitions)
+
+	for _, t := range healthy {
+		if l := last[t.ID]; l != nil && l.Final() {
+			continue
+		}
+		if _, err := advanceTask(e.db, app, t, last[t.ID], TransitionHealthPassed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLastTransitions(t *testing.T) {
+	transitions := []*TaskTransition{
+		{TaskID: "a", State: TransitionStarted},
+		{TaskID: "a", State: TransitionScheduled},
+		{TaskID: "b", State: TransitionScheduled},
+		{TaskID: "a", State: TransitionHealthPassed},
+		{TaskID: "b", State: TransitionCrashed},
+	}
+
+	assert.Equal(t, map[string]*TaskTransition{
+		"a": transitions[3],
+		"b": transitions[4],
+	}, lastTransitions(transitions))
+}
+
+func TestStoppedTransition(t *testing.T) {
+	release := &Release{
+		Version: 2,
+		Formation: Formation{
+			"web":    Process{Quantity: 2},
+			"worker": Process{Quantity: 1},
+		},
+	}
+
+	tests := []struct {
+		last    *TaskTransition
+		host    Host
+		running map[string]int
+		state   string
+		reason  string
+	}{
+		{
+			&TaskTransition{ProcessType: "web", Release: 2, State: TransitionStarted},
+			Host{ID: "i-1"},
+			map[string]int{"web": 2},
+			TransitionCrashed, "",
+		},
+		{
+			&TaskTransition{ProcessType: "web", Release: 2, State: TransitionStarted},
+			Host{ID: "i-1", Lost: true},
+			map[string]int{"web": 2},
+			TransitionUnscheduled, "host i-1 was lost",
+		},
+		{
+			&TaskTransition{ProcessType: "web", Release: 2, State: TransitionHealthPassed},
+			Host{ID: "i-1", Draining: true},
+			map[string]int{"web": 2},
+			TransitionUnscheduled, "host i-1 was drained",
+		},
+		{
+			&TaskTransition{ProcessType: "web", Release: 1, State: TransitionStarted},
+			Host{ID: "i-1"},
+			map[string]int{"web": 2},
+			TransitionUnscheduled, "replaced by v2",
+		},
+		{
+			&TaskTransition{ProcessType: "web", Release: 2, State: TransitionStarted},
+			Host{ID: "i-1"},
+			map[string]int{"web": 3},
+			TransitionUnscheduled, "scaled down to 2",
+		},
+		{
+			&TaskTransition{ProcessType: "scheduler", Release: 2, State: TransitionStarted},
+			Host{ID: "i-1"},
+			map[string]int{"scheduler": 1},
+			TransitionUnscheduled, "process was removed",
+		},
+	}
+
+	for _, tt := range tests {
+		state, reason := stoppedTransition(tt.last, release, tt.host, tt.running)
+		assert.Equal(t, tt.state, state)
+		assert.Equal(t, tt.reason, reason)
+	}
+
+	// Only as many tasks as were scaled down are unscheduled.
+	running := map[string]int{"web": 3}
+	last := &TaskTransition{ProcessType: "web", Release: 2, State: TransitionStarted}
+	state, _ := stoppedTransition(last, release, Host{}, running)
+	assert.Equal(t, TransitionUnscheduled, state)
+	state, _ = stoppedTransition(last, release, Host{}, running)
+	assert.Equal(t, TransitionCrashed, state)
+}
@@ -1,18 +1,44 @@
 package empire
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/remind101/empire/pkg/constraints"
+	"github.com/remind101/empire/pkg/headerutil"
+	"github.com/remind101/empire/pkg/image"
 	"github.com/remind101/empire/twelvefactor"
 	"golang.org/x/net/context"
 )
 
+// ErrInvalidTasksSort is returned when tasks are sorted by anything other than
+// their name.
+var ErrInvalidTasksSort = &ValidationError{errors.New("Tasks can only be sorted by name.")}
+
 // Host represents the host of the task
 type Host struct {
 	// the host id
 	ID string
+
+	// true if the scheduler has lost contact with the host
+	Lost bool
+
+	// true if processes on the host are being moved to other hosts
+	Draining bool
+
+	// the capacity pool that the host belongs to (e.g. "spot")
+	Capacity string
+
+	// the availability zone that the host is in (e.g. "us-east-1a")
+	Zone string
+}
+
+func newHost(h twelvefactor.Host) Host {
+	return Host{ID: h.ID, Lost: h.Lost, Draining: h.Draining, Capacity: h.Capacity, Zone: h.Zone}
 }
 
 // Task represents a running process.
@@ -20,9 +46,15 @@ type Task struct {
 	// The name of the task.
 	Name string
 
+	// The name of the app that this task belongs to.
+	App string
+
 	// The name of the process that this task is for.
 	Type string
 
+	// The release version that this task is running.
+	Version int
+
 	// The task id
 	ID string
 
@@ -32,6 +64,9 @@ type Task struct {
 	// The command that this task is running.
 	Command Command
 
+	// The image that this task is running.
+	Image image.Image
+
 	// The state of the task.
 	State string
 
@@ -42,20 +77,258 @@ type Task struct {
 	Constraints Constraints
 }
 
+// TasksQuery is used to filter the tasks for an app, or for all apps.
+type TasksQuery struct {
+	// The app to return tasks for. When listing tasks for all apps, this
+	// is nil, tasks are sorted by app then name, and Range.Start refers to
+	// `<app>/<name>`.
+	App *App
+
+	// If provided, only returns tasks for this process type.
+	Type *string
+
+	// If provided, only returns tasks running this release version.
+	Version *int
+
+	// If provided, only returns tasks in this state (e.g. RUNNING).
+	State *string
+
+	// If provided, only returns tasks running on this host.
+	Host *string
+
+	// If provided, only returns tasks running this image. When the image
+	// has no tag or digest, tasks running any version of the repository
+	// are returned.
+	Image *string
+
+	// If provided, only returns tasks for apps with labels matching the
+	// selector, when listing tasks for all apps.
+	Selector LabelSelector
+
+	// If provided, uses the limit, sorting and start parameters specified
+	// in the range. Tasks can only be sorted by name.
+	Range headerutil.Range
+}
+
+// schedulerQuery returns the filters that the scheduler can apply itself, so
+// that it doesn't have to return every task.
+func (q TasksQuery) schedulerQuery() twelvefactor.TasksQuery {
+	var sq twelvefactor.TasksQuery
+	if q.Type != nil {
+		sq.Process = *q.Type
+	}
+	if q.Host != nil {
+		sq.Host = *q.Host
+	}
+	return sq
+}
+
+// DefaultRange returns the default headerutil.Range used if values aren't
+// provided.
+func (q TasksQuery) DefaultRange() headerutil.Range {
+	sort, order := "name", "asc"
+	return headerutil.Range{
+		Sort:  &sort,
+		Order: &order,
+	}
+}
+
+// match returns true if the task matches the filters in the query.
+func (q TasksQuery) match(t *Task) bool {
+	if q.Type != nil && t.Type != *q.Type {
+		return false
+	}
+
+	if q.Version != nil && t.Version != *q.Version {
+		return false
+	}
+
+	if q.State != nil && !strings.EqualFold(t.State, *q.State) {
+		return false
+	}
+
+	if q.Host != nil && t.Host.ID != *q.Host {
+		return false
+	}
+
+	if q.Image != nil && !imageMatches(t.Image, *q.Image) {
+		return false
+	}
+
+	return true
+}
+
+// apply filters, sorts and limits the tasks.
+func (q TasksQuery) apply(tasks []*Task) ([]*Task, error) {
+	r := q.Range.WithDefaults(q.DefaultRange())
+
+	if *r.Sort != "name" {
+		return nil, ErrInvalidTasksSort
+	}
+
+	key := func(t *Task) string { return t.Name }
+	if q.App == nil {
+		key = func(t *Task) string { return t.App + "/" + t.Name }
+	}
+
+	desc := r.Order != nil && *r.Order == "desc"
+	sort.Sort(tasksByKey{tasks, key, desc})
+
+	var matched []*Task
+	for _, t := range tasks {
+		if !q.match(t) || !afterStart(key(t), r.Start, desc) {
+			continue
+		}
+
+		matched = append(matched, t)
+
+		if r.Max != nil && len(matched) == *r.Max {
+			break
+		}
+	}
+
+	return matched, nil
+}
+
+// imageMatches returns true if the image matches the filter.
+func imageMatches(img image.Image, filter string) bool {
+	f, err := image.Decode(filter)
+	if err != nil {
+		return false
+	}
+
+	if f.Tag == "" && f.Digest == "" {
+		return img.Registry == f.Registry && img.Repository == f.Repository
+	}
+
+	return img.String() == f.String()
+}
+
+// afterStart returns true if the name is at, or after, the start value in the
+// range.
+func afterStart(name string, start *string, desc bool) bool {
+	if start == nil {
+		return true
+	}
+
+	s, exclusive := *start, false
+	if strings.HasPrefix(s, "]") {
+		s, exclusive = s[1:], true
+	}
+
+	if name == s {
+		return !exclusive
+	}
+
+	if desc {
+		return name < s
+	}
+	return name > s
+}
+
+type tasksByKey struct {
+	tasks []*Task
+	key   func(*Task) string
+	desc  bool
+}
+
+func (s tasksByKey) Len() int      { return len(s.tasks) }
+func (s tasksByKey) Swap(i, j int) { s.tasks[i], s.tasks[j] = s.tasks[j], s.tasks[i] }
+func (s tasksByKey) Less(i, j int) bool {
+	if s.desc {
+		return s.key(s.tasks[i]) > s.key(s.tasks[j])
+	}
+	return s.key(s.tasks[i]) < s.key(s.tasks[j])
+}
+
 type tasksService struct {
 	*Empire
 }
 
-func (s *tasksService) Tasks(ctx context.Context, app *App) ([]*Task, error) {
+func (s *tasksService) Tasks(ctx context.Context, q TasksQuery) ([]*Task, error) {
+	scheduler, err := s.scheduler(q.App)
+	if err != nil {
+		return nil, err
+	}
+
+	sq := q.schedulerQuery()
+	sq.App = q.App.ID
+	instances, err := scheduler.QueryTasks(ctx, sq)
+	if err != nil {
+		return nil, err
+	}
+
 	var tasks []*Task
+	for _, i := range instances {
+		t := taskFromInstance(i)
+		t.App = q.App.Name
+		tasks = append(tasks, t)
+	}
+
+	return q.apply(tasks)
+}
 
-	instances, err := s.Scheduler.Tasks(ctx, app.ID)
+// ClusterTasks returns the tasks for all apps, matching the query. The
+// scheduler of each cluster is asked for the tasks of every app at once, rather
+// than asking for the tasks of each app.
+func (s *tasksService) ClusterTasks(ctx context.Context, q TasksQuery) ([]*Task, error) {
+	apps, err := apps(s.dbr, AppsQuery{Selector: q.Selector})
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*App)
+	for _, app := range apps {
+		byID[app.ID] = app
+	}
+
+	schedulers := map[string]Scheduler{"": s.Scheduler}
+	for cluster, scheduler := range s.Clusters {
+		schedulers[cluster] = scheduler
+	}
+
+	var tasks []*Task
+	for cluster, scheduler := range schedulers {
+		instances, err := scheduler.QueryTasks(ctx, q.schedulerQuery())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, i := range instances {
+			// Skip tasks of apps that weren't selected, or that
+			// belong to another cluster, in case schedulers are
+			// shared between clusters.
+			app, ok := byID[i.App]
+			if !ok || app.Cluster != cluster {
+				continue
+			}
+
+			t := taskFromInstance(i)
+			t.App = app.Name
+			tasks = append(tasks, t)
+		}
+	}
+
+	return q.apply(tasks)
+}
+
+func (s *tasksService) appTasks(ctx context.Context, app *App) ([]*Task, error) {
+	var tasks []*Task
+
+	scheduler, err := s.scheduler(app)
+	if err != nil {
+		return tasks, err
+	}
+
+	instances, err := scheduler.Tasks(ctx, app.ID)
 	if err != nil {
 		return tasks, err
 	}
 
 	for _, i := range instances {
-		tasks = append(tasks, taskFromInstance(i))
+		t := taskFromInstance(i)
+		t.App = app.Name
+		tasks = append(tasks, t)
 	}
 
 	return tasks, nil
@@ -70,15 +343,21 @@ func taskFromInstance(i *twelvefactor.Task) *Task {
 		version = "v0"
 	}
 
+	v, _ := strconv.Atoi(strings.TrimPrefix(version, "v"))
+
 	return &Task{
 		Name:    fmt.Sprintf("%s.%s.%s", version, i.Process.Type, i.ID),
 		Type:    string(i.Process.Type),
-		Host:    Host{ID: i.Host.ID},
+		Version: v,
+		ID:      i.ID,
+		Host:    newHost(i.Host),
 		Command: Command(i.Process.Command),
+		Image:   i.Process.Image,
 		Constraints: Constraints{
 			CPUShare: constraints.CPUShare(i.Process.CPUShares),
 			Memory:   constraints.Memory(i.Process.Memory),
 			Nproc:    constraints.Nproc(i.Process.Nproc),
+			GPU:      constraints.GPU(i.Process.GPUs),
 		},
 		State:     i.State,
 		UpdatedAt: i.UpdatedAt,
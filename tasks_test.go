@@ -0,0 +1,103 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/pkg/headerutil"
+	"github.com/remind101/empire/pkg/image"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTasksQuery(t *testing.T) {
+	tasks := func() []*Task {
+		return []*Task{
+			{Name: "v2.web.c", Type: "web", Version: 2, State: "RUNNING", Host: Host{ID: "i-2"}},
+			{Name: "v1.web.a", Type: "web", Version: 1, State: "RUNNING", Host: Host{ID: "i-1"}},
+			{Name: "v2.worker.b", Type: "worker", Version: 2, State: "PENDING", Host: Host{ID: "i-1"}},
+			{Name: "v2.web.d", Type: "web", Version: 2, State: "RUNNING", Host: Host{ID: "i-1"}},
+		}
+	}
+
+	names := func(tasks []*Task) []string {
+		var names []string
+		for _, t := range tasks {
+			names = append(names, t.Name)
+		}
+		return names
+	}
+
+	var (
+		web     = "web"
+		v2      = 2
+		pending = "pending"
+		host    = "i-1"
+		two     = 2
+		desc    = "desc"
+		after   = "]v2.web.c"
+		from    = "v2.web.c"
+		version = "version"
+	)
+
+	tests := []struct {
+		q   TasksQuery
+		out []string
+	}{
+		{TasksQuery{}, []string{"v1.web.a", "v2.web.c", "v2.web.d", "v2.worker.b"}},
+		{TasksQuery{Type: &web}, []string{"v1.web.a", "v2.web.c", "v2.web.d"}},
+		{TasksQuery{Version: &v2}, []string{"v2.web.c", "v2.web.d", "v2.worker.b"}},
+		{TasksQuery{State: &pending}, []string{"v2.worker.b"}},
+		{TasksQuery{Host: &host, Type: &web}, []string{"v1.web.a", "v2.web.d"}},
+		{TasksQuery{Range: headerutil.Range{Max: &two}}, []string{"v1.web.a", "v2.web.c"}},
+		{TasksQuery{Range: headerutil.Range{Max: &two, Start: &after}}, []string{"v2.web.d", "v2.worker.b"}},
+		{TasksQuery{Range: headerutil.Range{Max: &two, Start: &from}}, []string{"v2.web.c", "v2.web.d"}},
+		{TasksQuery{Range: headerutil.Range{Order: &desc, Start: &after}}, []string{"v1.web.a"}},
+	}
+
+	for _, tt := range tests {
+		tt.q.App = &App{Name: "acme-inc"}
+		out, err := tt.q.apply(tasks())
+		assert.NoError(t, err)
+		assert.Equal(t, tt.out, names(out))
+	}
+
+	_, err := TasksQuery{Range: headerutil.Range{Sort: &version}}.apply(tasks())
+	assert.Equal(t, ErrInvalidTasksSort, err)
+}
+
+func TestTasksQuery_Cluster(t *testing.T) {
+	tasks := []*Task{
+		{App: "blog", Name: "v1.web.a", Image: image.Image{Repository: "remind101/blog", Tag: "master"}},
+		{App: "acme-inc", Name: "v1.web.b", Image: image.Image{Repository: "remind101/acme-inc", Tag: "latest"}},
+		{App: "acme-inc", Name: "v1.worker.c", Image: image.Image{Repository: "remind101/acme-inc", Tag: "v2"}},
+	}
+
+	keys := func(tasks []*Task) []string {
+		var keys []string
+		for _, t := range tasks {
+			keys = append(keys, t.App+"/"+t.Name)
+		}
+		return keys
+	}
+
+	var (
+		repo  = "remind101/acme-inc"
+		tag   = "remind101/acme-inc:v2"
+		after = "]acme-inc/v1.worker.c"
+	)
+
+	tests := []struct {
+		q   TasksQuery
+		out []string
+	}{
+		{TasksQuery{}, []string{"acme-inc/v1.web.b", "acme-inc/v1.worker.c", "blog/v1.web.a"}},
+		{TasksQuery{Image: &repo}, []string{"acme-inc/v1.web.b", "acme-inc/v1.worker.c"}},
+		{TasksQuery{Image: &tag}, []string{"acme-inc/v1.worker.c"}},
+		{TasksQuery{Range: headerutil.Range{Start: &after}}, []string{"blog/v1.web.a"}},
+	}
+
+	for _, tt := range tests {
+		out, err := tt.q.apply(append([]*Task(nil), tasks...))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.out, keys(out))
+	}
+}
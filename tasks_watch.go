@@ -0,0 +1,124 @@
+package empire
+
+import (
+	"time"
+
+	"github.com/remind101/empire/pkg/headerutil"
+	"github.com/remind101/empire/twelvefactor"
+	"golang.org/x/net/context"
+)
+
+// DefaultWatchInterval is how often the scheduler is asked for the tasks of an
+// app when watching them.
+const DefaultWatchInterval = 2 * time.Second
+
+// MinWatchInterval is the shortest interval that tasks can be watched at, so
+// that watchers don't put too much load on the scheduler.
+const MinWatchInterval = time.Second
+
+// The kinds of changes that can happen to a task.
+const (
+	TaskAdded   = "added"
+	TaskUpdated = "updated"
+	TaskRemoved = "removed"
+)
+
+// TaskChange is a change to a task that's being watched.
+type TaskChange struct {
+	// What happened to the task. One of TaskAdded, TaskUpdated or
+	// TaskRemoved.
+	Type string
+
+	// The task. For removed tasks, this is the task as it was last seen.
+	Task *Task
+}
+
+// WatchTasksOpts are options provided when watching the tasks of an app.
+type WatchTasksOpts struct {
+	// The app, and filters for the tasks to watch. The range is ignored.
+	Query TasksQuery
+
+	// How often to look at the tasks. The zero value is
+	// DefaultWatchInterval.
+	Interval time.Duration
+
+	// Called with the changes to the tasks. The first call has every task
+	// that matches the query as an added task. Calls that don't have any
+	// changes are made after every interval, so that callers can keep
+	// connections alive.
+	Changes func([]*TaskChange) error
+}
+
+// WatchTasks looks at the tasks of an app on an interval, and sends what
+// changed to opts.Changes, until the context is canceled, or opts.Changes
+// returns an error.
+func (e *Empire) WatchTasks(ctx context.Context, opts WatchTasksOpts) error {
+	interval := opts.Interval
+	if interval == 0 {
+		interval = DefaultWatchInterval
+	}
+	if interval < MinWatchInterval {
+		interval = MinWatchInterval
+	}
+
+	q := opts.Query
+	q.Range = headerutil.Range{}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Watchers always get the tasks from the scheduler, rather than from a
+	// cache, which also keeps the cache fresh for everyone else.
+	fresh := twelvefactor.FreshTasks(ctx)
+
+	var previous []*Task
+	for {
+		tasks, err := e.tasks.Tasks(fresh, q)
+		if err != nil {
+			return err
+		}
+
+		if err := opts.Changes(diffTasks(previous, tasks)); err != nil {
+			return err
+		}
+		previous = tasks
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// diffTasks returns the tasks that were added, updated or removed between two
+// listings of tasks. A task is considered updated when its state, or host,
+// changed.
+func diffTasks(previous, current []*Task) []*TaskChange {
+	seen := make(map[string]*Task)
+	for _, t := range previous {
+		seen[t.Name] = t
+	}
+
+	var changes []*TaskChange
+	exists := make(map[string]bool)
+	for _, t := range current {
+		exists[t.Name] = true
+
+		p, ok := seen[t.Name]
+		switch {
+		case !ok:
+			changes = append(changes, &TaskChange{Type: TaskAdded, Task: t})
+		case p.State != t.State || p.Host.ID != t.Host.ID:
+			changes = append(changes, &TaskChange{Type: TaskUpdated, Task: t})
+		}
+	}
+
+	for _, t := range previous {
+		if !exists[t.Name] {
+			changes = append(changes, &TaskChange{Type: TaskRemoved, Task: t})
+		}
+	}
+
+	return changes
+}
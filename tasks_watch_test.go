@@ -0,0 +1,34 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffTasks(t *testing.T) {
+	a := &Task{Name: "v1.web.a", State: "RUNNING", Host: Host{ID: "i-1"}}
+	b := &Task{Name: "v1.web.b", State: "RUNNING", Host: Host{ID: "i-1"}}
+	c := &Task{Name: "v2.web.c", State: "PENDING"}
+	cRunning := &Task{Name: "v2.web.c", State: "RUNNING", Host: Host{ID: "i-2"}}
+
+	// Everything is added the first time.
+	assert.Equal(t, []*TaskChange{
+		{Type: TaskAdded, Task: a},
+		{Type: TaskAdded, Task: b},
+	}, diffTasks(nil, []*Task{a, b}))
+
+	// Nothing changed.
+	assert.Nil(t, diffTasks([]*Task{a, b}, []*Task{a, b}))
+
+	// A new task was started.
+	assert.Equal(t, []*TaskChange{
+		{Type: TaskAdded, Task: c},
+	}, diffTasks([]*Task{a, b}, []*Task{a, b, c}))
+
+	// The new task started running, and replaced an old task.
+	assert.Equal(t, []*TaskChange{
+		{Type: TaskUpdated, Task: cRunning},
+		{Type: TaskRemoved, Task: a},
+	}, diffTasks([]*Task{a, b, c}, []*Task{b, cRunning}))
+}
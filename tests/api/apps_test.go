@@ -117,6 +117,44 @@ func TestAppDeploy(t *testing.T) {
 	}
 }
 
+func TestAppOverview(t *testing.T) {
+	c, s := NewTestClient(t)
+	defer s.Close()
+
+	mustAppCreate(t, c, empire.App{Name: "acme-inc"})
+
+	overview, err := c.AppOverviewInfo("acme-inc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "acme-inc", overview.App.Name)
+	assert.Nil(t, overview.Release)
+	assert.Equal(t, 0, len(overview.Releases))
+
+	mustDeploy(t, c, DefaultImage)
+	if _, err := c.DomainCreate("acme-inc", "example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	overview, err = c.AppOverviewInfo("acme-inc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.NotNil(t, overview.Release) {
+		assert.Equal(t, 1, overview.Release.Version)
+	}
+	assert.NotEqual(t, "", overview.ConfigId)
+	assert.Equal(t, 1, len(overview.Releases))
+	if assert.Equal(t, 1, len(overview.Domains)) {
+		assert.Equal(t, "example.com", overview.Domains[0].Hostname)
+	}
+	for _, p := range overview.Processes {
+		if p.Type == "web" {
+			assert.Equal(t, 1, p.Quantity)
+		}
+	}
+}
+
 func TestAppDeployResourceDoesNotExist(t *testing.T) {
 	c, s := NewTestClient(t)
 	defer s.Close()
@@ -0,0 +1,57 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulk(t *testing.T) {
+	c, s := NewTestClient(t)
+	defer s.Close()
+
+	mustAppCreate(t, c, empire.App{Name: "acme-inc"})
+	mustAppCreate(t, c, empire.App{Name: "acme-api"})
+
+	tier := "web"
+	_, err := c.AppUpdate("acme-inc", &heroku.AppUpdateOpts{
+		Labels: map[string]*string{"tier": &tier},
+	}, "")
+	assert.NoError(t, err)
+
+	password := "s3cret"
+	opts := &heroku.BulkOpts{
+		Selector:  "tier=web",
+		Operation: "set",
+		Vars:      map[string]*string{"PASSWORD": &password},
+	}
+
+	// Planning doesn't change anything.
+	plan, err := c.Bulk(opts, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []heroku.BulkApp{{Id: plan.Apps[0].Id, Name: "acme-inc"}}, plan.Apps)
+	assert.Nil(t, plan.Results)
+
+	vars, err := c.ConfigVarInfo("acme-inc")
+	assert.NoError(t, err)
+	assert.Empty(t, vars["PASSWORD"])
+
+	// Confirming a plan for a different operation fails.
+	opts.Confirm = plan.Id
+	_, err = c.Bulk(&heroku.BulkOpts{Selector: "tier=web", Operation: "restart", Confirm: plan.Id}, "")
+	assert.Error(t, err)
+
+	plan, err = c.Bulk(opts, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []heroku.BulkResult{{App: plan.Apps[0]}}, plan.Results)
+
+	vars, err = c.ConfigVarInfo("acme-inc")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", vars["PASSWORD"])
+
+	vars, err = c.ConfigVarInfo("acme-api")
+	assert.NoError(t, err)
+	assert.Empty(t, vars["PASSWORD"])
+}
@@ -0,0 +1,54 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppExportImport(t *testing.T) {
+	c, s := NewTestClient(t)
+	defer s.Close()
+
+	mustAppCreate(t, c, empire.App{Name: "acme-inc"})
+
+	env := "production"
+	mustConfigVarUpdate(t, c, "acme-inc", map[string]*string{"RAILS_ENV": &env})
+
+	_, err := c.DomainCreate("acme-inc", "example.com")
+	assert.NoError(t, err)
+
+	mustAppDeploy(t, c, "acme-inc", "remind101/acme-inc:latest")
+
+	b := new(bytes.Buffer)
+	assert.NoError(t, c.AppExport("acme-inc", b))
+
+	var export map[string]interface{}
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &export))
+	assert.Equal(t, float64(empire.AppExportVersion), export["version"])
+	assert.Equal(t, map[string]interface{}{"RAILS_ENV": "production"}, export["config"])
+	assert.Equal(t, []interface{}{"example.com"}, export["domains"])
+
+	// The domain is in use by acme-inc, so it's removed before importing the
+	// app with another name.
+	mustAppDelete(t, c, "acme-inc")
+	export["name"] = "acme-copy"
+	raw, err := json.Marshal(export)
+	assert.NoError(t, err)
+
+	result, err := c.AppImport(&heroku.AppImportOpts{Export: raw}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "acme-copy", result.App)
+	assert.Contains(t, result.Changes, "create app acme-copy")
+
+	assert.Equal(t, map[string]string{"RAILS_ENV": "production"}, mustConfigVarInfo(t, c, "acme-copy"))
+
+	// Importing again doesn't change anything.
+	result, err = c.AppImport(&heroku.AppImportOpts{Export: raw}, "")
+	assert.NoError(t, err)
+	assert.Empty(t, result.Changes)
+}
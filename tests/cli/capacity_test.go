@@ -0,0 +1,13 @@
+package cli_test
+
+import "testing"
+
+func TestCapacity(t *testing.T) {
+	run(t, []Command{
+		{
+			"capacity -s 2X",
+			`default  i-aa111aa1  256/2048  512.00mb/8.00gb  1 dynos  fits 3
+default  total       256/2048  512.00mb/8.00gb  1 dynos  fits 3`,
+		},
+	})
+}
@@ -28,7 +28,8 @@ var fakeUser = &empire.User{
 func DeployCommand(tag, version string) Command {
 	return Command{
 		fmt.Sprintf("deploy remind101/acme-inc:%s", tag),
-		`Pulling repository remind101/acme-inc
+		`Status: Pulling image remind101/acme-inc:` + tag + `
+Pulling repository remind101/acme-inc
 345c7524bc96: Pulling image (` + tag + `) from remind101/acme-inc
 345c7524bc96: Pulling image (` + tag + `) from remind101/acme-inc, endpoint: https://registry-1.docker.io/v1/
 345c7524bc96: Pulling dependent layers
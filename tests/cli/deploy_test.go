@@ -9,7 +9,8 @@ func TestDeploy(t *testing.T) {
 	run(t, []Command{
 		{
 			"deploy remind101/acme-inc:9ea71ea5abe676f117b2c969a6ea3c1be8ed4098d2118b1fd9ea5a5e59aa24f2",
-			`Pulling repository remind101/acme-inc
+			`Status: Pulling image remind101/acme-inc:9ea71ea5abe676f117b2c969a6ea3c1be8ed4098d2118b1fd9ea5a5e59aa24f2
+Pulling repository remind101/acme-inc
 345c7524bc96: Pulling image (9ea71ea5abe676f117b2c969a6ea3c1be8ed4098d2118b1fd9ea5a5e59aa24f2) from remind101/acme-inc
 345c7524bc96: Pulling image (9ea71ea5abe676f117b2c969a6ea3c1be8ed4098d2118b1fd9ea5a5e59aa24f2) from remind101/acme-inc, endpoint: https://registry-1.docker.io/v1/
 345c7524bc96: Pulling dependent layers
@@ -24,7 +25,8 @@ Status: Finished processing events for release v1 of acme-inc`,
 		},
 		{
 			"deploy remind101/acme-inc:9ea71ea5abe676f117b2c969a6ea3c1be8ed4098d2118b1fd9ea5a5e59aa24f2 -m important",
-			`Pulling repository remind101/acme-inc
+			`Status: Pulling image remind101/acme-inc:9ea71ea5abe676f117b2c969a6ea3c1be8ed4098d2118b1fd9ea5a5e59aa24f2
+Pulling repository remind101/acme-inc
 345c7524bc96: Pulling image (9ea71ea5abe676f117b2c969a6ea3c1be8ed4098d2118b1fd9ea5a5e59aa24f2) from remind101/acme-inc
 345c7524bc96: Pulling image (9ea71ea5abe676f117b2c969a6ea3c1be8ed4098d2118b1fd9ea5a5e59aa24f2) from remind101/acme-inc, endpoint: https://registry-1.docker.io/v1/
 345c7524bc96: Pulling dependent layers
@@ -43,7 +45,8 @@ Status: Finished processing events for release v2 of acme-inc`,
 		},
 		{
 			"deploy -a my-app remind101/acme-inc:9ea71ea5abe676f117b2c969a6ea3c1be8ed4098d2118b1fd9ea5a5e59aa24f2",
-			`Pulling repository remind101/acme-inc
+			`Status: Pulling image remind101/acme-inc:9ea71ea5abe676f117b2c969a6ea3c1be8ed4098d2118b1fd9ea5a5e59aa24f2
+Pulling repository remind101/acme-inc
 345c7524bc96: Pulling image (9ea71ea5abe676f117b2c969a6ea3c1be8ed4098d2118b1fd9ea5a5e59aa24f2) from remind101/acme-inc
 345c7524bc96: Pulling image (9ea71ea5abe676f117b2c969a6ea3c1be8ed4098d2118b1fd9ea5a5e59aa24f2) from remind101/acme-inc, endpoint: https://registry-1.docker.io/v1/
 345c7524bc96: Pulling dependent layers
@@ -63,7 +66,8 @@ func TestDeploy_NoTag(t *testing.T) {
 	run(t, []Command{
 		{
 			"deploy remind101/acme-inc",
-			`Pulling repository remind101/acme-inc
+			`Status: Pulling image remind101/acme-inc:9ea71ea5abe676f117b2c969a6ea3c1be8ed4098d2118b1fd9ea5a5e59aa24f2
+Pulling repository remind101/acme-inc
 345c7524bc96: Pulling image (latest) from remind101/acme-inc
 345c7524bc96: Pulling image (latest) from remind101/acme-inc, endpoint: https://registry-1.docker.io/v1/
 345c7524bc96: Pulling dependent layers
@@ -94,7 +98,8 @@ func TestDeploy_CommitMessageRequired(t *testing.T) {
 	cli.Run(t, []Command{
 		{
 			"deploy remind101/acme-inc -m commit",
-			`Pulling repository remind101/acme-inc
+			`Status: Pulling image remind101/acme-inc:9ea71ea5abe676f117b2c969a6ea3c1be8ed4098d2118b1fd9ea5a5e59aa24f2
+Pulling repository remind101/acme-inc
 345c7524bc96: Pulling image (latest) from remind101/acme-inc
 345c7524bc96: Pulling image (latest) from remind101/acme-inc, endpoint: https://registry-1.docker.io/v1/
 345c7524bc96: Pulling dependent layers
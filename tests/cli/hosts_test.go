@@ -0,0 +1,22 @@
+package cli_test
+
+import "testing"
+
+func TestHosts(t *testing.T) {
+	run(t, []Command{
+		DeployCommand("latest", "v1"),
+		{
+			"cordon i-aa111aa1",
+			"Cordoned i-aa111aa1.",
+		},
+		{
+			"drain i-aa111aa1",
+			`acme-inc  v1.web.1  running
+Draining i-aa111aa1. 1 dynos will be moved to other hosts.`,
+		},
+		{
+			"uncordon i-aa111aa1",
+			"Uncordoned i-aa111aa1.",
+		},
+	})
+}
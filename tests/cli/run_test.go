@@ -29,3 +29,15 @@ func TestRunAttached(t *testing.T) {
 		},
 	})
 }
+
+func TestExec(t *testing.T) {
+	empiretest.SkipCI(t)
+
+	run(t, []Command{
+		DeployCommand("latest", "v1"),
+		{
+			"exec v1.web.1 ps aux -a acme-inc",
+			"Fake output for `ps aux` in 1",
+		},
+	})
+}
@@ -1,6 +1,7 @@
 package cli_test
 
 import (
+	"errors"
 	"testing"
 	"time"
 )
@@ -53,7 +54,7 @@ func TestScale_Constraints(t *testing.T) {
 		},
 		{
 			"scale web=2:256:600GB -a acme-inc",
-			"Scaled acme-inc to web=2:256:600.00gb.",
+			errors.New("error: web processes need 256:600.00gb, which is more than any host in the cluster has"),
 		},
 	})
 }
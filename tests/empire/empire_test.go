@@ -15,6 +15,7 @@ import (
 	"github.com/remind101/empire/empiretest"
 	"github.com/remind101/empire/pkg/image"
 	"github.com/remind101/empire/pkg/timex"
+	"github.com/remind101/empire/pkg/totp"
 	"github.com/remind101/empire/procfile"
 	"github.com/remind101/empire/twelvefactor"
 	"github.com/stretchr/testify/assert"
@@ -75,6 +76,133 @@ func TestEmpire_CertsAttach(t *testing.T) {
 	s.AssertExpectations(t)
 }
 
+func TestEmpire_Create_Team(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	e.Admins = []string{"admin"}
+
+	user := &empire.User{Name: "ejholmes"}
+
+	_, err := e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "acme-inc",
+		Team: "payments",
+	})
+	assert.EqualError(t, err, "ejholmes is not a member of the payments team")
+
+	_, err = e.TeamMembersCreate(context.Background(), empire.TeamMembersCreateOpts{
+		User:   user,
+		Member: &empire.TeamMember{Team: "payments", Username: "ejholmes"},
+	})
+	assert.NoError(t, err)
+
+	app, err := e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "acme-inc",
+		Team: "payments",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "payments", app.Team)
+
+	// Admins can create apps for any team.
+	app, err = e.Create(context.Background(), empire.CreateOpts{
+		User: &empire.User{Name: "admin"},
+		Name: "acme-worker",
+		Team: "billing",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "billing", app.Team)
+}
+
+func TestEmpire_Create_Namespace(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+
+	user := &empire.User{Name: "ejholmes"}
+
+	_, err := e.NamespacesCreate(context.Background(), empire.NamespacesCreateOpts{
+		User:      user,
+		Namespace: &empire.Namespace{Name: "payments", Team: "payments"},
+	})
+	assert.NoError(t, err)
+
+	_, err = e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "payments-api",
+	})
+	assert.EqualError(t, err, "ejholmes is not a member of the payments team")
+
+	user.Teams = []string{"payments"}
+	app, err := e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "payments-api",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "payments", app.Team)
+	assert.Equal(t, "payments", app.Namespace)
+}
+
+func TestEmpire_GrantsCreate_NamespaceAdmin(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	e.RBAC = true
+	e.Admins = []string{"root"}
+
+	root := &empire.User{Name: "root"}
+	alice := &empire.User{Name: "alice"}
+	bob := &empire.User{Name: "bob"}
+
+	_, err := e.NamespacesCreate(context.Background(), empire.NamespacesCreateOpts{
+		User:      root,
+		Namespace: &empire.Namespace{Name: "payments", Team: "payments"},
+	})
+	assert.NoError(t, err)
+
+	_, err = e.GrantsCreate(context.Background(), empire.GrantsCreateOpts{
+		User:  root,
+		Grant: &empire.Grant{Username: "alice", Namespace: "payments", Role: empire.RoleAdmin},
+	})
+	assert.NoError(t, err)
+
+	app, err := e.Create(context.Background(), empire.CreateOpts{
+		User: root,
+		Name: "payments-api",
+	})
+	assert.NoError(t, err)
+
+	// Admins of the namespace can manage grants on the apps in it.
+	grant, err := e.GrantsCreate(context.Background(), empire.GrantsCreateOpts{
+		User:  alice,
+		Grant: &empire.Grant{Username: "bob", AppID: &app.ID, Role: empire.RoleViewer},
+	})
+	assert.NoError(t, err)
+
+	_, err = e.GrantsCreate(context.Background(), empire.GrantsCreateOpts{
+		User:  bob,
+		Grant: &empire.Grant{Username: "bob", AppID: &app.ID, Role: empire.RoleAdmin},
+	})
+	assert.IsType(t, &empire.ForbiddenError{}, err)
+
+	assert.NoError(t, e.GrantsDestroy(context.Background(), empire.GrantsDestroyOpts{
+		User:  alice,
+		Grant: grant,
+	}))
+}
+
+func TestEmpire_APITokensCreate_NamedAfterUser(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	e.RBAC = true
+	e.Admins = []string{"ejholmes"}
+
+	token, err := e.APITokensCreate(context.Background(), empire.APITokensCreateOpts{
+		User: &empire.User{Name: "ejholmes"},
+		Name: "ejholmes",
+	})
+	assert.NoError(t, err)
+
+	// The token doesn't take on the roles of the admin with the same name.
+	user := token.User()
+	assert.Equal(t, "token:ejholmes", user.Name)
+	assert.EqualError(t, e.Authorize(user, nil, empire.RoleAdmin), "token:ejholmes does not have the admin role on all apps")
+}
+
 func TestEmpire_Deploy(t *testing.T) {
 	e := empiretest.NewEmpire(t)
 	s := new(mockScheduler)
@@ -204,6 +332,76 @@ func TestEmpire_Deploy_ImageNotFound(t *testing.T) {
 	s.AssertExpectations(t)
 }
 
+func TestEmpire_Deploy_IdempotencyKey(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+
+	user := &empire.User{Name: "ejholmes"}
+
+	deploy := func(key string) (*empire.Release, error) {
+		return e.Deploy(context.Background(), empire.DeployOpts{
+			User:           user,
+			Output:         empire.NewDeploymentStream(ioutil.Discard),
+			Image:          image.Image{Repository: "remind101/acme-inc"},
+			IdempotencyKey: key,
+		})
+	}
+
+	r1, err := deploy("build-1")
+	assert.NoError(t, err)
+
+	// Retrying with the same key returns the release that was already
+	// deployed.
+	r2, err := deploy("build-1")
+	assert.NoError(t, err)
+	assert.Equal(t, r1.ID, r2.ID)
+
+	r3, err := deploy("build-2")
+	assert.NoError(t, err)
+	assert.Equal(t, r1.Version+1, r3.Version)
+
+	// A key can't be reused for a different request.
+	_, err = e.Scale(context.Background(), empire.ScaleOpts{
+		User:           user,
+		App:            r1.App,
+		Updates:        []*empire.ProcessUpdate{{Process: "web", Quantity: 2}},
+		IdempotencyKey: "build-1",
+	})
+	assert.IsType(t, &empire.IdempotencyKeyError{}, err)
+}
+
+func TestEmpire_Scale_IdempotencyKey(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+
+	user := &empire.User{Name: "ejholmes"}
+
+	r, err := e.Deploy(context.Background(), empire.DeployOpts{
+		User:   user,
+		Output: empire.NewDeploymentStream(ioutil.Discard),
+		Image:  image.Image{Repository: "remind101/acme-inc"},
+	})
+	assert.NoError(t, err)
+
+	scale := func(quantity int) error {
+		_, err := e.Scale(context.Background(), empire.ScaleOpts{
+			User:           user,
+			App:            r.App,
+			Updates:        []*empire.ProcessUpdate{{Process: "web", Quantity: quantity}},
+			IdempotencyKey: "scale-1",
+		})
+		return err
+	}
+
+	assert.NoError(t, scale(2))
+	assert.NoError(t, scale(2))
+
+	// The key can't be reused to scale to a different quantity.
+	assert.IsType(t, &empire.IdempotencyKeyError{}, scale(3))
+
+	f, err := e.ListScale(context.Background(), r.App)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, f["web"].Quantity)
+}
+
 func TestEmpire_Run(t *testing.T) {
 	e := empiretest.NewEmpire(t)
 
@@ -614,11 +812,483 @@ func TestEmpire_Set(t *testing.T) {
 	s.AssertExpectations(t)
 }
 
+func TestEmpire_Scale_Quota(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+
+	user := &empire.User{Name: "ejholmes"}
+
+	app, err := e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+
+	_, err = e.Deploy(context.Background(), empire.DeployOpts{
+		App:    app,
+		User:   user,
+		Output: empire.NewDeploymentStream(ioutil.Discard),
+		Image:  image.Image{Repository: "remind101/acme-inc"},
+	})
+	assert.NoError(t, err)
+
+	_, err = e.QuotasCreate(context.Background(), empire.QuotasCreateOpts{
+		User:  user,
+		Quota: &empire.Quota{AppID: &app.ID, MaxInstances: 2},
+	})
+	assert.NoError(t, err)
+
+	_, err = e.Scale(context.Background(), empire.ScaleOpts{
+		User:    user,
+		App:     app,
+		Updates: []*empire.ProcessUpdate{{Process: "web", Quantity: 3}},
+	})
+	assert.IsType(t, &empire.QuotaExceededError{}, err)
+
+	_, err = e.Scale(context.Background(), empire.ScaleOpts{
+		User:    user,
+		App:     app,
+		Updates: []*empire.ProcessUpdate{{Process: "web", Quantity: 2}},
+	})
+	assert.NoError(t, err)
+}
+
+func TestEmpire_Scale_FormationVersion(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+
+	user := &empire.User{Name: "ejholmes"}
+
+	app, err := e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+
+	_, err = e.Deploy(context.Background(), empire.DeployOpts{
+		App:    app,
+		User:   user,
+		Output: empire.NewDeploymentStream(ioutil.Discard),
+		Image:  image.Image{Repository: "remind101/acme-inc"},
+	})
+	assert.NoError(t, err)
+
+	release, err := e.ReleasesFind(empire.ReleasesQuery{App: app})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, release.FormationVersion)
+
+	_, err = e.Scale(context.Background(), empire.ScaleOpts{
+		User:             user,
+		App:              app,
+		Updates:          []*empire.ProcessUpdate{{Process: "web", Quantity: 2}},
+		FormationVersion: release.FormationVersion,
+	})
+	assert.NoError(t, err)
+
+	// Scaling from the formation that was read before the last scale
+	// would overwrite it.
+	_, err = e.Scale(context.Background(), empire.ScaleOpts{
+		User:             user,
+		App:              app,
+		Updates:          []*empire.ProcessUpdate{{Process: "web", Quantity: 3}},
+		FormationVersion: release.FormationVersion,
+	})
+	assert.IsType(t, &empire.FormationConflictError{}, err)
+
+	release, err = e.ReleasesFind(empire.ReleasesQuery{App: app})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, release.FormationVersion)
+	assert.Equal(t, 2, release.Formation["web"].Quantity)
+}
+
+func TestEmpire_Scale_Hooks(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+
+	user := &empire.User{Name: "ejholmes"}
+
+	app, err := e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+
+	_, err = e.Deploy(context.Background(), empire.DeployOpts{
+		App:    app,
+		User:   user,
+		Output: empire.NewDeploymentStream(ioutil.Discard),
+		Image:  image.Image{Repository: "remind101/acme-inc"},
+	})
+	assert.NoError(t, err)
+
+	var after []error
+	e.Hooks = []empire.Hook{empire.HookFuncs{
+		BeforeFunc: func(ctx context.Context, req *empire.HookRequest) error {
+			if req.Operation != empire.OperationScale {
+				return nil
+			}
+			for _, up := range req.Opts.(empire.ScaleOpts).Updates {
+				if up.Quantity > 2 {
+					return errors.New("scaling above 2 instances requires approval")
+				}
+			}
+			return nil
+		},
+		AfterFunc: func(ctx context.Context, req *empire.HookRequest, err error) {
+			assert.Equal(t, user, req.User)
+			assert.Equal(t, app.ID, req.App.ID)
+			after = append(after, err)
+		},
+	}}
+
+	_, err = e.Scale(context.Background(), empire.ScaleOpts{
+		User:    user,
+		App:     app,
+		Updates: []*empire.ProcessUpdate{{Process: "web", Quantity: 3}},
+	})
+	assert.EqualError(t, err, "scaling above 2 instances requires approval")
+
+	f, err := e.ListScale(context.Background(), app)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, f["web"].Quantity)
+
+	_, err = e.Scale(context.Background(), empire.ScaleOpts{
+		User:    user,
+		App:     app,
+		Updates: []*empire.ProcessUpdate{{Process: "web", Quantity: 2}},
+	})
+	assert.NoError(t, err)
+
+	f, err = e.ListScale(context.Background(), app)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, f["web"].Quantity)
+
+	assert.Equal(t, 2, len(after))
+	assert.Error(t, after[0])
+	assert.NoError(t, after[1])
+}
+
+func TestEmpire_Scale_KeptAcrossDeploys(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+
+	user := &empire.User{Name: "ejholmes"}
+
+	app, err := e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+
+	deploy := func() {
+		_, err := e.Deploy(context.Background(), empire.DeployOpts{
+			App:    app,
+			User:   user,
+			Output: empire.NewDeploymentStream(ioutil.Discard),
+			Image:  image.Image{Repository: "remind101/acme-inc"},
+		})
+		assert.NoError(t, err)
+	}
+
+	deploy()
+
+	_, err = e.Scale(context.Background(), empire.ScaleOpts{
+		User: user,
+		App:  app,
+		Updates: []*empire.ProcessUpdate{
+			{Process: "worker", Quantity: 2, Constraints: &empire.Constraints{Memory: 1073741824, CPUShare: 512, Nproc: 512}},
+		},
+	})
+	assert.NoError(t, err)
+
+	deploy()
+
+	f, err := e.ListScale(context.Background(), app)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, f["web"].Quantity)
+	worker := f["worker"]
+	assert.Equal(t, 2, worker.Quantity)
+	assert.Equal(t, empire.Constraints{Memory: 1073741824, CPUShare: 512, Nproc: 512}, worker.Constraints())
+}
+
+func TestEmpire_Rollback_KeepsScale(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+
+	user := &empire.User{Name: "ejholmes"}
+
+	app, err := e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err := e.Deploy(context.Background(), empire.DeployOpts{
+			App:    app,
+			User:   user,
+			Output: empire.NewDeploymentStream(ioutil.Discard),
+			Image:  image.Image{Repository: "remind101/acme-inc"},
+		})
+		assert.NoError(t, err)
+	}
+
+	_, err = e.Scale(context.Background(), empire.ScaleOpts{
+		User: user,
+		App:  app,
+		Updates: []*empire.ProcessUpdate{
+			{Process: "web", Quantity: 3},
+			{Process: "worker", Quantity: 2, Constraints: &empire.Constraints{Memory: 1073741824, CPUShare: 512, Nproc: 512}},
+		},
+	})
+	assert.NoError(t, err)
+
+	r, err := e.Rollback(context.Background(), empire.RollbackOpts{
+		User:    user,
+		App:     app,
+		Version: 1,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, r.Version)
+
+	f, err := e.ListScale(context.Background(), app)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, f["web"].Quantity)
+	worker := f["worker"]
+	assert.Equal(t, 2, worker.Quantity)
+	assert.Equal(t, empire.Constraints{Memory: 1073741824, CPUShare: 512, Nproc: 512}, worker.Constraints())
+}
+
+func TestEmpire_Scale_ArgsKeptAcrossDeploys(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+
+	user := &empire.User{Name: "ejholmes"}
+
+	app, err := e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+
+	deploy := func() {
+		_, err := e.Deploy(context.Background(), empire.DeployOpts{
+			App:    app,
+			User:   user,
+			Output: empire.NewDeploymentStream(ioutil.Discard),
+			Image:  image.Image{Repository: "remind101/acme-inc"},
+		})
+		assert.NoError(t, err)
+	}
+
+	deploy()
+
+	args := empire.Command{"--threads=8"}
+	_, err = e.Scale(context.Background(), empire.ScaleOpts{
+		User:    user,
+		App:     app,
+		Updates: []*empire.ProcessUpdate{{Process: "worker", Quantity: 2, Args: &args}},
+	})
+	assert.NoError(t, err)
+
+	deploy()
+
+	release, err := e.ReleasesFind(empire.ReleasesQuery{App: app})
+	assert.NoError(t, err)
+	assert.Equal(t, empire.Command{"--threads=8"}, release.Formation["worker"].Args)
+	assert.Equal(t, 2, release.Formation["worker"].Quantity)
+}
+
+func TestEmpire_TwoFactorEnroll(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	e.RBAC = true
+	e.Admins = []string{"admin"}
+
+	user := &empire.User{Name: "ejholmes"}
+	admin := &empire.User{Name: "admin"}
+
+	// Users that aren't enrolled can't enroll themselves.
+	_, err := e.TwoFactorEnroll(context.Background(), empire.TwoFactorEnrollOpts{
+		User: user,
+	})
+	assert.IsType(t, &empire.ForbiddenError{}, err)
+
+	secret, err := e.TwoFactorEnroll(context.Background(), empire.TwoFactorEnrollOpts{
+		User:     admin,
+		Username: "ejholmes",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ejholmes", secret.Username)
+
+	// Replacing a secret requires a code from it, which can only be used
+	// once.
+	_, err = e.TwoFactorEnroll(context.Background(), empire.TwoFactorEnrollOpts{
+		User: user,
+	})
+	assert.Equal(t, empire.ErrTwoFactorCode, err)
+
+	code, err := totp.Code(secret.Secret, timex.Now())
+	assert.NoError(t, err)
+
+	assert.NoError(t, e.TwoFactorVerify(user, code))
+	assert.Equal(t, empire.ErrTwoFactorCode, e.TwoFactorVerify(user, code))
+
+	code, err = totp.Code(secret.Secret, timex.Now().Add(totp.Period*time.Second))
+	assert.NoError(t, err)
+
+	_, err = e.TwoFactorEnroll(context.Background(), empire.TwoFactorEnrollOpts{
+		User: user,
+		Code: code,
+	})
+	assert.NoError(t, err)
+}
+
+func TestEmpire_Scale_Protected(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+
+	user := &empire.User{Name: "ejholmes"}
+
+	secret, err := e.TwoFactorEnroll(context.Background(), empire.TwoFactorEnrollOpts{
+		User: user,
+	})
+	assert.NoError(t, err)
+
+	app, err := e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+
+	_, err = e.Deploy(context.Background(), empire.DeployOpts{
+		App:    app,
+		User:   user,
+		Output: empire.NewDeploymentStream(ioutil.Discard),
+		Image:  image.Image{Repository: "remind101/acme-inc"},
+	})
+	assert.NoError(t, err)
+
+	err = e.SetProtected(context.Background(), empire.SetProtectedOpts{
+		User:      user,
+		App:       app,
+		Protected: true,
+	})
+	assert.NoError(t, err)
+
+	scaleToZero := func(code *empire.TwoFactorCode) error {
+		_, err := e.Scale(context.Background(), empire.ScaleOpts{
+			User:      user,
+			App:       app,
+			Updates:   []*empire.ProcessUpdate{{Process: "web", Quantity: 0}},
+			TwoFactor: code,
+		})
+		return err
+	}
+
+	// Callers that can't provide a code, like Slack, can't scale a
+	// protected app to zero.
+	assert.Equal(t, empire.ErrTwoFactorRequired, scaleToZero(nil))
+
+	code, err := totp.Code(secret.Secret, timex.Now())
+	assert.NoError(t, err)
+	assert.NoError(t, scaleToZero(&empire.TwoFactorCode{Code: code}))
+
+	_, err = e.Scale(context.Background(), empire.ScaleOpts{
+		User:    user,
+		App:     app,
+		Updates: []*empire.ProcessUpdate{{Process: "web", Quantity: 1}},
+	})
+	assert.NoError(t, err)
+
+	// The code can't be replayed.
+	assert.Equal(t, empire.ErrTwoFactorCode, scaleToZero(&empire.TwoFactorCode{Code: code}))
+
+	err = e.Destroy(context.Background(), empire.DestroyOpts{
+		User: user,
+		App:  app,
+	})
+	assert.Equal(t, empire.ErrTwoFactorRequired, err)
+}
+
+func TestEmpire_SetTraffic_VerifiesPreviousImage(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+
+	user := &empire.User{Name: "ejholmes"}
+
+	var app *empire.App
+	for _, tag := range []string{"v1", "v2"} {
+		r, err := e.Deploy(context.Background(), empire.DeployOpts{
+			User:   user,
+			App:    app,
+			Output: empire.NewDeploymentStream(ioutil.Discard),
+			Image:  image.Image{Repository: "remind101/acme-inc", Tag: tag},
+		})
+		assert.NoError(t, err)
+		app = r.App
+	}
+
+	// The image of the previous release is no longer trusted.
+	e.ImageVerifier = imageVerifierFunc(func(ctx context.Context, img image.Image) error {
+		if img.Tag == "v1" {
+			return errors.New("untrusted")
+		}
+		return nil
+	})
+
+	err := e.SetTraffic(context.Background(), empire.SetTrafficOpts{
+		User:                  user,
+		App:                   app,
+		PreviousReleaseWeight: 50,
+	})
+	assert.IsType(t, &empire.ImageVerificationError{}, err)
+}
+
+type imageVerifierFunc func(context.Context, image.Image) error
+
+func (fn imageVerifierFunc) VerifyImage(ctx context.Context, img image.Image) error {
+	return fn(ctx, img)
+}
+
+func TestEmpire_Reschedule(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	s := new(mockScheduler)
+	e.Scheduler = s
+
+	user := &empire.User{Name: "ejholmes"}
+
+	app, err := e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+
+	process := &twelvefactor.Process{
+		Type: "web",
+		Env:  map[string]string{"EMPIRE_RELEASE": "v1"},
+	}
+	s.On("Tasks", app.ID).Return([]*twelvefactor.Task{
+		{ID: "a", Process: process, State: "RUNNING", Host: twelvefactor.Host{ID: "i-1"}},
+		{ID: "b", Process: process, State: "RUNNING", Host: twelvefactor.Host{ID: "i-2", Lost: true}},
+		{ID: "c", Process: process, State: "STOPPED", Host: twelvefactor.Host{ID: "i-2", Lost: true}},
+	}, nil)
+	s.On("Stop", "b").Return(nil)
+
+	tasks, err := e.Reschedule(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(tasks))
+	assert.Equal(t, "v1.web.b", tasks[0].Name)
+
+	s.AssertExpectations(t)
+}
+
 type mockScheduler struct {
 	empire.Scheduler
 	mock.Mock
 }
 
+func (m *mockScheduler) Tasks(_ context.Context, app string) ([]*twelvefactor.Task, error) {
+	args := m.Called(app)
+	return args.Get(0).([]*twelvefactor.Task), args.Error(1)
+}
+
+func (m *mockScheduler) Stop(_ context.Context, instanceID string) error {
+	args := m.Called(instanceID)
+	return args.Error(0)
+}
+
 type processesByType []*twelvefactor.Process
 
 func (e processesByType) Len() int           { return len(e) }
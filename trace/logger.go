@@ -0,0 +1,36 @@
+package trace
+
+import (
+	"strings"
+
+	"github.com/remind101/pkg/logger"
+)
+
+// Logger is an implementation of the Tracer interface that logs each span, in
+// logfmt, so that the spans of a trace can be assembled from the logs.
+type Logger struct {
+	logger logger.Logger
+}
+
+// NewLogger returns a new Logger that logs spans to l.
+func NewLogger(l logger.Logger) *Logger {
+	return &Logger{logger: l}
+}
+
+func (t *Logger) Record(span *Span) error {
+	pairs := []interface{}{
+		"name", span.Name,
+		"trace_id", span.TraceID,
+		"span_id", span.ID,
+		"parent_id", span.ParentID,
+		"duration", span.Duration,
+	}
+	if len(span.Tags) > 0 {
+		pairs = append(pairs, "tags", strings.Join(span.Tags, ","))
+	}
+	if span.Err != nil {
+		pairs = append(pairs, "error", span.Err)
+	}
+	t.logger.Info("span", pairs...)
+	return nil
+}
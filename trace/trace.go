@@ -0,0 +1,127 @@
+// Package trace provides spans for instrumenting Empire, so that the time that
+// an operation, like a deploy, spends in each step (e.g. the database, the
+// registry and the scheduler) can be attributed, rather than guessed at.
+package trace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/remind101/empire/stats"
+	"golang.org/x/net/context"
+)
+
+// Span is a timed step of an operation. Spans that are started from the
+// context of another span are its children, and share its trace id.
+type Span struct {
+	// Identifies the operation that the span is part of.
+	TraceID string
+
+	// Identifies the span.
+	ID string
+
+	// The id of the parent span, if there is one.
+	ParentID string
+
+	// The name of the step (e.g. "release.submit").
+	Name string
+
+	// Tags that describe the step (e.g. "app:acme-inc").
+	Tags []string
+
+	// When the step started, and how long it took.
+	Start    time.Time
+	Duration time.Duration
+
+	// The error that the step failed with, if it failed.
+	Err error
+
+	tracer Tracer
+	stats  stats.Stats
+}
+
+// Finish records the span, with the error that the step failed with, if any.
+// The duration is also sent to the stats backend in the context that the span
+// was started with, as a timing with the name of the span.
+func (s *Span) Finish(err error) {
+	s.Duration = time.Since(s.Start)
+	s.Err = err
+
+	if s.stats != nil {
+		s.stats.Timing(s.Name, s.Duration, 1.0, s.Tags)
+	}
+	if s.tracer != nil {
+		s.tracer.Record(s)
+	}
+}
+
+// Tracer records spans once they've finished.
+type Tracer interface {
+	Record(span *Span) error
+}
+
+type nullTracer struct{}
+
+func (t *nullTracer) Record(span *Span) error {
+	return nil
+}
+
+var Null = &nullTracer{}
+
+// Start starts a span, as a child of the span in the context, if there is one.
+// The returned context holds the new span, so that the spans started from it
+// are its children.
+func Start(ctx context.Context, name string, tags []string) (context.Context, *Span) {
+	span := &Span{
+		ID:    newID(),
+		Name:  name,
+		Tags:  tags,
+		Start: time.Now(),
+	}
+
+	if parent, ok := SpanFromContext(ctx); ok {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.ID
+	} else {
+		span.TraceID = newID()
+	}
+
+	span.tracer, _ = FromContext(ctx)
+	span.stats, _ = stats.FromContext(ctx)
+
+	return context.WithValue(ctx, spanKey, span), span
+}
+
+// WithTracer returns a new context.Context with the Tracer implementation
+// embedded.
+func WithTracer(ctx context.Context, tracer Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey, tracer)
+}
+
+// FromContext returns the Tracer implementation that's embedded in the
+// context.
+func FromContext(ctx context.Context) (Tracer, bool) {
+	tracer, ok := ctx.Value(tracerKey).(Tracer)
+	return tracer, ok
+}
+
+// SpanFromContext returns the span that's in progress in the context.
+func SpanFromContext(ctx context.Context) (*Span, bool) {
+	span, ok := ctx.Value(spanKey).(*Span)
+	return span, ok
+}
+
+// newID returns a new random id for a trace or a span.
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type key int
+
+const (
+	tracerKey key = iota
+	spanKey
+)
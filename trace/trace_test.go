@@ -0,0 +1,40 @@
+package trace
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestStart(t *testing.T) {
+	var spans []*Span
+	ctx := WithTracer(context.Background(), tracerFunc(func(span *Span) error {
+		spans = append(spans, span)
+		return nil
+	}))
+
+	ctx, parent := Start(ctx, "deploy", []string{"app:acme-inc"})
+	_, child := Start(ctx, "release.submit", nil)
+	child.Finish(errors.New("boom"))
+	parent.Finish(nil)
+
+	assert.Equal(t, []*Span{child, parent}, spans)
+	assert.Equal(t, parent.TraceID, child.TraceID)
+	assert.Equal(t, parent.ID, child.ParentID)
+	assert.Equal(t, "", parent.ParentID)
+	assert.EqualError(t, child.Err, "boom")
+	assert.NoError(t, parent.Err)
+}
+
+func TestStart_NoTracer(t *testing.T) {
+	_, span := Start(context.Background(), "deploy", nil)
+	span.Finish(nil)
+}
+
+type tracerFunc func(*Span) error
+
+func (fn tracerFunc) Record(span *Span) error {
+	return fn(span)
+}
@@ -0,0 +1,68 @@
+package empire
+
+import (
+	"errors"
+
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	// ErrPreviousReleaseWeight is returned when the weight of the previous
+	// release isn't a percentage.
+	ErrPreviousReleaseWeight = &ValidationError{
+		errors.New("The weight of the previous release must be between 0 and 100."),
+	}
+
+	// ErrNoPreviousRelease is returned when traffic is sent to the
+	// previous release of an app that has only been released once.
+	ErrNoPreviousRelease = &ValidationError{
+		errors.New("There's no previous release to send traffic to."),
+	}
+)
+
+// SetTrafficOpts are options provided when splitting traffic between the
+// current and previous release of an app.
+type SetTrafficOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// The percentage of traffic to send to the previous release.
+	PreviousReleaseWeight int
+
+	// Commit message
+	Message string
+}
+
+func (opts SetTrafficOpts) Event() TrafficEvent {
+	return TrafficEvent{
+		User:                  opts.User.Name,
+		App:                   opts.App.Name,
+		PreviousReleaseWeight: opts.PreviousReleaseWeight,
+		Message:               opts.Message,
+		app:                   opts.App,
+	}
+}
+
+func (opts SetTrafficOpts) Validate(e *Empire) error {
+	if err := e.authorize(opts.User, opts.App, ActionDeploy); err != nil {
+		return err
+	}
+	if opts.PreviousReleaseWeight < 0 || opts.PreviousReleaseWeight > 100 {
+		return ErrPreviousReleaseWeight
+	}
+	return e.requireMessages(opts.Message)
+}
+
+// previousRelease returns the release before the given release, which is
+// where traffic is sent when the app has a PreviousReleaseWeight.
+func previousRelease(db *gorm.DB, release *Release) (*Release, error) {
+	version := release.Version - 1
+	previous, err := releasesFind(db, ReleasesQuery{App: release.App, Version: &version})
+	if err == gorm.RecordNotFound {
+		return nil, ErrNoPreviousRelease
+	}
+	return previous, err
+}
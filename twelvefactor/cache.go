@@ -0,0 +1,182 @@
+package twelvefactor
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// now returns the current time, and can be replaced in tests.
+var now = time.Now
+
+// freshTasksKey is the context key that's set by FreshTasks.
+type freshTasksKey struct{}
+
+// FreshTasks returns a context that makes a cached Scheduler get the tasks
+// from the backend, rather than from the cache. What's returned is still
+// cached, so that a caller that regularly needs fresh state, like something
+// watching the tasks of an app, keeps the cache up to date for everyone else.
+func FreshTasks(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshTasksKey{}, true)
+}
+
+func isFreshTasks(ctx context.Context) bool {
+	fresh, _ := ctx.Value(freshTasksKey{}).(bool)
+	return fresh
+}
+
+// CacheTasks returns a Middleware that caches what Tasks and QueryTasks return
+// for the given amount of time, so that dashboards, and other clients that
+// poll the tasks of many apps, don't put load on the backend.
+//
+// The cached tasks of an app are invalidated when the app is submitted,
+// restarted, removed or run, and when a caller that asked for FreshTasks sees
+// that they changed, so that deployments see fresh state. Stopping a task,
+// and operations on hosts, invalidate everything.
+func CacheTasks(ttl time.Duration) Middleware {
+	return func(s Scheduler) Scheduler {
+		return &cachedScheduler{
+			Scheduler: s,
+			ttl:       ttl,
+			tasks:     make(map[TasksQuery]*cachedTasks),
+		}
+	}
+}
+
+// cachedTasks are the tasks returned for a query.
+type cachedTasks struct {
+	tasks   []*Task
+	expires time.Time
+}
+
+// cachedScheduler wraps a Scheduler to cache the tasks that it returns.
+type cachedScheduler struct {
+	Scheduler
+	ttl time.Duration
+
+	mu    sync.Mutex
+	tasks map[TasksQuery]*cachedTasks
+}
+
+func (s *cachedScheduler) Tasks(ctx context.Context, app string) ([]*Task, error) {
+	return s.cached(ctx, TasksQuery{App: app}, func() ([]*Task, error) {
+		return s.Scheduler.Tasks(ctx, app)
+	})
+}
+
+func (s *cachedScheduler) QueryTasks(ctx context.Context, q TasksQuery) ([]*Task, error) {
+	return s.cached(ctx, q, func() ([]*Task, error) {
+		return s.Scheduler.QueryTasks(ctx, q)
+	})
+}
+
+// cached returns the cached tasks for the query, or gets them with fn if they
+// aren't cached, they expired, or fresh tasks were asked for.
+func (s *cachedScheduler) cached(ctx context.Context, q TasksQuery, fn func() ([]*Task, error)) ([]*Task, error) {
+	fresh := isFreshTasks(ctx)
+
+	s.mu.Lock()
+	c, ok := s.tasks[q]
+	s.mu.Unlock()
+
+	if ok && !fresh && now().Before(c.expires) {
+		return c.tasks, nil
+	}
+
+	tasks, err := fn()
+	if err != nil {
+		return tasks, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Other queries for the app are likely stale as well.
+	if fresh && ok && tasksChanged(c.tasks, tasks) {
+		s.invalidate(q.App)
+	}
+	s.tasks[q] = &cachedTasks{tasks: tasks, expires: now().Add(s.ttl)}
+
+	return tasks, nil
+}
+
+// invalidate removes the cached tasks of the app, and the cached tasks for all
+// apps. An empty app removes everything. The lock must be held.
+func (s *cachedScheduler) invalidate(app string) {
+	for q := range s.tasks {
+		if app == "" || q.App == "" || q.App == app {
+			delete(s.tasks, q)
+		}
+	}
+}
+
+// Invalidate removes the cached tasks of the app, or of every app if app is
+// empty.
+func (s *cachedScheduler) Invalidate(app string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.invalidate(app)
+}
+
+func (s *cachedScheduler) Submit(ctx context.Context, app *Manifest, ss StatusStream) error {
+	defer s.Invalidate(app.AppID)
+	return s.Scheduler.Submit(ctx, app, ss)
+}
+
+func (s *cachedScheduler) Run(ctx context.Context, app *Manifest) error {
+	defer s.Invalidate(app.AppID)
+	return s.Scheduler.Run(ctx, app)
+}
+
+func (s *cachedScheduler) Remove(ctx context.Context, app string) error {
+	defer s.Invalidate(app)
+	return s.Scheduler.Remove(ctx, app)
+}
+
+func (s *cachedScheduler) Restart(ctx context.Context, app string, ss StatusStream) error {
+	defer s.Invalidate(app)
+	return s.Scheduler.Restart(ctx, app, ss)
+}
+
+func (s *cachedScheduler) Stop(ctx context.Context, instanceID string) error {
+	defer s.Invalidate("")
+	return s.Scheduler.Stop(ctx, instanceID)
+}
+
+func (s *cachedScheduler) Cordon(ctx context.Context, hostID string) error {
+	defer s.Invalidate("")
+	return s.Scheduler.Cordon(ctx, hostID)
+}
+
+func (s *cachedScheduler) Uncordon(ctx context.Context, hostID string) error {
+	defer s.Invalidate("")
+	return s.Scheduler.Uncordon(ctx, hostID)
+}
+
+func (s *cachedScheduler) Drain(ctx context.Context, hostID string) error {
+	defer s.Invalidate("")
+	return s.Scheduler.Drain(ctx, hostID)
+}
+
+// tasksChanged returns true if a task was added or removed, or changed state or
+// host.
+func tasksChanged(previous, current []*Task) bool {
+	if len(previous) != len(current) {
+		return true
+	}
+
+	seen := make(map[string]*Task)
+	for _, t := range previous {
+		seen[t.ID] = t
+	}
+
+	for _, t := range current {
+		p, ok := seen[t.ID]
+		if !ok || p.State != t.State || p.Host.ID != t.Host.ID {
+			return true
+		}
+	}
+
+	return false
+}
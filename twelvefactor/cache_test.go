@@ -0,0 +1,84 @@
+package twelvefactor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// countingScheduler returns its tasks, and counts how many times it was asked
+// for them.
+type countingScheduler struct {
+	Scheduler
+	tasks []*Task
+	calls int
+}
+
+func (s *countingScheduler) Tasks(ctx context.Context, app string) ([]*Task, error) {
+	return s.QueryTasks(ctx, TasksQuery{App: app})
+}
+
+func (s *countingScheduler) QueryTasks(ctx context.Context, q TasksQuery) ([]*Task, error) {
+	s.calls++
+	return s.tasks, nil
+}
+
+func (s *countingScheduler) Submit(ctx context.Context, app *Manifest, ss StatusStream) error {
+	return nil
+}
+
+func TestCacheTasks(t *testing.T) {
+	defer func() { now = time.Now }()
+	t0 := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return t0 }
+
+	b := &countingScheduler{tasks: []*Task{{ID: "1", State: "RUNNING"}}}
+	s := Use(b, CacheTasks(10*time.Second))
+	ctx := context.Background()
+
+	s.Tasks(ctx, "app")
+	s.Tasks(ctx, "app")
+	s.QueryTasks(ctx, TasksQuery{App: "app"})
+	assert.Equal(t, 1, b.calls)
+
+	// Other apps are cached separately.
+	s.Tasks(ctx, "other")
+	assert.Equal(t, 2, b.calls)
+
+	// Expired.
+	now = func() time.Time { return t0.Add(10 * time.Second) }
+	s.Tasks(ctx, "app")
+	s.Tasks(ctx, "other")
+	assert.Equal(t, 4, b.calls)
+
+	// Submitting the app invalidates it, but not other apps.
+	s.Submit(ctx, &Manifest{AppID: "app"}, nil)
+	s.Tasks(ctx, "app")
+	s.Tasks(ctx, "other")
+	assert.Equal(t, 5, b.calls)
+}
+
+func TestCacheTasks_FreshTasks(t *testing.T) {
+	b := &countingScheduler{tasks: []*Task{{ID: "1", State: "RUNNING"}}}
+	s := Use(b, CacheTasks(time.Minute))
+	ctx := context.Background()
+
+	s.Tasks(ctx, "app")
+	s.QueryTasks(ctx, TasksQuery{App: "app", Process: "web"})
+	assert.Equal(t, 2, b.calls)
+
+	// Unchanged tasks leave other queries cached.
+	s.Tasks(FreshTasks(ctx), "app")
+	s.QueryTasks(ctx, TasksQuery{App: "app", Process: "web"})
+	assert.Equal(t, 3, b.calls)
+
+	// Changed tasks invalidate other queries for the app, and are cached.
+	b.tasks = []*Task{{ID: "1", State: "STOPPED"}}
+	tasks, _ := s.Tasks(FreshTasks(ctx), "app")
+	assert.Equal(t, b.tasks, tasks)
+	s.Tasks(ctx, "app")
+	s.QueryTasks(ctx, TasksQuery{App: "app", Process: "web"})
+	assert.Equal(t, 5, b.calls)
+}
@@ -3,7 +3,9 @@
 package twelvefactor
 
 import (
+	"fmt"
 	"io"
+	"net"
 	"time"
 
 	"golang.org/x/net/context"
@@ -30,6 +32,65 @@ type Manifest struct {
 
 	// Process that belong to this app.
 	Processes []*Process
+
+	// Credentials that can be used to pull images from private registries.
+	PullSecrets []*PullSecret
+
+	// If not nil, the app is isolated, and the exposed ports of its
+	// processes should only accept connections from these sources.
+	Ingress []*Ingress
+
+	// Rules for routing the requests that the web process receives to
+	// other processes, in the order that they should be evaluated.
+	Routes []*Route
+
+	// If provided, the previous release of the app, which receives
+	// PreviousWeight percent of the traffic to the load balancers of the
+	// processes that it shares with this release.
+	Previous       *Manifest
+	PreviousWeight int
+}
+
+// Ingress allows the processes of another app to connect to a port of an App.
+type Ingress struct {
+	// The name of the app that's allowed to connect.
+	App string
+
+	// The cluster that the app runs in. An empty string represents the
+	// default cluster.
+	Cluster string
+
+	// The port, on the load balancer, that the app can connect to.
+	Port int
+}
+
+// Route routes requests that match a host and/or path to a process.
+type Route struct {
+	// If not empty, only requests for this host match.
+	Host string
+
+	// If not empty, only requests for this path match.
+	Path string
+
+	// The process that matching requests are sent to.
+	Process string
+}
+
+// PullSecret represents credentials for a private Docker registry. Schedulers
+// should use these when pulling images, instead of relying on credentials
+// configured on the host.
+type PullSecret struct {
+	// The registry that these credentials are for (e.g. "quay.io"). An
+	// empty string represents the Docker Hub.
+	Registry string
+
+	// The username and password used to authenticate with the registry.
+	Username string
+	Password string
+
+	// If provided, a reference to these credentials in a scheduler specific
+	// secret store. For ECS, this is the ARN of a Secrets Manager secret.
+	CredentialsParameter string
 }
 
 type Process struct {
@@ -58,6 +119,9 @@ type Process struct {
 	// ulimit -u
 	Nproc uint
 
+	// The number of GPUs to reserve for this process.
+	GPUs uint
+
 	// Quantity is the desired instances of this service to run.
 	Quantity int
 
@@ -70,11 +134,184 @@ type Process struct {
 	// Any ECS specific configuration.
 	ECS *procfile.ECS
 
+	// Containers that should be started and stopped with each instance of
+	// this process.
+	Sidecars []*Sidecar
+
+	// Storage to mount into the container.
+	Volumes []*Volume
+
+	// If provided, the privileges of the container.
+	Security *procfile.Security
+
+	// If provided, bounds the number of instances while the process is
+	// being deployed.
+	Rollout *Rollout
+
+	// If provided, instances should only be registered with the router
+	// (e.g. the load balancer) once they pass this check, and should be
+	// deregistered while they fail it.
+	Readiness *HealthCheck
+
+	// If provided, instances should be restarted when they fail this
+	// check.
+	Liveness *HealthCheck
+
+	// If provided, where the output of the process should be sent, instead
+	// of the default of the implementation.
+	Logging *Logging
+
+	// If true, exactly one instance of the process should run on each
+	// machine. Quantity is the number of machines, at the time the
+	// process was submitted.
+	Daemon bool
+
+	// If true, at most one instance of the process should run at a time.
+	// When the process is updated, the old instance needs to be fully
+	// stopped before the new instance is started.
+	Singleton bool
+
+	// For scheduled processes, what should happen when the process is
+	// triggered while a previous instance is still running: "allow",
+	// "skip", "queue" or "replace". Empty means "allow".
+	Overlap string
+
+	// The capacity pool that instances should be placed on: CapacitySpot
+	// or CapacityOnDemand. Empty means that instances can be placed on any
+	// machine.
+	Capacity string
+
+	// How instances should be placed on machines: PlacementBinpack or
+	// PlacementSpread. Empty means the default of the scheduler.
+	Placement string
+
 	// Input/Output streams.
 	Stdin          io.Reader
 	Stdout, Stderr io.Writer
 }
 
+// Rollout bounds the number of instances of a process while it's being
+// deployed, as percentages of the desired number of instances.
+type Rollout struct {
+	// How many instances can be started above the desired number.
+	MaxSurge int
+
+	// How many instances can be stopped below the desired number.
+	MaxUnavailable int
+}
+
+// Logging configures where the output of a Process is sent.
+type Logging struct {
+	// The Docker log driver (e.g. "json-file", "journald" or "syslog").
+	Driver string
+
+	// Options for the log driver (e.g. "max-size").
+	Options map[string]string
+}
+
+// HealthCheck checks the instances of a Process. Zero values use the defaults
+// of the implementation.
+type HealthCheck struct {
+	// For readiness checks, the HTTP path that's requested. An empty path
+	// checks that a TCP connection can be made to the process.
+	Path string
+
+	// For liveness checks, the command that's run inside the container.
+	Command []string
+
+	Interval time.Duration
+	Timeout  time.Duration
+
+	// The number of consecutive checks that need to pass, or fail, before
+	// an instance is considered healthy, or unhealthy.
+	HealthyThreshold   int
+	UnhealthyThreshold int
+
+	// For liveness checks, how long failures are ignored for after an
+	// instance starts.
+	StartPeriod time.Duration
+}
+
+// Sidecar represents a container that runs alongside each instance of a
+// Process. Sidecars share the network of the process, and inherit its
+// environment.
+type Sidecar struct {
+	// A name for the container, unique within the process.
+	Name string
+
+	// The Image to run.
+	Image image.Image
+
+	// If provided, the Command to run.
+	Command []string
+
+	// Environment variables to set, in addition to the environment of the
+	// process.
+	Env map[string]string
+
+	// The amount of RAM to allocate to this container in bytes.
+	Memory uint
+
+	// If true, the process should be stopped when this container exits.
+	Essential bool
+}
+
+// Capacity pools that machines belong to.
+const (
+	// CapacitySpot is capacity that's cheaper, but can be reclaimed by
+	// the provider at short notice (e.g. EC2 spot instances).
+	CapacitySpot = "spot"
+
+	// CapacityOnDemand is capacity that isn't reclaimed.
+	CapacityOnDemand = "on-demand"
+)
+
+// Placement strategies, which decide how instances are placed on machines.
+const (
+	// PlacementBinpack places instances on as few machines as possible,
+	// to reduce cost.
+	PlacementBinpack = "binpack"
+
+	// PlacementSpread places instances across as many availability zones,
+	// and machines, as possible, to tolerate the loss of a machine or
+	// zone.
+	PlacementSpread = "spread"
+)
+
+// Volume types.
+const (
+	// VolumeHost mounts a path from the host.
+	VolumeHost = "host"
+
+	// VolumeTmpfs mounts an in-memory filesystem.
+	VolumeTmpfs = "tmpfs"
+
+	// VolumeEBS mounts an EBS volume that persists across instances of the
+	// process.
+	VolumeEBS = "ebs"
+)
+
+// Volume represents storage that's mounted into the container of a Process.
+type Volume struct {
+	// A name for the volume, unique within the process.
+	Name string
+
+	// The type of volume (e.g. VolumeHost).
+	Type string
+
+	// For VolumeHost, the path on the host.
+	Source string
+
+	// The path to mount the volume at within the container.
+	Path string
+
+	// For VolumeTmpfs and VolumeEBS, the size of the volume in bytes.
+	Size uint
+
+	// If true, the volume is mounted read only.
+	ReadOnly bool
+}
+
 // Schedule represents a Schedule for scheduled tasks that run periodically.
 type Schedule interface{}
 
@@ -91,8 +328,48 @@ type Exposure struct {
 
 	// The ports to expose and map to the container.
 	Ports []Port
+
+	// How long a connection can be idle before it's closed. The zero
+	// value uses the default of the implementation.
+	IdleTimeout time.Duration
+
+	// How long in flight requests to an old instance of the process are
+	// given to finish before it's stopped. Nil uses the default of the
+	// implementation, and 0 stops it immediately.
+	DrainTimeout *time.Duration
+
+	// When true, connections can be upgraded to WebSockets.
+	WebSockets bool
+
+	// When true, requests from the same client should be sent to the same
+	// instance of the process.
+	StickySessions bool
+
+	// The version of HTTP that's used to send requests to the process. The
+	// zero value is ProtocolVersionHTTP1.
+	ProtocolVersion string
+
+	// If not empty, only connections from these CIDR blocks should be
+	// accepted.
+	AllowedCIDRs []string
+
+	// If not nil, requests without these credentials should be rejected.
+	BasicAuth *BasicAuth
+}
+
+// BasicAuth is a set of HTTP basic authentication credentials.
+type BasicAuth struct {
+	Username string
+	Password string
 }
 
+// Versions of HTTP that a process can be sent requests with.
+const (
+	ProtocolVersionHTTP1 = "http1"
+	ProtocolVersionHTTP2 = "http2"
+	ProtocolVersionGRPC  = "grpc"
+)
+
 // Port maps a host port to a container port.
 type Port struct {
 	// The port that external applications will connect to. It's
@@ -143,10 +420,58 @@ func (e *SSL) Protocol() string { return "ssl" }
 type Host struct {
 	// The host ID.
 	ID string
+
+	// Lost is true when the host has disappeared, or the scheduler can no
+	// longer communicate with it. Instances on a lost host should be
+	// considered gone, and won't be replaced until they're stopped.
+	Lost bool
+
+	// Draining is true when instances on the host are being moved to other
+	// hosts (e.g. because the host is being reclaimed).
+	Draining bool
+
+	// The capacity pool that the host belongs to. Hosts that aren't in a
+	// pool are on-demand.
+	Capacity string
+
+	// The availability zone that the host is in, if known.
+	Zone string
+}
+
+// Resources represents an amount of compute resources.
+type Resources struct {
+	// CPU units, where 1024 units is a full CPU core. This is the same unit
+	// as Process.CPUShares.
+	CPU uint
+
+	// Memory, in bytes.
+	Memory uint
+
+	// The number of GPUs.
+	GPU uint
+}
+
+// Machine represents a host that instances can be placed on, and how much of
+// its resources are already reserved by instances.
+type Machine struct {
+	Host Host
+
+	// The resources that the host makes available to instances.
+	Total Resources
+
+	// The resources reserved by instances running on the host.
+	Allocated Resources
+
+	// The number of instances running on the host.
+	Tasks int
 }
 
 // Task represents an Task of a Process.
 type Task struct {
+	// The id of the app that this instance belongs to. This is set by
+	// QueryTasks.
+	App string
+
 	Process *Process
 
 	// The instance ID.
@@ -160,6 +485,43 @@ type Task struct {
 
 	// The time that this instance was last updated.
 	UpdatedAt time.Time
+
+	// For instances that have stopped, the exit code of the process, if
+	// it's known.
+	ExitCode *int
+}
+
+// TasksQuery scopes the instances that are returned from QueryTasks. Fields
+// that are empty match every instance.
+type TasksQuery struct {
+	// The id of the app that the instances belong to. When empty,
+	// instances of every app are returned.
+	App string
+
+	// The process type that the instances are running.
+	Process string
+
+	// The id of the host that the instances are running on.
+	Host string
+}
+
+// Matches returns true if the instance matches the query. Backends that can't
+// filter instances by everything in the query use this to filter what they
+// return.
+func (q TasksQuery) Matches(t *Task) bool {
+	if q.App != "" && t.App != q.App {
+		return false
+	}
+
+	if q.Process != "" && (t.Process == nil || t.Process.Type != q.Process) {
+		return false
+	}
+
+	if q.Host != "" && t.Host.ID != q.Host {
+		return false
+	}
+
+	return true
 }
 
 // Scheduler is an interface for interfacing with Services.
@@ -180,12 +542,74 @@ type Scheduler interface {
 	// Instance lists the instances of a Process for an app.
 	Tasks(ctx context.Context, app string) ([]*Task, error)
 
+	// QueryTasks lists the running instances that match the query. This
+	// lets the backend only look at the instances of a single process, or
+	// host, or look at the instances of every app at once, rather than
+	// listing every instance of every app and filtering them.
+	QueryTasks(ctx context.Context, q TasksQuery) ([]*Task, error)
+
+	// StoppedTasks lists the one-off instances of an app that have
+	// recently stopped, with their exit codes.
+	StoppedTasks(ctx context.Context, app string) ([]*Task, error)
+
+	// Cleanup removes anything that the scheduler kept around to run the
+	// one-off instances of an app that have since stopped.
+	Cleanup(ctx context.Context, app string) error
+
 	// Stop stops an instance. The scheduler will automatically start a new
 	// instance.
 	Stop(ctx context.Context, instanceID string) error
 
 	// Restart restarts the processes within the App.
 	Restart(context.Context, string, StatusStream) error
+
+	// Exec runs a command inside of an already running instance of the app.
+	Exec(ctx context.Context, app string, instanceID string, opts ExecOpts) error
+
+	// Dial opens a TCP connection to the given port inside of an already
+	// running instance of the app. This is used to forward a local port to
+	// an instance, without exposing the port publicly.
+	Dial(ctx context.Context, app string, instanceID string, port int) (net.Conn, error)
+
+	// CopyTo extracts a tar archive into the directory at path, inside of
+	// an already running instance of the app.
+	CopyTo(ctx context.Context, app string, instanceID string, path string, archive io.Reader) error
+
+	// CopyFrom writes a tar archive of the file or directory at path,
+	// inside of an already running instance of the app, to w.
+	CopyFrom(ctx context.Context, app string, instanceID string, path string, w io.Writer) error
+
+	// Cordon marks a host as unschedulable, so that no new instances are
+	// placed on it. Instances already running on the host are left alone.
+	Cordon(ctx context.Context, hostID string) error
+
+	// Uncordon marks a cordoned or drained host as schedulable again.
+	Uncordon(ctx context.Context, hostID string) error
+
+	// Drain cordons a host, then moves the instances running on it to other
+	// hosts. Instances should be replaced without letting the number of
+	// healthy instances of a process drop below what the scheduler would
+	// allow during a deployment.
+	Drain(ctx context.Context, hostID string) error
+
+	// Machines lists the hosts that instances can be placed on, with their
+	// total and allocated resources.
+	Machines(ctx context.Context) ([]*Machine, error)
+}
+
+// ExecOpts are options provided when running a command inside of a running
+// instance.
+type ExecOpts struct {
+	// The command to run.
+	Command []string
+
+	// Whether a pseudo-TTY should be allocated for the command.
+	Tty bool
+
+	// Streams to attach to the command.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
 }
 
 // Trasnform wraps a Scheduler to perform transformations on the Manifest. This
@@ -213,17 +637,56 @@ func (t *transformer) Run(ctx context.Context, app *Manifest) error {
 	return t.Scheduler.Run(ctx, t.Transform(app))
 }
 
+// Middleware wraps a Scheduler to add behavior around it, like caching,
+// without changing the backend.
+type Middleware func(Scheduler) Scheduler
+
+// Use wraps the Scheduler with the middleware. The first middleware is the
+// outermost, so it sees each call first.
+func Use(s Scheduler, middleware ...Middleware) Scheduler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		s = middleware[i](s)
+	}
+	return s
+}
+
 // Env merges the App environment with any environment variables provided
 // in the process.
 func Env(app *Manifest, process *Process) map[string]string {
 	return merge(app.Env, process.Env)
 }
 
+// SidecarEnv merges the App and Process environment with any environment
+// variables provided in the sidecar.
+func SidecarEnv(app *Manifest, process *Process, sidecar *Sidecar) map[string]string {
+	return merge(app.Env, process.Env, sidecar.Env)
+}
+
 // Labels merges the App labels with any labels provided in the process.
 func Labels(app *Manifest, process *Process) map[string]string {
 	return merge(app.Labels, process.Labels)
 }
 
+// ImagePullSecret returns the PullSecret from the App that matches the registry
+// of the process image, or nil if there isn't one.
+func ImagePullSecret(app *Manifest, process *Process) *PullSecret {
+	for _, secret := range app.PullSecrets {
+		if secret.Registry == process.Image.Registry {
+			return secret
+		}
+	}
+	return nil
+}
+
+// SecurityOpts returns the Docker security options for the process, which
+// is where the seccomp profile is set.
+func SecurityOpts(process *Process) []string {
+	if process.Security == nil || process.Security.SeccompProfile == "" {
+		return nil
+	}
+	return []string{fmt.Sprintf("seccomp=%s", process.Security.SeccompProfile)}
+}
+
 // merges the maps together, favoring keys from the right to the left.
 func merge(envs ...map[string]string) map[string]string {
 	merged := make(map[string]string)
@@ -235,9 +698,34 @@ func merge(envs ...map[string]string) map[string]string {
 	return merged
 }
 
+// Phases of a deployment that can be published to a StatusStream, so that
+// clients can display structured progress.
+const (
+	// The image is being pulled.
+	PhaseImagePull = "image_pull"
+
+	// The new version has been submitted to the scheduler.
+	PhaseScheduled = "scheduled"
+
+	// New tasks have started, and are waiting to pass health checks.
+	PhaseHealthPending = "health_pending"
+
+	// New tasks are healthy.
+	PhaseHealthPassed = "health_passed"
+
+	// Tasks from the previous version have been removed.
+	PhaseRemoved = "removed"
+)
+
 type Status struct {
 	// A friendly human readable message about the status change.
 	Message string
+
+	// If provided, the phase of the deployment that this status represents.
+	Phase string
+
+	// If provided, the process that this status relates to.
+	Process string
 }
 
 // String implements the fmt.Stringer interface.
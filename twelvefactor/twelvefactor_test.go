@@ -0,0 +1,36 @@
+package twelvefactor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type recordingScheduler struct {
+	Scheduler
+	name  string
+	calls *[]string
+}
+
+func (s *recordingScheduler) Submit(ctx context.Context, app *Manifest, ss StatusStream) error {
+	*s.calls = append(*s.calls, s.name)
+	if s.Scheduler == nil {
+		return nil
+	}
+	return s.Scheduler.Submit(ctx, app, ss)
+}
+
+func TestUse(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(s Scheduler) Scheduler {
+			return &recordingScheduler{Scheduler: s, name: name, calls: &calls}
+		}
+	}
+
+	s := Use(&recordingScheduler{name: "backend", calls: &calls}, record("a"), record("b"))
+	assert.NoError(t, s.Submit(context.Background(), &Manifest{Name: "acme-inc"}, nil))
+
+	assert.Equal(t, []string{"a", "b", "backend"}, calls)
+}
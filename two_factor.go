@@ -0,0 +1,201 @@
+package empire
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/remind101/empire/pkg/totp"
+)
+
+var (
+	// ErrTwoFactorNotEnrolled is returned when a two factor code is
+	// verified for a user that hasn't enrolled.
+	ErrTwoFactorNotEnrolled = errors.New("You have not enrolled in two factor authentication.")
+
+	// ErrTwoFactorCode is returned when a two factor code is invalid, or
+	// has already been used.
+	ErrTwoFactorCode = errors.New("Two factor code is invalid.")
+
+	// ErrTwoFactorRequired is returned when a destructive operation on a
+	// protected app is performed without a two factor code.
+	ErrTwoFactorRequired = errors.New("Two factor code is required.")
+)
+
+// TwoFactorCode is a two factor code that's provided to confirm destructive
+// operations on protected apps. A code can only be accepted once, so once it's
+// verified, the same TwoFactorCode confirms the other operations that are
+// performed for the same request, like the changes made when an AppSpec is
+// applied.
+type TwoFactorCode struct {
+	// The code from the user's authenticator app.
+	Code string
+
+	// The user that the code was verified for, once it's verified.
+	verifiedFor string
+}
+
+// TwoFactorSecret is the TOTP secret that a user has enrolled with, which is
+// used to confirm destructive operations on protected apps.
+type TwoFactorSecret struct {
+	// A unique uuid that identifies the secret.
+	ID string
+
+	// The user that enrolled.
+	Username string
+
+	// The base32 encoded TOTP secret.
+	Secret string
+
+	// The time step of the last code that was accepted. Codes for this step,
+	// or earlier steps, are rejected, so that they can't be replayed.
+	LastStep int64
+
+	// The time that the user enrolled.
+	CreatedAt *time.Time
+}
+
+// BeforeCreate sets created_at before inserting.
+func (s *TwoFactorSecret) BeforeCreate() error {
+	t := timex.Now()
+	s.CreatedAt = &t
+	return nil
+}
+
+// URL returns an otpauth:// url that can be scanned by authenticator apps.
+func (s *TwoFactorSecret) URL() string {
+	return totp.URL("Empire", s.Username, s.Secret)
+}
+
+// TwoFactorSecretsQuery is a scope implementation for common things to filter
+// two factor secrets by.
+type TwoFactorSecretsQuery struct {
+	// If provided, finds the secret for the given user.
+	Username *string
+}
+
+// scope implements the scope interface.
+func (q TwoFactorSecretsQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.Username != nil {
+		scope = append(scope, fieldEquals("username", *q.Username))
+	}
+
+	return scope.scope(db)
+}
+
+// twoFactorSecretsFind returns the first matching two factor secret.
+func twoFactorSecretsFind(db *gorm.DB, scope scope) (*TwoFactorSecret, error) {
+	var secret TwoFactorSecret
+	return &secret, first(db, scope, &secret)
+}
+
+func twoFactorSecretsCreate(db *gorm.DB, secret *TwoFactorSecret) (*TwoFactorSecret, error) {
+	return secret, db.Create(secret).Error
+}
+
+func twoFactorSecretsDestroy(db *gorm.DB, username string) error {
+	return db.Where("username = ?", username).Delete(TwoFactorSecret{}).Error
+}
+
+// twoFactorSecretsUse records that a code for the time step was accepted. It
+// returns false if a code for the step, or a later step, was already accepted,
+// which includes a concurrent use of the same code.
+func twoFactorSecretsUse(db *gorm.DB, secret *TwoFactorSecret, step int64) (bool, error) {
+	result := db.Exec(`update two_factor_secrets set last_step = ? where id = ? and last_step < ?`, step, secret.ID, step)
+	if err := result.Error; err != nil {
+		return false, err
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	secret.LastStep = step
+	return true, nil
+}
+
+// verifyTwoFactorSecret returns nil if the code is valid for the secret, and
+// hasn't been used before.
+func verifyTwoFactorSecret(db *gorm.DB, secret *TwoFactorSecret, code string) error {
+	step, ok := totp.Verify(secret.Secret, code, timex.Now())
+	if !ok {
+		return ErrTwoFactorCode
+	}
+
+	ok, err := twoFactorSecretsUse(db, secret, int64(step))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTwoFactorCode
+	}
+
+	return nil
+}
+
+// requireTwoFactor returns an error if the app is protected, and the code isn't
+// a valid two factor code for the user. It's checked by destructive
+// operations, like destroying the app, scaling it to zero, or removing config
+// vars, so that it's enforced no matter how the operation is performed.
+func (e *Empire) requireTwoFactor(user *User, app *App, code *TwoFactorCode) error {
+	if !app.Protected {
+		return nil
+	}
+
+	if code == nil || code.Code == "" {
+		return ErrTwoFactorRequired
+	}
+
+	if user != nil && code.verifiedFor == user.Name {
+		return nil
+	}
+
+	if err := e.TwoFactorVerify(user, code.Code); err != nil {
+		return err
+	}
+
+	code.verifiedFor = user.Name
+	return nil
+}
+
+// TwoFactorEnrollOpts are options provided when enrolling in two factor
+// authentication.
+type TwoFactorEnrollOpts struct {
+	// User performing the action.
+	User *User
+
+	// The user to enroll. If empty, the user performing the action is
+	// enrolled. Only admins can enroll other users.
+	Username string
+
+	// If the user is already enrolled, a valid code for the current secret
+	// is required to replace it.
+	Code string
+}
+
+// TwoFactorResetOpts are options provided when removing a user's two factor
+// secret, for example when they've lost their device.
+type TwoFactorResetOpts struct {
+	// User performing the action.
+	User *User
+
+	// The user to reset.
+	Username string
+}
+
+// SetProtectedOpts are options provided when protecting, or unprotecting, an
+// app.
+type SetProtectedOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// Whether the app should be protected or not.
+	Protected bool
+
+	// Removing protection requires a two factor code.
+	TwoFactor *TwoFactorCode
+}
@@ -0,0 +1,24 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmpire_requireTwoFactor(t *testing.T) {
+	e := &Empire{}
+	user := &User{Name: "ejholmes"}
+
+	// Apps that aren't protected don't require a code.
+	assert.NoError(t, e.requireTwoFactor(user, &App{Name: "acme-inc"}, nil))
+
+	app := &App{Name: "acme-inc", Protected: true}
+	assert.Equal(t, ErrTwoFactorRequired, e.requireTwoFactor(user, app, nil))
+	assert.Equal(t, ErrTwoFactorRequired, e.requireTwoFactor(user, app, &TwoFactorCode{}))
+
+	// A code that's been verified for the user confirms the other
+	// operations of the request, without being verified again.
+	code := &TwoFactorCode{Code: "123456", verifiedFor: "ejholmes"}
+	assert.NoError(t, e.requireTwoFactor(user, app, code))
+}
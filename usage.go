@@ -0,0 +1,262 @@
+package empire
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	. "github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/pkg/timex"
+	"golang.org/x/net/context"
+)
+
+// UsagePeriod records the resources that an app had reserved in the cluster
+// over a period of time. A new period is started whenever a release changes
+// the number of instances, or memory, of an app. Usage reports are calculated
+// from these, so that platform costs can be attributed to the teams that own
+// the apps.
+type UsagePeriod struct {
+	// A unique uuid that identifies the period.
+	ID string
+
+	// The app that the resources were reserved by. The name and team are
+	// recorded too, so that usage can be reported after an app is destroyed,
+	// or moved to another team.
+	AppID   string
+	AppName string
+	Team    string
+
+	// The number of instances of all processes.
+	Instances uint
+
+	// The memory reserved by all instances, in bytes.
+	Memory uint
+
+	// The time that the period started.
+	StartedAt time.Time
+
+	// The time that the period ended. Nil if this is the current usage of
+	// the app.
+	EndedAt *time.Time
+}
+
+// hours returns the number of hours that the period overlaps with the given
+// time range.
+func (p *UsagePeriod) hours(start, end time.Time) float64 {
+	if p.StartedAt.After(start) {
+		start = p.StartedAt
+	}
+	if p.EndedAt != nil && p.EndedAt.Before(end) {
+		end = *p.EndedAt
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start).Hours()
+}
+
+// UsagePeriodsQuery is a scope implementation for common things to filter
+// usage periods by.
+type UsagePeriodsQuery struct {
+	// If provided, finds periods for the given app.
+	App *App
+
+	// If true, only finds periods that haven't ended.
+	Open bool
+
+	// If provided, finds periods that overlap with the range [Start, End).
+	Start, End *time.Time
+}
+
+// scope implements the scope interface.
+func (q UsagePeriodsQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.App != nil {
+		scope = append(scope, forApp(q.App))
+	}
+
+	if q.Open {
+		scope = append(scope, isNull("ended_at"))
+	}
+
+	if q.Start != nil {
+		scope = append(scope, scopeFunc(func(db *gorm.DB) *gorm.DB {
+			return db.Where("ended_at is null or ended_at > ?", *q.Start)
+		}))
+	}
+
+	if q.End != nil {
+		scope = append(scope, scopeFunc(func(db *gorm.DB) *gorm.DB {
+			return db.Where("started_at < ?", *q.End)
+		}))
+	}
+
+	scope = append(scope, order("started_at asc"))
+
+	return scope.scope(db)
+}
+
+// usagePeriods returns all usage periods matching the scope.
+func usagePeriods(db *gorm.DB, scope scope) ([]*UsagePeriod, error) {
+	var periods []*UsagePeriod
+	return periods, find(db, scope, &periods)
+}
+
+// recordUsage ends the current usage period for the app, and starts a new one
+// if the app now has resources reserved. Nothing is recorded if the usage
+// didn't change.
+func recordUsage(db *gorm.DB, app *App, u usage) error {
+	now := timex.Now()
+
+	var current UsagePeriod
+	err := first(db, UsagePeriodsQuery{App: app, Open: true}, &current)
+	switch err {
+	case nil:
+		if current.Instances == u.Instances && current.Memory == u.Memory && current.Team == app.Team {
+			return nil
+		}
+
+		current.EndedAt = &now
+		if err := db.Save(&current).Error; err != nil {
+			return err
+		}
+	case gorm.RecordNotFound:
+	default:
+		return err
+	}
+
+	if u.Instances == 0 {
+		return nil
+	}
+
+	return db.Create(&UsagePeriod{
+		AppID:     app.ID,
+		AppName:   app.Name,
+		Team:      app.Team,
+		Instances: u.Instances,
+		Memory:    u.Memory,
+		StartedAt: now,
+	}).Error
+}
+
+// AppUsage is the resources that an app reserved in a month.
+type AppUsage struct {
+	// The name of the app. When usage is reported by team, this is empty.
+	App string
+
+	// The team that owned the app while the resources were reserved.
+	Team string
+
+	// The first day of the month.
+	Month time.Time
+
+	// The sum of the number of instances reserved, multiplied by the number
+	// of hours that they were reserved.
+	InstanceHours float64
+
+	// The sum of the memory reserved, in GB, multiplied by the number of
+	// hours that it was reserved.
+	MemoryHours float64
+}
+
+// usageReport sums the usage periods that overlap with the month, by app and
+// team. The report is ordered by the time that the app first reserved
+// resources in the month. When byTeam is true, usage is summed by team only.
+func usageReport(periods []*UsagePeriod, month time.Time, byTeam bool) []*AppUsage {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	if now := timex.Now(); now.Before(end) {
+		end = now
+	}
+
+	type key struct{ app, team string }
+
+	var report []*AppUsage
+	byKey := make(map[key]*AppUsage)
+	for _, p := range periods {
+		h := p.hours(start, end)
+		if h == 0 {
+			continue
+		}
+
+		k := key{app: p.AppName, team: p.Team}
+		if byTeam {
+			k.app = ""
+		}
+
+		u, ok := byKey[k]
+		if !ok {
+			u = &AppUsage{App: k.app, Team: k.team, Month: start}
+			byKey[k] = u
+			report = append(report, u)
+		}
+
+		u.InstanceHours += float64(p.Instances) * h
+		u.MemoryHours += float64(p.Memory) / float64(GB) * h
+	}
+
+	return report
+}
+
+// UsageOpts are options provided when reporting usage.
+type UsageOpts struct {
+	// User performing the action.
+	User *User
+
+	// The month to report usage for. Any time within the month can be
+	// provided.
+	Month time.Time
+
+	// If true, usage is summed by team, rather than by app.
+	ByTeam bool
+
+	// If provided, only reports usage of apps with labels matching the
+	// selector.
+	Selector LabelSelector
+}
+
+// Usage reports the instance hours and memory hours that each app, or team,
+// reserved in the month.
+func (e *Empire) Usage(ctx context.Context, opts UsageOpts) ([]*AppUsage, error) {
+	if err := e.authorize(opts.User, nil, ActionAdmin); err != nil {
+		return nil, err
+	}
+
+	start := time.Date(opts.Month.Year(), opts.Month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	periods, err := usagePeriods(e.dbr, UsagePeriodsQuery{Start: &start, End: &end})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.Selector) > 0 {
+		periods, err = selectUsagePeriods(e.dbr, periods, opts.Selector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return usageReport(periods, start, opts.ByTeam), nil
+}
+
+// selectUsagePeriods returns the periods of apps with labels matching the
+// selector, including apps that have since been destroyed.
+func selectUsagePeriods(db *gorm.DB, periods []*UsagePeriod, selector LabelSelector) ([]*UsagePeriod, error) {
+	var selected []*App
+	if err := find(db, selector, &selected); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]bool)
+	for _, a := range selected {
+		ids[a.ID] = true
+	}
+
+	var matched []*UsagePeriod
+	for _, p := range periods {
+		if ids[p.AppID] {
+			matched = append(matched, p)
+		}
+	}
+	return matched, nil
+}
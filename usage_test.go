@@ -0,0 +1,67 @@
+package empire
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/pkg/timex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsagePeriodsQuery(t *testing.T) {
+	var (
+		app   = &App{ID: "4321"}
+		start = time.Date(2016, 12, 1, 0, 0, 0, 0, time.UTC)
+		end   = time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+
+	tests := scopeTests{
+		{UsagePeriodsQuery{}, "ORDER BY started_at asc", []interface{}{}},
+		{UsagePeriodsQuery{App: app, Open: true}, "WHERE (app_id = $1) AND (ended_at is null) ORDER BY started_at asc", []interface{}{"4321"}},
+		{UsagePeriodsQuery{Start: &start, End: &end}, "WHERE (ended_at is null or ended_at > $1) AND (started_at < $2) ORDER BY started_at asc", []interface{}{start, end}},
+	}
+
+	tests.Run(t)
+}
+
+func TestUsageReport(t *testing.T) {
+	now := timex.Now
+	defer func() { timex.Now = now }()
+	timex.Now = func() time.Time {
+		return time.Date(2017, 1, 15, 0, 0, 0, 0, time.UTC)
+	}
+
+	at := func(month time.Month, day int) time.Time {
+		return time.Date(2016, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	ended := func(month time.Month, day int) *time.Time {
+		t := at(month, day)
+		return &t
+	}
+
+	periods := []*UsagePeriod{
+		// Started before the month, and ended half way through it.
+		{AppName: "acme-inc", Team: "platform", Instances: 2, Memory: 1 * GB, StartedAt: at(11, 20), EndedAt: ended(12, 11)},
+		// Still running.
+		{AppName: "acme-inc", Team: "platform", Instances: 4, Memory: 2 * GB, StartedAt: at(12, 11)},
+		// Ended before the month.
+		{AppName: "blog", Team: "marketing", Instances: 1, Memory: 1 * GB, StartedAt: at(10, 1), EndedAt: ended(11, 1)},
+		{AppName: "api", Team: "platform", Instances: 1, Memory: 512 * MB, StartedAt: at(12, 1), EndedAt: ended(12, 2)},
+	}
+
+	month := at(12, 25)
+	assert.Equal(t, []*AppUsage{
+		{App: "acme-inc", Team: "platform", Month: at(12, 1), InstanceHours: 2*240 + 4*504, MemoryHours: 1*240 + 2*504},
+		{App: "api", Team: "platform", Month: at(12, 1), InstanceHours: 24, MemoryHours: 12},
+	}, usageReport(periods, month, false))
+
+	assert.Equal(t, []*AppUsage{
+		{Team: "platform", Month: at(12, 1), InstanceHours: 2*240 + 4*504 + 24, MemoryHours: 1*240 + 2*504 + 12},
+	}, usageReport(periods, month, true))
+
+	// The current month only includes usage up until now.
+	assert.Equal(t, []*AppUsage{
+		{App: "acme-inc", Team: "platform", Month: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), InstanceHours: 4 * 336, MemoryHours: 2 * 336},
+	}, usageReport(periods, timex.Now(), false))
+}
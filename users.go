@@ -7,6 +7,15 @@ type User struct {
 
 	// GitHubToken is a GitHub access token.
 	GitHubToken string `json:"-"`
+
+	// Teams are the teams that the identity provider reports the user as
+	// a member of (e.g. GitHub teams). These are used, along with team
+	// memberships stored in Empire, to resolve team grants.
+	Teams []string `json:"teams,omitempty"`
+
+	// If provided, restricts the apps and actions that this user can
+	// access. This is set for users authenticated with an APIToken.
+	Scope *Scope `json:"-"`
 }
 
 // IsValid returns nil if the User is valid.
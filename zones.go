@@ -0,0 +1,141 @@
+package empire
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/net/context"
+)
+
+// zoneWeights returns the share of the memory in the cluster that's in each
+// availability zone, which is the share of each process's instances that
+// should be placed in the zone. Machines that are lost, being drained, or
+// whose zone isn't known, aren't counted, so the weights change as machines
+// come and go.
+func zoneWeights(machines []*Machine) map[string]float64 {
+	memory := make(map[string]float64)
+	var total float64
+	for _, m := range machines {
+		if m.Host.Lost || m.Host.Draining || m.Host.Zone == "" {
+			continue
+		}
+		memory[m.Host.Zone] += float64(m.Total.Memory)
+		total += float64(m.Total.Memory)
+	}
+
+	weights := make(map[string]float64)
+	for zone, m := range memory {
+		if total > 0 {
+			weights[zone] = m / total
+		}
+	}
+	return weights
+}
+
+// zoneSkew is how far the instances of a process are from being balanced
+// across availability zones.
+type zoneSkew struct {
+	// The process.
+	Process string
+
+	// The zone with the most instances above its share.
+	Zone string
+
+	// How many instances the zone has above its share.
+	Skew float64
+
+	// An instance in the zone, which can be stopped so that it's replaced
+	// in another zone.
+	Task *Task
+}
+
+// zoneSkews returns the skew of each process whose instances have more than
+// maxSkew instances above their share in a zone, sorted by process. Processes
+// that have instances that aren't running, or that are on hosts that are
+// lost, being drained, or in an unknown zone, are still being placed, and
+// aren't included.
+func zoneSkews(tasks []*Task, weights map[string]float64, maxSkew int) []*zoneSkew {
+	if len(weights) == 0 {
+		return nil
+	}
+
+	byProcess := make(map[string][]*Task)
+	for _, t := range tasks {
+		if t.State == "STOPPED" {
+			continue
+		}
+		byProcess[t.Type] = append(byProcess[t.Type], t)
+	}
+
+	var skews []*zoneSkew
+	for process, tasks := range byProcess {
+		if skew := processZoneSkew(process, tasks, weights); skew != nil && skew.Skew > float64(maxSkew) {
+			skews = append(skews, skew)
+		}
+	}
+
+	sort.Slice(skews, func(i, j int) bool { return skews[i].Process < skews[j].Process })
+	return skews
+}
+
+func processZoneSkew(process string, tasks []*Task, weights map[string]float64) *zoneSkew {
+	byZone := make(map[string][]*Task)
+	for _, t := range tasks {
+		if t.State != "RUNNING" || t.Host.Lost || t.Host.Draining || t.Host.Zone == "" {
+			return nil
+		}
+		byZone[t.Host.Zone] = append(byZone[t.Host.Zone], t)
+	}
+
+	var skew *zoneSkew
+	for zone, ts := range byZone {
+		// Zones without any usable machines have a weight of 0, so
+		// all of their instances are above their share.
+		over := float64(len(ts)) - float64(len(tasks))*weights[zone]
+		if skew == nil || over > skew.Skew || (over == skew.Skew && zone < skew.Zone) {
+			sort.Slice(ts, func(i, j int) bool { return ts[i].Name < ts[j].Name })
+			skew = &zoneSkew{Process: process, Zone: zone, Skew: over, Task: ts[0]}
+		}
+	}
+	return skew
+}
+
+// rebalanceZones stops an instance of each process of the app whose instances
+// are skewed towards an availability zone by more than MaxZoneSkew instances,
+// so that the scheduler replaces it in a zone with less than its share. Only
+// one instance of a process is stopped at a time, so the process is brought
+// back into balance over several passes, without losing much of its capacity.
+func (s *rescheduleService) rebalanceZones(ctx context.Context, scheduler Scheduler, app *App, tasks []*Task, weights map[string]float64) ([]*Task, error) {
+	var (
+		rebalanced []*Task
+		errors     []error
+	)
+	for _, skew := range zoneSkews(tasks, weights, s.MaxZoneSkew) {
+		if err := unscheduleTask(s.db, app, skew.Task, fmt.Sprintf("rebalanced out of %s", skew.Zone)); err != nil {
+			errors = append(errors, err)
+		}
+
+		if err := scheduler.Stop(ctx, skew.Task.ID); err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		rebalanced = append(rebalanced, skew.Task)
+
+		if err := s.PublishEvent(ZoneRebalanceEvent{
+			App:     app.Name,
+			PID:     skew.Task.Name,
+			Process: skew.Process,
+			Zone:    skew.Zone,
+			app:     app,
+		}); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return rebalanced, &multiError{Errors: errors}
+	}
+
+	return rebalanced, nil
+}
@@ -0,0 +1,107 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZoneWeights(t *testing.T) {
+	gb := constraints.Memory(bytesize.GB)
+	machines := []*Machine{
+		{Host: Host{ID: "i-1", Zone: "us-east-1a"}, Total: Resources{Memory: 2 * gb}},
+		{Host: Host{ID: "i-2", Zone: "us-east-1a"}, Total: Resources{Memory: 4 * gb}},
+		{Host: Host{ID: "i-3", Zone: "us-east-1b"}, Total: Resources{Memory: 2 * gb}},
+		{Host: Host{ID: "i-4", Zone: "us-east-1b", Lost: true}, Total: Resources{Memory: 8 * gb}},
+		{Host: Host{ID: "i-5", Zone: "us-east-1c", Draining: true}, Total: Resources{Memory: 8 * gb}},
+		{Host: Host{ID: "i-6"}, Total: Resources{Memory: 8 * gb}},
+	}
+
+	assert.Equal(t, map[string]float64{
+		"us-east-1a": 0.75,
+		"us-east-1b": 0.25,
+	}, zoneWeights(machines))
+}
+
+func TestZoneSkews(t *testing.T) {
+	weights := map[string]float64{"us-east-1a": 0.5, "us-east-1b": 0.5}
+
+	task := func(name, process, zone string) *Task {
+		return &Task{Name: name, ID: name, Type: process, State: "RUNNING", Host: Host{Zone: zone}}
+	}
+
+	tests := []struct {
+		tasks   []*Task
+		maxSkew int
+		skews   []*zoneSkew
+	}{
+		// Balanced.
+		{
+			[]*Task{
+				task("v1.web.1", "web", "us-east-1a"),
+				task("v1.web.2", "web", "us-east-1b"),
+			},
+			1,
+			nil,
+		},
+
+		// Skewed, but within the threshold.
+		{
+			[]*Task{
+				task("v1.web.1", "web", "us-east-1a"),
+				task("v1.web.2", "web", "us-east-1a"),
+				task("v1.web.3", "web", "us-east-1a"),
+				task("v1.web.4", "web", "us-east-1b"),
+			},
+			1,
+			nil,
+		},
+
+		// Skewed beyond the threshold.
+		{
+			[]*Task{
+				task("v1.web.1", "web", "us-east-1b"),
+				task("v1.web.2", "web", "us-east-1a"),
+				task("v1.web.3", "web", "us-east-1a"),
+				task("v1.web.4", "web", "us-east-1a"),
+				task("v1.web.5", "web", "us-east-1a"),
+				task("v1.worker.1", "worker", "us-east-1a"),
+				task("v1.worker.2", "worker", "us-east-1b"),
+			},
+			1,
+			[]*zoneSkew{
+				{Process: "web", Zone: "us-east-1a", Skew: 1.5, Task: task("v1.web.2", "web", "us-east-1a")},
+			},
+		},
+
+		// Instances in a zone that has no usable machines.
+		{
+			[]*Task{
+				task("v1.web.1", "web", "us-east-1c"),
+				task("v1.web.2", "web", "us-east-1c"),
+			},
+			1,
+			[]*zoneSkew{
+				{Process: "web", Zone: "us-east-1c", Skew: 2, Task: task("v1.web.1", "web", "us-east-1c")},
+			},
+		},
+
+		// Processes that are still being placed aren't rebalanced.
+		{
+			[]*Task{
+				task("v1.web.1", "web", "us-east-1a"),
+				task("v1.web.2", "web", "us-east-1a"),
+				task("v1.web.3", "web", "us-east-1a"),
+				{Name: "v1.web.4", Type: "web", State: "PENDING"},
+			},
+			1,
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.skews, zoneSkews(tt.tasks, weights, tt.maxSkew))
+	}
+}